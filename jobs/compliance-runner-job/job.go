@@ -8,6 +8,7 @@ import (
 	authApi "github.com/opengovern/og-util/pkg/api"
 	"github.com/opengovern/og-util/pkg/httpclient"
 	"github.com/opengovern/opensecurity/services/compliance/api"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -103,8 +104,8 @@ func (w *Worker) RunJob(ctx context.Context, j Job) (int, error) {
 	var res *steampipe.Result
 	var err error
 	switch j.ExecutionPlan.Query.Language {
-	//case api.PolicyLanguageRego:
-	//	res, err = w.runRegoWorkerJob(ctx, j, queryParamMap)
+	case api.PolicyLanguageRego:
+		res, err = w.runRegoWorkerJob(ctx, j, queryParamMap)
 	case api.PolicyLanguageSQL:
 		res, err = w.runSqlWorkerJob(ctx, j, queryParamMap)
 	default:
@@ -137,6 +138,11 @@ func (w *Worker) RunJob(ctx context.Context, j Job) (int, error) {
 		zap.String("benchmarkID", j.ExecutionPlan.Callers[0].RootBenchmark))
 	w.logger.Sync()
 
+	complianceResults, err = w.applyComplianceExceptions(ctx, j, complianceResults)
+	if err != nil {
+		return 0, err
+	}
+
 	complianceResultsMap := make(map[string]types.ComplianceResult)
 	for i, f := range complianceResults {
 		f := f
@@ -147,6 +153,17 @@ func (w *Worker) RunJob(ctx context.Context, j Job) (int, error) {
 		complianceResultsMap[f.EsID] = f
 	}
 
+	trackDrifts := j.ExecutionPlan.Callers[0].TracksDriftEvents
+	var complianceResultDriftEvents []types.ComplianceResultDriftEvent
+	previousComplianceResults := make(map[string]types.ComplianceResult)
+	if trackDrifts {
+		previousComplianceResults, err = w.fetchComplianceResultsByIDs(ctx, complianceResultsMap)
+		if err != nil {
+			w.logger.Error("failed to fetch previous compliance results", zap.Error(err))
+			return 0, err
+		}
+	}
+
 	filters := make([]opengovernance.BoolFilter, 0)
 	filters = append(filters, opengovernance.NewTermFilter("benchmarkID", j.ExecutionPlan.Callers[0].RootBenchmark))
 	filters = append(filters, opengovernance.NewTermFilter("controlID", j.ExecutionPlan.Callers[0].ControlID))
@@ -194,96 +211,14 @@ func (w *Worker) RunJob(ctx context.Context, j Job) (int, error) {
 				closePaginator()
 				return 0, err
 			}
-			// Just Delete old Results for now
-			//newComplianceResult, ok := complianceResultsMap[f.EsID]
-			//if !ok {
-			//	if f.StateActive {
-			//		f := f
-			//		f.StateActive = false
-			//		f.LastUpdatedAt = j.CreatedAt.UnixMilli()
-			//		f.RunnerID = j.ID
-			//		f.ComplianceJobID = j.ParentJobID
-			//		f.EvaluatedAt = j.CreatedAt.UnixMilli()
-			//		reason := fmt.Sprintf("Engine didn't found resource %s in the query result", f.PlatformResourceID)
-			//		f.Reason = reason
-			//		fs := types.ComplianceResultDriftEvent{
-			//			ComplianceResultEsID:     f.EsID,
-			//			ParentComplianceJobID:    j.ParentJobID,
-			//			ComplianceJobID:          j.ID,
-			//			PreviousComplianceStatus: f.ComplianceStatus,
-			//			ComplianceStatus:         f.ComplianceStatus,
-			//			PreviousStateActive:      true,
-			//			StateActive:              f.StateActive,
-			//			EvaluatedAt:              j.CreatedAt.UnixMilli(),
-			//			Reason:                   reason,
-			//
-			//			FrameworkIds:        f.FrameworkIds,
-			//			ControlID:          f.ControlID,
-			//			IntegrationID:      f.IntegrationID,
-			//			IntegrationType:    f.IntegrationType,
-			//			Severity:           f.Severity,
-			//			PlatformResourceID: f.PlatformResourceID,
-			//			ResourceID:         f.ResourceID,
-			//			ResourceType:       f.ResourceType,
-			//		}
-			//		keys, idx := fs.KeysAndIndex()
-			//		fs.EsID = es.HashOf(keys...)
-			//		fs.EsIndex = idx
-			//
-			//		w.logger.Info("ComplianceResult is not found in the query result setting it to inactive", zap.Any("complianceResult", f), zap.Any("event", fs))
-			//		if trackDrifts {
-			//			complianceResultDriftEvents = append(complianceResultDriftEvents, fs)
-			//		}
-			//		newComplianceResults = append(newComplianceResults, f)
-			//	} else {
-			//		w.logger.Info("Old complianceResult found, it's inactive. doing nothing", zap.Any("complianceResult", f))
-			//	}
-			//	continue
-			//}
-			//
-			//if (f.ComplianceStatus != newComplianceResult.ComplianceStatus) ||
-			//	(f.StateActive != newComplianceResult.StateActive) {
-			//	newComplianceResult.LastUpdatedAt = j.CreatedAt.UnixMilli()
-			//	newComplianceResult.RunnerID = j.ID
-			//	newComplianceResult.ComplianceJobID = j.ParentJobID
-			//	fs := types.ComplianceResultDriftEvent{
-			//		ComplianceResultEsID:     f.EsID,
-			//		ParentComplianceJobID:    j.ParentJobID,
-			//		ComplianceJobID:          j.ID,
-			//		PreviousComplianceStatus: f.ComplianceStatus,
-			//		ComplianceStatus:         newComplianceResult.ComplianceStatus,
-			//		PreviousStateActive:      f.StateActive,
-			//		StateActive:              newComplianceResult.StateActive,
-			//		EvaluatedAt:              j.CreatedAt.UnixMilli(),
-			//		Reason:                   newComplianceResult.Reason,
-			//
-			//		FrameworkIds:        newComplianceResult.FrameworkIds,
-			//		ControlID:          newComplianceResult.ControlID,
-			//		IntegrationID:      newComplianceResult.IntegrationID,
-			//		IntegrationType:    newComplianceResult.IntegrationType,
-			//		Severity:           newComplianceResult.Severity,
-			//		PlatformResourceID: newComplianceResult.PlatformResourceID,
-			//		ResourceID:         newComplianceResult.ResourceID,
-			//		ResourceType:       newComplianceResult.ResourceType,
-			//	}
-			//	keys, idx := fs.KeysAndIndex()
-			//	fs.EsID = es.HashOf(keys...)
-			//	fs.EsIndex = idx
-			//
-			//	w.logger.Info("ComplianceResult status changed", zap.Any("old", f), zap.Any("new", newComplianceResult), zap.Any("event", fs))
-			//	if trackDrifts {
-			//		complianceResultDriftEvents = append(complianceResultDriftEvents, fs)
-			//	}
-			//} else {
-			//	w.logger.Info("ComplianceResult status didn't change. doing nothing", zap.Any("complianceResult", newComplianceResult))
-			//	newComplianceResult.LastUpdatedAt = f.LastUpdatedAt
-			//	newComplianceResult.RunnerID = j.ID
-			//	newComplianceResult.ComplianceJobID = j.ParentJobID
-			//}
-			//
-			//newComplianceResults = append(newComplianceResults, newComplianceResult)
-			//delete(complianceResultsMap, f.EsID)
-			//delete(complianceResultsMap, newComplianceResult.EsID)
+			if !trackDrifts {
+				continue
+			}
+			if _, ok := complianceResultsMap[f.EsID]; !ok && f.StateActive {
+				reason := fmt.Sprintf("Engine didn't found resource %s in the query result", f.PlatformResourceID)
+				complianceResultDriftEvents = append(complianceResultDriftEvents,
+					newComplianceResultDriftEvent(j, f, f, false, reason))
+			}
 		}
 	}
 	closePaginator()
@@ -291,45 +226,29 @@ func (w *Worker) RunJob(ctx context.Context, j Job) (int, error) {
 		newComplianceResult.LastUpdatedAt = j.CreatedAt.UnixMilli()
 		newComplianceResult.RunnerID = j.ID
 		newComplianceResult.ComplianceJobID = j.ParentJobID
-		//fs := types.ComplianceResultDriftEvent{
-		//	ComplianceResultEsID:  newComplianceResult.EsID,
-		//	ParentComplianceJobID: j.ParentJobID,
-		//	ComplianceJobID:       j.ID,
-		//	ComplianceStatus:      newComplianceResult.ComplianceStatus,
-		//	StateActive:           newComplianceResult.StateActive,
-		//	EvaluatedAt:           j.CreatedAt.UnixMilli(),
-		//	Reason:                newComplianceResult.Reason,
-		//
-		//	FrameworkIds:        newComplianceResult.FrameworkIds,
-		//	ControlID:          newComplianceResult.ControlID,
-		//	IntegrationID:      newComplianceResult.IntegrationID,
-		//	IntegrationType:    newComplianceResult.IntegrationType,
-		//	Severity:           newComplianceResult.Severity,
-		//	PlatformResourceID: newComplianceResult.PlatformResourceID,
-		//	ResourceID:         newComplianceResult.ResourceID,
-		//	ResourceType:       newComplianceResult.ResourceType,
-		//}
-		//keys, idx := fs.KeysAndIndex()
-		//fs.EsID = es.HashOf(keys...)
-		//fs.EsIndex = idx
-
-		//w.logger.Info("New complianceResult", zap.Any("complianceResult", newComplianceResult), zap.Any("event", fs))
-		//if trackDrifts {
-		//	complianceResultDriftEvents = append(complianceResultDriftEvents, fs)
-		//}
+		if trackDrifts {
+			previous, ok := previousComplianceResults[newComplianceResult.EsID]
+			if !ok {
+				// the first evaluation has no previous state, it is recorded but flagged so it is not reported as a drift
+				event := newComplianceResultDriftEvent(j, types.ComplianceResult{}, newComplianceResult, newComplianceResult.StateActive, newComplianceResult.Reason)
+				event.InitialState = true
+				complianceResultDriftEvents = append(complianceResultDriftEvents, event)
+			} else if previous.ComplianceStatus != newComplianceResult.ComplianceStatus || previous.StateActive != newComplianceResult.StateActive {
+				complianceResultDriftEvents = append(complianceResultDriftEvents,
+					newComplianceResultDriftEvent(j, previous, newComplianceResult, newComplianceResult.StateActive, newComplianceResult.Reason))
+			}
+		}
 		newComplianceResults = append(newComplianceResults, newComplianceResult)
 	}
 
 	var docs []es.Doc
-	//if trackDrifts {
-	//	for _, fs := range complianceResultDriftEvents {
-	//		keys, idx := fs.KeysAndIndex()
-	//		fs.EsID = es.HashOf(keys...)
-	//		fs.EsIndex = idx
-	//
-	//		docs = append(docs, fs)
-	//	}
-	//}
+	for _, fs := range complianceResultDriftEvents {
+		keys, idx := fs.KeysAndIndex()
+		fs.EsID = es.HashOf(keys...)
+		fs.EsIndex = idx
+
+		docs = append(docs, fs)
+	}
 	for _, f := range newComplianceResults {
 		keys, idx := f.KeysAndIndex()
 		f.EsID = es.HashOf(keys...)
@@ -399,59 +318,232 @@ func (w *Worker) runSqlWorkerJob(ctx context.Context, j Job, queryParamMap map[s
 	return res, nil
 }
 
-//
-//func (w *Worker) runRegoWorkerJob(ctx context.Context, j Job, queryParamMap map[string]string) (*steampipe.Result, error) {
-//	regoResults, err := w.regoEngine.Evaluate(ctx, j.ExecutionPlan.Query.RegoPolicies, j.ExecutionPlan.Query.Definition)
-//	if err != nil {
-//		w.logger.Error("failed to evaluate rego", zap.Error(err), zap.String("query_id", j.ExecutionPlan.Query.ID), zap.Stringp("integration_id", j.ExecutionPlan.IntegrationID))
-//		return nil, err
-//	}
-//
-//	regoResultMaps := make([]map[string]any, 0)
-//	for _, regoResult := range regoResults {
-//		for _, expression := range regoResult.Expressions {
-//			if messages, ok := expression.Value.([]any); ok {
-//				for _, msg := range messages {
-//					msgMap, ok := msg.(map[string]any)
-//					if !ok {
-//						w.logger.Error("failed to parse rego result, output is not an object", zap.Any("regoResult", expression.Value), zap.String("query_id", j.ExecutionPlan.Query.ID), zap.Stringp("integration_id", j.ExecutionPlan.IntegrationID), zap.Uint("job_id", j.ID), zap.String("type", fmt.Sprintf("%T", msg)))
-//						return nil, fmt.Errorf("failed to parse rego result output is not an object")
-//					}
-//					regoResultMaps = append(regoResultMaps, msgMap)
-//				}
-//			} else {
-//
-//			}
-//		}
-//	}
-//
-//	var results steampipe.Result
-//	for _, regoResultMap := range regoResultMaps {
-//		if len(results.Headers) == 0 {
-//			for k := range regoResultMap {
-//				results.Headers = append(results.Headers, k)
-//			}
-//		}
-//		var record []any
-//		for _, header := range results.Headers {
-//			record = append(record, regoResultMap[header])
-//		}
-//		results.Data = append(results.Data, record)
-//	}
-//
-//	w.logger.Info("runRegoWorkerJob QueryOutput",
-//		zap.Uint("job_id", j.ID),
-//		zap.Int("caller_count", len(j.ExecutionPlan.Callers)),
-//		zap.String("query", j.ExecutionPlan.Query.Definition),
-//		zap.String("query_id", j.ExecutionPlan.Query.ID),
-//		zap.Int("result_count", len(results.Data)),
-//	)
-//
-//	return &results, nil
-//}
+func (w *Worker) runRegoWorkerJob(ctx context.Context, j Job, queryParamMap map[string]string) (*steampipe.Result, error) {
+	input := map[string]any{
+		"parameters": queryParamMap,
+		"control_id": j.ExecutionPlan.ControlID,
+	}
+	if j.ExecutionPlan.IntegrationID != nil {
+		input["integration_id"] = *j.ExecutionPlan.IntegrationID
+	}
+
+	regoResults, err := w.regoEngine.EvaluateWithInput(ctx, j.ExecutionPlan.Query.RegoPolicies, j.ExecutionPlan.Query.Definition, input)
+	if err != nil {
+		w.logger.Error("failed to evaluate rego", zap.Error(err), zap.String("query_id", j.ExecutionPlan.Query.ID), zap.Stringp("integration_id", j.ExecutionPlan.IntegrationID))
+		return nil, err
+	}
+
+	regoResultMaps := make([]map[string]any, 0)
+	for _, regoResult := range regoResults {
+		for _, expression := range regoResult.Expressions {
+			var messages []any
+			switch v := expression.Value.(type) {
+			case []any:
+				messages = v
+			case map[string]any:
+				// partial set rules (deny contains msg if ...) are returned as a set and serialized as a list,
+				// while a single object result is treated as one row
+				messages = []any{v}
+			default:
+				w.logger.Error("failed to parse rego result, output is not a list of objects", zap.Any("regoResult", expression.Value), zap.String("query_id", j.ExecutionPlan.Query.ID), zap.Stringp("integration_id", j.ExecutionPlan.IntegrationID), zap.Uint("job_id", j.ID), zap.String("type", fmt.Sprintf("%T", expression.Value)))
+				return nil, fmt.Errorf("failed to parse rego result, output is not a list of objects: %s", expression.Text)
+			}
+			for _, msg := range messages {
+				msgMap, ok := msg.(map[string]any)
+				if !ok {
+					w.logger.Error("failed to parse rego result, output is not an object", zap.Any("regoResult", expression.Value), zap.String("query_id", j.ExecutionPlan.Query.ID), zap.Stringp("integration_id", j.ExecutionPlan.IntegrationID), zap.Uint("job_id", j.ID), zap.String("type", fmt.Sprintf("%T", msg)))
+					return nil, fmt.Errorf("failed to parse rego result output is not an object")
+				}
+				regoResultMaps = append(regoResultMaps, msgMap)
+			}
+		}
+	}
+
+	results := regoResultsToSteampipeResult(regoResultMaps)
+
+	w.logger.Info("runRegoWorkerJob QueryOutput",
+		zap.Uint("job_id", j.ID),
+		zap.Int("caller_count", len(j.ExecutionPlan.Callers)),
+		zap.String("query", j.ExecutionPlan.Query.Definition),
+		zap.String("query_id", j.ExecutionPlan.Query.ID),
+		zap.Int("result_count", len(results.Data)),
+	)
+
+	return results, nil
+}
+
+// regoResultsToSteampipeResult converts rego output objects into the tabular shape produced by cloudql queries
+// so both languages share the same compliance result extraction. Headers are the union of all object keys.
+func regoResultsToSteampipeResult(regoResultMaps []map[string]any) *steampipe.Result {
+	headerSet := make(map[string]bool)
+	var results steampipe.Result
+	for _, regoResultMap := range regoResultMaps {
+		for k := range regoResultMap {
+			if !headerSet[k] {
+				headerSet[k] = true
+				results.Headers = append(results.Headers, k)
+			}
+		}
+	}
+	sort.Strings(results.Headers)
+
+	for _, regoResultMap := range regoResultMaps {
+		record := make([]any, 0, len(results.Headers))
+		for _, header := range results.Headers {
+			value := regoResultMap[header]
+			if number, ok := value.(json.Number); ok {
+				if f, err := number.Float64(); err == nil {
+					value = f
+				}
+			}
+			record = append(record, value)
+		}
+		results.Data = append(results.Data, record)
+	}
+
+	return &results
+}
 
 type ComplianceResultsMultiGetResponse struct {
 	Docs []struct {
 		Source types.ComplianceResult `json:"_source"`
 	} `json:"docs"`
 }
+
+type ComplianceResultsByIDsResponse struct {
+	Hits struct {
+		Hits []struct {
+			ID     string                 `json:"_id"`
+			Source types.ComplianceResult `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// fetchComplianceResultsByIDs returns the currently stored state of the given compliance results keyed by their es id
+func (w *Worker) fetchComplianceResultsByIDs(ctx context.Context, complianceResults map[string]types.ComplianceResult) (map[string]types.ComplianceResult, error) {
+	result := make(map[string]types.ComplianceResult)
+	ids := make([]string, 0, len(complianceResults))
+	for id := range complianceResults {
+		ids = append(ids, id)
+	}
+
+	const batchSize = 1000
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+		query, err := json.Marshal(map[string]any{
+			"size": end - start,
+			"query": map[string]any{
+				"ids": map[string]any{
+					"values": ids[start:end],
+				},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var response ComplianceResultsByIDsResponse
+		if err := w.esClient.Search(ctx, types.ComplianceResultsIndex, string(query), &response); err != nil {
+			return nil, err
+		}
+		for _, hit := range response.Hits.Hits {
+			result[hit.ID] = hit.Source
+		}
+	}
+	return result, nil
+}
+
+func newComplianceResultDriftEvent(j Job, previous types.ComplianceResult, current types.ComplianceResult, stateActive bool, reason string) types.ComplianceResultDriftEvent {
+	return types.ComplianceResultDriftEvent{
+		ComplianceResultEsID:     current.EsID,
+		ParentComplianceJobID:    j.ParentJobID,
+		ComplianceJobID:          j.ID,
+		PreviousComplianceStatus: previous.ComplianceStatus,
+		ComplianceStatus:         current.ComplianceStatus,
+		PreviousStateActive:      previous.StateActive,
+		StateActive:              stateActive,
+		EvaluatedAt:              j.CreatedAt.UnixMilli(),
+		Reason:                   reason,
+
+		BenchmarkID:        current.BenchmarkID,
+		ControlID:          current.ControlID,
+		IntegrationID:      current.IntegrationID,
+		IntegrationType:    current.IntegrationType,
+		Severity:           current.Severity,
+		PlatformResourceID: current.PlatformResourceID,
+		ResourceID:         current.ResourceID,
+		ResourceType:       current.ResourceType,
+	}
+}
+
+// applyComplianceExceptions marks the alarms covered by an approved and unexpired exception as suppressed
+func (w *Worker) applyComplianceExceptions(ctx context.Context, j Job, complianceResults []types.ComplianceResult) ([]types.ComplianceResult, error) {
+	if len(complianceResults) == 0 {
+		return complianceResults, nil
+	}
+
+	exceptions, err := w.complianceClient.ListActiveComplianceExceptions(&httpclient.Context{Ctx: ctx, UserRole: authApi.AdminRole}, []string{j.ExecutionPlan.Callers[0].ControlID})
+	if err != nil {
+		w.logger.Error("failed to list compliance exceptions", zap.Uint("job_id", j.ID), zap.Error(err))
+		return nil, err
+	}
+	if len(exceptions) == 0 {
+		return complianceResults, nil
+	}
+
+	needsTags := false
+	for _, exception := range exceptions {
+		if exception.TagKey != "" {
+			needsTags = true
+			break
+		}
+	}
+
+	resourceTags := make(map[string]map[string][]string)
+	if needsTags {
+		var platformResourceIDs []string
+		for _, f := range complianceResults {
+			if f.ComplianceStatus == types.ComplianceStatusALARM && f.PlatformResourceID != "" {
+				platformResourceIDs = append(platformResourceIDs, f.PlatformResourceID)
+			}
+		}
+		for i := 0; i < len(platformResourceIDs); i += 1000 {
+			lookups, err := es2.FetchLookupByResourceIDBatch(ctx, w.esClient, platformResourceIDs[i:min(i+1000, len(platformResourceIDs))])
+			if err != nil {
+				w.logger.Error("failed to fetch resource tags", zap.Uint("job_id", j.ID), zap.Error(err))
+				return nil, err
+			}
+			for platformResourceID, resources := range lookups {
+				tags := make(map[string][]string)
+				for _, resource := range resources {
+					for _, tag := range resource.Tags {
+						tags[tag.Key] = append(tags[tag.Key], tag.Value)
+					}
+				}
+				resourceTags[platformResourceID] = tags
+			}
+		}
+	}
+
+	suppressed := 0
+	for i, f := range complianceResults {
+		if f.ComplianceStatus != types.ComplianceStatusALARM {
+			continue
+		}
+		for _, exception := range exceptions {
+			if !exception.Matches(f, resourceTags[f.PlatformResourceID]) {
+				continue
+			}
+			exceptionID := exception.ID
+			f.ComplianceStatus = types.ComplianceStatusSUPPRESSED
+			f.ExceptionID = &exceptionID
+			complianceResults[i] = f
+			suppressed++
+			break
+		}
+	}
+	w.logger.Info("Applied compliance exceptions", zap.Uint("job_id", j.ID),
+		zap.Int("exceptions", len(exceptions)), zap.Int("suppressed", suppressed))
+
+	return complianceResults, nil
+}
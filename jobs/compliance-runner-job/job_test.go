@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opengovern/opensecurity/services/compliance/api"
+	regoService "github.com/opengovern/opensecurity/services/rego/service"
+	"go.uber.org/zap"
+)
+
+func TestRegoResultsToSteampipeResult(t *testing.T) {
+	results := regoResultsToSteampipeResult([]map[string]any{
+		{"status": "alarm", "resource": "bucket-1", "count": json.Number("3")},
+		{"resource": "bucket-2", "reason": "public", "ratio": json.Number("0.5"), "size": json.Number("not a number")},
+	})
+
+	wantHeaders := []string{"count", "ratio", "reason", "resource", "size", "status"}
+	if !reflect.DeepEqual(results.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", results.Headers, wantHeaders)
+	}
+	wantData := [][]any{
+		{float64(3), nil, nil, "bucket-1", nil, "alarm"},
+		{nil, 0.5, "public", "bucket-2", json.Number("not a number"), nil},
+	}
+	if !reflect.DeepEqual(results.Data, wantData) {
+		t.Errorf("Data = %v, want %v", results.Data, wantData)
+	}
+
+	if empty := regoResultsToSteampipeResult(nil); len(empty.Headers) != 0 || len(empty.Data) != 0 {
+		t.Errorf("regoResultsToSteampipeResult(nil) = %+v, want an empty result", empty)
+	}
+}
+
+func TestRunRegoWorkerJobInput(t *testing.T) {
+	w := &Worker{logger: zap.NewNop(), regoEngine: &regoService.RegoEngine{}}
+	integrationID := "i-1"
+	j := Job{
+		ID: 1,
+		ExecutionPlan: ExecutionPlan{
+			ControlID:     "control-1",
+			IntegrationID: &integrationID,
+			Query: api.Policy{
+				ID:         "policy-1",
+				Definition: "data.compliance.results",
+				RegoPolicies: []string{`package compliance
+
+import rego.v1
+
+results contains {
+	"resource": input.control_id,
+	"integration_id": input.integration_id,
+	"region": input.parameters.region,
+	"count": 2,
+}
+`},
+			},
+		},
+	}
+
+	results, err := w.runRegoWorkerJob(context.Background(), j, map[string]string{"region": "us-east-1"})
+	if err != nil {
+		t.Fatalf("runRegoWorkerJob() error = %v", err)
+	}
+	wantHeaders := []string{"count", "integration_id", "region", "resource"}
+	if !reflect.DeepEqual(results.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", results.Headers, wantHeaders)
+	}
+	wantData := [][]any{{float64(2), "i-1", "us-east-1", "control-1"}}
+	if !reflect.DeepEqual(results.Data, wantData) {
+		t.Errorf("Data = %v, want %v", results.Data, wantData)
+	}
+
+	j.ExecutionPlan.IntegrationID = nil
+	j.ExecutionPlan.Query.RegoPolicies = []string{`package compliance
+
+import rego.v1
+
+results := {"resource": "all", "has_integration": has_integration}
+
+has_integration if input.integration_id
+
+default has_integration := false
+`}
+	results, err = w.runRegoWorkerJob(context.Background(), j, nil)
+	if err != nil {
+		t.Fatalf("runRegoWorkerJob() error = %v", err)
+	}
+	wantData = [][]any{{false, "all"}}
+	if !reflect.DeepEqual(results.Data, wantData) {
+		t.Errorf("Data without an integration = %v, want %v", results.Data, wantData)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	complianceApi "github.com/opengovern/opensecurity/services/compliance/api"
 	complianceClient "github.com/opengovern/opensecurity/services/compliance/client"
 	coreClient "github.com/opengovern/opensecurity/services/core/client"
+	regoService "github.com/opengovern/opensecurity/services/rego/service"
 	schedulerClient "github.com/opengovern/opensecurity/services/scheduler/client"
 	"go.uber.org/zap"
 )
@@ -45,12 +46,12 @@ type Config struct {
 }
 
 type Worker struct {
-	config        Config
-	logger        *zap.Logger
-	steampipeConn *steampipe.Database
-	esClient      opengovernance.Client
-	jq            *jq.JobQueue
-	//regoEngine        *regoService.RegoEngine
+	config            Config
+	logger            *zap.Logger
+	steampipeConn     *steampipe.Database
+	esClient          opengovernance.Client
+	jq                *jq.JobQueue
+	regoEngine        *regoService.RegoEngine
 	complianceClient  complianceClient.ComplianceServiceClient
 	integrationClient client.IntegrationServiceClient
 	schedulerClient   schedulerClient.SchedulerServiceClient
@@ -131,22 +132,22 @@ func NewWorker(
 	logger.Info("stream created", zap.String("stream", StreamName), zap.String("topic", queueTopic), zap.String("resultTopic", ResultQueueTopic))
 	logger.Sync()
 
-	//logger.Info("initializing rego engine")
-	//logger.Sync()
-	//regoEngine, err := regoService.NewRegoEngine(ctx, logger, steampipeConn)
-	//if err != nil {
-	//	logger.Error("failed to create rego engine", zap.Error(err))
-	//	logger.Sync()
-	//	return nil, err
-	//}
+	logger.Info("initializing rego engine")
+	logger.Sync()
+	regoEngine, err := regoService.NewRegoEngine(ctx, logger, steampipeConn)
+	if err != nil {
+		logger.Error("failed to create rego engine", zap.Error(err))
+		logger.Sync()
+		return nil, err
+	}
 
 	w := &Worker{
-		config:        config,
-		logger:        logger,
-		steampipeConn: steampipeConn,
-		esClient:      esClient,
-		jq:            jq,
-		//regoEngine:        regoEngine,
+		config:            config,
+		logger:            logger,
+		steampipeConn:     steampipeConn,
+		esClient:          esClient,
+		jq:                jq,
+		regoEngine:        regoEngine,
 		complianceClient:  complianceClient.NewComplianceClient(config.Compliance.BaseURL),
 		schedulerClient:   schedulerClient.NewSchedulerServiceClient(config.Scheduler.BaseURL),
 		integrationClient: integrationClient,
@@ -442,7 +442,8 @@ func (w *Worker) deleteComplianceResultsAndResourceFindingsOfRemovedIntegrations
 func addJobSummary(controlSummary *types.ComplianceJobReportControlSummary,
 	controlView *types.ComplianceJobReportControlView, resourceView *types.ComplianceJobReportResourceView,
 	cr types.ComplianceResult) {
-	if cr.ComplianceStatus != types.ComplianceStatusALARM && cr.ComplianceStatus != types.ComplianceStatusOK {
+	if cr.ComplianceStatus != types.ComplianceStatusALARM && cr.ComplianceStatus != types.ComplianceStatusOK &&
+		cr.ComplianceStatus != types.ComplianceStatusSUPPRESSED {
 		return
 	}
 
@@ -106,8 +106,7 @@ func (jd *JobDocs) AddComplianceResult(logger *zap.Logger, job Job,
 
 	if job.BenchmarkID == complianceResult.BenchmarkID {
 		jd.ComplianceResultSummary.Total += 1
-		if complianceResult.ComplianceStatus == types.ComplianceStatusOK || complianceResult.ComplianceStatus == types.ComplianceStatusINFO ||
-			complianceResult.ComplianceStatus == types.ComplianceStatusSKIP {
+		if complianceResult.ComplianceStatus.IsPassed() {
 			jd.ComplianceResultSummary.Passed += 1
 		} else {
 			jd.ComplianceResultSummary.Failed += 1
@@ -44,7 +44,8 @@ func (m Migration) Run(ctx context.Context, conf config.MigratorConfig, logger *
 	}
 
 	err = dbm.ORM.Transaction(func(tx *gorm.DB) error {
-		err := tx.Model(&integrationModels.IntegrationGroup{}).Where("1 = 1").Unscoped().Delete(&integrationModels.IntegrationGroup{}).Error
+		// groups defined by a selector are created by users and kept
+		err := tx.Model(&integrationModels.IntegrationGroup{}).Where("selector IS NULL").Unscoped().Delete(&integrationModels.IntegrationGroup{}).Error
 		if err != nil {
 			logger.Error("failed to delete integration groups", zap.Error(err))
 			return err
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// XPlatformAPIKeyIDHeader is set by the auth service when the request is authenticated with an API key
+	XPlatformAPIKeyIDHeader = "X-Platform-ApiKeyId"
+	// XPlatformSourceIPHeader is set by the auth service to the client ip as the gateway resolved it
+	XPlatformSourceIPHeader = "X-Platform-SourceIp"
+
+	// AuthBaseURLEnv is the address of the auth service that stores the audit events
+	AuthBaseURLEnv = "AUTH_BASE_URL"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+type Change struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// Event is a single mutating API call
+type Event struct {
+	ID         uint              `json:"id,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Service    string            `json:"service"`
+	UserID     string            `json:"user_id"`
+	APIKeyID   string            `json:"api_key_id,omitempty"`
+	Role       string            `json:"role"`
+	Method     string            `json:"method"`
+	Route      string            `json:"route"`
+	Path       string            `json:"path"`
+	TargetIDs  map[string]string `json:"target_ids,omitempty"`
+	Request    json.RawMessage   `json:"request,omitempty" swaggertype:"object"`
+	Changes    map[string]Change `json:"changes,omitempty"`
+	StatusCode int               `json:"status_code"`
+	Outcome    Outcome           `json:"outcome" enums:"success,failure,denied"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+	SourceIP   string            `json:"source_ip,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+}
+
+type IngestEventsRequest struct {
+	Events []Event `json:"events"`
+}
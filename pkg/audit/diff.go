@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Diff returns the changed fields between two values by their json representation, nested fields are joined with dots
+func Diff(before, after any) map[string]Change {
+	beforeFields := flatten(before)
+	afterFields := flatten(after)
+
+	changes := make(map[string]Change)
+	for k, b := range beforeFields {
+		a, ok := afterFields[k]
+		if !ok {
+			changes[k] = Change{Before: b}
+		} else if !reflect.DeepEqual(a, b) {
+			changes[k] = Change{Before: b, After: a}
+		}
+	}
+	for k, a := range afterFields {
+		if _, ok := beforeFields[k]; !ok {
+			changes[k] = Change{After: a}
+		}
+	}
+	return redactChanges(changes)
+}
+
+func flatten(v any) map[string]any {
+	fields := make(map[string]any)
+	if v == nil {
+		return fields
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return fields
+	}
+	flattenInto(fields, "", generic)
+	return fields
+}
+
+func flattenInto(fields map[string]any, prefix string, v any) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) == 0 {
+		if prefix == "" {
+			prefix = "value"
+		}
+		fields[prefix] = v
+		return
+	}
+	for k, child := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		flattenInto(fields, key, child)
+	}
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type nested struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels,omitempty"`
+	}
+
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		want   map[string]Change
+	}{
+		{
+			name:   "unchanged",
+			before: nested{Name: "a"},
+			after:  nested{Name: "a"},
+			want:   map[string]Change{},
+		},
+		{
+			name:   "changed field",
+			before: nested{Name: "a"},
+			after:  nested{Name: "b"},
+			want:   map[string]Change{"name": {Before: "a", After: "b"}},
+		},
+		{
+			name:   "nested fields are joined with dots",
+			before: nested{Name: "a", Labels: map[string]string{"env": "dev", "team": "x"}},
+			after:  nested{Name: "a", Labels: map[string]string{"env": "prod", "owner": "y"}},
+			want: map[string]Change{
+				"labels.env":   {Before: "dev", After: "prod"},
+				"labels.team":  {Before: "x"},
+				"labels.owner": {After: "y"},
+			},
+		},
+		{
+			name:   "created",
+			before: nil,
+			after:  map[string]any{"role": "admin"},
+			want:   map[string]Change{"role": {After: "admin"}},
+		},
+		{
+			name:   "deleted",
+			before: map[string]any{"role": "admin"},
+			after:  nil,
+			want:   map[string]Change{"role": {Before: "admin"}},
+		},
+		{
+			name:   "scalars",
+			before: 1,
+			after:  2,
+			want:   map[string]Change{"value": {Before: float64(1), After: float64(2)}},
+		},
+		{
+			name:   "sensitive fields are redacted",
+			before: map[string]any{"config": map[string]any{"client_secret": "old"}},
+			after:  map[string]any{"config": map[string]any{"client_secret": "new"}},
+			want:   map[string]Change{"config.client_secret": {Before: redacted, After: redacted}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Diff(tc.before, tc.after); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Diff() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
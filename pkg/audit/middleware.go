@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/httpserver"
+)
+
+const (
+	maxRequestBodySize = 64 * 1024
+
+	beforeContextKey  = "audit_before"
+	afterContextKey   = "audit_after"
+	targetsContextKey = "audit_targets"
+	skipContextKey    = "audit_skip"
+)
+
+// SetBefore records the state of the target before the change, it is diffed against SetAfter
+func SetBefore(c echo.Context, v any) {
+	c.Set(beforeContextKey, v)
+}
+
+// SetAfter records the state of the target after the change
+func SetAfter(c echo.Context, v any) {
+	c.Set(afterContextKey, v)
+}
+
+// SetTarget adds a target id that is not part of the route params, e.g. an id from the request body
+func SetTarget(c echo.Context, key, value string) {
+	targets, _ := c.Get(targetsContextKey).(map[string]string)
+	if targets == nil {
+		targets = make(map[string]string)
+	}
+	targets[key] = value
+	c.Set(targetsContextKey, targets)
+}
+
+// Skip excludes the request from the audit log, used for endpoints that are not changes, e.g. searches over POST
+func Skip(c echo.Context) {
+	c.Set(skipContextKey, true)
+}
+
+type bodyReader struct {
+	io.Reader
+	io.Closer
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Middleware records every mutating call with its caller, target ids, changes and outcome to the sink.
+// readOnlyRoutes are route paths (as registered) of POST endpoints that only read, e.g. filtered lists.
+func Middleware(service string, sink Sink, readOnlyRoutes ...string) echo.MiddlewareFunc {
+	readOnly := make(map[string]bool, len(readOnlyRoutes))
+	for _, route := range readOnlyRoutes {
+		readOnly[route] = true
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !isMutating(req.Method) {
+				return next(c)
+			}
+			if req.Method == http.MethodPost && readOnly[c.Path()] {
+				return next(c)
+			}
+
+			start := time.Now()
+			// only bodies up to maxRequestBodySize are recorded, larger ones are passed through untouched
+			var body []byte
+			if req.Body != nil {
+				buf, _ := io.ReadAll(io.LimitReader(req.Body, maxRequestBodySize+1))
+				req.Body = &bodyReader{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+				if len(buf) <= maxRequestBodySize {
+					body = buf
+				}
+			}
+
+			err := next(c)
+
+			if skip, _ := c.Get(skipContextKey).(bool); skip {
+				return err
+			}
+
+			event := Event{
+				Timestamp:  start.UTC(),
+				Service:    service,
+				UserID:     req.Header.Get(httpserver.XPlatformUserIDHeader),
+				APIKeyID:   req.Header.Get(XPlatformAPIKeyIDHeader),
+				Role:       req.Header.Get(httpserver.XPlatformUserRoleHeader),
+				Method:     req.Method,
+				Route:      c.Path(),
+				Path:       req.URL.Path,
+				DurationMs: time.Since(start).Milliseconds(),
+				SourceIP:   req.Header.Get(XPlatformSourceIPHeader),
+				RequestID:  req.Header.Get(echo.HeaderXRequestID),
+			}
+			if len(body) > 0 {
+				event.Request = redactJSON(body)
+			}
+
+			targets := make(map[string]string)
+			for i, name := range c.ParamNames() {
+				if i < len(c.ParamValues()) {
+					targets[name] = c.ParamValues()[i]
+				}
+			}
+			if extra, ok := c.Get(targetsContextKey).(map[string]string); ok {
+				for k, v := range extra {
+					targets[k] = v
+				}
+			}
+			if len(targets) > 0 {
+				event.TargetIDs = targets
+			}
+
+			before, after := c.Get(beforeContextKey), c.Get(afterContextKey)
+			if before != nil || after != nil {
+				event.Changes = Diff(before, after)
+			}
+
+			event.StatusCode = c.Response().Status
+			if err != nil {
+				event.StatusCode = http.StatusInternalServerError
+				event.Error = err.Error()
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					event.StatusCode = httpErr.Code
+					event.Error = fmt.Sprint(httpErr.Message)
+				}
+			}
+			switch {
+			case event.StatusCode == http.StatusUnauthorized || event.StatusCode == http.StatusForbidden ||
+				event.StatusCode == http.StatusNotAcceptable:
+				event.Outcome = OutcomeDenied
+			case event.StatusCode >= 400:
+				event.Outcome = OutcomeFailure
+			default:
+				event.Outcome = OutcomeSuccess
+			}
+
+			sink.Record(event)
+			return err
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type recordingSink struct {
+	events []Event
+}
+
+func (s *recordingSink) Record(event Event) {
+	s.events = append(s.events, event)
+}
+
+func TestMiddlewareSourceIP(t *testing.T) {
+	sink := &recordingSink{}
+	e := echo.New()
+	e.Use(Middleware("test", sink))
+	e.POST("/items", func(c echo.Context) error { return c.NoContent(http.StatusCreated) })
+
+	req := httptest.NewRequest(http.MethodPost, "/items", nil)
+	req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+	req.Header.Set(echo.HeaderXRealIP, "203.0.113.8")
+	req.Header.Set(XPlatformSourceIPHeader, "198.51.100.1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(sink.events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(sink.events))
+	}
+	if got := sink.events[0].SourceIP; got != "198.51.100.1" {
+		t.Errorf("SourceIP = %s, want the address set by the auth service", got)
+	}
+}
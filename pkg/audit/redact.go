@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var sensitiveKeyParts = []string{
+	"password", "secret", "token", "credential", "private", "apikey", "api_key", "authorization", "certificate",
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON replaces the values of sensitive keys, it returns nil if the body is not json
+func redactJSON(body []byte) json.RawMessage {
+	var generic any
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return nil
+	}
+	b, err := json.Marshal(redactValue(generic))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if isSensitiveKey(k) {
+				t[k] = redacted
+			} else {
+				t[k] = redactValue(child)
+			}
+		}
+		return t
+	case []any:
+		for i, child := range t {
+			t[i] = redactValue(child)
+		}
+		return t
+	}
+	return v
+}
+
+func redactChanges(changes map[string]Change) map[string]Change {
+	for k := range changes {
+		for _, part := range strings.Split(k, ".") {
+			if isSensitiveKey(part) {
+				changes[k] = Change{Before: redacted, After: redacted}
+				break
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,28 @@
+package audit
+
+import (
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "not json", body: "name=a", want: ""},
+		{name: "nothing sensitive", body: `{"name":"a","role":"admin"}`, want: `{"name":"a","role":"admin"}`},
+		{name: "password", body: `{"email":"a@b.c","password":"p"}`, want: `{"email":"a@b.c","password":"[REDACTED]"}`},
+		{name: "case insensitive", body: `{"ClientSecret":"s"}`, want: `{"ClientSecret":"[REDACTED]"}`},
+		{name: "nested object", body: `{"config":{"api_key":"k","region":"eu"}}`, want: `{"config":{"api_key":"[REDACTED]","region":"eu"}}`},
+		{name: "arrays", body: `[{"token":"t"},{"name":"n"}]`, want: `[{"token":"[REDACTED]"},{"name":"n"}]`},
+		{name: "whole sensitive object", body: `{"credentials":{"user":"u"}}`, want: `{"credentials":"[REDACTED]"}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(redactJSON([]byte(tc.body))); got != tc.want {
+				t.Errorf("redactJSON(%s) = %s, want %s", tc.body, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/opensecurity/pkg/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	sinkBufferSize    = 10000
+	sinkBatchSize     = 100
+	sinkFlushInterval = 2 * time.Second
+	sinkTimeout       = 10 * time.Second
+)
+
+type Sink interface {
+	Record(event Event)
+}
+
+// LogSink writes the events to the service logs, used when the auth service address is not configured
+type LogSink struct {
+	logger *zap.Logger
+}
+
+func NewLogSink(logger *zap.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Record(event Event) {
+	s.logger.Info("audit event", zap.Any("event", event))
+}
+
+// BatchSink hands the events in batches to its flush func without blocking the request, the events are buffered
+// up to sinkBufferSize and the ones that do not fit or fail to flush are written to the logs so they are not lost
+type BatchSink struct {
+	logger *zap.Logger
+	events chan Event
+	flush  func([]Event) error
+}
+
+func NewBatchSink(logger *zap.Logger, flush func([]Event) error) *BatchSink {
+	s := &BatchSink{
+		logger: logger,
+		events: make(chan Event, sinkBufferSize),
+		flush:  flush,
+	}
+	utils.EnsureRunGoroutine(s.run)
+	return s
+}
+
+func (s *BatchSink) Record(event Event) {
+	select {
+	case s.events <- event:
+	default:
+		s.logger.Error("audit event buffer is full, logging event", zap.Any("event", event))
+	}
+}
+
+func (s *BatchSink) run() {
+	t := time.NewTicker(sinkFlushInterval)
+	defer t.Stop()
+
+	var batch []Event
+	for {
+		select {
+		case event := <-s.events:
+			batch = append(batch, event)
+			if len(batch) < sinkBatchSize {
+				continue
+			}
+		case <-t.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if err := s.flush(batch); err != nil {
+			s.logger.Error("failed to store audit events, logging them", zap.Int("count", len(batch)), zap.Error(err))
+			for _, event := range batch {
+				s.logger.Info("audit event", zap.Any("event", event))
+			}
+		}
+		batch = nil
+	}
+}
+
+// HTTPSink sends the events in batches to the auth service
+type HTTPSink struct {
+	*BatchSink
+	url string
+}
+
+func NewHTTPSink(authBaseURL string, logger *zap.Logger) *HTTPSink {
+	s := &HTTPSink{url: fmt.Sprintf("%s/api/v1/audit/events", authBaseURL)}
+	s.BatchSink = NewBatchSink(logger, s.send)
+	return s
+}
+
+// NewSinkFromEnv returns an HTTPSink to the auth service when AUTH_BASE_URL is set and a LogSink otherwise
+func NewSinkFromEnv(logger *zap.Logger) Sink {
+	if authBaseURL := os.Getenv(AuthBaseURLEnv); authBaseURL != "" {
+		return NewHTTPSink(authBaseURL, logger)
+	}
+	logger.Warn("auth base url is not set, audit events are only logged")
+	return NewLogSink(logger)
+}
+
+func (s *HTTPSink) send(batch []Event) error {
+	payload, err := json.Marshal(IngestEventsRequest{Events: batch})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	httpCtx := httpclient.Context{Ctx: ctx, UserRole: api.AdminRole}
+	_, err = httpclient.DoRequest(ctx, http.MethodPost, s.url, httpCtx.ToHeaders(), payload, nil)
+	return err
+}
@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestBatchSinkFlushesFullBatches(t *testing.T) {
+	batches := make(chan []Event, 1)
+	sink := NewBatchSink(zap.NewNop(), func(events []Event) error {
+		batches <- events
+		return errors.New("flush errors are logged")
+	})
+	for i := 0; i < sinkBatchSize; i++ {
+		sink.Record(Event{Path: "/api/v1/users"})
+	}
+
+	select {
+	case batch := <-batches:
+		if len(batch) != sinkBatchSize {
+			t.Errorf("flushed %d events, want %d", len(batch), sinkBatchSize)
+		}
+	case <-time.After(sinkFlushInterval / 2):
+		t.Fatal("a full batch was not flushed before the flush interval")
+	}
+}
@@ -0,0 +1,220 @@
+// Package pluginsign verifies plugin bundles against a signed checksum manifest.
+//
+// A signed bundle carries, next to its files:
+//   - checksums.txt: one "<sha256 hex>  <file name>" line per file, the sha256sum format
+//   - checksums.txt.sig: "<key id> <base64 ed25519 signature of checksums.txt>"
+//
+// The trust store lists the accepted publisher keys, one "<key id> <base64 ed25519 public key>" per line.
+package pluginsign
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ChecksumsFile = "checksums.txt"
+	SignatureFile = "checksums.txt.sig"
+)
+
+type Status string
+
+const (
+	StatusVerified Status = "verified"
+	StatusUnsigned Status = "unsigned"
+)
+
+var (
+	ErrUntrustedKey     = errors.New("plugin is signed by a key that is not in the trust store")
+	ErrInvalidSignature = errors.New("plugin signature is invalid")
+	ErrChecksumMismatch = errors.New("plugin checksum does not match")
+	ErrUnlistedFile     = errors.New("plugin bundle has a file that is not listed in the checksums")
+)
+
+// TrustStore maps the key ids of the trusted publishers to their public keys
+type TrustStore map[string]ed25519.PublicKey
+
+// ParseTrustStore parses the "<key id> <base64 public key>" lines, empty lines and lines starting with # are ignored
+func ParseTrustStore(data []byte) (TrustStore, error) {
+	store := make(TrustStore)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("trust store line %d: expected \"<key id> <public key>\"", line)
+		}
+		key, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("trust store line %d: decode public key: %w", line, err)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("trust store line %d: public key should be %d bytes", line, ed25519.PublicKeySize)
+		}
+		store[fields[0]] = key
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// LoadTrustStore reads the trust store file, an empty path returns an empty store
+func LoadTrustStore(path string) (TrustStore, error) {
+	if path == "" {
+		return TrustStore{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrustStore(data)
+}
+
+// Result is the outcome of a bundle verification
+type Result struct {
+	Status Status
+	// KeyID is the id of the key that signed the bundle, empty when unsigned
+	KeyID string
+	// Checksums are the sha256 of the verified files by file name
+	Checksums map[string]string
+}
+
+// Checksum returns the hex sha256 of data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyDir verifies files of the bundle in dir. A bundle without checksums.txt is unsigned.
+// A bundle with checksums.txt must list files and every other file of the bundle with a matching
+// checksum, and a signature, when present, must be made by a key of the store.
+func VerifyDir(dir string, store TrustStore, files ...string) (*Result, error) {
+	checksumsData, err := os.ReadFile(filepath.Join(dir, ChecksumsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Result{Status: StatusUnsigned}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Status: StatusUnsigned, Checksums: make(map[string]string)}
+
+	signatureData, err := os.ReadFile(filepath.Join(dir, SignatureFile))
+	if err == nil {
+		keyID, err := verifySignature(checksumsData, signatureData, store)
+		if err != nil {
+			return nil, err
+		}
+		result.Status = StatusVerified
+		result.KeyID = keyID
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	expected, err := parseChecksums(checksumsData)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		want, ok := expected[file]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not listed in %s", ErrChecksumMismatch, file, ChecksumsFile)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+		got := Checksum(data)
+		if got != want {
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, file)
+		}
+		result.Checksums[file] = got
+	}
+
+	// a file the checksums do not cover could be loaded by the plugin without being verified
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if name == ChecksumsFile || name == SignatureFile {
+			return nil
+		}
+		want, ok := expected[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnlistedFile, name)
+		}
+		if _, verified := result.Checksums[name]; verified {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if Checksum(data) != want {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func verifySignature(message, signatureData []byte, store TrustStore) (string, error) {
+	fields := strings.Fields(string(signatureData))
+	if len(fields) != 2 {
+		return "", fmt.Errorf("%w: expected \"<key id> <signature>\" in %s", ErrInvalidSignature, SignatureFile)
+	}
+	key, ok := store[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUntrustedKey, fields[0])
+	}
+	signature, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if !ed25519.Verify(key, message, signature) {
+		return "", ErrInvalidSignature
+	}
+	return fields[0], nil
+}
+
+func parseChecksums(data []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line in %s: %q", ChecksumsFile, text)
+		}
+		// sha256sum marks binary mode with a leading *
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
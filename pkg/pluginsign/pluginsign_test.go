@@ -0,0 +1,208 @@
+package pluginsign
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBundle(t *testing.T, files map[string]string, listed []string, key ed25519.PrivateKey, keyID string) string {
+	t.Helper()
+	dir := t.TempDir()
+	checksums := ""
+	for _, name := range listed {
+		checksums += fmt.Sprintf("%s  %s\n", Checksum([]byte(files[name])), name)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if listed == nil {
+		return dir
+	}
+	if err := os.WriteFile(filepath.Join(dir, ChecksumsFile), []byte(checksums), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if key != nil {
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(checksums)))
+		if err := os.WriteFile(filepath.Join(dir, SignatureFile), []byte(keyID+" "+signature+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestVerifyDir(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := TrustStore{"publisher": public}
+	files := map[string]string{"plugin": "binary", "manifest.yaml": "id: plugin"}
+	all := []string{"plugin", "manifest.yaml"}
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T) string
+		status  Status
+		keyID   string
+		wantErr error
+	}{
+		{
+			name:   "signed bundle",
+			setup:  func(t *testing.T) string { return writeBundle(t, files, all, private, "publisher") },
+			status: StatusVerified,
+			keyID:  "publisher",
+		},
+		{
+			name:   "bundle without checksums",
+			setup:  func(t *testing.T) string { return writeBundle(t, files, nil, nil, "") },
+			status: StatusUnsigned,
+		},
+		{
+			name:   "checksums without signature",
+			setup:  func(t *testing.T) string { return writeBundle(t, files, all, nil, "") },
+			status: StatusUnsigned,
+		},
+		{
+			name: "tampered file",
+			setup: func(t *testing.T) string {
+				dir := writeBundle(t, files, all, private, "publisher")
+				if err := os.WriteFile(filepath.Join(dir, "plugin"), []byte("tampered"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "tampered file that is not requested",
+			setup: func(t *testing.T) string {
+				dir := writeBundle(t, files, all, private, "publisher")
+				if err := os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte("id: other"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "extra file",
+			setup: func(t *testing.T) string {
+				dir := writeBundle(t, files, all, private, "publisher")
+				if err := os.MkdirAll(filepath.Join(dir, "lib"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "lib", "hook.so"), []byte("extra"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			wantErr: ErrUnlistedFile,
+		},
+		{
+			name: "requested file is not listed",
+			setup: func(t *testing.T) string {
+				return writeBundle(t, files, []string{"manifest.yaml"}, private, "publisher")
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name:    "unknown key",
+			setup:   func(t *testing.T) string { return writeBundle(t, files, all, otherPrivate, "other") },
+			wantErr: ErrUntrustedKey,
+		},
+		{
+			name:    "signature of another key",
+			setup:   func(t *testing.T) string { return writeBundle(t, files, all, otherPrivate, "publisher") },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "checksums changed after signing",
+			setup: func(t *testing.T) string {
+				dir := writeBundle(t, files, all, private, "publisher")
+				f, err := os.OpenFile(filepath.Join(dir, ChecksumsFile), os.O_APPEND|os.O_WRONLY, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				if _, err := fmt.Fprintf(f, "%s  extra\n", Checksum([]byte("extra"))); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			wantErr: ErrInvalidSignature,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := VerifyDir(tc.setup(t), store, "plugin")
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tc.status {
+				t.Errorf("expected status %s, got %s", tc.status, result.Status)
+			}
+			if result.KeyID != tc.keyID {
+				t.Errorf("expected key id %q, got %q", tc.keyID, result.KeyID)
+			}
+		})
+	}
+}
+
+func TestParseTrustStore(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(public)
+
+	tests := []struct {
+		name    string
+		data    string
+		keys    int
+		wantErr bool
+	}{
+		{name: "keys with comments", data: "# publishers\n\npublisher " + encoded + "\n", keys: 1},
+		{name: "missing key", data: "publisher\n", wantErr: true},
+		{name: "invalid base64", data: "publisher not-base64!\n", wantErr: true},
+		{name: "short key", data: "publisher " + base64.StdEncoding.EncodeToString([]byte("short")) + "\n", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store, err := ParseTrustStore([]byte(tc.data))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(store) != tc.keys {
+				t.Errorf("expected %d keys, got %d", tc.keys, len(store))
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package rbac
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpserver"
+)
+
+const (
+	// XPlatformUserFrameworksScope is set by the auth service to the frameworks a scoped user can access
+	XPlatformUserFrameworksScope = "X-Platform-UserFrameworksScope"
+
+	// NoAccessScope is the scope value of a scoped user that is not bound to anything of the kind,
+	// an empty scope header means unrestricted access
+	NoAccessScope = "-"
+)
+
+func scopeFromHeader(c echo.Context, header string) ([]string, bool) {
+	value := strings.TrimSpace(c.Request().Header.Get(header))
+	if value == "" {
+		return nil, false
+	}
+	if value == NoAccessScope {
+		return []string{}, true
+	}
+	return strings.Split(value, ","), true
+}
+
+// ScopeHeaderValue returns the header value for a scope of the given ids
+func ScopeHeaderValue(ids []string) string {
+	if len(ids) == 0 {
+		return NoAccessScope
+	}
+	return strings.Join(ids, ",")
+}
+
+// IntegrationScope returns the integrations the caller can access, scoped is false when access is unrestricted
+func IntegrationScope(c echo.Context) (ids []string, scoped bool) {
+	return scopeFromHeader(c, httpserver.XPlatformUserConnectionsScope)
+}
+
+// FrameworkScope returns the frameworks the caller can access, scoped is false when access is unrestricted
+func FrameworkScope(c echo.Context) (ids []string, scoped bool) {
+	return scopeFromHeader(c, XPlatformUserFrameworksScope)
+}
+
+// IsScoped reports whether the caller is restricted by role bindings
+func IsScoped(c echo.Context) bool {
+	_, integrationsScoped := IntegrationScope(c)
+	_, frameworksScoped := FrameworkScope(c)
+	return integrationsScoped || frameworksScoped
+}
+
+func HasIntegrationAccess(c echo.Context, integrationID string) bool {
+	ids, scoped := IntegrationScope(c)
+	return !scoped || slices.Contains(ids, integrationID)
+}
+
+func HasFrameworkAccess(c echo.Context, frameworkID string) bool {
+	ids, scoped := FrameworkScope(c)
+	return !scoped || slices.Contains(ids, frameworkID)
+}
+
+func CheckIntegrationAccess(c echo.Context, integrationID string) error {
+	if !HasIntegrationAccess(c, integrationID) {
+		return echo.NewHTTPError(http.StatusForbidden, "access to integration is not allowed")
+	}
+	return nil
+}
+
+func CheckFrameworkAccess(c echo.Context, frameworkID string) error {
+	if !HasFrameworkAccess(c, frameworkID) {
+		return echo.NewHTTPError(http.StatusForbidden, "access to framework is not allowed")
+	}
+	return nil
+}
+
+// ResolveIntegrationIDs narrows the requested integration ids to the caller scope.
+// An empty request resolves to the whole scope, nil means no restriction.
+func ResolveIntegrationIDs(c echo.Context, integrationIDs []string) ([]string, error) {
+	return resolve(c, integrationIDs, IntegrationScope, "integration")
+}
+
+// ResolveFrameworkIDs narrows the requested framework ids to the caller scope.
+// An empty request resolves to the whole scope, nil means no restriction.
+func ResolveFrameworkIDs(c echo.Context, frameworkIDs []string) ([]string, error) {
+	return resolve(c, frameworkIDs, FrameworkScope, "framework")
+}
+
+func resolve(c echo.Context, requested []string, scopeFn func(echo.Context) ([]string, bool), kind string) ([]string, error) {
+	scope, scoped := scopeFn(c)
+	if !scoped {
+		return requested, nil
+	}
+	if len(requested) == 0 {
+		if len(scope) == 0 {
+			// keeps the filter non-empty so it matches nothing instead of everything
+			return []string{NoAccessScope}, nil
+		}
+		return scope, nil
+	}
+	var res []string
+	for _, id := range requested {
+		if slices.Contains(scope, id) {
+			res = append(res, id)
+		}
+	}
+	if len(res) == 0 {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "access to the requested "+kind+"s is not allowed")
+	}
+	return res, nil
+}
+
+// AuthorizeUnscopedHandler is httpserver.AuthorizeHandler for endpoints that manage the whole platform,
+// e.g. users and role bindings, which scoped users can not call regardless of their role
+func AuthorizeUnscopedHandler(h echo.HandlerFunc, minRole api.Role) echo.HandlerFunc {
+	return httpserver.AuthorizeHandler(func(c echo.Context) error {
+		if IsScoped(c) {
+			return echo.NewHTTPError(http.StatusForbidden, "scoped users can not access this endpoint")
+		}
+		return h(c)
+	}, minRole)
+}
@@ -0,0 +1,115 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpserver"
+)
+
+func newContext(role api.Role, integrationsScope, frameworksScope string) echo.Context {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(httpserver.XPlatformUserRoleHeader, string(role))
+	if integrationsScope != "" {
+		req.Header.Set(httpserver.XPlatformUserConnectionsScope, integrationsScope)
+	}
+	if frameworksScope != "" {
+		req.Header.Set(XPlatformUserFrameworksScope, frameworksScope)
+	}
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func httpStatus(err error) int {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return http.StatusOK
+}
+
+func TestAuthorizeUnscopedHandler(t *testing.T) {
+	ok := func(c echo.Context) error { return nil }
+
+	tests := []struct {
+		name              string
+		role              api.Role
+		integrationsScope string
+		frameworksScope   string
+		want              int
+	}{
+		{name: "unscoped admin", role: api.AdminRole, want: http.StatusOK},
+		{name: "admin scoped to integrations", role: api.AdminRole, integrationsScope: "i-1,i-2", want: http.StatusForbidden},
+		{name: "admin scoped to frameworks", role: api.AdminRole, frameworksScope: "fw-1", want: http.StatusForbidden},
+		{name: "admin scoped to nothing", role: api.AdminRole, integrationsScope: NoAccessScope, want: http.StatusForbidden},
+		{name: "unscoped viewer", role: api.ViewerRole, want: http.StatusNotAcceptable},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := AuthorizeUnscopedHandler(ok, api.AdminRole)(newContext(tc.role, tc.integrationsScope, tc.frameworksScope))
+			if got := httpStatus(err); got != tc.want {
+				t.Errorf("status = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIntegrationAccess(t *testing.T) {
+	tests := []struct {
+		name          string
+		scope         string
+		integrationID string
+		want          bool
+	}{
+		{name: "unscoped", scope: "", integrationID: "i-1", want: true},
+		{name: "in scope", scope: "i-1,i-2", integrationID: "i-2", want: true},
+		{name: "out of scope", scope: "i-1,i-2", integrationID: "i-3", want: false},
+		{name: "no access", scope: NoAccessScope, integrationID: "i-1", want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newContext(api.ViewerRole, tc.scope, "")
+			if got := HasIntegrationAccess(c, tc.integrationID); got != tc.want {
+				t.Errorf("HasIntegrationAccess() = %v, want %v", got, tc.want)
+			}
+			if got := CheckIntegrationAccess(c, tc.integrationID) == nil; got != tc.want {
+				t.Errorf("CheckIntegrationAccess() allowed = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveFrameworkIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     string
+		requested []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "unscoped keeps the request", scope: "", requested: []string{"fw-1"}, want: []string{"fw-1"}},
+		{name: "unscoped empty request", scope: "", requested: nil, want: nil},
+		{name: "empty request resolves to the scope", scope: "fw-1,fw-2", requested: nil, want: []string{"fw-1", "fw-2"}},
+		{name: "request is narrowed", scope: "fw-1,fw-2", requested: []string{"fw-2", "fw-3"}, want: []string{"fw-2"}},
+		{name: "request out of scope", scope: "fw-1", requested: []string{"fw-3"}, wantErr: true},
+		{name: "no access matches nothing", scope: NoAccessScope, requested: nil, want: []string{NoAccessScope}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResolveFrameworkIDs(newContext(api.ViewerRole, "", tc.scope), tc.requested)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ResolveFrameworkIDs() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ const (
 	ComplianceStatusINFO  ComplianceStatus = "info"
 	ComplianceStatusSKIP  ComplianceStatus = "skip"
 	ComplianceStatusERROR ComplianceStatus = "error"
+	// ComplianceStatusSUPPRESSED is an alarm covered by an approved compliance exception (accepted risk or false positive)
+	ComplianceStatusSUPPRESSED ComplianceStatus = "suppressed"
 )
 
 func GetComplianceStatuses() []ComplianceStatus {
@@ -37,7 +39,7 @@ func GetFailedComplianceStatuses() []ComplianceStatus {
 }
 
 func (r ComplianceStatus) IsPassed() bool {
-	return r == ComplianceStatusOK || r == ComplianceStatusINFO || r == ComplianceStatusSKIP
+	return r == ComplianceStatusOK || r == ComplianceStatusINFO || r == ComplianceStatusSKIP || r == ComplianceStatusSUPPRESSED
 }
 
 type ComplianceStatusSummaryWithTotal struct {
@@ -51,6 +53,8 @@ type ComplianceStatusSummary struct {
 	InfoCount  int `json:"infoCount" example:"1"`
 	SkipCount  int `json:"skipCount" example:"1"`
 	ErrorCount int `json:"errorCount" example:"1"`
+
+	SuppressedCount int `json:"suppressedCount" example:"1"`
 }
 
 func (c *ComplianceStatusSummary) AddComplianceStatusSummary(summary ComplianceStatusSummary) {
@@ -59,6 +63,7 @@ func (c *ComplianceStatusSummary) AddComplianceStatusSummary(summary ComplianceS
 	c.InfoCount += summary.InfoCount
 	c.SkipCount += summary.SkipCount
 	c.ErrorCount += summary.ErrorCount
+	c.SuppressedCount += summary.SuppressedCount
 }
 
 func (c *ComplianceStatusSummary) AddComplianceStatusMap(summary map[ComplianceStatus]int) {
@@ -67,6 +72,7 @@ func (c *ComplianceStatusSummary) AddComplianceStatusMap(summary map[ComplianceS
 	c.InfoCount += summary[ComplianceStatusINFO]
 	c.SkipCount += summary[ComplianceStatusSKIP]
 	c.ErrorCount += summary[ComplianceStatusERROR]
+	c.SuppressedCount += summary[ComplianceStatusSUPPRESSED]
 }
 
 type ComplianceResultShortSummary struct {
@@ -80,6 +86,7 @@ var complianceStatuses = []ComplianceStatus{
 	ComplianceStatusINFO,
 	ComplianceStatusSKIP,
 	ComplianceStatusERROR,
+	ComplianceStatusSUPPRESSED,
 }
 
 func ParseComplianceStatus(s string) ComplianceStatus {
@@ -18,6 +18,7 @@ type ComplianceResultDriftEvent struct {
 	StateActive              bool             `json:"stateActive"`
 	EvaluatedAt              int64            `json:"evaluatedAt"`
 	Reason                   string           `json:"reason"`
+	InitialState             bool             `json:"initialState"`
 
 	BenchmarkID        string                   `json:"benchmarkID" example:"azure_cis_v140"`
 	ControlID          string                   `json:"controlID" example:"azure_cis_v140_7_5"`
@@ -59,6 +60,7 @@ type ComplianceResult struct {
 	RunnerID           uint                     `json:"runnerID" example:"1"`
 	ComplianceJobID    uint                     `json:"complianceJobID" example:"1"`
 	LastUpdatedAt      int64                    `json:"lastUpdatedAt" example:"1589395200"`
+	ExceptionID        *uint                    `json:"exceptionID,omitempty" example:"1"`
 
 	ParentBenchmarks []string `json:"-"`
 }
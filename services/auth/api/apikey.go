@@ -8,6 +8,10 @@ import (
 type CreateAPIKeyRequest struct {
 	Name     string   `json:"name"`                                                 // Name of the key
 	Role api.Role `json:"role" enums:"admin,editor,viewer" example:"admin"` // Name of the role
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-01-01T00:00:00Z"` // Optional expiry, the key is deactivated afterwards
+	// Optional routes the key is limited to, either a preset (see APIKeyScopePresets) or "<METHOD|*> <path>"
+	// where path is the public path and may end with * to match a prefix
+	Scopes []string `json:"scopes" example:"quick-scan:trigger,GET /compliance/api/v3/job-report/*"`
 }
 type EditAPIKeyRequest struct {                                     
 	Role api.Role `json:"role" enums:"admin,editor,viewer" example:"admin"` // Name of the role
@@ -22,6 +26,8 @@ type CreateAPIKeyResponse struct {
 	CreatedAt time.Time `json:"created_at" example:"2023-03-31T09:36:09.855Z"`         // Creation timestamp in UTC
 	RoleName  api.Role  `json:"roleName" enums:"admin,editor,viewer" example:"admin"` // Name of the role
 	Token     string    `json:"token"`                                                // Token of the key
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Scopes    []string   `json:"scopes,omitempty"`
 }
 
 type APIKeyResponse struct {
@@ -33,6 +39,32 @@ type APIKeyResponse struct {
 	CreatorUserID string    `json:"creator_user_id" example:"auth|123456789"`               // Unique identifier of the user who created the key
 	Active        bool      `json:"active" example:"true"`                                // Activity state of the key
 	MaskedKey     string    `json:"maskedKey" example:"abc...de"`                         // Masked key
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	Scopes        []string   `json:"scopes,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP    string     `json:"last_used_ip,omitempty"`
+}
+
+// APIKeyScopePresets are the named scopes an api key can be limited to
+var APIKeyScopePresets = map[string][]string{
+	"compliance-results:read": {
+		"POST /compliance/api/v1/compliance_result",
+		"POST /compliance/api/v1/compliance_result/resource",
+		"POST /compliance/api/v1/compliance_result/filters",
+		"GET /compliance/api/v1/compliance_result/*",
+		"POST /compliance/api/v1/resource_findings",
+		"GET /compliance/api/v3/job-report/*",
+	},
+	"discovery:trigger": {
+		"POST /schedule/api/v3/discovery/run",
+		"POST /schedule/api/v3/discovery/status",
+	},
+	"quick-scan:trigger": {
+		"POST /schedule/api/v3/compliance/quick/sequence",
+		"GET /schedule/api/v3/compliance/quick/sequence/*",
+		"GET /compliance/api/v3/quick/sequence/*",
+		"GET /compliance/api/v3/job-report/*",
+	},
 }
 
 type UpdateKeyRoleRequest struct {
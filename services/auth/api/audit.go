@@ -0,0 +1,8 @@
+package api
+
+import "github.com/opengovern/opensecurity/pkg/audit"
+
+type ListAuditEventsResponse struct {
+	Items      []audit.Event `json:"items"`
+	TotalCount int64         `json:"total_count"`
+}
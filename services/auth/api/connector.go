@@ -4,23 +4,29 @@ package api
 // CreateConnectorRequest represents the expected payload for creating or updating a connector.
 type CreateConnectorRequest struct {
 
-	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc"`                                  // 'oidc' is supported for now
-	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid"` // Optional sub-type
+	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc saml ldap github"`
+	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid active-directory enterprise"` // Optional sub-type
 	Issuer           string `json:"issuer,omitempty" validate:"omitempty,url"`
 	TenantID         string `json:"tenant_id,omitempty" validate:"omitempty,uuid"`
-	ClientID         string `json:"client_id" validate:"required"`
-	ClientSecret     string `json:"client_secret" validate:"required"`
+	ClientID         string `json:"client_id"`     // Required for oidc and github
+	ClientSecret     string `json:"client_secret"` // Required for oidc and github
+	SAML             *SAMLConnectorConfig   `json:"saml,omitempty"`   // Required for saml
+	LDAP             *LDAPConnectorConfig   `json:"ldap,omitempty"`   // Required for ldap
+	GitHub           *GitHubConnectorConfig `json:"github,omitempty"` // Required for github
 	ID               string `json:"id,omitempty"`   // Optional
 	Name             string `json:"name,omitempty"` // Optional
 }
 type UpdateConnectorRequest struct {
 	ConnectorID 	string `json:"connector_id" validate:"required"`
-	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc"`                                  // 'oidc' is supported for now
-	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid"` // Optional sub-type
+	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc saml ldap github"`
+	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid active-directory enterprise"` // Optional sub-type
 	Issuer           string `json:"issuer,omitempty" validate:"omitempty,url"`
 	TenantID         string `json:"tenant_id,omitempty" validate:"omitempty,uuid"`
-	ClientID         string `json:"client_id" validate:"required"`
-	ClientSecret     string `json:"client_secret" validate:"required"`
+	ClientID         string `json:"client_id"`     // Required for oidc and github
+	ClientSecret     string `json:"client_secret"` // Required for oidc and github
+	SAML             *SAMLConnectorConfig   `json:"saml,omitempty"`   // Required for saml
+	LDAP             *LDAPConnectorConfig   `json:"ldap,omitempty"`   // Required for ldap
+	GitHub           *GitHubConnectorConfig `json:"github,omitempty"` // Required for github
 	ID               uint `json:"id,omitempty"`   // Optional
 	Name             string `json:"name,omitempty"` // Optional
 
@@ -57,3 +63,73 @@ type ConnectorSubTypes struct {
 		SubTypes      []ConnectorSubTypes `json:"sub_types"`
 
 	}
+
+
+type SAMLConnectorConfig struct {
+	SSOURL             string `json:"sso_url" validate:"required,url"`
+	CAData             string `json:"ca_data"` // PEM encoded certificates the assertions are signed with
+	EntityIssuer       string `json:"entity_issuer,omitempty"`
+	SSOIssuer          string `json:"sso_issuer,omitempty"`
+	UsernameAttr       string `json:"username_attr,omitempty" example:"name"`
+	EmailAttr          string `json:"email_attr,omitempty" example:"email"`
+	GroupsAttr         string `json:"groups_attr,omitempty" example:"groups"`
+	GroupsDelim        string `json:"groups_delim,omitempty"`
+	NameIDPolicyFormat string `json:"name_id_policy_format,omitempty" example:"persistent"`
+	// InsecureSkipSignatureValidation allows a missing ca_data, for testing only
+	InsecureSkipSignatureValidation bool `json:"insecure_skip_signature_validation,omitempty"`
+}
+
+type LDAPUserSearch struct {
+	BaseDN                string `json:"base_dn" validate:"required"`
+	Filter                string `json:"filter,omitempty" example:"(objectClass=person)"`
+	Username              string `json:"username,omitempty" example:"uid"`
+	IDAttr                string `json:"id_attr,omitempty" example:"uid"`
+	EmailAttr             string `json:"email_attr,omitempty" example:"mail"`
+	NameAttr              string `json:"name_attr,omitempty" example:"cn"`
+	PreferredUsernameAttr string `json:"preferred_username_attr,omitempty"`
+}
+
+type LDAPGroupSearch struct {
+	BaseDN    string `json:"base_dn" validate:"required"`
+	Filter    string `json:"filter,omitempty" example:"(objectClass=groupOfNames)"`
+	UserAttr  string `json:"user_attr,omitempty" example:"DN"`
+	GroupAttr string `json:"group_attr,omitempty" example:"member"`
+	NameAttr  string `json:"name_attr,omitempty" example:"cn"`
+}
+
+type LDAPConnectorConfig struct {
+	Host               string           `json:"host" validate:"required" example:"ldap.example.com:636"`
+	InsecureNoSSL      bool             `json:"insecure_no_ssl,omitempty"`
+	InsecureSkipVerify bool             `json:"insecure_skip_verify,omitempty"`
+	StartTLS           bool             `json:"start_tls,omitempty"`
+	RootCAData         string           `json:"root_ca_data,omitempty"` // PEM encoded
+	BindDN             string           `json:"bind_dn,omitempty"`
+	BindPW             string           `json:"bind_pw,omitempty"`
+	UsernamePrompt     string           `json:"username_prompt,omitempty"`
+	UserSearch         LDAPUserSearch   `json:"user_search"`
+	GroupSearch        *LDAPGroupSearch `json:"group_search,omitempty"`
+}
+
+type GitHubOrg struct {
+	Name  string   `json:"name" validate:"required"`
+	Teams []string `json:"teams,omitempty"`
+}
+
+type GitHubConnectorConfig struct {
+	Orgs          []GitHubOrg `json:"orgs,omitempty" validate:"omitempty,dive"`
+	LoadAllGroups bool        `json:"load_all_groups,omitempty"`
+	TeamNameField string      `json:"team_name_field,omitempty" validate:"omitempty,oneof=name slug both"`
+	UseLoginAsID  bool        `json:"use_login_as_id,omitempty"`
+	HostName      string      `json:"host_name,omitempty" example:"github.example.com"` // Required for the enterprise sub-type
+}
+
+type ConnectorCheck struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+type TestConnectorResponse struct {
+	Success bool             `json:"success"`
+	Checks  []ConnectorCheck `json:"checks"`
+}
@@ -27,6 +27,7 @@ type GetUsersResponse struct {
 	IsActive      bool       `json:"is_active"`
 	FullName      string     `json:"full_name"`
 	ConnectorId   string     `json:"connector_id"`
+	GroupManaged  bool       `json:"group_managed"` // Role is set by the group role mappings
 }
 
 type GetUsersRequest struct {
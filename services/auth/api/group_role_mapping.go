@@ -0,0 +1,22 @@
+package api
+
+import (
+	"time"
+
+	"github.com/opengovern/og-util/pkg/api"
+)
+
+type GroupRoleMapping struct {
+	ID          uint      `json:"id" example:"1"`
+	ConnectorID string    `json:"connector_id,omitempty" example:"entra-id"` // Empty matches every connector
+	Group       string    `json:"group" example:"platform-admins"`           // Group claim value, the group object id for Entra ID
+	Role        api.Role  `json:"role" enums:"admin,editor,viewer" example:"admin"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateGroupRoleMappingRequest struct {
+	ConnectorID string   `json:"connector_id" example:"entra-id"`
+	Group       string   `json:"group" validate:"required" example:"platform-admins"`
+	Role        api.Role `json:"role" enums:"admin,editor,viewer" example:"admin"`
+}
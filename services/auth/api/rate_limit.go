@@ -0,0 +1,48 @@
+package api
+
+import "time"
+
+type RateLimitPolicy struct {
+	ID                uint      `json:"id" example:"1"`
+	SubjectType       string    `json:"subject_type" enums:"role,user,api_key" example:"role"`
+	Subject           string    `json:"subject" example:"viewer"`              // Role name, user id or api key id
+	RouteClass        string    `json:"route_class,omitempty" example:"query"` // Empty applies to every route class
+	RequestsPerMinute int       `json:"requests_per_minute" example:"60"`
+	Burst             int       `json:"burst" example:"20"` // Requests allowed at once, defaults to requests_per_minute
+	CreatedBy         string    `json:"created_by"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type CreateRateLimitPolicyRequest struct {
+	SubjectType       string `json:"subject_type" validate:"required,oneof=role user api_key" example:"role"`
+	Subject           string `json:"subject" validate:"required" example:"viewer"`
+	RouteClass        string `json:"route_class" example:"query"`
+	RequestsPerMinute int    `json:"requests_per_minute" validate:"required,min=1" example:"60"`
+	Burst             int    `json:"burst" validate:"min=0" example:"20"`
+}
+
+type UpdateRateLimitPolicyRequest struct {
+	RequestsPerMinute int `json:"requests_per_minute" validate:"required,min=1" example:"60"`
+	Burst             int `json:"burst" validate:"min=0" example:"20"`
+}
+
+type RateLimitUsage struct {
+	RouteClass        string    `json:"route_class" example:"query"`
+	PolicyID          uint      `json:"policy_id"`
+	SubjectType       string    `json:"subject_type" example:"role"`
+	Subject           string    `json:"subject" example:"viewer"`
+	RequestsPerMinute int       `json:"requests_per_minute"`
+	Burst             int       `json:"burst"`
+	Remaining         int       `json:"remaining"`
+	FullAt            time.Time `json:"full_at"` // When the bucket is refilled to the burst
+}
+
+type RateLimitUsageResponse struct {
+	Caller string           `json:"caller" example:"user:local|admin@example.com"`
+	Usage  []RateLimitUsage `json:"usage"` // Route classes without a policy are not limited
+}
+
+type RateLimitRouteClass struct {
+	Name   string   `json:"name" example:"query"`
+	Routes []string `json:"routes,omitempty"`
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"time"
+
+	"github.com/opengovern/og-util/pkg/api"
+)
+
+type RoleBindingScopeType string
+
+const (
+	RoleBindingScopeIntegrationGroup RoleBindingScopeType = "integration_group"
+	RoleBindingScopeIntegration      RoleBindingScopeType = "integration"
+	RoleBindingScopeFramework        RoleBindingScopeType = "framework"
+)
+
+func (t RoleBindingScopeType) IsValid() bool {
+	switch t {
+	case RoleBindingScopeIntegrationGroup, RoleBindingScopeIntegration, RoleBindingScopeFramework:
+		return true
+	}
+	return false
+}
+
+type RoleBinding struct {
+	ID        uint                 `json:"id" example:"1"`
+	UserID    string               `json:"user_id,omitempty" example:"local|johndoe@example.com"` // External id of the bound user
+	APIKeyID  *uint                `json:"api_key_id,omitempty" example:"2"`                      // Id of the bound api key
+	Role      api.Role             `json:"role" enums:"admin,editor,viewer" example:"viewer"`
+	ScopeType RoleBindingScopeType `json:"scope_type" enums:"integration_group,integration,framework" example:"integration_group"`
+	ScopeID   string               `json:"scope_id" example:"business-unit-a"` // Integration group name, integration id or framework id
+	CreatedBy string               `json:"created_by"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+type CreateRoleBindingRequest struct {
+	UserID    string               `json:"user_id"`    // Either user_id or api_key_id is required
+	APIKeyID  *uint                `json:"api_key_id"` // Either user_id or api_key_id is required
+	Role      api.Role             `json:"role" enums:"admin,editor,viewer" example:"viewer"`
+	ScopeType RoleBindingScopeType `json:"scope_type" enums:"integration_group,integration,framework" example:"integration_group"`
+	ScopeID   string               `json:"scope_id" example:"business-unit-a"`
+}
@@ -0,0 +1,30 @@
+package api
+
+import "time"
+
+type UserSession struct {
+	ID          string    `json:"id"` // Hash of the token, the token itself is never stored
+	ConnectorID string    `json:"connector_id,omitempty" example:"entra-id"`
+	SourceIP    string    `json:"source_ip,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	IssuedAt    time.Time `json:"issued_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+type RevokeSessionRequest struct {
+	Reason string `json:"reason" example:"lost laptop"`
+}
+
+type LogoutEverywhereRequest struct {
+	Reason        string `json:"reason" example:"compromised account"`
+	RevokeAPIKeys bool   `json:"revoke_api_keys"` // Also deactivates the api keys the user created
+	KeepActive    bool   `json:"keep_active"`     // Leaves the user active so it can log in again, the user is deactivated otherwise
+}
+
+type LogoutEverywhereResponse struct {
+	RevokedSessions      int64 `json:"revoked_sessions"`
+	RevokedRefreshTokens int   `json:"revoked_refresh_tokens"`
+	DeactivatedAPIKeys   int64 `json:"deactivated_api_keys"`
+	UserDeactivated      bool  `json:"user_deactivated"`
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opengovern/opensecurity/services/auth/api"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyExpiryInterval = 5 * time.Minute
+	// apiKeyUsageInterval throttles the last used updates of a key
+	apiKeyUsageInterval = time.Minute
+)
+
+// apiKeyRoute is a parsed api key scope, "<METHOD|*> <path>" where the path may end with * to match a prefix
+type apiKeyRoute struct {
+	method string
+	path   string
+	prefix bool
+}
+
+func parseAPIKeyRoute(scope string) (apiKeyRoute, error) {
+	parts := strings.Fields(scope)
+	if len(parts) != 2 {
+		return apiKeyRoute{}, fmt.Errorf("invalid scope %q, expected \"<METHOD|*> <path>\"", scope)
+	}
+	method := strings.ToUpper(parts[0])
+	switch method {
+	case "*", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return apiKeyRoute{}, fmt.Errorf("invalid method %q in scope %q", parts[0], scope)
+	}
+	path := parts[1]
+	if !strings.HasPrefix(path, "/") {
+		return apiKeyRoute{}, fmt.Errorf("invalid path %q in scope %q", path, scope)
+	}
+	route := apiKeyRoute{method: method, path: path}
+	if strings.HasSuffix(path, "*") {
+		route.prefix = true
+		route.path = strings.TrimSuffix(path, "*")
+	}
+	if strings.Contains(route.path, "*") {
+		return apiKeyRoute{}, fmt.Errorf("wildcard is only allowed at the end of the path in scope %q", scope)
+	}
+	return route, nil
+}
+
+func (r apiKeyRoute) matches(method, path string) bool {
+	if r.method != "*" && r.method != strings.ToUpper(method) {
+		return false
+	}
+	if r.prefix {
+		return strings.HasPrefix(path, r.path)
+	}
+	return path == r.path
+}
+
+// expandAPIKeyScopes replaces the presets with their routes and validates the rest
+func expandAPIKeyScopes(scopes []string) ([]string, error) {
+	var res []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if preset, ok := api.APIKeyScopePresets[scope]; ok {
+			res = append(res, preset...)
+			continue
+		}
+		if _, err := parseAPIKeyRoute(scope); err != nil {
+			return nil, err
+		}
+		res = append(res, scope)
+	}
+	return res, nil
+}
+
+// apiKeyAllows reports whether the key can call the route, keys without scopes can call every route
+func apiKeyAllows(key *db.ApiKey, method, path string) bool {
+	if len(key.Scopes) == 0 {
+		return true
+	}
+	if idx := strings.IndexByte(path, '?'); idx >= 0 {
+		path = path[:idx]
+	}
+	for _, scope := range key.Scopes {
+		route, err := parseAPIKeyRoute(scope)
+		if err != nil {
+			continue
+		}
+		if route.matches(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeyUsageTracker stores the last usage of the keys, at most once per apiKeyUsageInterval per key
+type apiKeyUsageTracker struct {
+	logger *zap.Logger
+	db     db.Database
+
+	mu       sync.Mutex
+	lastSeen map[uint]time.Time
+}
+
+func newAPIKeyUsageTracker(logger *zap.Logger, adb db.Database) *apiKeyUsageTracker {
+	return &apiKeyUsageTracker{
+		logger:   logger,
+		db:       adb,
+		lastSeen: make(map[uint]time.Time),
+	}
+}
+
+func (t *apiKeyUsageTracker) track(keyID uint, ip string) {
+	now := time.Now()
+	t.mu.Lock()
+	if last, ok := t.lastSeen[keyID]; ok && now.Sub(last) < apiKeyUsageInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastSeen[keyID] = now
+	t.mu.Unlock()
+
+	go func() {
+		if err := t.db.UpdateApiKeyLastUsage(keyID, now, ip); err != nil {
+			t.logger.Error("failed to update api key last usage", zap.Uint("apiKeyId", keyID), zap.Error(err))
+		}
+	}()
+}
+
+// runApiKeyExpiry deactivates the api keys that passed their expiry
+func runApiKeyExpiry(logger *zap.Logger, adb db.Database) {
+	t := time.NewTicker(apiKeyExpiryInterval)
+	defer t.Stop()
+
+	for ; ; <-t.C {
+		deactivated, err := adb.DeactivateExpiredApiKeys(time.Now())
+		if err != nil {
+			logger.Error("failed to deactivate expired api keys", zap.Error(err))
+			continue
+		}
+		if deactivated > 0 {
+			logger.Info("deactivated expired api keys", zap.Int64("count", deactivated))
+		}
+	}
+}
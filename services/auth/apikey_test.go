@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	envoycore "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoyauth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/opengovern/opensecurity/services/auth/db"
+)
+
+func TestExpandAPIKeyScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "no scopes", scopes: nil, wantLen: 0},
+		{name: "preset", scopes: []string{"discovery:trigger"}, wantLen: 2},
+		{name: "route", scopes: []string{" GET /core/api/v1/* "}, wantLen: 1},
+		{name: "any method", scopes: []string{"* /core/api/v1/query/run"}, wantLen: 1},
+		{name: "unknown preset", scopes: []string{"everything:write"}, wantErr: true},
+		{name: "invalid method", scopes: []string{"FETCH /core"}, wantErr: true},
+		{name: "relative path", scopes: []string{"GET core/api"}, wantErr: true},
+		{name: "wildcard in the middle", scopes: []string{"GET /core/*/query"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandAPIKeyScopes(tc.scopes)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(got) != tc.wantLen {
+				t.Errorf("expandAPIKeyScopes() = %v, want %d scopes", got, tc.wantLen)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAllows(t *testing.T) {
+	results, err := expandAPIKeyScopes([]string{"compliance-results:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{name: "unscoped key", scopes: nil, method: http.MethodDelete, path: "/auth/api/v1/user/1", want: true},
+		{name: "exact route", scopes: []string{"POST /core/api/v1/query/run"}, method: http.MethodPost, path: "/core/api/v1/query/run", want: true},
+		{name: "query string is ignored", scopes: []string{"POST /core/api/v1/query/run"}, method: http.MethodPost, path: "/core/api/v1/query/run?x=1", want: true},
+		{name: "other method", scopes: []string{"POST /core/api/v1/query/run"}, method: http.MethodGet, path: "/core/api/v1/query/run", want: false},
+		{name: "method is case insensitive", scopes: []string{"get /core/api/v1/*"}, method: http.MethodGet, path: "/core/api/v1/queries", want: true},
+		{name: "prefix", scopes: []string{"* /core/api/v1/*"}, method: http.MethodPut, path: "/core/api/v1/queries/1", want: true},
+		{name: "exact route is not a prefix", scopes: []string{"GET /core/api/v1/query"}, method: http.MethodGet, path: "/core/api/v1/query/1", want: false},
+		{name: "results preset reads results", scopes: results, method: http.MethodGet, path: "/compliance/api/v1/compliance_result/single/abc", want: true},
+		{name: "results preset lists results", scopes: results, method: http.MethodPost, path: "/compliance/api/v1/compliance_result", want: true},
+		{name: "results preset reads job reports", scopes: results, method: http.MethodGet, path: "/compliance/api/v3/job-report/1/summary", want: true},
+		{name: "results preset does not read exceptions", scopes: results, method: http.MethodGet, path: "/compliance/api/v3/exceptions", want: false},
+		{name: "results preset does not read frameworks", scopes: results, method: http.MethodGet, path: "/compliance/api/v3/benchmarks/filters", want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key := &db.ApiKey{Scopes: tc.scopes}
+			if got := apiKeyAllows(key, tc.method, tc.path); got != tc.want {
+				t.Errorf("apiKeyAllows(%s %s) = %v, want %v", tc.method, tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRequestSourceIPIgnoresForwardedHeaders(t *testing.T) {
+	req := &envoyauth.CheckRequest{
+		Attributes: &envoyauth.AttributeContext{
+			Source: &envoyauth.AttributeContext_Peer{
+				Address: &envoycore.Address{
+					Address: &envoycore.Address_SocketAddress{
+						SocketAddress: &envoycore.SocketAddress{Address: "10.0.0.7"},
+					},
+				},
+			},
+			Request: &envoyauth.AttributeContext_Request{
+				Http: &envoyauth.AttributeContext_HttpRequest{
+					Headers: map[string]string{"x-forwarded-for": "1.2.3.4", "x-real-ip": "5.6.7.8"},
+				},
+			},
+		},
+	}
+	if got := requestSourceIP(req); got != "10.0.0.7" {
+		t.Errorf("requestSourceIP() = %s, want 10.0.0.7", got)
+	}
+}
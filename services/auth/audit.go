@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	envoyauth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/gogo/googleapis/google/rpc"
+	"google.golang.org/genproto/googleapis/rpc/status"
+
+	"github.com/opengovern/opensecurity/pkg/audit"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAuditRetentionDays = 365
+	auditCleanupInterval      = 6 * time.Hour
+
+	// auditIngestPublicPath is where the services send their events to the auth service directly,
+	// it is never reachable through the gateway so events can not be forged
+	auditIngestPublicPath = "/auth/api/v1/audit/events"
+)
+
+// isAuditIngestRequest reports whether a gateway request targets the internal audit ingestion endpoint
+func isAuditIngestRequest(method, requestPath string) bool {
+	if idx := strings.IndexByte(requestPath, '?'); idx >= 0 {
+		requestPath = requestPath[:idx]
+	}
+	return method != http.MethodGet && path.Clean("/"+requestPath) == auditIngestPublicPath
+}
+
+func auditIngestDeniedResponse() *envoyauth.CheckResponse {
+	return &envoyauth.CheckResponse{
+		Status: &status.Status{
+			Code: int32(rpc.NOT_FOUND),
+		},
+		HttpResponse: &envoyauth.CheckResponse_DeniedResponse{
+			DeniedResponse: &envoyauth.DeniedHttpResponse{
+				Status: &envoytype.HttpStatus{Code: http.StatusNotFound},
+				Body:   http.StatusText(http.StatusNotFound),
+			},
+		},
+	}
+}
+
+// newDBAuditSink stores the audit events of the auth service itself in batches without going through the http api
+func newDBAuditSink(logger *zap.Logger, adb db.Database) *audit.BatchSink {
+	return audit.NewBatchSink(logger, func(events []audit.Event) error {
+		dbEvents := make([]db.AuditEvent, 0, len(events))
+		for _, event := range events {
+			dbEvent, err := db.NewAuditEvent(event)
+			if err != nil {
+				return err
+			}
+			dbEvents = append(dbEvents, dbEvent)
+		}
+		return adb.CreateAuditEvents(dbEvents)
+	})
+}
+
+// runAuditRetention deletes the audit events older than the retention period
+func runAuditRetention(logger *zap.Logger, adb db.Database, retentionDays int) {
+	t := time.NewTicker(auditCleanupInterval)
+	defer t.Stop()
+
+	for ; ; <-t.C {
+		deleted, err := adb.CleanupAuditEventsOlderThan(time.Now().AddDate(0, 0, -retentionDays))
+		if err != nil {
+			logger.Error("failed to cleanup audit events", zap.Error(err))
+			continue
+		}
+		if deleted > 0 {
+			logger.Info("cleaned up audit events", zap.Int64("deleted", deleted), zap.Int("retention_days", retentionDays))
+		}
+	}
+}
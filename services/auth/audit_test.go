@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsAuditIngestRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{method: http.MethodPost, path: "/auth/api/v1/audit/events", want: true},
+		{method: http.MethodPost, path: "/auth/api/v1/audit/events?x=1", want: true},
+		{method: http.MethodPost, path: "/auth/api/v1/audit/events/", want: true},
+		{method: http.MethodPost, path: "//auth/api/v1/./audit/events", want: true},
+		{method: http.MethodPut, path: "/auth/api/v1/audit/events", want: true},
+		{method: http.MethodGet, path: "/auth/api/v1/audit/events", want: false},
+		{method: http.MethodPost, path: "/auth/api/v1/audit/events/export", want: false},
+		{method: http.MethodPost, path: "/auth/api/v1/keys", want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			if got := isAuditIngestRequest(tc.method, tc.path); got != tc.want {
+				t.Errorf("isAuditIngestRequest(%s, %s) = %v, want %v", tc.method, tc.path, got, tc.want)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/opengovern/og-util/pkg/httpserver"
 	"github.com/opengovern/og-util/pkg/postgres"
 	"github.com/opengovern/opensecurity/services/auth/db"
+	"github.com/opengovern/opensecurity/services/auth/utils"
 
 	"crypto/rand"
 
@@ -32,6 +33,14 @@ var (
 	platformKeyEnabledStr = os.Getenv("PLATFORM_KEY_ENABLED")
 	platformPublicKeyStr  = os.Getenv("PLATFORM_PUBLIC_KEY")
 	platformPrivateKeyStr = os.Getenv("PLATFORM_PRIVATE_KEY")
+	auditRetentionDaysStr = os.Getenv("AUDIT_RETENTION_DAYS")
+	integrationBaseURL    = os.Getenv("INTEGRATION_BASE_URL")
+
+	connectorCheckSchemes   = os.Getenv("CONNECTOR_CHECK_SCHEMES")
+	connectorCheckHTTPPorts = os.Getenv("CONNECTOR_CHECK_HTTP_PORTS")
+	connectorCheckLDAPPorts = os.Getenv("CONNECTOR_CHECK_LDAP_PORTS")
+	// connectorCheckAllowPrivateNetworksStr lets the checks reach identity providers on internal addresses
+	connectorCheckAllowPrivateNetworksStr = os.Getenv("CONNECTOR_CHECK_ALLOW_PRIVATE_NETWORKS")
 )
 
 func Command() *cobra.Command {
@@ -92,6 +101,17 @@ func start(ctx context.Context) error {
 		return fmt.Errorf("new postgres client: %w", err)
 	}
 
+	auditRetentionDays := defaultAuditRetentionDays
+	if auditRetentionDaysStr != "" {
+		auditRetentionDays, err = strconv.Atoi(auditRetentionDaysStr)
+		if err != nil || auditRetentionDays <= 0 {
+			return fmt.Errorf("auditRetentionDays [%s]: invalid value", auditRetentionDaysStr)
+		}
+	}
+	go runAuditRetention(logger, adb, auditRetentionDays)
+	go runApiKeyExpiry(logger, adb)
+	go runSessionCleanup(logger, adb)
+
 	if platformKeyEnabledStr == "" {
 		platformKeyEnabledStr = "false"
 	}
@@ -100,6 +120,14 @@ func start(ctx context.Context) error {
 		return fmt.Errorf("platformKeyEnabled [%s]: %w", platformKeyEnabledStr, err)
 	}
 
+	connectorCheckPolicy := utils.NewConnectorCheckPolicy(connectorCheckSchemes, connectorCheckHTTPPorts, connectorCheckLDAPPorts)
+	if connectorCheckAllowPrivateNetworksStr != "" {
+		connectorCheckPolicy.AllowPrivateNetworks, err = strconv.ParseBool(connectorCheckAllowPrivateNetworksStr)
+		if err != nil {
+			return fmt.Errorf("connectorCheckAllowPrivateNetworks [%s]: %w", connectorCheckAllowPrivateNetworksStr, err)
+		}
+	}
+
 	var platformPublicKey *rsa.PublicKey
 	var platformPrivateKey *rsa.PrivateKey
 	if platformKeyEnabled {
@@ -222,6 +250,12 @@ func start(ctx context.Context) error {
 		db:                  adb,
 		updateLoginUserList: nil,
 		updateLogin:         make(chan User, 100000),
+		scopes:              newScopeResolver(adb, integrationBaseURL),
+		apiKeyUsage:         newAPIKeyUsageTracker(logger, adb),
+		groupRoles:          newGroupRoleResolver(adb),
+		sessions:            newSessionTracker(logger, adb),
+		revocations:         newRevocationList(adb),
+		rateLimits:          newRateLimiter(adb),
 	}
 
 	go authServer.UpdateLastLoginLoop()
@@ -233,6 +267,8 @@ func start(ctx context.Context) error {
 			platformPrivateKey: platformPrivateKey,
 			db:                 adb,
 			authServer:         authServer,
+
+			connectorCheckPolicy: connectorCheckPolicy,
 		}
 		errors <- fmt.Errorf("http server: %w", httpserver.RegisterAndStart(ctx, logger, httpServerAddress, &routes))
 	}()
@@ -21,6 +21,15 @@ func (db Database) Initialize() error {
 		&User{},
 		&Configuration{},
 		&Connector{},
+		&AuditEvent{},
+		&RoleBinding{},
+		&GroupRoleMapping{},
+		&ScimToken{},
+		&ScimGroup{},
+		&ScimGroupMember{},
+		&UserSession{},
+		&SessionRevocation{},
+		&RateLimitPolicy{},
 	)
 	if err != nil {
 		return err
@@ -426,3 +435,254 @@ func (db Database) GetConnectorByConnectorType(connectorType string) (*Connector
 
 
 
+
+func (db Database) CreateAuditEvents(events []AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx := db.Orm.CreateInBatches(events, 100)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+type AuditEventFilters struct {
+	UserID   string
+	APIKeyID string
+	Service  string
+	Method   string
+	Route    string
+	TargetID string
+	Outcome  string
+	From     *time.Time
+	To       *time.Time
+}
+
+func (db Database) ListAuditEvents(filters AuditEventFilters, limit, offset int) ([]AuditEvent, int64, error) {
+	tx := db.Orm.Model(&AuditEvent{})
+	if filters.UserID != "" {
+		tx = tx.Where("user_id = ?", filters.UserID)
+	}
+	if filters.APIKeyID != "" {
+		tx = tx.Where("api_key_id = ?", filters.APIKeyID)
+	}
+	if filters.Service != "" {
+		tx = tx.Where("service = ?", filters.Service)
+	}
+	if filters.Method != "" {
+		tx = tx.Where("method = ?", filters.Method)
+	}
+	if filters.Route != "" {
+		tx = tx.Where("route LIKE ?", "%"+filters.Route+"%")
+	}
+	if filters.TargetID != "" {
+		tx = tx.Where("EXISTS (SELECT 1 FROM jsonb_each_text(target_ids) WHERE value = ?)", filters.TargetID)
+	}
+	if filters.Outcome != "" {
+		tx = tx.Where("outcome = ?", filters.Outcome)
+	}
+	if filters.From != nil {
+		tx = tx.Where("timestamp >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		tx = tx.Where("timestamp <= ?", *filters.To)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []AuditEvent
+	tx = tx.Order("timestamp desc").Order("id desc").Limit(limit).Offset(offset).Find(&events)
+	if tx.Error != nil {
+		return nil, 0, tx.Error
+	}
+	return events, total, nil
+}
+
+func (db Database) CleanupAuditEventsOlderThan(t time.Time) (int64, error) {
+	tx := db.Orm.Where("timestamp < ?", t).Delete(&AuditEvent{})
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	return tx.RowsAffected, nil
+}
+
+func (db Database) GetApiKeyByHash(keyHash string) (*ApiKey, error) {
+	var s ApiKey
+	tx := db.Orm.Model(&ApiKey{}).
+		Where("key_hash = ?", keyHash).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+func (db Database) CreateRoleBinding(binding *RoleBinding) error {
+	tx := db.Orm.Create(binding)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) GetRoleBinding(id uint) (*RoleBinding, error) {
+	var s RoleBinding
+	tx := db.Orm.Model(&RoleBinding{}).
+		Where("id = ?", id).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+func (db Database) ListRoleBindings(userID *string, apiKeyID *uint) ([]RoleBinding, error) {
+	var s []RoleBinding
+	tx := db.Orm.Model(&RoleBinding{})
+	if userID != nil {
+		tx = tx.Where("user_id = ?", *userID)
+	}
+	if apiKeyID != nil {
+		tx = tx.Where("api_key_id = ?", *apiKeyID)
+	}
+	tx = tx.Order("id").Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+// ListRoleBindingsForSubject returns the bindings of the api key when one is given, otherwise of the user
+func (db Database) ListRoleBindingsForSubject(userID string, apiKeyID *uint) ([]RoleBinding, error) {
+	var s []RoleBinding
+	tx := db.Orm.Model(&RoleBinding{})
+	if apiKeyID != nil {
+		tx = tx.Where("api_key_id = ?", *apiKeyID)
+	} else {
+		tx = tx.Where("user_id = ? AND api_key_id IS NULL", userID)
+	}
+	tx = tx.Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) DeleteRoleBinding(id uint) error {
+	tx := db.Orm.Where("id = ?", id).Delete(&RoleBinding{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) DeleteRoleBindingsOfUser(userID string) error {
+	tx := db.Orm.Where("user_id = ?", userID).Delete(&RoleBinding{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) DeleteRoleBindingsOfAPIKey(apiKeyID uint) error {
+	tx := db.Orm.Where("api_key_id = ?", apiKeyID).Delete(&RoleBinding{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) UpdateApiKeyLastUsage(id uint, lastUsedAt time.Time, lastUsedIP string) error {
+	tx := db.Orm.Model(&ApiKey{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"last_used_at": lastUsedAt, "last_used_ip": lastUsedIP})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+// DeactivateExpiredApiKeys deactivates the active keys that expired before t and returns how many were changed
+func (db Database) DeactivateExpiredApiKeys(t time.Time) (int64, error) {
+	tx := db.Orm.Model(&ApiKey{}).
+		Where("is_active = ? AND expires_at IS NOT NULL AND expires_at <= ?", true, t).
+		Update("is_active", false)
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	return tx.RowsAffected, nil
+}
+
+func (db Database) GetApiKey(id uint) (*ApiKey, error) {
+	var s ApiKey
+	tx := db.Orm.Model(&ApiKey{}).
+		Where("id = ?", id).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+func (db Database) ListGroupRoleMappings() ([]GroupRoleMapping, error) {
+	var s []GroupRoleMapping
+	tx := db.Orm.Model(&GroupRoleMapping{}).Order("id").Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) GetGroupRoleMapping(id uint) (*GroupRoleMapping, error) {
+	var s GroupRoleMapping
+	tx := db.Orm.Model(&GroupRoleMapping{}).
+		Where("id = ?", id).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+func (db Database) CreateGroupRoleMapping(mapping *GroupRoleMapping) error {
+	tx := db.Orm.Create(mapping)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) DeleteGroupRoleMapping(id uint) error {
+	tx := db.Orm.Where("id = ?", id).Delete(&GroupRoleMapping{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+// SetUserGroupRole sets the role and the active state of a user managed by the group role mappings
+func (db Database) SetUserGroupRole(id uint, role api.Role, isActive bool) error {
+	tx := db.Orm.Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"role": role, "is_active": isActive, "group_managed": true})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
@@ -1,7 +1,12 @@
 package db
 
 import (
+	"encoding/json"
+
+	"github.com/jackc/pgtype"
+	"github.com/lib/pq"
 	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/opensecurity/pkg/audit"
 	"gorm.io/gorm"
 	"time"
 )
@@ -20,6 +25,14 @@ type ApiKey struct {
 	IsActive      bool
 	KeyHash       string
 	MaskedKey     string
+	ExpiresAt     *time.Time
+	Scopes        pq.StringArray `gorm:"type:text[]"` // empty means every route allowed by the role
+	LastUsedAt    *time.Time
+	LastUsedIP    string
+}
+
+func (k ApiKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
 }
 
 type Connector struct {
@@ -44,4 +57,190 @@ type User struct {
 	Username              string
 	RequirePasswordChange bool `gorm:"default:true"`
 	IsActive              bool `gorm:"default:true"`
+	// GroupManaged users get their role from the group role mappings on every login
+	GroupManaged bool
+	// ScimExternalID is the id of the user in the identity provider provisioning it through SCIM
+	ScimExternalID string
+}
+
+type AuditEvent struct {
+	ID         uint      `gorm:"primaryKey"`
+	Timestamp  time.Time `gorm:"index"`
+	Service    string    `gorm:"index"`
+	UserID     string    `gorm:"index"`
+	APIKeyID   string
+	Role       string
+	Method     string
+	Route      string
+	Path       string
+	TargetIDs  pgtype.JSONB // map[string]string
+	Request    pgtype.JSONB
+	Changes    pgtype.JSONB // map[string]audit.Change
+	StatusCode int
+	Outcome    audit.Outcome `gorm:"index"`
+	Error      string
+	DurationMs int64
+	SourceIP   string
+	RequestID  string
+}
+
+func NewAuditEvent(e audit.Event) (AuditEvent, error) {
+	event := AuditEvent{
+		Timestamp:  e.Timestamp,
+		Service:    e.Service,
+		UserID:     e.UserID,
+		APIKeyID:   e.APIKeyID,
+		Role:       e.Role,
+		Method:     e.Method,
+		Route:      e.Route,
+		Path:       e.Path,
+		StatusCode: e.StatusCode,
+		Outcome:    e.Outcome,
+		Error:      e.Error,
+		DurationMs: e.DurationMs,
+		SourceIP:   e.SourceIP,
+		RequestID:  e.RequestID,
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if err := setJSONB(&event.TargetIDs, e.TargetIDs); err != nil {
+		return event, err
+	}
+	if len(e.Request) > 0 {
+		if err := event.Request.Set([]byte(e.Request)); err != nil {
+			return event, err
+		}
+	} else {
+		event.Request = pgtype.JSONB{Status: pgtype.Null}
+	}
+	if err := setJSONB(&event.Changes, e.Changes); err != nil {
+		return event, err
+	}
+	return event, nil
+}
+
+func setJSONB[T any](jp *pgtype.JSONB, v map[string]T) error {
+	if len(v) == 0 {
+		*jp = pgtype.JSONB{Status: pgtype.Null}
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return jp.Set(b)
+}
+
+func (e AuditEvent) ToAPI() audit.Event {
+	event := audit.Event{
+		ID:         e.ID,
+		Timestamp:  e.Timestamp,
+		Service:    e.Service,
+		UserID:     e.UserID,
+		APIKeyID:   e.APIKeyID,
+		Role:       e.Role,
+		Method:     e.Method,
+		Route:      e.Route,
+		Path:       e.Path,
+		StatusCode: e.StatusCode,
+		Outcome:    e.Outcome,
+		Error:      e.Error,
+		DurationMs: e.DurationMs,
+		SourceIP:   e.SourceIP,
+		RequestID:  e.RequestID,
+	}
+	if e.TargetIDs.Status == pgtype.Present {
+		_ = json.Unmarshal(e.TargetIDs.Bytes, &event.TargetIDs)
+	}
+	if e.Request.Status == pgtype.Present {
+		event.Request = e.Request.Bytes
+	}
+	if e.Changes.Status == pgtype.Present {
+		_ = json.Unmarshal(e.Changes.Bytes, &event.Changes)
+	}
+	return event
+}
+
+// RoleBinding grants a user or an api key a role on a single integration group, integration or framework
+type RoleBinding struct {
+	gorm.Model
+	UserID    string `gorm:"index"` // external id of the user
+	APIKeyID  *uint  `gorm:"index"`
+	Role      api.Role
+	ScopeType string
+	ScopeID   string
+	CreatedBy string
+}
+
+// GroupRoleMapping grants the users carrying the group claim of the identity provider a role
+type GroupRoleMapping struct {
+	gorm.Model
+	ConnectorID string `gorm:"index"` // empty matches every connector
+	Group       string `gorm:"index"`
+	Role        api.Role
+	CreatedBy   string
+}
+
+// ScimToken authenticates the identity provider on the SCIM endpoints, only its hash is stored
+type ScimToken struct {
+	gorm.Model
+	Name          string
+	KeyHash       string `gorm:"index"`
+	MaskedKey     string
+	CreatorUserID string
+	LastUsedAt    *time.Time
+}
+
+// ScimGroup is a group provisioned through SCIM, the group role mappings of the scim connector apply to its members
+type ScimGroup struct {
+	gorm.Model
+	DisplayName string `gorm:"index"`
+	ExternalID  string
+}
+
+type ScimGroupMember struct {
+	GroupID   uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// UserSession is a dex token seen by the auth gate, the token itself is not stored
+type UserSession struct {
+	gorm.Model
+	TokenID        string `gorm:"uniqueIndex"`
+	UserExternalID string `gorm:"index"`
+	Email          string
+	ConnectorID    string
+	// DexSubject is the sub claim of the dex token, dex revokes the refresh tokens of a user by it
+	DexSubject     string
+	SourceIP       string
+	UserAgent      string
+	IssuedAt       time.Time
+	ExpiresAt      time.Time
+	LastSeenAt     time.Time
+	RevokedAt      *time.Time
+}
+
+// SessionRevocation revokes a single token, or every token of the user issued before RevokedBefore when TokenID is empty
+type SessionRevocation struct {
+	gorm.Model
+	TokenID        string `gorm:"index"`
+	UserExternalID string `gorm:"index"`
+	RevokedBefore  time.Time
+	ExpiresAt      time.Time `gorm:"index"`
+	Reason         string
+	RevokedBy      string
+}
+
+// RateLimitPolicy is a token bucket limit of the callers of a role, of a user or of an api key.
+// An empty RouteClass applies to every route class.
+type RateLimitPolicy struct {
+	gorm.Model
+	SubjectType       string `gorm:"index:idx_rate_limit_subject"`
+	Subject           string `gorm:"index:idx_rate_limit_subject"`
+	RouteClass        string
+	RequestsPerMinute int
+	Burst             int
+	CreatedBy         string
 }
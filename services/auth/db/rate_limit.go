@@ -0,0 +1,73 @@
+package db
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+func (db Database) ListRateLimitPolicies() ([]RateLimitPolicy, error) {
+	var s []RateLimitPolicy
+	tx := db.Orm.Model(&RateLimitPolicy{}).Order("id").Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) GetRateLimitPolicy(id uint) (*RateLimitPolicy, error) {
+	var s RateLimitPolicy
+	tx := db.Orm.Model(&RateLimitPolicy{}).
+		Where("id = ?", id).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+func (db Database) GetRateLimitPolicyBySubject(subjectType, subject, routeClass string) (*RateLimitPolicy, error) {
+	var s RateLimitPolicy
+	tx := db.Orm.Model(&RateLimitPolicy{}).
+		Where("subject_type = ? AND subject = ? AND route_class = ?", subjectType, subject, routeClass).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+func (db Database) CreateRateLimitPolicy(policy *RateLimitPolicy) error {
+	tx := db.Orm.Create(policy)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) UpdateRateLimitPolicy(policy *RateLimitPolicy) error {
+	tx := db.Orm.Model(&RateLimitPolicy{}).
+		Where("id = ?", policy.ID).
+		Updates(map[string]any{
+			"requests_per_minute": policy.RequestsPerMinute,
+			"burst":               policy.Burst,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) DeleteRateLimitPolicy(id uint) (bool, error) {
+	tx := db.Orm.Where("id = ?", id).Delete(&RateLimitPolicy{})
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (db Database) CreateScimToken(token *ScimToken) error {
+	tx := db.Orm.Create(token)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) ListScimTokens() ([]ScimToken, error) {
+	var s []ScimToken
+	tx := db.Orm.Model(&ScimToken{}).Order("id").Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) GetScimTokenByHash(keyHash string) (*ScimToken, error) {
+	var s ScimToken
+	tx := db.Orm.Model(&ScimToken{}).
+		Where("key_hash = ?", keyHash).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+func (db Database) DeleteScimToken(id uint) (bool, error) {
+	tx := db.Orm.Where("id = ?", id).Delete(&ScimToken{})
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+func (db Database) UpdateScimTokenLastUsed(id uint, lastUsedAt time.Time) error {
+	tx := db.Orm.Model(&ScimToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", lastUsedAt)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+// ListUsersByAttribute lists the users, filtered case-insensitively on the column when one is given
+func (db Database) ListUsersByAttribute(column, value string, limit, offset int) ([]User, int64, error) {
+	var s []User
+	var total int64
+	tx := db.Orm.Model(&User{})
+	if column != "" {
+		tx = tx.Where("lower("+column+") = lower(?)", value)
+	}
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Order("id").Limit(limit).Offset(offset).Find(&s).Error; err != nil {
+		return nil, 0, err
+	}
+	return s, total, nil
+}
+
+func (db Database) ListUsersByIDs(ids []uint) ([]User, error) {
+	var s []User
+	if len(ids) == 0 {
+		return s, nil
+	}
+	tx := db.Orm.Model(&User{}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+// UpdateScimUser writes every attribute SCIM manages, zero values included
+func (db Database) UpdateScimUser(user *User) error {
+	tx := db.Orm.Model(&User{}).
+		Where("id = ?", user.ID).
+		Updates(map[string]any{
+			"email":            user.Email,
+			"email_verified":   user.EmailVerified,
+			"username":         user.Username,
+			"full_name":        user.FullName,
+			"role":             user.Role,
+			"is_active":        user.IsActive,
+			"scim_external_id": user.ScimExternalID,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) CreateScimGroup(group *ScimGroup) error {
+	tx := db.Orm.Create(group)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) GetScimGroup(id uint) (*ScimGroup, error) {
+	var s ScimGroup
+	tx := db.Orm.Model(&ScimGroup{}).
+		Where("id = ?", id).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+// ListScimGroups lists the groups, filtered case-insensitively on the column when one is given
+func (db Database) ListScimGroups(column, value string, limit, offset int) ([]ScimGroup, int64, error) {
+	var s []ScimGroup
+	var total int64
+	tx := db.Orm.Model(&ScimGroup{})
+	if column != "" {
+		tx = tx.Where("lower("+column+") = lower(?)", value)
+	}
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Order("id").Limit(limit).Offset(offset).Find(&s).Error; err != nil {
+		return nil, 0, err
+	}
+	return s, total, nil
+}
+
+func (db Database) UpdateScimGroup(group *ScimGroup) error {
+	tx := db.Orm.Model(&ScimGroup{}).
+		Where("id = ?", group.ID).
+		Updates(map[string]any{
+			"display_name": group.DisplayName,
+			"external_id":  group.ExternalID,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) DeleteScimGroup(id uint) error {
+	return db.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&ScimGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&ScimGroup{}).Error
+	})
+}
+
+func (db Database) ListScimGroupMembers(groupID uint) ([]ScimGroupMember, error) {
+	var s []ScimGroupMember
+	tx := db.Orm.Model(&ScimGroupMember{}).
+		Where("group_id = ?", groupID).
+		Order("user_id").
+		Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) ListScimGroupsOfUser(userID uint) ([]ScimGroup, error) {
+	var s []ScimGroup
+	tx := db.Orm.Model(&ScimGroup{}).
+		Joins("JOIN scim_group_members ON scim_group_members.group_id = scim_groups.id").
+		Where("scim_group_members.user_id = ?", userID).
+		Order("scim_groups.id").
+		Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) AddScimGroupMembers(groupID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	members := make([]ScimGroupMember, 0, len(userIDs))
+	for _, userID := range userIDs {
+		members = append(members, ScimGroupMember{GroupID: groupID, UserID: userID})
+	}
+	tx := db.Orm.Clauses(clause.OnConflict{DoNothing: true}).Create(&members)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) RemoveScimGroupMembers(groupID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	tx := db.Orm.Where("group_id = ? AND user_id IN ?", groupID, userIDs).Delete(&ScimGroupMember{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) DeleteScimGroupMembershipsOfUser(userID uint) error {
+	tx := db.Orm.Where("user_id = ?", userID).Delete(&ScimGroupMember{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpsertUserSession creates the session on its first sight and updates its last usage afterwards
+func (db Database) UpsertUserSession(session *UserSession) error {
+	tx := db.Orm.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at", "source_ip", "user_agent", "updated_at"}),
+	}).Create(session)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) ListActiveUserSessions(userExternalID string, now time.Time) ([]UserSession, error) {
+	var s []UserSession
+	tx := db.Orm.Model(&UserSession{}).
+		Where("user_external_id = ? AND revoked_at IS NULL AND expires_at > ?", userExternalID, now).
+		Order("last_seen_at desc").
+		Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) GetUserSession(tokenID string) (*UserSession, error) {
+	var s UserSession
+	tx := db.Orm.Model(&UserSession{}).
+		Where("token_id = ?", tokenID).
+		First(&s)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &s, nil
+}
+
+// RevokeUserSession records the revocation of the session's token and marks the session revoked
+func (db Database) RevokeUserSession(session *UserSession, revocation *SessionRevocation) error {
+	return db.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(revocation).Error; err != nil {
+			return err
+		}
+		return tx.Model(&UserSession{}).
+			Where("id = ?", session.ID).
+			Update("revoked_at", revocation.CreatedAt).Error
+	})
+}
+
+// RevokeAllUserSessions records the revocation of every token of the user issued so far and returns the number of sessions it ended
+func (db Database) RevokeAllUserSessions(revocation *SessionRevocation) (int64, error) {
+	var revoked int64
+	err := db.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(revocation).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&UserSession{}).
+			Where("user_external_id = ? AND revoked_at IS NULL AND expires_at > ?", revocation.UserExternalID, revocation.RevokedBefore).
+			Update("revoked_at", revocation.RevokedBefore)
+		revoked = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return revoked, nil
+}
+
+func (db Database) ListActiveSessionRevocations(now time.Time) ([]SessionRevocation, error) {
+	var s []SessionRevocation
+	tx := db.Orm.Model(&SessionRevocation{}).
+		Where("expires_at > ?", now).
+		Find(&s)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return s, nil
+}
+
+func (db Database) DeactivateApiKeysOfUser(creatorUserID string) (int64, error) {
+	tx := db.Orm.Model(&ApiKey{}).
+		Where("creator_user_id = ? AND is_active = ?", creatorUserID, true).
+		Update("is_active", false)
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	return tx.RowsAffected, nil
+}
+
+// ListDexSubjectsOfUser returns the dex subjects the user logged in with, expired sessions included as the refresh
+// tokens outlive them
+func (db Database) ListDexSubjectsOfUser(userExternalID string) ([]string, error) {
+	var subjects []string
+	tx := db.Orm.Model(&UserSession{}).
+		Where("user_external_id = ? AND dex_subject <> ''", userExternalID).
+		Distinct().
+		Pluck("dex_subject", &subjects)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return subjects, nil
+}
+
+// DeactivateUser deactivates the user and takes it out of the group role mappings, a later login matching a
+// mapped group does not reactivate it
+func (db Database) DeactivateUser(id uint) error {
+	tx := db.Orm.Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"is_active": false, "group_managed": false})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+// DeleteExpiredSessions removes the sessions and revocations whose tokens expired before t
+func (db Database) DeleteExpiredSessions(t time.Time) (int64, error) {
+	var deleted int64
+	err := db.Orm.Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Where("expires_at <= ?", t).Delete(&UserSession{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		return tx.Unscoped().Where("expires_at <= ?", t).Delete(&SessionRevocation{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
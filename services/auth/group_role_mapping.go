@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	api2 "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"go.uber.org/zap"
+)
+
+// groupRoleCacheTTL bounds how long mapping changes made through another replica, and changes made by hand to the
+// users the mappings were applied to, take to apply
+const groupRoleCacheTTL = time.Minute
+
+// maxGroupRoleUsers bounds the users kept in the cache, expired entries are dropped once it is reached
+const maxGroupRoleUsers = 10000
+
+// groupRoleUser is the state of a user the mappings were last applied to
+type groupRoleUser struct {
+	exists       bool
+	groupManaged bool
+	role         api2.Role
+	active       bool
+	expiresAt    time.Time
+}
+
+func newGroupRoleUser(user *db.User) groupRoleUser {
+	if user == nil || user.ID == 0 {
+		return groupRoleUser{}
+	}
+	return groupRoleUser{exists: true, groupManaged: user.GroupManaged, role: user.Role, active: user.IsActive}
+}
+
+// needsSync tells whether the user has to be created, updated or deactivated to match the resolved role.
+// Deactivated users are left as they are, only an admin can activate them again.
+func (u groupRoleUser) needsSync(role api2.Role, matched bool) bool {
+	switch {
+	case !u.exists:
+		return matched
+	case !u.groupManaged, !u.active:
+		return false
+	case !matched:
+		return true
+	default:
+		return u.role != role
+	}
+}
+
+// groupRoleResolver maps the group claims of the identity provider to platform roles
+type groupRoleResolver struct {
+	db db.Database
+
+	mu        sync.Mutex
+	mappings  []db.GroupRoleMapping
+	expiresAt time.Time
+	// users caches the state of the users by email so requests of users already in line with their groups skip the database
+	users map[string]groupRoleUser
+
+	// syncMu serializes the user updates so concurrent requests of a new user create it once
+	syncMu sync.Mutex
+}
+
+func newGroupRoleResolver(adb db.Database) *groupRoleResolver {
+	return &groupRoleResolver{db: adb, users: make(map[string]groupRoleUser)}
+}
+
+func (r *groupRoleResolver) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expiresAt = time.Time{}
+	r.users = make(map[string]groupRoleUser)
+}
+
+func (r *groupRoleResolver) cachedUser(email string) (groupRoleUser, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[email]
+	if !ok || time.Now().After(user.expiresAt) {
+		return groupRoleUser{}, false
+	}
+	return user, true
+}
+
+func (r *groupRoleResolver) cacheUser(email string, user groupRoleUser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if len(r.users) >= maxGroupRoleUsers {
+		for k, u := range r.users {
+			if now.After(u.expiresAt) {
+				delete(r.users, k)
+			}
+		}
+	}
+	user.expiresAt = now.Add(groupRoleCacheTTL)
+	r.users[email] = user
+}
+
+func (r *groupRoleResolver) list() ([]db.GroupRoleMapping, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Now().Before(r.expiresAt) {
+		return r.mappings, nil
+	}
+	mappings, err := r.db.ListGroupRoleMappings()
+	if err != nil {
+		return nil, err
+	}
+	r.mappings = mappings
+	r.expiresAt = time.Now().Add(groupRoleCacheTTL)
+	return mappings, nil
+}
+
+// resolve returns the highest role mapped to the groups. enforced is false when no mapping applies to the connector,
+// the users of the connector are then managed by hand only.
+func (r *groupRoleResolver) resolve(connectorID string, groups []string) (role api2.Role, matched bool, enforced bool, err error) {
+	mappings, err := r.list()
+	if err != nil {
+		return "", false, false, err
+	}
+	for _, mapping := range mappings {
+		if mapping.ConnectorID != "" && mapping.ConnectorID != connectorID {
+			continue
+		}
+		enforced = true
+		if !slices.Contains(groups, mapping.Group) {
+			continue
+		}
+		if !matched || rolePriority(mapping.Role) > rolePriority(role) {
+			role = mapping.Role
+		}
+		matched = true
+	}
+	return role, matched, enforced, nil
+}
+
+// applyGroupRoles creates the users matching a mapping on their first login and keeps the role of the users it
+// created in line with their groups, deactivating them once no mapped group is left. Users created by hand are left as they are.
+// Users are only created when the identity provider verified their email, deactivated users are left for an admin to activate again.
+func (s *Server) applyGroupRoles(claim *userClaim) error {
+	role, matched, enforced, err := s.groupRoles.resolve(claim.connectorID, claim.groups)
+	if err != nil {
+		return err
+	}
+	if !enforced {
+		return nil
+	}
+	if cached, ok := s.groupRoles.cachedUser(claim.Email); ok && !cached.needsSync(role, matched) {
+		return nil
+	}
+
+	user, err := s.db.GetUserByEmail(claim.Email)
+	if err != nil {
+		return err
+	}
+	state := newGroupRoleUser(user)
+	if !state.needsSync(role, matched) {
+		s.groupRoles.cacheUser(claim.Email, state)
+		return nil
+	}
+	if !state.exists && !claim.EmailVerified {
+		s.logger.Warn("not creating user from group role mappings, email is not verified", zap.String("email", claim.Email))
+		return nil
+	}
+
+	s.groupRoles.syncMu.Lock()
+	defer s.groupRoles.syncMu.Unlock()
+
+	// another request of the user may have synced it while this one was waiting
+	if user, err = s.db.GetUserByEmail(claim.Email); err != nil {
+		return err
+	}
+	state = newGroupRoleUser(user)
+	switch {
+	case !state.needsSync(role, matched):
+	case !state.exists:
+		connector := claim.connectorID
+		if connector == "" {
+			connector = "oidc"
+		}
+		fullName := strings.TrimSpace(claim.name)
+		if fullName == "" {
+			fullName = claim.Email
+		}
+		s.logger.Info("creating user from group role mappings", zap.String("email", claim.Email), zap.String("role", string(role)))
+		if err := s.db.CreateUser(&db.User{
+			Email:         claim.Email,
+			EmailVerified: claim.EmailVerified,
+			FullName:      fullName,
+			Username:      claim.Email,
+			Role:          role,
+			ConnectorId:   connector,
+			ExternalId:    fmt.Sprintf("%s|%s", connector, claim.Email),
+			IsActive:      true,
+			GroupManaged:  true,
+		}); err != nil {
+			return err
+		}
+		state = groupRoleUser{exists: true, groupManaged: true, role: role, active: true}
+	case !matched:
+		s.logger.Info("deactivating user without mapped groups", zap.String("email", claim.Email))
+		if err := s.db.SetUserGroupRole(user.ID, user.Role, false); err != nil {
+			return err
+		}
+		state.active = false
+	default:
+		s.logger.Info("updating user role from group role mappings", zap.String("email", claim.Email),
+			zap.String("from", string(user.Role)), zap.String("to", string(role)))
+		if err := s.db.SetUserGroupRole(user.ID, role, user.IsActive); err != nil {
+			return err
+		}
+		state.role = role
+	}
+	s.groupRoles.cacheUser(claim.Email, state)
+	return nil
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	api2 "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/opensecurity/services/auth/db"
+)
+
+func TestGroupRoleResolverResolve(t *testing.T) {
+	resolver := newGroupRoleResolver(db.Database{})
+	resolver.mappings = []db.GroupRoleMapping{
+		{Group: "viewers", Role: api2.ViewerRole},
+		{Group: "admins", Role: api2.AdminRole},
+		{Group: "editors", Role: api2.EditorRole, ConnectorID: "okta"},
+		{Group: "auditors", Role: api2.ViewerRole, ConnectorID: "okta"},
+	}
+	resolver.expiresAt = time.Now().Add(time.Hour)
+
+	oktaOnly := newGroupRoleResolver(db.Database{})
+	oktaOnly.mappings = []db.GroupRoleMapping{{Group: "editors", Role: api2.EditorRole, ConnectorID: "okta"}}
+	oktaOnly.expiresAt = time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		resolver     *groupRoleResolver
+		connectorID  string
+		groups       []string
+		wantRole     api2.Role
+		wantMatched  bool
+		wantEnforced bool
+	}{
+		{name: "no group", resolver: resolver, connectorID: "okta", wantEnforced: true},
+		{name: "unmapped group", resolver: resolver, connectorID: "okta", groups: []string{"other"}, wantEnforced: true},
+		{name: "global mapping", resolver: resolver, connectorID: "github", groups: []string{"viewers"},
+			wantRole: api2.ViewerRole, wantMatched: true, wantEnforced: true},
+		{name: "highest role wins", resolver: resolver, connectorID: "okta", groups: []string{"viewers", "admins", "editors"},
+			wantRole: api2.AdminRole, wantMatched: true, wantEnforced: true},
+		{name: "highest role wins whatever the order", resolver: resolver, connectorID: "okta", groups: []string{"auditors", "editors"},
+			wantRole: api2.EditorRole, wantMatched: true, wantEnforced: true},
+		{name: "mapping of another connector", resolver: resolver, connectorID: "github", groups: []string{"editors"}, wantEnforced: true},
+		{name: "connector without mapping", resolver: oktaOnly, connectorID: "github", groups: []string{"editors"}},
+		{name: "connector mapping", resolver: oktaOnly, connectorID: "okta", groups: []string{"editors"},
+			wantRole: api2.EditorRole, wantMatched: true, wantEnforced: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			role, matched, enforced, err := tc.resolver.resolve(tc.connectorID, tc.groups)
+			if err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+			if role != tc.wantRole || matched != tc.wantMatched || enforced != tc.wantEnforced {
+				t.Errorf("resolve() = %s, %v, %v, want %s, %v, %v", role, matched, enforced, tc.wantRole, tc.wantMatched, tc.wantEnforced)
+			}
+		})
+	}
+}
+
+func TestGroupRoleUserNeedsSync(t *testing.T) {
+	managed := groupRoleUser{exists: true, groupManaged: true, role: api2.EditorRole, active: true}
+	tests := []struct {
+		name    string
+		user    groupRoleUser
+		role    api2.Role
+		matched bool
+		want    bool
+	}{
+		{name: "new user with a mapped group", user: groupRoleUser{}, role: api2.ViewerRole, matched: true, want: true},
+		{name: "new user without a mapped group", user: groupRoleUser{}, want: false},
+		{name: "user created by hand", user: groupRoleUser{exists: true, role: api2.AdminRole, active: true}, role: api2.ViewerRole, matched: true, want: false},
+		{name: "same role", user: managed, role: api2.EditorRole, matched: true, want: false},
+		{name: "demoted", user: managed, role: api2.ViewerRole, matched: true, want: true},
+		{name: "no mapped group left", user: managed, want: true},
+		{name: "already deactivated", user: groupRoleUser{exists: true, groupManaged: true, role: api2.EditorRole}, want: false},
+		{name: "deactivated user matching again", user: groupRoleUser{exists: true, groupManaged: true, role: api2.EditorRole}, role: api2.EditorRole, matched: true, want: false},
+		{name: "deactivated user with another role", user: groupRoleUser{exists: true, groupManaged: true, role: api2.EditorRole}, role: api2.AdminRole, matched: true, want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.user.needsSync(tc.role, tc.matched); got != tc.want {
+				t.Errorf("needsSync(%s, %v) = %v, want %v", tc.role, tc.matched, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGroupRoleResolverUserCache(t *testing.T) {
+	resolver := newGroupRoleResolver(db.Database{})
+	if _, ok := resolver.cachedUser("a@example.com"); ok {
+		t.Fatal("cachedUser() found a user never cached")
+	}
+	resolver.cacheUser("a@example.com", groupRoleUser{exists: true, role: api2.ViewerRole})
+	if user, ok := resolver.cachedUser("a@example.com"); !ok || user.role != api2.ViewerRole {
+		t.Fatalf("cachedUser() = %v, %v, want the cached user", user, ok)
+	}
+	resolver.invalidate()
+	if _, ok := resolver.cachedUser("a@example.com"); ok {
+		t.Error("cachedUser() found a user after invalidate")
+	}
+}
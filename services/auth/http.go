@@ -19,6 +19,8 @@ import (
 	envoyauth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	api2 "github.com/opengovern/og-util/pkg/api"
 	"github.com/opengovern/og-util/pkg/httpserver"
+	"github.com/opengovern/opensecurity/pkg/audit"
+	"github.com/opengovern/opensecurity/pkg/rbac"
 	"github.com/opengovern/opensecurity/services/auth/utils"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc"
@@ -45,9 +47,13 @@ type httpRoutes struct {
 	platformPrivateKey *rsa.PrivateKey
 	db                 db.Database
 	authServer         *Server
+
+	connectorCheckPolicy utils.ConnectorCheckPolicy
 }
 
 func (r *httpRoutes) Register(e *echo.Echo) {
+	e.Use(audit.Middleware("auth", newDBAuditSink(r.logger, r.db), "/api/v1/connector/test"))
+
 	v1 := e.Group("/api/v1")
 	// VAlidate token
 	v1.GET("/check", r.Check)
@@ -55,23 +61,68 @@ func (r *httpRoutes) Register(e *echo.Echo) {
 	v1.GET("/users", httpserver.AuthorizeHandler(r.GetUsers, api2.EditorRole))                                      //checked
 	v1.GET("/user/:id", httpserver.AuthorizeHandler(r.GetUserDetails, api2.EditorRole))                             //checked
 	v1.GET("/me", httpserver.AuthorizeHandler(r.GetMe, api2.EditorRole))                                            //checked
-	v1.POST("/user", httpserver.AuthorizeHandler(r.CreateUser, api2.EditorRole))                                    //checked
-	v1.PUT("/user", httpserver.AuthorizeHandler(r.UpdateUser, api2.EditorRole))                                     //checked
+	v1.POST("/user", rbac.AuthorizeUnscopedHandler(r.CreateUser, api2.EditorRole))                                  //checked
+	v1.PUT("/user", rbac.AuthorizeUnscopedHandler(r.UpdateUser, api2.EditorRole))                                   //checked
 	v1.GET("/user/password/check", httpserver.AuthorizeHandler(r.CheckUserPasswordChangeRequired, api2.ViewerRole)) //checked
 	v1.POST("/user/password/reset", httpserver.AuthorizeHandler(r.ResetUserPassword, api2.ViewerRole))              //checked
-	v1.DELETE("/user/:id", httpserver.AuthorizeHandler(r.DeleteUser, api2.AdminRole))                               //checked
+	v1.DELETE("/user/:id", rbac.AuthorizeUnscopedHandler(r.DeleteUser, api2.AdminRole))                             //checked
+	// SESSIONS
+	v1.GET("/user/:id/sessions", rbac.AuthorizeUnscopedHandler(r.ListUserSessions, api2.AdminRole))
+	v1.DELETE("/user/:id/sessions/:sessionId", rbac.AuthorizeUnscopedHandler(r.RevokeUserSession, api2.AdminRole))
+	v1.POST("/user/:id/logout-everywhere", rbac.AuthorizeUnscopedHandler(r.LogoutEverywhere, api2.AdminRole))
+	v1.GET("/me/sessions", httpserver.AuthorizeHandler(r.ListMySessions, api2.ViewerRole))
+	v1.DELETE("/me/sessions/:sessionId", httpserver.AuthorizeHandler(r.RevokeMySession, api2.ViewerRole))
+	// RATE LIMITS
+	v1.GET("/rate-limits", rbac.AuthorizeUnscopedHandler(r.ListRateLimitPolicies, api2.AdminRole))
+	v1.POST("/rate-limits", rbac.AuthorizeUnscopedHandler(r.CreateRateLimitPolicy, api2.AdminRole))
+	v1.PUT("/rate-limits/:id", rbac.AuthorizeUnscopedHandler(r.UpdateRateLimitPolicy, api2.AdminRole))
+	v1.DELETE("/rate-limits/:id", rbac.AuthorizeUnscopedHandler(r.DeleteRateLimitPolicy, api2.AdminRole))
+	v1.GET("/rate-limits/route-classes", rbac.AuthorizeUnscopedHandler(r.ListRateLimitRouteClasses, api2.AdminRole))
+	v1.GET("/rate-limits/usage", rbac.AuthorizeUnscopedHandler(r.GetRateLimitUsage, api2.AdminRole))
+	v1.GET("/me/rate-limits", httpserver.AuthorizeHandler(r.GetMyRateLimitUsage, api2.ViewerRole))
 	// API KEYS
-	v1.POST("/keys", httpserver.AuthorizeHandler(r.CreateAPIKey, api2.AdminRole)) //checked
-	v1.GET("/keys", httpserver.AuthorizeHandler(r.ListAPIKeys, api2.AdminRole))   //checked
-	v1.DELETE("/key/:id", httpserver.AuthorizeHandler(r.DeleteAPIKey, api2.AdminRole))
-	v1.PUT("/key/:id", httpserver.AuthorizeHandler(r.EditAPIKey, api2.AdminRole))
+	v1.POST("/keys", rbac.AuthorizeUnscopedHandler(r.CreateAPIKey, api2.AdminRole)) //checked
+	v1.GET("/keys", rbac.AuthorizeUnscopedHandler(r.ListAPIKeys, api2.AdminRole))   //checked
+	v1.DELETE("/key/:id", rbac.AuthorizeUnscopedHandler(r.DeleteAPIKey, api2.AdminRole))
+	v1.PUT("/key/:id", rbac.AuthorizeUnscopedHandler(r.EditAPIKey, api2.AdminRole))
 	// connectors
-	v1.GET("/connectors", httpserver.AuthorizeHandler(r.GetConnectors, api2.AdminRole))
-	v1.GET("/connectors/supported-connector-types", httpserver.AuthorizeHandler(r.GetSupportedType, api2.AdminRole))
-	v1.GET("/connector/:type", httpserver.AuthorizeHandler(r.GetConnectors, api2.AdminRole))
-	v1.POST("/connector", httpserver.AuthorizeHandler(r.CreateConnector, api2.AdminRole))
-	v1.PUT("/connector", httpserver.AuthorizeHandler(r.UpdateConnector, api2.AdminRole))
-	v1.DELETE("/connector/:id", httpserver.AuthorizeHandler(r.DeleteConnector, api2.AdminRole))
+	v1.GET("/connectors", rbac.AuthorizeUnscopedHandler(r.GetConnectors, api2.AdminRole))
+	v1.GET("/connectors/supported-connector-types", rbac.AuthorizeUnscopedHandler(r.GetSupportedType, api2.AdminRole))
+	v1.GET("/connector/:type", rbac.AuthorizeUnscopedHandler(r.GetConnectors, api2.AdminRole))
+	v1.POST("/connector", rbac.AuthorizeUnscopedHandler(r.CreateConnector, api2.AdminRole))
+	v1.POST("/connector/test", rbac.AuthorizeUnscopedHandler(r.TestConnector, api2.AdminRole))
+	v1.PUT("/connector", rbac.AuthorizeUnscopedHandler(r.UpdateConnector, api2.AdminRole))
+	v1.DELETE("/connector/:id", rbac.AuthorizeUnscopedHandler(r.DeleteConnector, api2.AdminRole))
+	// audit log
+	v1.POST("/audit/events", rbac.AuthorizeUnscopedHandler(r.IngestAuditEvents, api2.AdminRole))
+	v1.GET("/audit/events", rbac.AuthorizeUnscopedHandler(r.ListAuditEvents, api2.AdminRole))
+	// role bindings
+	v1.GET("/role-bindings", rbac.AuthorizeUnscopedHandler(r.ListRoleBindings, api2.AdminRole))
+	v1.POST("/role-bindings", rbac.AuthorizeUnscopedHandler(r.CreateRoleBinding, api2.AdminRole))
+	v1.DELETE("/role-bindings/:id", rbac.AuthorizeUnscopedHandler(r.DeleteRoleBinding, api2.AdminRole))
+
+	v1.GET("/group-role-mappings", rbac.AuthorizeUnscopedHandler(r.ListGroupRoleMappings, api2.AdminRole))
+	v1.POST("/group-role-mappings", rbac.AuthorizeUnscopedHandler(r.CreateGroupRoleMapping, api2.AdminRole))
+	v1.DELETE("/group-role-mappings/:id", rbac.AuthorizeUnscopedHandler(r.DeleteGroupRoleMapping, api2.AdminRole))
+	// scim
+	v1.POST("/scim/tokens", rbac.AuthorizeUnscopedHandler(r.CreateScimToken, api2.AdminRole))
+	v1.GET("/scim/tokens", rbac.AuthorizeUnscopedHandler(r.ListScimTokens, api2.AdminRole))
+	v1.DELETE("/scim/tokens/:id", rbac.AuthorizeUnscopedHandler(r.DeleteScimToken, api2.AdminRole))
+
+	scim := e.Group("/scim/v2")
+	scim.GET("/ServiceProviderConfig", httpserver.AuthorizeHandler(r.ScimServiceProviderConfig, api2.AdminRole))
+	scim.GET("/Users", httpserver.AuthorizeHandler(r.ScimListUsers, api2.AdminRole))
+	scim.POST("/Users", httpserver.AuthorizeHandler(r.ScimCreateUser, api2.AdminRole))
+	scim.GET("/Users/:id", httpserver.AuthorizeHandler(r.ScimGetUser, api2.AdminRole))
+	scim.PUT("/Users/:id", httpserver.AuthorizeHandler(r.ScimReplaceUser, api2.AdminRole))
+	scim.PATCH("/Users/:id", httpserver.AuthorizeHandler(r.ScimPatchUser, api2.AdminRole))
+	scim.DELETE("/Users/:id", httpserver.AuthorizeHandler(r.ScimDeleteUser, api2.AdminRole))
+	scim.GET("/Groups", httpserver.AuthorizeHandler(r.ScimListGroups, api2.AdminRole))
+	scim.POST("/Groups", httpserver.AuthorizeHandler(r.ScimCreateGroup, api2.AdminRole))
+	scim.GET("/Groups/:id", httpserver.AuthorizeHandler(r.ScimGetGroup, api2.AdminRole))
+	scim.PUT("/Groups/:id", httpserver.AuthorizeHandler(r.ScimReplaceGroup, api2.AdminRole))
+	scim.PATCH("/Groups/:id", httpserver.AuthorizeHandler(r.ScimPatchGroup, api2.AdminRole))
+	scim.DELETE("/Groups/:id", httpserver.AuthorizeHandler(r.ScimDeleteGroup, api2.AdminRole))
 
 }
 
@@ -117,6 +168,9 @@ func (r *httpRoutes) Check(ctx echo.Context) error {
 		return err
 	}
 
+	if res.Status.Code == int32(codes.PermissionDenied) {
+		return echo.NewHTTPError(http.StatusForbidden, res.GetDeniedResponse().GetBody())
+	}
 	if res.Status.Code != int32(codes.OK) {
 		return echo.NewHTTPError(http.StatusUnauthorized, res.Status.Message)
 	}
@@ -168,6 +222,7 @@ func (r *httpRoutes) GetUsers(ctx echo.Context) error {
 			RoleName:      u.Role,
 			IsActive:      u.IsActive,
 			ConnectorId:   u.ConnectorId,
+			GroupManaged:  u.GroupManaged,
 		}
 		if u.LastLogin.IsZero() {
 			temp_resp.LastActivity = nil
@@ -291,6 +346,14 @@ func (r *httpRoutes) CreateAPIKey(ctx echo.Context) error {
 		return errors.New("failed to find user in auth")
 	}
 
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return echo.NewHTTPError(http.StatusBadRequest, "expires_at must be in the future")
+	}
+	scopes, err := expandAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
 	u := userClaim{
 		Role:           req.Role,
 		Email:          usr.Email,
@@ -333,6 +396,8 @@ func (r *httpRoutes) CreateAPIKey(ctx echo.Context) error {
 		IsActive:      true,
 		MaskedKey:     masked,
 		KeyHash:       keyHash,
+		ExpiresAt:     req.ExpiresAt,
+		Scopes:        scopes,
 	}
 
 	r.logger.Info("adding API Key")
@@ -342,14 +407,18 @@ func (r *httpRoutes) CreateAPIKey(ctx echo.Context) error {
 		return err
 	}
 
-	return ctx.JSON(http.StatusCreated, api.CreateAPIKeyResponse{
+	resp := api.CreateAPIKeyResponse{
 		ID:        apikey.ID,
 		Name:      apikey.Name,
 		Active:    apikey.IsActive,
 		CreatedAt: apikey.CreatedAt,
 		RoleName:  apikey.Role,
-		Token:     token,
-	})
+		ExpiresAt: apikey.ExpiresAt,
+		Scopes:    apikey.Scopes,
+	}
+	audit.SetAfter(ctx, resp)
+	resp.Token = token
+	return ctx.JSON(http.StatusCreated, resp)
 }
 
 // DeleteAPIKey godoc
@@ -373,6 +442,11 @@ func (r *httpRoutes) DeleteAPIKey(ctx echo.Context) error {
 	if err != nil {
 		return err
 	}
+	err = r.db.DeleteRoleBindingsOfAPIKey(uint(integer_id))
+	if err != nil {
+		return err
+	}
+	r.authServer.scopes.invalidate()
 
 	return ctx.NoContent(http.StatusAccepted)
 }
@@ -389,6 +463,23 @@ func (r *httpRoutes) EditAPIKey(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "id is required")
 	}
 
+	if req.IsActive {
+		keyID, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+		}
+		key, err := r.db.GetApiKey(uint(keyID))
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "api key not found")
+		}
+		if key.IsExpired(time.Now()) {
+			return echo.NewHTTPError(http.StatusBadRequest, "expired api key can not be activated")
+		}
+	}
+
 	err := r.db.UpdateAPIKey(id, req.IsActive, req.Role)
 	if err != nil {
 		return err
@@ -423,6 +514,10 @@ func (r *httpRoutes) ListAPIKeys(ctx echo.Context) error {
 			CreatorUserID: key.CreatorUserID,
 			Active:        key.IsActive,
 			MaskedKey:     key.MaskedKey,
+			ExpiresAt:     key.ExpiresAt,
+			Scopes:        key.Scopes,
+			LastUsedAt:    key.LastUsedAt,
+			LastUsedIP:    key.LastUsedIP,
 		})
 	}
 
@@ -684,6 +779,9 @@ func (r *httpRoutes) DeleteUser(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "id is required")
 	}
 
+	if user, err := r.db.GetUser(id); err == nil && user != nil && user.ID != 0 {
+		audit.SetBefore(ctx, map[string]any{"email": user.Email, "role": user.Role, "active": user.IsActive})
+	}
 	err := r.DoDeleteUser(id)
 	if err != nil {
 		return err
@@ -693,12 +791,6 @@ func (r *httpRoutes) DeleteUser(ctx echo.Context) error {
 }
 
 func (r *httpRoutes) DoDeleteUser(id string) error {
-	dexClient, err := newDexClient(dexGrpcAddress)
-	if err != nil {
-		r.logger.Error("failed to create dex client", zap.Error(err))
-		return echo.NewHTTPError(http.StatusBadRequest, "failed to create dex client")
-	}
-
 	user, err2 := r.db.GetUser(id)
 
 	if err2 != nil {
@@ -710,20 +802,35 @@ func (r *httpRoutes) DoDeleteUser(id string) error {
 	if user.ID == 1 {
 		return echo.NewHTTPError(http.StatusBadRequest, "cannot delete the first user")
 	}
-	dexReq := &dexApi.DeletePasswordReq{
-		Email: user.Email,
+	if err := r.removeUser(*user); err != nil {
+		r.logger.Error("failed to delete user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete user")
 	}
+	return nil
+}
 
-	_, err = dexClient.DeletePassword(context.TODO(), dexReq)
+// removeUser deletes the user with its dex password and role bindings, and deactivates the api keys it created
+func (r *httpRoutes) removeUser(user db.User) error {
+	dexClient, err := newDexClient(dexGrpcAddress)
 	if err != nil {
-		r.logger.Error("failed to remove dex password", zap.Error(err))
-		return echo.NewHTTPError(http.StatusBadRequest, "failed to remove dex password")
+		return fmt.Errorf("failed to create dex client: %w", err)
+	}
+	dexReq := &dexApi.DeletePasswordReq{
+		Email: user.Email,
+	}
+	if _, err := dexClient.DeletePassword(context.TODO(), dexReq); err != nil {
+		return fmt.Errorf("failed to remove dex password: %w", err)
 	}
 
-	err = r.db.DeleteUser(user.ID)
-	if err != nil {
-		r.logger.Error("failed to delete user", zap.Error(err))
-		return echo.NewHTTPError(http.StatusBadRequest, "failed to create user")
+	if err := r.db.DeleteUser(user.ID); err != nil {
+		return err
+	}
+	if err := r.db.DeleteRoleBindingsOfUser(user.ExternalId); err != nil {
+		return fmt.Errorf("failed to delete user role bindings: %w", err)
+	}
+	r.authServer.scopes.invalidate()
+	if _, err := r.db.DeactivateApiKeysOfUser(user.ExternalId); err != nil {
+		return fmt.Errorf("failed to deactivate user api keys: %w", err)
 	}
 	return nil
 }
@@ -928,6 +1035,11 @@ func (r *httpRoutes) GetConnectors(ctx echo.Context) error {
 				info.ClientID = config.ClientID
 				// Note: Omitting ClientSecret for security reasons
 			}
+		} else if issuer, clientID, err := utils.ConnectorSummary(strings.ToLower(connector.Type), connector.Config); err != nil {
+			r.logger.Error("Failed to unmarshal connector config", zap.String("connector", connector.Id), zap.Error(err))
+		} else {
+			info.Issuer = issuer
+			info.ClientID = clientID
 		}
 
 		resp = append(resp, info)
@@ -948,47 +1060,36 @@ func (r *httpRoutes) GetConnectors(ctx echo.Context) error {
 func (r *httpRoutes) GetSupportedType(ctx echo.Context) error {
 	var connectors []api.GetSupportedConnectorTypeResponse
 
-	subTypes := utils.SupportedConnectors["oidc"]
-	subTypesNames := utils.SupportedConnectorsNames["oidc"]
+	for _, connectorType := range utils.SupportedConnectorTypes {
+		subTypes := utils.SupportedConnectors[connectorType]
+		subTypesNames := utils.SupportedConnectorsNames[connectorType]
 
-	var types []api.ConnectorSubTypes
-	for i, key := range subTypes {
-		types = append(types, api.ConnectorSubTypes{
-			ID:   key,
-			Name: subTypesNames[i],
+		var types []api.ConnectorSubTypes
+		for i, key := range subTypes {
+			types = append(types, api.ConnectorSubTypes{
+				ID:   key,
+				Name: subTypesNames[i],
+			})
+		}
+		connectors = append(connectors, api.GetSupportedConnectorTypeResponse{
+			ConnectorType: connectorType,
+			SubTypes:      types,
 		})
 	}
-	connectors = append(connectors, api.GetSupportedConnectorTypeResponse{
-		ConnectorType: "oidc",
-		SubTypes:      types,
-	})
 
 	return ctx.JSON(http.StatusOK, connectors)
 
 }
 
-// CreateConnector godoc
-//
-//	@Summary		Create Connector
-//	@Description	Creates new OIDC connector.
-//	@Security		BearerToken
-//	@Tags			connectors
-//	@Produce		json
-//	@Success		200
-//	@Router			/auth/api/v1/connector/supported-connector-types [post]
-func (r *httpRoutes) CreateConnector(ctx echo.Context) error {
-	var req api.CreateConnectorRequest
-	if err := bindValidate(ctx, &req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err)
-	}
-
+// prepareConnectorRequest validates the connector settings of the type and fills in the default id and name
+func (r *httpRoutes) prepareConnectorRequest(req *api.CreateConnectorRequest) (utils.ConnectorCreator, *utils.CreateConnectorRequest, error) {
 	if req.ConnectorType == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "connector type is required")
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "connector type is required")
 	}
 	connectorTypeLower := strings.ToLower(req.ConnectorType)
 	creator := utils.GetConnectorCreator(connectorTypeLower)
 	if creator == nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "connector type is not supported")
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "connector type is not supported")
 	}
 
 	// default
@@ -1001,18 +1102,36 @@ func (r *httpRoutes) CreateConnector(ctx echo.Context) error {
 	if !utils.IsSupportedSubType(connectorTypeLower, connectorSubTypeLower) {
 		err := fmt.Sprintf("unsupported connector_sub_type '%s' for connector_type '%s'", connectorSubTypeLower, connectorTypeLower)
 		r.logger.Info(err)
-		return echo.NewHTTPError(http.StatusBadRequest, err)
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err)
 
 	}
-	switch connectorSubTypeLower {
-	case "general":
+	if err := validateConnectorSettings(connectorTypeLower, connectorSubTypeLower, req.ClientID, req.ClientSecret, req.SAML, req.LDAP, req.GitHub); err != nil {
+		r.logger.Info(err.Error())
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	switch {
+	case connectorTypeLower == "saml":
+		req.ID = utils.OrDefault(req.ID, "saml")
+		req.Name = utils.OrDefault(req.Name, "SAML 2.0")
+
+	case connectorTypeLower == "ldap":
+		if connectorSubTypeLower == "active-directory" {
+			req.ID = utils.OrDefault(req.ID, "active-directory")
+			req.Name = utils.OrDefault(req.Name, "Active Directory")
+		} else {
+			req.ID = utils.OrDefault(req.ID, "ldap")
+			req.Name = utils.OrDefault(req.Name, "LDAP")
+		}
+
+	case connectorTypeLower == "github":
+		req.ID = utils.OrDefault(req.ID, "github")
+		req.Name = utils.OrDefault(req.Name, "GitHub")
+
+	case connectorSubTypeLower == "general":
 		// Required: issuer, client_id, client_secret
 		if strings.TrimSpace(req.Issuer) == "" {
 			r.logger.Warn("Missing 'issuer' for 'general' OIDC connector")
-			return ctx.JSON(http.StatusBadRequest, map[string]string{
-				"error": "issuer is required for 'general' OIDC connector",
-			})
-
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "issuer is required for 'general' OIDC connector")
 		}
 
 		// Set default id and name if not provided
@@ -1023,12 +1142,12 @@ func (r *httpRoutes) CreateConnector(ctx echo.Context) error {
 			req.Name = "General OIDC"
 		}
 
-	case "entraid":
+	case connectorSubTypeLower == "entraid":
 		// Required: tenant_id, client_id, client_secret
 		if strings.TrimSpace(req.TenantID) == "" {
 			err := "Missing 'tenant_id' for 'entraid' OIDC connector"
 			r.logger.Info(err)
-			return echo.NewHTTPError(http.StatusBadRequest, err)
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err)
 
 		}
 		// fetching issuer
@@ -1043,7 +1162,7 @@ func (r *httpRoutes) CreateConnector(ctx echo.Context) error {
 
 		}
 
-	case "google-workspace":
+	case connectorSubTypeLower == "google-workspace":
 		// Required: client_id, client_secret
 
 		// Set default id and name if not provided
@@ -1056,17 +1175,101 @@ func (r *httpRoutes) CreateConnector(ctx echo.Context) error {
 
 		}
 	}
-	dexRequest := utils.CreateConnectorRequest{
-		ConnectorType:    req.ConnectorType,
+	return creator, &utils.CreateConnectorRequest{
+		ConnectorType:    connectorTypeLower,
 		ConnectorSubType: req.ConnectorSubType,
 		Issuer:           req.Issuer,
 		TenantID:         req.TenantID,
 		ClientID:         req.ClientID,
 		ClientSecret:     req.ClientSecret,
+		SAML:             req.SAML,
+		LDAP:             req.LDAP,
+		GitHub:           req.GitHub,
 		ID:               req.ID,
 		Name:             req.Name,
+	}, nil
+}
+
+// validateConnectorSettings checks the settings only some connector types take are given for them
+func validateConnectorSettings(connectorType, connectorSubType, clientID, clientSecret string, saml *api.SAMLConnectorConfig, ldap *api.LDAPConnectorConfig, github *api.GitHubConnectorConfig) error {
+	switch connectorType {
+	case "oidc", "github":
+		if strings.TrimSpace(clientID) == "" || strings.TrimSpace(clientSecret) == "" {
+			return fmt.Errorf("client_id and client_secret are required for %s connector", connectorType)
+		}
+		if connectorType == "github" && connectorSubType == "enterprise" && (github == nil || github.HostName == "") {
+			return fmt.Errorf("github.host_name is required for enterprise github connector")
+		}
+	case "saml":
+		if saml == nil {
+			return fmt.Errorf("saml is required for saml connector")
+		}
+	case "ldap":
+		if ldap == nil {
+			return fmt.Errorf("ldap is required for ldap connector")
+		}
+	}
+	return nil
+}
+
+func orDefault(value, def string) string {
+	if strings.TrimSpace(value) == "" {
+		return def
 	}
-	dexreq, err := creator(dexRequest)
+	return value
+}
+
+// TestConnector godoc
+//
+//	@Summary		Test Connector
+//	@Description	Checks the connector settings against the identity provider without saving them. Only the schemes and ports allowed by the CONNECTOR_CHECK_SCHEMES, CONNECTOR_CHECK_HTTP_PORTS and CONNECTOR_CHECK_LDAP_PORTS settings are contacted, and loopback, private and link-local addresses are refused unless CONNECTOR_CHECK_ALLOW_PRIVATE_NETWORKS is set.
+//	@Security		BearerToken
+//	@Tags			connectors
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateConnectorRequest	true	"Connector"
+//	@Success		200		{object}	api.TestConnectorResponse
+//	@Router			/auth/api/v1/connector/test [post]
+func (r *httpRoutes) TestConnector(ctx echo.Context) error {
+	var req api.CreateConnectorRequest
+	if err := bindValidate(ctx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	creator, dexRequest, err := r.prepareConnectorRequest(&req)
+	if err != nil {
+		return err
+	}
+	if _, err := creator(*dexRequest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp := api.TestConnectorResponse{Success: true, Checks: utils.CheckConnector(*dexRequest, r.connectorCheckPolicy)}
+	for _, check := range resp.Checks {
+		resp.Success = resp.Success && check.Success
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateConnector godoc
+//
+//	@Summary		Create Connector
+//	@Description	Creates new oidc, saml, ldap or github connector.
+//	@Security		BearerToken
+//	@Tags			connectors
+//	@Produce		json
+//	@Success		200
+//	@Router			/auth/api/v1/connector/supported-connector-types [post]
+func (r *httpRoutes) CreateConnector(ctx echo.Context) error {
+	var req api.CreateConnectorRequest
+	if err := bindValidate(ctx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	creator, dexRequest, err := r.prepareConnectorRequest(&req)
+	if err != nil {
+		return err
+	}
+	dexreq, err := creator(*dexRequest)
 	if err != nil {
 		r.logger.Error("Error on Creating dex request", zap.Error(err))
 		return echo.NewHTTPError(http.StatusBadRequest, err)
@@ -1107,7 +1310,7 @@ func (r *httpRoutes) CreateConnector(ctx echo.Context) error {
 // UpdateConnector godoc
 //
 //	@Summary		Update Connector
-//	@Description	Update new OIDC connector.
+//	@Description	Update oidc, saml, ldap or github connector.
 //	@Security		BearerToken
 //	@Tags			connectors
 //	@Produce		json
@@ -1124,14 +1327,24 @@ func (r *httpRoutes) UpdateConnector(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "ID required")
 	}
 
+	if req.ConnectorType != "oidc" && req.ConnectorSubType == "" {
+		req.ConnectorSubType = "general"
+	}
 	if !utils.IsSupportedSubType(req.ConnectorType, req.ConnectorSubType) {
 		err := fmt.Sprintf("unsupported connector_sub_type '%s' for connector_type '%s'", req.ConnectorType, req.ConnectorSubType)
 		r.logger.Info(err)
 		return echo.NewHTTPError(http.StatusBadRequest, err)
 
 	}
-	switch req.ConnectorSubType {
-	case "general":
+	if err := validateConnectorSettings(req.ConnectorType, req.ConnectorSubType, req.ClientID, req.ClientSecret, req.SAML, req.LDAP, req.GitHub); err != nil {
+		r.logger.Info(err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	switch {
+	case req.ConnectorType != "oidc":
+		// validated with the settings of the type
+
+	case req.ConnectorSubType == "general":
 		// Required: issuer, client_id, client_secret
 		if strings.TrimSpace(req.Issuer) == "" {
 			err := "Missing 'issuer' for 'general' OIDC connector update"
@@ -1139,9 +1352,9 @@ func (r *httpRoutes) UpdateConnector(ctx echo.Context) error {
 			return echo.NewHTTPError(http.StatusBadRequest, err)
 
 		}
-		// client_id and client_secret are already validated as required in the struct
+		// client_id and client_secret are checked by validateConnectorSettings
 
-	case "entraid":
+	case req.ConnectorSubType == "entraid":
 		// Required: tenant_id, client_id, client_secret
 		if strings.TrimSpace(req.TenantID) == "" {
 			err := "Missing 'tenant_id' for 'entraid' OIDC connector update"
@@ -1149,12 +1362,12 @@ func (r *httpRoutes) UpdateConnector(ctx echo.Context) error {
 			return echo.NewHTTPError(http.StatusBadRequest, err)
 
 		}
-		// client_id and client_secret are already validated as required in the struct
+		// client_id and client_secret are checked by validateConnectorSettings
 
-	case "google-workspace":
+	case req.ConnectorSubType == "google-workspace":
 		// Required: client_id, client_secret
 		// No additional fields needed
-		// client_id and client_secret are already validated as required in the struct
+		// client_id and client_secret are checked by validateConnectorSettings
 
 	default:
 		err := fmt.Sprintf("unsupported connector_sub_type: %s", req.ConnectorSubType)
@@ -1169,10 +1382,14 @@ func (r *httpRoutes) UpdateConnector(ctx echo.Context) error {
 		TenantID:         req.TenantID,
 		ClientID:         req.ClientID,
 		ClientSecret:     req.ClientSecret,
+		SAML:             req.SAML,
+		LDAP:             req.LDAP,
+		GitHub:           req.GitHub,
 		ID:               req.ConnectorID,
+		Name:             req.Name,
 	}
 
-	dexreq, err := utils.UpdateOIDCConnector(dexRequest)
+	dexreq, err := utils.UpdateConnectorConfig(dexRequest)
 	if err != nil {
 		r.logger.Error("Error on Creating dex request", zap.Error(err))
 		return echo.NewHTTPError(http.StatusBadRequest, err)
@@ -1253,3 +1470,904 @@ func (r *httpRoutes) DeleteConnector(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusAccepted)
 }
+
+// IngestAuditEvents godoc
+//
+//	@Summary		Ingest audit events
+//	@Description	Stores the audit events recorded by the other services, it is not audited itself. Internal only, it is rejected at the gateway.
+//	@Security		BearerToken
+//	@Tags			audit
+//	@Accept			json
+//	@Param			request	body	audit.IngestEventsRequest	true	"Audit events"
+//	@Success		202
+//	@Router			/auth/api/v1/audit/events [post]
+func (r *httpRoutes) IngestAuditEvents(ctx echo.Context) error {
+	audit.Skip(ctx)
+
+	var req audit.IngestEventsRequest
+	if err := ctx.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	events := make([]db.AuditEvent, 0, len(req.Events))
+	for _, event := range req.Events {
+		if event.Service == "" || event.Method == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "audit event service and method are required")
+		}
+		dbEvent, err := db.NewAuditEvent(event)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid audit event")
+		}
+		events = append(events, dbEvent)
+	}
+
+	if err := r.db.CreateAuditEvents(events); err != nil {
+		r.logger.Error("failed to store audit events", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to store audit events")
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// ListAuditEvents godoc
+//
+//	@Summary		List audit events
+//	@Description	Lists the mutating API calls across all services, newest first.
+//	@Security		BearerToken
+//	@Tags			audit
+//	@Produce		json
+//	@Param			user_id		query		string	false	"User ID"
+//	@Param			api_key_id	query		string	false	"API key ID"
+//	@Param			service		query		string	false	"Service"
+//	@Param			method		query		string	false	"HTTP method"
+//	@Param			route		query		string	false	"Route contains"
+//	@Param			target_id	query		string	false	"Target ID, matches any route param or recorded target"
+//	@Param			outcome		query		string	false	"Outcome"	Enums(success,failure,denied)
+//	@Param			from		query		string	false	"From time (RFC3339)"
+//	@Param			to			query		string	false	"To time (RFC3339)"
+//	@Param			limit		query		int		false	"Limit (default 100, max 1000)"
+//	@Param			offset		query		int		false	"Offset"
+//	@Success		200			{object}	api.ListAuditEventsResponse
+//	@Router			/auth/api/v1/audit/events [get]
+func (r *httpRoutes) ListAuditEvents(ctx echo.Context) error {
+	filters := db.AuditEventFilters{
+		UserID:   ctx.QueryParam("user_id"),
+		APIKeyID: ctx.QueryParam("api_key_id"),
+		Service:  ctx.QueryParam("service"),
+		Method:   strings.ToUpper(ctx.QueryParam("method")),
+		Route:    ctx.QueryParam("route"),
+		TargetID: ctx.QueryParam("target_id"),
+		Outcome:  ctx.QueryParam("outcome"),
+	}
+	for param, target := range map[string]**time.Time{"from": &filters.From, "to": &filters.To} {
+		if v := ctx.QueryParam(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s time", param))
+			}
+			*target = &t
+		}
+	}
+
+	limit, offset := 100, 0
+	if v := ctx.QueryParam("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = min(l, 1000)
+	}
+	if v := ctx.QueryParam("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid offset")
+		}
+		offset = o
+	}
+
+	events, total, err := r.db.ListAuditEvents(filters, limit, offset)
+	if err != nil {
+		r.logger.Error("failed to list audit events", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list audit events")
+	}
+
+	items := make([]audit.Event, 0, len(events))
+	for _, event := range events {
+		items = append(items, event.ToAPI())
+	}
+	return ctx.JSON(http.StatusOK, api.ListAuditEventsResponse{
+		Items:      items,
+		TotalCount: total,
+	})
+}
+
+// ListRoleBindings godoc
+//
+//	@Summary		List role bindings
+//	@Description	Lists the role bindings that scope users and api keys to integration groups, integrations or frameworks
+//	@Security		BearerToken
+//	@Tags			role-bindings
+//	@Produce		json
+//	@Param			user_id		query		string	false	"User external ID"
+//	@Param			api_key_id	query		int		false	"API key ID"
+//	@Success		200			{array}		api.RoleBinding
+//	@Router			/auth/api/v1/role-bindings [get]
+func (r *httpRoutes) ListRoleBindings(ctx echo.Context) error {
+	var userID *string
+	if v := ctx.QueryParam("user_id"); v != "" {
+		userID = &v
+	}
+	var apiKeyID *uint
+	if v := ctx.QueryParam("api_key_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid api key id")
+		}
+		keyID := uint(id)
+		apiKeyID = &keyID
+	}
+
+	bindings, err := r.db.ListRoleBindings(userID, apiKeyID)
+	if err != nil {
+		r.logger.Error("failed to list role bindings", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list role bindings")
+	}
+
+	resp := make([]api.RoleBinding, 0, len(bindings))
+	for _, binding := range bindings {
+		resp = append(resp, roleBindingToAPI(binding))
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateRoleBinding godoc
+//
+//	@Summary		Create role binding
+//	@Description	Binds a user or an api key to a role on an integration group, integration or framework.
+//	@Description	Once a user or key has a binding it only sees what its bindings cover, with the highest role among them capped at its global role.
+//	@Security		BearerToken
+//	@Tags			role-bindings
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateRoleBindingRequest	true	"Request Body"
+//	@Success		201		{object}	api.RoleBinding
+//	@Router			/auth/api/v1/role-bindings [post]
+func (r *httpRoutes) CreateRoleBinding(ctx echo.Context) error {
+	var req api.CreateRoleBindingRequest
+	if err := bindValidate(ctx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if (req.UserID == "") == (req.APIKeyID == nil) {
+		return echo.NewHTTPError(http.StatusBadRequest, "exactly one of user_id and api_key_id is required")
+	}
+	switch req.Role {
+	case api2.ViewerRole, api2.EditorRole, api2.AdminRole:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid role")
+	}
+	if !req.ScopeType.IsValid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid scope type")
+	}
+	req.ScopeID = strings.TrimSpace(req.ScopeID)
+	if req.ScopeID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "scope id is required")
+	}
+
+	if req.UserID != "" {
+		user, err := r.db.GetUserByExternalID(req.UserID)
+		if err != nil {
+			r.logger.Error("failed to get user", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user")
+		}
+		if user == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "user not found")
+		}
+	} else {
+		key, err := r.db.GetApiKey(*req.APIKeyID)
+		if err != nil {
+			r.logger.Error("failed to get api key", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get api key")
+		}
+		if key == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "api key not found")
+		}
+	}
+
+	binding := db.RoleBinding{
+		UserID:    req.UserID,
+		APIKeyID:  req.APIKeyID,
+		Role:      req.Role,
+		ScopeType: string(req.ScopeType),
+		ScopeID:   req.ScopeID,
+		CreatedBy: httpserver.GetUserID(ctx),
+	}
+	if err := r.db.CreateRoleBinding(&binding); err != nil {
+		r.logger.Error("failed to create role binding", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create role binding")
+	}
+	r.authServer.scopes.invalidate()
+
+	resp := roleBindingToAPI(binding)
+	audit.SetAfter(ctx, resp)
+	return ctx.JSON(http.StatusCreated, resp)
+}
+
+// DeleteRoleBinding godoc
+//
+//	@Summary		Delete role binding
+//	@Security		BearerToken
+//	@Tags			role-bindings
+//	@Param			id	path	int	true	"Role binding ID"
+//	@Success		202
+//	@Router			/auth/api/v1/role-bindings/{id} [delete]
+func (r *httpRoutes) DeleteRoleBinding(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+
+	binding, err := r.db.GetRoleBinding(uint(id))
+	if err != nil {
+		r.logger.Error("failed to get role binding", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get role binding")
+	}
+	if binding == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "role binding not found")
+	}
+	audit.SetBefore(ctx, roleBindingToAPI(*binding))
+
+	if err := r.db.DeleteRoleBinding(binding.ID); err != nil {
+		r.logger.Error("failed to delete role binding", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete role binding")
+	}
+	r.authServer.scopes.invalidate()
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func roleBindingToAPI(binding db.RoleBinding) api.RoleBinding {
+	return api.RoleBinding{
+		ID:        binding.ID,
+		UserID:    binding.UserID,
+		APIKeyID:  binding.APIKeyID,
+		Role:      binding.Role,
+		ScopeType: api.RoleBindingScopeType(binding.ScopeType),
+		ScopeID:   binding.ScopeID,
+		CreatedBy: binding.CreatedBy,
+		CreatedAt: binding.CreatedAt,
+	}
+}
+
+// ListGroupRoleMappings godoc
+//
+//	@Summary	List group role mappings
+//	@Security	BearerToken
+//	@Tags		group-role-mappings
+//	@Produce	json
+//	@Success	200	{array}	api.GroupRoleMapping
+//	@Router		/auth/api/v1/group-role-mappings [get]
+func (r *httpRoutes) ListGroupRoleMappings(ctx echo.Context) error {
+	mappings, err := r.db.ListGroupRoleMappings()
+	if err != nil {
+		r.logger.Error("failed to list group role mappings", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list group role mappings")
+	}
+
+	resp := make([]api.GroupRoleMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		resp = append(resp, groupRoleMappingToAPI(mapping))
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateGroupRoleMapping godoc
+//
+//	@Summary		Create group role mapping
+//	@Description	Maps a group claim of the identity provider to a role. Once a mapping applies to a connector, its users
+//	@Description	carrying a mapped group are created on their first login and get the highest mapped role on every login.
+//	@Description	Users created this way are deactivated when none of their groups is mapped anymore, users created by hand keep their role.
+//	@Security		BearerToken
+//	@Tags			group-role-mappings
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateGroupRoleMappingRequest	true	"Request Body"
+//	@Success		201		{object}	api.GroupRoleMapping
+//	@Router			/auth/api/v1/group-role-mappings [post]
+func (r *httpRoutes) CreateGroupRoleMapping(ctx echo.Context) error {
+	var req api.CreateGroupRoleMappingRequest
+	if err := bindValidate(ctx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	switch req.Role {
+	case api2.ViewerRole, api2.EditorRole, api2.AdminRole:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid role")
+	}
+	req.Group = strings.TrimSpace(req.Group)
+	if req.Group == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "group is required")
+	}
+	req.ConnectorID = strings.TrimSpace(req.ConnectorID)
+	if req.ConnectorID != "" {
+		connector, err := r.db.GetConnectorByConnectorID(req.ConnectorID)
+		if err != nil {
+			r.logger.Error("failed to get connector", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get connector")
+		}
+		if connector == nil || connector.ID == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "connector not found")
+		}
+	}
+
+	mapping := db.GroupRoleMapping{
+		ConnectorID: req.ConnectorID,
+		Group:       req.Group,
+		Role:        req.Role,
+		CreatedBy:   httpserver.GetUserID(ctx),
+	}
+	if err := r.db.CreateGroupRoleMapping(&mapping); err != nil {
+		r.logger.Error("failed to create group role mapping", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create group role mapping")
+	}
+	r.authServer.groupRoles.invalidate()
+
+	resp := groupRoleMappingToAPI(mapping)
+	audit.SetAfter(ctx, resp)
+	return ctx.JSON(http.StatusCreated, resp)
+}
+
+// DeleteGroupRoleMapping godoc
+//
+//	@Summary		Delete group role mapping
+//	@Description	The users that got their role from the mapping are demoted or deactivated on their next request
+//	@Security		BearerToken
+//	@Tags			group-role-mappings
+//	@Param			id	path	int	true	"Group role mapping ID"
+//	@Success		202
+//	@Router			/auth/api/v1/group-role-mappings/{id} [delete]
+func (r *httpRoutes) DeleteGroupRoleMapping(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+
+	mapping, err := r.db.GetGroupRoleMapping(uint(id))
+	if err != nil {
+		r.logger.Error("failed to get group role mapping", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get group role mapping")
+	}
+	if mapping == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "group role mapping not found")
+	}
+	audit.SetBefore(ctx, groupRoleMappingToAPI(*mapping))
+
+	if err := r.db.DeleteGroupRoleMapping(mapping.ID); err != nil {
+		r.logger.Error("failed to delete group role mapping", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete group role mapping")
+	}
+	r.authServer.groupRoles.invalidate()
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func groupRoleMappingToAPI(mapping db.GroupRoleMapping) api.GroupRoleMapping {
+	return api.GroupRoleMapping{
+		ID:          mapping.ID,
+		ConnectorID: mapping.ConnectorID,
+		Group:       mapping.Group,
+		Role:        mapping.Role,
+		CreatedBy:   mapping.CreatedBy,
+		CreatedAt:   mapping.CreatedAt,
+	}
+}
+
+func userSessionToAPI(session db.UserSession) api.UserSession {
+	return api.UserSession{
+		ID:          session.TokenID,
+		ConnectorID: session.ConnectorID,
+		SourceIP:    session.SourceIP,
+		UserAgent:   session.UserAgent,
+		IssuedAt:    session.IssuedAt,
+		ExpiresAt:   session.ExpiresAt,
+		LastSeenAt:  session.LastSeenAt,
+	}
+}
+
+func (r *httpRoutes) listUserSessions(ctx echo.Context, userExternalID string) error {
+	sessions, err := r.db.ListActiveUserSessions(userExternalID, time.Now())
+	if err != nil {
+		r.logger.Error("failed to list user sessions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list user sessions")
+	}
+	resp := make([]api.UserSession, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, userSessionToAPI(session))
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// revokeDexRefreshTokens revokes the refresh tokens dex issued to the subjects, so the revoked sessions can not get
+// new tokens, and returns the number of revoked refresh tokens
+func revokeDexRefreshTokens(ctx context.Context, subjects []string) (int, error) {
+	if len(subjects) == 0 {
+		return 0, nil
+	}
+	dexClient, err := newDexClient(dexGrpcAddress)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create dex client: %w", err)
+	}
+	revoked := 0
+	for _, subject := range subjects {
+		refresh, err := dexClient.ListRefresh(ctx, &dexApi.ListRefreshReq{UserId: subject})
+		if err != nil {
+			return revoked, fmt.Errorf("failed to list dex refresh tokens: %w", err)
+		}
+		for _, token := range refresh.GetRefreshTokens() {
+			resp, err := dexClient.RevokeRefresh(ctx, &dexApi.RevokeRefreshReq{UserId: subject, ClientId: token.GetClientId()})
+			if err != nil {
+				return revoked, fmt.Errorf("failed to revoke dex refresh token: %w", err)
+			}
+			if !resp.GetNotFound() {
+				revoked++
+			}
+		}
+	}
+	return revoked, nil
+}
+
+func (r *httpRoutes) revokeUserSession(ctx echo.Context, userExternalID string) error {
+	var req api.RevokeSessionRequest
+	if ctx.Request().ContentLength > 0 {
+		if err := bindValidate(ctx, &req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	session, err := r.db.GetUserSession(ctx.Param("sessionId"))
+	if err != nil {
+		r.logger.Error("failed to get user session", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user session")
+	}
+	if session == nil || session.UserExternalID != userExternalID {
+		return echo.NewHTTPError(http.StatusNotFound, "session not found")
+	}
+	if session.RevokedAt != nil {
+		return ctx.NoContent(http.StatusAccepted)
+	}
+
+	revocation := db.SessionRevocation{
+		TokenID:        session.TokenID,
+		UserExternalID: session.UserExternalID,
+		RevokedBefore:  time.Now(),
+		ExpiresAt:      session.ExpiresAt,
+		Reason:         req.Reason,
+		RevokedBy:      httpserver.GetUserID(ctx),
+	}
+	if err := r.db.RevokeUserSession(session, &revocation); err != nil {
+		r.logger.Error("failed to revoke user session", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke user session")
+	}
+	r.authServer.revocations.invalidate()
+	if session.DexSubject != "" {
+		if _, err := revokeDexRefreshTokens(ctx.Request().Context(), []string{session.DexSubject}); err != nil {
+			r.logger.Error("failed to revoke dex refresh tokens", zap.String("userId", userExternalID), zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke dex refresh tokens")
+		}
+	}
+	r.logger.Info("user session revoked", zap.String("userId", userExternalID), zap.String("sessionId", session.TokenID))
+
+	audit.SetTarget(ctx, "user_id", userExternalID)
+	audit.SetBefore(ctx, userSessionToAPI(*session))
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// ListUserSessions godoc
+//
+//	@Summary		List user sessions
+//	@Description	Returns the active sessions of the user, the dex tokens seen by the platform that are neither expired nor revoked.
+//	@Security		BearerToken
+//	@Tags			sessions
+//	@Produce		json
+//	@Param			id	path	string	true	"User ID"
+//	@Success		200	{array}	api.UserSession
+//	@Router			/auth/api/v1/user/{id}/sessions [get]
+func (r *httpRoutes) ListUserSessions(ctx echo.Context) error {
+	user, err := r.db.GetUser(ctx.Param("id"))
+	if err != nil || user == nil || user.ID == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+	return r.listUserSessions(ctx, user.ExternalId)
+}
+
+// RevokeUserSession godoc
+//
+//	@Summary		Revoke user session
+//	@Description	Revokes a single session of the user, its token is refused from then on. Dex keeps a refresh token per user and client, revoking it makes the other sessions of the same client log in again once their token expires.
+//	@Security		BearerToken
+//	@Tags			sessions
+//	@Accept			json
+//	@Param			id			path	string						true	"User ID"
+//	@Param			sessionId	path	string						true	"Session ID"
+//	@Param			request		body	api.RevokeSessionRequest	false	"Request Body"
+//	@Success		202
+//	@Router			/auth/api/v1/user/{id}/sessions/{sessionId} [delete]
+func (r *httpRoutes) RevokeUserSession(ctx echo.Context) error {
+	user, err := r.db.GetUser(ctx.Param("id"))
+	if err != nil || user == nil || user.ID == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+	return r.revokeUserSession(ctx, user.ExternalId)
+}
+
+// ListMySessions godoc
+//
+//	@Summary	List my sessions
+//	@Security	BearerToken
+//	@Tags		sessions
+//	@Produce	json
+//	@Success	200	{array}	api.UserSession
+//	@Router		/auth/api/v1/me/sessions [get]
+func (r *httpRoutes) ListMySessions(ctx echo.Context) error {
+	return r.listUserSessions(ctx, httpserver.GetUserID(ctx))
+}
+
+// RevokeMySession godoc
+//
+//	@Summary	Revoke my session
+//	@Security	BearerToken
+//	@Tags		sessions
+//	@Param		sessionId	path	string	true	"Session ID"
+//	@Success	202
+//	@Router		/auth/api/v1/me/sessions/{sessionId} [delete]
+func (r *httpRoutes) RevokeMySession(ctx echo.Context) error {
+	return r.revokeUserSession(ctx, httpserver.GetUserID(ctx))
+}
+
+// LogoutEverywhere godoc
+//
+//	@Summary		Log out everywhere
+//	@Description	Revokes every token issued to the user so far along with the dex refresh tokens and deactivates the user, keep_active leaves the user able to log in again. Optionally deactivates the api keys the user created.
+//	@Security		BearerToken
+//	@Tags			sessions
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"User ID"
+//	@Param			request	body		api.LogoutEverywhereRequest	false	"Request Body"
+//	@Success		200		{object}	api.LogoutEverywhereResponse
+//	@Router			/auth/api/v1/user/{id}/logout-everywhere [post]
+func (r *httpRoutes) LogoutEverywhere(ctx echo.Context) error {
+	var req api.LogoutEverywhereRequest
+	if ctx.Request().ContentLength > 0 {
+		if err := bindValidate(ctx, &req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	user, err := r.db.GetUser(ctx.Param("id"))
+	if err != nil || user == nil || user.ID == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	now := time.Now()
+	revocation := db.SessionRevocation{
+		UserExternalID: user.ExternalId,
+		RevokedBefore:  now,
+		ExpiresAt:      now.Add(userRevocationRetention),
+		Reason:         req.Reason,
+		RevokedBy:      httpserver.GetUserID(ctx),
+	}
+	var resp api.LogoutEverywhereResponse
+	resp.RevokedSessions, err = r.db.RevokeAllUserSessions(&revocation)
+	if err != nil {
+		r.logger.Error("failed to revoke user sessions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke user sessions")
+	}
+	r.authServer.revocations.invalidate()
+
+	subjects, err := r.db.ListDexSubjectsOfUser(user.ExternalId)
+	if err != nil {
+		r.logger.Error("failed to list user dex subjects", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke dex refresh tokens")
+	}
+	resp.RevokedRefreshTokens, err = revokeDexRefreshTokens(ctx.Request().Context(), subjects)
+	if err != nil {
+		r.logger.Error("failed to revoke dex refresh tokens", zap.String("userId", user.ExternalId), zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to revoke dex refresh tokens")
+	}
+	if !req.KeepActive && user.IsActive {
+		if err := r.db.DeactivateUser(user.ID); err != nil {
+			r.logger.Error("failed to deactivate user", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to deactivate user")
+		}
+		r.authServer.groupRoles.invalidate()
+		resp.UserDeactivated = true
+	}
+	if req.RevokeAPIKeys {
+		resp.DeactivatedAPIKeys, err = r.db.DeactivateApiKeysOfUser(user.ExternalId)
+		if err != nil {
+			r.logger.Error("failed to deactivate user api keys", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to deactivate user api keys")
+		}
+	}
+	r.logger.Info("user logged out everywhere", zap.String("userId", user.ExternalId),
+		zap.Int64("revokedSessions", resp.RevokedSessions), zap.Int("revokedRefreshTokens", resp.RevokedRefreshTokens),
+		zap.Int64("deactivatedApiKeys", resp.DeactivatedAPIKeys), zap.Bool("userDeactivated", resp.UserDeactivated))
+
+	audit.SetAfter(ctx, resp)
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func rateLimitPolicyToAPI(policy db.RateLimitPolicy) api.RateLimitPolicy {
+	return api.RateLimitPolicy{
+		ID:                policy.ID,
+		SubjectType:       policy.SubjectType,
+		Subject:           policy.Subject,
+		RouteClass:        policy.RouteClass,
+		RequestsPerMinute: policy.RequestsPerMinute,
+		Burst:             policy.Burst,
+		CreatedBy:         policy.CreatedBy,
+		CreatedAt:         policy.CreatedAt,
+	}
+}
+
+// ListRateLimitRouteClasses godoc
+//
+//	@Summary		List rate limit route classes
+//	@Description	Returns the route classes a rate limit policy can be limited to, default is every other route
+//	@Security		BearerToken
+//	@Tags			rate-limits
+//	@Produce		json
+//	@Success		200	{array}	api.RateLimitRouteClass
+//	@Router			/auth/api/v1/rate-limits/route-classes [get]
+func (r *httpRoutes) ListRateLimitRouteClasses(ctx echo.Context) error {
+	names := rateLimitRouteClassNames()
+	resp := make([]api.RateLimitRouteClass, 0, len(names))
+	for _, name := range names {
+		resp = append(resp, api.RateLimitRouteClass{Name: name, Routes: rateLimitRouteClasses[name]})
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// ListRateLimitPolicies godoc
+//
+//	@Summary	List rate limit policies
+//	@Security	BearerToken
+//	@Tags		rate-limits
+//	@Produce	json
+//	@Success	200	{array}	api.RateLimitPolicy
+//	@Router		/auth/api/v1/rate-limits [get]
+func (r *httpRoutes) ListRateLimitPolicies(ctx echo.Context) error {
+	policies, err := r.db.ListRateLimitPolicies()
+	if err != nil {
+		r.logger.Error("failed to list rate limit policies", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list rate limit policies")
+	}
+	resp := make([]api.RateLimitPolicy, 0, len(policies))
+	for _, policy := range policies {
+		resp = append(resp, rateLimitPolicyToAPI(policy))
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateRateLimitPolicy godoc
+//
+//	@Summary		Create rate limit policy
+//	@Description	Limits every caller of a role, a user or an api key with a token bucket, the most specific policy applies: api key over user over role, a route class over every route class. The limit applies per auth replica, a caller served by n replicas gets up to n times the limit.
+//	@Security		BearerToken
+//	@Tags			rate-limits
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateRateLimitPolicyRequest	true	"Request Body"
+//	@Success		201		{object}	api.RateLimitPolicy
+//	@Router			/auth/api/v1/rate-limits [post]
+func (r *httpRoutes) CreateRateLimitPolicy(ctx echo.Context) error {
+	var req api.CreateRateLimitPolicyRequest
+	if err := bindValidate(ctx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	req.Subject = strings.TrimSpace(req.Subject)
+	switch req.SubjectType {
+	case rateLimitSubjectRole:
+		switch api2.Role(req.Subject) {
+		case api2.ViewerRole, api2.EditorRole, api2.AdminRole:
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid role")
+		}
+	case rateLimitSubjectUser:
+		user, err := r.db.GetUserByExternalID(req.Subject)
+		if err != nil {
+			r.logger.Error("failed to get user", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user")
+		}
+		if user == nil || user.ID == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "user not found")
+		}
+	case rateLimitSubjectAPIKey:
+		id, err := strconv.ParseUint(req.Subject, 10, 32)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "api key subject should be the api key id")
+		}
+		key, err := r.db.GetApiKey(uint(id))
+		if err != nil {
+			r.logger.Error("failed to get api key", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get api key")
+		}
+		if key == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "api key not found")
+		}
+	}
+	if req.RouteClass != "" && !isRateLimitRouteClass(req.RouteClass) {
+		return echo.NewHTTPError(http.StatusBadRequest, "unknown route class")
+	}
+
+	existing, err := r.db.GetRateLimitPolicyBySubject(req.SubjectType, req.Subject, req.RouteClass)
+	if err != nil {
+		r.logger.Error("failed to get rate limit policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get rate limit policy")
+	}
+	if existing != nil {
+		return echo.NewHTTPError(http.StatusConflict, "a policy for this subject and route class already exists")
+	}
+
+	policy := db.RateLimitPolicy{
+		SubjectType:       req.SubjectType,
+		Subject:           req.Subject,
+		RouteClass:        req.RouteClass,
+		RequestsPerMinute: req.RequestsPerMinute,
+		Burst:             req.Burst,
+		CreatedBy:         httpserver.GetUserID(ctx),
+	}
+	if err := r.db.CreateRateLimitPolicy(&policy); err != nil {
+		r.logger.Error("failed to create rate limit policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create rate limit policy")
+	}
+	r.authServer.rateLimits.invalidate()
+
+	resp := rateLimitPolicyToAPI(policy)
+	audit.SetAfter(ctx, resp)
+	return ctx.JSON(http.StatusCreated, resp)
+}
+
+// UpdateRateLimitPolicy godoc
+//
+//	@Summary	Update rate limit policy
+//	@Security	BearerToken
+//	@Tags		rate-limits
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path		int									true	"Rate limit policy ID"
+//	@Param		request	body		api.UpdateRateLimitPolicyRequest	true	"Request Body"
+//	@Success	200		{object}	api.RateLimitPolicy
+//	@Router		/auth/api/v1/rate-limits/{id} [put]
+func (r *httpRoutes) UpdateRateLimitPolicy(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	var req api.UpdateRateLimitPolicyRequest
+	if err := bindValidate(ctx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	policy, err := r.db.GetRateLimitPolicy(uint(id))
+	if err != nil {
+		r.logger.Error("failed to get rate limit policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get rate limit policy")
+	}
+	if policy == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "rate limit policy not found")
+	}
+	audit.SetBefore(ctx, rateLimitPolicyToAPI(*policy))
+
+	policy.RequestsPerMinute, policy.Burst = req.RequestsPerMinute, req.Burst
+	if err := r.db.UpdateRateLimitPolicy(policy); err != nil {
+		r.logger.Error("failed to update rate limit policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update rate limit policy")
+	}
+	r.authServer.rateLimits.invalidate()
+
+	resp := rateLimitPolicyToAPI(*policy)
+	audit.SetAfter(ctx, resp)
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteRateLimitPolicy godoc
+//
+//	@Summary	Delete rate limit policy
+//	@Security	BearerToken
+//	@Tags		rate-limits
+//	@Param		id	path	int	true	"Rate limit policy ID"
+//	@Success	202
+//	@Router		/auth/api/v1/rate-limits/{id} [delete]
+func (r *httpRoutes) DeleteRateLimitPolicy(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	deleted, err := r.db.DeleteRateLimitPolicy(uint(id))
+	if err != nil {
+		r.logger.Error("failed to delete rate limit policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete rate limit policy")
+	}
+	if !deleted {
+		return echo.NewHTTPError(http.StatusNotFound, "rate limit policy not found")
+	}
+	r.authServer.rateLimits.invalidate()
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func (r *httpRoutes) rateLimitUsage(ctx echo.Context, caller rateLimitCaller) error {
+	usage, err := r.authServer.rateLimits.usage(caller)
+	if err != nil {
+		r.logger.Error("failed to get rate limit usage", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get rate limit usage")
+	}
+	if usage == nil {
+		usage = []api.RateLimitUsage{}
+	}
+	return ctx.JSON(http.StatusOK, api.RateLimitUsageResponse{Caller: caller.key(), Usage: usage})
+}
+
+// GetRateLimitUsage godoc
+//
+//	@Summary		Get rate limit usage
+//	@Description	Returns the remaining requests of a user or an api key per route class, as counted by this replica
+//	@Security		BearerToken
+//	@Tags			rate-limits
+//	@Produce		json
+//	@Param			user_id		query		string	false	"User ID"
+//	@Param			api_key_id	query		int		false	"API key ID"
+//	@Success		200			{object}	api.RateLimitUsageResponse
+//	@Router			/auth/api/v1/rate-limits/usage [get]
+func (r *httpRoutes) GetRateLimitUsage(ctx echo.Context) error {
+	var caller rateLimitCaller
+	userID := ctx.QueryParam("user_id")
+	if apiKeyID := ctx.QueryParam("api_key_id"); apiKeyID != "" {
+		id, err := strconv.ParseUint(apiKeyID, 10, 32)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid api_key_id")
+		}
+		key, err := r.db.GetApiKey(uint(id))
+		if err != nil {
+			r.logger.Error("failed to get api key", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get api key")
+		}
+		if key == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "api key not found")
+		}
+		caller.apiKeyID = apiKeyID
+		// api key requests are checked as their creator
+		userID = key.CreatorUserID
+	} else if userID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "user_id or api_key_id is required")
+	}
+
+	user, err := r.db.GetUserByExternalID(userID)
+	if err != nil {
+		r.logger.Error("failed to get user", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user")
+	}
+	if user == nil || user.ID == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+	caller.userID, caller.role = user.ExternalId, user.Role
+	return r.rateLimitUsage(ctx, caller)
+}
+
+// GetMyRateLimitUsage godoc
+//
+//	@Summary	Get my rate limit usage
+//	@Security	BearerToken
+//	@Tags		rate-limits
+//	@Produce	json
+//	@Success	200	{object}	api.RateLimitUsageResponse
+//	@Router		/auth/api/v1/me/rate-limits [get]
+func (r *httpRoutes) GetMyRateLimitUsage(ctx echo.Context) error {
+	return r.rateLimitUsage(ctx, rateLimitCaller{
+		userID:   httpserver.GetUserID(ctx),
+		apiKeyID: ctx.Request().Header.Get(audit.XPlatformAPIKeyIDHeader),
+		role:     httpserver.GetUserRole(ctx),
+	})
+}
@@ -0,0 +1,318 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	envoycore "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoyauth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/gogo/googleapis/google/rpc"
+	"github.com/labstack/echo/v4"
+	api2 "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/opensecurity/services/auth/api"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"google.golang.org/genproto/googleapis/rpc/status"
+)
+
+const (
+	rateLimitSubjectRole   = "role"
+	rateLimitSubjectUser   = "user"
+	rateLimitSubjectAPIKey = "api_key"
+
+	// defaultRouteClass is the class of the routes not listed in rateLimitRouteClasses
+	defaultRouteClass = "default"
+
+	// rateLimitCacheTTL bounds how long policy changes made through another replica take to apply
+	rateLimitCacheTTL = time.Minute
+	// maxRateLimitBuckets bounds the buckets kept in memory, full ones are dropped first and then the least recently used
+	maxRateLimitBuckets = 10000
+)
+
+// rateLimitRouteClasses are the expensive routes that can be limited apart from the rest, in the api key scope format
+var rateLimitRouteClasses = map[string][]string{
+	"query": {
+		"POST /core/api/v1/query/run",
+		"POST /core/api/v3/query/run",
+	},
+	"compliance-results": {
+		"* /compliance/api/v1/compliance_result*",
+		"POST /compliance/api/v1/resource_findings",
+	},
+}
+
+type rateLimitRoute struct {
+	class string
+	route apiKeyRoute
+}
+
+// rateLimitRoutes are the routes of rateLimitRouteClasses parsed once, in the order of the class names
+var rateLimitRoutes = parseRateLimitRoutes()
+
+func parseRateLimitRoutes() []rateLimitRoute {
+	var routes []rateLimitRoute
+	for _, name := range rateLimitRouteClassNames() {
+		for _, scope := range rateLimitRouteClasses[name] {
+			route, err := parseAPIKeyRoute(scope)
+			if err != nil {
+				panic(fmt.Sprintf("invalid route of rate limit route class %s: %v", name, err))
+			}
+			routes = append(routes, rateLimitRoute{class: name, route: route})
+		}
+	}
+	return routes
+}
+
+func rateLimitRouteClassNames() []string {
+	names := make([]string, 0, len(rateLimitRouteClasses)+1)
+	for name := range rateLimitRouteClasses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append(names, defaultRouteClass)
+}
+
+func isRateLimitRouteClass(name string) bool {
+	_, ok := rateLimitRouteClasses[name]
+	return ok || name == defaultRouteClass
+}
+
+func rateLimitRouteClass(method, path string) string {
+	if idx := strings.IndexByte(path, '?'); idx >= 0 {
+		path = path[:idx]
+	}
+	for _, r := range rateLimitRoutes {
+		if r.route.matches(method, path) {
+			return r.class
+		}
+	}
+	return defaultRouteClass
+}
+
+// rateLimitCaller is who the requests are counted for, the api key when one is used and the user otherwise
+type rateLimitCaller struct {
+	userID   string
+	apiKeyID string
+	role     api2.Role
+}
+
+func (c rateLimitCaller) key() string {
+	if c.apiKeyID != "" {
+		return rateLimitSubjectAPIKey + ":" + c.apiKeyID
+	}
+	return rateLimitSubjectUser + ":" + c.userID
+}
+
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time // last refill, buckets are refilled on every use
+	rate     float64   // tokens per second
+	capacity float64
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// fullAt returns when the bucket is refilled to its capacity
+func (b *tokenBucket) fullAt() time.Time {
+	return b.last.Add(time.Duration((b.capacity - b.tokens) / b.rate * float64(time.Second)))
+}
+
+// rateLimiter enforces the rate limit policies with a token bucket per caller and policy. The buckets live in the
+// memory of each replica, a caller whose requests are spread over n replicas gets up to n times the policy limit.
+type rateLimiter struct {
+	db db.Database
+
+	mu        sync.Mutex
+	policies  []db.RateLimitPolicy
+	expiresAt time.Time
+	buckets   map[string]*tokenBucket
+}
+
+func newRateLimiter(adb db.Database) *rateLimiter {
+	return &rateLimiter{
+		db:      adb,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (l *rateLimiter) invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expiresAt = time.Time{}
+}
+
+// loadPolicies must be called with the lock held
+func (l *rateLimiter) loadPolicies(now time.Time) error {
+	if now.Before(l.expiresAt) {
+		return nil
+	}
+	policies, err := l.db.ListRateLimitPolicies()
+	if err != nil {
+		return err
+	}
+	l.policies = policies
+	l.expiresAt = now.Add(rateLimitCacheTTL)
+	return nil
+}
+
+// policyFor returns the most specific policy of the caller for the route class: api key over user over role,
+// and a policy of the class over one of every class. It must be called with the lock held.
+func (l *rateLimiter) policyFor(caller rateLimitCaller, routeClass string) *db.RateLimitPolicy {
+	var best *db.RateLimitPolicy
+	bestScore := 0
+	for i, policy := range l.policies {
+		if policy.RouteClass != "" && policy.RouteClass != routeClass {
+			continue
+		}
+		score := 0
+		switch {
+		case policy.SubjectType == rateLimitSubjectAPIKey && caller.apiKeyID != "" && policy.Subject == caller.apiKeyID:
+			score = 6
+		case policy.SubjectType == rateLimitSubjectUser && policy.Subject == caller.userID:
+			score = 4
+		case policy.SubjectType == rateLimitSubjectRole && policy.Subject == string(caller.role):
+			score = 2
+		default:
+			continue
+		}
+		if policy.RouteClass != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = &l.policies[i], score
+		}
+	}
+	return best
+}
+
+// bucket returns the refilled bucket of the caller for the policy. It must be called with the lock held.
+func (l *rateLimiter) bucket(caller rateLimitCaller, policy *db.RateLimitPolicy, now time.Time) *tokenBucket {
+	key := caller.key() + "|" + strconv.FormatUint(uint64(policy.ID), 10)
+	capacity := float64(policy.Burst)
+	if capacity <= 0 {
+		capacity = float64(policy.RequestsPerMinute)
+	}
+	rate := float64(policy.RequestsPerMinute) / 60
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitBuckets {
+			l.pruneBuckets(now)
+		}
+		b = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+	// policy updates apply to the existing buckets
+	b.rate, b.capacity = rate, capacity
+	b.refill(now)
+	return b
+}
+
+// pruneBuckets drops the full buckets, which would be created again as they are, and then the least recently used
+// ones until a tenth of maxRateLimitBuckets is free. It must be called with the lock held.
+func (l *rateLimiter) pruneBuckets(now time.Time) {
+	for key, b := range l.buckets {
+		if !b.fullAt().After(now) {
+			delete(l.buckets, key)
+		}
+	}
+	if len(l.buckets) < maxRateLimitBuckets {
+		return
+	}
+
+	keys := make([]string, 0, len(l.buckets))
+	for key := range l.buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return l.buckets[keys[i]].last.Before(l.buckets[keys[j]].last)
+	})
+	for _, key := range keys[:len(keys)-maxRateLimitBuckets*9/10] {
+		delete(l.buckets, key)
+	}
+}
+
+// allow takes a token of the caller for the route, it returns how long to wait when there is none left.
+// Callers without a policy for the route class are not limited.
+func (l *rateLimiter) allow(caller rateLimitCaller, method, path string) (bool, time.Duration, *db.RateLimitPolicy, error) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.loadPolicies(now); err != nil {
+		return false, 0, nil, err
+	}
+	policy := l.policyFor(caller, rateLimitRouteClass(method, path))
+	if policy == nil {
+		return true, 0, nil, nil
+	}
+
+	b := l.bucket(caller, policy, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, policy, nil
+	}
+	retryAfter := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, retryAfter, policy, nil
+}
+
+// usage returns the state of the buckets of the caller per route class without taking tokens
+func (l *rateLimiter) usage(caller rateLimitCaller) ([]api.RateLimitUsage, error) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.loadPolicies(now); err != nil {
+		return nil, err
+	}
+
+	var res []api.RateLimitUsage
+	for _, routeClass := range rateLimitRouteClassNames() {
+		policy := l.policyFor(caller, routeClass)
+		if policy == nil {
+			continue
+		}
+		b := l.bucket(caller, policy, now)
+		res = append(res, api.RateLimitUsage{
+			RouteClass:        routeClass,
+			PolicyID:          policy.ID,
+			SubjectType:       policy.SubjectType,
+			Subject:           policy.Subject,
+			RequestsPerMinute: policy.RequestsPerMinute,
+			Burst:             int(b.capacity),
+			Remaining:         int(b.tokens),
+			FullAt:            b.fullAt(),
+		})
+	}
+	return res, nil
+}
+
+// rateLimitedResponse denies the request with a 429 telling the caller when to retry
+func rateLimitedResponse(retryAfter time.Duration, policy *db.RateLimitPolicy) *envoyauth.CheckResponse {
+	header := func(key, value string) *envoycore.HeaderValueOption {
+		return &envoycore.HeaderValueOption{Header: &envoycore.HeaderValue{Key: key, Value: value}}
+	}
+	return &envoyauth.CheckResponse{
+		Status: &status.Status{
+			Code: int32(rpc.RESOURCE_EXHAUSTED),
+		},
+		HttpResponse: &envoyauth.CheckResponse_DeniedResponse{
+			DeniedResponse: &envoyauth.DeniedHttpResponse{
+				Status: &envoytype.HttpStatus{Code: http.StatusTooManyRequests},
+				Headers: []*envoycore.HeaderValueOption{
+					header(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))),
+					header("X-RateLimit-Limit", strconv.Itoa(policy.RequestsPerMinute)),
+					header("X-RateLimit-Remaining", "0"),
+				},
+				Body: "rate limit exceeded",
+			},
+		},
+	}
+}
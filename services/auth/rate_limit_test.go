@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	api2 "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"gorm.io/gorm"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		bucket     tokenBucket
+		after      time.Duration
+		wantTokens float64
+		wantFullAt time.Time
+	}{
+		{
+			name:       "refills at the rate",
+			bucket:     tokenBucket{tokens: 0, last: start, rate: 1, capacity: 10},
+			after:      4 * time.Second,
+			wantTokens: 4,
+			wantFullAt: start.Add(10 * time.Second),
+		},
+		{
+			name:       "stops at the capacity",
+			bucket:     tokenBucket{tokens: 5, last: start, rate: 1, capacity: 10},
+			after:      time.Minute,
+			wantTokens: 10,
+			wantFullAt: start.Add(time.Minute),
+		},
+		{
+			name:       "partial token",
+			bucket:     tokenBucket{tokens: 0.5, last: start, rate: 0.5, capacity: 2},
+			after:      time.Second,
+			wantTokens: 1,
+			wantFullAt: start.Add(3 * time.Second),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := tc.bucket
+			b.refill(start.Add(tc.after))
+			if math.Abs(b.tokens-tc.wantTokens) > 1e-9 {
+				t.Errorf("refill() tokens = %v, want %v", b.tokens, tc.wantTokens)
+			}
+			if got := b.fullAt(); !got.Equal(tc.wantFullAt) {
+				t.Errorf("fullAt() = %s, want %s", got, tc.wantFullAt)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := newRateLimiter(db.Database{})
+	limiter.policies = []db.RateLimitPolicy{
+		{Model: gorm.Model{ID: 1}, SubjectType: rateLimitSubjectRole, Subject: string(api2.ViewerRole), RequestsPerMinute: 60, Burst: 2},
+		{Model: gorm.Model{ID: 2}, SubjectType: rateLimitSubjectUser, Subject: "jane", RouteClass: "query", RequestsPerMinute: 6},
+	}
+	limiter.expiresAt = time.Now().Add(time.Hour)
+	viewer := rateLimitCaller{userID: "john", role: api2.ViewerRole}
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _, err := limiter.allow(viewer, "GET", "/integration/api/v1/integrations"); err != nil || !ok {
+			t.Fatalf("allow() request %d = %v, %v, want allowed within the burst", i, ok, err)
+		}
+	}
+	ok, retryAfter, policy, err := limiter.allow(viewer, "GET", "/integration/api/v1/integrations")
+	if err != nil || ok {
+		t.Fatalf("allow() = %v, %v, want limited once the burst is used", ok, err)
+	}
+	if policy.ID != 1 || retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("allow() = policy %d, retry after %s, want policy 1 and at most a second", policy.ID, retryAfter)
+	}
+
+	jane := rateLimitCaller{userID: "jane", role: api2.AdminRole}
+	if ok, _, policy, err := limiter.allow(jane, "GET", "/integration/api/v1/integrations"); err != nil || !ok || policy != nil {
+		t.Errorf("allow() = %v, %v, %v, want a route class without a policy not limited", ok, policy, err)
+	}
+	if _, _, policy, _ := limiter.allow(jane, "POST", "/core/api/v1/query/run?x=1"); policy == nil || policy.ID != 2 {
+		t.Errorf("allow() policy = %v, want the policy of the query route class", policy)
+	}
+}
+
+func TestRateLimiterPruneBuckets(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(db.Database{})
+	for i := 0; i < maxRateLimitBuckets; i++ {
+		limiter.buckets[fmt.Sprintf("used-%d", i)] = &tokenBucket{last: now.Add(-time.Duration(i) * time.Second), rate: 0.001, capacity: 10}
+	}
+	limiter.buckets["full"] = &tokenBucket{tokens: 10, last: now, rate: 1, capacity: 10}
+
+	limiter.pruneBuckets(now)
+	if _, ok := limiter.buckets["full"]; ok {
+		t.Error("pruneBuckets() kept a full bucket")
+	}
+	if got, want := len(limiter.buckets), maxRateLimitBuckets*9/10; got != want {
+		t.Fatalf("pruneBuckets() left %d buckets, want %d", got, want)
+	}
+	if _, ok := limiter.buckets["used-0"]; !ok {
+		t.Error("pruneBuckets() dropped the most recently used bucket")
+	}
+	if _, ok := limiter.buckets[fmt.Sprintf("used-%d", maxRateLimitBuckets-1)]; ok {
+		t.Error("pruneBuckets() kept the least recently used bucket")
+	}
+}
+
+func TestRateLimitRouteClass(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{method: "POST", path: "/core/api/v1/query/run", want: "query"},
+		{method: "POST", path: "/core/api/v3/query/run?limit=10", want: "query"},
+		{method: "GET", path: "/core/api/v1/query/run", want: defaultRouteClass},
+		{method: "GET", path: "/compliance/api/v1/compliance_result/abc", want: "compliance-results"},
+		{method: "GET", path: "/integration/api/v1/integrations", want: defaultRouteClass},
+	}
+	for _, tc := range tests {
+		if got := rateLimitRouteClass(tc.method, tc.path); got != tc.want {
+			t.Errorf("rateLimitRouteClass(%s, %s) = %s, want %s", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,1268 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	envoycore "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoyauth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/gogo/googleapis/google/rpc"
+	"github.com/labstack/echo/v4"
+	api2 "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpserver"
+	"github.com/opengovern/opensecurity/pkg/audit"
+	"github.com/opengovern/opensecurity/pkg/rbac"
+	"github.com/opengovern/opensecurity/services/auth/api"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/status"
+)
+
+const (
+	// scimPublicPath is the public prefix of the SCIM endpoints, they only accept the SCIM tokens
+	scimPublicPath = "/auth/scim/v2/"
+	// scimConnectorID is the connector of the users provisioned through SCIM and the one the group role mappings of SCIM groups use
+	scimConnectorID = "scim"
+	scimUserID      = "scim"
+	scimTokenPrefix = "scim_"
+
+	scimDefaultCount = 100
+	scimMaxCount     = 200
+	// scimTokenUsageInterval throttles the last used updates of a token
+	scimTokenUsageInterval = time.Minute
+)
+
+// scimFilterPattern matches the single `<attribute> eq "<value>"` filters identity providers send
+var scimFilterPattern = regexp.MustCompile(`^\s*([A-Za-z0-9.:]+)\s+[eE][qQ]\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+var scimMemberPathPattern = regexp.MustCompile(`^members\[value eq "([^"]*)"\]$`)
+
+var scimUserFilterColumns = map[string]string{
+	"username":     "username",
+	"emails":       "email",
+	"emails.value": "email",
+	"externalid":   "scim_external_id",
+}
+
+var scimGroupFilterColumns = map[string]string{
+	"displayname": "display_name",
+	"externalid":  "external_id",
+}
+
+// checkScim authenticates the requests on the SCIM endpoints with the SCIM tokens only
+func (s *Server) checkScim(authHeader string, unAuth *envoyauth.CheckResponse) *envoyauth.CheckResponse {
+	token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	if !strings.HasPrefix(authHeader, "Bearer ") || !strings.HasPrefix(token, scimTokenPrefix) {
+		return unAuth
+	}
+	hash := sha512.Sum512([]byte(token))
+	scimToken, err := s.db.GetScimTokenByHash(hex.EncodeToString(hash[:]))
+	if err != nil {
+		s.logger.Error("failed to get scim token by hash", zap.Error(err))
+		return unAuth
+	}
+	if scimToken == nil {
+		s.logger.Warn("denied access due to unknown scim token")
+		return unAuth
+	}
+	if now := time.Now(); scimToken.LastUsedAt == nil || now.Sub(*scimToken.LastUsedAt) > scimTokenUsageInterval {
+		go func() {
+			if err := s.db.UpdateScimTokenLastUsed(scimToken.ID, now); err != nil {
+				s.logger.Error("failed to update scim token last usage", zap.Uint("scimTokenId", scimToken.ID), zap.Error(err))
+			}
+		}()
+	}
+
+	header := func(key, value string) *envoycore.HeaderValueOption {
+		return &envoycore.HeaderValueOption{Header: &envoycore.HeaderValue{Key: key, Value: value}}
+	}
+	return &envoyauth.CheckResponse{
+		Status: &status.Status{
+			Code: int32(rpc.OK),
+		},
+		HttpResponse: &envoyauth.CheckResponse_OkResponse{
+			OkResponse: &envoyauth.OkHttpResponse{
+				Headers: []*envoycore.HeaderValueOption{
+					header(httpserver.XPlatformUserIDHeader, scimUserID),
+					header(httpserver.XPlatformUserRoleHeader, string(api2.AdminRole)),
+					header(httpserver.XPlatformUserConnectionsScope, ""),
+					header(rbac.XPlatformUserFrameworksScope, ""),
+					header(audit.XPlatformAPIKeyIDHeader, ""),
+				},
+			},
+		},
+	}
+}
+
+func scimJSON(ctx echo.Context, code int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(code, "application/scim+json", body)
+}
+
+func scimError(ctx echo.Context, code int, scimType, detail string) error {
+	return scimJSON(ctx, code, api.ScimError{
+		Schemas:  []string{api.ScimSchemaError},
+		Status:   strconv.Itoa(code),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func (r *httpRoutes) scimInternalError(ctx echo.Context, msg string, err error) error {
+	r.logger.Error(msg, zap.Error(err))
+	return scimError(ctx, http.StatusInternalServerError, "", msg)
+}
+
+func scimLocation(ctx echo.Context, resourceType string, id uint) string {
+	return fmt.Sprintf("%s://%s%s%s/%d", ctx.Scheme(), ctx.Request().Host, scimPublicPath, resourceType, id)
+}
+
+// scimPage returns the 1-based start index and the count of a list request
+func scimPage(ctx echo.Context) (int, int) {
+	startIndex, count := 1, scimDefaultCount
+	if v, err := strconv.Atoi(ctx.QueryParam("startIndex")); err == nil && v > 0 {
+		startIndex = v
+	}
+	if v, err := strconv.Atoi(ctx.QueryParam("count")); err == nil && v >= 0 {
+		count = min(v, scimMaxCount)
+	}
+	return startIndex, count
+}
+
+func parseScimFilter(filter string, columns map[string]string) (string, string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", nil
+	}
+	match := scimFilterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", fmt.Errorf("only `<attribute> eq \"<value>\"` filters are supported")
+	}
+	attribute := strings.ToLower(match[1])
+	attribute = strings.TrimPrefix(attribute, strings.ToLower(api.ScimSchemaUser)+":")
+	column, ok := columns[attribute]
+	if !ok {
+		return "", "", fmt.Errorf("filtering on %s is not supported", match[1])
+	}
+	value := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(match[2])
+	return column, value, nil
+}
+
+func parseScimID(ctx echo.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func scimRole(roles []api.ScimMultiValue) (api2.Role, bool, error) {
+	var role api2.Role
+	found := false
+	for _, v := range roles {
+		r := api2.Role(strings.ToLower(strings.TrimSpace(v.Value)))
+		switch r {
+		case api2.ViewerRole, api2.EditorRole, api2.AdminRole:
+		default:
+			return "", false, fmt.Errorf("invalid role %s, expected one of admin, editor, viewer", v.Value)
+		}
+		if !found || rolePriority(r) > rolePriority(role) {
+			role = r
+		}
+		found = true
+	}
+	return role, found, nil
+}
+
+// scimEmail returns the primary email of the user, the first one or the user name otherwise
+func scimEmail(u api.ScimUser) string {
+	for _, e := range u.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	for _, e := range u.Emails {
+		if e.Value != "" {
+			return e.Value
+		}
+	}
+	return u.UserName
+}
+
+func (r *httpRoutes) scimUserToAPI(ctx echo.Context, user db.User, groups []db.ScimGroup) api.ScimUser {
+	active := user.IsActive
+	res := api.ScimUser{
+		Schemas:     []string{api.ScimSchemaUser},
+		ID:          strconv.FormatUint(uint64(user.ID), 10),
+		ExternalID:  user.ScimExternalID,
+		UserName:    user.Username,
+		Name:        &api.ScimName{Formatted: user.FullName},
+		DisplayName: user.FullName,
+		Active:      &active,
+		Emails:      []api.ScimMultiValue{{Value: user.Email, Type: "work", Primary: true}},
+		Roles:       []api.ScimMultiValue{{Value: string(user.Role), Primary: true}},
+		Meta: &api.ScimMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     scimLocation(ctx, "Users", user.ID),
+		},
+	}
+	if res.UserName == "" {
+		res.UserName = user.Email
+	}
+	for _, g := range groups {
+		res.Groups = append(res.Groups, api.ScimMultiValue{
+			Value:   strconv.FormatUint(uint64(g.ID), 10),
+			Display: g.DisplayName,
+			Ref:     scimLocation(ctx, "Groups", g.ID),
+		})
+	}
+	return res
+}
+
+func (r *httpRoutes) scimGroupToAPI(ctx echo.Context, group db.ScimGroup, members []db.User) api.ScimGroup {
+	res := api.ScimGroup{
+		Schemas:     []string{api.ScimSchemaGroup},
+		ID:          strconv.FormatUint(uint64(group.ID), 10),
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Meta: &api.ScimMeta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+			Location:     scimLocation(ctx, "Groups", group.ID),
+		},
+	}
+	for _, m := range members {
+		res.Members = append(res.Members, api.ScimMultiValue{
+			Value:   strconv.FormatUint(uint64(m.ID), 10),
+			Display: m.Email,
+			Ref:     scimLocation(ctx, "Users", m.ID),
+		})
+	}
+	return res
+}
+
+// syncScimRoles gives the users the highest role the group role mappings of the scim connector grant their SCIM groups,
+// their role is then managed by the mappings. As on login, a user whose role comes from the mappings is deactivated once
+// it is in no mapped group, users whose role was set by hand keep it. Reactivation is left to the active attribute.
+func (r *httpRoutes) syncScimRoles(userIDs []uint) error {
+	for _, userID := range userIDs {
+		user, err := r.db.GetUser(strconv.FormatUint(uint64(userID), 10))
+		if err != nil {
+			return err
+		}
+		if user == nil || user.ID == 0 {
+			continue
+		}
+		groups, err := r.db.ListScimGroupsOfUser(userID)
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(groups))
+		for _, g := range groups {
+			names = append(names, g.DisplayName)
+		}
+		role, matched, enforced, err := r.authServer.groupRoles.resolve(scimConnectorID, names)
+		if err != nil {
+			return err
+		}
+		if !enforced {
+			continue
+		}
+		switch {
+		case matched && (user.Role != role || !user.GroupManaged):
+			r.logger.Info("updating scim user role from group role mappings", zap.String("email", user.Email),
+				zap.String("from", string(user.Role)), zap.String("to", string(role)))
+			if err := r.db.SetUserGroupRole(user.ID, role, user.IsActive); err != nil {
+				return err
+			}
+		case !matched && user.GroupManaged && user.IsActive:
+			r.logger.Info("deactivating scim user without mapped groups", zap.String("email", user.Email))
+			if err := r.db.SetUserGroupRole(user.ID, user.Role, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ScimServiceProviderConfig godoc
+//
+//	@Summary	SCIM service provider config
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Produce	json
+//	@Success	200	{object}	api.ScimServiceProviderConfig
+//	@Router		/auth/scim/v2/ServiceProviderConfig [get]
+func (r *httpRoutes) ScimServiceProviderConfig(ctx echo.Context) error {
+	return scimJSON(ctx, http.StatusOK, api.ScimServiceProviderConfig{
+		Schemas: []string{api.ScimSchemaServiceProviderConfig},
+		Patch:   api.ScimSupported{Supported: true},
+		Filter:  api.ScimFilterSupported{Supported: true, MaxResults: scimMaxCount},
+		AuthenticationSchemes: []api.ScimAuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "SCIM token",
+			Description: "Bearer token created through /auth/api/v1/scim/tokens",
+		}},
+	})
+}
+
+// ScimListUsers godoc
+//
+//	@Summary	List SCIM users
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Produce	json
+//	@Param		filter		query		string	false	"userName, emails or externalId eq filter"
+//	@Param		startIndex	query		int		false	"1-based start index"
+//	@Param		count		query		int		false	"Page size"
+//	@Success	200			{object}	api.ScimListResponse
+//	@Router		/auth/scim/v2/Users [get]
+func (r *httpRoutes) ScimListUsers(ctx echo.Context) error {
+	column, value, err := parseScimFilter(ctx.QueryParam("filter"), scimUserFilterColumns)
+	if err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidFilter", err.Error())
+	}
+	startIndex, count := scimPage(ctx)
+	users, total, err := r.db.ListUsersByAttribute(column, value, count, startIndex-1)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to list users", err)
+	}
+
+	resources := make([]api.ScimUser, 0, len(users))
+	for _, user := range users {
+		groups, err := r.db.ListScimGroupsOfUser(user.ID)
+		if err != nil {
+			return r.scimInternalError(ctx, "failed to list user groups", err)
+		}
+		resources = append(resources, r.scimUserToAPI(ctx, user, groups))
+	}
+	return scimJSON(ctx, http.StatusOK, api.ScimListResponse{
+		Schemas:      []string{api.ScimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (r *httpRoutes) getScimUser(ctx echo.Context) (*db.User, error) {
+	id, ok := parseScimID(ctx)
+	if !ok {
+		return nil, scimError(ctx, http.StatusNotFound, "", "user not found")
+	}
+	user, err := r.db.GetUser(strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		return nil, r.scimInternalError(ctx, "failed to get user", err)
+	}
+	if user == nil || user.ID == 0 {
+		return nil, scimError(ctx, http.StatusNotFound, "", "user not found")
+	}
+	return user, nil
+}
+
+func (r *httpRoutes) writeScimUser(ctx echo.Context, code int, userID uint) error {
+	user, err := r.db.GetUser(strconv.FormatUint(uint64(userID), 10))
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to get user", err)
+	}
+	groups, err := r.db.ListScimGroupsOfUser(userID)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to list user groups", err)
+	}
+	return scimJSON(ctx, code, r.scimUserToAPI(ctx, *user, groups))
+}
+
+// ScimGetUser godoc
+//
+//	@Summary	Get SCIM user
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Produce	json
+//	@Param		id	path		string	true	"User ID"
+//	@Success	200	{object}	api.ScimUser
+//	@Router		/auth/scim/v2/Users/{id} [get]
+func (r *httpRoutes) ScimGetUser(ctx echo.Context) error {
+	user, err := r.getScimUser(ctx)
+	if user == nil {
+		return err
+	}
+	return r.writeScimUser(ctx, http.StatusOK, user.ID)
+}
+
+// applyScimUser sets the attributes of the SCIM user on the platform user, the role only when roles are given
+func (r *httpRoutes) applyScimUser(ctx echo.Context, user *db.User, req api.ScimUser) error {
+	req.UserName = strings.TrimSpace(req.UserName)
+	if req.UserName == "" {
+		return scimError(ctx, http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	email := strings.ToLower(strings.TrimSpace(scimEmail(req)))
+	if email != user.Email {
+		existing, err := r.db.GetUserByEmail(email)
+		if err != nil {
+			return r.scimInternalError(ctx, "failed to get user", err)
+		}
+		if existing != nil && existing.ID != 0 && existing.ID != user.ID {
+			return scimError(ctx, http.StatusConflict, "uniqueness", "a user with this email already exists")
+		}
+	}
+	role, ok, err := scimRole(req.Roles)
+	if err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+	}
+	if ok {
+		user.Role = role
+	}
+
+	user.Email = email
+	user.Username = req.UserName
+	user.ScimExternalID = req.ExternalID
+	user.FullName = req.DisplayName
+	if user.FullName == "" && req.Name != nil {
+		user.FullName = req.Name.Formatted
+		if user.FullName == "" {
+			user.FullName = strings.TrimSpace(req.Name.GivenName + " " + req.Name.FamilyName)
+		}
+	}
+	if user.FullName == "" {
+		user.FullName = req.UserName
+	}
+	if req.Active != nil {
+		user.IsActive = *req.Active
+	}
+	return nil
+}
+
+// ScimCreateUser godoc
+//
+//	@Summary	Create SCIM user
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Accept		json
+//	@Produce	json
+//	@Param		request	body		api.ScimUser	true	"User"
+//	@Success	201		{object}	api.ScimUser
+//	@Router		/auth/scim/v2/Users [post]
+func (r *httpRoutes) ScimCreateUser(ctx echo.Context) error {
+	var req api.ScimUser
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&req); err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidSyntax", "invalid user")
+	}
+
+	user := db.User{
+		Role:        api2.ViewerRole,
+		IsActive:    true,
+		ConnectorId: scimConnectorID,
+	}
+	if err := r.applyScimUser(ctx, &user, req); err != nil {
+		return err
+	}
+	existing, err := r.db.GetUserByEmail(user.Email)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to get user", err)
+	}
+	if existing != nil && existing.ID != 0 {
+		return scimError(ctx, http.StatusConflict, "uniqueness", "a user with this email already exists")
+	}
+	user.ExternalId = fmt.Sprintf("%s|%s", scimConnectorID, user.Email)
+	active := user.IsActive
+	user.IsActive = true
+	if err := r.db.CreateUser(&user); err != nil {
+		return r.scimInternalError(ctx, "failed to create user", err)
+	}
+	// is_active defaults to true on insert
+	if !active {
+		user.IsActive = false
+		if err := r.db.UpdateScimUser(&user); err != nil {
+			return r.scimInternalError(ctx, "failed to update user", err)
+		}
+	}
+	r.logger.Info("user provisioned through scim", zap.String("email", user.Email))
+
+	audit.SetAfter(ctx, map[string]any{"id": user.ID, "email": user.Email, "role": user.Role, "active": active})
+	return r.writeScimUser(ctx, http.StatusCreated, user.ID)
+}
+
+func (r *httpRoutes) saveScimUser(ctx echo.Context, user *db.User, before db.User) error {
+	if err := r.db.UpdateScimUser(user); err != nil {
+		return r.scimInternalError(ctx, "failed to update user", err)
+	}
+	if before.IsActive && !user.IsActive {
+		r.logger.Info("user deprovisioned through scim", zap.String("email", user.Email))
+	}
+	audit.SetBefore(ctx, map[string]any{"email": before.Email, "role": before.Role, "active": before.IsActive})
+	audit.SetAfter(ctx, map[string]any{"email": user.Email, "role": user.Role, "active": user.IsActive})
+	return r.writeScimUser(ctx, http.StatusOK, user.ID)
+}
+
+// ScimReplaceUser godoc
+//
+//	@Summary	Replace SCIM user
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path		string			true	"User ID"
+//	@Param		request	body		api.ScimUser	true	"User"
+//	@Success	200		{object}	api.ScimUser
+//	@Router		/auth/scim/v2/Users/{id} [put]
+func (r *httpRoutes) ScimReplaceUser(ctx echo.Context) error {
+	user, err := r.getScimUser(ctx)
+	if user == nil {
+		return err
+	}
+	var req api.ScimUser
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&req); err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidSyntax", "invalid user")
+	}
+	if req.Active == nil {
+		req.Active = &user.IsActive
+	}
+
+	before := *user
+	if err := r.applyScimUser(ctx, user, req); err != nil {
+		return err
+	}
+	return r.saveScimUser(ctx, user, before)
+}
+
+// ScimPatchUser godoc
+//
+//	@Summary		Patch SCIM user
+//	@Description	Supports active, userName, displayName, name, externalId, emails and roles, other attributes are ignored
+//	@Security		BearerToken
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"User ID"
+//	@Param			request	body		api.ScimPatchRequest	true	"Patch"
+//	@Success		200		{object}	api.ScimUser
+//	@Router			/auth/scim/v2/Users/{id} [patch]
+func (r *httpRoutes) ScimPatchUser(ctx echo.Context) error {
+	user, err := r.getScimUser(ctx)
+	if user == nil {
+		return err
+	}
+	var req api.ScimPatchRequest
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&req); err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidSyntax", "invalid patch")
+	}
+
+	current := r.scimUserToAPI(ctx, *user, nil)
+	// the role is only changed when the patch touches it
+	current.Roles = nil
+	for _, op := range req.Operations {
+		if err := patchScimUser(&current, strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+			return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+		}
+	}
+
+	before := *user
+	if err := r.applyScimUser(ctx, user, current); err != nil {
+		return err
+	}
+	return r.saveScimUser(ctx, user, before)
+}
+
+func scimString(value json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", fmt.Errorf("expected a string value")
+	}
+	return s, nil
+}
+
+// scimBool accepts the "True"/"False" strings some identity providers send
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	s, err := scimString(value)
+	if err != nil {
+		return false, fmt.Errorf("expected a boolean value")
+	}
+	return strconv.ParseBool(strings.ToLower(s))
+}
+
+// scimMultiValues accepts a single value as well as a list
+func scimMultiValues(value json.RawMessage) ([]api.ScimMultiValue, error) {
+	var values []api.ScimMultiValue
+	if err := json.Unmarshal(value, &values); err == nil {
+		return values, nil
+	}
+	var v api.ScimMultiValue
+	if err := json.Unmarshal(value, &v); err != nil {
+		return nil, fmt.Errorf("expected a list of values")
+	}
+	return []api.ScimMultiValue{v}, nil
+}
+
+func patchScimUser(u *api.ScimUser, op, path string, value json.RawMessage) error {
+	switch op {
+	case "add", "replace", "remove":
+	default:
+		return fmt.Errorf("unsupported op %s", op)
+	}
+	if path == "" {
+		if op == "remove" {
+			return fmt.Errorf("remove requires a path")
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(value, &attributes); err != nil {
+			return fmt.Errorf("expected an object value without a path")
+		}
+		for attribute, v := range attributes {
+			if err := patchScimUser(u, op, attribute, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path = strings.TrimPrefix(strings.ToLower(path), strings.ToLower(api.ScimSchemaUser)+":")
+	if u.Name == nil {
+		u.Name = &api.ScimName{}
+	}
+	var err error
+	switch {
+	case path == "active":
+		if op == "remove" {
+			return fmt.Errorf("active can not be removed")
+		}
+		var active bool
+		if active, err = scimBool(value); err == nil {
+			u.Active = &active
+		}
+	case path == "username":
+		if op == "remove" {
+			return fmt.Errorf("userName can not be removed")
+		}
+		u.UserName, err = scimString(value)
+	case path == "displayname":
+		u.DisplayName = ""
+		if op != "remove" {
+			u.DisplayName, err = scimString(value)
+		}
+	case path == "externalid":
+		u.ExternalID = ""
+		if op != "remove" {
+			u.ExternalID, err = scimString(value)
+		}
+	case path == "name":
+		u.Name, u.DisplayName = &api.ScimName{}, ""
+		if op != "remove" {
+			err = json.Unmarshal(value, u.Name)
+		}
+	case path == "name.formatted", path == "name.givenname", path == "name.familyname":
+		var s string
+		if op != "remove" {
+			if s, err = scimString(value); err != nil {
+				return err
+			}
+		}
+		switch path {
+		case "name.formatted":
+			u.Name.Formatted = s
+		case "name.givenname":
+			u.Name.GivenName = s
+		default:
+			u.Name.FamilyName = s
+		}
+		// the display name is derived from the name again
+		u.DisplayName = ""
+	case path == "emails":
+		u.Emails = nil
+		if op != "remove" {
+			u.Emails, err = scimMultiValues(value)
+		}
+	case strings.HasPrefix(path, "emails[") && strings.HasSuffix(path, "].value"):
+		if op == "remove" {
+			u.Emails = nil
+			return nil
+		}
+		var email string
+		if email, err = scimString(value); err == nil {
+			u.Emails = []api.ScimMultiValue{{Value: email, Primary: true}}
+		}
+	case path == "roles":
+		u.Roles = []api.ScimMultiValue{{Value: string(api2.ViewerRole)}}
+		if op != "remove" {
+			u.Roles, err = scimMultiValues(value)
+		}
+	}
+	return err
+}
+
+// ScimDeleteUser godoc
+//
+//	@Summary	Delete SCIM user
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Param		id	path	string	true	"User ID"
+//	@Success	204
+//	@Router		/auth/scim/v2/Users/{id} [delete]
+func (r *httpRoutes) ScimDeleteUser(ctx echo.Context) error {
+	user, err := r.getScimUser(ctx)
+	if user == nil {
+		return err
+	}
+	if user.ID == 1 {
+		return scimError(ctx, http.StatusBadRequest, "mutability", "the first user can not be deleted")
+	}
+	audit.SetBefore(ctx, map[string]any{"email": user.Email, "role": user.Role, "active": user.IsActive})
+
+	if err := r.db.DeleteScimGroupMembershipsOfUser(user.ID); err != nil {
+		return r.scimInternalError(ctx, "failed to delete user group memberships", err)
+	}
+	if err := r.removeUser(*user); err != nil {
+		return r.scimInternalError(ctx, "failed to delete user", err)
+	}
+	r.logger.Info("user deleted through scim", zap.String("email", user.Email))
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ScimListGroups godoc
+//
+//	@Summary	List SCIM groups
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Produce	json
+//	@Param		filter				query		string	false	"displayName or externalId eq filter"
+//	@Param		startIndex			query		int		false	"1-based start index"
+//	@Param		count				query		int		false	"Page size"
+//	@Param		excludedAttributes	query		string	false	"members to leave the members out"
+//	@Success	200					{object}	api.ScimListResponse
+//	@Router		/auth/scim/v2/Groups [get]
+func (r *httpRoutes) ScimListGroups(ctx echo.Context) error {
+	column, value, err := parseScimFilter(ctx.QueryParam("filter"), scimGroupFilterColumns)
+	if err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidFilter", err.Error())
+	}
+	startIndex, count := scimPage(ctx)
+	groups, total, err := r.db.ListScimGroups(column, value, count, startIndex-1)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to list groups", err)
+	}
+
+	resources := make([]api.ScimGroup, 0, len(groups))
+	for _, group := range groups {
+		members, err := r.scimGroupMembers(ctx, group.ID)
+		if err != nil {
+			return r.scimInternalError(ctx, "failed to list group members", err)
+		}
+		resources = append(resources, r.scimGroupToAPI(ctx, group, members))
+	}
+	return scimJSON(ctx, http.StatusOK, api.ScimListResponse{
+		Schemas:      []string{api.ScimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// scimGroupMembers lists the members of the group unless the request excludes them
+func (r *httpRoutes) scimGroupMembers(ctx echo.Context, groupID uint) ([]db.User, error) {
+	if strings.Contains(strings.ToLower(ctx.QueryParam("excludedAttributes")), "members") {
+		return nil, nil
+	}
+	members, err := r.db.ListScimGroupMembers(groupID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	return r.db.ListUsersByIDs(ids)
+}
+
+func (r *httpRoutes) getScimGroup(ctx echo.Context) (*db.ScimGroup, error) {
+	id, ok := parseScimID(ctx)
+	if !ok {
+		return nil, scimError(ctx, http.StatusNotFound, "", "group not found")
+	}
+	group, err := r.db.GetScimGroup(id)
+	if err != nil {
+		return nil, r.scimInternalError(ctx, "failed to get group", err)
+	}
+	if group == nil {
+		return nil, scimError(ctx, http.StatusNotFound, "", "group not found")
+	}
+	return group, nil
+}
+
+func (r *httpRoutes) writeScimGroup(ctx echo.Context, code int, groupID uint) error {
+	group, err := r.db.GetScimGroup(groupID)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to get group", err)
+	}
+	members, err := r.scimGroupMembers(ctx, groupID)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to list group members", err)
+	}
+	return scimJSON(ctx, code, r.scimGroupToAPI(ctx, *group, members))
+}
+
+// ScimGetGroup godoc
+//
+//	@Summary	Get SCIM group
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Produce	json
+//	@Param		id	path		string	true	"Group ID"
+//	@Success	200	{object}	api.ScimGroup
+//	@Router		/auth/scim/v2/Groups/{id} [get]
+func (r *httpRoutes) ScimGetGroup(ctx echo.Context) error {
+	group, err := r.getScimGroup(ctx)
+	if group == nil {
+		return err
+	}
+	return r.writeScimGroup(ctx, http.StatusOK, group.ID)
+}
+
+// scimMemberIDs resolves the member values to user ids, all of them must exist
+func (r *httpRoutes) scimMemberIDs(members []api.ScimMultiValue) ([]uint, error) {
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		id, err := strconv.ParseUint(m.Value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid member %s", m.Value)
+		}
+		ids = append(ids, uint(id))
+	}
+	users, err := r.db.ListUsersByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uint]bool, len(users))
+	for _, u := range users {
+		found[u.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("member %d not found", id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *httpRoutes) checkScimGroupName(ctx echo.Context, displayName string, groupID uint) error {
+	if strings.TrimSpace(displayName) == "" {
+		return scimError(ctx, http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	existing, _, err := r.db.ListScimGroups("display_name", displayName, 1, 0)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to list groups", err)
+	}
+	if len(existing) > 0 && existing[0].ID != groupID {
+		return scimError(ctx, http.StatusConflict, "uniqueness", "a group with this displayName already exists")
+	}
+	return nil
+}
+
+// setScimGroupMembers makes the given users the only members of the group and syncs the roles of the affected users
+func (r *httpRoutes) setScimGroupMembers(groupID uint, userIDs []uint) error {
+	current, err := r.db.ListScimGroupMembers(groupID)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+	var removed []uint
+	for _, m := range current {
+		if !wanted[m.UserID] {
+			removed = append(removed, m.UserID)
+		}
+	}
+	if err := r.db.RemoveScimGroupMembers(groupID, removed); err != nil {
+		return err
+	}
+	if err := r.db.AddScimGroupMembers(groupID, userIDs); err != nil {
+		return err
+	}
+	return r.syncScimRoles(append(removed, userIDs...))
+}
+
+// ScimCreateGroup godoc
+//
+//	@Summary		Create SCIM group
+//	@Description	The group role mappings of the scim connector give the members of a group, matched by its displayName, their role
+//	@Security		BearerToken
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.ScimGroup	true	"Group"
+//	@Success		201		{object}	api.ScimGroup
+//	@Router			/auth/scim/v2/Groups [post]
+func (r *httpRoutes) ScimCreateGroup(ctx echo.Context) error {
+	var req api.ScimGroup
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&req); err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidSyntax", "invalid group")
+	}
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	if err := r.checkScimGroupName(ctx, req.DisplayName, 0); err != nil {
+		return err
+	}
+	memberIDs, err := r.scimMemberIDs(req.Members)
+	if err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+	}
+
+	group := db.ScimGroup{DisplayName: req.DisplayName, ExternalID: req.ExternalID}
+	if err := r.db.CreateScimGroup(&group); err != nil {
+		return r.scimInternalError(ctx, "failed to create group", err)
+	}
+	if err := r.setScimGroupMembers(group.ID, memberIDs); err != nil {
+		return r.scimInternalError(ctx, "failed to set group members", err)
+	}
+
+	audit.SetAfter(ctx, map[string]any{"id": group.ID, "display_name": group.DisplayName, "members": memberIDs})
+	return r.writeScimGroup(ctx, http.StatusCreated, group.ID)
+}
+
+// ScimReplaceGroup godoc
+//
+//	@Summary	Replace SCIM group
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Accept		json
+//	@Produce	json
+//	@Param		id		path		string			true	"Group ID"
+//	@Param		request	body		api.ScimGroup	true	"Group"
+//	@Success	200		{object}	api.ScimGroup
+//	@Router		/auth/scim/v2/Groups/{id} [put]
+func (r *httpRoutes) ScimReplaceGroup(ctx echo.Context) error {
+	group, err := r.getScimGroup(ctx)
+	if group == nil {
+		return err
+	}
+	var req api.ScimGroup
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&req); err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidSyntax", "invalid group")
+	}
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	if err := r.checkScimGroupName(ctx, req.DisplayName, group.ID); err != nil {
+		return err
+	}
+	memberIDs, err := r.scimMemberIDs(req.Members)
+	if err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+	}
+
+	renamed := group.DisplayName != req.DisplayName
+	group.DisplayName, group.ExternalID = req.DisplayName, req.ExternalID
+	if err := r.db.UpdateScimGroup(group); err != nil {
+		return r.scimInternalError(ctx, "failed to update group", err)
+	}
+	if err := r.setScimGroupMembers(group.ID, memberIDs); err != nil {
+		return r.scimInternalError(ctx, "failed to set group members", err)
+	}
+	if renamed {
+		if err := r.syncScimGroupRoles(group.ID); err != nil {
+			return r.scimInternalError(ctx, "failed to sync member roles", err)
+		}
+	}
+
+	audit.SetAfter(ctx, map[string]any{"id": group.ID, "display_name": group.DisplayName, "members": memberIDs})
+	return r.writeScimGroup(ctx, http.StatusOK, group.ID)
+}
+
+func (r *httpRoutes) syncScimGroupRoles(groupID uint) error {
+	members, err := r.db.ListScimGroupMembers(groupID)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	return r.syncScimRoles(ids)
+}
+
+// scimPatchMemberIDs returns the member ids a patch operation targets, from its value or a members[value eq "<id>"] path
+func (r *httpRoutes) scimPatchMemberIDs(path string, value json.RawMessage) ([]uint, error) {
+	if match := scimMemberPathPattern.FindStringSubmatch(path); match != nil {
+		return r.scimMemberIDs([]api.ScimMultiValue{{Value: match[1]}})
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	members, err := scimMultiValues(value)
+	if err != nil {
+		return nil, err
+	}
+	return r.scimMemberIDs(members)
+}
+
+// ScimPatchGroup godoc
+//
+//	@Summary		Patch SCIM group
+//	@Description	Supports adding, removing and replacing members and replacing displayName and externalId
+//	@Security		BearerToken
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Group ID"
+//	@Param			request	body		api.ScimPatchRequest	true	"Patch"
+//	@Success		200		{object}	api.ScimGroup
+//	@Router			/auth/scim/v2/Groups/{id} [patch]
+func (r *httpRoutes) ScimPatchGroup(ctx echo.Context) error {
+	group, err := r.getScimGroup(ctx)
+	if group == nil {
+		return err
+	}
+	var req api.ScimPatchRequest
+	if err := json.NewDecoder(ctx.Request().Body).Decode(&req); err != nil {
+		return scimError(ctx, http.StatusBadRequest, "invalidSyntax", "invalid patch")
+	}
+
+	current, err := r.db.ListScimGroupMembers(group.ID)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to list group members", err)
+	}
+	members := make(map[uint]bool, len(current))
+	for _, m := range current {
+		members[m.UserID] = true
+	}
+	displayName, externalID := group.DisplayName, group.ExternalID
+
+	for _, op := range req.Operations {
+		opName, path := strings.ToLower(op.Op), strings.ToLower(strings.TrimSpace(op.Path))
+		value := op.Value
+		if path == "" && opName != "remove" {
+			// a path-less operation carries the attributes in its value
+			var attributes map[string]json.RawMessage
+			if err := json.Unmarshal(value, &attributes); err != nil {
+				return scimError(ctx, http.StatusBadRequest, "invalidValue", "expected an object value without a path")
+			}
+			for attribute, v := range attributes {
+				switch strings.ToLower(attribute) {
+				case "displayname":
+					if displayName, err = scimString(v); err != nil {
+						return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+					}
+				case "externalid":
+					if externalID, err = scimString(v); err != nil {
+						return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+					}
+				case "members":
+					path, value = "members", v
+				}
+			}
+			if path == "" {
+				continue
+			}
+		}
+
+		switch {
+		case path == "displayname":
+			if displayName, err = scimString(value); err != nil {
+				return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+			}
+		case path == "externalid":
+			externalID = ""
+			if opName != "remove" {
+				if externalID, err = scimString(value); err != nil {
+					return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+				}
+			}
+		case strings.HasPrefix(path, "members"):
+			ids, err := r.scimPatchMemberIDs(path, value)
+			if err != nil {
+				return scimError(ctx, http.StatusBadRequest, "invalidValue", err.Error())
+			}
+			switch opName {
+			case "add":
+				for _, id := range ids {
+					members[id] = true
+				}
+			case "replace":
+				members = make(map[uint]bool, len(ids))
+				for _, id := range ids {
+					members[id] = true
+				}
+			case "remove":
+				if path == "members" && len(value) == 0 {
+					members = make(map[uint]bool)
+				}
+				for _, id := range ids {
+					delete(members, id)
+				}
+			default:
+				return scimError(ctx, http.StatusBadRequest, "invalidValue", fmt.Sprintf("unsupported op %s", op.Op))
+			}
+		}
+	}
+
+	displayName = strings.TrimSpace(displayName)
+	if err := r.checkScimGroupName(ctx, displayName, group.ID); err != nil {
+		return err
+	}
+	renamed := displayName != group.DisplayName
+	group.DisplayName, group.ExternalID = displayName, externalID
+	if err := r.db.UpdateScimGroup(group); err != nil {
+		return r.scimInternalError(ctx, "failed to update group", err)
+	}
+	memberIDs := make([]uint, 0, len(members))
+	for id := range members {
+		memberIDs = append(memberIDs, id)
+	}
+	if err := r.setScimGroupMembers(group.ID, memberIDs); err != nil {
+		return r.scimInternalError(ctx, "failed to set group members", err)
+	}
+	if renamed {
+		if err := r.syncScimGroupRoles(group.ID); err != nil {
+			return r.scimInternalError(ctx, "failed to sync member roles", err)
+		}
+	}
+
+	audit.SetAfter(ctx, map[string]any{"id": group.ID, "display_name": group.DisplayName, "members": memberIDs})
+	return r.writeScimGroup(ctx, http.StatusOK, group.ID)
+}
+
+// ScimDeleteGroup godoc
+//
+//	@Summary	Delete SCIM group
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Param		id	path	string	true	"Group ID"
+//	@Success	204
+//	@Router		/auth/scim/v2/Groups/{id} [delete]
+func (r *httpRoutes) ScimDeleteGroup(ctx echo.Context) error {
+	group, err := r.getScimGroup(ctx)
+	if group == nil {
+		return err
+	}
+	members, err := r.db.ListScimGroupMembers(group.ID)
+	if err != nil {
+		return r.scimInternalError(ctx, "failed to list group members", err)
+	}
+	audit.SetBefore(ctx, map[string]any{"id": group.ID, "display_name": group.DisplayName})
+
+	if err := r.db.DeleteScimGroup(group.ID); err != nil {
+		return r.scimInternalError(ctx, "failed to delete group", err)
+	}
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	if err := r.syncScimRoles(ids); err != nil {
+		return r.scimInternalError(ctx, "failed to sync member roles", err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func scimTokenToAPI(token db.ScimToken) api.ScimToken {
+	return api.ScimToken{
+		ID:            token.ID,
+		Name:          token.Name,
+		MaskedKey:     token.MaskedKey,
+		CreatorUserID: token.CreatorUserID,
+		CreatedAt:     token.CreatedAt,
+		LastUsedAt:    token.LastUsedAt,
+	}
+}
+
+// CreateScimToken godoc
+//
+//	@Summary		Create SCIM token
+//	@Description	Creates a bearer token for the identity provider to call the SCIM endpoints with, the token is only returned once
+//	@Security		BearerToken
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateScimTokenRequest	true	"Request Body"
+//	@Success		201		{object}	api.CreateScimTokenResponse
+//	@Router			/auth/api/v1/scim/tokens [post]
+func (r *httpRoutes) CreateScimToken(ctx echo.Context) error {
+	var req api.CreateScimTokenRequest
+	if err := bindValidate(ctx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		r.logger.Error("failed to generate scim token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to generate scim token")
+	}
+	token := scimTokenPrefix + hex.EncodeToString(secret)
+	hash := sha512.Sum512([]byte(token))
+
+	scimToken := db.ScimToken{
+		Name:          req.Name,
+		KeyHash:       hex.EncodeToString(hash[:]),
+		MaskedKey:     fmt.Sprintf("%s...%s", token[:len(scimTokenPrefix)+4], token[len(token)-4:]),
+		CreatorUserID: httpserver.GetUserID(ctx),
+	}
+	if err := r.db.CreateScimToken(&scimToken); err != nil {
+		r.logger.Error("failed to create scim token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create scim token")
+	}
+
+	resp := scimTokenToAPI(scimToken)
+	audit.SetAfter(ctx, resp)
+	return ctx.JSON(http.StatusCreated, api.CreateScimTokenResponse{ScimToken: resp, Token: token})
+}
+
+// ListScimTokens godoc
+//
+//	@Summary	List SCIM tokens
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Produce	json
+//	@Success	200	{array}	api.ScimToken
+//	@Router		/auth/api/v1/scim/tokens [get]
+func (r *httpRoutes) ListScimTokens(ctx echo.Context) error {
+	tokens, err := r.db.ListScimTokens()
+	if err != nil {
+		r.logger.Error("failed to list scim tokens", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list scim tokens")
+	}
+	resp := make([]api.ScimToken, 0, len(tokens))
+	for _, token := range tokens {
+		resp = append(resp, scimTokenToAPI(token))
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteScimToken godoc
+//
+//	@Summary	Delete SCIM token
+//	@Security	BearerToken
+//	@Tags		scim
+//	@Param		id	path	int	true	"SCIM token ID"
+//	@Success	202
+//	@Router		/auth/api/v1/scim/tokens/{id} [delete]
+func (r *httpRoutes) DeleteScimToken(ctx echo.Context) error {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+	}
+	deleted, err := r.db.DeleteScimToken(uint(id))
+	if err != nil {
+		r.logger.Error("failed to delete scim token", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete scim token")
+	}
+	if !deleted {
+		return echo.NewHTTPError(http.StatusNotFound, "scim token not found")
+	}
+	return ctx.NoContent(http.StatusAccepted)
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opengovern/opensecurity/services/auth/api"
+)
+
+func TestParseScimFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     string
+		wantColumn string
+		wantValue  string
+		wantErr    bool
+	}{
+		{name: "empty", filter: ""},
+		{name: "blank", filter: "   "},
+		{name: "user name", filter: `userName eq "jane@example.com"`, wantColumn: "username", wantValue: "jane@example.com"},
+		{name: "case insensitive", filter: `USERNAME EQ "jane@example.com"`, wantColumn: "username", wantValue: "jane@example.com"},
+		{name: "email value", filter: `emails.value eq "jane@example.com"`, wantColumn: "email", wantValue: "jane@example.com"},
+		{name: "schema prefix", filter: `urn:ietf:params:scim:schemas:core:2.0:User:externalId eq "00u1"`, wantColumn: "scim_external_id", wantValue: "00u1"},
+		{name: "escaped quote", filter: `userName eq "ja\"ne"`, wantColumn: "username", wantValue: `ja"ne`},
+		{name: "escaped backslash", filter: `userName eq "ja\\ne"`, wantColumn: "username", wantValue: `ja\ne`},
+		{name: "unsupported attribute", filter: `displayName eq "Jane"`, wantErr: true},
+		{name: "unsupported operator", filter: `userName co "jane"`, wantErr: true},
+		{name: "compound filter", filter: `userName eq "a" and userName eq "b"`, wantErr: true},
+		{name: "unquoted value", filter: `userName eq jane`, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			column, value, err := parseScimFilter(tc.filter, scimUserFilterColumns)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseScimFilter() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if column != tc.wantColumn || value != tc.wantValue {
+				t.Errorf("parseScimFilter() = %q, %q, want %q, %q", column, value, tc.wantColumn, tc.wantValue)
+			}
+		})
+	}
+}
+
+func TestPatchScimUser(t *testing.T) {
+	active, inactive := true, false
+	base := func() api.ScimUser {
+		return api.ScimUser{
+			UserName:    "jane@example.com",
+			DisplayName: "Jane Doe",
+			ExternalID:  "00u1",
+			Active:      &active,
+			Emails:      []api.ScimMultiValue{{Value: "jane@example.com", Primary: true}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		op      string
+		path    string
+		value   string
+		want    func(u *api.ScimUser)
+		wantErr bool
+	}{
+		{name: "deactivate", op: "replace", path: "active", value: `false`, want: func(u *api.ScimUser) { u.Active = &inactive }},
+		{name: "deactivate with a string", op: "replace", path: "active", value: `"False"`, want: func(u *api.ScimUser) { u.Active = &inactive }},
+		{name: "active can not be removed", op: "remove", path: "active", wantErr: true},
+		{name: "invalid active", op: "replace", path: "active", value: `"maybe"`, wantErr: true},
+		{name: "user name", op: "replace", path: "userName", value: `"j@example.com"`, want: func(u *api.ScimUser) { u.UserName = "j@example.com" }},
+		{name: "user name can not be removed", op: "remove", path: "userName", wantErr: true},
+		{name: "remove external id", op: "remove", path: "externalId", want: func(u *api.ScimUser) { u.ExternalID = "" }},
+		{
+			name: "given name resets the display name", op: "replace", path: "name.givenName", value: `"Janet"`,
+			want: func(u *api.ScimUser) { u.Name.GivenName, u.DisplayName = "Janet", "" },
+		},
+		{
+			name: "email by filter", op: "replace", path: `emails[type eq "work"].value`, value: `"janet@example.com"`,
+			want: func(u *api.ScimUser) { u.Emails = []api.ScimMultiValue{{Value: "janet@example.com", Primary: true}} },
+		},
+		{
+			name: "single role", op: "add", path: "roles", value: `{"value": "editor"}`,
+			want: func(u *api.ScimUser) { u.Roles = []api.ScimMultiValue{{Value: "editor"}} },
+		},
+		{
+			name: "removed roles fall back to viewer", op: "remove", path: "roles",
+			want: func(u *api.ScimUser) { u.Roles = []api.ScimMultiValue{{Value: "viewer"}} },
+		},
+		{
+			name: "attributes without a path", op: "Replace", value: `{"active": false, "displayName": "Janet"}`,
+			want: func(u *api.ScimUser) { u.Active, u.DisplayName = &inactive, "Janet" },
+		},
+		{name: "remove without a path", op: "remove", wantErr: true},
+		{name: "unsupported op", op: "move", path: "active", value: `true`, wantErr: true},
+		{name: "unknown attribute is ignored", op: "replace", path: "title", value: `"CTO"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := base()
+			var value json.RawMessage
+			if tc.value != "" {
+				value = json.RawMessage(tc.value)
+			}
+			err := patchScimUser(&got, strings.ToLower(tc.op), tc.path, value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("patchScimUser() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			want := base()
+			want.Name = &api.ScimName{}
+			if tc.want != nil {
+				tc.want(&want)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("patchScimUser() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
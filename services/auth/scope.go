@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	api2 "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/opensecurity/pkg/rbac"
+	"github.com/opengovern/opensecurity/services/auth/api"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	integrationClient "github.com/opengovern/opensecurity/services/integration/client"
+)
+
+// scopeCacheTTL bounds how long integration group membership changes take to reach scoped users
+const scopeCacheTTL = time.Minute
+
+// subjectScope is what the role bindings of a user or api key resolve to
+type subjectScope struct {
+	scoped bool
+	role   api2.Role
+	// nil when the subject is not restricted on that kind
+	integrationIDs []string
+	frameworkIDs   []string
+
+	expiresAt time.Time
+}
+
+// effectiveRole caps the global role of the subject at the role of its bindings, a binding never grants
+// more than the global role since the role header also applies to the unscoped routes
+func (s subjectScope) effectiveRole(globalRole api2.Role) api2.Role {
+	if s.scoped && rolePriority(s.role) < rolePriority(globalRole) {
+		return s.role
+	}
+	return globalRole
+}
+
+func (s subjectScope) integrationsHeader() string {
+	if s.integrationIDs == nil {
+		return ""
+	}
+	return rbac.ScopeHeaderValue(s.integrationIDs)
+}
+
+func (s subjectScope) frameworksHeader() string {
+	if s.frameworkIDs == nil {
+		return ""
+	}
+	return rbac.ScopeHeaderValue(s.frameworkIDs)
+}
+
+type scopeResolver struct {
+	db                db.Database
+	integrationClient integrationClient.IntegrationServiceClient
+
+	mu    sync.Mutex
+	cache map[string]subjectScope
+}
+
+func newScopeResolver(adb db.Database, integrationBaseURL string) *scopeResolver {
+	return &scopeResolver{
+		db:                adb,
+		integrationClient: integrationClient.NewIntegrationServiceClient(integrationBaseURL),
+		cache:             make(map[string]subjectScope),
+	}
+}
+
+func (r *scopeResolver) invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = make(map[string]subjectScope)
+}
+
+// resolve returns the scope of the api key when apiKeyID is set, otherwise of the user
+func (r *scopeResolver) resolve(ctx context.Context, userID string, apiKeyID *uint) (subjectScope, error) {
+	key := "user:" + userID
+	if apiKeyID != nil {
+		key = fmt.Sprintf("key:%d", *apiKeyID)
+	}
+
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	bindings, err := r.db.ListRoleBindingsForSubject(userID, apiKeyID)
+	if err != nil {
+		return subjectScope{}, err
+	}
+
+	scope := subjectScope{expiresAt: time.Now().Add(scopeCacheTTL)}
+	for _, binding := range bindings {
+		if !scope.scoped || rolePriority(binding.Role) > rolePriority(scope.role) {
+			scope.role = binding.Role
+		}
+		scope.scoped = true
+
+		switch api.RoleBindingScopeType(binding.ScopeType) {
+		case api.RoleBindingScopeIntegration:
+			scope.integrationIDs = appendUnique(scope.integrationIDs, binding.ScopeID)
+		case api.RoleBindingScopeIntegrationGroup:
+			group, err := r.integrationClient.GetIntegrationGroup(&httpclient.Context{Ctx: ctx, UserRole: api2.AdminRole}, binding.ScopeID)
+			if err != nil {
+				return subjectScope{}, fmt.Errorf("resolve integration group %s: %w", binding.ScopeID, err)
+			}
+			if scope.integrationIDs == nil {
+				scope.integrationIDs = []string{}
+			}
+			for _, id := range group.IntegrationIds {
+				scope.integrationIDs = appendUnique(scope.integrationIDs, id)
+			}
+		case api.RoleBindingScopeFramework:
+			scope.frameworkIDs = appendUnique(scope.frameworkIDs, binding.ScopeID)
+		}
+	}
+
+	r.mu.Lock()
+	r.cache[key] = scope
+	r.mu.Unlock()
+	return scope, nil
+}
+
+func appendUnique(ids []string, id string) []string {
+	if ids == nil {
+		ids = []string{}
+	}
+	if slices.Contains(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
+
+func rolePriority(role api2.Role) int {
+	switch role {
+	case api2.AdminRole:
+		return 2
+	case api2.EditorRole:
+		return 1
+	default:
+		return 0
+	}
+}
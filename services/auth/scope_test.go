@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+
+	api2 "github.com/opengovern/og-util/pkg/api"
+)
+
+func TestSubjectScopeEffectiveRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      subjectScope
+		globalRole api2.Role
+		want       api2.Role
+	}{
+		{name: "unscoped keeps the global role", scope: subjectScope{}, globalRole: api2.EditorRole, want: api2.EditorRole},
+		{name: "binding can not escalate a viewer", scope: subjectScope{scoped: true, role: api2.AdminRole}, globalRole: api2.ViewerRole, want: api2.ViewerRole},
+		{name: "binding can not escalate an editor", scope: subjectScope{scoped: true, role: api2.AdminRole}, globalRole: api2.EditorRole, want: api2.EditorRole},
+		{name: "binding lowers an admin", scope: subjectScope{scoped: true, role: api2.ViewerRole}, globalRole: api2.AdminRole, want: api2.ViewerRole},
+		{name: "same role", scope: subjectScope{scoped: true, role: api2.AdminRole}, globalRole: api2.AdminRole, want: api2.AdminRole},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.scope.effectiveRole(tc.globalRole); got != tc.want {
+				t.Errorf("effectiveRole(%s) = %s, want %s", tc.globalRole, got, tc.want)
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package auth
 import (
 	"context"
 	"crypto/rsa"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +22,8 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/opengovern/og-util/pkg/api"
 	"github.com/opengovern/og-util/pkg/httpserver"
+	"github.com/opengovern/opensecurity/pkg/audit"
+	"github.com/opengovern/opensecurity/pkg/rbac"
 	"github.com/opengovern/opensecurity/services/auth/db"
 	"github.com/opengovern/opensecurity/services/auth/utils"
 	"go.uber.org/zap"
@@ -42,6 +47,12 @@ type Server struct {
 	db                  db.Database
 	updateLoginUserList []User
 	updateLogin         chan User
+	scopes              *scopeResolver
+	apiKeyUsage         *apiKeyUsageTracker
+	groupRoles          *groupRoleResolver
+	sessions            *sessionTracker
+	revocations         *revocationList
+	rateLimits          *rateLimiter
 }
 
 type DexClaims struct {
@@ -108,6 +119,10 @@ func (s *Server) UpdateLastLoginLoop() {
 }
 
 func (s *Server) UpdateLastLogin(claim *userClaim) {
+	if claim.sessionID != "" {
+		s.sessions.track(claim)
+	}
+
 	timeNow := time.Now()
 	doUpdate := false
 	if claim.MemberSince == nil {
@@ -155,6 +170,16 @@ func (s *Server) Check(ctx context.Context, req *envoyauth.CheckRequest) (*envoy
 		authHeader = headers[strings.ToLower(echo.HeaderAuthorization)]
 	}
 
+	if strings.HasPrefix(httpRequest.Path, scimPublicPath) {
+		return s.checkScim(authHeader, unAuth), nil
+	}
+	if isAuditIngestRequest(httpRequest.Method, httpRequest.Path) {
+		s.logger.Warn("denied audit event ingestion through the gateway",
+			zap.String("reqId", httpRequest.Id),
+			zap.String("method", httpRequest.Method))
+		return auditIngestDeniedResponse(), nil
+	}
+
 	user, err := s.Verify(ctx, authHeader)
 	if err != nil {
 		s.logger.Warn("denied access due to unsuccessful token verification",
@@ -165,6 +190,28 @@ func (s *Server) Check(ctx context.Context, req *envoyauth.CheckRequest) (*envoy
 		return unAuth, nil
 	}
 
+	if user.apiKey != nil {
+		if !apiKeyAllows(user.apiKey, httpRequest.Method, httpRequest.Path) {
+			s.logger.Warn("denied access due to api key scopes",
+				zap.String("reqId", httpRequest.Id),
+				zap.String("path", httpRequest.Path),
+				zap.String("method", httpRequest.Method),
+				zap.Uint("apiKeyId", user.apiKey.ID))
+			return &envoyauth.CheckResponse{
+				Status: &status.Status{
+					Code: int32(rpc.PERMISSION_DENIED),
+				},
+				HttpResponse: &envoyauth.CheckResponse_DeniedResponse{
+					DeniedResponse: &envoyauth.DeniedHttpResponse{
+						Status: &envoytype.HttpStatus{Code: 403},
+						Body:   "api key is not allowed to access this route",
+					},
+				},
+			}, nil
+		}
+		s.apiKeyUsage.track(user.apiKey.ID, requestSourceIP(req))
+	}
+
 	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
 	if user.Email == "" {
 		s.logger.Warn("denied access due to failure to get email from token",
@@ -175,6 +222,19 @@ func (s *Server) Check(ctx context.Context, req *envoyauth.CheckRequest) (*envoy
 		return unAuth, nil
 	}
 
+	if err := s.syncUser(user); errors.Is(err, errSessionRevoked) {
+		s.logger.Warn("denied access due to revoked session",
+			zap.String("reqId", httpRequest.Id),
+			zap.String("email", user.Email),
+			zap.String("sessionId", user.sessionID))
+		return unAuth, nil
+	} else if err != nil {
+		s.logger.Error("denied access due to failure to check session revocations or apply group role mappings",
+			zap.String("email", user.Email),
+			zap.Error(err))
+		return unAuth, nil
+	}
+
 	theUser, err := utils.GetUserByEmail(user.Email, s.db)
 	if err != nil {
 		s.logger.Warn("failed to get user",
@@ -197,6 +257,43 @@ func (s *Server) Check(ctx context.Context, req *envoyauth.CheckRequest) (*envoy
 	user.MemberSince = &theUser.CreatedAt
 	user.UserLastLogin = &theUser.LastLogin
 
+	if user.sessionID != "" {
+		user.sourceIP = requestSourceIP(req)
+		user.userAgent = headers["user-agent"]
+	}
+
+	var apiKeyID *uint
+	if user.APIKeyID != "" {
+		id, err := strconv.ParseUint(user.APIKeyID, 10, 32)
+		if err == nil {
+			keyID := uint(id)
+			apiKeyID = &keyID
+		}
+	}
+	scope, err := s.scopes.resolve(ctx, theUser.ExternalId, apiKeyID)
+	if err != nil {
+		s.logger.Error("denied access due to failure to resolve role bindings",
+			zap.String("userId", theUser.ExternalId),
+			zap.String("apiKeyId", user.APIKeyID),
+			zap.Error(err))
+		return unAuth, nil
+	}
+	user.Role = scope.effectiveRole(user.Role)
+
+	caller := rateLimitCaller{userID: theUser.ExternalId, apiKeyID: user.APIKeyID, role: user.Role}
+	allowed, retryAfter, policy, err := s.rateLimits.allow(caller, httpRequest.Method, httpRequest.Path)
+	if err != nil {
+		// the limits protect the services, failing to load them should not take the platform down
+		s.logger.Error("failed to check rate limits", zap.String("caller", caller.key()), zap.Error(err))
+	} else if !allowed {
+		s.logger.Warn("denied access due to rate limit",
+			zap.String("reqId", httpRequest.Id),
+			zap.String("path", httpRequest.Path),
+			zap.String("caller", caller.key()),
+			zap.Uint("rateLimitPolicyId", policy.ID))
+		return rateLimitedResponse(retryAfter, policy), nil
+	}
+
 	go s.UpdateLastLogin(user)
 
 	return &envoyauth.CheckResponse{
@@ -234,7 +331,27 @@ func (s *Server) Check(ctx context.Context, req *envoyauth.CheckRequest) (*envoy
 					{
 						Header: &envoycore.HeaderValue{
 							Key:   httpserver.XPlatformUserConnectionsScope,
-							Value: theUser.ExternalId,
+							Value: scope.integrationsHeader(),
+						},
+					},
+					{
+						Header: &envoycore.HeaderValue{
+							Key:   rbac.XPlatformUserFrameworksScope,
+							Value: scope.frameworksHeader(),
+						},
+					},
+					{
+						// always set so a client can not pass its own value through
+						Header: &envoycore.HeaderValue{
+							Key:   audit.XPlatformAPIKeyIDHeader,
+							Value: user.APIKeyID,
+						},
+					},
+					{
+						// the audit log records this instead of the forwarded headers the client controls
+						Header: &envoycore.HeaderValue{
+							Key:   audit.XPlatformSourceIPHeader,
+							Value: requestSourceIP(req),
 						},
 					},
 				},
@@ -243,6 +360,12 @@ func (s *Server) Check(ctx context.Context, req *envoyauth.CheckRequest) (*envoy
 	}, nil
 }
 
+// requestSourceIP returns the client ip as envoy resolved it, the forwarded headers are set by the client
+// and only trusted by envoy up to its configured hops
+func requestSourceIP(req *envoyauth.CheckRequest) string {
+	return req.GetAttributes().GetSource().GetAddress().GetSocketAddress().GetAddress()
+}
+
 type userClaim struct {
 	Role           api.Role
 	Email          string
@@ -251,6 +374,21 @@ type userClaim struct {
 	ConnectionIDs  map[string][]string
 	ExternalUserID string `json:"sub"`
 	EmailVerified  bool
+	// APIKeyID is resolved from the token hash, it is not part of the signed claims
+	APIKeyID string `json:"-"`
+
+	apiKey *db.ApiKey
+	// set from the dex token, used by the group role mappings
+	name        string
+	groups      []string
+	connectorID string
+	// set for dex tokens, used by the session tracking and revocations
+	sessionID  string
+	dexSubject string
+	issuedAt   time.Time
+	expiresAt  time.Time
+	sourceIP   string
+	userAgent  string
 }
 
 func (u userClaim) Valid() error {
@@ -286,9 +424,17 @@ func (s *Server) Verify(ctx context.Context, authToken string) (*userClaim, erro
 		}
 		s.logger.Info("dex verifier claims", zap.Any("claims", claimsMap))
 
+		connectorID, _ := claimsMap.FederatedClaims["connector_id"].(string)
 		return &userClaim{
 			Email:         claimsMap.Email,
 			EmailVerified: claimsMap.EmailVerified,
+			name:          claimsMap.Name,
+			groups:        claimsMap.Groups,
+			connectorID:   connectorID,
+			sessionID:     sessionTokenID(token),
+			dexSubject:    dv.Subject,
+			issuedAt:      dv.IssuedAt,
+			expiresAt:     dv.Expiry,
 		}, nil
 	} else {
 		s.logger.Error("dex verifier verify error", zap.Error(err))
@@ -299,6 +445,23 @@ func (s *Server) Verify(ctx context.Context, authToken string) (*userClaim, erro
 			return s.platformPublicKey, nil
 		})
 		if errk == nil {
+			hash := sha512.Sum512([]byte(token))
+			apiKey, err := s.db.GetApiKeyByHash(hex.EncodeToString(hash[:]))
+			if err != nil {
+				s.logger.Error("failed to get api key by hash", zap.Error(err))
+				return nil, err
+			}
+			if apiKey == nil {
+				return nil, errors.New("api key not found")
+			}
+			if !apiKey.IsActive {
+				return nil, errors.New("api key is not active")
+			}
+			if apiKey.IsExpired(time.Now()) {
+				return nil, errors.New("api key is expired")
+			}
+			u.APIKeyID = strconv.FormatUint(uint64(apiKey.ID), 10)
+			u.apiKey = apiKey
 			return &u, nil
 		} else {
 			fmt.Println("failed to auth with platform cred due to", errk)
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionSeenInterval throttles the last seen updates of a session
+	sessionSeenInterval = time.Minute
+	// revocationCacheTTL bounds how long revocations made through another replica take to apply
+	revocationCacheTTL = 10 * time.Second
+	// userRevocationRetention outlives the dex token lifetimes, so a user revocation covers every token issued before it
+	userRevocationRetention = 7 * 24 * time.Hour
+	sessionCleanupInterval  = time.Hour
+	// maxTrackedSessions bounds the throttling state kept in memory
+	maxTrackedSessions = 10000
+)
+
+// sessionTokenID identifies a dex token without keeping the token itself
+func sessionTokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// sessionTracker stores the sessions seen by the auth gate, at most once per sessionSeenInterval per session
+type sessionTracker struct {
+	logger *zap.Logger
+	db     db.Database
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+func newSessionTracker(logger *zap.Logger, adb db.Database) *sessionTracker {
+	return &sessionTracker{
+		logger:   logger,
+		db:       adb,
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+func (t *sessionTracker) track(claim *userClaim) {
+	now := time.Now()
+	t.mu.Lock()
+	if last, ok := t.lastSeen[claim.sessionID]; ok && now.Sub(last) < sessionSeenInterval {
+		t.mu.Unlock()
+		return
+	}
+	if len(t.lastSeen) >= maxTrackedSessions {
+		for id, last := range t.lastSeen {
+			if now.Sub(last) >= sessionSeenInterval {
+				delete(t.lastSeen, id)
+			}
+		}
+	}
+	t.lastSeen[claim.sessionID] = now
+	t.mu.Unlock()
+
+	session := db.UserSession{
+		TokenID:        claim.sessionID,
+		UserExternalID: claim.ExternalUserID,
+		Email:          claim.Email,
+		ConnectorID:    claim.connectorID,
+		DexSubject:     claim.dexSubject,
+		SourceIP:       claim.sourceIP,
+		UserAgent:      claim.userAgent,
+		IssuedAt:       claim.issuedAt,
+		ExpiresAt:      claim.expiresAt,
+		LastSeenAt:     now,
+	}
+	if err := t.db.UpsertUserSession(&session); err != nil {
+		t.logger.Error("failed to update user session", zap.String("email", claim.Email), zap.Error(err))
+	}
+}
+
+// revocationList caches the active session revocations checked on every request
+type revocationList struct {
+	db db.Database
+
+	mu        sync.Mutex
+	tokens    map[string]bool
+	users     map[string]time.Time
+	expiresAt time.Time
+}
+
+func newRevocationList(adb db.Database) *revocationList {
+	return &revocationList{db: adb}
+}
+
+func (l *revocationList) invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expiresAt = time.Time{}
+}
+
+// isRevoked reports whether the token was revoked by itself or by a revocation of every session of its user
+func (l *revocationList) isRevoked(tokenID, userExternalID string, issuedAt time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := time.Now(); !now.Before(l.expiresAt) {
+		revocations, err := l.db.ListActiveSessionRevocations(now)
+		if err != nil {
+			return false, err
+		}
+		tokens := make(map[string]bool)
+		users := make(map[string]time.Time)
+		for _, r := range revocations {
+			if r.TokenID != "" {
+				tokens[r.TokenID] = true
+				continue
+			}
+			if r.RevokedBefore.After(users[r.UserExternalID]) {
+				users[r.UserExternalID] = r.RevokedBefore
+			}
+		}
+		l.tokens, l.users, l.expiresAt = tokens, users, now.Add(revocationCacheTTL)
+	}
+
+	if l.tokens[tokenID] {
+		return true, nil
+	}
+	revokedBefore, ok := l.users[userExternalID]
+	// iat has a second precision, a token issued in the second of the revocation is revoked as well
+	return ok && !issuedAt.After(revokedBefore), nil
+}
+
+// errSessionRevoked is returned by syncUser for the tokens of a revoked session
+var errSessionRevoked = errors.New("session revoked")
+
+// syncUser checks the session of a token was not revoked and then applies the group role mappings to its user.
+// The revocations are checked first, a revoked session must not create, promote or otherwise sync its user.
+func (s *Server) syncUser(claim *userClaim) error {
+	if claim.sessionID != "" {
+		user, err := s.db.GetUserByEmail(claim.Email)
+		if err != nil {
+			return err
+		}
+		var externalID string
+		if user != nil {
+			externalID = user.ExternalId
+		}
+		revoked, err := s.revocations.isRevoked(claim.sessionID, externalID, claim.issuedAt)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return errSessionRevoked
+		}
+	}
+	if claim.apiKey != nil {
+		return nil
+	}
+	return s.applyGroupRoles(claim)
+}
+
+// runSessionCleanup deletes the sessions and revocations of the expired tokens
+func runSessionCleanup(logger *zap.Logger, adb db.Database) {
+	t := time.NewTicker(sessionCleanupInterval)
+	defer t.Stop()
+
+	for ; ; <-t.C {
+		deleted, err := adb.DeleteExpiredSessions(time.Now())
+		if err != nil {
+			logger.Error("failed to delete expired sessions", zap.Error(err))
+			continue
+		}
+		if deleted > 0 {
+			logger.Info("deleted expired sessions", zap.Int64("count", deleted))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	api2 "github.com/opengovern/og-util/pkg/api"
+	idocker "github.com/opengovern/og-util/pkg/dockertest"
+	"github.com/opengovern/opensecurity/services/auth/db"
+	"github.com/opengovern/opensecurity/services/auth/utils"
+	"github.com/ory/dockertest/v3"
+	"go.uber.org/zap"
+)
+
+func TestRevocationListIsRevoked(t *testing.T) {
+	revokedAt := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	list := newRevocationList(db.Database{})
+	list.tokens = map[string]bool{"revoked-token": true}
+	list.users = map[string]time.Time{"oidc|jane@example.com": revokedAt}
+	list.expiresAt = time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		tokenID  string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "revoked token", tokenID: "revoked-token", userID: "oidc|john@example.com", issuedAt: revokedAt, want: true},
+		{name: "other token", tokenID: "other-token", userID: "oidc|john@example.com", issuedAt: revokedAt},
+		{name: "issued before the user revocation", tokenID: "other-token", userID: "oidc|jane@example.com", issuedAt: revokedAt.Add(-time.Hour), want: true},
+		{name: "issued in the second of the user revocation", tokenID: "other-token", userID: "oidc|jane@example.com", issuedAt: revokedAt, want: true},
+		{name: "issued after the user revocation", tokenID: "other-token", userID: "oidc|jane@example.com", issuedAt: revokedAt.Add(time.Second)},
+		{name: "revoked token of a revoked user", tokenID: "revoked-token", userID: "oidc|jane@example.com", issuedAt: revokedAt.Add(time.Hour), want: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := list.isRevoked(tc.tokenID, tc.userID, tc.issuedAt)
+			if err != nil {
+				t.Fatalf("isRevoked() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("isRevoked(%s, %s, %s) = %v, want %v", tc.tokenID, tc.userID, tc.issuedAt, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLogoutEverywhereKeepsGroupManagedUserInactive(t *testing.T) {
+	if pool, err := dockertest.NewPool(""); err != nil || pool.Client.Ping() != nil {
+		t.Skip("docker is not available")
+	}
+	adb := db.Database{Orm: idocker.StartupPostgreSQL(t)}
+	if err := adb.Initialize(); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := adb.CreateGroupRoleMapping(&db.GroupRoleMapping{Group: "engineers", Role: api2.EditorRole}); err != nil {
+		t.Fatalf("CreateGroupRoleMapping() error = %v", err)
+	}
+	user := &db.User{
+		Email:        "jane@example.com",
+		Role:         api2.EditorRole,
+		ConnectorId:  "oidc",
+		ExternalId:   "oidc|jane@example.com",
+		IsActive:     true,
+		GroupManaged: true,
+	}
+	if err := adb.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	server := &Server{
+		logger:      zap.NewNop(),
+		db:          adb,
+		groupRoles:  newGroupRoleResolver(adb),
+		revocations: newRevocationList(adb),
+	}
+	claim := func(issuedAt time.Time) *userClaim {
+		return &userClaim{Email: user.Email, EmailVerified: true, groups: []string{"engineers"}, sessionID: sessionTokenID(issuedAt.String()), issuedAt: issuedAt}
+	}
+	if err := server.syncUser(claim(time.Now().Add(-time.Minute))); err != nil {
+		t.Fatalf("syncUser() before the log out error = %v", err)
+	}
+
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.FormatUint(uint64(user.ID), 10))
+	routes := &httpRoutes{logger: zap.NewNop(), db: adb, authServer: server}
+	if err := routes.LogoutEverywhere(c); err != nil {
+		t.Fatalf("LogoutEverywhere() error = %v", err)
+	}
+
+	if err := server.syncUser(claim(time.Now().Add(-time.Minute))); err != errSessionRevoked {
+		t.Errorf("syncUser() of a session issued before the log out error = %v, want %v", err, errSessionRevoked)
+	}
+	// a token issued after the log out is not revoked, its groups still match but the user stays deactivated
+	if err := server.syncUser(claim(time.Now().Add(time.Minute))); err != nil {
+		t.Fatalf("syncUser() of a session issued after the log out error = %v", err)
+	}
+	if _, err := utils.GetUserByEmail(user.Email, adb); err == nil || err.Error() != "user disabled" {
+		t.Errorf("GetUserByEmail() error = %v, want the user disabled", err)
+	}
+
+	// a group managed user deactivated by the mappings is not activated again once its groups match
+	if err := adb.SetUserGroupRole(user.ID, api2.EditorRole, false); err != nil {
+		t.Fatalf("SetUserGroupRole() error = %v", err)
+	}
+	server.groupRoles.invalidate()
+	if err := server.syncUser(claim(time.Now().Add(time.Minute))); err != nil {
+		t.Fatalf("syncUser() of a deactivated group managed user error = %v", err)
+	}
+	if _, err := utils.GetUserByEmail(user.Email, adb); err == nil || err.Error() != "user disabled" {
+		t.Errorf("GetUserByEmail() of a deactivated group managed user error = %v, want the user disabled", err)
+	}
+}
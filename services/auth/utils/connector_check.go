@@ -0,0 +1,423 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/opengovern/opensecurity/services/auth/api"
+)
+
+const connectorCheckTimeout = 10 * time.Second
+
+// ConnectorCheckPolicy limits the endpoints the connector checks connect to, the checks run from inside the
+// platform network and would otherwise probe any host and port on behalf of the caller
+type ConnectorCheckPolicy struct {
+	Schemes   []string
+	HTTPPorts []string
+	LDAPPorts []string
+	// AllowPrivateNetworks lets the checks connect to loopback, private and link-local addresses
+	AllowPrivateNetworks bool
+}
+
+// NewConnectorCheckPolicy builds the policy from comma separated lists, empty lists keep the defaults:
+// https on port 443 and ldap on ports 389 and 636
+func NewConnectorCheckPolicy(schemes, httpPorts, ldapPorts string) ConnectorCheckPolicy {
+	split := func(value string, def []string) []string {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 {
+			return def
+		}
+		return items
+	}
+	return ConnectorCheckPolicy{
+		Schemes:   split(schemes, []string{"https"}),
+		HTTPPorts: split(httpPorts, []string{"443"}),
+		LDAPPorts: split(ldapPorts, []string{"389", "636"}),
+	}
+}
+
+type endpointNotAllowedError struct {
+	host string
+}
+
+func (e endpointNotAllowedError) Error() string {
+	return fmt.Sprintf("%s is not an allowed endpoint", e.host)
+}
+
+// checkURL returns an error naming only the host when the url is outside the policy
+func (p ConnectorCheckPolicy) checkURL(u *url.URL) error {
+	if u.Hostname() == "" {
+		return endpointNotAllowedError{host: u.String()}
+	}
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[scheme]
+	}
+	if !slices.Contains(p.Schemes, scheme) || !slices.Contains(p.HTTPPorts, port) {
+		return endpointNotAllowedError{host: u.Host}
+	}
+	return nil
+}
+
+type privateAddressError struct{}
+
+func (privateAddressError) Error() string {
+	return "private addresses are not allowed"
+}
+
+// checkAddress refuses the loopback, private, link-local and unspecified addresses unless the policy allows them.
+// It runs on the resolved address of every connection, so redirects and names resolving to such addresses are covered.
+func (p ConnectorCheckPolicy) checkAddress(address string) error {
+	if p.AllowPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return privateAddressError{}
+	}
+	return nil
+}
+
+// dialer connects to the addresses allowed by checkAddress only
+func (p ConnectorCheckPolicy) dialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: connectorCheckTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return p.checkAddress(address)
+		},
+	}
+}
+
+type connectorChecker struct {
+	policy ConnectorCheckPolicy
+	client *http.Client
+}
+
+func newConnectorChecker(policy ConnectorCheckPolicy) *connectorChecker {
+	return &connectorChecker{
+		policy: policy,
+		client: &http.Client{
+			Timeout: connectorCheckTimeout,
+			// no proxy, the addresses are checked where the connections are made
+			Transport: &http.Transport{
+				DialContext:         policy.dialer().DialContext,
+				TLSHandshakeTimeout: connectorCheckTimeout,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return fmt.Errorf("too many redirects")
+				}
+				return policy.checkURL(req.URL)
+			},
+		},
+	}
+}
+
+// ParseCertificates parses the PEM encoded certificates, at least one is expected
+func ParseCertificates(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
+
+func connectorCheck(name string, err error, success string) api.ConnectorCheck {
+	if err != nil {
+		return api.ConnectorCheck{Name: name, Success: false, Message: err.Error()}
+	}
+	return api.ConnectorCheck{Name: name, Success: true, Message: success}
+}
+
+// CheckConnector tries the connector settings against the identity provider without saving them, only the
+// endpoints allowed by the policy are contacted
+func CheckConnector(params CreateConnectorRequest, policy ConnectorCheckPolicy) []api.ConnectorCheck {
+	c := newConnectorChecker(policy)
+	switch params.ConnectorType {
+	case "oidc":
+		return c.checkOIDCConnector(params)
+	case "saml":
+		return c.checkSAMLConnector(params)
+	case "ldap":
+		return c.checkLDAPConnector(params)
+	case "github":
+		return c.checkGitHubConnector(params)
+	}
+	return []api.ConnectorCheck{{Name: "connector type", Message: fmt.Sprintf("unsupported connector_type: %s", params.ConnectorType)}}
+}
+
+func (c *connectorChecker) checkOIDCConnector(params CreateConnectorRequest) []api.ConnectorCheck {
+	issuer := params.Issuer
+	switch params.ConnectorSubType {
+	case "google-workspace":
+		issuer = "https://accounts.google.com"
+	case "entraid":
+		if issuer == "" {
+			var err error
+			if issuer, err = fetchEntraIDIssuer(params.TenantID); err != nil {
+				return []api.ConnectorCheck{connectorCheck("issuer", err, "")}
+			}
+		}
+	}
+
+	var discovery struct {
+		Issuer        string `json:"issuer"`
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	err := c.getJSON(strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &discovery)
+	if err == nil && strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		err = fmt.Errorf("discovery document is for issuer %s", discovery.Issuer)
+	}
+	checks := []api.ConnectorCheck{connectorCheck("discovery", err, fmt.Sprintf("fetched the discovery document of %s", issuer))}
+	if err != nil {
+		return checks
+	}
+	if discovery.TokenEndpoint == "" {
+		return append(checks, api.ConnectorCheck{Name: "client credentials", Message: "discovery document has no token endpoint"})
+	}
+
+	// a client credentials grant is refused with invalid_client only when the client itself is rejected
+	form := url.Values{"grant_type": {"client_credentials"}, "scope": {"openid"}}
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return append(checks, connectorCheck("client credentials", err, ""))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(params.ClientID), url.QueryEscape(params.ClientSecret))
+	var tokenResp struct {
+		Error string `json:"error"`
+	}
+	err = c.doJSON(req, &tokenResp, true)
+	if err == nil && tokenResp.Error == "invalid_client" {
+		err = fmt.Errorf("client_id or client_secret rejected by the identity provider")
+	}
+	return append(checks, connectorCheck("client credentials", err, "client accepted by the identity provider"))
+}
+
+func (c *connectorChecker) checkSAMLConnector(params CreateConnectorRequest) []api.ConnectorCheck {
+	var checks []api.ConnectorCheck
+	if params.SAML == nil {
+		return []api.ConnectorCheck{{Name: "config", Message: "saml config is required for saml connector"}}
+	}
+
+	if params.SAML.CAData != "" {
+		certs, err := ParseCertificates(params.SAML.CAData)
+		if err == nil {
+			for _, cert := range certs {
+				if time.Now().After(cert.NotAfter) {
+					err = fmt.Errorf("certificate %s expired on %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+					break
+				}
+			}
+		}
+		checks = append(checks, connectorCheck("certificate", err, fmt.Sprintf("%d valid signing certificate(s)", len(certs))))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, params.SAML.SSOURL, nil)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = c.do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				err = fmt.Errorf("sso url responded with status %d", resp.StatusCode)
+			}
+		}
+	}
+	return append(checks, connectorCheck("sso url", err, "sso url is reachable"))
+}
+
+func (c *connectorChecker) checkGitHubConnector(params CreateConnectorRequest) []api.ConnectorCheck {
+	webURL, apiURL := "https://github.com", "https://api.github.com"
+	if params.ConnectorSubType == "enterprise" && params.GitHub != nil && params.GitHub.HostName != "" {
+		webURL = "https://" + params.GitHub.HostName
+		apiURL = webURL + "/api/v3"
+	}
+
+	// exchanging an invalid code tells wrong client credentials apart from a wrong code
+	form := url.Values{"client_id": {params.ClientID}, "client_secret": {params.ClientSecret}, "code": {"connection-check"}}
+	req, err := http.NewRequest(http.MethodPost, webURL+"/login/oauth/access_token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return []api.ConnectorCheck{connectorCheck("client credentials", err, "")}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var tokenResp struct {
+		Error string `json:"error"`
+	}
+	err = c.doJSON(req, &tokenResp, true)
+	if err == nil && tokenResp.Error == "incorrect_client_credentials" {
+		err = fmt.Errorf("client_id or client_secret rejected by github")
+	}
+	checks := []api.ConnectorCheck{connectorCheck("client credentials", err, "client accepted by github")}
+
+	if params.GitHub != nil {
+		for _, org := range params.GitHub.Orgs {
+			var o struct {
+				Login string `json:"login"`
+			}
+			err := c.getJSON(fmt.Sprintf("%s/orgs/%s", apiURL, url.PathEscape(org.Name)), &o)
+			checks = append(checks, connectorCheck("org "+org.Name, err, "organization found"))
+		}
+	}
+	return checks
+}
+
+func (c *connectorChecker) getJSON(u string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(req, v, false)
+}
+
+// do sends the request when its url is allowed by the policy, transport errors are not returned as they
+// would tell apart closed ports and unreachable hosts
+func (c *connectorChecker) do(req *http.Request) (*http.Response, error) {
+	if err := c.policy.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var notAllowed endpointNotAllowedError
+		if errors.As(err, &notAllowed) {
+			return nil, notAllowed
+		}
+		if errors.As(err, &privateAddressError{}) {
+			return nil, endpointNotAllowedError{host: req.URL.Host}
+		}
+		return nil, fmt.Errorf("%s is not reachable", req.URL.Host)
+	}
+	return resp, nil
+}
+
+// doJSON decodes the response body, error statuses are only decoded when allowErrorStatus is set
+func (c *connectorChecker) doJSON(req *http.Request, v any, allowErrorStatus bool) error {
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError || (!allowErrorStatus && resp.StatusCode != http.StatusOK) {
+		return fmt.Errorf("%s responded with status %d", req.URL.Host, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%s is not reachable", req.URL.Host)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("unexpected response from %s", req.URL.Host)
+	}
+	return nil
+}
+
+func (c *connectorChecker) checkLDAPConnector(params CreateConnectorRequest) []api.ConnectorCheck {
+	cfg := params.LDAP
+	if cfg == nil {
+		return []api.ConnectorCheck{{Name: "config", Message: "ldap config is required for ldap connector"}}
+	}
+	host, port, err := net.SplitHostPort(cfg.Host)
+	if err != nil {
+		return []api.ConnectorCheck{{Name: "connection", Message: "host must be in the host:port form"}}
+	}
+	if !slices.Contains(c.policy.LDAPPorts, port) {
+		return []api.ConnectorCheck{connectorCheck("connection", endpointNotAllowedError{host: cfg.Host}, "")}
+	}
+	tlsConfig := &tls.Config{ServerName: host, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.RootCAData != "" {
+		certs, err := ParseCertificates(cfg.RootCAData)
+		if err != nil {
+			return []api.ConnectorCheck{connectorCheck("connection", err, "")}
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		for _, cert := range certs {
+			tlsConfig.RootCAs.AddCert(cert)
+		}
+	}
+
+	dialer := ldap.DialWithDialer(c.policy.dialer())
+	var conn *ldap.Conn
+	if cfg.InsecureNoSSL || cfg.StartTLS {
+		conn, err = ldap.DialURL("ldap://"+cfg.Host, dialer)
+	} else {
+		conn, err = ldap.DialURL("ldaps://"+cfg.Host, dialer, ldap.DialWithTLSConfig(tlsConfig))
+	}
+	if errors.As(err, &privateAddressError{}) {
+		return []api.ConnectorCheck{connectorCheck("connection", endpointNotAllowedError{host: cfg.Host}, "")}
+	}
+	if err != nil {
+		return []api.ConnectorCheck{{Name: "connection", Message: fmt.Sprintf("%s is not reachable", cfg.Host)}}
+	}
+	defer conn.Close()
+	conn.SetTimeout(connectorCheckTimeout)
+
+	if cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			return []api.ConnectorCheck{{Name: "connection", Message: fmt.Sprintf("start tls with %s failed", cfg.Host)}}
+		}
+	}
+	checks := []api.ConnectorCheck{connectorCheck("connection", nil, fmt.Sprintf("connected to %s", cfg.Host))}
+
+	bindMessage := "anonymous bind succeeded"
+	if cfg.BindDN != "" {
+		err = conn.Bind(cfg.BindDN, cfg.BindPW)
+		bindMessage = fmt.Sprintf("bound as %s", cfg.BindDN)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	return append(checks, connectorCheck("bind", ldapBindError(err), bindMessage))
+}
+
+// ldapBindError keeps only the ldap result of a failed bind
+func ldapBindError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var ldapErr *ldap.Error
+	if !errors.As(err, &ldapErr) || ldapErr.ResultCode == ldap.ErrorNetwork {
+		return fmt.Errorf("bind failed")
+	}
+	if ldapErr.ResultCode == ldap.LDAPResultInvalidCredentials {
+		return fmt.Errorf("invalid credentials")
+	}
+	return fmt.Errorf("bind failed: %s", ldap.LDAPResultCodeMap[ldapErr.ResultCode])
+}
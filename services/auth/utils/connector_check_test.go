@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opengovern/opensecurity/services/auth/api"
+)
+
+func TestNewConnectorCheckPolicy(t *testing.T) {
+	policy := NewConnectorCheckPolicy("", " ", "")
+	want := ConnectorCheckPolicy{Schemes: []string{"https"}, HTTPPorts: []string{"443"}, LDAPPorts: []string{"389", "636"}}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("NewConnectorCheckPolicy() = %+v, want %+v", policy, want)
+	}
+
+	policy = NewConnectorCheckPolicy("HTTPS, http", "443,8443", "3269")
+	want = ConnectorCheckPolicy{Schemes: []string{"https", "http"}, HTTPPorts: []string{"443", "8443"}, LDAPPorts: []string{"3269"}}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("NewConnectorCheckPolicy() = %+v, want %+v", policy, want)
+	}
+}
+
+func TestConnectorCheckPolicyCheckURL(t *testing.T) {
+	policy := NewConnectorCheckPolicy("", "443,8443", "")
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "https", url: "https://idp.example.com/.well-known/openid-configuration"},
+		{name: "allowed port", url: "https://idp.example.com:8443/token"},
+		{name: "scheme case", url: "HTTPS://idp.example.com"},
+		{name: "http", url: "http://idp.example.com", wantErr: true},
+		{name: "port not allowed", url: "https://10.0.0.1:5432", wantErr: true},
+		{name: "other scheme", url: "gopher://idp.example.com:443", wantErr: true},
+		{name: "no host", url: "https:///path", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := policy.checkURL(u); (err != nil) != tc.wantErr {
+				t.Errorf("checkURL(%s) error = %v, wantErr %v", tc.url, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckConnectorRefusesEndpoints(t *testing.T) {
+	policy := NewConnectorCheckPolicy("", "", "")
+	tests := []struct {
+		name   string
+		params CreateConnectorRequest
+	}{
+		{name: "oidc issuer port", params: CreateConnectorRequest{ConnectorType: "oidc", Issuer: "https://10.0.0.1:6379"}},
+		{name: "saml http url", params: CreateConnectorRequest{ConnectorType: "saml", SAML: &api.SAMLConnectorConfig{SSOURL: "http://10.0.0.1/sso"}}},
+		{name: "ldap port", params: CreateConnectorRequest{ConnectorType: "ldap", LDAP: &api.LDAPConnectorConfig{Host: "10.0.0.1:5432"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checks := CheckConnector(tc.params, policy)
+			last := checks[len(checks)-1]
+			if last.Success {
+				t.Fatalf("CheckConnector() succeeded against an endpoint outside the policy")
+			}
+			if !strings.HasSuffix(last.Message, "is not an allowed endpoint") {
+				t.Errorf("CheckConnector() message = %q, want a not allowed endpoint", last.Message)
+			}
+		})
+	}
+}
+
+func TestConnectorCheckPolicyCheckAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443"},
+		{address: "127.0.0.1:443", wantErr: true},
+		{address: "[::1]:443", wantErr: true},
+		{address: "10.0.0.1:443", wantErr: true},
+		{address: "172.16.0.1:443", wantErr: true},
+		{address: "192.168.1.1:636", wantErr: true},
+		{address: "169.254.169.254:80", wantErr: true},
+		{address: "[fe80::1]:443", wantErr: true},
+		{address: "[fd00::1]:443", wantErr: true},
+		{address: "0.0.0.0:443", wantErr: true},
+	}
+	for _, tc := range tests {
+		err := ConnectorCheckPolicy{}.checkAddress(tc.address)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("checkAddress(%s) error = %v, wantErr %v", tc.address, err, tc.wantErr)
+		}
+		if err := (ConnectorCheckPolicy{AllowPrivateNetworks: true}).checkAddress(tc.address); err != nil {
+			t.Errorf("checkAddress(%s) with private networks allowed error = %v", tc.address, err)
+		}
+	}
+}
+
+func TestCheckConnectorRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	policy := NewConnectorCheckPolicy("http", serverURL.Port(), serverURL.Port())
+	tests := []struct {
+		name   string
+		params CreateConnectorRequest
+	}{
+		{name: "oidc issuer on loopback", params: CreateConnectorRequest{ConnectorType: "oidc", Issuer: server.URL}},
+		{name: "ldap host on loopback", params: CreateConnectorRequest{ConnectorType: "ldap", LDAP: &api.LDAPConnectorConfig{Host: serverURL.Host}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			checks := CheckConnector(tc.params, policy)
+			last := checks[len(checks)-1]
+			if last.Success {
+				t.Fatalf("CheckConnector() succeeded against a loopback address")
+			}
+			if !strings.HasSuffix(last.Message, "is not an allowed endpoint") {
+				t.Errorf("CheckConnector() message = %q, want a not allowed endpoint", last.Message)
+			}
+		})
+	}
+
+	policy.AllowPrivateNetworks = true
+	checks := CheckConnector(CreateConnectorRequest{ConnectorType: "oidc", Issuer: server.URL}, policy)
+	if last := checks[len(checks)-1]; strings.HasSuffix(last.Message, "is not an allowed endpoint") {
+		t.Errorf("CheckConnector() message = %q with private networks allowed", last.Message)
+	}
+}
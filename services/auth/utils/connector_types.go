@@ -0,0 +1,305 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	dexapi "github.com/dexidp/dex/api/v2"
+)
+
+// SAMLConfig is the config of the Dex saml connector
+type SAMLConfig struct {
+	SSOURL                          string `json:"ssoURL"`
+	CAData                          []byte `json:"caData,omitempty"`
+	EntityIssuer                    string `json:"entityIssuer,omitempty"`
+	SSOIssuer                       string `json:"ssoIssuer,omitempty"`
+	RedirectURI                     string `json:"redirectURI"`
+	UsernameAttr                    string `json:"usernameAttr"`
+	EmailAttr                       string `json:"emailAttr"`
+	GroupsAttr                      string `json:"groupsAttr,omitempty"`
+	GroupsDelim                     string `json:"groupsDelim,omitempty"`
+	NameIDPolicyFormat              string `json:"nameIDPolicyFormat,omitempty"`
+	InsecureSkipSignatureValidation bool   `json:"insecureSkipSignatureValidation,omitempty"`
+}
+
+type LDAPUserSearchConfig struct {
+	BaseDN                string `json:"baseDN"`
+	Filter                string `json:"filter,omitempty"`
+	Username              string `json:"username"`
+	IDAttr                string `json:"idAttr"`
+	EmailAttr             string `json:"emailAttr"`
+	NameAttr              string `json:"nameAttr,omitempty"`
+	PreferredUsernameAttr string `json:"preferredUsernameAttr,omitempty"`
+}
+
+type LDAPUserMatcher struct {
+	UserAttr  string `json:"userAttr"`
+	GroupAttr string `json:"groupAttr"`
+}
+
+type LDAPGroupSearchConfig struct {
+	BaseDN       string            `json:"baseDN"`
+	Filter       string            `json:"filter,omitempty"`
+	UserMatchers []LDAPUserMatcher `json:"userMatchers"`
+	NameAttr     string            `json:"nameAttr"`
+}
+
+// LDAPConfig is the config of the Dex ldap connector
+type LDAPConfig struct {
+	Host               string                 `json:"host"`
+	InsecureNoSSL      bool                   `json:"insecureNoSSL,omitempty"`
+	InsecureSkipVerify bool                   `json:"insecureSkipVerify,omitempty"`
+	StartTLS           bool                   `json:"startTLS,omitempty"`
+	RootCAData         []byte                 `json:"rootCAData,omitempty"`
+	BindDN             string                 `json:"bindDN,omitempty"`
+	BindPW             string                 `json:"bindPW,omitempty"`
+	UsernamePrompt     string                 `json:"usernamePrompt,omitempty"`
+	UserSearch         LDAPUserSearchConfig   `json:"userSearch"`
+	GroupSearch        *LDAPGroupSearchConfig `json:"groupSearch,omitempty"`
+}
+
+type GitHubOrgConfig struct {
+	Name  string   `json:"name"`
+	Teams []string `json:"teams,omitempty"`
+}
+
+// GitHubConfig is the config of the Dex github connector
+type GitHubConfig struct {
+	ClientID      string            `json:"clientID"`
+	ClientSecret  string            `json:"clientSecret"`
+	RedirectURI   string            `json:"redirectURI"`
+	Orgs          []GitHubOrgConfig `json:"orgs,omitempty"`
+	LoadAllGroups bool              `json:"loadAllGroups,omitempty"`
+	TeamNameField string            `json:"teamNameField,omitempty"`
+	UseLoginAsID  bool              `json:"useLoginAsID,omitempty"`
+	HostName      string            `json:"hostName,omitempty"`
+}
+
+// ldapDefaults are the attributes of the directories of each ldap sub-type
+var ldapDefaults = map[string]struct {
+	userFilter, username, idAttr, emailAttr, nameAttr string
+	groupFilter, userAttr, groupAttr, groupNameAttr   string
+}{
+	"general": {
+		userFilter: "(objectClass=person)", username: "uid", idAttr: "uid", emailAttr: "mail", nameAttr: "cn",
+		groupFilter: "(objectClass=groupOfNames)", userAttr: "DN", groupAttr: "member", groupNameAttr: "cn",
+	},
+	"active-directory": {
+		userFilter: "(objectClass=person)", username: "sAMAccountName", idAttr: "DN", emailAttr: "userPrincipalName", nameAttr: "cn",
+		groupFilter: "(objectClass=group)", userAttr: "DN", groupAttr: "member", groupNameAttr: "cn",
+	},
+}
+
+func dexCallbackURL() string {
+	return strings.Split(os.Getenv("DEX_CALLBACK_URL"), ",")[0]
+}
+
+func OrDefault(value, def string) string {
+	if strings.TrimSpace(value) == "" {
+		return def
+	}
+	return value
+}
+
+func newCreateConnectorReq(params CreateConnectorRequest, config any) (*dexapi.CreateConnectorReq, error) {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s config: %w", params.ConnectorType, err)
+	}
+	return &dexapi.CreateConnectorReq{
+		Connector: &dexapi.Connector{
+			Id:     params.ID,
+			Type:   params.ConnectorType,
+			Name:   params.Name,
+			Config: configBytes,
+		},
+	}, nil
+}
+
+func BuildSAMLConfig(params CreateConnectorRequest) (*SAMLConfig, error) {
+	saml := params.SAML
+	if saml == nil {
+		return nil, fmt.Errorf("saml config is required for saml connector")
+	}
+	if strings.TrimSpace(saml.CAData) == "" && !saml.InsecureSkipSignatureValidation {
+		return nil, fmt.Errorf("ca_data is required for saml connector")
+	}
+	if saml.CAData != "" {
+		if _, err := ParseCertificates(saml.CAData); err != nil {
+			return nil, fmt.Errorf("invalid ca_data: %w", err)
+		}
+	}
+	nameIDPolicyFormat := saml.NameIDPolicyFormat
+	if nameIDPolicyFormat == "" {
+		nameIDPolicyFormat = "persistent"
+	}
+	return &SAMLConfig{
+		SSOURL:                          saml.SSOURL,
+		CAData:                          []byte(saml.CAData),
+		EntityIssuer:                    saml.EntityIssuer,
+		SSOIssuer:                       saml.SSOIssuer,
+		RedirectURI:                     dexCallbackURL(),
+		UsernameAttr:                    OrDefault(saml.UsernameAttr, "name"),
+		EmailAttr:                       OrDefault(saml.EmailAttr, "email"),
+		GroupsAttr:                      OrDefault(saml.GroupsAttr, "groups"),
+		GroupsDelim:                     saml.GroupsDelim,
+		NameIDPolicyFormat:              nameIDPolicyFormat,
+		InsecureSkipSignatureValidation: saml.InsecureSkipSignatureValidation,
+	}, nil
+}
+
+func CreateSAMLConnector(params CreateConnectorRequest) (*dexapi.CreateConnectorReq, error) {
+	config, err := BuildSAMLConfig(params)
+	if err != nil {
+		return nil, err
+	}
+	return newCreateConnectorReq(params, config)
+}
+
+func BuildLDAPConfig(params CreateConnectorRequest) (*LDAPConfig, error) {
+	ldap := params.LDAP
+	if ldap == nil {
+		return nil, fmt.Errorf("ldap config is required for ldap connector")
+	}
+	if _, _, err := net.SplitHostPort(ldap.Host); err != nil {
+		return nil, fmt.Errorf("ldap host should be host:port: %w", err)
+	}
+	if ldap.RootCAData != "" {
+		if _, err := ParseCertificates(ldap.RootCAData); err != nil {
+			return nil, fmt.Errorf("invalid root_ca_data: %w", err)
+		}
+	}
+	defaults, ok := ldapDefaults[OrDefault(params.ConnectorSubType, "general")]
+	if !ok {
+		return nil, fmt.Errorf("unsupported connector_sub_type: %s", params.ConnectorSubType)
+	}
+
+	config := &LDAPConfig{
+		Host:               ldap.Host,
+		InsecureNoSSL:      ldap.InsecureNoSSL,
+		InsecureSkipVerify: ldap.InsecureSkipVerify,
+		StartTLS:           ldap.StartTLS,
+		BindDN:             ldap.BindDN,
+		BindPW:             ldap.BindPW,
+		UsernamePrompt:     OrDefault(ldap.UsernamePrompt, "Username"),
+		UserSearch: LDAPUserSearchConfig{
+			BaseDN:                ldap.UserSearch.BaseDN,
+			Filter:                OrDefault(ldap.UserSearch.Filter, defaults.userFilter),
+			Username:              OrDefault(ldap.UserSearch.Username, defaults.username),
+			IDAttr:                OrDefault(ldap.UserSearch.IDAttr, defaults.idAttr),
+			EmailAttr:             OrDefault(ldap.UserSearch.EmailAttr, defaults.emailAttr),
+			NameAttr:              OrDefault(ldap.UserSearch.NameAttr, defaults.nameAttr),
+			PreferredUsernameAttr: ldap.UserSearch.PreferredUsernameAttr,
+		},
+	}
+	if ldap.RootCAData != "" {
+		config.RootCAData = []byte(ldap.RootCAData)
+	}
+	if g := ldap.GroupSearch; g != nil {
+		config.GroupSearch = &LDAPGroupSearchConfig{
+			BaseDN: g.BaseDN,
+			Filter: OrDefault(g.Filter, defaults.groupFilter),
+			UserMatchers: []LDAPUserMatcher{{
+				UserAttr:  OrDefault(g.UserAttr, defaults.userAttr),
+				GroupAttr: OrDefault(g.GroupAttr, defaults.groupAttr),
+			}},
+			NameAttr: OrDefault(g.NameAttr, defaults.groupNameAttr),
+		}
+	}
+	return config, nil
+}
+
+func CreateLDAPConnector(params CreateConnectorRequest) (*dexapi.CreateConnectorReq, error) {
+	config, err := BuildLDAPConfig(params)
+	if err != nil {
+		return nil, err
+	}
+	return newCreateConnectorReq(params, config)
+}
+
+func BuildGitHubConfig(params CreateConnectorRequest) (*GitHubConfig, error) {
+	if params.ClientID == "" || params.ClientSecret == "" {
+		return nil, fmt.Errorf("client_id and client_secret are required for github connector")
+	}
+	config := &GitHubConfig{
+		ClientID:     params.ClientID,
+		ClientSecret: params.ClientSecret,
+		RedirectURI:  dexCallbackURL(),
+	}
+	if gh := params.GitHub; gh != nil {
+		for _, org := range gh.Orgs {
+			config.Orgs = append(config.Orgs, GitHubOrgConfig{Name: org.Name, Teams: org.Teams})
+		}
+		config.LoadAllGroups = gh.LoadAllGroups
+		config.TeamNameField = gh.TeamNameField
+		config.UseLoginAsID = gh.UseLoginAsID
+		config.HostName = gh.HostName
+	}
+	if params.ConnectorSubType == "enterprise" && config.HostName == "" {
+		return nil, fmt.Errorf("github.host_name is required for enterprise github connector")
+	}
+	if params.ConnectorSubType != "enterprise" {
+		config.HostName = ""
+	}
+	return config, nil
+}
+
+func CreateGitHubConnector(params CreateConnectorRequest) (*dexapi.CreateConnectorReq, error) {
+	config, err := BuildGitHubConfig(params)
+	if err != nil {
+		return nil, err
+	}
+	return newCreateConnectorReq(params, config)
+}
+
+// UpdateConnectorConfig builds the dex update request of any supported connector type
+func UpdateConnectorConfig(params UpdateConnectorRequest) (*dexapi.UpdateConnectorReq, error) {
+	if params.ConnectorType == "oidc" {
+		return UpdateOIDCConnector(params)
+	}
+	creator := GetConnectorCreator(params.ConnectorType)
+	if creator == nil {
+		return nil, fmt.Errorf("unsupported connector_type: %s", params.ConnectorType)
+	}
+	createReq, err := creator(CreateConnectorRequest{
+		ConnectorType:    params.ConnectorType,
+		ConnectorSubType: params.ConnectorSubType,
+		ClientID:         params.ClientID,
+		ClientSecret:     params.ClientSecret,
+		SAML:             params.SAML,
+		LDAP:             params.LDAP,
+		GitHub:           params.GitHub,
+		ID:               params.ID,
+		Name:             params.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dexapi.UpdateConnectorReq{
+		Id:        params.ID,
+		NewName:   params.Name,
+		NewConfig: createReq.Connector.Config,
+	}, nil
+}
+
+// ConnectorSummary returns the address and client id of a connector config to list it with
+func ConnectorSummary(connectorType string, config []byte) (issuer string, clientID string, err error) {
+	switch connectorType {
+	case "saml":
+		var c SAMLConfig
+		err = json.Unmarshal(config, &c)
+		return c.SSOURL, "", err
+	case "ldap":
+		var c LDAPConfig
+		err = json.Unmarshal(config, &c)
+		return c.Host, "", err
+	case "github":
+		var c GitHubConfig
+		err = json.Unmarshal(config, &c)
+		return OrDefault(c.HostName, "github.com"), c.ClientID, err
+	}
+	return "", "", nil
+}
@@ -10,29 +10,36 @@ import (
 	"strings"
 
 	dexapi "github.com/dexidp/dex/api/v2"
+	"github.com/opengovern/opensecurity/services/auth/api"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 type CreateConnectorRequest struct {
 
-	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc"`                                  // 'oidc' is supported for now
-	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid"` // Optional sub-type
+	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc saml ldap github"`
+	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid active-directory enterprise"` // Optional sub-type
 	Issuer           string `json:"issuer,omitempty" validate:"omitempty,url"`
 	TenantID         string `json:"tenant_id,omitempty" validate:"omitempty,uuid"`
-	ClientID         string `json:"client_id" validate:"required"`
-	ClientSecret     string `json:"client_secret" validate:"required"`
+	ClientID         string `json:"client_id"`
+	ClientSecret     string `json:"client_secret"`
+	SAML             *api.SAMLConnectorConfig   `json:"saml,omitempty"`
+	LDAP             *api.LDAPConnectorConfig   `json:"ldap,omitempty"`
+	GitHub           *api.GitHubConnectorConfig `json:"github,omitempty"`
 	ID               string `json:"id,omitempty"`   // Optional
 	Name             string `json:"name,omitempty"` // Optional
 }
 type UpdateConnectorRequest struct {
 	ConnectorID 	string `json:"connector_id" validate:"required"`
-	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc"`                                  // 'oidc' is supported for now
-	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid"` // Optional sub-type
+	ConnectorType    string `json:"connector_type" validate:"required,oneof=oidc saml ldap github"`
+	ConnectorSubType string `json:"connector_sub_type" validate:"omitempty,oneof=general google-workspace entraid active-directory enterprise"` // Optional sub-type
 	Issuer           string `json:"issuer,omitempty" validate:"omitempty,url"`
 	TenantID         string `json:"tenant_id,omitempty" validate:"omitempty,uuid"`
-	ClientID         string `json:"client_id" validate:"required"`
-	ClientSecret     string `json:"client_secret" validate:"required"`
+	ClientID         string `json:"client_id"`
+	ClientSecret     string `json:"client_secret"`
+	SAML             *api.SAMLConnectorConfig   `json:"saml,omitempty"`
+	LDAP             *api.LDAPConnectorConfig   `json:"ldap,omitempty"`
+	GitHub           *api.GitHubConnectorConfig `json:"github,omitempty"`
 	ID               string `json:"id,omitempty"`   // Optional
 	Name             string `json:"name,omitempty"` // Optional
 }
@@ -55,15 +62,24 @@ type OIDCConfig struct {
 type ConnectorCreator func( params CreateConnectorRequest) (*dexapi.CreateConnectorReq, error)
 
 var  connectorCreators = map[string]ConnectorCreator{
-	"oidc": CreateOIDCConnector,
-	// Future connector types can be added here, e.g., "saml": (*DexClient).CreateSAMLConnector
+	"oidc":   CreateOIDCConnector,
+	"saml":   CreateSAMLConnector,
+	"ldap":   CreateLDAPConnector,
+	"github": CreateGitHubConnector,
 }
+// SupportedConnectorTypes is the order the connector types are listed in
+var SupportedConnectorTypes = []string{"oidc", "saml", "ldap", "github"}
 var SupportedConnectors = map[string][]string{
-	"oidc": {"general", "google-workspace", "entraid"},
-	// Add more connector types and their sub-types here as needed.
+	"oidc":   {"general", "google-workspace", "entraid"},
+	"saml":   {"general"},
+	"ldap":   {"general", "active-directory"},
+	"github": {"general", "enterprise"},
 }
 var SupportedConnectorsNames = map[string][]string{
-	"oidc": {"General OIDC", "Google Workspaces", "AzureAD/EntraID"},
+	"oidc":   {"General OIDC", "Google Workspaces", "AzureAD/EntraID"},
+	"saml":   {"SAML 2.0"},
+	"ldap":   {"LDAP", "Active Directory"},
+	"github": {"GitHub", "GitHub Enterprise Server"},
 
 }
 
@@ -131,6 +131,7 @@ func (c *ComplianceStatusSummary) AddESComplianceStatusMap(summary map[types.Com
 	c.FailedCount += summary[types.ComplianceStatusALARM]
 	c.PassedCount += summary[types.ComplianceStatusINFO]
 	c.PassedCount += summary[types.ComplianceStatusSKIP]
+	c.PassedCount += summary[types.ComplianceStatusSUPPRESSED]
 	c.FailedCount += summary[types.ComplianceStatusERROR]
 }
 
@@ -139,6 +140,7 @@ func (c *ComplianceStatusSummaryV2) AddESComplianceStatusMap(summary map[types.C
 	c.FailedCount += summary[types.ComplianceStatusALARM]
 	c.PassedCount += summary[types.ComplianceStatusINFO]
 	c.PassedCount += summary[types.ComplianceStatusSKIP]
+	c.PassedCount += summary[types.ComplianceStatusSUPPRESSED]
 	c.FailedCount += summary[types.ComplianceStatusERROR]
 
 	c.TotalCount = c.FailedCount + c.PassedCount
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+type ComplianceComparisonResource struct {
+	ResourceID     string                 `json:"resource_id"`
+	ResourceName   string                 `json:"resource_name,omitempty"`
+	ResourceType   string                 `json:"resource_type"`
+	IntegrationID  string                 `json:"integration_id"`
+	BaseStatus     types.ComplianceStatus `json:"base_status,omitempty"`
+	TargetStatus   types.ComplianceStatus `json:"target_status,omitempty"`
+	Reason         string                 `json:"reason,omitempty"`
+	ChangedAt      *time.Time             `json:"changed_at,omitempty"` // Latest drift event between the two jobs, if drift events are tracked
+	ChangedByJobID *uint                  `json:"changed_by_job_id,omitempty"`
+}
+
+type ComplianceComparisonControl struct {
+	ControlID        string                         `json:"control_id"`
+	Title            string                         `json:"title,omitempty"`
+	Severity         types.ComplianceResultSeverity `json:"severity"`
+	NewlyFailing     []ComplianceComparisonResource `json:"newly_failing"`
+	NewlyPassing     []ComplianceComparisonResource `json:"newly_passing"`
+	UnchangedFailing []ComplianceComparisonResource `json:"unchanged_failing"`
+	Removed          []ComplianceComparisonResource `json:"removed"` // Evaluated in the base job but not in the target job
+}
+
+type ComplianceComparisonTotals struct {
+	NewlyFailing     int `json:"newly_failing"`
+	NewlyPassing     int `json:"newly_passing"`
+	UnchangedFailing int `json:"unchanged_failing"`
+	Removed          int `json:"removed"`
+}
+
+type ComplianceComparison struct {
+	FrameworkID     string                        `json:"framework_id"`
+	BaseJobID       uint                          `json:"base_job_id"`
+	BaseJobTime     time.Time                     `json:"base_job_time"`
+	TargetJobID     uint                          `json:"target_job_id"`
+	TargetJobTime   time.Time                     `json:"target_job_time"`
+	Totals          ComplianceComparisonTotals    `json:"totals"`
+	Controls        []ComplianceComparisonControl `json:"controls"` // Only controls with at least one newly failing, newly passing, unchanged failing or removed resource
+	DriftEventsUsed bool                          `json:"drift_events_used"`
+}
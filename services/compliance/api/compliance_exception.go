@@ -0,0 +1,143 @@
+package api
+
+import (
+	"slices"
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+type ComplianceExceptionType string
+
+const (
+	ComplianceExceptionTypeAcceptedRisk  ComplianceExceptionType = "accepted_risk"
+	ComplianceExceptionTypeFalsePositive ComplianceExceptionType = "false_positive"
+)
+
+type ComplianceExceptionStatus string
+
+const (
+	ComplianceExceptionStatusPending  ComplianceExceptionStatus = "pending"
+	ComplianceExceptionStatusApproved ComplianceExceptionStatus = "approved"
+	ComplianceExceptionStatusRejected ComplianceExceptionStatus = "rejected"
+	ComplianceExceptionStatusRevoked  ComplianceExceptionStatus = "revoked"
+	ComplianceExceptionStatusExpired  ComplianceExceptionStatus = "expired"
+)
+
+type ComplianceExceptionAction string
+
+const (
+	ComplianceExceptionActionCreated  ComplianceExceptionAction = "created"
+	ComplianceExceptionActionUpdated  ComplianceExceptionAction = "updated"
+	ComplianceExceptionActionApproved ComplianceExceptionAction = "approved"
+	ComplianceExceptionActionRejected ComplianceExceptionAction = "rejected"
+	ComplianceExceptionActionRevoked  ComplianceExceptionAction = "revoked"
+)
+
+type ComplianceExceptionEvent struct {
+	Action    ComplianceExceptionAction `json:"action" example:"approved"`
+	Actor     string                    `json:"actor"`
+	Comment   string                    `json:"comment"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
+
+type ComplianceException struct {
+	ID   uint                    `json:"id" example:"1"`
+	Type ComplianceExceptionType `json:"type" enums:"accepted_risk,false_positive" example:"accepted_risk"`
+
+	// Scope, every set field has to match for a compliance result to be suppressed
+	ControlID         string   `json:"control_id" example:"aws_cis_v140_1_4"`
+	ResourceIDPattern string   `json:"resource_id_pattern" example:"arn:aws:s3:::public-assets-*"`
+	IntegrationIDs    []string `json:"integration_ids"`
+	TagKey            string   `json:"tag_key" example:"environment"`
+	TagValue          string   `json:"tag_value" example:"sandbox"`
+
+	Reason      string                     `json:"reason"`
+	Status      ComplianceExceptionStatus  `json:"status" enums:"pending,approved,rejected,revoked,expired" example:"approved"`
+	RequestedBy string                     `json:"requested_by"`
+	ApprovedBy  string                     `json:"approved_by,omitempty"`
+	ApprovedAt  *time.Time                 `json:"approved_at,omitempty"`
+	ExpiresAt   time.Time                  `json:"expires_at"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	Events      []ComplianceExceptionEvent `json:"events,omitempty"`
+}
+
+// Matches reports whether the exception scope covers the compliance result, resourceTags are the canonical tags of the resource
+func (e ComplianceException) Matches(result types.ComplianceResult, resourceTags map[string][]string) bool {
+	if e.ControlID != result.ControlID {
+		return false
+	}
+	if len(e.IntegrationIDs) > 0 && !slices.Contains(e.IntegrationIDs, result.IntegrationID) {
+		return false
+	}
+	if e.ResourceIDPattern != "" {
+		if !MatchResourceIDPattern(e.ResourceIDPattern, result.ResourceID) &&
+			!MatchResourceIDPattern(e.ResourceIDPattern, result.PlatformResourceID) {
+			return false
+		}
+	}
+	if e.TagKey != "" {
+		values, ok := resourceTags[e.TagKey]
+		if !ok || (e.TagValue != "" && !slices.Contains(values, e.TagValue)) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchResourceIDPattern matches a resource id against a glob where * matches any sequence, including the
+// / separators of ARNs and Azure ids, and ? matches a single character
+func MatchResourceIDPattern(pattern, resourceID string) bool {
+	p, r := 0, 0
+	star, starR := -1, 0
+	for r < len(resourceID) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == resourceID[r]):
+			p++
+			r++
+		case p < len(pattern) && pattern[p] == '*':
+			star, starR = p, r
+			p++
+		case star >= 0:
+			// let the last * absorb one more character
+			starR++
+			p, r = star+1, starR
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+type CreateComplianceExceptionRequest struct {
+	Type              ComplianceExceptionType `json:"type" enums:"accepted_risk,false_positive" example:"accepted_risk" validate:"required"`
+	ControlID         string                  `json:"control_id" example:"aws_cis_v140_1_4" validate:"required"`
+	ResourceIDPattern string                  `json:"resource_id_pattern" example:"arn:aws:s3:::public-assets-*"`
+	IntegrationIDs    []string                `json:"integration_ids"`
+	TagKey            string                  `json:"tag_key" example:"environment"`
+	TagValue          string                  `json:"tag_value" example:"sandbox"`
+	Reason            string                  `json:"reason" validate:"required"`
+	ExpiresAt         time.Time               `json:"expires_at" validate:"required"`
+}
+
+type UpdateComplianceExceptionRequest struct {
+	ResourceIDPattern *string    `json:"resource_id_pattern" example:"arn:aws:s3:::public-assets-*"`
+	IntegrationIDs    []string   `json:"integration_ids"`
+	TagKey            *string    `json:"tag_key" example:"environment"`
+	TagValue          *string    `json:"tag_value" example:"sandbox"`
+	Reason            *string    `json:"reason"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+}
+
+type ReviewComplianceExceptionRequest struct {
+	Comment string `json:"comment"`
+}
+
+type ListComplianceExceptionsResponse struct {
+	Items      []ComplianceException `json:"items"`
+	TotalCount int                   `json:"total_count"`
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+func TestMatchResourceIDPattern(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		resourceID string
+		want       bool
+	}{
+		{pattern: "arn:aws:s3:::public-assets-*", resourceID: "arn:aws:s3:::public-assets-eu", want: true},
+		{pattern: "arn:aws:s3:::bucket/*", resourceID: "arn:aws:s3:::bucket/logs/2024/01/a.gz", want: true},
+		{pattern: "arn:aws:s3:::bucket/*", resourceID: "arn:aws:s3:::other/logs", want: false},
+		{pattern: "/subscriptions/*/resourceGroups/rg/*", resourceID: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm-1", want: true},
+		{pattern: "/subscriptions/*/resourceGroups/rg/*", resourceID: "/subscriptions/123/resourceGroups/rg-2/providers/Microsoft.Compute/virtualMachines/vm-1", want: false},
+		{pattern: "*/virtualMachines/vm-?", resourceID: "/subscriptions/123/providers/Microsoft.Compute/virtualMachines/vm-1", want: true},
+		{pattern: "*/virtualMachines/vm-?", resourceID: "/subscriptions/123/providers/Microsoft.Compute/virtualMachines/vm-10", want: false},
+		{pattern: "i-*-prod*", resourceID: "i-123-staging-prod", want: true},
+		{pattern: "i-*-prod", resourceID: "i-123-prod-old", want: false},
+		{pattern: "exact", resourceID: "exact", want: true},
+		{pattern: "exact", resourceID: "exactly", want: false},
+		{pattern: "[abc]", resourceID: "a", want: false},
+		{pattern: "*", resourceID: "", want: true},
+		{pattern: "a*", resourceID: "", want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.resourceID, func(t *testing.T) {
+			if got := MatchResourceIDPattern(tc.pattern, tc.resourceID); got != tc.want {
+				t.Errorf("MatchResourceIDPattern(%q, %q) = %v, want %v", tc.pattern, tc.resourceID, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestComplianceExceptionMatches(t *testing.T) {
+	result := types.ComplianceResult{
+		ControlID:          "aws_s3_public",
+		IntegrationID:      "i-1",
+		ResourceID:         "arn:aws:s3:::assets/public/logo.png",
+		PlatformResourceID: "platform-1",
+	}
+	tags := map[string][]string{"environment": {"sandbox"}}
+
+	tests := []struct {
+		name      string
+		exception ComplianceException
+		want      bool
+	}{
+		{name: "control only", exception: ComplianceException{ControlID: "aws_s3_public"}, want: true},
+		{name: "other control", exception: ComplianceException{ControlID: "aws_s3_encrypted"}, want: false},
+		{name: "integration", exception: ComplianceException{ControlID: "aws_s3_public", IntegrationIDs: []string{"i-1"}}, want: true},
+		{name: "other integration", exception: ComplianceException{ControlID: "aws_s3_public", IntegrationIDs: []string{"i-2"}}, want: false},
+		{name: "resource pattern across slashes", exception: ComplianceException{ControlID: "aws_s3_public", ResourceIDPattern: "arn:aws:s3:::assets/*"}, want: true},
+		{name: "platform resource id", exception: ComplianceException{ControlID: "aws_s3_public", ResourceIDPattern: "platform-*"}, want: true},
+		{name: "other resource", exception: ComplianceException{ControlID: "aws_s3_public", ResourceIDPattern: "arn:aws:s3:::private/*"}, want: false},
+		{name: "tag key", exception: ComplianceException{ControlID: "aws_s3_public", TagKey: "environment"}, want: true},
+		{name: "tag value", exception: ComplianceException{ControlID: "aws_s3_public", TagKey: "environment", TagValue: "sandbox"}, want: true},
+		{name: "other tag value", exception: ComplianceException{ControlID: "aws_s3_public", TagKey: "environment", TagValue: "prod"}, want: false},
+		{name: "missing tag", exception: ComplianceException{ControlID: "aws_s3_public", TagKey: "owner"}, want: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.exception.Matches(result, tags); got != tc.want {
+				t.Errorf("Matches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -87,4 +87,29 @@ type ListFrameworkAvailableAssignmentsResponse struct {
 
 type AddAssignmentsRequest struct {
 	Integrations []string `json:"integrations"`
+	// IntegrationGroups assigns the framework to the members of the groups, integrations joining a group later are assigned too
+	IntegrationGroups []string `json:"integration_groups"`
+}
+
+type FrameworkGroupAssignment struct {
+	IntegrationGroup string    `json:"integration_group"`
+	AssignedAt       time.Time `json:"assigned_at"`
+	IntegrationCount int       `json:"integration_count"`
+}
+
+// ExplicitFrameworkAssignment lists the integrations and integration groups a framework is assigned to directly
+type ExplicitFrameworkAssignment struct {
+	FrameworkID       string   `json:"framework_id"`
+	Integrations      []string `json:"integrations"`
+	IntegrationGroups []string `json:"integration_groups"`
+}
+
+type ListExplicitFrameworkAssignmentsResponse struct {
+	Items      []ExplicitFrameworkAssignment `json:"items"`
+	TotalCount int                           `json:"total_count"`
+}
+
+type ListFrameworkGroupAssignmentsResponse struct {
+	Items      []FrameworkGroupAssignment `json:"items"`
+	TotalCount int                        `json:"total_count"`
 }
\ No newline at end of file
@@ -24,6 +24,7 @@ type ComplianceResultDriftEvent struct {
 	StateActive              bool             `json:"stateActive"`
 	EvaluatedAt              time.Time        `json:"evaluatedAt"`
 	Reason                   string           `json:"reason"`
+	InitialState             bool             `json:"initialState"`
 
 	BenchmarkID        string                         `json:"benchmarkID" example:"azure_cis_v140"`
 	ControlID          string                         `json:"controlID" example:"azure_cis_v140_7_5"`
@@ -56,6 +57,7 @@ func GetAPIComplianceResultDriftEventFromESComplianceResultDriftEvent(compliance
 		StateActive:              complianceResultDriftEvent.StateActive,
 		EvaluatedAt:              time.UnixMilli(complianceResultDriftEvent.EvaluatedAt),
 		Reason:                   complianceResultDriftEvent.Reason,
+		InitialState:             complianceResultDriftEvent.InitialState,
 
 		BenchmarkID:        complianceResultDriftEvent.BenchmarkID,
 		ControlID:          complianceResultDriftEvent.ControlID,
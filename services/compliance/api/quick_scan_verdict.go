@@ -0,0 +1,57 @@
+package api
+
+import (
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+type QuickScanVerdictStatus string
+
+const (
+	QuickScanVerdictPassed  QuickScanVerdictStatus = "PASSED"
+	QuickScanVerdictFailed  QuickScanVerdictStatus = "FAILED"
+	QuickScanVerdictError   QuickScanVerdictStatus = "ERROR"
+	QuickScanVerdictPending QuickScanVerdictStatus = "PENDING"
+)
+
+// ExitCode is the process exit code a pipeline step should use for the verdict
+func (s QuickScanVerdictStatus) ExitCode() int {
+	switch s {
+	case QuickScanVerdictPassed:
+		return 0
+	case QuickScanVerdictFailed:
+		return 1
+	default:
+		return 2
+	}
+}
+
+type QuickScanGateViolationReason string
+
+const (
+	QuickScanGateViolationThreshold QuickScanGateViolationReason = "threshold_exceeded"
+	QuickScanGateViolationMandatory QuickScanGateViolationReason = "mandatory_control_failed"
+)
+
+type QuickScanGateViolation struct {
+	ControlID         string                         `json:"control_id"`
+	Severity          types.ComplianceResultSeverity `json:"severity"`
+	Reason            QuickScanGateViolationReason   `json:"reason"`
+	FailedResourceIDs []string                       `json:"failed_resource_ids"`
+	NewResourceIDs    []string                       `json:"new_resource_ids"`
+}
+
+type QuickScanVerdict struct {
+	RunID          string                 `json:"run_id"`
+	QuickRunID     *string                `json:"quick_run_id,omitempty"`
+	BaselineRunID  *string                `json:"baseline_run_id,omitempty"`
+	Status         QuickScanVerdictStatus `json:"status" enums:"PASSED,FAILED,ERROR,PENDING"`
+	ExitCode       int                    `json:"exit_code"`
+	Message        string                 `json:"message,omitempty"`
+	FailureMessage string                 `json:"failure_message,omitempty"`
+
+	// FailuresBySeverity counts every failing result, NewFailuresBySeverity only those not failing in the baseline
+	FailuresBySeverity    map[types.ComplianceResultSeverity]int `json:"failures_by_severity"`
+	NewFailuresBySeverity map[types.ComplianceResultSeverity]int `json:"new_failures_by_severity"`
+	MaxFailures           map[types.ComplianceResultSeverity]int `json:"max_failures,omitempty"`
+	Violations            []QuickScanGateViolation               `json:"violations"`
+}
@@ -1,8 +1,10 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -24,6 +26,9 @@ type ComplianceServiceClient interface {
 	GetControlDetails(ctx *httpclient.Context, controlID string) (*compliance.GetControlDetailsResponse, error)
 	ListBenchmarksNestedForBenchmark(ctx *httpclient.Context, benchmarkId string) (*compliance.NestedBenchmark, error)
 	PurgeSampleData(ctx *httpclient.Context) error
+	ListActiveComplianceExceptions(ctx *httpclient.Context, controlIDs []string) ([]compliance.ComplianceException, error)
+	ListExplicitFrameworkAssignments(ctx *httpclient.Context) (*compliance.ListExplicitFrameworkAssignmentsResponse, error)
+	AddFrameworkAssignments(ctx *httpclient.Context, frameworkID string, req compliance.AddAssignmentsRequest) error
 }
 
 type complianceClient struct {
@@ -156,40 +161,24 @@ func (s *complianceClient) GetControl(ctx *httpclient.Context, controlID string)
 }
 
 func (s *complianceClient) ListControl(ctx *httpclient.Context, controlIDs []string, tags map[string][]string) ([]compliance.Control, error) {
+	params := url.Values{}
+
 	url := fmt.Sprintf("%s/api/v1/benchmarks/controls", s.baseURL)
 
-	firstParamAttached := false
-	if len(controlIDs) > 0 {
-		for _, controlID := range controlIDs {
-			if !firstParamAttached {
-				url += "?"
-				firstParamAttached = true
-			} else {
-				url += "&"
-			}
-			url += fmt.Sprintf("control_id=%s", controlID)
-		}
+	for _, controlID := range controlIDs {
+		params.Add("control_id", controlID)
 	}
 	for tagKey, tagValues := range tags {
 		for _, tagValue := range tagValues {
-			if !firstParamAttached {
-				url += "?"
-				firstParamAttached = true
-			} else {
-				url += "&"
-			}
-			url += fmt.Sprintf("tag=%s=%s", tagKey, tagValue)
+			params.Add("tag", fmt.Sprintf("%s=%s", tagKey, tagValue))
 		}
 		if len(tagValues) == 0 {
-			if !firstParamAttached {
-				url += "?"
-				firstParamAttached = true
-			} else {
-				url += "&"
-			}
-			url += fmt.Sprintf("tag=%s=", tagKey)
+			params.Add("tag", fmt.Sprintf("%s=", tagKey))
 		}
 	}
+	if len(params) > 0 {
+		url += "?" + params.Encode()
+	}
 
 	var response []compliance.Control
 	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodGet, url, ctx.ToHeaders(), nil, &response); err != nil {
@@ -215,40 +204,24 @@ func (s *complianceClient) ListQueries(ctx *httpclient.Context) ([]compliance.Po
 }
 
 func (s *complianceClient) ListBenchmarks(ctx *httpclient.Context, frameworkIDs []string, tags map[string][]string) ([]compliance.Benchmark, error) {
+	params := url.Values{}
+
 	url := fmt.Sprintf("%s/api/v1/benchmarks", s.baseURL)
 
-	isFirstParamAttached := false
-	if len(frameworkIDs) > 0 {
-		for _, controlID := range frameworkIDs {
-			if !isFirstParamAttached {
-				url += "?"
-				isFirstParamAttached = true
-			} else {
-				url += "&"
-			}
-			url += fmt.Sprintf("framework_id=%s", controlID)
-		}
+	for _, frameworkID := range frameworkIDs {
+		params.Add("framework_id", frameworkID)
 	}
 	for tagKey, tagValues := range tags {
 		for _, tagValue := range tagValues {
-			if !isFirstParamAttached {
-				url += "?"
-				isFirstParamAttached = true
-			} else {
-				url += "&"
-			}
-			url += fmt.Sprintf("tag=%s=%s", tagKey, tagValue)
+			params.Add("tag", fmt.Sprintf("%s=%s", tagKey, tagValue))
 		}
 		if len(tagValues) == 0 {
-			if !isFirstParamAttached {
-				url += "?"
-				isFirstParamAttached = true
-			} else {
-				url += "&"
-			}
-			url += fmt.Sprintf("tag=%s=", tagKey)
+			params.Add("tag", fmt.Sprintf("%s=", tagKey))
 		}
 	}
+	if len(params) > 0 {
+		url += "?" + params.Encode()
+	}
 
 	var benchmarks []compliance.Benchmark
 	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodGet, url, ctx.ToHeaders(), nil, &benchmarks); err != nil {
@@ -308,3 +281,50 @@ func (s *complianceClient) PurgeSampleData(ctx *httpclient.Context) error {
 	}
 	return nil
 }
+
+func (s *complianceClient) ListActiveComplianceExceptions(ctx *httpclient.Context, controlIDs []string) ([]compliance.ComplianceException, error) {
+	params := url.Values{}
+	params.Set("active", "true")
+	for _, controlID := range controlIDs {
+		params.Add("control_id", controlID)
+	}
+	url := fmt.Sprintf("%s/api/v3/exceptions?%s", s.baseURL, params.Encode())
+
+	var response compliance.ListComplianceExceptionsResponse
+	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodGet, url, ctx.ToHeaders(), nil, &response); err != nil {
+		if 400 <= statusCode && statusCode < 500 {
+			return nil, echo.NewHTTPError(statusCode, err.Error())
+		}
+		return nil, err
+	}
+	return response.Items, nil
+}
+
+func (s *complianceClient) ListExplicitFrameworkAssignments(ctx *httpclient.Context) (*compliance.ListExplicitFrameworkAssignmentsResponse, error) {
+	url := fmt.Sprintf("%s/api/v1/frameworks/assignments", s.baseURL)
+
+	var response compliance.ListExplicitFrameworkAssignmentsResponse
+	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodGet, url, ctx.ToHeaders(), nil, &response); err != nil {
+		if 400 <= statusCode && statusCode < 500 {
+			return nil, echo.NewHTTPError(statusCode, err.Error())
+		}
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (s *complianceClient) AddFrameworkAssignments(ctx *httpclient.Context, frameworkID string, req compliance.AddAssignmentsRequest) error {
+	url := fmt.Sprintf("%s/api/v1/frameworks/%s/assignments", s.baseURL, frameworkID)
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodPut, url, ctx.ToHeaders(), payload, nil); err != nil {
+		if 400 <= statusCode && statusCode < 500 {
+			return echo.NewHTTPError(statusCode, err.Error())
+		}
+		return err
+	}
+	return nil
+}
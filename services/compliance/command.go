@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/opengovern/og-util/pkg/config"
 	"github.com/opengovern/og-util/pkg/httpserver"
+	"github.com/opengovern/opensecurity/pkg/utils"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -56,5 +57,9 @@ func startHttpServer(ctx context.Context) error {
 		return fmt.Errorf("init http handler: %w", err)
 	}
 
+	utils.EnsureRunGoroutine(func() {
+		handler.RunGroupAssignmentSync(ctx)
+	})
+
 	return httpserver.RegisterAndStart(ctx, logger, conf.Http.Address, handler)
 }
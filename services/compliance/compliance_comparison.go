@@ -0,0 +1,152 @@
+package compliance
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+	"github.com/opengovern/opensecurity/services/compliance/api"
+)
+
+type comparisonResult struct {
+	controlID     string
+	severity      types.ComplianceResultSeverity
+	integrationID string
+	resourceID    string
+	resourceName  string
+	resourceType  string
+	status        types.ComplianceStatus
+	reason        string
+}
+
+func comparisonKey(controlID, integrationID, resourceID string) string {
+	return controlID + "|" + integrationID + "|" + resourceID
+}
+
+// comparisonResults flattens the resource view of a job, keeping only the integrations allowed by hasAccess
+func comparisonResults(view *types.ComplianceJobReportResourceView, hasAccess func(integrationID string) bool) map[string]comparisonResult {
+	results := make(map[string]comparisonResult)
+	if view == nil {
+		return results
+	}
+	for integrationID, integration := range view.Integrations {
+		if !hasAccess(integrationID) {
+			continue
+		}
+		for resourceType, resourceTypeResult := range integration.ResourceTypes {
+			for resourceID, resource := range resourceTypeResult.Resources {
+				for status, findings := range resource.Results {
+					for _, finding := range findings {
+						results[comparisonKey(finding.ControlID, integrationID, resourceID)] = comparisonResult{
+							controlID:     finding.ControlID,
+							severity:      finding.Severity,
+							integrationID: integrationID,
+							resourceID:    resourceID,
+							resourceName:  resource.ResourceName,
+							resourceType:  resourceType,
+							status:        status,
+							reason:        finding.Reason,
+						}
+					}
+				}
+			}
+		}
+	}
+	return results
+}
+
+// compareJobReports diffs the results of two jobs of the same framework per control.
+// events are the drift events between the two jobs, latest first, and only annotate the changes.
+func compareJobReports(comparison *api.ComplianceComparison, base, target map[string]comparisonResult,
+	events []types.ComplianceResultDriftEvent) {
+	latestEvents := make(map[string]types.ComplianceResultDriftEvent)
+	for _, event := range events {
+		key := comparisonKey(event.ControlID, event.IntegrationID, event.ResourceID)
+		if _, ok := latestEvents[key]; !ok {
+			latestEvents[key] = event
+		}
+	}
+
+	controls := make(map[string]*api.ComplianceComparisonControl)
+	control := func(result comparisonResult) *api.ComplianceComparisonControl {
+		c, ok := controls[result.controlID]
+		if !ok {
+			c = &api.ComplianceComparisonControl{
+				ControlID:        result.controlID,
+				Severity:         result.severity,
+				NewlyFailing:     []api.ComplianceComparisonResource{},
+				NewlyPassing:     []api.ComplianceComparisonResource{},
+				UnchangedFailing: []api.ComplianceComparisonResource{},
+				Removed:          []api.ComplianceComparisonResource{},
+			}
+			controls[result.controlID] = c
+		}
+		return c
+	}
+	resource := func(key string, result comparisonResult, baseStatus, targetStatus types.ComplianceStatus, changed bool) api.ComplianceComparisonResource {
+		r := api.ComplianceComparisonResource{
+			ResourceID:    result.resourceID,
+			ResourceName:  result.resourceName,
+			ResourceType:  result.resourceType,
+			IntegrationID: result.integrationID,
+			BaseStatus:    baseStatus,
+			TargetStatus:  targetStatus,
+			Reason:        result.reason,
+		}
+		if event, ok := latestEvents[key]; ok && changed {
+			changedAt := time.UnixMilli(event.EvaluatedAt)
+			jobID := event.ParentComplianceJobID
+			r.ChangedAt = &changedAt
+			r.ChangedByJobID = &jobID
+		}
+		return r
+	}
+
+	for key, t := range target {
+		b, inBase := base[key]
+		switch {
+		case t.status.IsPassed():
+			if inBase && !b.status.IsPassed() {
+				c := control(t)
+				c.NewlyPassing = append(c.NewlyPassing, resource(key, t, b.status, t.status, true))
+				comparison.Totals.NewlyPassing++
+			}
+		case inBase && !b.status.IsPassed():
+			c := control(t)
+			c.UnchangedFailing = append(c.UnchangedFailing, resource(key, t, b.status, t.status, false))
+			comparison.Totals.UnchangedFailing++
+		default:
+			c := control(t)
+			c.NewlyFailing = append(c.NewlyFailing, resource(key, t, b.status, t.status, true))
+			comparison.Totals.NewlyFailing++
+		}
+	}
+	for key, b := range base {
+		if _, ok := target[key]; ok {
+			continue
+		}
+		c := control(b)
+		c.Removed = append(c.Removed, resource(key, b, b.status, "", true))
+		comparison.Totals.Removed++
+	}
+
+	comparison.Controls = make([]api.ComplianceComparisonControl, 0, len(controls))
+	for _, c := range controls {
+		for _, resources := range [][]api.ComplianceComparisonResource{c.NewlyFailing, c.NewlyPassing, c.UnchangedFailing, c.Removed} {
+			sort.Slice(resources, func(i, j int) bool {
+				if resources[i].IntegrationID != resources[j].IntegrationID {
+					return resources[i].IntegrationID < resources[j].IntegrationID
+				}
+				return resources[i].ResourceID < resources[j].ResourceID
+			})
+		}
+		comparison.Controls = append(comparison.Controls, *c)
+	}
+	sort.Slice(comparison.Controls, func(i, j int) bool {
+		a, b := comparison.Controls[i], comparison.Controls[j]
+		if a.Severity.Level() != b.Severity.Level() {
+			return a.Severity.Level() > b.Severity.Level()
+		}
+		return a.ControlID < b.ControlID
+	})
+}
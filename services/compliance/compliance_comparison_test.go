@@ -0,0 +1,76 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+	"github.com/opengovern/opensecurity/services/compliance/api"
+)
+
+func TestCompareJobReports(t *testing.T) {
+	high := types.ComplianceResultSeverityHigh
+	low := types.ComplianceResultSeverityLow
+	alarm, ok := types.ComplianceStatusALARM, types.ComplianceStatusOK
+	all := func(string) bool { return true }
+
+	base := comparisonResults(testResourceView(
+		testFinding{resourceID: "fixed", controlID: "c-high", severity: high, status: alarm},
+		testFinding{resourceID: "still-failing", controlID: "c-high", severity: high, status: alarm},
+		testFinding{resourceID: "gone", controlID: "c-low", severity: low, status: alarm},
+		testFinding{resourceID: "broken", controlID: "c-low", severity: low, status: ok},
+		testFinding{resourceID: "fine", controlID: "c-low", severity: low, status: ok},
+	), all)
+	target := comparisonResults(testResourceView(
+		testFinding{resourceID: "fixed", controlID: "c-high", severity: high, status: ok},
+		testFinding{resourceID: "still-failing", controlID: "c-high", severity: high, status: alarm},
+		testFinding{resourceID: "broken", controlID: "c-low", severity: low, status: alarm},
+		testFinding{resourceID: "new", controlID: "c-low", severity: low, status: alarm},
+		testFinding{resourceID: "fine", controlID: "c-low", severity: low, status: ok},
+	), all)
+	events := []types.ComplianceResultDriftEvent{
+		{ControlID: "c-low", IntegrationID: "i-1", ResourceID: "broken", ParentComplianceJobID: 7, EvaluatedAt: 2000},
+		{ControlID: "c-low", IntegrationID: "i-1", ResourceID: "broken", ParentComplianceJobID: 6, EvaluatedAt: 1000},
+	}
+
+	var comparison api.ComplianceComparison
+	compareJobReports(&comparison, base, target, events)
+
+	wantTotals := api.ComplianceComparisonTotals{NewlyFailing: 2, NewlyPassing: 1, UnchangedFailing: 1, Removed: 1}
+	if comparison.Totals != wantTotals {
+		t.Errorf("totals = %+v, want %+v", comparison.Totals, wantTotals)
+	}
+	if len(comparison.Controls) != 2 || comparison.Controls[0].ControlID != "c-high" || comparison.Controls[1].ControlID != "c-low" {
+		t.Fatalf("controls should be sorted by severity, got %+v", comparison.Controls)
+	}
+
+	tests := []struct {
+		name      string
+		resources []api.ComplianceComparisonResource
+		want      []string
+	}{
+		{name: "high newly passing", resources: comparison.Controls[0].NewlyPassing, want: []string{"fixed"}},
+		{name: "high unchanged failing", resources: comparison.Controls[0].UnchangedFailing, want: []string{"still-failing"}},
+		{name: "low newly failing", resources: comparison.Controls[1].NewlyFailing, want: []string{"broken", "new"}},
+		{name: "low removed", resources: comparison.Controls[1].Removed, want: []string{"gone"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if len(tc.resources) != len(tc.want) {
+				t.Fatalf("got %+v, want %v", tc.resources, tc.want)
+			}
+			for i, id := range tc.want {
+				if tc.resources[i].ResourceID != id {
+					t.Errorf("resource %d = %s, want %s", i, tc.resources[i].ResourceID, id)
+				}
+			}
+		})
+	}
+
+	broken := comparison.Controls[1].NewlyFailing[0]
+	if broken.ChangedByJobID == nil || *broken.ChangedByJobID != 7 {
+		t.Errorf("the latest drift event should annotate the change, got %+v", broken)
+	}
+	if comparison.Controls[1].NewlyFailing[1].ChangedByJobID != nil {
+		t.Errorf("resources without events should not be annotated")
+	}
+}
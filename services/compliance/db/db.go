@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"gorm.io/gorm/logger"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"github.com/opengovern/og-util/pkg/model"
+	"github.com/opengovern/opensecurity/services/compliance/api"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -26,7 +28,10 @@ func (db Database) Initialize(ctx context.Context) error {
 		&Benchmark{},
 		&BenchmarkTag{},
 		&BenchmarkAssignment{},
+		&BenchmarkGroupAssignment{},
 		&FrameworkComplianceSummary{},
+		&ComplianceException{},
+		&ComplianceExceptionEvent{},
 	)
 	if err != nil {
 		return err
@@ -822,6 +827,77 @@ func (db Database) DeleteBenchmarkAssignmentByIds(ctx context.Context, benchmark
 	return nil
 }
 
+func (db Database) AddBenchmarkGroupAssignment(ctx context.Context, assignment *BenchmarkGroupAssignment) error {
+	tx := db.Orm.WithContext(ctx).Where(BenchmarkGroupAssignment{
+		BenchmarkId:      assignment.BenchmarkId,
+		IntegrationGroup: assignment.IntegrationGroup,
+	}).FirstOrCreate(assignment)
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) ListBenchmarkGroupAssignments(ctx context.Context, benchmarkId *string) ([]BenchmarkGroupAssignment, error) {
+	var s []BenchmarkGroupAssignment
+	tx := db.Orm.WithContext(ctx).Model(&BenchmarkGroupAssignment{})
+	if benchmarkId != nil {
+		tx = tx.Where("benchmark_id = ?", *benchmarkId)
+	}
+	tx = tx.Order("benchmark_id, integration_group").Find(&s)
+
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return s, nil
+}
+
+// DeleteBenchmarkGroupAssignment removes the group assignment and the assignments made through it
+func (db Database) DeleteBenchmarkGroupAssignment(ctx context.Context, benchmarkId, integrationGroup string) (bool, error) {
+	deleted := false
+	err := db.Orm.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Where("benchmark_id = ? AND integration_group = ?", benchmarkId, integrationGroup).
+			Delete(&BenchmarkGroupAssignment{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected > 0
+		return tx.Unscoped().Where("benchmark_id = ? AND integration_group = ?", benchmarkId, integrationGroup).
+			Delete(&BenchmarkAssignment{}).Error
+	})
+	return deleted, err
+}
+
+// ListBenchmarkAssignmentsByGroup lists the assignments of the benchmark made through the group
+func (db Database) ListBenchmarkAssignmentsByGroup(ctx context.Context, benchmarkId, integrationGroup string) ([]BenchmarkAssignment, error) {
+	var s []BenchmarkAssignment
+	tx := db.Orm.WithContext(ctx).Model(&BenchmarkAssignment{}).
+		Where("benchmark_id = ? AND integration_group = ?", benchmarkId, integrationGroup).
+		Find(&s)
+
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return s, nil
+}
+
+// SetBenchmarkAssignmentGroup sets the group an assignment is made through, nil makes it a direct assignment
+func (db Database) SetBenchmarkAssignmentGroup(ctx context.Context, benchmarkId, integrationId string, integrationGroup *string) error {
+	tx := db.Orm.WithContext(ctx).Model(&BenchmarkAssignment{}).
+		Where("benchmark_id = ? AND integration_id = ?", benchmarkId, integrationId).
+		Update("integration_group", integrationGroup)
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
 func (db Database) DeleteBenchmarkAssignmentByBenchmarkId(ctx context.Context, benchmarkId string) error {
 	tx := db.Orm.WithContext(ctx).Where("benchmark_id = ?", benchmarkId).Delete(&BenchmarkAssignment{})
 
@@ -1030,3 +1106,77 @@ func (db Database) GetFrameworkComplianceResultSummary(frameworkId string) (*Fra
 	}
 	return &summary, nil
 }
+
+// =========== Compliance Exceptions ===========
+
+func (db Database) CreateComplianceException(ctx context.Context, exception *ComplianceException) error {
+	tx := db.Orm.WithContext(ctx).Create(exception)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+func (db Database) GetComplianceException(ctx context.Context, id uint) (*ComplianceException, error) {
+	var exception ComplianceException
+	tx := db.Orm.WithContext(ctx).Model(&ComplianceException{}).
+		Preload("Events", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id ASC")
+		}).
+		Where("id = ?", id).First(&exception)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &exception, nil
+}
+
+func (db Database) ListComplianceExceptions(ctx context.Context, controlIDs []string, statuses []api.ComplianceExceptionStatus) ([]ComplianceException, error) {
+	var exceptions []ComplianceException
+	tx := db.Orm.WithContext(ctx).Model(&ComplianceException{})
+	if len(controlIDs) > 0 {
+		tx = tx.Where("control_id IN ?", controlIDs)
+	}
+	if len(statuses) > 0 {
+		tx = tx.Where("status IN ?", statuses)
+	}
+	tx = tx.Order("id ASC").Find(&exceptions)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return exceptions, nil
+}
+
+// ListActiveComplianceExceptions returns the approved exceptions that are not expired at the given time
+func (db Database) ListActiveComplianceExceptions(ctx context.Context, controlIDs []string, at time.Time) ([]ComplianceException, error) {
+	var exceptions []ComplianceException
+	tx := db.Orm.WithContext(ctx).Model(&ComplianceException{}).
+		Where("status = ? AND expires_at > ?", api.ComplianceExceptionStatusApproved, at)
+	if len(controlIDs) > 0 {
+		tx = tx.Where("control_id IN ?", controlIDs)
+	}
+	tx = tx.Order("id ASC").Find(&exceptions)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return exceptions, nil
+}
+
+// UpdateComplianceException saves the exception and appends the event to its trail in a single transaction
+func (db Database) UpdateComplianceException(ctx context.Context, exception *ComplianceException, event ComplianceExceptionEvent) error {
+	return db.Orm.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&ComplianceException{}).
+			Where("id = ?", exception.ID).
+			Select("resource_id_pattern", "integration_ids", "tag_key", "tag_value", "reason", "status",
+				"approved_by", "approved_at", "expires_at", "updated_at").
+			Updates(exception).Error
+		if err != nil {
+			return err
+		}
+
+		event.ExceptionID = exception.ID
+		return tx.Create(&event).Error
+	})
+}
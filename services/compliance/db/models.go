@@ -24,6 +24,16 @@ type BenchmarkAssignment struct {
 	BenchmarkId   string  `gorm:"index:idx_benchmark_source; index:idx_benchmark_rc; not null"`
 	IntegrationID *string `gorm:"index:idx_benchmark_source"`
 	AssignedAt    time.Time
+	// IntegrationGroup is the group the integration is assigned through, nil for direct assignments
+	IntegrationGroup *string `gorm:"index"`
+}
+
+// BenchmarkGroupAssignment assigns a framework to the members of an integration group, as they join and leave it
+type BenchmarkGroupAssignment struct {
+	ID               uint   `gorm:"primarykey"`
+	BenchmarkId      string `gorm:"uniqueIndex:idx_benchmark_group; not null"`
+	IntegrationGroup string `gorm:"uniqueIndex:idx_benchmark_group; not null"`
+	AssignedAt       time.Time
 }
 
 type BenchmarkAssignmentsCount struct {
@@ -334,3 +344,71 @@ type FrameworkComplianceSummary struct {
 
 	UpdatedAt time.Time
 }
+
+type ComplianceException struct {
+	ID                uint `gorm:"primarykey"`
+	Type              api.ComplianceExceptionType
+	ControlID         string `gorm:"index;not null"`
+	ResourceIDPattern string
+	IntegrationIDs    pq.StringArray `gorm:"type:text[]"`
+	TagKey            string
+	TagValue          string
+	Reason            string
+	Status            api.ComplianceExceptionStatus `gorm:"index"`
+	RequestedBy       string
+	ApprovedBy        string
+	ApprovedAt        *time.Time
+	ExpiresAt         time.Time
+
+	Events []ComplianceExceptionEvent `gorm:"foreignKey:ExceptionID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ComplianceExceptionEvent is the audit trail of an exception, rows are never updated
+type ComplianceExceptionEvent struct {
+	ID          uint `gorm:"primarykey"`
+	ExceptionID uint `gorm:"index"`
+	Action      api.ComplianceExceptionAction
+	Actor       string
+	Comment     string
+	CreatedAt   time.Time
+}
+
+func (e ComplianceException) IsActive(now time.Time) bool {
+	return e.Status == api.ComplianceExceptionStatusApproved && e.ExpiresAt.After(now)
+}
+
+func (e ComplianceException) ToApi() api.ComplianceException {
+	status := e.Status
+	if status == api.ComplianceExceptionStatusApproved && !e.ExpiresAt.After(time.Now()) {
+		status = api.ComplianceExceptionStatusExpired
+	}
+	exception := api.ComplianceException{
+		ID:                e.ID,
+		Type:              e.Type,
+		ControlID:         e.ControlID,
+		ResourceIDPattern: e.ResourceIDPattern,
+		IntegrationIDs:    e.IntegrationIDs,
+		TagKey:            e.TagKey,
+		TagValue:          e.TagValue,
+		Reason:            e.Reason,
+		Status:            status,
+		RequestedBy:       e.RequestedBy,
+		ApprovedBy:        e.ApprovedBy,
+		ApprovedAt:        e.ApprovedAt,
+		ExpiresAt:         e.ExpiresAt,
+		CreatedAt:         e.CreatedAt,
+		UpdatedAt:         e.UpdatedAt,
+	}
+	for _, event := range e.Events {
+		exception.Events = append(exception.Events, api.ComplianceExceptionEvent{
+			Action:    event.Action,
+			Actor:     event.Actor,
+			Comment:   event.Comment,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return exception
+}
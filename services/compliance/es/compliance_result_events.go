@@ -516,3 +516,72 @@ func ComplianceResultDriftEventsCount(ctx context.Context, client opengovernance
 
 	return response.Hits.Total.Value, err
 }
+
+const driftEventsPageSize = 10000
+
+// FetchComplianceResultDriftEventsBetween returns the drift events of the framework evaluated in (from, to], latest first
+func FetchComplianceResultDriftEventsBetween(ctx context.Context, logger *zap.Logger, client opengovernance.Client,
+	benchmarkID string, integrationIDs []string, from, to time.Time) ([]types.ComplianceResultDriftEvent, error) {
+	filters := []any{
+		map[string]any{
+			"term": map[string]any{
+				"benchmarkID": benchmarkID,
+			},
+		},
+		map[string]any{
+			"range": map[string]any{
+				"evaluatedAt": map[string]any{
+					"gt":  from.UnixMilli(),
+					"lte": to.UnixMilli(),
+				},
+			},
+		},
+	}
+	if len(integrationIDs) > 0 {
+		filters = append(filters, map[string]any{
+			"terms": map[string][]string{
+				"integrationID": integrationIDs,
+			},
+		})
+	}
+	// pages with search_after so comparisons of large jobs are not truncated at the window size
+	var result []types.ComplianceResultDriftEvent
+	var searchAfter []any
+	for {
+		request := map[string]any{
+			"query": map[string]any{
+				"bool": map[string]any{
+					"filter": filters,
+				},
+			},
+			"sort": []map[string]any{
+				{"evaluatedAt": "desc"},
+				{"_id": "asc"},
+			},
+			"size": driftEventsPageSize,
+		}
+		if len(searchAfter) > 0 {
+			request["search_after"] = searchAfter
+		}
+
+		jsonReq, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Fetching complianceResult events between", zap.String("request", string(jsonReq)), zap.String("index", types.ComplianceResultEventsIndex))
+
+		var resp FetchComplianceResultDriftEventsByComplianceResultIDResponse
+		err = client.Search(ctx, types.ComplianceResultEventsIndex, string(jsonReq), &resp)
+		if err != nil {
+			logger.Error("Failed to fetch complianceResult events between", zap.Error(err), zap.String("request", string(jsonReq)), zap.String("index", types.ComplianceResultEventsIndex))
+			return nil, err
+		}
+		for _, hit := range resp.Hits.Hits {
+			result = append(result, hit.Source)
+		}
+		if len(resp.Hits.Hits) < driftEventsPageSize {
+			return result, nil
+		}
+		searchAfter = resp.Hits.Hits[len(resp.Hits.Hits)-1].Sort
+	}
+}
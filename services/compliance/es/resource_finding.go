@@ -245,10 +245,10 @@ func ResourceFindingsQuery(ctx context.Context, logger *zap.Logger, client openg
 			scriptSource :=
 				fmt.Sprintf(`int total = 0; 
 for (int i=0; i<params['_source']['complianceResults'].length;++i) { 
-  if(params['_source']['complianceResults'][i]['complianceStatus'] != '%s' && params['_source']['complianceResults'][i]['complianceStatus'] != '%s' && params['_source']['complianceResults'][i]['complianceStatus'] != '%s') 
+  if(params['_source']['complianceResults'][i]['complianceStatus'] != '%s' && params['_source']['complianceResults'][i]['complianceStatus'] != '%s' && params['_source']['complianceResults'][i]['complianceStatus'] != '%s' && params['_source']['complianceResults'][i]['complianceStatus'] != '%s') 
     total+=1;
   } 
-return total;`, types.ComplianceStatusOK, types.ComplianceStatusINFO, types.ComplianceStatusSKIP)
+return total;`, types.ComplianceStatusOK, types.ComplianceStatusINFO, types.ComplianceStatusSKIP, types.ComplianceStatusSUPPRESSED)
 			requestSort = append(requestSort, map[string]any{
 				"_script": map[string]any{
 					"type": "number",
@@ -796,6 +796,8 @@ func GetPerFieldResourceComplianceResult(ctx context.Context, logger *zap.Logger
 				complianceStatusSummary.SkipCount += complianceBucket.ResourceCount.DocCount
 			case types.ComplianceStatusERROR:
 				complianceStatusSummary.ErrorCount += complianceBucket.ResourceCount.DocCount
+			case types.ComplianceStatusSUPPRESSED:
+				complianceStatusSummary.SuppressedCount += complianceBucket.ResourceCount.DocCount
 			}
 		}
 		result[integrationBucket.Key] = complianceStatusSummary
@@ -0,0 +1,256 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	authApi "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/og-util/pkg/ticker"
+	"github.com/opengovern/opensecurity/pkg/utils"
+	"github.com/opengovern/opensecurity/services/compliance/api"
+	"github.com/opengovern/opensecurity/services/compliance/db"
+	integrationapi "github.com/opengovern/opensecurity/services/integration/api/models"
+	"go.uber.org/zap"
+)
+
+// syncGroupAssignment assigns the framework to the current members of the group and removes it from the departed ones
+func (h *HttpHandler) syncGroupAssignment(ctx context.Context, groupAssignment db.BenchmarkGroupAssignment, framework *db.Benchmark) error {
+	clientCtx := &httpclient.Context{Ctx: ctx, UserRole: authApi.AdminRole}
+	group, err := h.integrationClient.GetIntegrationGroup(clientCtx, groupAssignment.IntegrationGroup)
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code == http.StatusNotFound {
+		// the group is deleted, the framework is removed from the integrations assigned through it
+		if _, err := h.db.DeleteBenchmarkGroupAssignment(ctx, framework.ID, groupAssignment.IntegrationGroup); err != nil {
+			return err
+		}
+		h.logger.Info("removed assignment of deleted integration group", zap.String("framework", framework.ID),
+			zap.String("group", groupAssignment.IntegrationGroup))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get integration group %s: %w", groupAssignment.IntegrationGroup, err)
+	}
+
+	members := make(map[string]bool)
+	if len(group.IntegrationIds) > 0 {
+		integrations, err := h.integrationClient.ListIntegrationsByFilters(clientCtx, integrationapi.ListIntegrationsRequest{
+			IntegrationID:   group.IntegrationIds,
+			IntegrationType: framework.IntegrationType,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list integrations: %w", err)
+		}
+		for _, i := range integrations.Integrations {
+			members[i.IntegrationID] = true
+		}
+	}
+
+	current, err := h.db.ListBenchmarkAssignmentsByGroup(ctx, framework.ID, groupAssignment.IntegrationGroup)
+	if err != nil {
+		return err
+	}
+	for _, assignment := range current {
+		if assignment.IntegrationID == nil {
+			continue
+		}
+		if members[*assignment.IntegrationID] {
+			delete(members, *assignment.IntegrationID)
+			continue
+		}
+		if err := h.db.DeleteBenchmarkAssignmentByIds(ctx, framework.ID, assignment.IntegrationID); err != nil {
+			return err
+		}
+	}
+
+	for integrationID := range members {
+		existing, err := h.db.GetBenchmarkAssignmentByIds(ctx, framework.ID, utils.GetPointer(integrationID))
+		if err != nil {
+			return err
+		}
+		// direct assignments and assignments through other groups are kept as they are
+		if existing != nil && existing.ID != 0 {
+			continue
+		}
+		if err := h.db.AddBenchmarkAssignment(ctx, &db.BenchmarkAssignment{
+			BenchmarkId:      framework.ID,
+			IntegrationID:    utils.GetPointer(integrationID),
+			AssignedAt:       time.Now(),
+			IntegrationGroup: utils.GetPointer(groupAssignment.IntegrationGroup),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncGroupAssignments keeps the framework assignments made through integration groups up to date with the group members
+func (h *HttpHandler) SyncGroupAssignments(ctx context.Context) error {
+	groupAssignments, err := h.db.ListBenchmarkGroupAssignments(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, groupAssignment := range groupAssignments {
+		framework, err := h.db.GetFramework(ctx, groupAssignment.BenchmarkId)
+		if err != nil {
+			return err
+		}
+		if framework == nil || !framework.Enabled {
+			continue
+		}
+		if err := h.syncGroupAssignment(ctx, groupAssignment, framework); err != nil {
+			h.logger.Error("failed to sync group assignment", zap.String("framework", groupAssignment.BenchmarkId),
+				zap.String("group", groupAssignment.IntegrationGroup), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (h *HttpHandler) RunGroupAssignmentSync(ctx context.Context) {
+	t := ticker.NewTicker(5*time.Minute, time.Second*10)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := h.SyncGroupAssignments(ctx); err != nil {
+				h.logger.Warn("failed to sync group assignments", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ListFrameworkGroupAssignments godoc
+//
+//	@Summary		List framework group assignments
+//	@Description	List the integration groups the framework is assigned to
+//	@Security		BearerToken
+//	@Tags			benchmarks_assignment
+//	@Produce		json
+//	@Param			framework-id	path		string	true	"Framework ID"
+//	@Success		200				{object}	api.ListFrameworkGroupAssignmentsResponse
+//	@Router			/compliance/api/v1/frameworks/{framework-id}/assignments/groups [get]
+func (h *HttpHandler) ListFrameworkGroupAssignments(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	frameworkId := echoCtx.Param("framework-id")
+
+	groupAssignments, err := h.db.ListBenchmarkGroupAssignments(ctx, &frameworkId)
+	if err != nil {
+		h.logger.Error("failed to list group assignments", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list group assignments")
+	}
+
+	items := make([]api.FrameworkGroupAssignment, 0, len(groupAssignments))
+	for _, groupAssignment := range groupAssignments {
+		assignments, err := h.db.ListBenchmarkAssignmentsByGroup(ctx, frameworkId, groupAssignment.IntegrationGroup)
+		if err != nil {
+			h.logger.Error("failed to list group assignments", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to list group assignments")
+		}
+		items = append(items, api.FrameworkGroupAssignment{
+			IntegrationGroup: groupAssignment.IntegrationGroup,
+			AssignedAt:       groupAssignment.AssignedAt,
+			IntegrationCount: len(assignments),
+		})
+	}
+
+	return echoCtx.JSON(http.StatusOK, api.ListFrameworkGroupAssignmentsResponse{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
+
+// DeleteFrameworkGroupAssignment godoc
+//
+//	@Summary		Delete framework group assignment
+//	@Description	Remove the framework from an integration group and from the integrations assigned through it
+//	@Security		BearerToken
+//	@Tags			benchmarks_assignment
+//	@Produce		json
+//	@Param			framework-id		path	string	true	"Framework ID"
+//	@Param			integration-group	path	string	true	"Integration group name"
+//	@Success		200
+//	@Router			/compliance/api/v1/frameworks/{framework-id}/assignments/groups/{integration-group} [delete]
+func (h *HttpHandler) DeleteFrameworkGroupAssignment(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+	frameworkId := echoCtx.Param("framework-id")
+	groupName := echoCtx.Param("integration-group")
+
+	deleted, err := h.db.DeleteBenchmarkGroupAssignment(ctx, frameworkId, groupName)
+	if err != nil {
+		h.logger.Error("failed to delete group assignment", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete group assignment")
+	}
+	if !deleted {
+		return echo.NewHTTPError(http.StatusNotFound, "group assignment not found")
+	}
+
+	return echoCtx.NoContent(http.StatusOK)
+}
+
+// ListExplicitFrameworkAssignments godoc
+//
+//	@Summary		List explicit framework assignments
+//	@Description	List the integrations and integration groups each framework is assigned to directly, integrations assigned through a group are not listed
+//	@Security		BearerToken
+//	@Tags			benchmarks_assignment
+//	@Produce		json
+//	@Success		200	{object}	api.ListExplicitFrameworkAssignmentsResponse
+//	@Router			/compliance/api/v1/frameworks/assignments [get]
+func (h *HttpHandler) ListExplicitFrameworkAssignments(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+
+	assignments, err := h.db.ListBenchmarkAssignments(ctx)
+	if err != nil {
+		h.logger.Error("failed to list assignments", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list assignments")
+	}
+	groupAssignments, err := h.db.ListBenchmarkGroupAssignments(ctx, nil)
+	if err != nil {
+		h.logger.Error("failed to list group assignments", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list group assignments")
+	}
+
+	byFramework := make(map[string]*api.ExplicitFrameworkAssignment)
+	get := func(frameworkId string) *api.ExplicitFrameworkAssignment {
+		if _, ok := byFramework[frameworkId]; !ok {
+			byFramework[frameworkId] = &api.ExplicitFrameworkAssignment{
+				FrameworkID:       frameworkId,
+				Integrations:      []string{},
+				IntegrationGroups: []string{},
+			}
+		}
+		return byFramework[frameworkId]
+	}
+	for _, assignment := range assignments {
+		if assignment.IntegrationID == nil || assignment.IntegrationGroup != nil {
+			continue
+		}
+		item := get(assignment.BenchmarkId)
+		item.Integrations = append(item.Integrations, *assignment.IntegrationID)
+	}
+	for _, groupAssignment := range groupAssignments {
+		item := get(groupAssignment.BenchmarkId)
+		item.IntegrationGroups = append(item.IntegrationGroups, groupAssignment.IntegrationGroup)
+	}
+
+	items := make([]api.ExplicitFrameworkAssignment, 0, len(byFramework))
+	for _, item := range byFramework {
+		sort.Strings(item.Integrations)
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].FrameworkID < items[j].FrameworkID
+	})
+
+	return echoCtx.JSON(http.StatusOK, api.ListExplicitFrameworkAssignmentsResponse{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
@@ -0,0 +1,98 @@
+package compliance
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	authApi "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpserver"
+	"github.com/opengovern/opensecurity/pkg/rbac"
+)
+
+type testValidator struct {
+	validate *validator.Validate
+}
+
+func (v testValidator) Validate(i any) error {
+	return v.validate.Struct(i)
+}
+
+// TestFrameworkScopedResults checks that a user bound only to frameworks can not read the results of other frameworks,
+// the handlers fail before reaching elasticsearch
+func TestFrameworkScopedResults(t *testing.T) {
+	h := &HttpHandler{}
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		method  string
+		target  string
+		body    string
+		params  map[string]string
+	}{
+		{
+			name: "compliance results", handler: h.GetComplianceResults, method: http.MethodPost, target: "/",
+			body: `{"filters": {"benchmarkID": ["other_framework"]}}`,
+		},
+		{
+			name: "compliance result filter values", handler: h.GetComplianceResultFilterValues, method: http.MethodPost, target: "/",
+			body: `{"benchmarkID": ["other_framework"]}`,
+		},
+		{
+			name: "resource findings", handler: h.ListResourceFindings, method: http.MethodPost, target: "/",
+			body: `{"filters": {"integrationID": ["allowed-integration"], "benchmarkID": ["other_framework"]}}`,
+		},
+		{
+			name: "top field", handler: h.GetTopFieldByComplianceResultCount, method: http.MethodGet, target: "/?benchmarkId=other_framework",
+			params: map[string]string{"field": "resourceType", "count": "5"},
+		},
+		{
+			name: "accounts summary", handler: h.GetAccountsComplianceResultsSummary, method: http.MethodGet, target: "/",
+			params: map[string]string{"benchmarkId": "other_framework"},
+		},
+		{
+			name: "services summary", handler: h.GetServicesComplianceResultsSummary, method: http.MethodGet, target: "/",
+			params: map[string]string{"benchmarkId": "other_framework"},
+		},
+		{
+			name: "framework summary", handler: h.GetBenchmarkSummary, method: http.MethodGet, target: "/",
+			params: map[string]string{"benchmark_id": "other_framework"},
+		},
+		{
+			name: "framework controls", handler: h.GetBenchmarkControlsTree, method: http.MethodGet, target: "/",
+			params: map[string]string{"benchmark_id": "other_framework"},
+		},
+		{
+			name: "framework trend", handler: h.GetBenchmarkTrendV3, method: http.MethodPost, target: "/",
+			params: map[string]string{"benchmark_id": "other_framework"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(httpserver.XPlatformUserRoleHeader, string(authApi.ViewerRole))
+			req.Header.Set(rbac.XPlatformUserFrameworksScope, "allowed_framework")
+			req.Header.Set(httpserver.XPlatformUserConnectionsScope, "allowed-integration")
+			e := echo.New()
+			e.Validator = testValidator{validate: validator.New()}
+			c := e.NewContext(req, httptest.NewRecorder())
+			var names, values []string
+			for name, value := range tc.params {
+				names, values = append(names, name), append(values, value)
+			}
+			c.SetParamNames(names...)
+			c.SetParamValues(values...)
+
+			err := tc.handler(c)
+			var httpErr *echo.HTTPError
+			if !errors.As(err, &httpErr) || httpErr.Code != http.StatusForbidden {
+				t.Errorf("error = %v, want %d", err, http.StatusForbidden)
+			}
+		})
+	}
+}
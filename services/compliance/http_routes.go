@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -26,12 +27,15 @@ import (
 	"github.com/opengovern/og-util/pkg/model"
 	"github.com/opengovern/opensecurity/jobs/compliance-summarizer-job/types"
 	model2 "github.com/opengovern/opensecurity/jobs/post-install-job/db/model"
+	"github.com/opengovern/opensecurity/pkg/audit"
+	"github.com/opengovern/opensecurity/pkg/rbac"
 	opengovernanceTypes "github.com/opengovern/opensecurity/pkg/types"
 	types2 "github.com/opengovern/opensecurity/pkg/types"
 	"github.com/opengovern/opensecurity/pkg/utils"
 	"github.com/opengovern/opensecurity/services/compliance/api"
 	"github.com/opengovern/opensecurity/services/compliance/db"
 	"github.com/opengovern/opensecurity/services/compliance/es"
+	"github.com/opengovern/opensecurity/services/compliance/report"
 	coreApi "github.com/opengovern/opensecurity/services/core/api"
 	"github.com/opengovern/opensecurity/services/core/db/models"
 	integrationapi "github.com/opengovern/opensecurity/services/integration/api/models"
@@ -54,6 +58,20 @@ const (
 )
 
 func (h *HttpHandler) Register(e *echo.Echo) {
+	e.Use(audit.Middleware("compliance", audit.NewSinkFromEnv(h.logger),
+		"/api/v1/compliance_result",
+		"/api/v1/compliance_result/resource",
+		"/api/v1/compliance_result/filters",
+		"/api/v1/resource_findings",
+		"/api/v1/frameworks",
+		"/api/v3/benchmarks",
+		"/api/v3/benchmarks/summary",
+		"/api/v3/benchmark/:benchmark_id",
+		"/api/v3/compliance/summary/benchmark",
+		"/api/v3/benchmarks/:benchmark_id/trend",
+		"/api/v3/controls",
+	))
+
 	v1 := e.Group("/api/v1")
 
 	benchmarks := v1.Group("/benchmarks")
@@ -93,12 +111,16 @@ func (h *HttpHandler) Register(e *echo.Echo) {
 
 	complianceFrameworks := v1.Group("/frameworks")
 	complianceFrameworks.POST("", httpserver2.AuthorizeHandler(h.ListFrameworks, authApi.ViewerRole))
+	complianceFrameworks.GET("/assignments", httpserver2.AuthorizeHandler(h.ListExplicitFrameworkAssignments, authApi.ViewerRole))
 	complianceFrameworks.GET("/:framework-id/assignments", httpserver2.AuthorizeHandler(h.ListFrameworkAssignments, authApi.ViewerRole))
 	complianceFrameworks.GET("/:framework-id/assignments/available", httpserver2.AuthorizeHandler(h.ListFrameworkAvailableAssignments, authApi.ViewerRole))
 	complianceFrameworks.PUT("/:framework-id/assignments", httpserver2.AuthorizeHandler(h.AddAssignment, authApi.EditorRole))
 	complianceFrameworks.DELETE("/:framework-id/assignments/:integration-id", httpserver2.AuthorizeHandler(h.DeleteAssignment, authApi.EditorRole))
+	complianceFrameworks.GET("/:framework-id/assignments/groups", httpserver2.AuthorizeHandler(h.ListFrameworkGroupAssignments, authApi.ViewerRole))
+	complianceFrameworks.DELETE("/:framework-id/assignments/groups/:integration-group", httpserver2.AuthorizeHandler(h.DeleteFrameworkGroupAssignment, authApi.EditorRole))
 	complianceFrameworks.PUT("/:framework-id", httpserver2.AuthorizeHandler(h.UpdateFrameworkSetting, authApi.EditorRole))
 	complianceFrameworks.GET("/:framework_id/coverage", httpserver2.AuthorizeHandler(h.GetFrameworkCoverage, authApi.ViewerRole))
+	complianceFrameworks.GET("/:framework_id/compare", httpserver2.AuthorizeHandler(h.CompareFrameworkComplianceJobs, authApi.ViewerRole))
 
 	v3 := e.Group("/api/v3")
 
@@ -125,9 +147,19 @@ func (h *HttpHandler) Register(e *echo.Echo) {
 
 	v3.GET("/quick/scan/:run_id", httpserver2.AuthorizeHandler(h.GetQuickScanSummary, authApi.ViewerRole))
 	v3.GET("/quick/sequence/:run_id", httpserver2.AuthorizeHandler(h.GetQuickSequenceSummary, authApi.ViewerRole))
+	v3.GET("/quick/sequence/:run_id/verdict", httpserver2.AuthorizeHandler(h.GetQuickSequenceVerdict, authApi.ViewerRole))
 
 	v3.GET("/job-report/:run_id/details/by-control", httpserver2.AuthorizeHandler(h.GetComplianceJobReport, authApi.ViewerRole))
 	v3.GET("/job-report/:run_id/summary", httpserver2.AuthorizeHandler(h.GetJobReportSummary, authApi.ViewerRole))
+	v3.GET("/job-report/:run_id/export", httpserver2.AuthorizeHandler(h.ExportComplianceJobReport, authApi.ViewerRole))
+
+	v3.GET("/exceptions", httpserver2.AuthorizeHandler(h.ListComplianceExceptions, authApi.ViewerRole))
+	v3.POST("/exceptions", httpserver2.AuthorizeHandler(h.CreateComplianceException, authApi.EditorRole))
+	v3.GET("/exceptions/:exception_id", httpserver2.AuthorizeHandler(h.GetComplianceException, authApi.ViewerRole))
+	v3.PUT("/exceptions/:exception_id", httpserver2.AuthorizeHandler(h.UpdateComplianceException, authApi.EditorRole))
+	v3.POST("/exceptions/:exception_id/approve", httpserver2.AuthorizeHandler(h.ApproveComplianceException, authApi.AdminRole))
+	v3.POST("/exceptions/:exception_id/reject", httpserver2.AuthorizeHandler(h.RejectComplianceException, authApi.AdminRole))
+	v3.POST("/exceptions/:exception_id/revoke", httpserver2.AuthorizeHandler(h.RevokeComplianceException, authApi.AdminRole))
 }
 
 func bindValidate(ctx echo.Context, i any) error {
@@ -142,9 +174,12 @@ func bindValidate(ctx echo.Context, i any) error {
 	return nil
 }
 
-func (h *HttpHandler) getIntegrationIdFilterFromInputs(ctx context.Context, integrationIds []string, integrationGroup []string) ([]string, error) {
+// getIntegrationIdFilterFromInputs resolves the integration filter and narrows it to the caller role bindings,
+// nil means no filter
+func (h *HttpHandler) getIntegrationIdFilterFromInputs(echoCtx echo.Context, integrationIds []string, integrationGroup []string) ([]string, error) {
+	ctx := echoCtx.Request().Context()
 	if len(integrationIds) == 0 && len(integrationGroup) == 0 {
-		return nil, nil
+		return rbac.ResolveIntegrationIDs(echoCtx, nil)
 	}
 
 	if len(integrationIds) > 0 && len(integrationGroup) > 0 {
@@ -152,7 +187,7 @@ func (h *HttpHandler) getIntegrationIdFilterFromInputs(ctx context.Context, inte
 	}
 
 	if len(integrationIds) > 0 {
-		return integrationIds, nil
+		return rbac.ResolveIntegrationIDs(echoCtx, integrationIds)
 	}
 
 	check := make(map[string]bool)
@@ -164,7 +199,7 @@ func (h *HttpHandler) getIntegrationIdFilterFromInputs(ctx context.Context, inte
 			return nil, err
 		}
 		if len(integrationGroupObj.IntegrationIds) == 0 {
-			return nil, err
+			return rbac.ResolveIntegrationIDs(echoCtx, nil)
 		}
 
 		// Check for duplicate integration groups
@@ -177,17 +212,28 @@ func (h *HttpHandler) getIntegrationIdFilterFromInputs(ctx context.Context, inte
 	}
 	integrationIds = integrationIDSChecked
 
-	return integrationIds, nil
+	return rbac.ResolveIntegrationIDs(echoCtx, integrationIds)
 }
 
 func (h *HttpHandler) getIntegrationIdFilterFromParams(echoCtx echo.Context) ([]string, error) {
 	integrationIds := httpserver2.QueryArrayParam(echoCtx, IntegrationIDParam)
-	//integrationIds, err := httpserver2.ResolveIntegrationIDs(echoCtx, integrationIds)
-	//if err != nil {
-	//	return nil, err
-	//}
 	integrationGroup := httpserver2.QueryArrayParam(echoCtx, IntegrationGroupParam)
-	return h.getIntegrationIdFilterFromInputs(echoCtx.Request().Context(), integrationIds, integrationGroup)
+	return h.getIntegrationIdFilterFromInputs(echoCtx, integrationIds, integrationGroup)
+}
+
+// checkComplianceJobAccess denies scoped users the results of jobs on frameworks or integrations outside their role bindings
+func checkComplianceJobAccess(echoCtx echo.Context, job *schedulerapi.GetComplianceJobStatusResponse) error {
+	for _, framework := range job.Frameworks {
+		if err := rbac.CheckFrameworkAccess(echoCtx, framework.FrameworkID); err != nil {
+			return err
+		}
+	}
+	for _, integration := range job.Integrations {
+		if err := rbac.CheckIntegrationAccess(echoCtx, integration.IntegrationID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 var tracer = otel.Tracer("new_compliance")
@@ -212,7 +258,11 @@ func (h *HttpHandler) GetComplianceResults(echoCtx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	req.Filters.IntegrationID, err = h.getIntegrationIdFilterFromInputs(echoCtx.Request().Context(), req.Filters.IntegrationID, req.Filters.IntegrationGroup)
+	req.Filters.IntegrationID, err = h.getIntegrationIdFilterFromInputs(echoCtx, req.Filters.IntegrationID, req.Filters.IntegrationGroup)
+	if err != nil {
+		return err
+	}
+	req.Filters.BenchmarkID, err = rbac.ResolveFrameworkIDs(echoCtx, req.Filters.BenchmarkID)
 	if err != nil {
 		return err
 	}
@@ -460,7 +510,7 @@ func (h *HttpHandler) GetSingleResourceFinding(echoCtx echo.Context) error {
 			}
 		}
 	}
-	if lookupResource == nil {
+	if lookupResource == nil || !rbac.HasIntegrationAccess(echoCtx, lookupResource.IntegrationID) {
 		return echo.NewHTTPError(http.StatusNotFound, "resource not found")
 	}
 
@@ -659,15 +709,14 @@ func (h *HttpHandler) GetComplianceResultFilterValues(echoCtx echo.Context) erro
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	req.IntegrationID, err = h.getIntegrationIdFilterFromInputs(echoCtx.Request().Context(), req.IntegrationID, req.IntegrationGroup)
+	req.IntegrationID, err = h.getIntegrationIdFilterFromInputs(echoCtx, req.IntegrationID, req.IntegrationGroup)
+	if err != nil {
+		return err
+	}
+	req.BenchmarkID, err = rbac.ResolveFrameworkIDs(echoCtx, req.BenchmarkID)
 	if err != nil {
 		return err
 	}
-
-	//req.IntegrationID, err = httpserver2.ResolveIntegrationIDs(echoCtx, req.IntegrationID)
-	//if err != nil {
-	//	return err
-	//}
 
 	if len(req.ComplianceStatus) == 0 {
 		req.ComplianceStatus = []api.ComplianceStatus{api.ComplianceStatusFailed}
@@ -911,7 +960,10 @@ func (h *HttpHandler) GetTopFieldByComplianceResultCount(echoCtx echo.Context) e
 	}
 	notIntegrationIDs := httpserver2.QueryArrayParam(echoCtx, "notIntegrationId")
 	integrationTypes := httpserver2.QueryArrayParam(echoCtx, "integrationTypes")
-	benchmarkIDs := httpserver2.QueryArrayParam(echoCtx, "benchmarkId")
+	benchmarkIDs, err := rbac.ResolveFrameworkIDs(echoCtx, httpserver2.QueryArrayParam(echoCtx, "benchmarkId"))
+	if err != nil {
+		return err
+	}
 	controlIDs := httpserver2.QueryArrayParam(echoCtx, "controlId")
 	jobIDs := httpserver2.QueryArrayParam(echoCtx, "jobId")
 	severities := opengovernanceTypes.ParseComplianceResultSeverities(httpserver2.QueryArrayParam(echoCtx, "severities"))
@@ -1338,6 +1390,9 @@ func (h *HttpHandler) GetAccountsComplianceResultsSummary(echoCtx echo.Context)
 	ctx := echoCtx.Request().Context()
 
 	benchmarkID := echoCtx.Param("benchmarkId")
+	if err := rbac.CheckFrameworkAccess(echoCtx, benchmarkID); err != nil {
+		return err
+	}
 	integrationIDs, err := h.getIntegrationIdFilterFromParams(echoCtx)
 	if err != nil {
 		return err
@@ -1437,6 +1492,9 @@ func (h *HttpHandler) GetServicesComplianceResultsSummary(echoCtx echo.Context)
 	ctx := echoCtx.Request().Context()
 
 	benchmarkID := echoCtx.Param("benchmarkId")
+	if err := rbac.CheckFrameworkAccess(echoCtx, benchmarkID); err != nil {
+		return err
+	}
 	integrationIDs, err := h.getIntegrationIdFilterFromParams(echoCtx)
 	if err != nil {
 		return err
@@ -1538,6 +1596,7 @@ func (h *HttpHandler) ChangeBenchmarkSettings(echoCtx echo.Context) error {
 		if err != nil {
 			return err
 		}
+		audit.SetAfter(echoCtx, map[string]any{"tracks_drift_events": tracksDriftEvents})
 	}
 
 	return echoCtx.NoContent(http.StatusOK)
@@ -1579,10 +1638,14 @@ func (h *HttpHandler) ListResourceFindings(echoCtx echo.Context) error {
 		req.Filters.IntegrationID = integrationIDs
 	}
 
-	//req.Filters.IntegrationID, err = httpserver2.ResolveIntegrationIDs(echoCtx, req.Filters.IntegrationID)
-	//if err != nil {
-	//	return err
-	//}
+	req.Filters.IntegrationID, err = rbac.ResolveIntegrationIDs(echoCtx, req.Filters.IntegrationID)
+	if err != nil {
+		return err
+	}
+	req.Filters.BenchmarkID, err = rbac.ResolveFrameworkIDs(echoCtx, req.Filters.BenchmarkID)
+	if err != nil {
+		return err
+	}
 
 	if len(req.AfterSortKey) != 0 {
 		expectedLen := len(req.Sort) + 1
@@ -1797,6 +1860,9 @@ func addToControlSeverityResultV2(controlSeverityResult api.BenchmarkControlsSev
 //	@Router			/compliance/api/v1/benchmarks/{benchmark_id}/summary [get]
 func (h *HttpHandler) GetBenchmarkSummary(echoCtx echo.Context) error {
 	ctx := echoCtx.Request().Context()
+	if err := rbac.CheckFrameworkAccess(echoCtx, echoCtx.Param("benchmark_id")); err != nil {
+		return err
+	}
 
 	integrationIDs, err := h.getIntegrationIdFilterFromParams(echoCtx)
 	if err != nil {
@@ -2098,6 +2164,9 @@ func (h *HttpHandler) GetBenchmarkControlsTree(echoCtx echo.Context) error {
 
 	tagMap := model.TagStringsToTagMap(httpserver2.QueryArrayParam(echoCtx, "tag"))
 	benchmarkID := echoCtx.Param("benchmark_id")
+	if err := rbac.CheckFrameworkAccess(echoCtx, benchmarkID); err != nil {
+		return err
+	}
 
 	integrationIDs, err := h.getIntegrationIdFilterFromParams(echoCtx)
 	if err != nil {
@@ -2286,6 +2355,10 @@ func (h *HttpHandler) ListControlsFiltered(echoCtx echo.Context) error {
 	if req.ComplianceResultFilters != nil {
 		integrationIDs = req.ComplianceResultFilters.IntegrationID
 	}
+	integrationIDs, err = rbac.ResolveIntegrationIDs(echoCtx, integrationIDs)
+	if err != nil {
+		return err
+	}
 	if len(integrationIDs) == 0 {
 		integrations, err := h.integrationClient.ListIntegrations(&httpclient.Context{UserRole: authApi.AdminRole}, nil)
 		if err != nil {
@@ -2409,6 +2482,10 @@ func (h *HttpHandler) ListControlsFiltered(echoCtx echo.Context) error {
 			if len(req.ComplianceResultFilters.BenchmarkID) > 0 {
 				benchmarksFilter = req.ComplianceResultFilters.BenchmarkID
 			}
+			benchmarksFilter, err = rbac.ResolveFrameworkIDs(echoCtx, benchmarksFilter)
+			if err != nil {
+				return err
+			}
 			fRes, err = es.ComplianceResultsCountByControlID(ctx, h.logger, h.client, req.ComplianceResultFilters.ResourceID,
 				req.ComplianceResultFilters.IntegrationType, integrationIDs, req.ComplianceResultFilters.NotIntegrationID,
 				req.ComplianceResultFilters.ResourceTypeID, benchmarksFilter, controlIDs, req.ComplianceResultFilters.Severity,
@@ -2732,21 +2809,18 @@ func (h *HttpHandler) GetControlSummary(echoCtx echo.Context) error {
 
 	controlID := echoCtx.Param("controlId")
 	integrationIds := httpserver2.QueryArrayParam(echoCtx, IntegrationIDParam)
-	//integrationIds, err := httpserver2.ResolveIntegrationIDs(echoCtx, integrationIds)
-	//if err != nil {
-	//	return err
-	//}
 	integrationGroup := httpserver2.QueryArrayParam(echoCtx, IntegrationGroupParam)
 
 	if len(integrationIds) == 0 && len(integrationGroup) == 0 {
 		integrationGroup = []string{"active"}
 	}
-	integrationIDs, err := h.getIntegrationIdFilterFromInputs(echoCtx.Request().Context(), integrationIds, integrationGroup)
+	integrationIDs, err := h.getIntegrationIdFilterFromInputs(echoCtx, integrationIds, integrationGroup)
 	if err != nil {
 		return err
 	}
 
-	controlSummary, err := h.getControlSummary(ctx, controlID, nil, integrationIDs)
+	frameworkScope, _ := rbac.FrameworkScope(echoCtx)
+	controlSummary, err := h.getControlSummary(ctx, controlID, nil, integrationIDs, frameworkScope)
 	if err != nil {
 		return err
 	}
@@ -2754,7 +2828,8 @@ func (h *HttpHandler) GetControlSummary(echoCtx echo.Context) error {
 	return echoCtx.JSON(http.StatusOK, controlSummary)
 }
 
-func (h *HttpHandler) getControlSummary(ctx context.Context, controlID string, benchmarkID *string, integrationIDs []string) (*api.ControlSummary, error) {
+// getControlSummary summarizes the control results, frameworkScope limits the frameworks counted when not nil
+func (h *HttpHandler) getControlSummary(ctx context.Context, controlID string, benchmarkID *string, integrationIDs []string, frameworkScope []string) (*api.ControlSummary, error) {
 	control, err := h.db.GetControl(ctx, controlID)
 	if err != nil {
 		h.logger.Error("failed to fetch control", zap.Error(err), zap.String("controlID", controlID), zap.Stringp("benchmarkID", benchmarkID))
@@ -2806,6 +2881,9 @@ func (h *HttpHandler) getControlSummary(ctx context.Context, controlID string, b
 	benchmarkIds := make([]string, 0, len(benchmarks))
 	apiBenchmarks := make([]api.Benchmark, 0, len(benchmarks))
 	for _, benchmark := range benchmarks {
+		if frameworkScope != nil && !slices.Contains(frameworkScope, benchmark.ID) {
+			continue
+		}
 		benchmarkIds = append(benchmarkIds, benchmark.ID)
 		apiBenchmarks = append(apiBenchmarks, benchmark.ToApi())
 	}
@@ -2955,7 +3033,7 @@ func (h *HttpHandler) ListAssignmentsByBenchmark(echoCtx echo.Context) error {
 	resp := api.BenchmarkAssignedEntities{}
 
 	for _, item := range assignedIntegrations {
-		if httpserver2.CheckAccessToConnectionID(echoCtx, item.IntegrationID) != nil {
+		if !rbac.HasIntegrationAccess(echoCtx, item.IntegrationID) {
 			continue
 		}
 		resp.Integrations = append(resp.Integrations, item)
@@ -3059,6 +3137,9 @@ func (h *HttpHandler) ListBenchmarksFiltered(echoCtx echo.Context) error {
 
 	var items []api.GetBenchmarkListItem
 	for _, b := range benchmarks {
+		if !rbac.HasFrameworkAccess(echoCtx, b.ID) {
+			continue
+		}
 		var incidentCount int
 		complianceResults, err := h.getBenchmarkComplianceResultSummary(ctx, b.ID, req.ComplianceResultFilters)
 		if err != nil {
@@ -3384,6 +3465,9 @@ func (h *HttpHandler) GetBenchmarkDetails(echoCtx echo.Context) error {
 	ctx := echoCtx.Request().Context()
 
 	benchmarkId := echoCtx.Param("benchmark_id")
+	if err := rbac.CheckFrameworkAccess(echoCtx, benchmarkId); err != nil {
+		return err
+	}
 
 	var req api.GetBenchmarkDetailsRequest
 	if err := bindValidate(echoCtx, &req); err != nil {
@@ -4615,6 +4699,9 @@ func (h *HttpHandler) ListBenchmarksNestedForBenchmark(echoCtx echo.Context) err
 func (h *HttpHandler) GetBenchmarkTrendV3(echoCtx echo.Context) error {
 	ctx := echoCtx.Request().Context()
 	clientCtx := &httpclient.Context{UserRole: authApi.AdminRole}
+	if err := rbac.CheckFrameworkAccess(echoCtx, echoCtx.Param("benchmark_id")); err != nil {
+		return err
+	}
 
 	var req api.GetBenchmarkTrendV3Request
 	if err := bindValidate(echoCtx, &req); err != nil {
@@ -4888,6 +4975,9 @@ func (h HttpHandler) GetQuickScanSummary(c echo.Context) error {
 		h.logger.Error("failed to get compliance job", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get compliance job")
 	}
+	if err := checkComplianceJobAccess(c, complianceJob); err != nil {
+		return err
+	}
 	if complianceJob.JobStatus == schedulerapi.ComplianceJobTimeout {
 		return echo.NewHTTPError(http.StatusBadRequest, "job has been timed out")
 	} else if complianceJob.JobStatus == schedulerapi.ComplianceJobRunnersInProgress ||
@@ -5046,6 +5136,128 @@ func (h HttpHandler) GetQuickSequenceSummary(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+// GetQuickSequenceVerdict godoc
+//
+//	@Summary		Get the gate verdict of a quick scan sequence
+//	@Description	Evaluates the gate of a quick scan sequence against its results. With wait=true the request blocks until the sequence finishes or the timeout is reached.
+//	@Description	The exit_code of the verdict is 0 when the gate passed, 1 when it failed and 2 when the scan failed or is still running.
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Produce		json
+//	@Param			run_id	path		string	true	"Quick scan sequence id"
+//	@Param			wait	query		bool	false	"Wait for the sequence to finish"
+//	@Param			timeout	query		int		false	"Wait timeout in seconds (default 300, max 900)"
+//	@Success		200		{object}	api.QuickScanVerdict
+//	@Router			/compliance/api/v3/quick/sequence/{run_id}/verdict [get]
+func (h HttpHandler) GetQuickSequenceVerdict(c echo.Context) error {
+	ctx := c.Request().Context()
+	clientCtx := &httpclient.Context{Ctx: ctx, UserRole: authApi.AdminRole}
+
+	runID := c.Param("run_id")
+	wait := c.QueryParam("wait") == "true"
+	timeout := quickScanVerdictDefaultTimeout
+	if timeoutStr := c.QueryParam("timeout"); timeoutStr != "" {
+		seconds, err := strconv.Atoi(timeoutStr)
+		if err != nil || seconds <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid timeout")
+		}
+		timeout = min(time.Duration(seconds)*time.Second, quickScanVerdictMaxTimeout)
+	}
+
+	deadline := time.Now().Add(timeout)
+	var sequence *schedulerapi.QuickScanSequence
+	for {
+		var err error
+		sequence, err = h.schedulerClient.GetComplianceQuickSequence(clientCtx, runID)
+		if err != nil {
+			h.logger.Error("failed to get quick scan sequence", zap.String("run_id", runID), zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get quick scan sequence")
+		}
+		if sequence.Status == schedulerapi.QuickScanSequenceFinished || sequence.Status == schedulerapi.QuickScanSequenceFailed ||
+			!wait || time.Now().After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(quickScanVerdictPollInterval):
+		}
+	}
+
+	verdict := api.QuickScanVerdict{
+		RunID:      runID,
+		QuickRunID: sequence.ComplianceQuickRunID,
+	}
+	if sequence.Gate != nil {
+		verdict.BaselineRunID = sequence.Gate.BaselineRunID
+	}
+
+	switch sequence.Status {
+	case schedulerapi.QuickScanSequenceFinished:
+	case schedulerapi.QuickScanSequenceFailed:
+		verdict.Status = api.QuickScanVerdictError
+		verdict.ExitCode = verdict.Status.ExitCode()
+		verdict.FailureMessage = sequence.FailureMessage
+		return c.JSON(http.StatusOK, verdict)
+	default:
+		verdict.Status = api.QuickScanVerdictPending
+		verdict.ExitCode = verdict.Status.ExitCode()
+		verdict.Message = fmt.Sprintf("quick scan sequence is %s", sequence.Status)
+		return c.JSON(http.StatusOK, verdict)
+	}
+
+	view, err := h.getQuickSequenceResourceView(ctx, sequence)
+	if err != nil {
+		return err
+	}
+	if view == nil {
+		verdict.Status = api.QuickScanVerdictError
+		verdict.ExitCode = verdict.Status.ExitCode()
+		verdict.Message = "quick scan results not found"
+		return c.JSON(http.StatusOK, verdict)
+	}
+
+	var baselineView *types2.ComplianceJobReportResourceView
+	if sequence.Gate != nil && sequence.Gate.BaselineRunID != nil {
+		baseline, err := h.schedulerClient.GetComplianceQuickSequence(clientCtx, *sequence.Gate.BaselineRunID)
+		if err != nil {
+			h.logger.Error("failed to get baseline quick scan sequence", zap.String("run_id", *sequence.Gate.BaselineRunID), zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get baseline quick scan sequence")
+		}
+		if baseline.Status != schedulerapi.QuickScanSequenceFinished {
+			verdict.Status = api.QuickScanVerdictError
+			verdict.ExitCode = verdict.Status.ExitCode()
+			verdict.Message = fmt.Sprintf("baseline quick scan sequence is %s", baseline.Status)
+			return c.JSON(http.StatusOK, verdict)
+		}
+		baselineView, err = h.getQuickSequenceResourceView(ctx, baseline)
+		if err != nil {
+			return err
+		}
+		if baselineView == nil {
+			verdict.Status = api.QuickScanVerdictError
+			verdict.ExitCode = verdict.Status.ExitCode()
+			verdict.Message = "baseline quick scan results not found"
+			return c.JSON(http.StatusOK, verdict)
+		}
+	}
+
+	evaluateQuickScanGate(&verdict, sequence.Gate, view, baselineView)
+	return c.JSON(http.StatusOK, verdict)
+}
+
+func (h HttpHandler) getQuickSequenceResourceView(ctx context.Context, sequence *schedulerapi.QuickScanSequence) (*types2.ComplianceJobReportResourceView, error) {
+	if sequence.ComplianceQuickRunID == nil {
+		return nil, nil
+	}
+	view, err := es.GetJobReportResourceViewByJobID(ctx, h.logger, h.client, *sequence.ComplianceQuickRunID, false)
+	if err != nil {
+		h.logger.Error("failed to get quick scan resource view", zap.String("run_id", sequence.ID), zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get quick scan results")
+	}
+	return view, nil
+}
+
 // GetComplianceJobReport godoc
 //
 //	@Summary		List all workspaces with owner id
@@ -5070,6 +5282,9 @@ func (h HttpHandler) GetComplianceJobReport(c echo.Context) error {
 		h.logger.Error("failed to get compliance job", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get compliance job")
 	}
+	if err := checkComplianceJobAccess(c, complianceJob); err != nil {
+		return err
+	}
 	if complianceJob.JobStatus == schedulerapi.ComplianceJobTimeout {
 		return echo.NewHTTPError(http.StatusBadRequest, "job has been timed out")
 	} else if complianceJob.JobStatus == schedulerapi.ComplianceJobRunnersInProgress ||
@@ -5111,6 +5326,9 @@ func (h HttpHandler) GetJobReportSummary(ctx echo.Context) error {
 		h.logger.Error("failed to get compliance job", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get compliance job")
 	}
+	if err := checkComplianceJobAccess(ctx, complianceJob); err != nil {
+		return err
+	}
 	if complianceJob.JobStatus == schedulerapi.ComplianceJobTimeout {
 		return echo.NewHTTPError(http.StatusBadRequest, "job has been timed out")
 	} else if complianceJob.JobStatus == schedulerapi.ComplianceJobRunnersInProgress ||
@@ -5201,6 +5419,108 @@ func (h HttpHandler) GetJobReportSummary(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, response)
 }
 
+// ExportComplianceJobReport godoc
+//
+//	@Summary		Export Job Report
+//	@Description	Export the results of a compliance job as SARIF 2.1, CSV (one row per control and resource), JUnit XML or a self-contained HTML report
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Produce		json,text/csv,application/xml,text/html
+//	@Param			format	query	string	true	"Export format"	Enums(sarif,csv,junit,html)
+//	@Param			run_id	path	string	true	"compliance job id"
+//	@Success		200
+//	@Router			/compliance/api/v3/job-report/{run_id}/export [get]
+func (h HttpHandler) ExportComplianceJobReport(c echo.Context) error {
+	clientCtx := &httpclient.Context{UserRole: authApi.AdminRole}
+	ctx := c.Request().Context()
+
+	jobId := c.Param("run_id")
+	format, err := report.ParseFormat(strings.ToLower(c.QueryParam("format")))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "format should be one of sarif, csv, junit or html")
+	}
+
+	complianceJob, err := h.schedulerClient.GetComplianceJobStatus(clientCtx, jobId)
+	if err != nil {
+		h.logger.Error("failed to get compliance job", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get compliance job")
+	}
+	if err := checkComplianceJobAccess(c, complianceJob); err != nil {
+		return err
+	}
+	if complianceJob.JobStatus == schedulerapi.ComplianceJobTimeout {
+		return echo.NewHTTPError(http.StatusBadRequest, "job has been timed out")
+	} else if complianceJob.JobStatus == schedulerapi.ComplianceJobRunnersInProgress ||
+		complianceJob.JobStatus == schedulerapi.ComplianceJobCreated ||
+		complianceJob.JobStatus == schedulerapi.ComplianceJobSummarizerInProgress {
+		return echo.NewHTTPError(http.StatusBadRequest, "job is in progress")
+	}
+
+	resourceView, err := es.GetJobReportResourceViewByJobID(ctx, h.logger, h.client, jobId, true)
+	if err != nil {
+		h.logger.Error("failed to get job report resource view by job id", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get job report")
+	}
+	if resourceView == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "job report not found")
+	}
+
+	frameworkTitle := resourceView.JobSummary.FrameworkID
+	framework, err := h.db.GetFramework(ctx, resourceView.JobSummary.FrameworkID)
+	if err != nil {
+		h.logger.Error("failed to get framework by frameworkID", zap.String("framework", resourceView.JobSummary.FrameworkID), zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get framework by frameworkID")
+	}
+	if framework != nil && framework.Title != "" {
+		frameworkTitle = framework.Title
+	}
+
+	controlIDsMap := make(map[string]bool)
+	for _, integration := range resourceView.Integrations {
+		for _, resourceType := range integration.ResourceTypes {
+			for _, resource := range resourceType.Resources {
+				for _, findings := range resource.Results {
+					for _, finding := range findings {
+						controlIDsMap[finding.ControlID] = true
+					}
+				}
+			}
+		}
+	}
+	controlInfos := make(map[string]report.ControlInfo)
+	if len(controlIDsMap) > 0 {
+		var controlIDs []string
+		for controlID := range controlIDsMap {
+			controlIDs = append(controlIDs, controlID)
+		}
+		controls, err := h.db.ListControls(controlIDs, nil)
+		if err != nil {
+			h.logger.Error("failed to list controls", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to list controls")
+		}
+		for _, control := range controls {
+			controlInfos[control.ID] = report.ControlInfo{
+				Title:       control.Title,
+				Description: control.Description,
+				DocumentURI: control.DocumentURI,
+			}
+		}
+	}
+
+	r := report.NewReport(*resourceView, frameworkTitle, controlInfos)
+
+	c.Response().Header().Set(echo.HeaderContentType, format.ContentType())
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"compliance-job-%s.%s\"", jobId, format.FileExtension()))
+	c.Response().WriteHeader(http.StatusOK)
+	if err := r.Write(c.Response(), format); err != nil {
+		// headers are already sent, the client gets a truncated file
+		h.logger.Error("failed to write job report export", zap.String("format", string(format)), zap.Error(err))
+		return nil
+	}
+	return nil
+}
+
 // ListPolicies godoc
 //
 //	@Summary		List all policies
@@ -5611,7 +5931,7 @@ func (h *HttpHandler) AddAssignment(echoCtx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	if len(req.Integrations) == 0 {
+	if len(req.Integrations) == 0 && len(req.IntegrationGroups) == 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "integration id is empty")
 	}
 	frameworkId := echoCtx.Param("framework-id")
@@ -5633,6 +5953,9 @@ func (h *HttpHandler) AddAssignment(echoCtx echo.Context) error {
 	if strings.HasPrefix(framework.ID, "baseline_") {
 		return echo.NewHTTPError(http.StatusBadRequest, "framework is baseline")
 	}
+	if before, err := h.frameworkAssignmentState(ctx, frameworkId); err == nil {
+		audit.SetBefore(echoCtx, before)
+	}
 	supportedPlugins := make(map[string]bool)
 	for _, it := range framework.IntegrationType {
 		supportedPlugins[it] = true
@@ -5661,11 +5984,64 @@ func (h *HttpHandler) AddAssignment(echoCtx echo.Context) error {
 			h.logger.Error("failed to add assignment", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to add assignment")
 		}
+		// an explicit assignment outlives the group the integration may already be assigned through
+		if err := h.db.SetBenchmarkAssignmentGroup(ctx, frameworkId, integration.IntegrationID, nil); err != nil {
+			h.logger.Error("failed to add assignment", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to add assignment")
+		}
+	}
+
+	for _, groupName := range req.IntegrationGroups {
+		if _, err := h.integrationClient.GetIntegrationGroup(&httpclient.Context{Ctx: ctx, UserRole: authApi.AdminRole}, groupName); err != nil {
+			h.logger.Error("failed to get integration group", zap.String("group", groupName), zap.Error(err))
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to get integration group %s", groupName))
+		}
+
+		groupAssignment := &db.BenchmarkGroupAssignment{
+			BenchmarkId:      frameworkId,
+			IntegrationGroup: groupName,
+			AssignedAt:       time.Now(),
+		}
+		if err := h.db.AddBenchmarkGroupAssignment(ctx, groupAssignment); err != nil {
+			h.logger.Error("failed to add group assignment", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to add group assignment")
+		}
+		if err := h.syncGroupAssignment(ctx, *groupAssignment, framework); err != nil {
+			h.logger.Error("failed to sync group assignment", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to sync group assignment")
+		}
+	}
+	if after, err := h.frameworkAssignmentState(ctx, frameworkId); err == nil {
+		audit.SetAfter(echoCtx, after)
 	}
 
 	return echoCtx.NoContent(http.StatusOK)
 }
 
+// frameworkAssignmentState returns the integrations and integration groups assigned to the framework for the audit log
+func (h *HttpHandler) frameworkAssignmentState(ctx context.Context, frameworkId string) (map[string][]string, error) {
+	assignments, err := h.db.GetBenchmarkAssignmentsByBenchmarkId(ctx, frameworkId)
+	if err != nil {
+		return nil, err
+	}
+	groupAssignments, err := h.db.ListBenchmarkGroupAssignments(ctx, &frameworkId)
+	if err != nil {
+		return nil, err
+	}
+	state := map[string][]string{"integrations": {}, "integration_groups": {}}
+	for _, assignment := range assignments {
+		if assignment.IntegrationID != nil {
+			state["integrations"] = append(state["integrations"], *assignment.IntegrationID)
+		}
+	}
+	for _, groupAssignment := range groupAssignments {
+		state["integration_groups"] = append(state["integration_groups"], groupAssignment.IntegrationGroup)
+	}
+	sort.Strings(state["integrations"])
+	sort.Strings(state["integration_groups"])
+	return state, nil
+}
+
 // DeleteAssignment godoc
 //
 //	@Summary		Create framework assignment
@@ -5699,6 +6075,9 @@ func (h *HttpHandler) DeleteAssignment(echoCtx echo.Context) error {
 	if assignment == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "assignment not found")
 	}
+	if assignment.IntegrationGroup != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("integration is assigned through integration group %s", *assignment.IntegrationGroup))
+	}
 
 	if err := h.db.DeleteBenchmarkAssignmentByIds(ctx, frameworkId, &integrationId); err != nil {
 		h.logger.Error("failed to delete assignment", zap.Error(err))
@@ -5799,6 +6178,157 @@ func (h HttpHandler) GetFrameworkCoverage(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, coverage)
 }
 
+// CompareFrameworkComplianceJobs godoc
+//
+//	@Summary		Compare two compliance jobs of a framework
+//	@Description	Returns the newly failing, newly passing, unchanged failing and removed resources per control between two compliance jobs of the framework.
+//	@Description	Each side is either a job id or a timestamp, a timestamp resolves to the latest succeeded job of the framework created at or before it.
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Produce		json
+//	@Param			framework_id	path		string	true	"Framework id"
+//	@Param			base_job_id		query		string	false	"Base compliance job id"
+//	@Param			target_job_id	query		string	false	"Target compliance job id"
+//	@Param			base_time		query		int		false	"Base timestamp in unix seconds, used when base_job_id is empty"
+//	@Param			target_time		query		int		false	"Target timestamp in unix seconds, used when target_job_id is empty"
+//	@Success		200				{object}	api.ComplianceComparison
+//	@Router			/compliance/api/v1/frameworks/{framework_id}/compare [get]
+func (h HttpHandler) CompareFrameworkComplianceJobs(c echo.Context) error {
+	ctx := c.Request().Context()
+	frameworkID := c.Param("framework_id")
+	if err := rbac.CheckFrameworkAccess(c, frameworkID); err != nil {
+		return err
+	}
+
+	baseJob, err := h.resolveComparisonJob(c, frameworkID, c.QueryParam("base_job_id"), c.QueryParam("base_time"), "base")
+	if err != nil {
+		return err
+	}
+	targetJob, err := h.resolveComparisonJob(c, frameworkID, c.QueryParam("target_job_id"), c.QueryParam("target_time"), "target")
+	if err != nil {
+		return err
+	}
+	if baseJob.JobId == targetJob.JobId {
+		return echo.NewHTTPError(http.StatusBadRequest, "base and target resolve to the same compliance job")
+	}
+	if baseJob.CreatedAt.After(targetJob.CreatedAt) {
+		return echo.NewHTTPError(http.StatusBadRequest, "base compliance job should be older than the target compliance job")
+	}
+
+	hasAccess := func(integrationID string) bool {
+		return rbac.HasIntegrationAccess(c, integrationID)
+	}
+	var views [2]map[string]comparisonResult
+	for i, job := range []*schedulerapi.GetComplianceJobStatusResponse{baseJob, targetJob} {
+		view, err := es.GetJobReportResourceViewByJobID(ctx, h.logger, h.client, strconv.FormatUint(uint64(job.JobId), 10), true)
+		if err != nil {
+			h.logger.Error("failed to get job report resource view by job id", zap.Uint("job_id", job.JobId), zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get job report")
+		}
+		if view == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("job report of compliance job %d not found", job.JobId))
+		}
+		views[i] = comparisonResults(view, hasAccess)
+	}
+
+	comparison := api.ComplianceComparison{
+		FrameworkID:   frameworkID,
+		BaseJobID:     baseJob.JobId,
+		BaseJobTime:   baseJob.CreatedAt,
+		TargetJobID:   targetJob.JobId,
+		TargetJobTime: targetJob.CreatedAt,
+	}
+
+	integrationIDs, err := rbac.ResolveIntegrationIDs(c, nil)
+	if err != nil {
+		return err
+	}
+	var driftEvents []opengovernanceTypes.ComplianceResultDriftEvent
+	events, err := es.FetchComplianceResultDriftEventsBetween(ctx, h.logger, h.client, frameworkID, integrationIDs, baseJob.CreatedAt, targetJob.LastUpdatedAt)
+	if err != nil {
+		h.logger.Warn("failed to fetch drift events, comparing without them", zap.String("framework_id", frameworkID), zap.Error(err))
+	} else {
+		for _, event := range events {
+			// the events of the base job lead to the base state itself
+			if event.ParentComplianceJobID == baseJob.JobId {
+				continue
+			}
+			driftEvents = append(driftEvents, event)
+		}
+		comparison.DriftEventsUsed = len(driftEvents) > 0
+	}
+
+	compareJobReports(&comparison, views[0], views[1], driftEvents)
+
+	if len(comparison.Controls) > 0 {
+		controlIDs := make([]string, 0, len(comparison.Controls))
+		for _, control := range comparison.Controls {
+			controlIDs = append(controlIDs, control.ControlID)
+		}
+		controls, err := h.db.ListControls(controlIDs, nil)
+		if err != nil {
+			h.logger.Error("failed to list controls", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to list controls")
+		}
+		titles := make(map[string]string)
+		for _, control := range controls {
+			titles[control.ID] = control.Title
+		}
+		for i := range comparison.Controls {
+			comparison.Controls[i].Title = titles[comparison.Controls[i].ControlID]
+		}
+	}
+
+	return c.JSON(http.StatusOK, comparison)
+}
+
+// resolveComparisonJob returns the succeeded compliance job of the framework given by its id or by a unix timestamp
+func (h HttpHandler) resolveComparisonJob(c echo.Context, frameworkID, jobID, timestamp, side string) (*schedulerapi.GetComplianceJobStatusResponse, error) {
+	clientCtx := &httpclient.Context{UserRole: authApi.AdminRole}
+
+	if jobID == "" {
+		if timestamp == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("either %[1]s_job_id or %[1]s_time is required", side))
+		}
+		t, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s_time", side))
+		}
+		job, err := h.schedulerClient.GetLatestSucceededComplianceJobForBenchmarkBefore(clientCtx, frameworkID, time.Unix(t, 0))
+		if err != nil {
+			h.logger.Error("failed to get latest compliance job of framework", zap.String("framework_id", frameworkID), zap.Error(err))
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get compliance job")
+		}
+		if job == nil {
+			return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("no succeeded compliance job of the framework before %s_time", side))
+		}
+		jobID = strconv.FormatUint(uint64(job.ID), 10)
+	}
+
+	job, err := h.schedulerClient.GetComplianceJobStatus(clientCtx, jobID)
+	if err != nil {
+		h.logger.Error("failed to get compliance job", zap.String("job_id", jobID), zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get compliance job")
+	}
+	if job == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("%s compliance job not found", side))
+	}
+	inFramework := false
+	for _, framework := range job.Frameworks {
+		if framework.FrameworkID == frameworkID {
+			inFramework = true
+			break
+		}
+	}
+	if !inFramework {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s compliance job is not a job of the framework", side))
+	}
+	if job.JobStatus != schedulerapi.ComplianceJobSucceeded {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s compliance job has not succeeded", side))
+	}
+	return job, nil
+}
+
 // ListFrameworks godoc
 //
 //	@Summary	List frameworks with compliance summary
@@ -5859,6 +6389,9 @@ func (h *HttpHandler) ListFrameworks(echoCtx echo.Context) error {
 
 	var items []api.FrameworkItem
 	for _, f := range frameworks {
+		if !rbac.HasFrameworkAccess(echoCtx, f.ID) {
+			continue
+		}
 		metadata := db.BenchmarkMetadata{}
 
 		if len(f.Metadata.Bytes) > 0 {
@@ -6054,3 +6587,338 @@ func (h HttpHandler) PurgeSampleData(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+// ListComplianceExceptions godoc
+//
+//	@Summary		List compliance exceptions
+//	@Description	List the accepted risk and false positive exceptions suppressing compliance results
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Produce		json
+//	@Param			control_id	query		[]string	false	"Control IDs"
+//	@Param			status		query		[]string	false	"Statuses"	Enums(pending,approved,rejected,revoked,expired)
+//	@Param			active		query		bool		false	"Only approved exceptions that are not expired"
+//	@Success		200			{object}	api.ListComplianceExceptionsResponse
+//	@Router			/compliance/api/v3/exceptions [get]
+func (h *HttpHandler) ListComplianceExceptions(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+
+	controlIDs := httpserver2.QueryArrayParam(echoCtx, "control_id")
+	var statuses []api.ComplianceExceptionStatus
+	for _, status := range httpserver2.QueryArrayParam(echoCtx, "status") {
+		statuses = append(statuses, api.ComplianceExceptionStatus(strings.ToLower(status)))
+	}
+
+	var exceptions []db.ComplianceException
+	var err error
+	if echoCtx.QueryParam("active") == "true" {
+		exceptions, err = h.db.ListActiveComplianceExceptions(ctx, controlIDs, time.Now())
+	} else {
+		exceptions, err = h.db.ListComplianceExceptions(ctx, controlIDs, nil)
+	}
+	if err != nil {
+		h.logger.Error("failed to list compliance exceptions", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list compliance exceptions")
+	}
+
+	items := make([]api.ComplianceException, 0, len(exceptions))
+	for _, exception := range exceptions {
+		item := exception.ToApi()
+		if len(statuses) > 0 && !slices.Contains(statuses, item.Status) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return echoCtx.JSON(http.StatusOK, api.ListComplianceExceptionsResponse{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
+
+// GetComplianceException godoc
+//
+//	@Summary		Get a compliance exception
+//	@Description	Get a compliance exception with its full approval trail
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Produce		json
+//	@Param			exception_id	path		string	true	"Exception ID"
+//	@Success		200				{object}	api.ComplianceException
+//	@Router			/compliance/api/v3/exceptions/{exception_id} [get]
+func (h *HttpHandler) GetComplianceException(echoCtx echo.Context) error {
+	exception, err := h.getComplianceExceptionFromParam(echoCtx)
+	if err != nil {
+		return err
+	}
+
+	return echoCtx.JSON(http.StatusOK, exception.ToApi())
+}
+
+// CreateComplianceException godoc
+//
+//	@Summary		Request a compliance exception
+//	@Description	Request an accepted risk or false positive exception for a control, scoped by resource id pattern, integrations and resource tag.
+//	@Description	At least one of them is required so an exception never covers every resource of the control.
+//	@Description	The exception is pending until an admin approves it and stops applying once it expires.
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateComplianceExceptionRequest	true	"Create compliance exception request"
+//	@Success		201		{object}	api.ComplianceException
+//	@Router			/compliance/api/v3/exceptions [post]
+func (h *HttpHandler) CreateComplianceException(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+
+	var req api.CreateComplianceExceptionRequest
+	if err := bindValidate(echoCtx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	switch req.Type {
+	case api.ComplianceExceptionTypeAcceptedRisk, api.ComplianceExceptionTypeFalsePositive:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid exception type")
+	}
+	if err := validateComplianceExceptionScope(req.ResourceIDPattern, req.IntegrationIDs, req.TagKey, req.TagValue, req.ExpiresAt); err != nil {
+		return err
+	}
+
+	control, err := h.db.GetControl(ctx, req.ControlID)
+	if err != nil {
+		h.logger.Error("failed to get control", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get control")
+	}
+	if control == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "control not found")
+	}
+
+	userID := httpserver2.GetUserID(echoCtx)
+	exception := db.ComplianceException{
+		Type:              req.Type,
+		ControlID:         req.ControlID,
+		ResourceIDPattern: req.ResourceIDPattern,
+		IntegrationIDs:    req.IntegrationIDs,
+		TagKey:            req.TagKey,
+		TagValue:          req.TagValue,
+		Reason:            req.Reason,
+		Status:            api.ComplianceExceptionStatusPending,
+		RequestedBy:       userID,
+		ExpiresAt:         req.ExpiresAt,
+		Events: []db.ComplianceExceptionEvent{
+			{
+				Action:  api.ComplianceExceptionActionCreated,
+				Actor:   userID,
+				Comment: req.Reason,
+			},
+		},
+	}
+	if err := h.db.CreateComplianceException(ctx, &exception); err != nil {
+		h.logger.Error("failed to create compliance exception", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create compliance exception")
+	}
+
+	return echoCtx.JSON(http.StatusCreated, exception.ToApi())
+}
+
+// UpdateComplianceException godoc
+//
+//	@Summary		Update a pending compliance exception
+//	@Description	Update the scope, reason or expiry of an exception that has not been reviewed yet
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Accept			json
+//	@Produce		json
+//	@Param			exception_id	path		string									true	"Exception ID"
+//	@Param			request			body		api.UpdateComplianceExceptionRequest	true	"Update compliance exception request"
+//	@Success		200				{object}	api.ComplianceException
+//	@Router			/compliance/api/v3/exceptions/{exception_id} [put]
+func (h *HttpHandler) UpdateComplianceException(echoCtx echo.Context) error {
+	ctx := echoCtx.Request().Context()
+
+	var req api.UpdateComplianceExceptionRequest
+	if err := bindValidate(echoCtx, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	exception, err := h.getComplianceExceptionFromParam(echoCtx)
+	if err != nil {
+		return err
+	}
+	if exception.Status != api.ComplianceExceptionStatusPending {
+		return echo.NewHTTPError(http.StatusConflict, "only pending exceptions can be updated")
+	}
+
+	if req.ResourceIDPattern != nil {
+		exception.ResourceIDPattern = *req.ResourceIDPattern
+	}
+	if req.IntegrationIDs != nil {
+		exception.IntegrationIDs = req.IntegrationIDs
+	}
+	if req.TagKey != nil {
+		exception.TagKey = *req.TagKey
+	}
+	if req.TagValue != nil {
+		exception.TagValue = *req.TagValue
+	}
+	if req.Reason != nil {
+		exception.Reason = *req.Reason
+	}
+	if req.ExpiresAt != nil {
+		exception.ExpiresAt = *req.ExpiresAt
+	}
+	if err := validateComplianceExceptionScope(exception.ResourceIDPattern, exception.IntegrationIDs, exception.TagKey, exception.TagValue, exception.ExpiresAt); err != nil {
+		return err
+	}
+	exception.UpdatedAt = time.Now()
+
+	event := db.ComplianceExceptionEvent{
+		Action:  api.ComplianceExceptionActionUpdated,
+		Actor:   httpserver2.GetUserID(echoCtx),
+		Comment: exception.Reason,
+	}
+	if err := h.db.UpdateComplianceException(ctx, exception, event); err != nil {
+		h.logger.Error("failed to update compliance exception", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update compliance exception")
+	}
+
+	return h.GetComplianceException(echoCtx)
+}
+
+// ApproveComplianceException godoc
+//
+//	@Summary		Approve a compliance exception
+//	@Description	Approve a pending exception, matching alarms are reported as suppressed from the next compliance run.
+//	@Description	An exception can not be approved by the user who requested it.
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Accept			json
+//	@Produce		json
+//	@Param			exception_id	path		string									true	"Exception ID"
+//	@Param			request			body		api.ReviewComplianceExceptionRequest	false	"Review comment"
+//	@Success		200				{object}	api.ComplianceException
+//	@Router			/compliance/api/v3/exceptions/{exception_id}/approve [post]
+func (h *HttpHandler) ApproveComplianceException(echoCtx echo.Context) error {
+	return h.reviewComplianceException(echoCtx, api.ComplianceExceptionActionApproved)
+}
+
+// RejectComplianceException godoc
+//
+//	@Summary		Reject a compliance exception
+//	@Description	Reject a pending exception
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Accept			json
+//	@Produce		json
+//	@Param			exception_id	path		string									true	"Exception ID"
+//	@Param			request			body		api.ReviewComplianceExceptionRequest	false	"Review comment"
+//	@Success		200				{object}	api.ComplianceException
+//	@Router			/compliance/api/v3/exceptions/{exception_id}/reject [post]
+func (h *HttpHandler) RejectComplianceException(echoCtx echo.Context) error {
+	return h.reviewComplianceException(echoCtx, api.ComplianceExceptionActionRejected)
+}
+
+// RevokeComplianceException godoc
+//
+//	@Summary		Revoke a compliance exception
+//	@Description	Revoke an approved exception before it expires, matching results are reported as alarms again from the next compliance run
+//	@Security		BearerToken
+//	@Tags			compliance
+//	@Accept			json
+//	@Produce		json
+//	@Param			exception_id	path		string									true	"Exception ID"
+//	@Param			request			body		api.ReviewComplianceExceptionRequest	false	"Review comment"
+//	@Success		200				{object}	api.ComplianceException
+//	@Router			/compliance/api/v3/exceptions/{exception_id}/revoke [post]
+func (h *HttpHandler) RevokeComplianceException(echoCtx echo.Context) error {
+	return h.reviewComplianceException(echoCtx, api.ComplianceExceptionActionRevoked)
+}
+
+func (h *HttpHandler) reviewComplianceException(echoCtx echo.Context, action api.ComplianceExceptionAction) error {
+	ctx := echoCtx.Request().Context()
+
+	var req api.ReviewComplianceExceptionRequest
+	if echoCtx.Request().ContentLength > 0 {
+		if err := echoCtx.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+		}
+	}
+
+	exception, err := h.getComplianceExceptionFromParam(echoCtx)
+	if err != nil {
+		return err
+	}
+
+	userID := httpserver2.GetUserID(echoCtx)
+	now := time.Now()
+	switch action {
+	case api.ComplianceExceptionActionApproved:
+		if exception.Status != api.ComplianceExceptionStatusPending {
+			return echo.NewHTTPError(http.StatusConflict, "only pending exceptions can be approved")
+		}
+		if exception.RequestedBy == userID {
+			return echo.NewHTTPError(http.StatusForbidden, "an exception can not be approved by its requester")
+		}
+		if !exception.ExpiresAt.After(now) {
+			return echo.NewHTTPError(http.StatusBadRequest, "exception is already expired")
+		}
+		exception.Status = api.ComplianceExceptionStatusApproved
+		exception.ApprovedBy = userID
+		exception.ApprovedAt = &now
+	case api.ComplianceExceptionActionRejected:
+		if exception.Status != api.ComplianceExceptionStatusPending {
+			return echo.NewHTTPError(http.StatusConflict, "only pending exceptions can be rejected")
+		}
+		exception.Status = api.ComplianceExceptionStatusRejected
+	case api.ComplianceExceptionActionRevoked:
+		if !exception.IsActive(now) {
+			return echo.NewHTTPError(http.StatusConflict, "only active exceptions can be revoked")
+		}
+		exception.Status = api.ComplianceExceptionStatusRevoked
+	}
+	exception.UpdatedAt = now
+
+	event := db.ComplianceExceptionEvent{
+		Action:  action,
+		Actor:   userID,
+		Comment: req.Comment,
+	}
+	if err := h.db.UpdateComplianceException(ctx, exception, event); err != nil {
+		h.logger.Error("failed to update compliance exception", zap.Error(err), zap.String("action", string(action)))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update compliance exception")
+	}
+
+	return h.GetComplianceException(echoCtx)
+}
+
+func (h *HttpHandler) getComplianceExceptionFromParam(echoCtx echo.Context) (*db.ComplianceException, error) {
+	exceptionID, err := strconv.ParseUint(echoCtx.Param("exception_id"), 10, 64)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid exception id")
+	}
+
+	exception, err := h.db.GetComplianceException(echoCtx.Request().Context(), uint(exceptionID))
+	if err != nil {
+		h.logger.Error("failed to get compliance exception", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get compliance exception")
+	}
+	if exception == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "compliance exception not found")
+	}
+	return exception, nil
+}
+
+func validateComplianceExceptionScope(resourceIDPattern string, integrationIDs []string, tagKey, tagValue string, expiresAt time.Time) error {
+	if tagKey == "" && tagValue != "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "tag value requires a tag key")
+	}
+	// a pattern of wildcards only matches every resource and does not narrow the exception
+	if strings.Trim(resourceIDPattern, "*") == "" && len(integrationIDs) == 0 && tagKey == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "exception requires a resource id pattern, integrations or a tag key")
+	}
+	if !expiresAt.After(time.Now()) {
+		return echo.NewHTTPError(http.StatusBadRequest, "expiry date must be in the future")
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -150,3 +151,38 @@ func TestHttpServer(t *testing.T) {
 func (s *HttpServerSuite) TestDatabaseTableStructure() {
 	time.Sleep(5 * time.Minute)
 }
+
+func TestValidateComplianceExceptionScope(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour)
+	tests := []struct {
+		name              string
+		resourceIDPattern string
+		integrationIDs    []string
+		tagKey            string
+		tagValue          string
+		expiresAt         time.Time
+		wantErr           bool
+	}{
+		{name: "resource id pattern", resourceIDPattern: "arn:aws:s3:::public-assets-*", expiresAt: expiresAt},
+		{name: "integrations", integrationIDs: []string{"i-1"}, expiresAt: expiresAt},
+		{name: "tag", tagKey: "environment", tagValue: "sandbox", expiresAt: expiresAt},
+		{name: "tag key only", tagKey: "environment", expiresAt: expiresAt},
+		{name: "no scope", expiresAt: expiresAt, wantErr: true},
+		{name: "wildcard pattern", resourceIDPattern: "**", expiresAt: expiresAt, wantErr: true},
+		{name: "empty integrations", integrationIDs: []string{}, expiresAt: expiresAt, wantErr: true},
+		{name: "tag value without key", resourceIDPattern: "arn:*", tagValue: "sandbox", expiresAt: expiresAt, wantErr: true},
+		{name: "expired", resourceIDPattern: "arn:*", expiresAt: time.Now().Add(-time.Hour), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateComplianceExceptionScope(tc.resourceIDPattern, tc.integrationIDs, tc.tagKey, tc.tagValue, tc.expiresAt)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validateComplianceExceptionScope() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			var httpErr *echo.HTTPError
+			if err != nil && (!errors.As(err, &httpErr) || httpErr.Code != http.StatusBadRequest) {
+				t.Errorf("validateComplianceExceptionScope() error = %v, want a bad request", err)
+			}
+		})
+	}
+}
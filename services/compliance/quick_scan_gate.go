@@ -0,0 +1,142 @@
+package compliance
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+	"github.com/opengovern/opensecurity/services/compliance/api"
+	schedulerapi "github.com/opengovern/opensecurity/services/scheduler/api"
+)
+
+const (
+	quickScanVerdictDefaultTimeout = 5 * time.Minute
+	quickScanVerdictMaxTimeout     = 15 * time.Minute
+	quickScanVerdictPollInterval   = 5 * time.Second
+)
+
+type quickScanFailure struct {
+	controlID  string
+	severity   types.ComplianceResultSeverity
+	resourceID string
+	isNew      bool
+}
+
+// failingResultKeys returns the control/integration/resource keys of the failing results of a quick run
+func failingResultKeys(view *types.ComplianceJobReportResourceView) map[string]bool {
+	keys := make(map[string]bool)
+	if view == nil {
+		return keys
+	}
+	for integrationID, integration := range view.Integrations {
+		for _, resourceType := range integration.ResourceTypes {
+			for resourceID, resource := range resourceType.Resources {
+				for status, findings := range resource.Results {
+					if status.IsPassed() {
+						continue
+					}
+					for _, finding := range findings {
+						keys[finding.ControlID+"|"+integrationID+"|"+resourceID] = true
+					}
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// evaluateQuickScanGate fills the counts and violations of the verdict and sets its status.
+// A nil gate fails on any failing result.
+func evaluateQuickScanGate(verdict *api.QuickScanVerdict, gate *schedulerapi.QuickScanGate,
+	view *types.ComplianceJobReportResourceView, baseline *types.ComplianceJobReportResourceView) {
+	verdict.FailuresBySeverity = make(map[types.ComplianceResultSeverity]int)
+	verdict.NewFailuresBySeverity = make(map[types.ComplianceResultSeverity]int)
+	verdict.Violations = []api.QuickScanGateViolation{}
+
+	baselineKeys := failingResultKeys(baseline)
+	var failures []quickScanFailure
+	for integrationID, integration := range view.Integrations {
+		for _, resourceType := range integration.ResourceTypes {
+			for resourceID, resource := range resourceType.Resources {
+				for status, findings := range resource.Results {
+					if status.IsPassed() {
+						continue
+					}
+					for _, finding := range findings {
+						failure := quickScanFailure{
+							controlID:  finding.ControlID,
+							severity:   finding.Severity,
+							resourceID: resourceID,
+							isNew:      !baselineKeys[finding.ControlID+"|"+integrationID+"|"+resourceID],
+						}
+						verdict.FailuresBySeverity[failure.severity]++
+						if failure.isNew {
+							verdict.NewFailuresBySeverity[failure.severity]++
+						}
+						failures = append(failures, failure)
+					}
+				}
+			}
+		}
+	}
+
+	mandatory := make(map[string]bool)
+	if gate != nil {
+		verdict.MaxFailures = gate.MaxFailures
+		for _, controlID := range gate.MandatoryControls {
+			mandatory[controlID] = true
+		}
+	}
+	exceeded := func(severity types.ComplianceResultSeverity) bool {
+		if gate == nil {
+			return true
+		}
+		maxFailures, ok := gate.MaxFailures[severity]
+		return ok && verdict.NewFailuresBySeverity[severity] > maxFailures
+	}
+
+	violations := make(map[string]*api.QuickScanGateViolation)
+	for _, failure := range failures {
+		var reason api.QuickScanGateViolationReason
+		switch {
+		case mandatory[failure.controlID]:
+			reason = api.QuickScanGateViolationMandatory
+		case failure.isNew && exceeded(failure.severity):
+			reason = api.QuickScanGateViolationThreshold
+		default:
+			continue
+		}
+		violation, ok := violations[failure.controlID]
+		if !ok {
+			violation = &api.QuickScanGateViolation{
+				ControlID: failure.controlID,
+				Severity:  failure.severity,
+				Reason:    reason,
+			}
+			violations[failure.controlID] = violation
+		}
+		violation.FailedResourceIDs = append(violation.FailedResourceIDs, failure.resourceID)
+		if failure.isNew {
+			violation.NewResourceIDs = append(violation.NewResourceIDs, failure.resourceID)
+		}
+	}
+	for _, violation := range violations {
+		sort.Strings(violation.FailedResourceIDs)
+		sort.Strings(violation.NewResourceIDs)
+		verdict.Violations = append(verdict.Violations, *violation)
+	}
+	sort.Slice(verdict.Violations, func(i, j int) bool {
+		a, b := verdict.Violations[i], verdict.Violations[j]
+		if a.Severity.Level() != b.Severity.Level() {
+			return a.Severity.Level() > b.Severity.Level()
+		}
+		return a.ControlID < b.ControlID
+	})
+
+	if len(verdict.Violations) > 0 {
+		verdict.Status = api.QuickScanVerdictFailed
+	} else {
+		verdict.Status = api.QuickScanVerdictPassed
+	}
+	verdict.ExitCode = verdict.Status.ExitCode()
+}
@@ -0,0 +1,114 @@
+package compliance
+
+import (
+	"testing"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+	"github.com/opengovern/opensecurity/services/compliance/api"
+	schedulerapi "github.com/opengovern/opensecurity/services/scheduler/api"
+)
+
+type testFinding struct {
+	resourceID string
+	controlID  string
+	severity   types.ComplianceResultSeverity
+	status     types.ComplianceStatus
+}
+
+func testResourceView(findings ...testFinding) *types.ComplianceJobReportResourceView {
+	resources := make(map[string]types.AuditResourceResult)
+	for _, f := range findings {
+		resource, ok := resources[f.resourceID]
+		if !ok {
+			resource = types.AuditResourceResult{Results: make(map[types.ComplianceStatus][]types.AuditControlFinding)}
+		}
+		resource.Results[f.status] = append(resource.Results[f.status], types.AuditControlFinding{ControlID: f.controlID, Severity: f.severity})
+		resources[f.resourceID] = resource
+	}
+	return &types.ComplianceJobReportResourceView{
+		Integrations: map[string]types.AuditIntegrationResult{
+			"i-1": {ResourceTypes: map[string]types.AuditResourceTypesResult{"bucket": {Resources: resources}}},
+		},
+	}
+}
+
+func TestEvaluateQuickScanGate(t *testing.T) {
+	high := types.ComplianceResultSeverityHigh
+	low := types.ComplianceResultSeverityLow
+	view := testResourceView(
+		testFinding{resourceID: "r-1", controlID: "c-high", severity: high, status: types.ComplianceStatusALARM},
+		testFinding{resourceID: "r-2", controlID: "c-high", severity: high, status: types.ComplianceStatusALARM},
+		testFinding{resourceID: "r-1", controlID: "c-low", severity: low, status: types.ComplianceStatusALARM},
+		testFinding{resourceID: "r-3", controlID: "c-ok", severity: high, status: types.ComplianceStatusOK},
+	)
+	baseline := testResourceView(
+		testFinding{resourceID: "r-1", controlID: "c-high", severity: high, status: types.ComplianceStatusALARM},
+		testFinding{resourceID: "r-1", controlID: "c-low", severity: low, status: types.ComplianceStatusALARM},
+	)
+
+	tests := []struct {
+		name           string
+		gate           *schedulerapi.QuickScanGate
+		baseline       *types.ComplianceJobReportResourceView
+		wantStatus     api.QuickScanVerdictStatus
+		wantViolations map[string]api.QuickScanGateViolationReason
+	}{
+		{
+			name:       "no gate fails on any failure",
+			wantStatus: api.QuickScanVerdictFailed,
+			wantViolations: map[string]api.QuickScanGateViolationReason{
+				"c-high": api.QuickScanGateViolationThreshold,
+				"c-low":  api.QuickScanGateViolationThreshold,
+			},
+		},
+		{
+			name:           "within thresholds",
+			gate:           &schedulerapi.QuickScanGate{MaxFailures: map[types.ComplianceResultSeverity]int{high: 2}},
+			wantStatus:     api.QuickScanVerdictPassed,
+			wantViolations: map[string]api.QuickScanGateViolationReason{},
+		},
+		{
+			name:           "threshold exceeded",
+			gate:           &schedulerapi.QuickScanGate{MaxFailures: map[types.ComplianceResultSeverity]int{high: 1}},
+			wantStatus:     api.QuickScanVerdictFailed,
+			wantViolations: map[string]api.QuickScanGateViolationReason{"c-high": api.QuickScanGateViolationThreshold},
+		},
+		{
+			name:           "baseline failures do not count",
+			gate:           &schedulerapi.QuickScanGate{MaxFailures: map[types.ComplianceResultSeverity]int{high: 1, low: 0}},
+			baseline:       baseline,
+			wantStatus:     api.QuickScanVerdictPassed,
+			wantViolations: map[string]api.QuickScanGateViolationReason{},
+		},
+		{
+			name:           "mandatory control fails even in the baseline",
+			gate:           &schedulerapi.QuickScanGate{MandatoryControls: []string{"c-low"}},
+			baseline:       baseline,
+			wantStatus:     api.QuickScanVerdictFailed,
+			wantViolations: map[string]api.QuickScanGateViolationReason{"c-low": api.QuickScanGateViolationMandatory},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var verdict api.QuickScanVerdict
+			evaluateQuickScanGate(&verdict, tc.gate, view, tc.baseline)
+			if verdict.Status != tc.wantStatus {
+				t.Errorf("status = %s, want %s", verdict.Status, tc.wantStatus)
+			}
+			if verdict.ExitCode != tc.wantStatus.ExitCode() {
+				t.Errorf("exit code = %d, want %d", verdict.ExitCode, tc.wantStatus.ExitCode())
+			}
+			if verdict.FailuresBySeverity[high] != 2 || verdict.FailuresBySeverity[low] != 1 {
+				t.Errorf("unexpected failure counts %v", verdict.FailuresBySeverity)
+			}
+			if len(verdict.Violations) != len(tc.wantViolations) {
+				t.Fatalf("violations = %+v, want %v", verdict.Violations, tc.wantViolations)
+			}
+			for _, violation := range verdict.Violations {
+				if reason, ok := tc.wantViolations[violation.ControlID]; !ok || reason != violation.Reason {
+					t.Errorf("unexpected violation %+v", violation)
+				}
+			}
+		})
+	}
+}
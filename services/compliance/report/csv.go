@@ -0,0 +1,34 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{
+	"job_id", "framework_id", "control_id", "control_title", "severity",
+	"integration_id", "resource_type", "resource_id", "resource_name", "compliance_status", "reason",
+}
+
+// WriteCSV writes one row per control and resource result
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	jobID := strconv.FormatUint(uint64(r.JobID), 10)
+	for _, control := range r.Controls {
+		for _, result := range control.Results {
+			err := cw.Write([]string{
+				jobID, r.FrameworkID, control.ID, control.Title, control.Severity.String(),
+				result.IntegrationID, result.ResourceType, result.ResourceID, result.ResourceName, string(result.Status), result.Reason,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
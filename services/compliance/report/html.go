@@ -0,0 +1,76 @@
+package report
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"statusClass": func(status types.ComplianceStatus) string {
+		if status.IsPassed() {
+			return "passed"
+		}
+		return "failed"
+	},
+	"count": func(summary map[types.ComplianceStatus]uint64, status string) uint64 {
+		return summary[types.ComplianceStatus(status)]
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.FrameworkTitle}} - Compliance Report (job {{.JobID}})</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 12px; color: #1f2328; margin: 24px; }
+h1 { font-size: 20px; margin-bottom: 4px; }
+h2 { font-size: 15px; margin: 24px 0 4px; }
+table { border-collapse: collapse; width: 100%; margin: 8px 0; }
+th, td { border: 1px solid #d0d7de; padding: 4px 6px; text-align: left; vertical-align: top; word-break: break-all; }
+th { background: #f6f8fa; }
+.meta { color: #57606a; }
+.passed { color: #1a7f37; }
+.failed { color: #cf222e; font-weight: 600; }
+.severity { text-transform: uppercase; font-size: 10px; }
+.control { page-break-inside: avoid; }
+@media print { body { margin: 0; } h2 { page-break-after: avoid; } }
+</style>
+</head>
+<body>
+<h1>{{.FrameworkTitle}}</h1>
+<div class="meta">Framework {{.FrameworkID}} &middot; Job {{.JobID}} &middot; Started {{.StartedAt.Format "2006-01-02 15:04 MST"}} &middot; Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</div>
+<div class="meta">Integrations: {{range $i, $id := .IntegrationIDs}}{{if $i}}, {{end}}{{$id}}{{end}}</div>
+
+<h2>Summary</h2>
+<table>
+<tr><th>ok</th><th>alarm</th><th>suppressed</th><th>info</th><th>skip</th><th>error</th></tr>
+<tr>
+<td>{{count .Summary "ok"}}</td><td>{{count .Summary "alarm"}}</td><td>{{count .Summary "suppressed"}}</td>
+<td>{{count .Summary "info"}}</td><td>{{count .Summary "skip"}}</td><td>{{count .Summary "error"}}</td>
+</tr>
+</table>
+
+<h2>Controls</h2>
+<table>
+<tr><th>Control</th><th>Title</th><th>Severity</th><th>Result</th></tr>
+{{range .Controls}}<tr><td>{{.ID}}</td><td>{{.Title}}</td><td class="severity">{{.Severity}}</td><td>{{if .Failed}}<span class="failed">failed</span>{{else}}<span class="passed">passed</span>{{end}}</td></tr>
+{{end}}</table>
+
+{{range .Controls}}<div class="control">
+<h2>{{.ID}} &mdash; {{.Title}}</h2>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+<div class="meta"><span class="severity">{{.Severity}}</span>{{if .DocumentURI}} &middot; <a href="{{.DocumentURI}}">documentation</a>{{end}}</div>
+<table>
+<tr><th>Integration</th><th>Resource type</th><th>Resource</th><th>Status</th><th>Reason</th></tr>
+{{range .Results}}<tr><td>{{.IntegrationID}}</td><td>{{.ResourceType}}</td><td>{{if .ResourceName}}{{.ResourceName}}<br>{{end}}{{.ResourceID}}</td><td class="{{statusClass .Status}}">{{.Status}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>
+</div>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes a self-contained html report with print styles so it can be saved as pdf from a browser
+func (r Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes one test suite per control and one test case per resource result,
+// alarms are failures, errors are errors and skipped or suppressed results are skipped
+func (r Report) WriteJUnit(w io.Writer) error {
+	suites := junitTestSuites{
+		Name: fmt.Sprintf("%s (job %d)", r.FrameworkID, r.JobID),
+	}
+	for _, control := range r.Controls {
+		suite := junitTestSuite{
+			Name: control.ID,
+		}
+		if !r.StartedAt.IsZero() {
+			suite.Timestamp = r.StartedAt.UTC().Format("2006-01-02T15:04:05")
+		}
+		for _, result := range control.Results {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%s [%s]", result.ResourceID, result.IntegrationID),
+				ClassName: control.ID,
+			}
+			message := &junitMessage{
+				Message: result.Reason,
+				Type:    string(result.Status),
+				Body:    fmt.Sprintf("%s\nseverity: %s\nresource type: %s", control.Title, control.Severity, result.ResourceType),
+			}
+			switch result.Status {
+			case types.ComplianceStatusALARM:
+				tc.Failure = message
+				suite.Failures++
+			case types.ComplianceStatusERROR:
+				tc.Error = message
+				suite.Errors++
+			case types.ComplianceStatusSKIP, types.ComplianceStatusSUPPRESSED:
+				tc.Skipped = message
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, tc)
+			suite.Tests++
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
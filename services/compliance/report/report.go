@@ -0,0 +1,177 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+type Format string
+
+const (
+	FormatSARIF Format = "sarif"
+	FormatCSV   Format = "csv"
+	FormatJUnit Format = "junit"
+	FormatHTML  Format = "html"
+)
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatSARIF:
+		return "application/sarif+json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJUnit:
+		return "application/xml; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+func (f Format) FileExtension() string {
+	switch f {
+	case FormatSARIF:
+		return "sarif"
+	case FormatCSV:
+		return "csv"
+	case FormatJUnit:
+		return "xml"
+	case FormatHTML:
+		return "html"
+	}
+	return "txt"
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatSARIF, FormatCSV, FormatJUnit, FormatHTML:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("unsupported format %s", s)
+}
+
+type ControlInfo struct {
+	Title       string
+	Description string
+	DocumentURI string
+}
+
+type Result struct {
+	IntegrationID string
+	ResourceType  string
+	ResourceID    string
+	ResourceName  string
+	Status        types.ComplianceStatus
+	Reason        string
+}
+
+type Control struct {
+	ID string
+	ControlInfo
+	Severity types.ComplianceResultSeverity
+	Summary  map[types.ComplianceStatus]uint64
+	Results  []Result
+}
+
+// Failed reports whether any resource of the control is in a failing status
+func (c Control) Failed() bool {
+	for status, count := range c.Summary {
+		if count > 0 && !status.IsPassed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Report is a flattened compliance job report, controls and their results are sorted so exports are reproducible
+type Report struct {
+	JobID          uint
+	FrameworkID    string
+	FrameworkTitle string
+	IntegrationIDs []string
+	StartedAt      time.Time
+	GeneratedAt    time.Time
+	Summary        map[types.ComplianceStatus]uint64
+	Controls       []Control
+}
+
+func NewReport(view types.ComplianceJobReportResourceView, frameworkTitle string, controlInfos map[string]ControlInfo) Report {
+	controls := make(map[string]*Control)
+	for integrationID, integration := range view.Integrations {
+		for resourceType, resourceTypeResult := range integration.ResourceTypes {
+			for resourceID, resource := range resourceTypeResult.Resources {
+				for status, findings := range resource.Results {
+					for _, finding := range findings {
+						control, ok := controls[finding.ControlID]
+						if !ok {
+							control = &Control{
+								ID:          finding.ControlID,
+								ControlInfo: controlInfos[finding.ControlID],
+								Severity:    finding.Severity,
+								Summary:     make(map[types.ComplianceStatus]uint64),
+							}
+							controls[finding.ControlID] = control
+						}
+						control.Summary[status]++
+						control.Results = append(control.Results, Result{
+							IntegrationID: integrationID,
+							ResourceType:  resourceType,
+							ResourceID:    resourceID,
+							ResourceName:  resource.ResourceName,
+							Status:        status,
+							Reason:        finding.Reason,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	r := Report{
+		JobID:          view.JobSummary.JobID,
+		FrameworkID:    view.JobSummary.FrameworkID,
+		FrameworkTitle: frameworkTitle,
+		IntegrationIDs: view.JobSummary.IntegrationIDs,
+		StartedAt:      view.JobSummary.JobStartedAt,
+		GeneratedAt:    time.Now().UTC(),
+		Summary:        view.ComplianceSummary,
+	}
+	for _, control := range controls {
+		sort.Slice(control.Results, func(i, j int) bool {
+			a, b := control.Results[i], control.Results[j]
+			if a.IntegrationID != b.IntegrationID {
+				return a.IntegrationID < b.IntegrationID
+			}
+			if a.ResourceID != b.ResourceID {
+				return a.ResourceID < b.ResourceID
+			}
+			return a.Status < b.Status
+		})
+		if control.Title == "" {
+			control.Title = control.ID
+		}
+		r.Controls = append(r.Controls, *control)
+	}
+	sort.Slice(r.Controls, func(i, j int) bool {
+		return r.Controls[i].ID < r.Controls[j].ID
+	})
+	return r
+}
+
+func (r Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatSARIF:
+		return r.WriteSARIF(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	case FormatJUnit:
+		return r.WriteJUnit(w)
+	case FormatHTML:
+		return r.WriteHTML(w)
+	}
+	return fmt.Errorf("unsupported format %s", format)
+}
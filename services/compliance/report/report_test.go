@@ -0,0 +1,157 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+func testReport() Report {
+	return Report{
+		JobID:          42,
+		FrameworkID:    "aws_cis_v300",
+		FrameworkTitle: "AWS CIS v3.0.0",
+		IntegrationIDs: []string{"i-1"},
+		StartedAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Controls: []Control{
+			{
+				ID:          "aws_cis_v300_1_4",
+				ControlInfo: ControlInfo{Title: "No root access key", Description: "Root must not have access keys"},
+				Severity:    types.ComplianceResultSeverityCritical,
+				Summary:     map[types.ComplianceStatus]uint64{types.ComplianceStatusALARM: 1, types.ComplianceStatusOK: 1},
+				Results: []Result{
+					{IntegrationID: "i-1", ResourceType: "aws::iam::account", ResourceID: "acc-1", Status: types.ComplianceStatusALARM, Reason: "root has an access key"},
+					{IntegrationID: "i-1", ResourceType: "aws::iam::account", ResourceID: "acc-2", Status: types.ComplianceStatusOK},
+				},
+			},
+			{
+				ID:          "aws_cis_v300_2_1",
+				ControlInfo: ControlInfo{Title: "Encrypted buckets"},
+				Severity:    types.ComplianceResultSeverityMedium,
+				Summary:     map[types.ComplianceStatus]uint64{types.ComplianceStatusERROR: 1, types.ComplianceStatusSUPPRESSED: 1, types.ComplianceStatusSKIP: 1},
+				Results: []Result{
+					{IntegrationID: "i-1", ResourceType: "aws::s3::bucket", ResourceID: "b-1", Status: types.ComplianceStatusERROR, Reason: "access denied"},
+					{IntegrationID: "i-1", ResourceType: "aws::s3::bucket", ResourceID: "b-2", Status: types.ComplianceStatusSUPPRESSED},
+					{IntegrationID: "i-1", ResourceType: "aws::s3::bucket", ResourceID: "b-3", Status: types.ComplianceStatusSKIP},
+				},
+			},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "sarif", want: FormatSARIF},
+		{in: "csv", want: FormatCSV},
+		{in: "junit", want: FormatJUnit},
+		{in: "html", want: FormatHTML},
+		{in: "pdf", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseFormat(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseFormat(%q) = %q, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 6 {
+		t.Fatalf("expected a header and 5 rows, got %d rows", len(rows))
+	}
+	want := []string{"42", "aws_cis_v300", "aws_cis_v300_1_4", "No root access key", "critical",
+		"i-1", "aws::iam::account", "acc-1", "", "alarm", "root has an access key"}
+	for i := range want {
+		if rows[1][i] != want[i] {
+			t.Errorf("column %s = %q, want %q", csvHeader[i], rows[1][i], want[i])
+		}
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteSARIF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("expected one rule per control, got %d", len(run.Tool.Driver.Rules))
+	}
+
+	tests := []struct {
+		resourceID string
+		ruleIndex  int
+		level      string
+		suppressed bool
+	}{
+		{resourceID: "acc-1", ruleIndex: 0, level: "error"},
+		{resourceID: "b-1", ruleIndex: 1, level: "warning"},
+		{resourceID: "b-2", ruleIndex: 1, level: "warning", suppressed: true},
+	}
+	if len(run.Results) != len(tests) {
+		t.Fatalf("expected %d results without the passed ones, got %d", len(tests), len(run.Results))
+	}
+	for i, tc := range tests {
+		got := run.Results[i]
+		if got.Locations[0].LogicalLocations[0].Name != tc.resourceID || got.RuleIndex != tc.ruleIndex ||
+			got.Level != tc.level || (len(got.Suppressions) > 0) != tc.suppressed {
+			t.Errorf("result %d = %+v, want %+v", i, got, tc)
+		}
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteJUnit(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                             string
+		tests, failures, errors, skipped int
+	}{
+		{name: "aws_cis_v300_1_4", tests: 2, failures: 1},
+		{name: "aws_cis_v300_2_1", tests: 3, errors: 1, skipped: 2},
+	}
+	if len(suites.Suites) != len(tests) {
+		t.Fatalf("expected %d suites, got %d", len(tests), len(suites.Suites))
+	}
+	for i, tc := range tests {
+		got := suites.Suites[i]
+		if got.Name != tc.name || got.Tests != tc.tests || got.Failures != tc.failures || got.Errors != tc.errors || got.Skipped != tc.skipped {
+			t.Errorf("suite %d = %+v, want %+v", i, got, tc)
+		}
+	}
+	if suites.Tests != 5 || suites.Failures != 1 || suites.Errors != 1 || suites.Skipped != 2 {
+		t.Errorf("unexpected totals %+v", suites)
+	}
+}
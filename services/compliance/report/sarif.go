@@ -0,0 +1,191 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "OpenSecurity"
+	toolURI      = "https://github.com/opengovern/opensecurity"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool              `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	FullDescription      *sarifMessage          `json:"fullDescription,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Suppressions        []sarifSuppress   `json:"suppressions,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppress struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// sarifLevel maps the control severity to the SARIF result level
+func sarifLevel(severity types.ComplianceResultSeverity) string {
+	switch severity {
+	case types.ComplianceResultSeverityCritical, types.ComplianceResultSeverityHigh:
+		return "error"
+	case types.ComplianceResultSeverityMedium:
+		return "warning"
+	}
+	return "note"
+}
+
+// sarifSecuritySeverity is the numeric score code scanning dashboards use to rank the rules
+func sarifSecuritySeverity(severity types.ComplianceResultSeverity) string {
+	switch severity {
+	case types.ComplianceResultSeverityCritical:
+		return "9.5"
+	case types.ComplianceResultSeverityHigh:
+		return "8.0"
+	case types.ComplianceResultSeverityMedium:
+		return "5.5"
+	case types.ComplianceResultSeverityLow:
+		return "2.0"
+	}
+	return "0.0"
+}
+
+// WriteSARIF writes the failing and suppressed results as a SARIF 2.1.0 log, one rule per control
+func (r Report) WriteSARIF(w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+		Properties: map[string]interface{}{
+			"jobId":          r.JobID,
+			"frameworkId":    r.FrameworkID,
+			"frameworkTitle": r.FrameworkTitle,
+			"integrationIds": r.IntegrationIDs,
+			"startedAt":      r.StartedAt,
+		},
+	}
+
+	for ruleIndex, control := range r.Controls {
+		rule := sarifRule{
+			ID:               control.ID,
+			Name:             control.Title,
+			ShortDescription: sarifMessage{Text: control.Title},
+			HelpURI:          control.DocumentURI,
+			DefaultConfiguration: sarifRuleConfiguration{
+				Level: sarifLevel(control.Severity),
+			},
+			Properties: map[string]interface{}{
+				"severity":          control.Severity.String(),
+				"security-severity": sarifSecuritySeverity(control.Severity),
+				"tags":              []string{"compliance", r.FrameworkID},
+			},
+		}
+		if control.Description != "" {
+			rule.FullDescription = &sarifMessage{Text: control.Description}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+
+		for _, result := range control.Results {
+			if result.Status.IsPassed() && result.Status != types.ComplianceStatusSUPPRESSED {
+				continue
+			}
+			message := result.Reason
+			if message == "" {
+				message = fmt.Sprintf("%s is %s for %s", result.ResourceID, result.Status, control.ID)
+			}
+			sr := sarifResult{
+				RuleID:    control.ID,
+				RuleIndex: ruleIndex,
+				Level:     sarifLevel(control.Severity),
+				Message:   sarifMessage{Text: message},
+				Locations: []sarifLocation{
+					{
+						LogicalLocations: []sarifLogicalLocation{
+							{
+								Name:               result.ResourceID,
+								FullyQualifiedName: fmt.Sprintf("%s/%s/%s", result.IntegrationID, result.ResourceType, result.ResourceID),
+								Kind:               "resource",
+							},
+						},
+					},
+				},
+				PartialFingerprints: map[string]string{
+					"opensecurity/v1": fmt.Sprintf("%s:%s:%s", control.ID, result.IntegrationID, result.ResourceID),
+				},
+			}
+			if result.Status == types.ComplianceStatusSUPPRESSED {
+				sr.Suppressions = []sarifSuppress{{Kind: "external", Justification: "compliance exception"}}
+			}
+			run.Results = append(run.Results, sr)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/opengovern/opensecurity/pkg/audit"
 	"github.com/opengovern/opensecurity/pkg/utils"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -46,6 +47,13 @@ import (
 )
 
 func (h HttpHandler) Register(r *echo.Echo) {
+	r.Use(audit.Middleware("core", audit.NewSinkFromEnv(h.logger),
+		"/api/v1/query_parameter",
+		"/api/v1/query/run",
+		"/api/v3/queries",
+		"/api/v3/query/run",
+	))
+
 	v1 := r.Group("/api/v1")
 	// metadata
 	filter := v1.Group("/filter")
@@ -275,6 +283,22 @@ func (h HttpHandler) SetQueryParameter(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "no query parameters provided")
 	}
 
+	keys := make([]string, 0, len(req.QueryParameters))
+	for _, apiParam := range req.QueryParameters {
+		keys = append(keys, apiParam.Key)
+	}
+	existing, err := h.db.GetQueryParametersByIds(keys)
+	if err != nil {
+		h.logger.Error("error getting query parameters", zap.Error(err))
+		return err
+	}
+	before := make(map[string]string)
+	for _, param := range existing {
+		before[param.Key] = param.Value
+	}
+	audit.SetBefore(ctx, before)
+
+	after := make(map[string]string)
 	dbQueryParams := make([]*models.PolicyParameterValues, 0, len(req.QueryParameters))
 	for _, apiParam := range req.QueryParameters {
 		//key, err := models.ParseQueryParameterKey(apiParam.Key)
@@ -284,13 +308,15 @@ func (h HttpHandler) SetQueryParameter(ctx echo.Context) error {
 		dbParam := models.QueryParameterFromAPI(apiParam)
 		dbParam.Key = apiParam.Key
 		dbQueryParams = append(dbQueryParams, &dbParam)
+		after[dbParam.Key] = dbParam.Value
 	}
 
-	err := h.db.SetQueryParameters(dbQueryParams)
+	err = h.db.SetQueryParameters(dbQueryParams)
 	if err != nil {
 		h.logger.Error("error setting query parameters", zap.Error(err))
 		return err
 	}
+	audit.SetAfter(ctx, after)
 
 	return ctx.JSON(http.StatusOK, nil)
 }
@@ -20,6 +20,7 @@ import (
 	"github.com/opengovern/og-util/pkg/httpserver"
 	"github.com/opengovern/og-util/pkg/integration"
 	queryrunner "github.com/opengovern/opensecurity/jobs/query-runner-job"
+	"github.com/opengovern/opensecurity/pkg/rbac"
 	"github.com/opengovern/opensecurity/pkg/types"
 	"github.com/opengovern/opensecurity/services/core/rego_runner"
 	"github.com/turbot/steampipe-plugin-sdk/v5/plugin"
@@ -389,7 +390,7 @@ func (h *HttpHandler) ListQueriesTags(ctx echo.Context) error {
 // RunQuery godoc
 //
 //	@Summary		Run query
-//	@Description	Run provided named query and returns the result.
+//	@Description	Run provided named query and returns the result. Users scoped to integrations by role bindings can not run queries.
 //	@Security		BearerToken
 //	@Tags			named_query
 //	@Accepts		json
@@ -399,6 +400,9 @@ func (h *HttpHandler) ListQueriesTags(ctx echo.Context) error {
 //	@Success		200		{object}	api.RunQueryResponse
 //	@Router			/inventory/api/v1/query/run [post]
 func (h *HttpHandler) RunQuery(ctx echo.Context) error {
+	if err := rejectScopedQuery(ctx); err != nil {
+		return err
+	}
 	var req api.RunQueryRequest
 	if err := bindValidate(ctx, &req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -448,6 +452,15 @@ func (h *HttpHandler) RunQuery(ctx echo.Context) error {
 	return ctx.JSON(200, resp)
 }
 
+// rejectScopedQuery refuses the queries of callers scoped to integrations, the engine runs them against every
+// integration and a result row can not be attributed to one, any column can be aliased in the query
+func rejectScopedQuery(ctx echo.Context) error {
+	if _, scoped := rbac.IntegrationScope(ctx); scoped {
+		return echo.NewHTTPError(http.StatusForbidden, "queries can not be run by users scoped to integrations")
+	}
+	return nil
+}
+
 // GetRecentRanQueries godoc
 //
 //	@Summary		List recently ran queries
@@ -1015,7 +1028,7 @@ func arrayContains(array []string, key string) bool {
 // RunQueryByID godoc
 //
 //	@Summary		Run query by named query or compliance ID
-//	@Description	Run provided named query or compliance and returns the result.
+//	@Description	Run provided named query or compliance and returns the result. Users scoped to integrations by role bindings can not run queries.
 //	@Security		BearerToken
 //	@Tags			named_query
 //	@Accepts		json
@@ -1025,6 +1038,9 @@ func arrayContains(array []string, key string) bool {
 //	@Success		200		{object}	api.RunQueryResponse
 //	@Router			/inventory/api/v3/query/run [post]
 func (h *HttpHandler) RunQueryByID(ctx echo.Context) error {
+	if err := rejectScopedQuery(ctx); err != nil {
+		return err
+	}
 	var req api.RunQueryByIDRequest
 	if err := bindValidate(ctx, &req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -1708,6 +1724,9 @@ func (h *HttpHandler) ListQueriesV2Internal(req api.ListQueryV2Request) (*api.Li
 
 func (h *HttpHandler) RunQueryInternal(ctx echo.Context, req api.RunQueryRequest) (*api.RunQueryResponse, error) {
 	var resp *api.RunQueryResponse
+	if err := rejectScopedQuery(ctx); err != nil {
+		return resp, err
+	}
 
 	if err := bindValidate(ctx, &req); err != nil {
 		return resp, echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpserver"
+	coreApi "github.com/opengovern/opensecurity/services/core/api"
+)
+
+func TestRunQueryRejectsScopedCallers(t *testing.T) {
+	// aliasing an allowed integration id used to pass a filter on the result rows
+	body := `{"query": "select 'allowed-id' as platform_integration_id, * from aws_iam_user"}`
+	newContext := func(scope string) echo.Context {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/query/run", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(httpserver.XPlatformUserRoleHeader, string(api.AdminRole))
+		req.Header.Set(httpserver.XPlatformUserConnectionsScope, scope)
+		return echo.New().NewContext(req, httptest.NewRecorder())
+	}
+
+	h := &HttpHandler{}
+	tests := []struct {
+		name string
+		run  func(echo.Context) error
+	}{
+		{name: "run query", run: h.RunQuery},
+		{name: "run query by id", run: h.RunQueryByID},
+		{name: "run query internal", run: func(c echo.Context) error {
+			query := "select 'allowed-id' as platform_integration_id, * from aws_iam_user"
+			_, err := h.RunQueryInternal(c, coreApi.RunQueryRequest{Query: &query})
+			return err
+		}},
+	}
+	for _, tc := range tests {
+		for _, scope := range []string{"allowed-id", "-"} {
+			t.Run(tc.name+" "+scope, func(t *testing.T) {
+				err := tc.run(newContext(scope))
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) || httpErr.Code != http.StatusForbidden {
+					t.Errorf("error = %v, want %d", err, http.StatusForbidden)
+				}
+			})
+		}
+	}
+}
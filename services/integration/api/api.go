@@ -6,13 +6,17 @@ import (
 	"github.com/opengovern/og-util/pkg/steampipe"
 	"github.com/opengovern/og-util/pkg/ticker"
 	"github.com/opengovern/og-util/pkg/vault"
+	"github.com/opengovern/opensecurity/pkg/audit"
 	"github.com/opengovern/opensecurity/pkg/utils"
+	complianceClient "github.com/opengovern/opensecurity/services/compliance/client"
 	coreClient "github.com/opengovern/opensecurity/services/core/client"
 	"github.com/opengovern/opensecurity/services/integration/api/credentials"
 	integration_type2 "github.com/opengovern/opensecurity/services/integration/api/integration-types"
 	"github.com/opengovern/opensecurity/services/integration/api/integrations"
 	"github.com/opengovern/opensecurity/services/integration/db"
 	integration_type "github.com/opengovern/opensecurity/services/integration/integration-type"
+	"github.com/opengovern/opensecurity/services/integration/secrets"
+	schedulerClient "github.com/opengovern/opensecurity/services/scheduler/client"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -29,37 +33,52 @@ type API struct {
 	masterAccessKey string
 	masterSecretKey string
 	vault           vault.VaultSourceConfig
+	secrets         *secrets.Store
 
 	steampipeOption *steampipe.Option
 
-	coreClient coreClient.CoreServiceClient
+	coreClient       coreClient.CoreServiceClient
+	schedulerClient  schedulerClient.SchedulerServiceClient
+	complianceClient complianceClient.ComplianceServiceClient
 }
 
 func New(
 	logger *zap.Logger,
 	db db.Database,
 	vault vault.VaultSourceConfig,
+	secrets *secrets.Store,
 	steampipeOption *steampipe.Option,
 	kubeClient client.Client,
 	typeManager *integration_type.IntegrationTypeManager,
 	elastic opengovernance.Client,
 	coreClient coreClient.CoreServiceClient,
+	schedulerClient schedulerClient.SchedulerServiceClient,
+	complianceClient complianceClient.ComplianceServiceClient,
 ) *API {
 	return &API{
-		logger:          logger.Named("api"),
-		database:        db,
-		vault:           vault,
-		steampipeOption: steampipeOption,
-		kubeClient:      kubeClient,
-		typeManager:     typeManager,
-		elastic:         elastic,
-		coreClient:      coreClient,
+		logger:           logger.Named("api"),
+		database:         db,
+		vault:            vault,
+		secrets:          secrets,
+		steampipeOption:  steampipeOption,
+		kubeClient:       kubeClient,
+		typeManager:      typeManager,
+		elastic:          elastic,
+		coreClient:       coreClient,
+		schedulerClient:  schedulerClient,
+		complianceClient: complianceClient,
 	}
 }
 
 func (api *API) Register(e *echo.Echo) {
-	integrationsApi := integrations.New(api.vault, api.database, api.logger, api.steampipeOption, api.kubeClient, api.typeManager)
-	cred := credentials.New(api.vault, api.database, api.logger)
+	e.Use(audit.Middleware("integration", audit.NewSinkFromEnv(api.logger),
+		"/api/v1/integrations/list",
+		"/api/v1/credentials/list",
+		"/api/v1/integration-types/:integration_type/resource-type/label",
+	))
+
+	integrationsApi := integrations.New(api.vault, api.secrets, api.database, api.logger, api.steampipeOption, api.kubeClient, api.typeManager, api.schedulerClient, api.complianceClient)
+	cred := credentials.New(api.vault, api.secrets, api.database, api.logger, api.typeManager, api.schedulerClient)
 	integrationType := integration_type2.New(api.typeManager, api.database, api.logger, api.elastic, api.coreClient)
 
 	integrationsApi.Register(e.Group("/api/v1/integrations"))
@@ -69,6 +88,15 @@ func (api *API) Register(e *echo.Echo) {
 	utils.EnsureRunGoroutine(func() {
 		api.CheckPluginInstallTimeout(context.Background())
 	})
+	utils.EnsureRunGoroutine(func() {
+		cred.RunRotationVerifier(context.Background())
+	})
+	utils.EnsureRunGoroutine(func() {
+		integrationsApi.RunIntegrationGroupSync(context.Background())
+	})
+	utils.EnsureRunGoroutine(func() {
+		integrationsApi.RunAutoOnboarding(context.Background())
+	})
 }
 
 func (api *API) CheckPluginInstallTimeout(ctx context.Context) {
@@ -7,6 +7,9 @@ import (
 	"github.com/opengovern/og-util/pkg/vault"
 	"github.com/opengovern/opensecurity/services/integration/api/models"
 	"github.com/opengovern/opensecurity/services/integration/db"
+	integration_type "github.com/opengovern/opensecurity/services/integration/integration-type"
+	"github.com/opengovern/opensecurity/services/integration/secrets"
+	schedulerClient "github.com/opengovern/opensecurity/services/scheduler/client"
 	"go.uber.org/zap"
 	ioutil "io/ioutil"
 	"net/http"
@@ -14,29 +17,44 @@ import (
 )
 
 type API struct {
-	vault    vault.VaultSourceConfig
-	logger   *zap.Logger
-	database db.Database
+	vault           vault.VaultSourceConfig
+	secrets         *secrets.Store
+	logger          *zap.Logger
+	database        db.Database
+	typesManager    *integration_type.IntegrationTypeManager
+	schedulerClient schedulerClient.SchedulerServiceClient
 }
 
 func New(
 	vault vault.VaultSourceConfig,
+	secrets *secrets.Store,
 	database db.Database,
 	logger *zap.Logger,
+	typesManager *integration_type.IntegrationTypeManager,
+	schedulerClient schedulerClient.SchedulerServiceClient,
 ) API {
 	return API{
-		vault:    vault,
-		database: database,
-		logger:   logger.Named("credentials"),
+		vault:           vault,
+		secrets:         secrets,
+		database:        database,
+		logger:          logger.Named("credentials"),
+		typesManager:    typesManager,
+		schedulerClient: schedulerClient,
 	}
 }
 
 func (h API) Register(g *echo.Group) {
 	g.GET("", httpserver.AuthorizeHandler(h.List, api.ViewerRole))
 	g.POST("/list", httpserver.AuthorizeHandler(h.CredentialsFilteredList, api.ViewerRole))
+	g.GET("/expiring", httpserver.AuthorizeHandler(h.ListExpiringCredentials, api.ViewerRole))
 	g.DELETE("/:credentialId", httpserver.AuthorizeHandler(h.Delete, api.EditorRole))
 	g.GET("/:credentialId", httpserver.AuthorizeHandler(h.Get, api.ViewerRole))
 	g.PUT("/:credentialId", httpserver.AuthorizeHandler(h.UpdateCredential, api.ViewerRole))
+	g.POST("/:credentialId/rotate", httpserver.AuthorizeHandler(h.RotateCredential, api.EditorRole))
+	g.GET("/:credentialId/onboarding-policy", httpserver.AuthorizeHandler(h.GetOnboardingPolicy, api.ViewerRole))
+	g.PUT("/:credentialId/onboarding-policy", httpserver.AuthorizeHandler(h.SetOnboardingPolicy, api.EditorRole))
+	g.DELETE("/:credentialId/onboarding-policy", httpserver.AuthorizeHandler(h.DeleteOnboardingPolicy, api.EditorRole))
+	g.GET("/:credentialId/onboarding-policy/pending", httpserver.AuthorizeHandler(h.ListPendingIntegrations, api.ViewerRole))
 }
 
 // Delete godoc
@@ -57,6 +75,10 @@ func (h API) Delete(c echo.Context) error {
 		h.logger.Error("failed to delete credential", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete credential")
 	}
+	if err := h.database.DeleteCredentialOnboardingPolicy(credentialId); err != nil {
+		h.logger.Error("failed to delete onboarding policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete onboarding policy")
+	}
 
 	return c.NoContent(http.StatusOK)
 }
@@ -109,37 +131,9 @@ func (h API) UpdateCredential(c echo.Context) error {
 
 	var req models.UpdateCredentialRequest
 
-	contentType := c.Request().Header.Get("Content-Type")
-	if strings.HasPrefix(contentType, "multipart/form-data") {
-		err := c.Request().ParseMultipartForm(10 << 20) // 10 MB max memory
-		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse multipart form")
-		}
-
-		formData := make(map[string]any)
-
-		for key, values := range c.Request().MultipartForm.Value {
-			if len(values) > 0 {
-				formData[key] = values[0]
-			}
-		}
-
-		for key, fileHeaders := range c.Request().MultipartForm.File {
-			if len(fileHeaders) > 0 {
-				file, err := fileHeaders[0].Open()
-				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to open uploaded file")
-				}
-				defer file.Close()
-
-				content, err := ioutil.ReadAll(file)
-				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
-				}
-
-				formData[key] = string(content)
-			}
-		}
+	if formData, ok, err := multipartCredentials(c); err != nil {
+		return err
+	} else if ok {
 		req.Credentials = formData
 	} else {
 		if err := c.Bind(&req); err != nil {
@@ -152,6 +146,9 @@ func (h API) UpdateCredential(c echo.Context) error {
 		h.logger.Error("failed to get credential", zap.Error(err))
 		return echo.NewHTTPError(http.StatusNotFound, "credential not found")
 	}
+	if ref, err := credential.GetSecretReference(); err != nil || ref != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "secret of the credential is kept in an external backend, update it there")
+	}
 
 	mapData, err := h.vault.Decrypt(c.Request().Context(), credential.Secret)
 	if err != nil {
@@ -166,27 +163,18 @@ func (h API) UpdateCredential(c echo.Context) error {
 		h.logger.Error("failed to encrypt secret", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to encrypt config")
 	}
-	masked := make(map[string]any)
-		for key, value := range req.Credentials {
-		strValue, ok := value.(string) // Ensure the value is a string
-		if !ok {
-			// If it's not a string, just skip masking
-			masked[key] = "not available"
-			continue
-		}
-
-		// Get the last 5 characters, or the full string if it's shorter
-		if len(strValue) > 5 {
-			masked[key] = "*****" + strValue[len(strValue)-5:]
-		} else {
-			masked[key] = "*****" + strValue
-		}
-			}
+	masked := maskSecret(req.Credentials)
 	err = h.database.UpdateCredential(credentialId, secret,masked,req.Description)
 	if err != nil {
 		h.logger.Error("failed to update credential", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update credential")
 	}
+	h.updateValidity(credential, mapData, req.ExpiresAt)
+	err = h.database.UpdateCredentialValidity(credentialId, credential.IssuedAt, credential.ExpiresAt, credential.ExpirySource)
+	if err != nil {
+		h.logger.Error("failed to update credential validity", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update credential")
+	}
 
 	return c.NoContent(http.StatusOK)
 }
@@ -254,5 +242,50 @@ func (h API) Get(c echo.Context) error {
 		h.logger.Error("failed to convert credentials to API model", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert integration to API model")
 	}
+	if item.SecretReference != nil {
+		// describers get the referenced secret encrypted for the job, it is not stored
+		item.Secret, err = h.secrets.Ciphertext(c.Request().Context(), credential)
+		if err != nil {
+			h.logger.Error("failed to resolve referenced secret", zap.Error(err), zap.String("credentialId", credentialId))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to resolve referenced secret")
+		}
+	}
 	return c.JSON(http.StatusOK, item)
 }
+
+// multipartCredentials reads the credential values of a multipart form, uploaded files are read as string values
+func multipartCredentials(c echo.Context) (map[string]any, bool, error) {
+	contentType := c.Request().Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "multipart/form-data") {
+		return nil, false, nil
+	}
+	err := c.Request().ParseMultipartForm(10 << 20) // 10 MB max memory
+	if err != nil {
+		return nil, false, echo.NewHTTPError(http.StatusBadRequest, "Failed to parse multipart form")
+	}
+
+	formData := make(map[string]any)
+
+	for key, values := range c.Request().MultipartForm.Value {
+		if len(values) > 0 {
+			formData[key] = values[0]
+		}
+	}
+
+	for key, fileHeaders := range c.Request().MultipartForm.File {
+		if len(fileHeaders) > 0 {
+			file, err := fileHeaders[0].Open()
+			if err != nil {
+				return nil, false, echo.NewHTTPError(http.StatusInternalServerError, "Failed to open uploaded file")
+			}
+			content, err := ioutil.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return nil, false, echo.NewHTTPError(http.StatusInternalServerError, "Failed to read uploaded file")
+			}
+
+			formData[key] = string(content)
+		}
+	}
+	return formData, true, nil
+}
@@ -0,0 +1,169 @@
+package credentials
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/jackc/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	"github.com/opengovern/opensecurity/services/integration/entities"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const minOnboardingIntervalMinutes = 15
+
+// GetOnboardingPolicy godoc
+//
+//	@Summary		Get credential onboarding policy
+//	@Description	Get the policy the integrations newly reachable by the credential are onboarded with
+//	@Security		BearerToken
+//	@Tags			credentials
+//	@Produce		json
+//	@Param			credentialId	path		string	true	"credentialId"
+//	@Success		200				{object}	models.CredentialOnboardingPolicy
+//	@Router			/integration/api/v1/credentials/{credentialId}/onboarding-policy [get]
+func (h API) GetOnboardingPolicy(c echo.Context) error {
+	policy, err := h.database.GetCredentialOnboardingPolicy(c.Param("credentialId"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "credential has no onboarding policy")
+	} else if err != nil {
+		h.logger.Error("failed to get onboarding policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get onboarding policy")
+	}
+
+	item, err := policy.ToApi()
+	if err != nil {
+		h.logger.Error("failed to convert onboarding policy to API model", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert onboarding policy to API model")
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// SetOnboardingPolicy godoc
+//
+//	@Summary		Set credential onboarding policy
+//	@Description	Set the policy the integrations newly reachable by the credential are onboarded with, the credential is then rediscovered periodically
+//	@Security		BearerToken
+//	@Tags			credentials
+//	@Produce		json
+//	@Param			credentialId	path		string								true	"credentialId"
+//	@Param			request			body		models.CredentialOnboardingPolicy	true	"Request"
+//	@Success		200				{object}	models.CredentialOnboardingPolicy
+//	@Router			/integration/api/v1/credentials/{credentialId}/onboarding-policy [put]
+func (h API) SetOnboardingPolicy(c echo.Context) error {
+	req := models.CredentialOnboardingPolicy{
+		IntervalMinutes: 60,
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	credential, err := h.database.GetCredential(c.Param("credentialId"))
+	if err != nil {
+		h.logger.Error("failed to get credential", zap.Error(err))
+		return echo.NewHTTPError(http.StatusNotFound, "credential not found")
+	}
+
+	policy := models2.CredentialOnboardingPolicy{
+		CredentialID:    credential.ID,
+		Mode:            models2.CredentialOnboardingMode(req.Mode),
+		ArchiveMissing:  req.ArchiveMissing,
+		IntervalMinutes: req.IntervalMinutes,
+	}
+	switch policy.Mode {
+	case models2.CredentialOnboardingModeAll, models2.CredentialOnboardingModeNotify:
+	case models2.CredentialOnboardingModeMatching:
+		if req.NamePattern == "" && req.LabelSelector == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "matching mode requires a name pattern or a label selector")
+		}
+		if _, err := regexp.Compile(req.NamePattern); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid name pattern")
+		}
+		policy.NamePattern = req.NamePattern
+		if req.LabelSelector != nil {
+			if err := entities.ValidateSelector(*req.LabelSelector); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			selectorJsonb := pgtype.JSONB{}
+			if err := selectorJsonb.Set(req.LabelSelector); err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to set label selector")
+			}
+			policy.LabelSelector = selectorJsonb
+		}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "mode must be one of all, matching, notify")
+	}
+	if policy.IntervalMinutes < minOnboardingIntervalMinutes {
+		return echo.NewHTTPError(http.StatusBadRequest, "interval_minutes must be at least 15")
+	}
+	if policy.LabelSelector.Status != pgtype.Present {
+		policy.LabelSelector = pgtype.JSONB{Status: pgtype.Null}
+	}
+
+	if err := h.database.UpsertCredentialOnboardingPolicy(&policy); err != nil {
+		h.logger.Error("failed to set onboarding policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to set onboarding policy")
+	}
+
+	stored, err := h.database.GetCredentialOnboardingPolicy(credential.ID.String())
+	if err != nil {
+		h.logger.Error("failed to get onboarding policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get onboarding policy")
+	}
+	item, err := stored.ToApi()
+	if err != nil {
+		h.logger.Error("failed to convert onboarding policy to API model", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert onboarding policy to API model")
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// DeleteOnboardingPolicy godoc
+//
+//	@Summary		Delete credential onboarding policy
+//	@Description	Stop rediscovering the integrations of the credential, integrations are then only added manually
+//	@Security		BearerToken
+//	@Tags			credentials
+//	@Produce		json
+//	@Param			credentialId	path	string	true	"credentialId"
+//	@Success		200
+//	@Router			/integration/api/v1/credentials/{credentialId}/onboarding-policy [delete]
+func (h API) DeleteOnboardingPolicy(c echo.Context) error {
+	if err := h.database.DeleteCredentialOnboardingPolicy(c.Param("credentialId")); err != nil {
+		h.logger.Error("failed to delete onboarding policy", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete onboarding policy")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ListPendingIntegrations godoc
+//
+//	@Summary		List pending integrations
+//	@Description	List the integrations discovered by the credential that the onboarding policy did not add, they can be added through the add integrations API
+//	@Security		BearerToken
+//	@Tags			credentials
+//	@Produce		json
+//	@Param			credentialId	path		string	true	"credentialId"
+//	@Success		200				{object}	models.ListPendingIntegrationsResponse
+//	@Router			/integration/api/v1/credentials/{credentialId}/onboarding-policy/pending [get]
+func (h API) ListPendingIntegrations(c echo.Context) error {
+	pending, err := h.database.ListPendingIntegrations(c.Param("credentialId"))
+	if err != nil {
+		h.logger.Error("failed to list pending integrations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list pending integrations")
+	}
+
+	items := make([]models.PendingIntegration, 0, len(pending))
+	for _, p := range pending {
+		items = append(items, p.ToApi())
+	}
+	return c.JSON(http.StatusOK, models.ListPendingIntegrationsResponse{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
@@ -0,0 +1,383 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/og-util/pkg/httpserver"
+	"github.com/opengovern/og-util/pkg/integration"
+	"github.com/opengovern/og-util/pkg/ticker"
+	"github.com/opengovern/opensecurity/pkg/rbac"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	schedulerApi "github.com/opengovern/opensecurity/services/scheduler/api"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultExpiringWithinDays = 30
+	// rotationVerifyTimeout is how long discovery of a rotated credential is awaited before it is accepted as is
+	rotationVerifyTimeout = 6 * time.Hour
+)
+
+// maskSecret masks the values of the secret, keeping the last 5 characters of string values
+func maskSecret(values map[string]any) map[string]any {
+	masked := make(map[string]any)
+	for key, value := range values {
+		strValue, ok := value.(string)
+		if !ok {
+			masked[key] = "not available"
+			continue
+		}
+		if len(strValue) > 5 {
+			masked[key] = "*****" + strValue[len(strValue)-5:]
+		} else {
+			masked[key] = "*****" + strValue
+		}
+	}
+	return masked
+}
+
+// updateValidity sets the validity of the credential to expiresAt when given, otherwise from its secret,
+// keeping a manually set expiry
+func (h API) updateValidity(credential *models2.Credential, secret map[string]any, expiresAt *time.Time) {
+	if expiresAt != nil {
+		credential.ExpiresAt, credential.ExpirySource = expiresAt, models2.CredentialExpirySourceManual
+		return
+	}
+	if credential.ExpirySource == models2.CredentialExpirySourceManual {
+		return
+	}
+	credential.IssuedAt, credential.ExpiresAt, credential.ExpirySource = models2.CredentialValidity(secret)
+}
+
+// checkCredentialSecret validates the secret with the plugin: the healthcheck of every integration of the credential
+// must pass, a credential without integrations must discover at least one
+func (h API) checkCredentialSecret(credential *models2.Credential, integrations []models2.Integration, secret map[string]any) error {
+	integrationType, ok := h.typesManager.GetIntegrationTypeMap()[credential.IntegrationType]
+	if !ok || integrationType == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid integration type")
+	}
+	jsonData, err := json.Marshal(secret)
+	if err != nil {
+		h.logger.Error("failed to marshal json data", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal json data")
+	}
+
+	if len(integrations) == 0 {
+		discovered, err := integrationType.DiscoverIntegrations(jsonData)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("new credential failed validation: %v", err))
+		}
+		if len(discovered) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "new credential failed validation: no integration discovered")
+		}
+		return nil
+	}
+
+	for _, integ := range integrations {
+		if integ.State == integration.IntegrationStateArchived {
+			continue
+		}
+		integrationApi, err := integ.ToApi()
+		if err != nil {
+			h.logger.Error("failed to create integration api", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to create integration api")
+		}
+		healthy, err := integrationType.HealthCheck(jsonData, integrationApi.ProviderID, integrationApi.Labels, integrationApi.Annotations)
+		if err != nil || !healthy {
+			reason := "unhealthy"
+			if err != nil {
+				reason = err.Error()
+			}
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("new credential failed healthcheck of integration %s: %s", integ.ProviderID, reason))
+		}
+	}
+	return nil
+}
+
+// RotateCredential godoc
+//
+//	@Summary		Rotate credential
+//	@Description	Replace the secret of a credential after it passes the healthcheck of its integrations.
+//	@Description	Discovery is then run with the new secret and the previous secret is restored if it fails.
+//	@Security		BearerToken
+//	@Tags			credentials
+//	@Produce		json
+//	@Param			credentialId	path		string							true	"credentialId"
+//	@Param			request			body		models.RotateCredentialRequest	true	"Request"
+//	@Success		200				{object}	models.Credential
+//	@Router			/integration/api/v1/credentials/{credentialId}/rotate [post]
+func (h API) RotateCredential(c echo.Context) error {
+	credentialId := c.Param("credentialId")
+
+	var req models.RotateCredentialRequest
+	if formData, ok, err := multipartCredentials(c); err != nil {
+		return err
+	} else if ok {
+		req.Description = c.FormValue("description")
+		delete(formData, "description")
+		req.Credentials = formData
+	} else if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+	if len(req.Credentials) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "credentials are required")
+	}
+
+	credential, err := h.database.GetCredential(credentialId)
+	if err != nil || credential == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "credential not found")
+	}
+	if ref, err := credential.GetSecretReference(); err != nil || ref != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "secret of the credential is kept in an external backend, rotate it there")
+	}
+	if credential.RotationState == models2.CredentialRotationStateVerifying {
+		return echo.NewHTTPError(http.StatusConflict, "previous rotation of the credential is being verified")
+	}
+
+	integrations, err := h.database.ListIntegrationsByCredential(credentialId)
+	if err != nil {
+		h.logger.Error("failed to list credential integrations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list credential integrations")
+	}
+	for _, integ := range integrations {
+		if err := rbac.CheckIntegrationAccess(c, integ.IntegrationID.String()); err != nil {
+			return err
+		}
+	}
+
+	mapData, err := h.vault.Decrypt(c.Request().Context(), credential.Secret)
+	if err != nil {
+		h.logger.Error("failed to decrypt secret", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to decrypt config")
+	}
+	for k, v := range req.Credentials {
+		mapData[k] = v
+	}
+	if err := h.checkCredentialSecret(credential, integrations, mapData); err != nil {
+		return err
+	}
+
+	secret, err := h.vault.Encrypt(c.Request().Context(), mapData)
+	if err != nil {
+		h.logger.Error("failed to encrypt secret", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to encrypt config")
+	}
+	masked := make(map[string]any)
+	if credential.MaskedSecret.Status == pgtype.Present {
+		if err := json.Unmarshal(credential.MaskedSecret.Bytes, &masked); err != nil {
+			h.logger.Warn("failed to unmarshal masked secret", zap.Error(err))
+		}
+	}
+	for k, v := range maskSecret(req.Credentials) {
+		masked[k] = v
+	}
+	maskedJsonData, err := json.Marshal(masked)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal masked secret")
+	}
+
+	now := time.Now()
+	credential.PreviousSecret = credential.Secret
+	credential.PreviousMaskedSecret = credential.MaskedSecret
+	credential.Secret = secret
+	credential.MaskedSecret = pgtype.JSONB{}
+	if err := credential.MaskedSecret.Set(maskedJsonData); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal masked secret")
+	}
+	if req.Description != "" {
+		credential.Description = req.Description
+	}
+	if req.ExpiresAt != nil {
+		credential.IssuedAt, credential.ExpiresAt, credential.ExpirySource = &now, req.ExpiresAt, models2.CredentialExpirySourceManual
+	} else {
+		credential.IssuedAt, credential.ExpiresAt, credential.ExpirySource = models2.CredentialValidity(mapData)
+	}
+	credential.RotatedAt = &now
+	credential.RotationMessage = ""
+	credential.RotationTriggerID = ""
+	credential.RotationState = models2.CredentialRotationStateVerified
+
+	if h.schedulerClient != nil && len(integrations) > 0 {
+		var integrationInfo []schedulerApi.IntegrationInfoFilter
+		for _, integ := range integrations {
+			if integ.State != integration.IntegrationStateActive {
+				continue
+			}
+			integrationID := integ.IntegrationID.String()
+			integrationInfo = append(integrationInfo, schedulerApi.IntegrationInfoFilter{IntegrationID: &integrationID})
+		}
+		if len(integrationInfo) > 0 {
+			// the secret is swapped before discovery runs so the jobs use it
+			credential.RotationState = models2.CredentialRotationStateVerifying
+			if err := h.database.RotateCredential(credential); err != nil {
+				h.logger.Error("failed to rotate credential", zap.Error(err))
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to rotate credential")
+			}
+			resp, err := h.schedulerClient.RunDiscovery(&httpclient.Context{UserRole: api.AdminRole}, httpserver.GetUserID(c),
+				schedulerApi.RunDiscoveryRequest{IntegrationInfo: integrationInfo})
+			if err != nil {
+				h.logger.Error("failed to run discovery of rotated credential", zap.Error(err), zap.String("credentialId", credentialId))
+				h.rollbackRotation(c.Request().Context(), credential, fmt.Sprintf("failed to run discovery: %v", err))
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to run discovery with the new credential, rotation is rolled back")
+			}
+			credential.RotationTriggerID = strconv.FormatUint(uint64(resp.TriggerID), 10)
+		}
+	}
+	if credential.RotationState == models2.CredentialRotationStateVerified {
+		credential.PreviousSecret = ""
+		credential.PreviousMaskedSecret = pgtype.JSONB{Status: pgtype.Null}
+	}
+	if err := h.database.RotateCredential(credential); err != nil {
+		h.logger.Error("failed to rotate credential", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to rotate credential")
+	}
+
+	item, err := credential.ToApi(false)
+	if err != nil {
+		h.logger.Error("failed to convert credentials to API model", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert credentials to API model")
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+// rollbackRotation restores the previous secret of a rotated credential
+func (h API) rollbackRotation(ctx context.Context, credential *models2.Credential, reason string) {
+	credential.Secret = credential.PreviousSecret
+	credential.MaskedSecret = credential.PreviousMaskedSecret
+	credential.PreviousSecret = ""
+	credential.PreviousMaskedSecret = pgtype.JSONB{Status: pgtype.Null}
+	credential.RotationState = models2.CredentialRotationStateRolledBack
+	credential.RotationMessage = reason
+	if secret, err := h.vault.Decrypt(ctx, credential.Secret); err == nil {
+		credential.ExpirySource = ""
+		h.updateValidity(credential, secret, nil)
+	} else {
+		h.logger.Warn("failed to decrypt previous secret", zap.Error(err), zap.String("credentialId", credential.ID.String()))
+	}
+
+	if err := h.database.RotateCredential(credential); err != nil {
+		h.logger.Error("failed to roll back credential rotation", zap.Error(err), zap.String("credentialId", credential.ID.String()))
+		return
+	}
+	h.logger.Warn("credential rotation rolled back", zap.String("credentialId", credential.ID.String()), zap.String("reason", reason))
+}
+
+// completeRotation drops the previous secret of a verified rotation
+func (h API) completeRotation(credential *models2.Credential, message string) {
+	credential.PreviousSecret = ""
+	credential.PreviousMaskedSecret = pgtype.JSONB{Status: pgtype.Null}
+	credential.RotationState = models2.CredentialRotationStateVerified
+	credential.RotationMessage = message
+	if err := h.database.RotateCredential(credential); err != nil {
+		h.logger.Error("failed to complete credential rotation", zap.Error(err), zap.String("credentialId", credential.ID.String()))
+	}
+}
+
+// verifyRotation checks the discovery run with the new secret of the credential, rolling back when all its jobs failed
+func (h API) verifyRotation(ctx context.Context, credential *models2.Credential) {
+	if credential.RotationTriggerID == "" {
+		h.completeRotation(credential, "")
+		return
+	}
+
+	progress, err := h.schedulerClient.GetIntegrationDiscoveryProgress(&httpclient.Context{Ctx: ctx, UserRole: api.AdminRole},
+		schedulerApi.GetIntegrationDiscoveryProgressRequest{TriggerID: credential.RotationTriggerID})
+	if err != nil {
+		h.logger.Warn("failed to get discovery progress of rotated credential", zap.Error(err), zap.String("credentialId", credential.ID.String()))
+		return
+	}
+	summary, breakdown := progress.TriggerIdProgressSummary, progress.TriggerIdProgressBreakdown
+	if summary == nil || breakdown == nil || summary.TotalCount == 0 {
+		h.completeRotation(credential, "")
+		return
+	}
+	if summary.ProcessedCount < summary.TotalCount {
+		if credential.RotatedAt != nil && time.Since(*credential.RotatedAt) > rotationVerifyTimeout {
+			h.completeRotation(credential, "discovery with the new secret did not finish in time, rotation is kept")
+		}
+		return
+	}
+	if breakdown.SucceededCount == 0 && breakdown.FailedCount+breakdown.TimeoutCount > 0 {
+		h.rollbackRotation(ctx, credential, fmt.Sprintf("discovery with the new secret failed: %d failed, %d timed out",
+			breakdown.FailedCount, breakdown.TimeoutCount))
+		return
+	}
+	h.completeRotation(credential, "")
+}
+
+// RunRotationVerifier periodically verifies the discovery of rotated credentials
+func (h API) RunRotationVerifier(ctx context.Context) {
+	if h.schedulerClient == nil {
+		return
+	}
+	t := ticker.NewTicker(time.Minute, time.Second*10)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			credentials, err := h.database.ListCredentialsByRotationState(models2.CredentialRotationStateVerifying)
+			if err != nil {
+				h.logger.Warn("failed to list rotating credentials", zap.Error(err))
+				continue
+			}
+			for i := range credentials {
+				h.verifyRotation(ctx, &credentials[i])
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ListExpiringCredentials godoc
+//
+//	@Summary		List expiring credentials
+//	@Description	List credentials expiring within the given days, expired credentials included
+//	@Security		BearerToken
+//	@Tags			credentials
+//	@Produce		json
+//	@Param			within_days	query		int	false	"days until expiry, default 30"
+//	@Success		200			{object}	models.ListCredentialsResponse
+//	@Router			/integration/api/v1/credentials/expiring [get]
+func (h API) ListExpiringCredentials(c echo.Context) error {
+	withinDays := defaultExpiringWithinDays
+	if v := c.QueryParam("within_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid within_days")
+		}
+		withinDays = days
+	}
+
+	credentials, err := h.database.ListCredentialsExpiringBefore(time.Now().AddDate(0, 0, withinDays))
+	if err != nil {
+		h.logger.Error("failed to list expiring credentials", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list expiring credentials")
+	}
+
+	items := make([]models.Credential, 0, len(credentials))
+	for _, credential := range credentials {
+		item, err := credential.ToApi(false)
+		if err != nil {
+			h.logger.Error("failed to convert credentials to API model", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert credentials to API model")
+		}
+		items = append(items, *item)
+	}
+
+	return c.JSON(http.StatusOK, models.ListCredentialsResponse{
+		Credentials: items,
+		TotalCount:  len(items),
+	})
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/opengovern/og-util/pkg/httpserver"
 	"github.com/opengovern/og-util/pkg/integration"
 	"github.com/opengovern/og-util/pkg/integration/interfaces"
+	"github.com/opengovern/opensecurity/pkg/audit"
 	"github.com/opengovern/opensecurity/pkg/utils"
 	"github.com/opengovern/opensecurity/services/integration/api/models"
 	"github.com/opengovern/opensecurity/services/integration/db"
@@ -70,6 +71,9 @@ func (a *API) Register(e *echo.Group) {
 	plugin.GET("/:id/manifest", httpserver.AuthorizeHandler(a.GetManifest, api.ViewerRole))
 	plugin.POST("/load/id/:id", httpserver.AuthorizeHandler(a.LoadPluginWithID, api.EditorRole))
 	plugin.POST("/load/url/:http_url", httpserver.AuthorizeHandler(a.LoadPluginWithURL, api.EditorRole))
+	plugin.POST("/load/local/:id", httpserver.AuthorizeHandler(a.LoadPluginFromLocalRegistry, api.EditorRole))
+	plugin.POST("/load/upload", httpserver.AuthorizeHandler(a.UploadPlugin, api.EditorRole))
+	plugin.GET("/registry/local", httpserver.AuthorizeHandler(a.ListLocalRegistryPlugins, api.ViewerRole))
 	plugin.DELETE("/uninstall/id/:id", httpserver.AuthorizeHandler(a.UninstallPlugin, api.EditorRole))
 	plugin.POST("/:id/enable", httpserver.AuthorizeHandler(a.EnablePlugin, api.EditorRole))
 	plugin.POST("/:id/disable", httpserver.AuthorizeHandler(a.DisablePlugin, api.EditorRole))
@@ -77,6 +81,11 @@ func (a *API) Register(e *echo.Group) {
 	plugin.GET("/:id", httpserver.AuthorizeHandler(a.GetPlugin, api.ViewerRole))
 	plugin.GET("/:id/integrations", httpserver.AuthorizeHandler(a.ListPluginIntegrations, api.ViewerRole))
 	plugin.GET("/:id/credentials", httpserver.AuthorizeHandler(a.ListPluginCredentials, api.ViewerRole))
+	plugin.GET("/:id/versions", httpserver.AuthorizeHandler(a.ListPluginVersions, api.ViewerRole))
+	plugin.POST("/:id/versions/:version/activate", httpserver.AuthorizeHandler(a.ActivatePluginVersion, api.EditorRole))
+	plugin.POST("/:id/rollback", httpserver.AuthorizeHandler(a.RollbackPlugin, api.EditorRole))
+	plugin.POST("/:id/pin", httpserver.AuthorizeHandler(a.PinPluginVersion, api.EditorRole))
+	plugin.DELETE("/:id/pin", httpserver.AuthorizeHandler(a.UnpinPluginVersion, api.EditorRole))
 	plugin.POST("/:id/healthcheck", httpserver.AuthorizeHandler(a.HealthCheck, api.ViewerRole))
 	plugin.GET("/tables", httpserver.AuthorizeHandler(a.GetPluginsTables, api.ViewerRole))
 }
@@ -335,6 +344,21 @@ func (a *API) LoadPluginWithID(c echo.Context) error {
 	if plugin == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "plugin not found")
 	}
+	if plugin.PinnedVersion != "" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("plugin is pinned to version %s", plugin.PinnedVersion))
+	}
+
+	// plugins listed in the local registry are installed from it instead of their remote url
+	localPath, _, err := a.localRegistryPluginPath(pluginID)
+	if err != nil {
+		a.logger.Error("failed to read local plugin registry", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to read local plugin registry")
+	}
+	if localPath != "" {
+		plugin.URL = localPath
+	} else if isPluginUpload(plugin.URL) {
+		return echo.NewHTTPError(http.StatusBadRequest, "plugin was installed from an uploaded bundle, upload the bundle again to reinstall it")
+	}
 
 	err = a.CheckEnoughMemory()
 	if err != nil {
@@ -386,9 +410,22 @@ func (a *API) LoadPluginWithURL(c echo.Context) error {
 	if pluginURL == "" {
 		return echo.NewHTTPError(http.StatusNotFound, "plugin url is empty")
 	}
-	url := pluginURL
 
-	err = a.CheckEnoughMemory()
+	return a.loadPluginFromURL(c, pluginURL, nil)
+}
+
+// loadPluginFromURL installs the bundle at url, a remote url or a local path, as a new plugin or as a new version of
+// the plugin it belongs to. catalog, when given, fills the catalog fields of a new plugin.
+func (a *API) loadPluginFromURL(c echo.Context, url string, catalog *models2.IntegrationPlugin) error {
+	// an upload is removed once its bundle is read, updates read it in the background and remove it themselves
+	updating := false
+	defer func() {
+		if !updating {
+			a.removePluginUpload(url)
+		}
+	}()
+
+	err := a.CheckEnoughMemory()
 	if err != nil {
 		a.logger.Error("checking enough memory failed", zap.Error(err))
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -428,6 +465,12 @@ func (a *API) LoadPluginWithURL(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration binaries")
 		}
 
+		signature, err := a.verifyPluginBundle(baseDir + "/integration_type")
+		if err != nil {
+			a.logger.Error("plugin bundle verification failed", zap.Error(err), zap.String("url", url))
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("plugin bundle verification failed: %v", err))
+		}
+
 		// make scope to delete integrationPlugin and cloudqlPlugin after usage
 		{
 			// read integration-plugin file
@@ -458,6 +501,23 @@ func (a *API) LoadPluginWithURL(c echo.Context) error {
 
 			a.logger.Info("done reading files", zap.String("url", url), zap.String("url", url), zap.String("integrationType", m.IntegrationType.String()), zap.Int("integrationPluginSize", len(integrationPlugin)), zap.Int("cloudqlPluginSize", len(cloudqlPlugin)))
 
+			err = a.checkPluginCompatibility(c.Request().Context(), m.SupportedPlatformVersion)
+			if err != nil {
+				a.logger.Error("plugin is not compatible with the platform", zap.Error(err), zap.String("url", url))
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
+			existing, err := a.database.GetPluginByID(m.IntegrationType.String())
+			if err != nil {
+				a.logger.Error("failed to get plugin", zap.Error(err))
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to get plugin")
+			}
+			if existing != nil && existing.PluginID != "" {
+				// the plugin is known with another url, install the bundle as its new version
+				updating = true
+				return a.updatePluginFromURL(c, existing, url)
+			}
+
 			plugin = &models2.IntegrationPlugin{
 				PluginID:        m.IntegrationType.String(),
 				IntegrationType: m.IntegrationType,
@@ -465,25 +525,44 @@ func (a *API) LoadPluginWithURL(c echo.Context) error {
 				DescriberTag:    m.DescriberTag,
 				InstallState:    models2.IntegrationTypeInstallStateInstalling,
 				URL:             url,
+				Version:         bundleVersion(m, integrationPlugin),
 			}
-			err = a.database.CreatePlugin(plugin)
-			if err != nil {
-				a.logger.Error("failed to create plugin", zap.Error(err), zap.String("id", m.IntegrationType.String()))
-				return echo.NewHTTPError(http.StatusInternalServerError, "failed to create plugin")
+			if catalog != nil {
+				plugin.Name = catalog.Name
+				plugin.Tier = catalog.Tier
+				plugin.Description = catalog.Description
+				plugin.Icon = catalog.Icon
+				plugin.Availability = catalog.Availability
+				plugin.SourceCode = catalog.SourceCode
+				plugin.PackageType = catalog.PackageType
+				plugin.Tags = catalog.Tags
 			}
-
 			pluginBinary := &models2.IntegrationPluginBinary{
 				PluginID: m.IntegrationType.String(),
 
 				IntegrationPlugin: integrationPlugin,
 				CloudQlPlugin:     cloudqlPlugin,
 			}
+			setPluginSignature(plugin, signature, pluginBinary)
+
+			err = a.database.CreatePlugin(plugin)
+			if err != nil {
+				a.logger.Error("failed to create plugin", zap.Error(err), zap.String("id", m.IntegrationType.String()))
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to create plugin")
+			}
+
 			err = a.database.CreatePluginBinary(pluginBinary)
 			if err != nil {
 				a.logger.Error("failed to create plugin binary", zap.Error(err), zap.String("id", m.IntegrationType.String()))
 				return echo.NewHTTPError(http.StatusInternalServerError, "failed to create plugin binary")
 			}
 
+			err = a.retainPluginVersion(plugin, m, signature, pluginBinary)
+			if err != nil {
+				a.logger.Error("failed to retain plugin version", zap.Error(err), zap.String("id", m.IntegrationType.String()))
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to retain plugin version")
+			}
+
 			go func() {
 				err = a.LoadPlugin(c.Request().Context(), plugin, pluginBinary)
 				if err != nil {
@@ -511,22 +590,43 @@ func (a *API) LoadPluginWithURL(c echo.Context) error {
 			}()
 		}
 	} else {
-		plugin.InstallState = models2.IntegrationTypeInstallStateInstalling
+		updating = true
+		return a.updatePluginFromURL(c, plugin, url)
+	}
 
-		err = a.database.UpdatePlugin(plugin)
-		if err != nil {
-			a.logger.Error("failed to update plugin", zap.Error(err), zap.String("id", plugin.PluginID))
-			return echo.NewHTTPError(http.StatusInternalServerError, "failed to update plugin")
+	return c.NoContent(http.StatusOK)
+}
+
+// updatePluginFromURL starts installing the bundle at url as the new version of an existing plugin
+func (a *API) updatePluginFromURL(c echo.Context, plugin *models2.IntegrationPlugin, url string) error {
+	started := false
+	defer func() {
+		if !started {
+			a.removePluginUpload(url)
 		}
+	}()
 
-		go func() {
-			err = a.InstallOrUpdatePlugin(context.Background(), plugin)
-			if err != nil {
-				a.logger.Error("failed to update plugin", zap.Error(err), zap.String("id", plugin.PluginID))
-			}
-		}()
+	if plugin.PinnedVersion != "" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("plugin is pinned to version %s", plugin.PinnedVersion))
+	}
+	plugin.URL = url
+	plugin.InstallState = models2.IntegrationTypeInstallStateInstalling
+
+	err := a.database.UpdatePlugin(plugin)
+	if err != nil {
+		a.logger.Error("failed to update plugin", zap.Error(err), zap.String("id", plugin.PluginID))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update plugin")
 	}
 
+	started = true
+	go func() {
+		defer a.removePluginUpload(url)
+		err := a.InstallOrUpdatePlugin(context.Background(), plugin)
+		if err != nil {
+			a.logger.Error("failed to update plugin", zap.Error(err), zap.String("id", plugin.PluginID))
+		}
+	}()
+
 	return c.NoContent(http.StatusOK)
 }
 
@@ -570,6 +670,7 @@ func (a *API) UninstallPlugin(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "integration type has credentials")
 	}
 
+	audit.SetBefore(c, map[string]any{"version": plugin.Version, "install_state": plugin.InstallState, "operational_status": plugin.OperationalStatus})
 	plugin.InstallState = models2.IntegrationTypeInstallStateNotInstalled
 	plugin.OperationalStatus = models2.IntegrationPluginOperationalStatusDisabled
 
@@ -584,6 +685,7 @@ func (a *API) UninstallPlugin(c echo.Context) error {
 		a.logger.Error("failed to update plugin", zap.Error(err), zap.String("id", plugin.PluginID))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update plugin")
 	}
+	audit.SetAfter(c, map[string]any{"version": plugin.Version, "install_state": plugin.InstallState, "operational_status": plugin.OperationalStatus})
 
 	return c.NoContent(http.StatusOK)
 }
@@ -761,6 +863,11 @@ func (a *API) ListPlugins(c echo.Context) error {
 			DescriberURL:             plugin.DescriberURL,
 			Name:                     plugin.Name,
 			Count:                    count,
+			SignatureStatus:          plugin.SignatureStatus,
+			SignedBy:                 plugin.SignedBy,
+			VerifiedAt:               plugin.VerifiedAt,
+			Version:                  plugin.Version,
+			PinnedVersion:            plugin.PinnedVersion,
 		})
 	}
 
@@ -863,6 +970,11 @@ func (a *API) GetPlugin(c echo.Context) error {
 		PackageType:              plugin.PackageType,
 		DescriberURL:             plugin.DescriberURL,
 		Name:                     plugin.Name,
+		SignatureStatus:          plugin.SignatureStatus,
+		SignedBy:                 plugin.SignedBy,
+		VerifiedAt:               plugin.VerifiedAt,
+		Version:                  plugin.Version,
+		PinnedVersion:            plugin.PinnedVersion,
 	})
 }
 
@@ -1028,6 +1140,11 @@ func (a *API) InstallOrUpdatePlugin(ctx context.Context, plugin *models2.Integra
 		return err
 	}
 	url := plugin.URL
+	if isPluginUpload(url) {
+		if _, err = os.Stat(url); err != nil {
+			return fmt.Errorf("uploaded plugin bundle %s was already installed and removed, upload it again: %w", url, err)
+		}
+	}
 	// remove existing files
 	if err = os.RemoveAll(baseDir + "/integration_type"); err != nil {
 		a.logger.Error("failed to remove existing files", zap.Error(err), zap.String("id", plugin.PluginID), zap.String("path", baseDir+"/integration_type"))
@@ -1045,6 +1162,12 @@ func (a *API) InstallOrUpdatePlugin(ctx context.Context, plugin *models2.Integra
 		return err
 	}
 
+	signature, err := a.verifyPluginBundle(baseDir + "/integration_type")
+	if err != nil {
+		a.logger.Error("plugin bundle verification failed", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
+
 	// read integration-plugin file
 	integrationPlugin, err := os.ReadFile(baseDir + "/integration_type/integration-plugin")
 	if err != nil {
@@ -1072,6 +1195,11 @@ func (a *API) InstallOrUpdatePlugin(ctx context.Context, plugin *models2.Integra
 		return err
 	}
 
+	if err = a.checkPluginCompatibility(ctx, m.SupportedPlatformVersion); err != nil {
+		a.logger.Error("plugin is not compatible with the platform", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
+
 	// Opensearch templates
 	a.logger.Info("checking for index-templates", zap.String("id", plugin.PluginID))
 	var files []string
@@ -1114,6 +1242,7 @@ func (a *API) InstallOrUpdatePlugin(ctx context.Context, plugin *models2.Integra
 
 	plugin.DescriberURL = m.DescriberURL
 	plugin.DescriberTag = m.DescriberTag
+	plugin.Version = bundleVersion(m, integrationPlugin)
 
 	pluginBinary := &models2.IntegrationPluginBinary{
 		PluginID: m.IntegrationType.String(),
@@ -1121,11 +1250,22 @@ func (a *API) InstallOrUpdatePlugin(ctx context.Context, plugin *models2.Integra
 		IntegrationPlugin: integrationPlugin,
 		CloudQlPlugin:     cloudqlPlugin,
 	}
+	setPluginSignature(plugin, signature, pluginBinary)
+	err = a.database.UpdatePluginSignature(plugin)
+	if err != nil {
+		a.logger.Error("failed to update plugin signature", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
 	err = a.database.CreatePluginBinary(pluginBinary)
 	if err != nil {
 		a.logger.Error("failed to create plugin binary", zap.Error(err), zap.String("id", m.IntegrationType.String()))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create plugin binary")
 	}
+	err = a.retainPluginVersion(plugin, m, signature, pluginBinary)
+	if err != nil {
+		a.logger.Error("failed to retain plugin version", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
 	err = a.LoadPlugin(context.Background(), plugin, pluginBinary)
 	if err != nil {
 		a.logger.Error("failed to load plugin", zap.Error(err), zap.String("id", plugin.PluginID))
@@ -1136,6 +1276,11 @@ func (a *API) InstallOrUpdatePlugin(ctx context.Context, plugin *models2.Integra
 }
 
 func (a *API) LoadPlugin(ctx context.Context, plugin *models2.IntegrationPlugin, pluginBinary *models2.IntegrationPluginBinary) error {
+	if err := plugin.CheckBinary(*pluginBinary); err != nil {
+		a.logger.Error("refusing to load plugin", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
+
 	// create directory for plugins if not exists
 	baseDir := "/plugins"
 	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
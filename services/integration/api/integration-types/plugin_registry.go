@@ -0,0 +1,318 @@
+package integration_types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/jackc/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/integration"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+)
+
+const (
+	localRegistryIndexFile = "index.yaml"
+	pluginUploadsDir       = "/integration-types/uploads"
+
+	defaultMaxPluginUploadSizeMB = 512
+)
+
+// pluginArchiveExtensions are the archive formats accepted on upload, the downloader extracts them by extension
+var pluginArchiveExtensions = []string{".tar.gz", ".tgz", ".zip"}
+
+// localRegistryPlugin is a plugin of the local registry index, in the format of the plugins of the platform manifest.
+// The package url is a bundle archive or directory, relative to the registry directory.
+type localRegistryPlugin struct {
+	IntegrationType integration.Type    `yaml:"integration_type"`
+	Name            string              `yaml:"name"`
+	Tier            string              `yaml:"tier"`
+	Tags            map[string][]string `yaml:"tags"`
+	Description     string              `yaml:"description"`
+	Icon            string              `yaml:"icon"`
+	Availability    string              `yaml:"availability"`
+	SourceCode      string              `yaml:"source_code"`
+	PackageType     string              `yaml:"package_type"`
+	ArtifactDetails struct {
+		PackageURL string `yaml:"package_url"`
+	} `yaml:"artifact_details"`
+}
+
+type localRegistryIndex struct {
+	Plugins []localRegistryPlugin `yaml:"plugins"`
+}
+
+// readLocalRegistry reads the index of the local registry, returning nil when no local registry is configured
+func (a *API) readLocalRegistry() (*localRegistryIndex, error) {
+	registryPath := a.typeManager.PluginsConfig().LocalRegistryPath
+	if registryPath == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(registryPath, localRegistryIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	var index localRegistryIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", localRegistryIndexFile, err)
+	}
+	return &index, nil
+}
+
+// path returns the location of the bundle, which must be inside the registry directory
+func (p localRegistryPlugin) path(registryPath string) (string, error) {
+	packageURL := p.ArtifactDetails.PackageURL
+	if packageURL == "" {
+		return "", fmt.Errorf("plugin %s has no package url", p.IntegrationType)
+	}
+	path := filepath.Join(registryPath, packageURL)
+	if rel, err := filepath.Rel(registryPath, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("package url of plugin %s is outside of the local registry", p.IntegrationType)
+	}
+	return path, nil
+}
+
+// localRegistryPluginPath returns the bundle path of the plugin in the local registry, empty when it is not listed
+func (a *API) localRegistryPluginPath(pluginID string) (string, *localRegistryPlugin, error) {
+	index, err := a.readLocalRegistry()
+	if err != nil || index == nil {
+		return "", nil, err
+	}
+	for _, p := range index.Plugins {
+		if p.IntegrationType.String() != pluginID {
+			continue
+		}
+		path, err := p.path(a.typeManager.PluginsConfig().LocalRegistryPath)
+		if err != nil {
+			return "", nil, err
+		}
+		return path, &p, nil
+	}
+	return "", nil, nil
+}
+
+func (a *API) checkNoPluginInstalling() error {
+	installingPlugins, err := a.database.ListInstallingPlugins()
+	if err != nil {
+		a.logger.Error("failed to list installing plugins", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list installing plugins")
+	}
+	if len(installingPlugins) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "other plugin install is in progress")
+	}
+	return nil
+}
+
+// ListLocalRegistryPlugins godoc
+//
+// @Summary			List local registry plugins
+// @Description		List the plugins of the local registry directory configured for air-gapped installs
+// @Security		BearerToken
+// @Tags			integration_types
+// @Produce			json
+// @Success			200	{object}	models.LocalRegistryPluginListResponse
+// @Router			/integration/api/v1/plugin/registry/local [get]
+func (a *API) ListLocalRegistryPlugins(c echo.Context) error {
+	index, err := a.readLocalRegistry()
+	if err != nil {
+		a.logger.Error("failed to read local plugin registry", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to read local plugin registry")
+	}
+	if index == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "local plugin registry is not configured")
+	}
+
+	registryPath := a.typeManager.PluginsConfig().LocalRegistryPath
+	items := make([]models.LocalRegistryPlugin, 0, len(index.Plugins))
+	for _, p := range index.Plugins {
+		path, err := p.path(registryPath)
+		if err != nil {
+			a.logger.Warn("skipping local registry plugin", zap.Error(err))
+			continue
+		}
+		item := models.LocalRegistryPlugin{
+			PluginID:     p.IntegrationType.String(),
+			Name:         p.Name,
+			Tier:         p.Tier,
+			Description:  p.Description,
+			Icon:         p.Icon,
+			Availability: p.Availability,
+			PackageType:  p.PackageType,
+			Path:         path,
+			InstallState: string(models2.IntegrationTypeInstallStateNotInstalled),
+		}
+		plugin, err := a.database.GetPluginByID(p.IntegrationType.String())
+		if err != nil {
+			a.logger.Error("failed to get plugin", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get plugin")
+		}
+		if plugin != nil && plugin.PluginID != "" {
+			item.InstallState = string(plugin.InstallState)
+			item.InstalledVersion = plugin.Version
+		}
+		items = append(items, item)
+	}
+
+	return c.JSON(http.StatusOK, models.LocalRegistryPluginListResponse{Items: items})
+}
+
+// LoadPluginFromLocalRegistry godoc
+//
+// @Summary			Load plugin from the local registry
+// @Description		Install or update the plugin with the bundle listed for it in the local registry directory
+// @Security		BearerToken
+// @Tags			integration_types
+// @Produce			json
+// @Param			id	path	string	true	"plugin id"
+// @Success			200
+// @Router			/integration/api/v1/plugin/load/local/{id} [post]
+func (a *API) LoadPluginFromLocalRegistry(c echo.Context) error {
+	if err := a.checkNoPluginInstalling(); err != nil {
+		return err
+	}
+
+	path, entry, err := a.localRegistryPluginPath(c.Param("id"))
+	if err != nil {
+		a.logger.Error("failed to read local plugin registry", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to read local plugin registry")
+	}
+	if entry == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "plugin not found in the local registry")
+	}
+
+	tagsJsonData, err := json.Marshal(entry.Tags)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal plugin tags")
+	}
+	var tags pgtype.JSONB
+	if err := tags.Set(tagsJsonData); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal plugin tags")
+	}
+
+	return a.loadPluginFromURL(c, path, &models2.IntegrationPlugin{
+		Name:         entry.Name,
+		Tier:         entry.Tier,
+		Description:  entry.Description,
+		Icon:         entry.Icon,
+		Availability: entry.Availability,
+		SourceCode:   entry.SourceCode,
+		PackageType:  entry.PackageType,
+		Tags:         tags,
+	})
+}
+
+// UploadPlugin godoc
+//
+// @Summary			Upload plugin
+// @Description		Install or update a plugin with an uploaded bundle archive (.tar.gz, .tgz or .zip)
+// @Security		BearerToken
+// @Tags			integration_types
+// @Accept			multipart/form-data
+// @Produce			json
+// @Param			file	formData	file	true	"plugin bundle archive"
+// @Success			200
+// @Failure			413
+// @Router			/integration/api/v1/plugin/load/upload [post]
+func (a *API) UploadPlugin(c echo.Context) error {
+	if err := a.checkNoPluginInstalling(); err != nil {
+		return err
+	}
+
+	file, ext, err := pluginUploadFile(c, a.typeManager.PluginsConfig().MaxUploadSizeMB)
+	if err != nil {
+		return err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read file")
+	}
+	defer src.Close()
+
+	path, err := storePluginUpload(pluginUploadsDir, src, ext)
+	if err != nil {
+		a.logger.Error("failed to store plugin upload", zap.Error(err), zap.String("file", file.Filename))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to store plugin upload")
+	}
+	a.logger.Info("stored plugin upload", zap.String("file", file.Filename), zap.String("path", path))
+
+	return a.loadPluginFromURL(c, path, nil)
+}
+
+// pluginUploadFile returns the archive uploaded with the request and its extension, the request body is capped
+// to maxSizeMB, or to defaultMaxPluginUploadSizeMB when it is not set
+func pluginUploadFile(c echo.Context, maxSizeMB int64) (*multipart.FileHeader, string, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultMaxPluginUploadSizeMB
+	}
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxSizeMB<<20)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, "", echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("plugin archive should be at most %d MB", maxSizeMB))
+		}
+		return nil, "", echo.NewHTTPError(http.StatusBadRequest, "file is required")
+	}
+	for _, ext := range pluginArchiveExtensions {
+		if strings.HasSuffix(strings.ToLower(file.Filename), ext) {
+			return file, ext, nil
+		}
+	}
+	return nil, "", echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("plugin archive should be one of %s", strings.Join(pluginArchiveExtensions, ", ")))
+}
+
+// isPluginUpload reports whether url is an uploaded bundle. Uploads are removed once they are installed, the plugins
+// and versions installed from one keep its path but can not be downloaded again.
+func isPluginUpload(url string) bool {
+	return filepath.Dir(url) == pluginUploadsDir
+}
+
+// removePluginUpload deletes the bundle at url once it is installed or failed to, when it is an upload
+func (a *API) removePluginUpload(url string) {
+	if !isPluginUpload(url) {
+		return
+	}
+	if err := os.Remove(url); err != nil && !os.IsNotExist(err) {
+		a.logger.Warn("failed to remove plugin upload", zap.Error(err), zap.String("path", url))
+	}
+}
+
+// storePluginUpload writes the upload to dir, named after its checksum so the same archive keeps its path
+func storePluginUpload(dir string, src io.Reader, ext string) (string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, "upload-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), src); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, hex.EncodeToString(h.Sum(nil))+ext)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,149 @@
+package integration_types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestLocalRegistryPluginPath(t *testing.T) {
+	registryPath := filepath.Join(t.TempDir(), "registry")
+	tests := []struct {
+		name       string
+		packageURL string
+		want       string
+		wantErr    bool
+	}{
+		{name: "bundle in the registry", packageURL: "bundles/aws.tar.gz", want: filepath.Join(registryPath, "bundles", "aws.tar.gz")},
+		{name: "absolute path is joined to the registry", packageURL: "/bundles/aws.tar.gz", want: filepath.Join(registryPath, "bundles", "aws.tar.gz")},
+		{name: "name starting with dots", packageURL: "..aws.tar.gz", want: filepath.Join(registryPath, "..aws.tar.gz")},
+		{name: "parent directory", packageURL: "../aws.tar.gz", wantErr: true},
+		{name: "traversal through a subdirectory", packageURL: "bundles/../../etc/passwd", wantErr: true},
+		{name: "registry parent", packageURL: "..", wantErr: true},
+		{name: "no package url", packageURL: "", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var p localRegistryPlugin
+			p.IntegrationType = "aws_cloud_account"
+			p.ArtifactDetails.PackageURL = tc.packageURL
+			got, err := p.path(registryPath)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("path(%s) error = %v, wantErr %v", tc.packageURL, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("path(%s) = %s, want %s", tc.packageURL, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStorePluginUpload(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "uploads")
+	content := []byte("plugin bundle")
+	sum := sha256.Sum256(content)
+	want := filepath.Join(dir, hex.EncodeToString(sum[:])+".tgz")
+
+	for i := 0; i < 2; i++ {
+		path, err := storePluginUpload(dir, bytes.NewReader(content), ".tgz")
+		if err != nil {
+			t.Fatalf("storePluginUpload() error = %v", err)
+		}
+		if path != want {
+			t.Errorf("storePluginUpload() = %s, want %s", path, want)
+		}
+	}
+	stored, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !bytes.Equal(stored, content) {
+		t.Errorf("stored %q, want %q", stored, content)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("uploads directory has %d files, want the stored upload only", len(entries))
+	}
+}
+
+func TestIsPluginUpload(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: filepath.Join(pluginUploadsDir, "abc.tgz"), want: true},
+		{url: "/integration-types/integration_type", want: false},
+		{url: "/registry/bundles/aws.tgz", want: false},
+		{url: "https://github.com/opengovern/og-describer-aws/releases/download/v0.1.0/bundle.tar.gz", want: false},
+	}
+	for _, tc := range tests {
+		if got := isPluginUpload(tc.url); got != tc.want {
+			t.Errorf("isPluginUpload(%s) = %v, want %v", tc.url, got, tc.want)
+		}
+	}
+}
+
+func newPluginUploadContext(t *testing.T, filename string, size int) echo.Context {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	if _, err := part.Write(bytes.Repeat([]byte{'a'}, size)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/plugin/load/upload", &body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestPluginUploadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		size     int
+		wantExt  string
+		wantCode int
+	}{
+		{name: "archive", filename: "bundle.tar.gz", size: 1024, wantExt: ".tar.gz"},
+		{name: "extension case", filename: "BUNDLE.ZIP", size: 1024, wantExt: ".zip"},
+		{name: "not an archive", filename: "bundle.txt", size: 1024, wantCode: http.StatusBadRequest},
+		{name: "over the size cap", filename: "bundle.tgz", size: 2 << 20, wantCode: http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newPluginUploadContext(t, tc.filename, tc.size)
+			file, ext, err := pluginUploadFile(c, 1)
+			if tc.wantCode != 0 {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) || httpErr.Code != tc.wantCode {
+					t.Fatalf("pluginUploadFile() error = %v, want status %d", err, tc.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pluginUploadFile() error = %v", err)
+			}
+			if ext != tc.wantExt || file.Filename != tc.filename {
+				t.Errorf("pluginUploadFile() = %s %s, want %s %s", file.Filename, ext, tc.filename, tc.wantExt)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package integration_types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/pluginsign"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+)
+
+var pluginBundleFiles = []string{"integration-plugin", "cloudql-plugin", "manifest.yaml"}
+
+// verifyPluginBundle checks the downloaded bundle in dir against its signed checksum manifest
+func (a *API) verifyPluginBundle(dir string) (*pluginsign.Result, error) {
+	cnf := a.typeManager.PluginsConfig()
+	store, err := pluginsign.LoadTrustStore(cnf.TrustedKeysPath)
+	if err != nil {
+		return nil, fmt.Errorf("load plugin trust store: %w", err)
+	}
+	result, err := pluginsign.VerifyDir(dir, store, pluginBundleFiles...)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status != pluginsign.StatusVerified {
+		// once publishers are trusted an unsigned or stripped bundle can not be told apart from a tampered one
+		if cnf.RequireSignature || len(store) > 0 {
+			return nil, errors.New("plugin bundle is not signed by a trusted key")
+		}
+		a.logger.Warn("installing unsigned plugin bundle", zap.String("path", dir))
+	}
+	return result, nil
+}
+
+// setPluginSignature records the verification result and the checksums of the binaries that are going to be stored
+func setPluginSignature(plugin *models2.IntegrationPlugin, result *pluginsign.Result, binary *models2.IntegrationPluginBinary) {
+	now := time.Now()
+	plugin.SignatureStatus = string(result.Status)
+	plugin.SignedBy = result.KeyID
+	plugin.IntegrationPluginSHA256 = pluginsign.Checksum(binary.IntegrationPlugin)
+	plugin.CloudQLPluginSHA256 = pluginsign.Checksum(binary.CloudQlPlugin)
+	plugin.VerifiedAt = &now
+}
@@ -0,0 +1,408 @@
+package integration_types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/opensecurity/pkg/pluginsign"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+)
+
+const defaultRetainedPluginVersions = 3
+
+// bundleVersion returns the version of a bundle, falling back to the checksum of its binary for manifests without any version
+func bundleVersion(m models2.Manifest, integrationPlugin []byte) string {
+	if v := m.PluginVersion(); v != "" {
+		return v
+	}
+	return "sha256-" + pluginsign.Checksum(integrationPlugin)[:12]
+}
+
+// checkPluginCompatibility rejects bundles that do not support the platform version, the check is skipped when the platform version is unknown
+func (a *API) checkPluginCompatibility(ctx context.Context, supportedPlatformVersion string) error {
+	platformVersion, err := a.typeManager.PlatformVersion(ctx)
+	if err != nil {
+		a.logger.Warn("failed to get platform version, skipping plugin compatibility check", zap.Error(err))
+		return nil
+	}
+	err = models2.CheckPlatformCompatibility(supportedPlatformVersion, platformVersion)
+	if errors.Is(err, models2.ErrUnknownPlatformVersion) {
+		a.logger.Warn("skipping plugin compatibility check", zap.Error(err))
+		return nil
+	}
+	return err
+}
+
+// retainPluginVersion stores the bundle of the active version of the plugin and prunes the oldest versions
+func (a *API) retainPluginVersion(plugin *models2.IntegrationPlugin, m models2.Manifest, signature *pluginsign.Result, binary *models2.IntegrationPluginBinary) error {
+	now := time.Now()
+	err := a.database.UpsertPluginVersion(&models2.IntegrationPluginVersion{
+		PluginID:                 plugin.PluginID,
+		Version:                  plugin.Version,
+		URL:                      plugin.URL,
+		DescriberURL:             m.DescriberURL,
+		DescriberTag:             m.DescriberTag,
+		SupportedPlatformVersion: m.SupportedPlatformVersion,
+		SignatureStatus:          string(signature.Status),
+		SignedBy:                 signature.KeyID,
+		CreatedAt:                now,
+		ActivatedAt:              now,
+		IntegrationPlugin:        binary.IntegrationPlugin,
+		CloudQlPlugin:            binary.CloudQlPlugin,
+	})
+	if err != nil {
+		return err
+	}
+
+	retained := a.typeManager.PluginsConfig().RetainedVersions
+	if retained <= 0 {
+		retained = defaultRetainedPluginVersions
+	}
+	return a.database.PrunePluginVersions(plugin.PluginID, retained, plugin.Version, plugin.PinnedVersion)
+}
+
+// activatePluginVersion replaces the binary of the plugin with a retained version and reloads it,
+// which also restarts the cloudql enabled services. When the switch fails the previous binary is restored.
+func (a *API) activatePluginVersion(ctx context.Context, plugin *models2.IntegrationPlugin, pluginVersion *models2.IntegrationPluginVersion) error {
+	previous := *plugin
+	previousBinary, err := a.database.GetPluginBinaryByID(plugin.PluginID)
+	if err != nil {
+		a.logger.Error("failed to get plugin binary", zap.Error(err), zap.String("id", plugin.PluginID))
+	} else {
+		err = a.replacePluginBinary(ctx, plugin, pluginVersion)
+		if err != nil && previousBinary != nil && len(previousBinary.IntegrationPlugin) > 0 {
+			*plugin = previous
+			if restoreErr := a.restorePluginBinary(ctx, plugin, previousBinary); restoreErr != nil {
+				a.logger.Error("failed to restore previous plugin binary", zap.Error(restoreErr), zap.String("id", plugin.PluginID))
+				plugin.OperationalStatus = models2.IntegrationPluginOperationalStatusFailed
+			}
+		}
+	}
+
+	// the plugin keeps its previous version installed when the switch fails
+	plugin.InstallState = models2.IntegrationTypeInstallStateInstalled
+	if err == nil {
+		plugin.OperationalStatus = models2.IntegrationPluginOperationalStatusEnabled
+	}
+	if updateErr := a.database.UpdatePlugin(plugin); updateErr != nil {
+		a.logger.Error("failed to update plugin", zap.Error(updateErr), zap.String("id", plugin.PluginID))
+	}
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("switched plugin version", zap.String("id", plugin.PluginID), zap.String("version", pluginVersion.Version))
+	return nil
+}
+
+func (a *API) replacePluginBinary(ctx context.Context, plugin *models2.IntegrationPlugin, pluginVersion *models2.IntegrationPluginVersion) error {
+	pluginBinary := &models2.IntegrationPluginBinary{
+		PluginID: plugin.PluginID,
+
+		IntegrationPlugin: pluginVersion.IntegrationPlugin,
+		CloudQlPlugin:     pluginVersion.CloudQlPlugin,
+	}
+
+	plugin.Version = pluginVersion.Version
+	if pluginVersion.URL != "" {
+		plugin.URL = pluginVersion.URL
+	}
+	plugin.DescriberURL = pluginVersion.DescriberURL
+	plugin.DescriberTag = pluginVersion.DescriberTag
+	setPluginSignature(plugin, &pluginsign.Result{
+		Status: pluginsign.Status(pluginVersion.SignatureStatus),
+		KeyID:  pluginVersion.SignedBy,
+	}, pluginBinary)
+
+	err := a.database.UpdatePluginSignature(plugin)
+	if err != nil {
+		a.logger.Error("failed to update plugin signature", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
+	err = a.database.CreatePluginBinary(pluginBinary)
+	if err != nil {
+		a.logger.Error("failed to create plugin binary", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
+	err = a.database.UpdatePluginVersionActivatedAt(plugin.PluginID, pluginVersion.Version, time.Now())
+	if err != nil {
+		a.logger.Error("failed to update plugin version", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
+	err = a.LoadPlugin(ctx, plugin, pluginBinary)
+	if err != nil {
+		a.logger.Error("failed to load plugin", zap.Error(err), zap.String("id", plugin.PluginID))
+		return err
+	}
+	return nil
+}
+
+// restorePluginBinary puts back the binary and the signature of the plugin from before a failed switch and reloads it
+func (a *API) restorePluginBinary(ctx context.Context, plugin *models2.IntegrationPlugin, pluginBinary *models2.IntegrationPluginBinary) error {
+	err := a.database.UpdatePluginSignature(plugin)
+	if err != nil {
+		return err
+	}
+	err = a.database.CreatePluginBinary(pluginBinary)
+	if err != nil {
+		return err
+	}
+	if plugin.Version != "" {
+		err = a.database.UpdatePluginVersionActivatedAt(plugin.PluginID, plugin.Version, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return a.LoadPlugin(ctx, plugin, pluginBinary)
+}
+
+// switchPluginVersion starts activating a retained version of the plugin
+func (a *API) switchPluginVersion(c echo.Context, plugin *models2.IntegrationPlugin, version string) error {
+	if plugin.PinnedVersion != "" && plugin.PinnedVersion != version {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("plugin is pinned to version %s", plugin.PinnedVersion))
+	}
+	if plugin.Version == version {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("version %s is already active", version))
+	}
+
+	if err := a.checkNoPluginInstalling(); err != nil {
+		return err
+	}
+
+	pluginVersion, err := a.database.GetPluginVersion(plugin.PluginID, version)
+	if err != nil {
+		a.logger.Error("failed to get plugin version", zap.Error(err), zap.String("id", plugin.PluginID))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get plugin version")
+	}
+	if pluginVersion == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "plugin version not found")
+	}
+
+	err = a.checkPluginCompatibility(c.Request().Context(), pluginVersion.SupportedPlatformVersion)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = a.CheckEnoughMemory()
+	if err != nil {
+		a.logger.Error("checking enough memory failed", zap.Error(err))
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	plugin.InstallState = models2.IntegrationTypeInstallStateInstalling
+	err = a.database.UpdatePlugin(plugin)
+	if err != nil {
+		a.logger.Error("failed to update plugin", zap.Error(err), zap.String("id", plugin.PluginID))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update plugin")
+	}
+
+	go func() {
+		err := a.activatePluginVersion(context.Background(), plugin, pluginVersion)
+		if err != nil {
+			a.logger.Error("failed to switch plugin version", zap.Error(err), zap.String("id", plugin.PluginID),
+				zap.String("version", version))
+		}
+	}()
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (a *API) getPluginOr404(id string) (*models2.IntegrationPlugin, error) {
+	plugin, err := a.database.GetPluginByID(id)
+	if err != nil {
+		a.logger.Error("failed to get plugin", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get plugin")
+	}
+	if plugin == nil || plugin.PluginID == "" {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "plugin not found")
+	}
+	return plugin, nil
+}
+
+// ListPluginVersions godoc
+//
+// @Summary			List plugin versions
+// @Description		List the retained versions of the plugin that can be activated or rolled back to
+// @Security		BearerToken
+// @Tags			integration_types
+// @Produce			json
+// @Param			id	path		string	true	"plugin id"
+// @Success			200	{object}	models.IntegrationPluginVersionListResponse
+// @Router			/integration/api/v1/plugin/{id}/versions [get]
+func (a *API) ListPluginVersions(c echo.Context) error {
+	plugin, err := a.getPluginOr404(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	versions, err := a.database.ListPluginVersions(plugin.PluginID)
+	if err != nil {
+		a.logger.Error("failed to list plugin versions", zap.Error(err), zap.String("id", plugin.PluginID))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list plugin versions")
+	}
+
+	platformVersion, err := a.typeManager.PlatformVersion(c.Request().Context())
+	if err != nil {
+		a.logger.Warn("failed to get platform version", zap.Error(err))
+	}
+
+	items := make([]models.IntegrationPluginVersion, 0, len(versions))
+	for _, v := range versions {
+		compatible := true
+		if platformVersion != "" {
+			err := models2.CheckPlatformCompatibility(v.SupportedPlatformVersion, platformVersion)
+			compatible = err == nil || errors.Is(err, models2.ErrUnknownPlatformVersion)
+		}
+		items = append(items, models.IntegrationPluginVersion{
+			Version:                  v.Version,
+			URL:                      v.URL,
+			DescriberURL:             v.DescriberURL,
+			DescriberTag:             v.DescriberTag,
+			SupportedPlatformVersion: v.SupportedPlatformVersion,
+			Compatible:               compatible,
+			SignatureStatus:          v.SignatureStatus,
+			SignedBy:                 v.SignedBy,
+			Active:                   v.Version == plugin.Version,
+			Pinned:                   v.Version == plugin.PinnedVersion,
+			CreatedAt:                v.CreatedAt,
+			ActivatedAt:              v.ActivatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.IntegrationPluginVersionListResponse{
+		PluginID:        plugin.PluginID,
+		PlatformVersion: platformVersion,
+		Items:           items,
+	})
+}
+
+// ActivatePluginVersion godoc
+//
+// @Summary			Activate plugin version
+// @Description		Switch the plugin to a retained version and restart the cloudql enabled services
+// @Security		BearerToken
+// @Tags			integration_types
+// @Produce			json
+// @Param			id		path	string	true	"plugin id"
+// @Param			version	path	string	true	"plugin version"
+// @Success			200
+// @Router			/integration/api/v1/plugin/{id}/versions/{version}/activate [post]
+func (a *API) ActivatePluginVersion(c echo.Context) error {
+	plugin, err := a.getPluginOr404(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return a.switchPluginVersion(c, plugin, c.Param("version"))
+}
+
+// RollbackPlugin godoc
+//
+// @Summary			Rollback plugin
+// @Description		Switch the plugin back to the version that was active before the current one and restart the cloudql enabled services
+// @Security		BearerToken
+// @Tags			integration_types
+// @Produce			json
+// @Param			id	path	string	true	"plugin id"
+// @Success			200
+// @Router			/integration/api/v1/plugin/{id}/rollback [post]
+func (a *API) RollbackPlugin(c echo.Context) error {
+	plugin, err := a.getPluginOr404(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	versions, err := a.database.ListPluginVersions(plugin.PluginID)
+	if err != nil {
+		a.logger.Error("failed to list plugin versions", zap.Error(err), zap.String("id", plugin.PluginID))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list plugin versions")
+	}
+	for _, v := range versions {
+		if v.Version != plugin.Version {
+			return a.switchPluginVersion(c, plugin, v.Version)
+		}
+	}
+
+	return echo.NewHTTPError(http.StatusBadRequest, "no previous version is retained")
+}
+
+// PinPluginVersion godoc
+//
+// @Summary			Pin plugin version
+// @Description		Pin the plugin to a version, loading or switching to any other version is rejected until it is unpinned
+// @Security		BearerToken
+// @Tags			integration_types
+// @Accept			json
+// @Produce			json
+// @Param			id		path	string							true	"plugin id"
+// @Param			request	body	models.PinPluginVersionRequest	false	"version to pin"
+// @Success			200
+// @Router			/integration/api/v1/plugin/{id}/pin [post]
+func (a *API) PinPluginVersion(c echo.Context) error {
+	plugin, err := a.getPluginOr404(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	var req models.PinPluginVersionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+	version := req.Version
+	if version == "" {
+		version = plugin.Version
+	}
+	if version == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "plugin has no active version to pin")
+	}
+
+	if version != plugin.Version {
+		pluginVersion, err := a.database.GetPluginVersion(plugin.PluginID, version)
+		if err != nil {
+			a.logger.Error("failed to get plugin version", zap.Error(err), zap.String("id", plugin.PluginID))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get plugin version")
+		}
+		if pluginVersion == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "plugin version not found")
+		}
+	}
+
+	err = a.database.UpdatePluginPinnedVersion(plugin.PluginID, version)
+	if err != nil {
+		a.logger.Error("failed to pin plugin version", zap.Error(err), zap.String("id", plugin.PluginID))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to pin plugin version")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// UnpinPluginVersion godoc
+//
+// @Summary			Unpin plugin version
+// @Description		Remove the version pin of the plugin
+// @Security		BearerToken
+// @Tags			integration_types
+// @Produce			json
+// @Param			id	path	string	true	"plugin id"
+// @Success			200
+// @Router			/integration/api/v1/plugin/{id}/pin [delete]
+func (a *API) UnpinPluginVersion(c echo.Context) error {
+	plugin, err := a.getPluginOr404(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	err = a.database.UpdatePluginPinnedVersion(plugin.PluginID, "")
+	if err != nil {
+		a.logger.Error("failed to unpin plugin version", zap.Error(err), zap.String("id", plugin.PluginID))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unpin plugin version")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
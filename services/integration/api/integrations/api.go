@@ -2,6 +2,7 @@ package integrations
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -23,14 +24,19 @@ import (
 	"github.com/opengovern/og-util/pkg/integration"
 	"github.com/opengovern/og-util/pkg/steampipe"
 	"github.com/opengovern/og-util/pkg/vault"
+	"github.com/opengovern/opensecurity/pkg/rbac"
 	"github.com/opengovern/opensecurity/pkg/utils"
+	complianceClient "github.com/opengovern/opensecurity/services/compliance/client"
 	"github.com/opengovern/opensecurity/services/integration/api/models"
 	"github.com/opengovern/opensecurity/services/integration/db"
 	"github.com/opengovern/opensecurity/services/integration/entities"
 	integration_type "github.com/opengovern/opensecurity/services/integration/integration-type"
 	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"github.com/opengovern/opensecurity/services/integration/secrets"
+	schedulerClient "github.com/opengovern/opensecurity/services/scheduler/client"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
+	"gorm.io/gorm"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,11 +45,15 @@ import (
 
 type API struct {
 	vault        vault.VaultSourceConfig
+	secrets      *secrets.Store
 	logger       *zap.Logger
 	database     db.Database
 	kubeClient   client.Client
 	typesManager *integration_type.IntegrationTypeManager
 
+	schedulerClient  schedulerClient.SchedulerServiceClient
+	complianceClient complianceClient.ComplianceServiceClient
+
 	steampipeOption *steampipe.Option
 	steampipeLock   sync.Mutex
 	steampipeConn   *steampipe.Database
@@ -58,20 +68,26 @@ const (
 
 func New(
 	vault vault.VaultSourceConfig,
+	secrets *secrets.Store,
 	database db.Database,
 	logger *zap.Logger,
 	steampipeOption *steampipe.Option,
 	kubeClien client.Client,
 	typesManager *integration_type.IntegrationTypeManager,
+	schedulerClient schedulerClient.SchedulerServiceClient,
+	complianceClient complianceClient.ComplianceServiceClient,
 ) API {
 	return API{
-		vault:           vault,
-		database:        database,
-		logger:          logger.Named("integrations"),
-		steampipeOption: steampipeOption,
-		steampipeLock:   sync.Mutex{},
-		kubeClient:      kubeClien,
-		typesManager:    typesManager,
+		vault:            vault,
+		secrets:          secrets,
+		database:         database,
+		logger:           logger.Named("integrations"),
+		steampipeOption:  steampipeOption,
+		steampipeLock:    sync.Mutex{},
+		kubeClient:       kubeClien,
+		typesManager:     typesManager,
+		schedulerClient:  schedulerClient,
+		complianceClient: complianceClient,
 	}
 }
 
@@ -80,12 +96,20 @@ func (h *API) Register(g *echo.Group) {
 	g.POST("/list", httpserver.AuthorizeHandler(h.ListByFilters, api.ViewerRole))
 	g.POST("/discover", httpserver.AuthorizeHandler(h.DiscoverIntegrations, api.EditorRole))
 	g.POST("/add", httpserver.AuthorizeHandler(h.AddIntegrations, api.EditorRole))
+	g.GET("/manifest/export", rbac.AuthorizeUnscopedHandler(h.ExportManifest, api.ViewerRole))
+	g.POST("/manifest/import", rbac.AuthorizeUnscopedHandler(h.ImportManifest, api.AdminRole))
 	g.PUT("/:IntegrationID/healthcheck", httpserver.AuthorizeHandler(h.IntegrationHealthcheck, api.EditorRole))
 	g.DELETE("/:IntegrationID", httpserver.AuthorizeHandler(h.Delete, api.EditorRole))
 	g.GET("/:IntegrationID", httpserver.AuthorizeHandler(h.Get, api.ViewerRole))
 	g.POST("/:IntegrationID", httpserver.AuthorizeHandler(h.Update, api.EditorRole))
+	g.GET("/:IntegrationID/health", httpserver.AuthorizeHandler(h.GetIntegrationHealth, api.ViewerRole))
+	g.GET("/:IntegrationID/permissions", httpserver.AuthorizeHandler(h.GetIntegrationPermissions, api.ViewerRole))
 	g.GET("/integration-groups", httpserver.AuthorizeHandler(h.ListIntegrationGroups, api.ViewerRole))
 	g.GET("/integration-groups/:integrationGroupName", httpserver.AuthorizeHandler(h.GetIntegrationGroup, api.ViewerRole))
+	g.POST("/integration-groups", httpserver.AuthorizeHandler(h.CreateIntegrationGroup, api.EditorRole))
+	g.PUT("/integration-groups/:integrationGroupName", httpserver.AuthorizeHandler(h.UpdateIntegrationGroup, api.EditorRole))
+	g.DELETE("/integration-groups/:integrationGroupName", httpserver.AuthorizeHandler(h.DeleteIntegrationGroup, api.EditorRole))
+	g.GET("/integration-groups/:integrationGroupName/history", httpserver.AuthorizeHandler(h.ListIntegrationGroupHistory, api.ViewerRole))
 	g.PUT("/sample/purge", httpserver.AuthorizeHandler(h.PurgeSampleData, api.EditorRole))
 	g.PUT("/:integration_id/resource", httpserver.AuthorizeHandler(h.SetResourceTypesForIntegration, api.EditorRole))
 
@@ -150,6 +174,12 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 
 				} else if key == "description" || key == "Description" {
 					req.Description = values[0]
+				} else if key == "expires_at" {
+					expiresAt, err := time.Parse(time.RFC3339, values[0])
+					if err != nil {
+						return echo.NewHTTPError(http.StatusBadRequest, "expires_at should be an RFC 3339 time")
+					}
+					req.ExpiresAt = &expiresAt
 				} else {
 					keys := strings.Split(key, ".")
 					formData[keys[1]] = values[0]
@@ -184,6 +214,7 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 	var err error
 	var integrationType integration.Type
 	var credentialIDStr string
+	var expirySource models2.CredentialExpirySource
 
 	if req.CredentialID != nil {
 		credentialIDStr = *req.CredentialID
@@ -193,10 +224,11 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 			return echo.NewHTTPError(http.StatusNotFound, "credential not found")
 		}
 		integrationType = credential.IntegrationType
+		expirySource = credential.ExpirySource
 
-		mapData, err := h.vault.Decrypt(c.Request().Context(), credential.Secret)
+		mapData, err := h.secrets.Resolve(c.Request().Context(), credential)
 		if err != nil {
-			h.logger.Error("failed to decrypt secret", zap.Error(err))
+			h.logger.Error("failed to resolve secret", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to decrypt config")
 		}
 
@@ -209,6 +241,22 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 			h.logger.Error("failed to marshal json data", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal json data")
 		}
+	} else if req.SecretReference != nil {
+		integrationType = req.IntegrationType
+		if len(req.Credentials) > 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "credentials and secret_reference are mutually exclusive")
+		}
+		credential, mapData, err := h.createReferenceCredential(c.Request().Context(), req.IntegrationType, req.CredentialType, req.Description, *req.SecretReference)
+		if err != nil {
+			return err
+		}
+		jsonData, err = json.Marshal(mapData)
+		if err != nil {
+			h.logger.Error("failed to marshal json data", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal json data")
+		}
+		credentialIDStr = credential.ID.String()
+		expirySource = credential.ExpirySource
 	} else {
 		integrationType = req.IntegrationType
 		jsonData, err = json.Marshal(req.Credentials)
@@ -258,6 +306,7 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 		credentialMetadataJsonb := pgtype.JSONB{}
 
 		err = credentialMetadataJsonb.Set(metadataJsonData)
+		issuedAt, expiresAt, derivedSource := models2.CredentialValidity(mapData)
 		err = h.database.CreateCredential(&models2.Credential{
 			ID:              credentialID,
 			IntegrationType: req.IntegrationType,
@@ -266,12 +315,27 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 			MaskedSecret:    maskedSecretJsonb,
 			Secret:          secret,
 			Metadata:        credentialMetadataJsonb,
+			IssuedAt:        issuedAt,
+			ExpiresAt:       expiresAt,
+			ExpirySource:    derivedSource,
 		})
 		if err != nil {
 			h.logger.Error("failed to create credential", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to create credential")
 		}
 		credentialIDStr = credentialID.String()
+		expirySource = derivedSource
+	}
+
+	// an expiry given with a new credential wins over the one reported by the plugin, which wins over the derived one
+	if req.CredentialID == nil && req.ExpiresAt != nil {
+		now := time.Now()
+		err = h.database.UpdateCredentialValidity(credentialIDStr, &now, req.ExpiresAt, models2.CredentialExpirySourceManual)
+		if err != nil {
+			h.logger.Error("failed to update credential validity", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to update credential")
+		}
+		expirySource = models2.CredentialExpirySourceManual
 	}
 
 	integration, ok := h.typesManager.GetIntegrationTypeMap()[integrationType]
@@ -295,6 +359,13 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 
 	integrations, err := integration.DiscoverIntegrations(jsonData)
 	h.logger.Info("discovered integrations", zap.Any("integrations", integrations))
+	if expiresAt := models2.ReportedCredentialExpiry(integrations); expiresAt != nil && expirySource != models2.CredentialExpirySourceManual {
+		err = h.database.UpdateCredentialValidity(credentialIDStr, nil, expiresAt, models2.CredentialExpirySourcePlugin)
+		if err != nil {
+			h.logger.Error("failed to update credential validity", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to update credential")
+		}
+	}
 	var integrationsAPI []models.Integration
 	for _, in := range integrations {
 		i := models2.Integration{Integration: in}
@@ -321,6 +392,54 @@ func (h *API) DiscoverIntegrations(c echo.Context) error {
 	})
 }
 
+// createReferenceCredential creates a credential reading its secret from an external backend, only the keys of the secret are stored
+func (h *API) createReferenceCredential(ctx context.Context, integrationType integration.Type, credentialType, description string,
+	ref models.CredentialSecretReference) (*models2.Credential, map[string]any, error) {
+	mapData, err := h.secrets.Read(ctx, ref)
+	if err != nil {
+		h.logger.Error("failed to read referenced secret", zap.Error(err))
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("failed to read referenced secret: %v", err))
+	}
+
+	masked := make(map[string]any)
+	for key := range mapData {
+		masked[key] = "*****"
+	}
+	maskedSecretJsonb := pgtype.JSONB{}
+	if err := maskedSecretJsonb.Set(masked); err != nil {
+		h.logger.Error("failed to set masked secret", zap.Error(err))
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to set masked secret")
+	}
+	secretReferenceJsonb := pgtype.JSONB{}
+	if err := secretReferenceJsonb.Set(ref); err != nil {
+		h.logger.Error("failed to set secret reference", zap.Error(err))
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to set secret reference")
+	}
+	credentialMetadataJsonb := pgtype.JSONB{}
+	if err := credentialMetadataJsonb.Set(map[string]string{}); err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to set credential metadata")
+	}
+
+	issuedAt, expiresAt, expirySource := models2.CredentialValidity(mapData)
+	credential := models2.Credential{
+		ID:              uuid.New(),
+		IntegrationType: integrationType,
+		CredentialType:  credentialType,
+		Description:     description,
+		MaskedSecret:    maskedSecretJsonb,
+		SecretReference: secretReferenceJsonb,
+		Metadata:        credentialMetadataJsonb,
+		IssuedAt:        issuedAt,
+		ExpiresAt:       expiresAt,
+		ExpirySource:    expirySource,
+	}
+	if err := h.database.CreateCredential(&credential); err != nil {
+		h.logger.Error("failed to create credential", zap.Error(err))
+		return nil, nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to create credential")
+	}
+	return &credential, mapData, nil
+}
+
 // AddIntegrations godoc
 //
 //	@Summary		Add integrations
@@ -348,9 +467,9 @@ func (h *API) AddIntegrations(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "credential not found")
 	}
 
-	mapData, err := h.vault.Decrypt(c.Request().Context(), credential.Secret)
+	mapData, err := h.secrets.Resolve(c.Request().Context(), credential)
 	if err != nil {
-		h.logger.Error("failed to decrypt secret", zap.Error(err))
+		h.logger.Error("failed to resolve secret", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to decrypt config")
 	}
 
@@ -406,44 +525,7 @@ func (h *API) AddIntegrations(c echo.Context) error {
 		if _, ok := integrationTypeIntegrationsMap[i.ProviderID]; ok {
 			continue
 		}
-		i.IntegrationType = req.IntegrationType
-
-		i.CredentialID = credentialID
-
-		healthcheckTime := time.Now()
-		i.LastCheck = &healthcheckTime
-
-		if i.Labels.Status != pgtype.Present {
-			err = i.Labels.Set("{}")
-			if err != nil {
-				h.logger.Error("failed to set label", zap.Error(err))
-				return echo.NewHTTPError(http.StatusInternalServerError, "failed to set label")
-			}
-		}
-
-		if i.Annotations.Status != pgtype.Present {
-			err = i.Annotations.Set("{}")
-			if err != nil {
-				h.logger.Error("failed to set annotations", zap.Error(err))
-				return echo.NewHTTPError(http.StatusInternalServerError, "failed to set annotations")
-			}
-		}
-
-		iApi, err := i.ToApi()
-		if err != nil {
-			h.logger.Error("failed to create integration api", zap.Error(err))
-			return echo.NewHTTPError(http.StatusInternalServerError, "failed to create integration api")
-		}
-		healthy, err := integrationType.HealthCheck(jsonData, i.ProviderID, iApi.Labels, iApi.Annotations)
-		if err != nil || !healthy {
-			h.logger.Info("integration is not healthy", zap.String("integration_id", i.IntegrationID.String()), zap.Error(err))
-			i.State = integration.IntegrationStateInactive
-		} else {
-			i.State = integration.IntegrationStateActive
-		}
-
-		err = h.database.CreateIntegration(&i)
-		if err != nil {
+		if err := h.onboardIntegration(integrationType, jsonData, req.IntegrationType, credentialID, i); err != nil {
 			h.logger.Error("failed to create integration", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to create integration")
 		}
@@ -469,6 +551,9 @@ func (h *API) IntegrationHealthcheck(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
 	}
+	if err := rbac.CheckIntegrationAccess(c, IntegrationID.String()); err != nil {
+		return err
+	}
 
 	integ, err := h.database.GetIntegration(IntegrationID)
 	if err != nil {
@@ -476,7 +561,16 @@ func (h *API) IntegrationHealthcheck(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration")
 	}
 
+	recorded := false
 	defer func() {
+		if err != nil && integ != nil && !recorded {
+			h.recordHealthCheck(models2.IntegrationHealthCheck{
+				IntegrationID: integ.IntegrationID,
+				CheckedAt:     time.Now(),
+				Status:        models2.IntegrationHealthStatusUnhealthy,
+				Error:         err.Error(),
+			})
+		}
 		if err != nil && integ != nil && integ.State != integration.IntegrationStateArchived {
 			h.logger.Error("healthcheck failed", zap.Error(err))
 			healthcheckTime := time.Now()
@@ -503,9 +597,9 @@ func (h *API) IntegrationHealthcheck(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusNotFound, "credential not found")
 	}
 
-	mapData, err := h.vault.Decrypt(c.Request().Context(), credential.Secret)
+	mapData, err := h.secrets.Resolve(c.Request().Context(), credential)
 	if err != nil {
-		h.logger.Error("failed to decrypt secret", zap.Error(err))
+		h.logger.Error("failed to resolve secret", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to decrypt config")
 	}
 
@@ -530,22 +624,45 @@ func (h *API) IntegrationHealthcheck(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create integration api")
 	}
 
+	checkStart := time.Now()
 	healthy, err := integrationType.HealthCheck(jsonData, integrationApi.ProviderID, integrationApi.Labels, integrationApi.Annotations)
+	check := models2.IntegrationHealthCheck{
+		IntegrationID: integ.IntegrationID,
+		CheckedAt:     checkStart,
+		LatencyMs:     time.Since(checkStart).Milliseconds(),
+		Status:        models2.IntegrationHealthStatusHealthy,
+	}
 	if err != nil || !healthy {
 		h.logger.Error("healthcheck failed", zap.Error(err))
 		if integ.State != integration.IntegrationStateArchived {
 			integ.State = integration.IntegrationStateInactive
 		}
-		_, err = integ.AddAnnotations("platform/integration/health-reason", err.Error())
+		check.Status = models2.IntegrationHealthStatusUnhealthy
+		check.Error = "healthcheck did not pass"
 		if err != nil {
-			h.logger.Error("failed to add annotations", zap.Error(err))
-			return echo.NewHTTPError(http.StatusInternalServerError, "failed to add annotations")
+			check.Error = err.Error()
 		}
 	} else {
 		if integ.State != integration.IntegrationStateArchived {
 			integ.State = integration.IntegrationStateActive
 		}
+		h.checkDiscoveryPermissions(c.Request().Context(), integ, &check)
 	}
+	if check.Status != models2.IntegrationHealthStatusHealthy {
+		_, err = integ.AddAnnotations("platform/integration/health-reason", check.Error)
+		if err != nil {
+			h.logger.Error("failed to add annotations", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to add annotations")
+		}
+	}
+	_, err = integ.AddAnnotations(healthStatusAnnotation, string(check.Status))
+	if err != nil {
+		h.logger.Error("failed to add annotations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to add annotations")
+	}
+	h.recordHealthCheck(check)
+	recorded = true
+
 	healthcheckTime := time.Now()
 	integ.LastCheck = &healthcheckTime
 	err = h.database.UpdateIntegration(integ)
@@ -578,12 +695,18 @@ func (h *API) Delete(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
 	}
+	if err := rbac.CheckIntegrationAccess(c, IntegrationID.String()); err != nil {
+		return err
+	}
 
 	err = h.database.DeleteIntegration(IntegrationID)
 	if err != nil {
 		h.logger.Error("failed to delete credential", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete credential")
 	}
+	if err := h.database.DeleteIntegrationHealthChecks(IntegrationID); err != nil {
+		h.logger.Error("failed to delete integration health history", zap.Error(err))
+	}
 
 	return c.NoContent(http.StatusOK)
 }
@@ -614,6 +737,9 @@ func (h *API) List(c echo.Context) error {
 
 	var items []models.Integration
 	for _, integration := range integrations {
+		if !rbac.HasIntegrationAccess(c, integration.IntegrationID.String()) {
+			continue
+		}
 		item, err := integration.ToApi()
 		if err != nil {
 			h.logger.Error("failed to convert integration to API model", zap.Error(err))
@@ -652,6 +778,9 @@ func (h *API) ListByFilters(c echo.Context) error {
 
 	var items []models.Integration
 	for _, integration := range integrations {
+		if !rbac.HasIntegrationAccess(c, integration.IntegrationID.String()) {
+			continue
+		}
 		item, err := integration.ToApi()
 		if err != nil {
 			h.logger.Error("failed to convert integration to API model", zap.Error(err))
@@ -712,7 +841,12 @@ func (h *API) ListIntegrationGroups(c echo.Context) error {
 
 	var items []models.IntegrationGroup
 	for _, integrationGroup := range integrationGroups {
-		integrationGroupApi, err := entities.NewIntegrationGroup(c.Request().Context(), steampipeConn, integrationGroup)
+		memberIDs, err := h.database.ListIntegrationGroupMemberIDs(integrationGroup.Name)
+		if err != nil {
+			h.logger.Error("failed to list integration group members", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to list integration group members")
+		}
+		integrationGroupApi, err := entities.NewIntegrationGroup(c.Request().Context(), steampipeConn, integrationGroup, memberIDs)
 		if err != nil {
 			h.logger.Error("failed to convert integration group to API model", zap.Error(err))
 			return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert integration group to API model")
@@ -764,6 +898,9 @@ func (h *API) GetIntegrationGroup(c echo.Context) error {
 	}
 
 	integrationGroup, err := h.database.GetIntegrationGroup(integrationGroupName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "integration group not found")
+	}
 	if err != nil {
 		h.logger.Error("failed to list credentials", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list credential")
@@ -775,7 +912,12 @@ func (h *API) GetIntegrationGroup(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get steampipe connection")
 	}
 
-	integrationGroupApi, err := entities.NewIntegrationGroup(c.Request().Context(), steampipeConn, *integrationGroup)
+	memberIDs, err := h.database.ListIntegrationGroupMemberIDs(integrationGroup.Name)
+	if err != nil {
+		h.logger.Error("failed to list integration group members", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list integration group members")
+	}
+	integrationGroupApi, err := entities.NewIntegrationGroup(c.Request().Context(), steampipeConn, *integrationGroup, memberIDs)
 	if err != nil {
 		h.logger.Error("failed to convert integration group to API model", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert integration group to API model")
@@ -816,6 +958,9 @@ func (h *API) Get(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
 	}
+	if err := rbac.CheckIntegrationAccess(c, IntegrationID.String()); err != nil {
+		return err
+	}
 
 	integration, err := h.database.GetIntegration(IntegrationID)
 	if err != nil {
@@ -847,6 +992,9 @@ func (h *API) Update(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
 	}
+	if err := rbac.CheckIntegrationAccess(c, IntegrationID.String()); err != nil {
+		return err
+	}
 
 	var req models.UpdateRequest
 
@@ -866,6 +1014,10 @@ func (h *API) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get credential")
 	}
 
+	if ref, err := credential.GetSecretReference(); err != nil || ref != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "secret of the credential is kept in an external backend, update it there")
+	}
+
 	credentials, err := h.vault.Decrypt(c.Request().Context(), credential.Secret)
 	if err != nil {
 		h.logger.Error("failed to decrypt secret", zap.Error(err))
@@ -903,6 +1055,20 @@ func (h *API) Update(c echo.Context) error {
 		h.logger.Error("failed to update credential", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update credential")
 	}
+	if req.ExpiresAt != nil {
+		err = h.database.UpdateCredentialValidity(integration.CredentialID.String(), credential.IssuedAt, req.ExpiresAt, models2.CredentialExpirySourceManual)
+		if err != nil {
+			h.logger.Error("failed to update credential validity", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to update credential")
+		}
+	} else if credential.ExpirySource != models2.CredentialExpirySourceManual {
+		issuedAt, expiresAt, expirySource := models2.CredentialValidity(credentials)
+		err = h.database.UpdateCredentialValidity(integration.CredentialID.String(), issuedAt, expiresAt, expirySource)
+		if err != nil {
+			h.logger.Error("failed to update credential validity", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to update credential")
+		}
+	}
 
 	return c.NoContent(http.StatusOK)
 }
@@ -1777,6 +1943,9 @@ func (a *API) SetResourceTypesForIntegration(c echo.Context) error {
 		a.logger.Error("failed to parse integration id", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to parse integration id")
 	}
+	if err := rbac.CheckIntegrationAccess(c, integrationID.String()); err != nil {
+		return err
+	}
 
 	req := new(models.SetResourceTypesForIntegration)
 	if err = c.Bind(req); err != nil {
@@ -0,0 +1,302 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/integration"
+	"github.com/opengovern/og-util/pkg/ticker"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	"github.com/opengovern/opensecurity/services/integration/entities"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+)
+
+// syncIntegrationGroup materializes the members of a selector group from the labels and annotations of the integrations
+func (h *API) syncIntegrationGroup(group models2.IntegrationGroup, integrations []models2.Integration) error {
+	var selector models.IntegrationGroupSelector
+	if err := json.Unmarshal(group.Selector.Bytes, &selector); err != nil {
+		return err
+	}
+
+	var memberIDs []uuid.UUID
+	for _, i := range integrations {
+		if i.State == integration.IntegrationStateArchived {
+			continue
+		}
+		apiIntegration, err := i.ToApi()
+		if err != nil {
+			return err
+		}
+		if entities.MatchSelector(selector, apiIntegration.Labels, apiIntegration.Annotations) {
+			memberIDs = append(memberIDs, i.IntegrationID)
+		}
+	}
+
+	joined, left, err := h.database.SetIntegrationGroupMembers(group.Name, memberIDs)
+	if err != nil {
+		return err
+	}
+	if joined > 0 || left > 0 {
+		h.logger.Info("integration group membership changed", zap.String("group", group.Name),
+			zap.Int("joined", joined), zap.Int("left", left))
+	}
+	return nil
+}
+
+// SyncIntegrationGroups materializes the members of all selector groups
+func (h *API) SyncIntegrationGroups() error {
+	groups, err := h.database.ListSelectorIntegrationGroups()
+	if err != nil || len(groups) == 0 {
+		return err
+	}
+	integrations, err := h.database.ListIntegration(nil)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if err := h.syncIntegrationGroup(group, integrations); err != nil {
+			h.logger.Error("failed to sync integration group", zap.String("group", group.Name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// RunIntegrationGroupSync keeps the members of the selector groups up to date with the labels of the integrations
+func (h *API) RunIntegrationGroupSync(ctx context.Context) {
+	t := ticker.NewTicker(time.Minute, time.Second*10)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := h.SyncIntegrationGroups(); err != nil {
+				h.logger.Warn("failed to sync integration groups", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *API) setGroupSelector(group *models2.IntegrationGroup, selector models.IntegrationGroupSelector) error {
+	if err := entities.ValidateSelector(selector); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	selectorJsonb := pgtype.JSONB{}
+	if err := selectorJsonb.Set(selector); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to set selector")
+	}
+	group.Selector = selectorJsonb
+	return nil
+}
+
+func (h *API) integrationGroupResponse(c echo.Context, group models2.IntegrationGroup) error {
+	memberIDs, err := h.database.ListIntegrationGroupMemberIDs(group.Name)
+	if err != nil {
+		h.logger.Error("failed to list integration group members", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list integration group members")
+	}
+	groupApi, err := entities.NewIntegrationGroup(c.Request().Context(), nil, group, memberIDs)
+	if err != nil {
+		h.logger.Error("failed to convert integration group to API model", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert integration group to API model")
+	}
+	return c.JSON(http.StatusOK, groupApi)
+}
+
+// getSelectorIntegrationGroup returns the group, selector groups are the only ones editable through the API
+func (h *API) getSelectorIntegrationGroup(name string) (*models2.IntegrationGroup, error) {
+	group, err := h.database.GetIntegrationGroup(name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "integration group not found")
+	} else if err != nil {
+		h.logger.Error("failed to get integration group", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration group")
+	}
+	if group.Selector.Status != pgtype.Present {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "integration group is defined by a query and managed by the platform")
+	}
+	return group, nil
+}
+
+// CreateIntegrationGroup godoc
+//
+//	@Summary		Create integration group
+//	@Description	Create an integration group whose members are the integrations matching the label and annotation selector
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			request	body		models.CreateIntegrationGroupRequest	true	"Request"
+//	@Success		200		{object}	models.IntegrationGroup
+//	@Router			/integration/api/v1/integrations/integration-groups [post]
+func (h *API) CreateIntegrationGroup(c echo.Context) error {
+	var req models.CreateIntegrationGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+	if req.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	}
+
+	if _, err := h.database.GetIntegrationGroup(req.Name); err == nil {
+		return echo.NewHTTPError(http.StatusConflict, "integration group already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		h.logger.Error("failed to get integration group", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration group")
+	}
+
+	group := models2.IntegrationGroup{Name: req.Name}
+	if err := h.setGroupSelector(&group, req.Selector); err != nil {
+		return err
+	}
+	if err := h.database.CreateIntegrationGroup(&group); err != nil {
+		h.logger.Error("failed to create integration group", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create integration group")
+	}
+
+	integrations, err := h.database.ListIntegration(nil)
+	if err != nil {
+		h.logger.Error("failed to list integrations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list integrations")
+	}
+	if err := h.syncIntegrationGroup(group, integrations); err != nil {
+		h.logger.Error("failed to sync integration group", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to sync integration group")
+	}
+
+	return h.integrationGroupResponse(c, group)
+}
+
+// UpdateIntegrationGroup godoc
+//
+//	@Summary		Update integration group
+//	@Description	Update the selector of an integration group, the members are updated right away
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			integrationGroupName	path		string									true	"integrationGroupName"
+//	@Param			request					body		models.UpdateIntegrationGroupRequest	true	"Request"
+//	@Success		200						{object}	models.IntegrationGroup
+//	@Router			/integration/api/v1/integrations/integration-groups/{integrationGroupName} [put]
+func (h *API) UpdateIntegrationGroup(c echo.Context) error {
+	var req models.UpdateIntegrationGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	group, err := h.getSelectorIntegrationGroup(c.Param("integrationGroupName"))
+	if err != nil {
+		return err
+	}
+	if err := h.setGroupSelector(group, req.Selector); err != nil {
+		return err
+	}
+	if err := h.database.UpdateIntegrationGroup(group); err != nil {
+		h.logger.Error("failed to update integration group", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update integration group")
+	}
+
+	integrations, err := h.database.ListIntegration(nil)
+	if err != nil {
+		h.logger.Error("failed to list integrations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list integrations")
+	}
+	if err := h.syncIntegrationGroup(*group, integrations); err != nil {
+		h.logger.Error("failed to sync integration group", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to sync integration group")
+	}
+
+	return h.integrationGroupResponse(c, *group)
+}
+
+// DeleteIntegrationGroup godoc
+//
+//	@Summary		Delete integration group
+//	@Description	Delete an integration group defined by a selector
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			integrationGroupName	path	string	true	"integrationGroupName"
+//	@Success		200
+//	@Router			/integration/api/v1/integrations/integration-groups/{integrationGroupName} [delete]
+func (h *API) DeleteIntegrationGroup(c echo.Context) error {
+	group, err := h.getSelectorIntegrationGroup(c.Param("integrationGroupName"))
+	if err != nil {
+		return err
+	}
+	if err := h.database.DeleteIntegrationGroup(group.Name); err != nil {
+		h.logger.Error("failed to delete integration group", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete integration group")
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ListIntegrationGroupHistory godoc
+//
+//	@Summary		List integration group membership history
+//	@Description	List the integrations joining and leaving an integration group, latest first
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			integrationGroupName	path		string	true	"integrationGroupName"
+//	@Param			since					query		string	false	"RFC3339 time of the oldest change"
+//	@Param			cursor					query		int		false	"cursor"
+//	@Param			per_page				query		int		false	"per page"
+//	@Success		200						{object}	models.ListIntegrationGroupHistoryResponse
+//	@Router			/integration/api/v1/integrations/integration-groups/{integrationGroupName}/history [get]
+func (h *API) ListIntegrationGroupHistory(c echo.Context) error {
+	name := c.Param("integrationGroupName")
+
+	var since *time.Time
+	if v := c.QueryParam("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid since")
+		}
+		since = &t
+	}
+	perPage, cursor := 100, 1
+	if v := c.QueryParam("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid per_page")
+		}
+		perPage = n
+	}
+	if v := c.QueryParam("cursor"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
+		cursor = n
+	}
+
+	events, total, err := h.database.ListIntegrationGroupMembershipEvents(name, since, perPage, (cursor-1)*perPage)
+	if err != nil {
+		h.logger.Error("failed to list integration group history", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list integration group history")
+	}
+
+	items := make([]models.IntegrationGroupMembershipEvent, 0, len(events))
+	for _, e := range events {
+		items = append(items, models.IntegrationGroupMembershipEvent{
+			IntegrationID: e.IntegrationID.String(),
+			Action:        string(e.Action),
+			CreatedAt:     e.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.ListIntegrationGroupHistoryResponse{
+		Items:      items,
+		TotalCount: total,
+	})
+}
@@ -0,0 +1,185 @@
+package integrations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/opensecurity/pkg/rbac"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+)
+
+const (
+	healthStatusAnnotation = "platform/integration/health-status"
+	// degradedConsecutiveFailures is the number of discovery jobs of a resource type failing in a row on
+	// missing permissions after which a healthy integration is degraded
+	degradedConsecutiveFailures = 3
+)
+
+// checkDiscoveryPermissions degrades the healthcheck when discovery of the integration keeps failing on missing permissions
+func (h *API) checkDiscoveryPermissions(ctx context.Context, integ *models2.Integration, check *models2.IntegrationHealthCheck) {
+	if h.schedulerClient == nil {
+		return
+	}
+	failures, err := h.schedulerClient.GetIntegrationDiscoveryPermissionFailures(&httpclient.Context{Ctx: ctx, UserRole: api.AdminRole}, integ.IntegrationID.String())
+	if err != nil {
+		h.logger.Warn("failed to get discovery permission failures", zap.String("integration_id", integ.IntegrationID.String()), zap.Error(err))
+		return
+	}
+
+	var missing []models.IntegrationMissingPermission
+	for _, f := range failures.Items {
+		if f.ConsecutiveFailures < degradedConsecutiveFailures {
+			continue
+		}
+		missing = append(missing, models.IntegrationMissingPermission{
+			ResourceType: f.ResourceType,
+			ErrorCode:    f.ErrorCode,
+			Message:      f.FailureMessage,
+		})
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	missingJsonb := pgtype.JSONB{}
+	if err := missingJsonb.Set(missing); err != nil {
+		h.logger.Error("failed to set missing permissions", zap.Error(err))
+		return
+	}
+	check.Status = models2.IntegrationHealthStatusDegraded
+	check.Error = fmt.Sprintf("discovery of %d resource types keeps failing on missing permissions", len(missing))
+	check.MissingPermissions = missingJsonb
+}
+
+func (h *API) recordHealthCheck(check models2.IntegrationHealthCheck) {
+	if err := h.database.CreateIntegrationHealthCheck(&check); err != nil {
+		h.logger.Error("failed to record healthcheck", zap.String("integration_id", check.IntegrationID.String()), zap.Error(err))
+	}
+}
+
+// healthUptime returns the share of the window since the first known status the integration was up.
+// statuses are ordered by time, the first one may precede the window.
+func healthUptime(statuses []models2.IntegrationHealthCheck, since, now time.Time) float64 {
+	var covered, up time.Duration
+	for i, s := range statuses {
+		start := s.CheckedAt
+		if start.Before(since) {
+			start = since
+		}
+		end := now
+		if i+1 < len(statuses) {
+			end = statuses[i+1].CheckedAt
+		}
+		if !end.After(start) {
+			continue
+		}
+		covered += end.Sub(start)
+		if s.Status != models2.IntegrationHealthStatusUnhealthy {
+			up += end.Sub(start)
+		}
+	}
+	if covered == 0 {
+		return 0
+	}
+	return float64(up) / float64(covered)
+}
+
+// GetIntegrationHealth godoc
+//
+//	@Summary		Get integration health
+//	@Description	Get the uptime, last healthy time and healthcheck history of an integration
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			IntegrationID	path		string	true	"IntegrationID"
+//	@Param			days			query		int		false	"window in days, defaults to 30"
+//	@Param			cursor			query		int		false	"cursor"
+//	@Param			per_page		query		int		false	"per page"
+//	@Success		200				{object}	models.IntegrationHealthResponse
+//	@Router			/integration/api/v1/integrations/{IntegrationID}/health [get]
+func (h *API) GetIntegrationHealth(c echo.Context) error {
+	integrationID, err := uuid.Parse(c.Param("IntegrationID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid integration id")
+	}
+	if err := rbac.CheckIntegrationAccess(c, integrationID.String()); err != nil {
+		return err
+	}
+	if _, err := h.database.GetIntegration(integrationID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "integration not found")
+	} else if err != nil {
+		h.logger.Error("failed to get integration", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration")
+	}
+
+	days, perPage, cursor := 30, 50, 1
+	for param, target := range map[string]*int{"days": &days, "per_page": &perPage, "cursor": &cursor} {
+		if v := c.QueryParam(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s", param))
+			}
+			*target = n
+		}
+	}
+	if days > 365 {
+		return echo.NewHTTPError(http.StatusBadRequest, "days must be at most 365")
+	}
+
+	now := time.Now()
+	since := now.Add(-time.Duration(days) * 24 * time.Hour)
+
+	statuses, err := h.database.ListIntegrationHealthStatuses(integrationID, since)
+	if err != nil {
+		h.logger.Error("failed to list healthchecks", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list healthchecks")
+	}
+	checks, total, err := h.database.ListIntegrationHealthChecks(integrationID, since, perPage, (cursor-1)*perPage)
+	if err != nil {
+		h.logger.Error("failed to list healthchecks", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list healthchecks")
+	}
+	lastHealthy, err := h.database.GetLastHealthyIntegrationHealthCheck(integrationID)
+	if err != nil {
+		h.logger.Error("failed to get last healthy healthcheck", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get last healthy healthcheck")
+	}
+
+	res := models.IntegrationHealthResponse{
+		IntegrationID: integrationID.String(),
+		Uptime:        healthUptime(statuses, since, now),
+		WindowDays:    days,
+		Items:         make([]models.IntegrationHealthCheck, 0, len(checks)),
+		TotalCount:    total,
+	}
+	if len(statuses) > 0 {
+		last := statuses[len(statuses)-1]
+		res.Status = string(last.Status)
+		res.LastCheckAt = &last.CheckedAt
+	}
+	if lastHealthy != nil {
+		res.LastHealthyAt = &lastHealthy.CheckedAt
+	}
+	for _, check := range checks {
+		item, err := check.ToApi()
+		if err != nil {
+			h.logger.Error("failed to convert healthcheck to API model", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert healthcheck to API model")
+		}
+		res.Items = append(res.Items, item)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,82 @@
+package integrations
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+)
+
+func TestHealthUptime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-10 * time.Hour)
+	check := func(hoursAgo int, status models2.IntegrationHealthStatus) models2.IntegrationHealthCheck {
+		return models2.IntegrationHealthCheck{CheckedAt: now.Add(-time.Duration(hoursAgo) * time.Hour), Status: status}
+	}
+
+	tests := []struct {
+		name     string
+		statuses []models2.IntegrationHealthCheck
+		want     float64
+	}{
+		{name: "no status", want: 0},
+		{
+			name:     "always healthy",
+			statuses: []models2.IntegrationHealthCheck{check(8, models2.IntegrationHealthStatusHealthy)},
+			want:     1,
+		},
+		{
+			name:     "always unhealthy",
+			statuses: []models2.IntegrationHealthCheck{check(8, models2.IntegrationHealthStatusUnhealthy)},
+			want:     0,
+		},
+		{
+			name: "degraded counts as up",
+			statuses: []models2.IntegrationHealthCheck{
+				check(8, models2.IntegrationHealthStatusDegraded),
+				check(4, models2.IntegrationHealthStatusUnhealthy),
+			},
+			want: 0.5,
+		},
+		{
+			name: "window starts at the first status",
+			statuses: []models2.IntegrationHealthCheck{
+				check(4, models2.IntegrationHealthStatusHealthy),
+				check(1, models2.IntegrationHealthStatusUnhealthy),
+			},
+			want: 0.75,
+		},
+		{
+			name: "status before the window is clipped",
+			statuses: []models2.IntegrationHealthCheck{
+				check(20, models2.IntegrationHealthStatusUnhealthy),
+				check(5, models2.IntegrationHealthStatusHealthy),
+			},
+			want: 0.5,
+		},
+		{
+			name: "statuses entirely before the window",
+			statuses: []models2.IntegrationHealthCheck{
+				check(30, models2.IntegrationHealthStatusUnhealthy),
+				check(20, models2.IntegrationHealthStatusHealthy),
+			},
+			want: 1,
+		},
+		{
+			name: "checks at the same time",
+			statuses: []models2.IntegrationHealthCheck{
+				check(2, models2.IntegrationHealthStatusUnhealthy),
+				check(2, models2.IntegrationHealthStatusHealthy),
+			},
+			want: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := healthUptime(tc.statuses, since, now); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("healthUptime() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
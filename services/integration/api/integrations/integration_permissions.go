@@ -0,0 +1,197 @@
+package integrations
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/goccy/go-yaml"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/opensecurity/pkg/rbac"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// deniedActionPatterns extract the denied permission from the discovery errors of the cloud providers
+var deniedActionPatterns = []*regexp.Regexp{
+	// AWS: ... is not authorized to perform: ec2:DescribeInstances on resource ...
+	regexp.MustCompile(`not authorized to perform:? ([A-Za-z0-9-]+:[A-Za-z0-9*]+)`),
+	// Azure: ... does not have authorization to perform action 'Microsoft.Compute/virtualMachines/read' ...
+	regexp.MustCompile(`perform action '([^']+)'`),
+	// GCP: Permission 'compute.instances.list' denied ...
+	regexp.MustCompile(`Permission '([^']+)' denied`),
+}
+
+func deniedActions(message string) []string {
+	var actions []string
+	for _, pattern := range deniedActionPatterns {
+		for _, match := range pattern.FindAllStringSubmatch(message, -1) {
+			actions = append(actions, match[1])
+		}
+	}
+	return actions
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetIntegrationPermissions godoc
+//
+//	@Summary		Get integration permissions
+//	@Description	Compare the permissions the plugin requires for the resource types of the integration with the ones its discovery jobs got denied,
+//	@Description	and report the blind resource types, the missing permissions and the permissions the integration likely does not need.
+//	@Description	Permissions named in the discovery errors are missing, the other ones are only suspected.
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			IntegrationID	path		string	true	"IntegrationID"
+//	@Success		200				{object}	models.IntegrationPermissionsResponse
+//	@Router			/integration/api/v1/integrations/{IntegrationID}/permissions [get]
+func (h *API) GetIntegrationPermissions(c echo.Context) error {
+	integrationID, err := uuid.Parse(c.Param("IntegrationID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid integration id")
+	}
+	if err := rbac.CheckIntegrationAccess(c, integrationID.String()); err != nil {
+		return err
+	}
+	integ, err := h.database.GetIntegration(integrationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "integration not found")
+	} else if err != nil {
+		h.logger.Error("failed to get integration", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration")
+	}
+
+	integrationType, ok := h.typesManager.GetIntegrationTypeMap()[integ.IntegrationType]
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "integration type not found")
+	}
+	conf, err := integrationType.GetConfiguration()
+	if err != nil {
+		h.logger.Error("failed to get integration type configuration", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration type configuration")
+	}
+	var manifest models2.Manifest
+	if err := yaml.Unmarshal(conf.Manifest, &manifest); err != nil {
+		h.logger.Error("failed to parse integration type manifest", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to parse integration type manifest")
+	}
+
+	iApi, err := integ.ToApi()
+	if err != nil {
+		h.logger.Error("failed to convert integration to API model", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to convert integration to API model")
+	}
+	resourceTypes, err := integrationType.GetResourceTypesByLabels(iApi.Labels)
+	if err != nil {
+		h.logger.Error("failed to get resource types", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get resource types")
+	}
+	enabled, err := h.database.GetIntegrationResourcetypes(integrationID.String())
+	if err != nil {
+		h.logger.Error("failed to get integration resource types", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get integration resource types")
+	}
+	discovered := make(map[string]bool)
+	for _, rt := range resourceTypes {
+		discovered[rt.Name] = true
+	}
+	if enabled != nil && len(enabled.ResourceTypes) > 0 {
+		valid := make(map[string]bool)
+		for _, rt := range enabled.ResourceTypes {
+			if discovered[rt] {
+				valid[rt] = true
+			}
+		}
+		discovered = valid
+	}
+
+	failures := make(map[string]models.IntegrationResourceTypePermissions)
+	if h.schedulerClient != nil {
+		res, err := h.schedulerClient.GetIntegrationDiscoveryPermissionFailures(&httpclient.Context{Ctx: c.Request().Context(), UserRole: api.AdminRole}, integrationID.String())
+		if err != nil {
+			h.logger.Error("failed to get discovery permission failures", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get discovery permission failures")
+		}
+		for _, f := range res.Items {
+			lastFailedAt := f.LastFailedAt
+			failures[f.ResourceType] = models.IntegrationResourceTypePermissions{
+				ConsecutiveFailures: f.ConsecutiveFailures,
+				ErrorCode:           f.ErrorCode,
+				FailureMessage:      f.FailureMessage,
+				LastFailedAt:        &lastFailedAt,
+			}
+		}
+	}
+
+	required, missing, suspectedMissing := make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	excess, blind := make(map[string]bool), make(map[string]bool)
+	res := models.IntegrationPermissionsResponse{
+		IntegrationID:       integrationID.String(),
+		IntegrationType:     integ.IntegrationType.String(),
+		PermissionsDeclared: len(manifest.ResourceTypePermissions) > 0,
+		ResourceTypes:       make([]models.IntegrationResourceTypePermissions, 0, len(discovered)),
+	}
+	for _, resourceType := range sortedKeys(discovered) {
+		item := models.IntegrationResourceTypePermissions{
+			ResourceType:        resourceType,
+			RequiredPermissions: manifest.ResourceTypePermissions[resourceType],
+		}
+		if item.RequiredPermissions == nil {
+			item.RequiredPermissions = []string{}
+		}
+		for _, p := range item.RequiredPermissions {
+			required[p] = true
+		}
+		if f, ok := failures[resourceType]; ok {
+			item.Blind = true
+			item.ConsecutiveFailures, item.ErrorCode, item.FailureMessage, item.LastFailedAt = f.ConsecutiveFailures, f.ErrorCode, f.FailureMessage, f.LastFailedAt
+			// the denied permissions named in the error are certain, the declared ones are all suspects otherwise
+			item.MissingPermissions = deniedActions(f.FailureMessage)
+			if len(item.MissingPermissions) == 0 {
+				item.SuspectedMissingPermissions = item.RequiredPermissions
+			}
+			for _, p := range item.MissingPermissions {
+				missing[p] = true
+			}
+			for _, p := range item.SuspectedMissingPermissions {
+				suspectedMissing[p] = true
+			}
+			blind[resourceType] = true
+		}
+		res.ResourceTypes = append(res.ResourceTypes, item)
+	}
+	for resourceType, permissions := range manifest.ResourceTypePermissions {
+		if discovered[resourceType] {
+			continue
+		}
+		for _, p := range permissions {
+			if !required[p] {
+				excess[p] = true
+			}
+		}
+	}
+
+	res.RequiredPermissions = sortedKeys(required)
+	res.MissingPermissions = sortedKeys(missing)
+	for p := range missing {
+		delete(suspectedMissing, p)
+	}
+	res.SuspectedMissingPermissions = sortedKeys(suspectedMissing)
+	res.SuspectedExcessPermissions = sortedKeys(excess)
+	res.BlindResourceTypes = sortedKeys(blind)
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,56 @@
+package integrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeniedActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{
+			name:    "aws",
+			message: "User: arn:aws:iam::123456789012:user/ro is not authorized to perform: ec2:DescribeInstances on resource: *",
+			want:    []string{"ec2:DescribeInstances"},
+		},
+		{
+			name:    "aws without colon",
+			message: "AccessDenied: is not authorized to perform s3:GetBucketPolicy because no identity-based policy allows it",
+			want:    []string{"s3:GetBucketPolicy"},
+		},
+		{
+			name:    "aws wildcard",
+			message: "is not authorized to perform: iam:List* on resource",
+			want:    []string{"iam:List*"},
+		},
+		{
+			name: "azure",
+			message: "The client 'abc' with object id 'abc' does not have authorization to perform action " +
+				"'Microsoft.Compute/virtualMachines/read' over scope '/subscriptions/x'",
+			want: []string{"Microsoft.Compute/virtualMachines/read"},
+		},
+		{
+			name:    "gcp",
+			message: "googleapi: Error 403: Permission 'compute.instances.list' denied on resource 'projects/p'",
+			want:    []string{"compute.instances.list"},
+		},
+		{
+			name: "several actions",
+			message: "Permission 'storage.buckets.list' denied on resource, " +
+				"Permission 'storage.buckets.getIamPolicy' denied on resource",
+			want: []string{"storage.buckets.list", "storage.buckets.getIamPolicy"},
+		},
+		{name: "no action named", message: "AccessDenied: Access Denied", want: nil},
+		{name: "empty", message: "", want: nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := deniedActions(tc.message); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("deniedActions() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,763 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/goccy/go-yaml"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/og-util/pkg/integration"
+	complianceApi "github.com/opengovern/opensecurity/services/compliance/api"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	"github.com/opengovern/opensecurity/services/integration/entities"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"gorm.io/gorm"
+)
+
+const maxManifestSize = 10 << 20
+
+func integrationKey(integrationType integration.Type, providerID string) string {
+	return fmt.Sprintf("%s/%s", integrationType, providerID)
+}
+
+func secretReferenceKey(integrationType integration.Type, ref models.CredentialSecretReference) string {
+	return fmt.Sprintf("%s|%s|%s|%s", integrationType, ref.Backend, ref.Path, ref.Key)
+}
+
+// manifestCredentialNames names the credentials by their description when it is unique, by their id otherwise
+func manifestCredentialNames(credentials []models2.Credential) map[uuid.UUID]string {
+	descriptions := make(map[string]int)
+	for _, c := range credentials {
+		descriptions[c.Description]++
+	}
+	names := make(map[uuid.UUID]string, len(credentials))
+	for _, c := range credentials {
+		if c.Description != "" && descriptions[c.Description] == 1 {
+			names[c.ID] = c.Description
+		} else {
+			names[c.ID] = c.ID.String()
+		}
+	}
+	return names
+}
+
+func manifestIntegration(i models2.Integration) bool {
+	return i.State != integration.IntegrationStateArchived && i.State != integration.IntegrationStateSample
+}
+
+// buildManifest describes the current credentials, integrations, selector groups and explicit framework assignments
+func (h *API) buildManifest(ctx context.Context) (*models.Manifest, error) {
+	manifest := models.Manifest{APIVersion: models.ManifestAPIVersion}
+
+	credentials, err := h.database.ListCredentials()
+	if err != nil {
+		return nil, err
+	}
+	credentialNames := manifestCredentialNames(credentials)
+	for _, c := range credentials {
+		ref, err := c.GetSecretReference()
+		if err != nil {
+			return nil, err
+		}
+		manifest.Credentials = append(manifest.Credentials, models.ManifestCredential{
+			Name:            credentialNames[c.ID],
+			IntegrationType: c.IntegrationType,
+			CredentialType:  c.CredentialType,
+			Description:     c.Description,
+			SecretReference: ref,
+		})
+	}
+
+	integrations, err := h.database.ListIntegration(nil)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]models.ManifestIntegrationRef)
+	for _, i := range integrations {
+		if !manifestIntegration(i) {
+			continue
+		}
+		iApi, err := i.ToApi()
+		if err != nil {
+			return nil, err
+		}
+		refs[i.IntegrationID.String()] = models.ManifestIntegrationRef{IntegrationType: i.IntegrationType, ProviderID: i.ProviderID}
+		manifest.Integrations = append(manifest.Integrations, models.ManifestIntegration{
+			IntegrationType: i.IntegrationType,
+			ProviderID:      i.ProviderID,
+			Name:            i.Name,
+			Credential:      credentialNames[i.CredentialID],
+			Labels:          iApi.Labels,
+		})
+	}
+
+	groups, err := h.database.ListSelectorIntegrationGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		var selector models.IntegrationGroupSelector
+		if err := json.Unmarshal(g.Selector.Bytes, &selector); err != nil {
+			return nil, err
+		}
+		manifest.IntegrationGroups = append(manifest.IntegrationGroups, models.ManifestIntegrationGroup{
+			Name:     g.Name,
+			Selector: selector,
+		})
+	}
+
+	if h.complianceClient != nil {
+		assignments, err := h.complianceClient.ListExplicitFrameworkAssignments(&httpclient.Context{Ctx: ctx, UserRole: api.AdminRole})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list framework assignments: %w", err)
+		}
+		for _, a := range assignments.Items {
+			fa := models.ManifestFrameworkAssignment{
+				FrameworkID:       a.FrameworkID,
+				IntegrationGroups: a.IntegrationGroups,
+			}
+			for _, id := range a.Integrations {
+				if ref, ok := refs[id]; ok {
+					fa.Integrations = append(fa.Integrations, ref)
+				}
+			}
+			if len(fa.Integrations) > 0 || len(fa.IntegrationGroups) > 0 {
+				manifest.FrameworkAssignments = append(manifest.FrameworkAssignments, fa)
+			}
+		}
+	}
+
+	sort.Slice(manifest.Credentials, func(i, j int) bool { return manifest.Credentials[i].Name < manifest.Credentials[j].Name })
+	sort.Slice(manifest.Integrations, func(i, j int) bool {
+		return integrationKey(manifest.Integrations[i].IntegrationType, manifest.Integrations[i].ProviderID) <
+			integrationKey(manifest.Integrations[j].IntegrationType, manifest.Integrations[j].ProviderID)
+	})
+	return &manifest, nil
+}
+
+// ExportManifest godoc
+//
+//	@Summary		Export manifest
+//	@Description	Export the credentials, integrations, integration groups and framework assignments as a manifest, secrets are never exported.
+//	@Description	The manifest describes the whole platform, users scoped to integrations can not export it.
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			format	query		string	false	"yaml or json, defaults to yaml"
+//	@Success		200		{object}	models.Manifest
+//	@Router			/integration/api/v1/integrations/manifest/export [get]
+func (h *API) ExportManifest(c echo.Context) error {
+	manifest, err := h.buildManifest(c.Request().Context())
+	if err != nil {
+		h.logger.Error("failed to build manifest", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to build manifest")
+	}
+
+	switch c.QueryParam("format") {
+	case "json":
+		return c.JSON(http.StatusOK, manifest)
+	case "", "yaml":
+		out, err := yaml.Marshal(manifest)
+		if err != nil {
+			h.logger.Error("failed to marshal manifest", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to marshal manifest")
+		}
+		return c.Blob(http.StatusOK, "application/yaml", out)
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be yaml or json")
+	}
+}
+
+// manifestImport plans, and in apply mode applies, a manifest against the current state
+type manifestImport struct {
+	h       *API
+	ctx     context.Context
+	mode    models.ManifestImportMode
+	changes []models.ManifestChange
+
+	// credentials maps manifest credential names to existing credentials, uuid.Nil for the ones still to create
+	credentials map[string]uuid.UUID
+	// integrations maps integration keys to existing integrations
+	integrations map[string]models2.Integration
+	// declared are the integration keys and group names of the manifest
+	declaredIntegrations map[string]bool
+	declaredGroups       map[string]bool
+	// discovered caches the integrations discovered per credential during apply
+	discovered map[uuid.UUID]map[string]integration.Integration
+}
+
+func newManifestImport(ctx context.Context, h *API, mode models.ManifestImportMode) *manifestImport {
+	return &manifestImport{
+		h:                    h,
+		ctx:                  ctx,
+		mode:                 mode,
+		credentials:          make(map[string]uuid.UUID),
+		declaredIntegrations: make(map[string]bool),
+		declaredGroups:       make(map[string]bool),
+		discovered:           make(map[uuid.UUID]map[string]integration.Integration),
+	}
+}
+
+func (m *manifestImport) apply() bool {
+	return m.mode == models.ManifestImportModeApply
+}
+
+func (m *manifestImport) add(change models.ManifestChange) {
+	if m.mode == models.ManifestImportModeDryRun {
+		change.Fields = nil
+	}
+	m.changes = append(m.changes, change)
+}
+
+func (m *manifestImport) invalid(kind, key, message string) {
+	m.add(models.ManifestChange{Kind: kind, Key: key, Action: models.ManifestChangeActionInvalid, Message: message})
+}
+
+func (m *manifestImport) failed(kind, key string, fields []models.ManifestFieldChange, err error) {
+	m.h.logger.Error("failed to apply manifest change", zap.String("kind", kind), zap.String("key", key), zap.Error(err))
+	m.add(models.ManifestChange{Kind: kind, Key: key, Action: models.ManifestChangeActionFailed, Fields: fields, Message: err.Error()})
+}
+
+func changeAction(exists bool, fields []models.ManifestFieldChange) models.ManifestChangeAction {
+	switch {
+	case !exists:
+		return models.ManifestChangeActionCreate
+	case len(fields) > 0:
+		return models.ManifestChangeActionUpdate
+	default:
+		return models.ManifestChangeActionUnchanged
+	}
+}
+
+func (m *manifestImport) planCredentials(manifest *models.Manifest) error {
+	existing, err := m.h.database.ListCredentials()
+	if err != nil {
+		return err
+	}
+	byReference := make(map[string]models2.Credential)
+	byName := make(map[string][]models2.Credential)
+	for _, c := range existing {
+		ref, err := c.GetSecretReference()
+		if err != nil {
+			return err
+		}
+		if ref != nil {
+			byReference[secretReferenceKey(c.IntegrationType, *ref)] = c
+		}
+		byName[c.ID.String()] = append(byName[c.ID.String()], c)
+		if c.Description != "" {
+			byName[c.Description] = append(byName[c.Description], c)
+		}
+	}
+
+	for _, mc := range manifest.Credentials {
+		const kind = "credential"
+		if mc.Name == "" {
+			m.invalid(kind, mc.Name, "name is required")
+			continue
+		}
+		if _, ok := m.credentials[mc.Name]; ok {
+			m.invalid(kind, mc.Name, "name is declared more than once")
+			continue
+		}
+		if _, ok := m.h.typesManager.GetIntegrationTypeMap()[mc.IntegrationType]; !ok {
+			m.invalid(kind, mc.Name, fmt.Sprintf("unknown integration type %s", mc.IntegrationType))
+			continue
+		}
+
+		var current *models2.Credential
+		if mc.SecretReference != nil {
+			if c, ok := byReference[secretReferenceKey(mc.IntegrationType, *mc.SecretReference)]; ok {
+				current = &c
+			}
+		} else if candidates := byName[mc.Name]; len(candidates) == 1 && candidates[0].IntegrationType == mc.IntegrationType {
+			current = &candidates[0]
+		}
+
+		if current == nil {
+			if mc.SecretReference == nil {
+				m.invalid(kind, mc.Name, "credentials without a secret_reference must already exist, secrets are never part of a manifest")
+				continue
+			}
+			if !m.apply() {
+				if _, err := m.h.secrets.Read(m.ctx, *mc.SecretReference); err != nil {
+					m.invalid(kind, mc.Name, fmt.Sprintf("failed to read referenced secret: %v", err))
+					continue
+				}
+				m.credentials[mc.Name] = uuid.Nil
+				m.add(models.ManifestChange{Kind: kind, Key: mc.Name, Action: models.ManifestChangeActionCreate})
+				continue
+			}
+			credential, _, err := m.h.createReferenceCredential(m.ctx, mc.IntegrationType, mc.CredentialType, mc.Description, *mc.SecretReference)
+			if err != nil {
+				m.failed(kind, mc.Name, nil, err)
+				continue
+			}
+			m.credentials[mc.Name] = credential.ID
+			m.add(models.ManifestChange{Kind: kind, Key: mc.Name, Action: models.ManifestChangeActionCreate})
+			continue
+		}
+
+		m.credentials[mc.Name] = current.ID
+		var fields []models.ManifestFieldChange
+		if mc.Description != current.Description {
+			fields = append(fields, models.ManifestFieldChange{Field: "description", From: current.Description, To: mc.Description})
+		}
+		if mc.CredentialType != current.CredentialType {
+			fields = append(fields, models.ManifestFieldChange{Field: "credential_type", From: current.CredentialType, To: mc.CredentialType})
+		}
+		if m.apply() && len(fields) > 0 {
+			if err := m.h.database.UpdateCredentialDetails(current.ID.String(), mc.CredentialType, mc.Description); err != nil {
+				m.failed(kind, mc.Name, fields, err)
+				continue
+			}
+		}
+		m.add(models.ManifestChange{Kind: kind, Key: mc.Name, Action: changeAction(true, fields), Fields: fields})
+	}
+	return nil
+}
+
+func (m *manifestImport) loadIntegrations() error {
+	integrations, err := m.h.database.ListIntegration(nil)
+	if err != nil {
+		return err
+	}
+	m.integrations = make(map[string]models2.Integration, len(integrations))
+	for _, i := range integrations {
+		if i.State == integration.IntegrationStateSample {
+			continue
+		}
+		m.integrations[integrationKey(i.IntegrationType, i.ProviderID)] = i
+	}
+	return nil
+}
+
+// discover lists the integrations reachable by the credential, once per credential
+func (m *manifestImport) discover(credentialID uuid.UUID) (map[string]integration.Integration, []byte, error) {
+	credential, err := m.h.database.GetCredential(credentialID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	mapData, err := m.h.secrets.Resolve(m.ctx, credential)
+	if err != nil {
+		return nil, nil, err
+	}
+	jsonData, err := json.Marshal(mapData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if discovered, ok := m.discovered[credentialID]; ok {
+		return discovered, jsonData, nil
+	}
+
+	integrationType := m.h.typesManager.GetIntegrationTypeMap()[credential.IntegrationType]
+	if integrationType == nil {
+		return nil, nil, fmt.Errorf("integration type %s is not loaded", credential.IntegrationType)
+	}
+	integrations, err := integrationType.DiscoverIntegrations(jsonData)
+	if err != nil {
+		return nil, nil, err
+	}
+	discovered := make(map[string]integration.Integration, len(integrations))
+	for _, i := range integrations {
+		discovered[i.ProviderID] = i
+	}
+	m.discovered[credentialID] = discovered
+	return discovered, jsonData, nil
+}
+
+func labelsJsonb(labels map[string]string) (pgtype.JSONB, error) {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	jsonb := pgtype.JSONB{}
+	err := jsonb.Set(labels)
+	return jsonb, err
+}
+
+func (m *manifestImport) planIntegrations(manifest *models.Manifest) error {
+	if err := m.loadIntegrations(); err != nil {
+		return err
+	}
+
+	added := make(map[uuid.UUID]int)
+	for _, mi := range manifest.Integrations {
+		const kind = "integration"
+		key := integrationKey(mi.IntegrationType, mi.ProviderID)
+		if mi.ProviderID == "" {
+			m.invalid(kind, key, "provider_id is required")
+			continue
+		}
+		if m.declaredIntegrations[key] {
+			m.invalid(kind, key, "integration is declared more than once")
+			continue
+		}
+		m.declaredIntegrations[key] = true
+		credentialID, ok := m.credentials[mi.Credential]
+		if !ok {
+			m.invalid(kind, key, fmt.Sprintf("credential %s is not declared in the manifest", mi.Credential))
+			continue
+		}
+
+		current, exists := m.integrations[key]
+		if !exists {
+			if !m.apply() {
+				m.add(models.ManifestChange{Kind: kind, Key: key, Action: models.ManifestChangeActionCreate,
+					Message: "the integration is looked up through discovery with the credential on apply"})
+				continue
+			}
+			discovered, jsonData, err := m.discover(credentialID)
+			if err != nil {
+				m.failed(kind, key, nil, fmt.Errorf("failed to discover integrations of credential %s: %w", mi.Credential, err))
+				continue
+			}
+			in, ok := discovered[mi.ProviderID]
+			if !ok {
+				m.failed(kind, key, nil, fmt.Errorf("integration is not discovered by credential %s", mi.Credential))
+				continue
+			}
+			i := models2.Integration{Integration: in}
+			iApi, err := i.ToApi()
+			if err != nil {
+				m.failed(kind, key, nil, err)
+				continue
+			}
+			labels := iApi.Labels
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			for k, v := range mi.Labels {
+				labels[k] = v
+			}
+			if i.Labels, err = labelsJsonb(labels); err != nil {
+				m.failed(kind, key, nil, err)
+				continue
+			}
+			if mi.Name != "" {
+				i.Name = mi.Name
+			}
+			integrationType := m.h.typesManager.GetIntegrationTypeMap()[mi.IntegrationType]
+			if integrationType == nil {
+				m.failed(kind, key, nil, fmt.Errorf("integration type %s is not loaded", mi.IntegrationType))
+				continue
+			}
+			if err := m.h.onboardIntegration(integrationType, jsonData, mi.IntegrationType, credentialID, i); err != nil {
+				m.failed(kind, key, nil, err)
+				continue
+			}
+			added[credentialID]++
+			m.add(models.ManifestChange{Kind: kind, Key: key, Action: models.ManifestChangeActionCreate})
+			continue
+		}
+
+		iApi, err := current.ToApi()
+		if err != nil {
+			m.failed(kind, key, nil, err)
+			continue
+		}
+		update := models2.Integration{Integration: integration.Integration{IntegrationID: current.IntegrationID}}
+		var fields []models.ManifestFieldChange
+		if mi.Name != "" && mi.Name != current.Name {
+			fields = append(fields, models.ManifestFieldChange{Field: "name", From: current.Name, To: mi.Name})
+			update.Name = mi.Name
+		}
+		if credentialID != uuid.Nil && credentialID != current.CredentialID {
+			fields = append(fields, models.ManifestFieldChange{Field: "credential", From: current.CredentialID.String(), To: mi.Credential})
+			update.CredentialID = credentialID
+		}
+		labels := make(map[string]string, len(iApi.Labels)+len(mi.Labels))
+		for k, v := range iApi.Labels {
+			labels[k] = v
+		}
+		for k, v := range mi.Labels {
+			if from, ok := iApi.Labels[k]; !ok || from != v {
+				fields = append(fields, models.ManifestFieldChange{Field: "labels." + k, From: from, To: v})
+				labels[k] = v
+			}
+		}
+		if len(labels) != len(iApi.Labels) || !reflect.DeepEqual(labels, iApi.Labels) {
+			if update.Labels, err = labelsJsonb(labels); err != nil {
+				m.failed(kind, key, fields, err)
+				continue
+			}
+		}
+		if m.apply() && len(fields) > 0 {
+			if err := m.h.database.UpdateIntegration(&update); err != nil {
+				m.failed(kind, key, fields, err)
+				continue
+			}
+		}
+		m.add(models.ManifestChange{Kind: kind, Key: key, Action: changeAction(true, fields), Fields: fields})
+	}
+
+	for credentialID, count := range added {
+		credential, err := m.h.database.GetCredential(credentialID.String())
+		if err != nil {
+			return err
+		}
+		if err := m.h.database.UpdateCredentialIntegrationCount(credentialID.String(), credential.IntegrationCount+count); err != nil {
+			return err
+		}
+	}
+	if m.apply() {
+		return m.loadIntegrations()
+	}
+	return nil
+}
+
+func (m *manifestImport) planIntegrationGroups(manifest *models.Manifest) error {
+	var applied []models2.IntegrationGroup
+	for _, mg := range manifest.IntegrationGroups {
+		const kind = "integration_group"
+		if mg.Name == "" {
+			m.invalid(kind, mg.Name, "name is required")
+			continue
+		}
+		if m.declaredGroups[mg.Name] {
+			m.invalid(kind, mg.Name, "integration group is declared more than once")
+			continue
+		}
+		m.declaredGroups[mg.Name] = true
+		if err := entities.ValidateSelector(mg.Selector); err != nil {
+			m.invalid(kind, mg.Name, err.Error())
+			continue
+		}
+
+		current, err := m.h.database.GetIntegrationGroup(mg.Name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		exists := err == nil
+		var fields []models.ManifestFieldChange
+		if exists {
+			if current.Selector.Status != pgtype.Present {
+				m.invalid(kind, mg.Name, "integration group is defined by a query and managed by the platform")
+				continue
+			}
+			var selector models.IntegrationGroupSelector
+			if err := json.Unmarshal(current.Selector.Bytes, &selector); err != nil {
+				m.failed(kind, mg.Name, nil, err)
+				continue
+			}
+			if !reflect.DeepEqual(selector, mg.Selector) {
+				fields = append(fields, models.ManifestFieldChange{Field: "selector", From: selector, To: mg.Selector})
+			}
+		}
+
+		if m.apply() && (!exists || len(fields) > 0) {
+			group := models2.IntegrationGroup{Name: mg.Name}
+			if err := group.Selector.Set(mg.Selector); err != nil {
+				m.failed(kind, mg.Name, fields, err)
+				continue
+			}
+			if exists {
+				err = m.h.database.UpdateIntegrationGroup(&group)
+			} else {
+				err = m.h.database.CreateIntegrationGroup(&group)
+			}
+			if err != nil {
+				m.failed(kind, mg.Name, fields, err)
+				continue
+			}
+			applied = append(applied, group)
+		}
+		m.add(models.ManifestChange{Kind: kind, Key: mg.Name, Action: changeAction(exists, fields), Fields: fields})
+	}
+
+	if len(applied) == 0 {
+		return nil
+	}
+	integrations, err := m.h.database.ListIntegration(nil)
+	if err != nil {
+		return err
+	}
+	for _, group := range applied {
+		if err := m.h.syncIntegrationGroup(group, integrations); err != nil {
+			m.h.logger.Error("failed to sync integration group", zap.String("group", group.Name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (m *manifestImport) planFrameworkAssignments(manifest *models.Manifest) error {
+	const kind = "framework_assignment"
+	if len(manifest.FrameworkAssignments) == 0 {
+		return nil
+	}
+	if m.h.complianceClient == nil {
+		for _, fa := range manifest.FrameworkAssignments {
+			m.invalid(kind, fa.FrameworkID, "compliance service is not configured")
+		}
+		return nil
+	}
+
+	clientCtx := &httpclient.Context{Ctx: m.ctx, UserRole: api.AdminRole}
+	assignments, err := m.h.complianceClient.ListExplicitFrameworkAssignments(clientCtx)
+	if err != nil {
+		return fmt.Errorf("failed to list framework assignments: %w", err)
+	}
+	current := make(map[string]complianceApi.ExplicitFrameworkAssignment)
+	for _, a := range assignments.Items {
+		current[a.FrameworkID] = a
+	}
+
+	for _, fa := range manifest.FrameworkAssignments {
+		if fa.FrameworkID == "" {
+			m.invalid(kind, fa.FrameworkID, "framework_id is required")
+			continue
+		}
+		existing, exists := current[fa.FrameworkID]
+		assigned := make(map[string]bool)
+		for _, id := range existing.Integrations {
+			assigned[id] = true
+		}
+		for _, g := range existing.IntegrationGroups {
+			assigned["group:"+g] = true
+		}
+
+		var req complianceApi.AddAssignmentsRequest
+		var fields []models.ManifestFieldChange
+		var problems []string
+		for _, ref := range fa.Integrations {
+			key := integrationKey(ref.IntegrationType, ref.ProviderID)
+			i, ok := m.integrations[key]
+			if !ok {
+				if !m.declaredIntegrations[key] || m.apply() {
+					problems = append(problems, fmt.Sprintf("integration %s does not exist", key))
+				} else {
+					fields = append(fields, models.ManifestFieldChange{Field: "integrations", To: key})
+				}
+				continue
+			}
+			if !assigned[i.IntegrationID.String()] {
+				req.Integrations = append(req.Integrations, i.IntegrationID.String())
+				fields = append(fields, models.ManifestFieldChange{Field: "integrations", To: key})
+			}
+		}
+		for _, g := range fa.IntegrationGroups {
+			if !m.declaredGroups[g] {
+				if _, err := m.h.database.GetIntegrationGroup(g); err != nil {
+					problems = append(problems, fmt.Sprintf("integration group %s does not exist", g))
+					continue
+				}
+			}
+			if !assigned["group:"+g] {
+				req.IntegrationGroups = append(req.IntegrationGroups, g)
+				fields = append(fields, models.ManifestFieldChange{Field: "integration_groups", To: g})
+			}
+		}
+		if len(problems) > 0 {
+			if m.apply() {
+				m.failed(kind, fa.FrameworkID, fields, errors.New(fmt.Sprint(problems)))
+			} else {
+				m.invalid(kind, fa.FrameworkID, fmt.Sprint(problems))
+			}
+			continue
+		}
+
+		if m.apply() && (len(req.Integrations) > 0 || len(req.IntegrationGroups) > 0) {
+			if err := m.h.complianceClient.AddFrameworkAssignments(clientCtx, fa.FrameworkID, req); err != nil {
+				m.failed(kind, fa.FrameworkID, fields, err)
+				continue
+			}
+		}
+		action := changeAction(true, fields)
+		if !exists && len(fields) > 0 {
+			action = models.ManifestChangeActionCreate
+		}
+		m.add(models.ManifestChange{Kind: kind, Key: fa.FrameworkID, Action: action, Fields: fields})
+	}
+	return nil
+}
+
+// ImportManifest godoc
+//
+//	@Summary		Import manifest
+//	@Description	Import a YAML or JSON manifest. dry_run validates it and lists the changes, diff adds the changed fields,
+//	@Description	apply makes the changes. Resources missing from the manifest are left untouched, labels are merged.
+//	@Description	apply plans the manifest as diff first and makes no change unless it is valid, the manifest is then planned again
+//	@Description	and each change is made on its own, without a transaction: a change that fails is reported as failed,
+//	@Description	the changes made before it are kept and nothing is rolled back. Importing the manifest again resumes it.
+//	@Description	Users scoped to integrations can not import manifests.
+//	@Security		BearerToken
+//	@Tags			integrations
+//	@Produce		json
+//	@Param			mode	query		string			false	"dry_run, diff or apply, defaults to dry_run"
+//	@Param			request	body		models.Manifest	true	"Manifest"
+//	@Success		200		{object}	models.ImportManifestResponse
+//	@Router			/integration/api/v1/integrations/manifest/import [post]
+func (h *API) ImportManifest(c echo.Context) error {
+	mode := models.ManifestImportMode(c.QueryParam("mode"))
+	switch mode {
+	case "":
+		mode = models.ManifestImportModeDryRun
+	case models.ManifestImportModeDryRun, models.ManifestImportModeDiff, models.ManifestImportModeApply:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "mode must be one of dry_run, diff, apply")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxManifestSize))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "failed to read manifest")
+	}
+	var manifest models.Manifest
+	if err := yaml.Unmarshal(body, &manifest); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid manifest: %v", err))
+	}
+	if manifest.APIVersion != models.ManifestAPIVersion {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("api_version must be %s", models.ManifestAPIVersion))
+	}
+
+	// the manifest is applied only when a dry run of it is valid, the apply step plans it again against the
+	// current state and makes the changes one by one, the ones made before a failure are kept
+	steps := []models.ManifestImportMode{mode}
+	if mode == models.ManifestImportModeApply {
+		steps = []models.ManifestImportMode{models.ManifestImportModeDiff, models.ManifestImportModeApply}
+	}
+	var m *manifestImport
+	for _, step := range steps {
+		m = newManifestImport(c.Request().Context(), h, step)
+		for _, plan := range []func(*models.Manifest) error{
+			m.planCredentials, m.planIntegrations, m.planIntegrationGroups, m.planFrameworkAssignments,
+		} {
+			if err := plan(&manifest); err != nil {
+				h.logger.Error("failed to import manifest", zap.Error(err))
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to import manifest")
+			}
+		}
+		if !m.valid() {
+			break
+		}
+	}
+
+	res := models.ImportManifestResponse{
+		Mode:    mode,
+		Valid:   m.valid(),
+		Applied: m.apply(),
+		Changes: m.changes,
+	}
+	if res.Changes == nil {
+		res.Changes = []models.ManifestChange{}
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (m *manifestImport) valid() bool {
+	for _, change := range m.changes {
+		if change.Action == models.ManifestChangeActionInvalid {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,308 @@
+package integrations
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	idocker "github.com/opengovern/og-util/pkg/dockertest"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/og-util/pkg/integration"
+	"github.com/opengovern/og-util/pkg/integration/interfaces"
+	complianceApi "github.com/opengovern/opensecurity/services/compliance/api"
+	complianceClient "github.com/opengovern/opensecurity/services/compliance/client"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	"github.com/opengovern/opensecurity/services/integration/db"
+	integration_type "github.com/opengovern/opensecurity/services/integration/integration-type"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"github.com/ory/dockertest/v3"
+	"go.uber.org/zap"
+)
+
+const testIntegrationType = integration.Type("aws_cloud_account")
+
+var manifestImportModes = []models.ManifestImportMode{
+	models.ManifestImportModeDryRun, models.ManifestImportModeDiff, models.ManifestImportModeApply,
+}
+
+// expectedChanges drops the fields of the changes in dry_run mode, like manifestImport.add
+func expectedChanges(mode models.ManifestImportMode, changes []models.ManifestChange) []models.ManifestChange {
+	if mode != models.ManifestImportModeDryRun {
+		return changes
+	}
+	out := make([]models.ManifestChange, 0, len(changes))
+	for _, change := range changes {
+		change.Fields = nil
+		out = append(out, change)
+	}
+	return out
+}
+
+type fakeFrameworkAssignmentClient struct {
+	complianceClient.ComplianceServiceClient
+
+	assignments []complianceApi.ExplicitFrameworkAssignment
+	added       map[string]complianceApi.AddAssignmentsRequest
+}
+
+func (c *fakeFrameworkAssignmentClient) ListExplicitFrameworkAssignments(*httpclient.Context) (*complianceApi.ListExplicitFrameworkAssignmentsResponse, error) {
+	return &complianceApi.ListExplicitFrameworkAssignmentsResponse{Items: c.assignments, TotalCount: len(c.assignments)}, nil
+}
+
+func (c *fakeFrameworkAssignmentClient) AddFrameworkAssignments(_ *httpclient.Context, frameworkID string, req complianceApi.AddAssignmentsRequest) error {
+	c.added[frameworkID] = req
+	return nil
+}
+
+func TestPlanFrameworkAssignments(t *testing.T) {
+	assigned, unassigned := uuid.New(), uuid.New()
+	manifest := &models.Manifest{FrameworkAssignments: []models.ManifestFrameworkAssignment{
+		{
+			FrameworkID: "aws_cis",
+			Integrations: []models.ManifestIntegrationRef{
+				{IntegrationType: testIntegrationType, ProviderID: "111"},
+				{IntegrationType: testIntegrationType, ProviderID: "222"},
+			},
+			IntegrationGroups: []string{"production"},
+		},
+		{FrameworkID: "aws_nist", Integrations: []models.ManifestIntegrationRef{{IntegrationType: testIntegrationType, ProviderID: "333"}}},
+		{FrameworkID: "aws_foundational", Integrations: []models.ManifestIntegrationRef{{IntegrationType: testIntegrationType, ProviderID: "111"}}},
+		{Integrations: []models.ManifestIntegrationRef{{IntegrationType: testIntegrationType, ProviderID: "111"}}},
+	}}
+
+	tests := []struct {
+		mode      models.ManifestImportMode
+		want      []models.ManifestChange
+		wantAdded map[string]complianceApi.AddAssignmentsRequest
+	}{
+		{
+			mode: models.ManifestImportModeDryRun,
+			want: []models.ManifestChange{
+				{Kind: "framework_assignment", Key: "aws_cis", Action: models.ManifestChangeActionUpdate},
+				{Kind: "framework_assignment", Key: "aws_nist", Action: models.ManifestChangeActionCreate},
+				{Kind: "framework_assignment", Key: "aws_foundational", Action: models.ManifestChangeActionUnchanged},
+				{Kind: "framework_assignment", Action: models.ManifestChangeActionInvalid, Message: "framework_id is required"},
+			},
+			wantAdded: map[string]complianceApi.AddAssignmentsRequest{},
+		},
+		{
+			mode: models.ManifestImportModeDiff,
+			want: []models.ManifestChange{
+				{Kind: "framework_assignment", Key: "aws_cis", Action: models.ManifestChangeActionUpdate, Fields: []models.ManifestFieldChange{
+					{Field: "integrations", To: "aws_cloud_account/222"},
+					{Field: "integration_groups", To: "production"},
+				}},
+				{Kind: "framework_assignment", Key: "aws_nist", Action: models.ManifestChangeActionCreate, Fields: []models.ManifestFieldChange{
+					{Field: "integrations", To: "aws_cloud_account/333"},
+				}},
+				{Kind: "framework_assignment", Key: "aws_foundational", Action: models.ManifestChangeActionUnchanged},
+				{Kind: "framework_assignment", Action: models.ManifestChangeActionInvalid, Message: "framework_id is required"},
+			},
+			wantAdded: map[string]complianceApi.AddAssignmentsRequest{},
+		},
+		{
+			mode: models.ManifestImportModeApply,
+			want: []models.ManifestChange{
+				{Kind: "framework_assignment", Key: "aws_cis", Action: models.ManifestChangeActionUpdate, Fields: []models.ManifestFieldChange{
+					{Field: "integrations", To: "aws_cloud_account/222"},
+					{Field: "integration_groups", To: "production"},
+				}},
+				// the integration is declared but was not onboarded by the integrations step of the apply
+				{Kind: "framework_assignment", Key: "aws_nist", Action: models.ManifestChangeActionFailed,
+					Message: "[integration aws_cloud_account/333 does not exist]"},
+				{Kind: "framework_assignment", Key: "aws_foundational", Action: models.ManifestChangeActionUnchanged},
+				{Kind: "framework_assignment", Action: models.ManifestChangeActionInvalid, Message: "framework_id is required"},
+			},
+			wantAdded: map[string]complianceApi.AddAssignmentsRequest{
+				"aws_cis": {Integrations: []string{unassigned.String()}, IntegrationGroups: []string{"production"}},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			client := &fakeFrameworkAssignmentClient{
+				assignments: []complianceApi.ExplicitFrameworkAssignment{
+					{FrameworkID: "aws_cis", Integrations: []string{assigned.String()}},
+					{FrameworkID: "aws_foundational", Integrations: []string{assigned.String()}},
+				},
+				added: make(map[string]complianceApi.AddAssignmentsRequest),
+			}
+			m := newManifestImport(context.Background(), &API{logger: zap.NewNop(), complianceClient: client}, tc.mode)
+			m.integrations = map[string]models2.Integration{
+				"aws_cloud_account/111": {Integration: integration.Integration{IntegrationID: assigned}},
+				"aws_cloud_account/222": {Integration: integration.Integration{IntegrationID: unassigned}},
+			}
+			m.declaredIntegrations["aws_cloud_account/333"] = true
+			m.declaredGroups["production"] = true
+
+			if err := m.planFrameworkAssignments(manifest); err != nil {
+				t.Fatalf("planFrameworkAssignments() error = %v", err)
+			}
+			if !reflect.DeepEqual(m.changes, tc.want) {
+				t.Errorf("changes = %+v, want %+v", m.changes, tc.want)
+			}
+			if !reflect.DeepEqual(client.added, tc.wantAdded) {
+				t.Errorf("added assignments = %+v, want %+v", client.added, tc.wantAdded)
+			}
+		})
+	}
+}
+
+// newManifestTestAPI returns an API on a fresh database, the test is skipped when docker is not available
+func newManifestTestAPI(t *testing.T) *API {
+	t.Helper()
+	if pool, err := dockertest.NewPool(""); err != nil || pool.Client.Ping() != nil {
+		t.Skip("docker is not available")
+	}
+	orm := idocker.StartupPostgreSQL(t)
+	if err := orm.AutoMigrate(&models2.Credential{}, &models2.Integration{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+	return &API{
+		logger:       zap.NewNop(),
+		database:     db.Database{Orm: orm},
+		typesManager: &integration_type.IntegrationTypeManager{IntegrationTypes: map[integration.Type]interfaces.IntegrationType{testIntegrationType: nil}},
+	}
+}
+
+func createTestCredential(t *testing.T, h *API, credentialType, description string) models2.Credential {
+	t.Helper()
+	credential := models2.Credential{
+		IntegrationType: testIntegrationType,
+		CredentialType:  credentialType,
+		Description:     description,
+		Metadata:        pgtype.JSONB{Status: pgtype.Null},
+		MaskedSecret:    pgtype.JSONB{Status: pgtype.Null},
+	}
+	if err := h.database.CreateCredential(&credential); err != nil {
+		t.Fatalf("CreateCredential() error = %v", err)
+	}
+	return credential
+}
+
+func TestPlanCredentials(t *testing.T) {
+	manifest := &models.Manifest{Credentials: []models.ManifestCredential{
+		{Name: "production", IntegrationType: testIntegrationType, CredentialType: "assume_role", Description: "production"},
+		{Name: "production", IntegrationType: testIntegrationType, CredentialType: "assume_role", Description: "production"},
+		{Name: "staging", IntegrationType: testIntegrationType},
+		{Name: "other", IntegrationType: "unknown_type"},
+		{IntegrationType: testIntegrationType},
+	}}
+	changes := []models.ManifestChange{
+		{Kind: "credential", Key: "production", Action: models.ManifestChangeActionUpdate, Fields: []models.ManifestFieldChange{
+			{Field: "credential_type", From: "access_key", To: "assume_role"},
+		}},
+		{Kind: "credential", Key: "production", Action: models.ManifestChangeActionInvalid, Message: "name is declared more than once"},
+		{Kind: "credential", Key: "staging", Action: models.ManifestChangeActionInvalid,
+			Message: "credentials without a secret_reference must already exist, secrets are never part of a manifest"},
+		{Kind: "credential", Key: "other", Action: models.ManifestChangeActionInvalid, Message: "unknown integration type unknown_type"},
+		{Kind: "credential", Action: models.ManifestChangeActionInvalid, Message: "name is required"},
+	}
+
+	h := newManifestTestAPI(t)
+	for _, mode := range manifestImportModes {
+		t.Run(string(mode), func(t *testing.T) {
+			credential := createTestCredential(t, h, "access_key", "production")
+			defer h.database.Orm.Unscoped().Delete(&credential)
+
+			m := newManifestImport(context.Background(), h, mode)
+			if err := m.planCredentials(manifest); err != nil {
+				t.Fatalf("planCredentials() error = %v", err)
+			}
+			if want := expectedChanges(mode, changes); !reflect.DeepEqual(m.changes, want) {
+				t.Errorf("changes = %+v, want %+v", m.changes, want)
+			}
+			if m.credentials["production"] != credential.ID {
+				t.Errorf("credentials[production] = %s, want %s", m.credentials["production"], credential.ID)
+			}
+
+			current, err := h.database.GetCredential(credential.ID.String())
+			if err != nil {
+				t.Fatalf("GetCredential() error = %v", err)
+			}
+			want := "access_key"
+			if mode == models.ManifestImportModeApply {
+				want = "assume_role"
+			}
+			if current.CredentialType != want {
+				t.Errorf("credential type = %s, want %s", current.CredentialType, want)
+			}
+		})
+	}
+}
+
+func TestPlanIntegrations(t *testing.T) {
+	h := newManifestTestAPI(t)
+	credential := createTestCredential(t, h, "access_key", "production")
+
+	for _, mode := range manifestImportModes {
+		t.Run(string(mode), func(t *testing.T) {
+			labels, err := labelsJsonb(map[string]string{"env": "dev", "team": "platform"})
+			if err != nil {
+				t.Fatalf("labelsJsonb() error = %v", err)
+			}
+			current := models2.Integration{Integration: integration.Integration{
+				ProviderID:      "111",
+				Name:            "old name",
+				IntegrationType: testIntegrationType,
+				Annotations:     pgtype.JSONB{Status: pgtype.Null},
+				Labels:          labels,
+				CredentialID:    credential.ID,
+				State:           integration.IntegrationStateActive,
+			}}
+			if err := h.database.CreateIntegration(&current); err != nil {
+				t.Fatalf("CreateIntegration() error = %v", err)
+			}
+			defer h.database.Orm.Unscoped().Delete(&current)
+
+			manifest := &models.Manifest{Integrations: []models.ManifestIntegration{
+				{IntegrationType: testIntegrationType, ProviderID: "111", Name: "new name", Credential: "production", Labels: map[string]string{"env": "prod"}},
+				{IntegrationType: testIntegrationType, ProviderID: "111", Credential: "production"},
+				{IntegrationType: testIntegrationType, ProviderID: "444", Credential: "staging"},
+				{IntegrationType: testIntegrationType, Credential: "production"},
+			}}
+			changes := []models.ManifestChange{
+				{Kind: "integration", Key: "aws_cloud_account/111", Action: models.ManifestChangeActionUpdate, Fields: []models.ManifestFieldChange{
+					{Field: "name", From: "old name", To: "new name"},
+					{Field: "labels.env", From: "dev", To: "prod"},
+				}},
+				{Kind: "integration", Key: "aws_cloud_account/111", Action: models.ManifestChangeActionInvalid, Message: "integration is declared more than once"},
+				{Kind: "integration", Key: "aws_cloud_account/444", Action: models.ManifestChangeActionInvalid, Message: "credential staging is not declared in the manifest"},
+				{Kind: "integration", Key: "aws_cloud_account/", Action: models.ManifestChangeActionInvalid, Message: "provider_id is required"},
+			}
+			// creating an integration discovers it with the credential, only the plan of it is covered here
+			if mode != models.ManifestImportModeApply {
+				manifest.Integrations = append(manifest.Integrations, models.ManifestIntegration{IntegrationType: testIntegrationType, ProviderID: "222", Credential: "production"})
+				changes = append(changes, models.ManifestChange{Kind: "integration", Key: "aws_cloud_account/222", Action: models.ManifestChangeActionCreate,
+					Message: "the integration is looked up through discovery with the credential on apply"})
+			}
+
+			m := newManifestImport(context.Background(), h, mode)
+			m.credentials["production"] = credential.ID
+			if err := m.planIntegrations(manifest); err != nil {
+				t.Fatalf("planIntegrations() error = %v", err)
+			}
+			if want := expectedChanges(mode, changes); !reflect.DeepEqual(m.changes, want) {
+				t.Errorf("changes = %+v, want %+v", m.changes, want)
+			}
+
+			updated, err := h.database.GetIntegration(current.IntegrationID)
+			if err != nil {
+				t.Fatalf("GetIntegration() error = %v", err)
+			}
+			iApi, err := updated.ToApi()
+			if err != nil {
+				t.Fatalf("ToApi() error = %v", err)
+			}
+			wantName, wantLabels := "old name", map[string]string{"env": "dev", "team": "platform"}
+			if mode == models.ManifestImportModeApply {
+				wantName, wantLabels = "new name", map[string]string{"env": "prod", "team": "platform"}
+			}
+			if updated.Name != wantName || !reflect.DeepEqual(iApi.Labels, wantLabels) {
+				t.Errorf("integration = %s %v, want %s %v", updated.Name, iApi.Labels, wantName, wantLabels)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package integrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	authApi "github.com/opengovern/og-util/pkg/api"
+	"github.com/opengovern/og-util/pkg/httpclient"
+	"github.com/opengovern/og-util/pkg/integration"
+	"github.com/opengovern/og-util/pkg/integration/interfaces"
+	"github.com/opengovern/og-util/pkg/ticker"
+	"github.com/opengovern/opensecurity/services/integration/entities"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	schedulerapi "github.com/opengovern/opensecurity/services/scheduler/api"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+// onboardIntegration health checks and stores an integration discovered by the credential
+func (h *API) onboardIntegration(integrationType interfaces.IntegrationType, jsonData []byte, it integration.Type, credentialID uuid.UUID, i models2.Integration) error {
+	i.IntegrationType = it
+	i.CredentialID = credentialID
+
+	healthcheckTime := time.Now()
+	i.LastCheck = &healthcheckTime
+
+	if i.Labels.Status != pgtype.Present {
+		if err := i.Labels.Set("{}"); err != nil {
+			return fmt.Errorf("failed to set label: %w", err)
+		}
+	}
+	if i.Annotations.Status != pgtype.Present {
+		if err := i.Annotations.Set("{}"); err != nil {
+			return fmt.Errorf("failed to set annotations: %w", err)
+		}
+	}
+
+	iApi, err := i.ToApi()
+	if err != nil {
+		return fmt.Errorf("failed to create integration api: %w", err)
+	}
+	healthy, err := integrationType.HealthCheck(jsonData, i.ProviderID, iApi.Labels, iApi.Annotations)
+	if err != nil || !healthy {
+		h.logger.Info("integration is not healthy", zap.String("integration_id", i.IntegrationID.String()), zap.Error(err))
+		i.State = integration.IntegrationStateInactive
+	} else {
+		i.State = integration.IntegrationStateActive
+	}
+
+	return h.database.CreateIntegration(&i)
+}
+
+// restoreIntegration brings back an archived integration discovered again by its credential
+func (h *API) restoreIntegration(integrationType interfaces.IntegrationType, jsonData []byte, i models2.Integration) error {
+	iApi, err := i.ToApi()
+	if err != nil {
+		return err
+	}
+	healthcheckTime := time.Now()
+	i.LastCheck = &healthcheckTime
+	healthy, err := integrationType.HealthCheck(jsonData, i.ProviderID, iApi.Labels, iApi.Annotations)
+	if err != nil || !healthy {
+		i.State = integration.IntegrationStateInactive
+	} else {
+		i.State = integration.IntegrationStateActive
+	}
+	return h.database.UpdateIntegration(&i)
+}
+
+// onboardingMatcher tells whether a discovered integration is added by a matching policy
+func onboardingMatcher(policy models2.CredentialOnboardingPolicy) (func(models2.Integration) (bool, error), error) {
+	var namePattern *regexp.Regexp
+	if policy.NamePattern != "" {
+		var err error
+		if namePattern, err = regexp.Compile(policy.NamePattern); err != nil {
+			return nil, err
+		}
+	}
+	selector, err := policy.GetLabelSelector()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(i models2.Integration) (bool, error) {
+		if namePattern != nil && !namePattern.MatchString(i.Name) {
+			return false, nil
+		}
+		if selector == nil {
+			return true, nil
+		}
+		iApi, err := i.ToApi()
+		if err != nil {
+			return false, err
+		}
+		return entities.MatchSelector(*selector, iApi.Labels, iApi.Annotations), nil
+	}, nil
+}
+
+// rediscoverCredential discovers the integrations reachable by the credential and applies its onboarding policy
+func (h *API) rediscoverCredential(ctx context.Context, policy models2.CredentialOnboardingPolicy) error {
+	credential, err := h.database.GetCredential(policy.CredentialID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get credential: %w", err)
+	}
+
+	integrationType := h.typesManager.GetIntegrationTypeMap()[credential.IntegrationType]
+	if integrationType == nil {
+		return fmt.Errorf("integration type %s is not loaded", credential.IntegrationType)
+	}
+	plugin, err := h.database.GetPluginByID(credential.IntegrationType.String())
+	if err != nil {
+		return fmt.Errorf("failed to get plugin: %w", err)
+	}
+	if plugin.OperationalStatus != models2.IntegrationPluginOperationalStatusEnabled ||
+		plugin.InstallState == models2.IntegrationTypeInstallStateNotInstalled {
+		return fmt.Errorf("integration type %s is not enabled", credential.IntegrationType)
+	}
+
+	mapData, err := h.secrets.Resolve(ctx, credential)
+	if err != nil {
+		return fmt.Errorf("failed to resolve secret: %w", err)
+	}
+	jsonData, err := json.Marshal(mapData)
+	if err != nil {
+		return err
+	}
+
+	discovered, err := integrationType.DiscoverIntegrations(jsonData)
+	if err != nil {
+		return fmt.Errorf("failed to discover integrations: %w", err)
+	}
+
+	matches := func(models2.Integration) (bool, error) { return true, nil }
+	if policy.Mode == models2.CredentialOnboardingModeMatching {
+		if matches, err = onboardingMatcher(policy); err != nil {
+			return err
+		}
+	}
+
+	owned, err := h.database.ListIntegrationsByCredential(credential.ID.String())
+	if err != nil {
+		return err
+	}
+	ownedByProviderID := make(map[string]models2.Integration, len(owned))
+	for _, i := range owned {
+		ownedByProviderID[i.ProviderID] = i
+	}
+	typeIntegrations, err := h.database.ListIntegration([]integration.Type{credential.IntegrationType})
+	if err != nil {
+		return err
+	}
+	knownProviderIDs := make(map[string]bool, len(typeIntegrations))
+	for _, i := range typeIntegrations {
+		knownProviderIDs[i.ProviderID] = true
+	}
+
+	seen := make(map[string]bool, len(discovered))
+	var pending []models2.PendingIntegration
+	added := 0
+	for _, in := range discovered {
+		i := models2.Integration{Integration: in}
+		seen[i.ProviderID] = true
+
+		if existing, ok := ownedByProviderID[i.ProviderID]; ok {
+			if existing.State == integration.IntegrationStateArchived {
+				if err := h.restoreIntegration(integrationType, jsonData, existing); err != nil {
+					return fmt.Errorf("failed to restore integration %s: %w", existing.IntegrationID, err)
+				}
+				h.logger.Info("integration discovered again and restored", zap.String("integration_id", existing.IntegrationID.String()))
+			}
+			continue
+		}
+		if knownProviderIDs[i.ProviderID] {
+			continue
+		}
+
+		onboard := policy.Mode != models2.CredentialOnboardingModeNotify
+		if onboard {
+			if onboard, err = matches(i); err != nil {
+				return err
+			}
+		}
+		if !onboard {
+			pending = append(pending, models2.PendingIntegration{ProviderID: i.ProviderID, Name: i.Name})
+			continue
+		}
+		if err := h.onboardIntegration(integrationType, jsonData, credential.IntegrationType, credential.ID, i); err != nil {
+			return fmt.Errorf("failed to onboard integration %s: %w", i.ProviderID, err)
+		}
+		h.logger.Info("integration onboarded automatically", zap.String("credential_id", credential.ID.String()),
+			zap.String("provider_id", i.ProviderID), zap.String("name", i.Name))
+		added++
+	}
+
+	// an empty discovery is more likely a broken credential than an empty scope, nothing is archived then
+	if policy.ArchiveMissing && len(discovered) > 0 {
+		var missing []uuid.UUID
+		for _, i := range owned {
+			if !seen[i.ProviderID] && i.State != integration.IntegrationStateArchived {
+				missing = append(missing, i.IntegrationID)
+			}
+		}
+		if err := h.database.ArchiveIntegrations(missing); err != nil {
+			return fmt.Errorf("failed to archive integrations: %w", err)
+		}
+		if len(missing) > 0 {
+			h.logger.Info("integrations no longer discovered archived", zap.String("credential_id", credential.ID.String()),
+				zap.Int("count", len(missing)))
+		}
+	}
+
+	newPending, err := h.database.SetPendingIntegrations(credential.ID, pending)
+	if err != nil {
+		return err
+	}
+	for _, p := range newPending {
+		h.logger.Warn("new integration pending onboarding", zap.String("credential_id", credential.ID.String()),
+			zap.String("provider_id", p.ProviderID), zap.String("name", p.Name))
+	}
+	h.notifyPendingIntegrations(ctx, credential.ID.String(), credential.IntegrationType, newPending)
+
+	if added > 0 {
+		if err := h.database.UpdateCredentialIntegrationCount(credential.ID.String(), credential.IntegrationCount+added); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyPendingIntegrations sends the new pending integrations to the notification rules subscribed to them,
+// a failure is only logged as the integrations stay listed as pending
+func (h *API) notifyPendingIntegrations(ctx context.Context, credentialID string, integrationType integration.Type, pending []models2.PendingIntegration) {
+	if len(pending) == 0 || h.schedulerClient == nil {
+		return
+	}
+	request := schedulerapi.NotifyPendingIntegrationsRequest{
+		CredentialID:    credentialID,
+		IntegrationType: integrationType.String(),
+	}
+	for _, p := range pending {
+		request.Integrations = append(request.Integrations, schedulerapi.PendingIntegration{ProviderID: p.ProviderID, Name: p.Name})
+	}
+	if _, err := h.schedulerClient.NotifyPendingIntegrations(&httpclient.Context{Ctx: ctx, UserRole: authApi.AdminRole}, request); err != nil {
+		h.logger.Error("failed to notify pending integrations", zap.String("credential_id", credentialID), zap.Error(err))
+	}
+}
+
+// RunAutoOnboarding periodically rediscovers the integrations of the credentials having an onboarding policy
+func (h *API) RunAutoOnboarding(ctx context.Context) {
+	t := ticker.NewTicker(time.Minute, time.Second*10)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			policies, err := h.database.ListCredentialOnboardingPolicies()
+			if err != nil {
+				h.logger.Warn("failed to list onboarding policies", zap.Error(err))
+				continue
+			}
+			for _, policy := range policies {
+				now := time.Now()
+				if policy.LastDiscoveryAt != nil &&
+					now.Before(policy.LastDiscoveryAt.Add(time.Duration(policy.IntervalMinutes)*time.Minute)) {
+					continue
+				}
+				discoveryError := ""
+				if err := h.rediscoverCredential(ctx, policy); err != nil {
+					h.logger.Error("failed to rediscover credential", zap.String("credential_id", policy.CredentialID.String()), zap.Error(err))
+					discoveryError = err.Error()
+				}
+				if err := h.database.UpdateCredentialOnboardingRun(policy.CredentialID, now, discoveryError); err != nil {
+					h.logger.Error("failed to update onboarding policy", zap.Error(err))
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
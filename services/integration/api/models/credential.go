@@ -16,6 +16,26 @@ type Credential struct {
 	Description     string            `json:"description"`
 	CreatedAt       time.Time         `json:"created_at"`
 	UpdatedAt       time.Time         `json:"updated_at"`
+
+	IssuedAt        *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	ExpirySource    string     `json:"expiry_source,omitempty" enums:"certificate,token,manual,plugin"`
+	RotatedAt       *time.Time `json:"rotated_at,omitempty"`
+	RotationState   string     `json:"rotation_state,omitempty" enums:"verifying,verified,rolled_back"`
+	RotationMessage string     `json:"rotation_message,omitempty"`
+
+	// SecretReference is set for credentials whose secret is kept in an external backend
+	SecretReference *CredentialSecretReference `json:"secret_reference,omitempty"`
+}
+
+// CredentialSecretReference points at a secret in an external backend, resolved each time the secret is used.
+// Path is the KV v2 path for vault, "namespace/name" or "name" of a Kubernetes secret for kubernetes,
+// and a JSON or YAML file, or a directory with a file per key, for file.
+// Key optionally selects a single value of the secret holding the credentials as a JSON object.
+type CredentialSecretReference struct {
+	Backend string `json:"backend" enums:"vault,kubernetes,file"`
+	Path    string `json:"path"`
+	Key     string `json:"key,omitempty"`
 }
 
 type ListCredentialsRequest struct {
@@ -26,9 +46,45 @@ type ListCredentialsRequest struct {
 type UpdateCredentialRequest struct {
 	Credentials map[string]any `json:"credentials"`
 	Description string         `json:"description"`
+	// ExpiresAt sets the expiry of the credential instead of deriving it from the certificates and tokens of the secret
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RotateCredentialRequest struct {
+	Credentials map[string]any `json:"credentials"`
+	Description string         `json:"description"`
+	// ExpiresAt overrides the expiry derived from the certificates and tokens of the new secret
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 type ListCredentialsResponse struct {
 	Credentials []Credential `json:"credentials"`
 	TotalCount  int          `json:"total_count"`
 }
+
+// CredentialOnboardingPolicy makes the platform rediscover the integrations reachable by the credential.
+// Mode all adds every new integration, matching adds the ones whose name matches NamePattern and whose labels
+// and annotations match LabelSelector, notify only lists them as pending.
+type CredentialOnboardingPolicy struct {
+	Mode          string                    `json:"mode" enums:"all,matching,notify"`
+	NamePattern   string                    `json:"name_pattern,omitempty"`
+	LabelSelector *IntegrationGroupSelector `json:"label_selector,omitempty"`
+	// ArchiveMissing archives the integrations of the credential that are no longer discovered, defaults to false
+	ArchiveMissing  bool `json:"archive_missing"`
+	IntervalMinutes int  `json:"interval_minutes"`
+
+	LastDiscoveryAt    *time.Time `json:"last_discovery_at,omitempty"`
+	LastDiscoveryError string     `json:"last_discovery_error,omitempty"`
+}
+
+type PendingIntegration struct {
+	ProviderID  string    `json:"provider_id"`
+	Name        string    `json:"name"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+type ListPendingIntegrationsResponse struct {
+	Items      []PendingIntegration `json:"items"`
+	TotalCount int                  `json:"total_count"`
+}
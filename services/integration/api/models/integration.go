@@ -20,6 +20,11 @@ type DiscoverIntegrationRequest struct {
 	Description     string           `json:"description"`
 	CredentialID    *string          `json:"credential_id"`
 	Credentials     map[string]any   `json:"credentials"`
+	// SecretReference creates a credential reading its secret from an external backend instead of storing Credentials
+	SecretReference *CredentialSecretReference `json:"secret_reference"`
+	// ExpiresAt sets the expiry of a new credential instead of the one reported by the plugin or derived from
+	// the certificates and tokens of the secret
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 type DiscoverIntegrationResponse struct {
@@ -36,6 +41,8 @@ type AddIntegrationsRequest struct {
 type UpdateRequest struct {
 	Credentials map[string]any `json:"credentials"`
 	Description string         `json:"description"`
+	// ExpiresAt sets the expiry of the credential instead of deriving it from the certificates and tokens of the secret
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 type Integration struct {
@@ -69,3 +76,66 @@ type ListIntegrationsRequest struct {
 type SetResourceTypesForIntegration struct {
 	ResourceTypes []string `json:"resource_types"`
 }
+
+// IntegrationMissingPermission is a resource type the discovery of the integration is not allowed to read
+type IntegrationMissingPermission struct {
+	ResourceType string `json:"resource_type"`
+	ErrorCode    string `json:"error_code"`
+	Message      string `json:"message"`
+}
+
+type IntegrationHealthCheck struct {
+	CheckedAt          time.Time                      `json:"checked_at"`
+	Status             string                         `json:"status" enums:"healthy,degraded,unhealthy"`
+	Error              string                         `json:"error,omitempty"`
+	LatencyMs          int64                          `json:"latency_ms"`
+	MissingPermissions []IntegrationMissingPermission `json:"missing_permissions,omitempty"`
+}
+
+type IntegrationHealthResponse struct {
+	IntegrationID string `json:"integration_id"`
+	Status        string `json:"status,omitempty" enums:"healthy,degraded,unhealthy"`
+	// Uptime is the share of the window the healthcheck passed, degraded time included
+	Uptime        float64                  `json:"uptime"`
+	WindowDays    int                      `json:"window_days"`
+	LastCheckAt   *time.Time               `json:"last_check_at,omitempty"`
+	LastHealthyAt *time.Time               `json:"last_healthy_at,omitempty"`
+	Items         []IntegrationHealthCheck `json:"items"`
+	TotalCount    int64                    `json:"total_count"`
+}
+
+type IntegrationResourceTypePermissions struct {
+	ResourceType string `json:"resource_type"`
+	// RequiredPermissions are the permissions the plugin declares for discovering the resource type
+	RequiredPermissions []string `json:"required_permissions"`
+	// Blind is set when the latest discovery jobs of the resource type were denied
+	Blind bool `json:"blind"`
+	// MissingPermissions are the denied permissions named in the discovery error
+	MissingPermissions []string `json:"missing_permissions,omitempty"`
+	// SuspectedMissingPermissions are the declared permissions of a blind resource type whose discovery error names none
+	SuspectedMissingPermissions []string   `json:"suspected_missing_permissions,omitempty"`
+	ConsecutiveFailures         int        `json:"consecutive_failures,omitempty"`
+	ErrorCode                   string     `json:"error_code,omitempty"`
+	FailureMessage              string     `json:"failure_message,omitempty"`
+	LastFailedAt                *time.Time `json:"last_failed_at,omitempty"`
+}
+
+type IntegrationPermissionsResponse struct {
+	IntegrationID   string `json:"integration_id"`
+	IntegrationType string `json:"integration_type"`
+	// PermissionsDeclared is false when the plugin does not declare the permissions of its resource types,
+	// missing permissions are then only the ones named in the discovery errors
+	PermissionsDeclared bool `json:"permissions_declared"`
+	// RequiredPermissions is the least privilege set discovering the resource types of the integration needs
+	RequiredPermissions []string `json:"required_permissions"`
+	// MissingPermissions are named as denied by the discovery errors
+	MissingPermissions []string `json:"missing_permissions"`
+	// SuspectedMissingPermissions are declared for blind resource types whose discovery errors name no permission,
+	// any of them may be the missing one
+	SuspectedMissingPermissions []string `json:"suspected_missing_permissions"`
+	// SuspectedExcessPermissions are declared by the plugin only for resource types the integration does not discover,
+	// the permissions actually granted to the credential are not known so they are not necessarily granted
+	SuspectedExcessPermissions []string                             `json:"suspected_excess_permissions"`
+	BlindResourceTypes         []string                             `json:"blind_resource_types"`
+	ResourceTypes              []IntegrationResourceTypePermissions `json:"resource_types"`
+}
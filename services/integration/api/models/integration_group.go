@@ -1,8 +1,59 @@
 package models
 
+import "time"
+
 type IntegrationGroup struct {
-	Name           string        `json:"name" example:"UltraSightApplication"`
-	Query          string        `json:"query" example:"SELECT og_id FROM platform_integrations WHERE labels->'application' IS NOT NULL AND labels->'application' @> '\"UltraSight\"'"`
-	IntegrationIds []string      `json:"integration_ids,omitempty" example:"[\"1e8ac3bf-c268-4a87-9374-ce04cc40a596\"]"`
-	Integrations   []Integration `json:"integrations,omitempty"`
+	Name           string                    `json:"name" example:"UltraSightApplication"`
+	Query          string                    `json:"query" example:"SELECT og_id FROM platform_integrations WHERE labels->'application' IS NOT NULL AND labels->'application' @> '\"UltraSight\"'"`
+	Selector       *IntegrationGroupSelector `json:"selector,omitempty"`
+	IntegrationIds []string                  `json:"integration_ids,omitempty" example:"[\"1e8ac3bf-c268-4a87-9374-ce04cc40a596\"]"`
+	Integrations   []Integration             `json:"integrations,omitempty"`
+}
+
+// IntegrationGroupSelector matches integrations by their labels and annotations.
+// Exactly one field is set: All matches when every selector matches, Any when one of them does,
+// Not when its selector does not, and Label or Annotation when the requirement holds.
+type IntegrationGroupSelector struct {
+	All        []IntegrationGroupSelector `json:"all,omitempty"`
+	Any        []IntegrationGroupSelector `json:"any,omitempty"`
+	Not        *IntegrationGroupSelector  `json:"not,omitempty"`
+	Label      *SelectorRequirement       `json:"label,omitempty"`
+	Annotation *SelectorRequirement       `json:"annotation,omitempty"`
+}
+
+type SelectorOperator string
+
+const (
+	SelectorOperatorEquals    SelectorOperator = "equals"
+	SelectorOperatorNotEquals SelectorOperator = "not_equals"
+	SelectorOperatorIn        SelectorOperator = "in"
+	SelectorOperatorNotIn     SelectorOperator = "not_in"
+	SelectorOperatorExists    SelectorOperator = "exists"
+	SelectorOperatorNotExists SelectorOperator = "not_exists"
+)
+
+type SelectorRequirement struct {
+	Key      string           `json:"key" example:"environment"`
+	Operator SelectorOperator `json:"operator" enums:"equals,not_equals,in,not_in,exists,not_exists" example:"in"`
+	Values   []string         `json:"values,omitempty" example:"[\"production\"]"`
+}
+
+type CreateIntegrationGroupRequest struct {
+	Name     string                   `json:"name"`
+	Selector IntegrationGroupSelector `json:"selector"`
+}
+
+type UpdateIntegrationGroupRequest struct {
+	Selector IntegrationGroupSelector `json:"selector"`
+}
+
+type IntegrationGroupMembershipEvent struct {
+	IntegrationID string    `json:"integration_id"`
+	Action        string    `json:"action" enums:"joined,left"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ListIntegrationGroupHistoryResponse struct {
+	Items      []IntegrationGroupMembershipEvent `json:"items"`
+	TotalCount int64                             `json:"total_count"`
 }
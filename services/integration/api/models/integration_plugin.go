@@ -27,9 +27,58 @@ type IntegrationPlugin struct {
 	DescriberTag      string                          `json:"describer_tag"`
 	Count             IntegrationTypeIntegrationCount `json:"count"`
 
+	SignatureStatus string     `json:"signature_status,omitempty" enums:"verified,unsigned"` // Empty for plugins installed before bundle verification
+	SignedBy        string     `json:"signed_by,omitempty"`                                  // Trusted key id of the publisher
+	VerifiedAt      *time.Time `json:"verified_at,omitempty"`
+
+	Version       string `json:"version,omitempty"`
+	PinnedVersion string `json:"pinned_version,omitempty"`
+
 	OperationalStatusUpdates []OperationalStatusUpdate `json:"operational_status_updates"`
 }
 
+type IntegrationPluginVersion struct {
+	Version                  string    `json:"version"`
+	URL                      string    `json:"url"`
+	DescriberURL             string    `json:"describer_url"`
+	DescriberTag             string    `json:"describer_tag"`
+	SupportedPlatformVersion string    `json:"supported_platform_version"`
+	Compatible               bool      `json:"compatible"` // Always true when the platform version is unknown
+	SignatureStatus          string    `json:"signature_status,omitempty" enums:"verified,unsigned"`
+	SignedBy                 string    `json:"signed_by,omitempty"`
+	Active                   bool      `json:"active"`
+	Pinned                   bool      `json:"pinned"`
+	CreatedAt                time.Time `json:"created_at"`
+	ActivatedAt              time.Time `json:"activated_at"`
+}
+
+type IntegrationPluginVersionListResponse struct {
+	PluginID        string                     `json:"plugin_id"`
+	PlatformVersion string                     `json:"platform_version"`
+	Items           []IntegrationPluginVersion `json:"items"` // Latest activated first
+}
+
+type PinPluginVersionRequest struct {
+	Version string `json:"version"` // Defaults to the active version
+}
+
+type LocalRegistryPlugin struct {
+	PluginID         string `json:"plugin_id"`
+	Name             string `json:"name"`
+	Tier             string `json:"tier"`
+	Description      string `json:"description"`
+	Icon             string `json:"icon"`
+	Availability     string `json:"availability"`
+	PackageType      string `json:"package_type"`
+	Path             string `json:"path"` // Bundle archive or directory in the local registry
+	InstallState     string `json:"install_state"`
+	InstalledVersion string `json:"installed_version,omitempty"`
+}
+
+type LocalRegistryPluginListResponse struct {
+	Items []LocalRegistryPlugin `json:"items"`
+}
+
 type IntegrationPluginListResponse struct {
 	Items      []IntegrationPlugin `json:"items"`
 	TotalCount int                 `json:"total_count"`
@@ -0,0 +1,90 @@
+package models
+
+import "github.com/opengovern/og-util/pkg/integration"
+
+const ManifestAPIVersion = "opensecurity/v1"
+
+// Manifest declares the credentials, integrations, integration groups and framework assignments of the platform.
+// Credentials are matched by their secret reference and integrations by their type and provider id,
+// so a manifest exported from an install can be imported in another one.
+type Manifest struct {
+	APIVersion           string                        `json:"api_version"`
+	Credentials          []ManifestCredential          `json:"credentials,omitempty"`
+	Integrations         []ManifestIntegration         `json:"integrations,omitempty"`
+	IntegrationGroups    []ManifestIntegrationGroup    `json:"integration_groups,omitempty"`
+	FrameworkAssignments []ManifestFrameworkAssignment `json:"framework_assignments,omitempty"`
+}
+
+type ManifestCredential struct {
+	// Name identifies the credential within the manifest, integrations refer to it
+	Name            string                     `json:"name"`
+	IntegrationType integration.Type           `json:"integration_type"`
+	CredentialType  string                     `json:"credential_type,omitempty"`
+	Description     string                     `json:"description,omitempty"`
+	SecretReference *CredentialSecretReference `json:"secret_reference,omitempty"`
+}
+
+type ManifestIntegration struct {
+	IntegrationType integration.Type `json:"integration_type"`
+	ProviderID      string           `json:"provider_id"`
+	Name            string           `json:"name,omitempty"`
+	// Credential is the name of the manifest credential the integration is discovered with
+	Credential string            `json:"credential"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+type ManifestIntegrationGroup struct {
+	Name     string                   `json:"name"`
+	Selector IntegrationGroupSelector `json:"selector"`
+}
+
+type ManifestIntegrationRef struct {
+	IntegrationType integration.Type `json:"integration_type"`
+	ProviderID      string           `json:"provider_id"`
+}
+
+type ManifestFrameworkAssignment struct {
+	FrameworkID       string                   `json:"framework_id"`
+	Integrations      []ManifestIntegrationRef `json:"integrations,omitempty"`
+	IntegrationGroups []string                 `json:"integration_groups,omitempty"`
+}
+
+type ManifestImportMode string
+
+const (
+	ManifestImportModeDryRun ManifestImportMode = "dry_run"
+	ManifestImportModeDiff   ManifestImportMode = "diff"
+	ManifestImportModeApply  ManifestImportMode = "apply"
+)
+
+type ManifestChangeAction string
+
+const (
+	ManifestChangeActionCreate    ManifestChangeAction = "create"
+	ManifestChangeActionUpdate    ManifestChangeAction = "update"
+	ManifestChangeActionUnchanged ManifestChangeAction = "unchanged"
+	ManifestChangeActionInvalid   ManifestChangeAction = "invalid"
+	ManifestChangeActionFailed    ManifestChangeAction = "failed"
+)
+
+type ManifestFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from,omitempty"`
+	To    any    `json:"to,omitempty"`
+}
+
+type ManifestChange struct {
+	Kind   string               `json:"kind" enums:"credential,integration,integration_group,framework_assignment"`
+	Key    string               `json:"key"`
+	Action ManifestChangeAction `json:"action" enums:"create,update,unchanged,invalid,failed"`
+	// Fields is only filled in diff and apply modes
+	Fields  []ManifestFieldChange `json:"fields,omitempty"`
+	Message string                `json:"message,omitempty"`
+}
+
+type ImportManifestResponse struct {
+	Mode    ManifestImportMode `json:"mode" enums:"dry_run,diff,apply"`
+	Valid   bool               `json:"valid"`
+	Applied bool               `json:"applied"`
+	Changes []ManifestChange   `json:"changes"`
+}
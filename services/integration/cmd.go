@@ -13,11 +13,14 @@ import (
 	"github.com/opengovern/og-util/pkg/steampipe"
 	"github.com/opengovern/og-util/pkg/vault"
 	"github.com/opengovern/opensecurity/pkg/utils"
+	complianceClient "github.com/opengovern/opensecurity/services/compliance/client"
 	core "github.com/opengovern/opensecurity/services/core/client"
 	"github.com/opengovern/opensecurity/services/integration/api"
 	"github.com/opengovern/opensecurity/services/integration/config"
 	"github.com/opengovern/opensecurity/services/integration/db"
 	integration_type "github.com/opengovern/opensecurity/services/integration/integration-type"
+	"github.com/opengovern/opensecurity/services/integration/secrets"
+	schedulerClient "github.com/opengovern/opensecurity/services/scheduler/client"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
@@ -110,6 +113,14 @@ func Command() *cobra.Command {
 			}
 
 			coreClient := core.NewCoreServiceClient(cnf.Core.BaseURL)
+			var scheduler schedulerClient.SchedulerServiceClient
+			if cnf.Scheduler.BaseURL != "" {
+				scheduler = schedulerClient.NewSchedulerServiceClient(cnf.Scheduler.BaseURL)
+			}
+			var compliance complianceClient.ComplianceServiceClient
+			if cnf.Compliance.BaseURL != "" {
+				compliance = complianceClient.NewComplianceClient(cnf.Compliance.BaseURL)
+			}
 
 			_, err = coreClient.VaultConfigured(&httpclient.Context{UserRole: api3.AdminRole})
 			if err != nil && errors.Is(err, core.ErrConfigNotFound) {
@@ -161,6 +172,8 @@ func Command() *cobra.Command {
 				}
 			}
 
+			secretStore := secrets.NewStore(logger, vaultSc, cnf.SecretBackends, kubeClient)
+
 			typeManager := integration_type.NewIntegrationTypeManager(logger, db, integrationTypesDb, kubeClient, clientset, metricsClient, cnf.IntegrationPlugins)
 
 			cmd.SilenceUsage = true
@@ -177,7 +190,7 @@ func Command() *cobra.Command {
 				cmd.Context(),
 				logger,
 				cnf.Http.Address,
-				api.New(logger, db, vaultSc, &steampipeOption, kubeClient, typeManager, elastic, coreClient),
+				api.New(logger, db, vaultSc, secretStore, &steampipeOption, kubeClient, typeManager, elastic, coreClient, scheduler, compliance),
 			)
 		},
 	}
@@ -8,6 +8,47 @@ import (
 type IntegrationPluginsConfig struct {
 	PingIntervalSeconds  int `json:"ping_interval_seconds" koanf:"ping_interval_seconds"`
 	MaxAutoRebootRetries int `json:"max_auto_reboot_retries" koanf:"max_auto_reboot_retries"`
+
+	// TrustedKeysPath is the trust store of the publisher keys plugin bundles can be signed with,
+	// once set plugin bundles that are not signed by one of its keys are rejected
+	TrustedKeysPath string `json:"trusted_keys_path" koanf:"trusted_keys_path"`
+	// RequireSignature rejects plugin bundles that are not signed by a trusted key even without a trust store
+	RequireSignature bool `json:"require_signature" koanf:"require_signature"`
+	// RetainedVersions is the number of versions kept per plugin for rollback, defaults to 3
+	RetainedVersions int `json:"retained_versions" koanf:"retained_versions"`
+	// LocalRegistryPath is a directory with an index.yaml listing plugin bundles, used instead of remote urls in air-gapped clusters
+	LocalRegistryPath string `json:"local_registry_path" koanf:"local_registry_path"`
+	// MaxUploadSizeMB caps the size of uploaded plugin bundle archives, defaults to 512
+	MaxUploadSizeMB int64 `json:"max_upload_size_mb" koanf:"max_upload_size_mb"`
+}
+
+// SecretBackendsConfig enables the external backends credentials can reference their secret in, all are disabled by default
+type SecretBackendsConfig struct {
+	Vault      VaultSecretBackendConfig      `json:"vault" koanf:"vault"`
+	Kubernetes KubernetesSecretBackendConfig `json:"kubernetes" koanf:"kubernetes"`
+	File       FileSecretBackendConfig       `json:"file" koanf:"file"`
+}
+
+type VaultSecretBackendConfig struct {
+	// Address of the HashiCorp Vault server, the backend is enabled when set
+	Address string `json:"address" koanf:"address"`
+	// Mount is the KV v2 mount secrets are read from, defaults to secret
+	Mount string `json:"mount" koanf:"mount"`
+	// Role is the kubernetes auth role the service logs in with
+	Role string `json:"role" koanf:"role"`
+	// AuthMount is the mount path of the kubernetes auth method, defaults to kubernetes
+	AuthMount string `json:"auth_mount" koanf:"auth_mount"`
+}
+
+type KubernetesSecretBackendConfig struct {
+	Enabled bool `json:"enabled" koanf:"enabled"`
+	// Namespaces secrets can be read from, required to enable the backend. The namespace of the platform is refused.
+	Namespaces []string `json:"namespaces" koanf:"namespaces"`
+}
+
+type FileSecretBackendConfig struct {
+	// BasePath is the directory referenced files must be in, the backend is enabled when set
+	BasePath string `json:"base_path" koanf:"base_path"`
 }
 
 type IntegrationConfig struct {
@@ -16,6 +57,10 @@ type IntegrationConfig struct {
 	Http      koanf.HttpServer            `json:"http,omitempty" koanf:"http"`
 	Vault     vault.Config                `json:"vault,omitempty" koanf:"vault"`
 	Core      koanf.OpenGovernanceService `json:"core,omitempty" koanf:"core"`
+	Scheduler koanf.OpenGovernanceService `json:"scheduler,omitempty" koanf:"scheduler"`
+	// Compliance is used to import and export framework assignments with the manifest
+	Compliance koanf.OpenGovernanceService `json:"compliance,omitempty" koanf:"compliance"`
 
 	IntegrationPlugins IntegrationPluginsConfig `json:"integration_plugins,omitempty" koanf:"integration_plugins"`
+	SecretBackends     SecretBackendsConfig     `json:"secret_backends,omitempty" koanf:"secret_backends"`
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgtype"
 	"github.com/opengovern/opensecurity/services/integration/models"
@@ -104,6 +105,22 @@ func (db Database) UpdateCredential(id string, secret string,masked map[string]a
 	return nil
 }
 
+// UpdateCredentialDetails updates the type and description of a credential
+func (db Database) UpdateCredentialDetails(id string, credentialType, description string) error {
+	tx := db.Orm.
+		Model(&models.Credential{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"credential_type": credentialType,
+			"description":     description,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
 // update descripion and integration count
 func (db Database) UpdateCredentialIntegrationCount(id string, count int) error {
 	tx:= db.Orm.
@@ -117,3 +134,59 @@ func (db Database) UpdateCredentialIntegrationCount(id string, count int) error
 	return nil
 }
 
+// UpdateCredentialValidity stores the issue and expiry time of the credential secret
+func (db Database) UpdateCredentialValidity(id string, issuedAt, expiresAt *time.Time, source models.CredentialExpirySource) error {
+	tx := db.Orm.
+		Model(&models.Credential{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"issued_at":     issuedAt,
+			"expires_at":    expiresAt,
+			"expiry_source": source,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+// RotateCredential swaps the secret of the credential, keeping the current one as the previous secret
+func (db Database) RotateCredential(credential *models.Credential) error {
+	tx := db.Orm.
+		Model(&models.Credential{}).
+		Where("id = ?", credential.ID).
+		Select("secret", "masked_secret", "description", "issued_at", "expires_at", "expiry_source",
+			"rotated_at", "rotation_state", "rotation_trigger_id", "rotation_message", "previous_secret", "previous_masked_secret").
+		Updates(credential)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	return nil
+}
+
+// ListCredentialsByRotationState lists the credentials of a rotation state
+func (db Database) ListCredentialsByRotationState(state models.CredentialRotationState) ([]models.Credential, error) {
+	var credentials []models.Credential
+	tx := db.Orm.
+		Model(&models.Credential{}).
+		Where("rotation_state = ?", state).
+		Find(&credentials)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return credentials, nil
+}
+
+// ListCredentialsExpiringBefore lists the credentials with an expiry before t, expired ones included, soonest first
+func (db Database) ListCredentialsExpiringBefore(t time.Time) ([]models.Credential, error) {
+	var credentials []models.Credential
+	tx := db.Orm.
+		Model(&models.Credential{}).
+		Where("expires_at IS NOT NULL AND expires_at < ?", t).
+		Order("expires_at ASC").
+		Find(&credentials)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return credentials, nil
+}
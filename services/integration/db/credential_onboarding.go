@@ -0,0 +1,154 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opengovern/og-util/pkg/integration"
+	"github.com/opengovern/opensecurity/services/integration/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetCredentialOnboardingPolicy gets the onboarding policy of a credential
+func (db Database) GetCredentialOnboardingPolicy(credentialID string) (*models.CredentialOnboardingPolicy, error) {
+	var policy models.CredentialOnboardingPolicy
+	tx := db.Orm.
+		Model(&models.CredentialOnboardingPolicy{}).
+		Where("credential_id = ?", credentialID).
+		First(&policy)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return &policy, nil
+}
+
+// ListCredentialOnboardingPolicies lists the onboarding policies of all credentials
+func (db Database) ListCredentialOnboardingPolicies() ([]models.CredentialOnboardingPolicy, error) {
+	var policies []models.CredentialOnboardingPolicy
+	tx := db.Orm.
+		Model(&models.CredentialOnboardingPolicy{}).
+		Find(&policies)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return policies, nil
+}
+
+// UpsertCredentialOnboardingPolicy creates or replaces the onboarding policy of a credential
+func (db Database) UpsertCredentialOnboardingPolicy(policy *models.CredentialOnboardingPolicy) error {
+	tx := db.Orm.
+		Model(&models.CredentialOnboardingPolicy{}).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "credential_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"mode", "name_pattern", "label_selector", "archive_missing", "interval_minutes", "updated_at"}),
+		}).
+		Create(policy)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// DeleteCredentialOnboardingPolicy deletes the onboarding policy of a credential and its pending integrations
+func (db Database) DeleteCredentialOnboardingPolicy(credentialID string) error {
+	return db.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("credential_id = ?", credentialID).Delete(&models.PendingIntegration{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("credential_id = ?", credentialID).Delete(&models.CredentialOnboardingPolicy{}).Error
+	})
+}
+
+// UpdateCredentialOnboardingRun records the outcome of the last rediscovery of a credential
+func (db Database) UpdateCredentialOnboardingRun(credentialID uuid.UUID, at time.Time, discoveryError string) error {
+	tx := db.Orm.
+		Model(&models.CredentialOnboardingPolicy{}).
+		Where("credential_id = ?", credentialID).
+		Updates(map[string]any{
+			"last_discovery_at":    at,
+			"last_discovery_error": discoveryError,
+		})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// SetPendingIntegrations replaces the pending integrations of a credential and returns the ones seen for the first time
+func (db Database) SetPendingIntegrations(credentialID uuid.UUID, pending []models.PendingIntegration) ([]models.PendingIntegration, error) {
+	var added []models.PendingIntegration
+	err := db.Orm.Transaction(func(tx *gorm.DB) error {
+		var current []models.PendingIntegration
+		if err := tx.Where("credential_id = ?", credentialID).Find(&current).Error; err != nil {
+			return err
+		}
+		known := make(map[string]models.PendingIntegration, len(current))
+		for _, p := range current {
+			known[p.ProviderID] = p
+		}
+
+		now := time.Now()
+		providerIDs := make([]string, 0, len(pending))
+		for _, p := range pending {
+			p.CredentialID = credentialID
+			p.LastSeenAt = now
+			if k, ok := known[p.ProviderID]; ok {
+				p.FirstSeenAt = k.FirstSeenAt
+			} else {
+				p.FirstSeenAt = now
+				added = append(added, p)
+			}
+			if err := tx.Save(&p).Error; err != nil {
+				return err
+			}
+			providerIDs = append(providerIDs, p.ProviderID)
+		}
+
+		del := tx.Where("credential_id = ?", credentialID)
+		if len(providerIDs) > 0 {
+			del = del.Where("provider_id NOT IN ?", providerIDs)
+		}
+		return del.Delete(&models.PendingIntegration{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
+
+// ListPendingIntegrations lists the pending integrations of a credential
+func (db Database) ListPendingIntegrations(credentialID string) ([]models.PendingIntegration, error) {
+	var pending []models.PendingIntegration
+	tx := db.Orm.
+		Model(&models.PendingIntegration{}).
+		Where("credential_id = ?", credentialID).
+		Order("first_seen_at desc, provider_id").
+		Find(&pending)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return pending, nil
+}
+
+// ArchiveIntegrations archives the given integrations
+func (db Database) ArchiveIntegrations(integrationIDs []uuid.UUID) error {
+	if len(integrationIDs) == 0 {
+		return nil
+	}
+	tx := db.Orm.
+		Model(&models.Integration{}).
+		Where("integration_id IN ?", integrationIDs).
+		Update("state", integration.IntegrationStateArchived)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
@@ -111,6 +111,20 @@ func (db Database) ListIntegrationsByFilters(IntegrationIDs []string, types []st
 	return integrations, nil
 }
 
+// ListIntegrationsByCredential lists the integrations using the credential
+func (db Database) ListIntegrationsByCredential(credentialID string) ([]models.Integration, error) {
+	var integrations []models.Integration
+	tx := db.Orm.
+		Model(&models.Integration{}).
+		Where("credential_id = ?", credentialID).
+		Find(&integrations)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return integrations, nil
+}
+
 // GetIntegration get a Integration
 func (db Database) GetIntegration(tracker uuid.UUID) (*models.Integration, error) {
 	var integration models.Integration
@@ -1,15 +1,31 @@
 package db
 
 import (
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/opengovern/opensecurity/services/integration/models"
+	"gorm.io/gorm"
 )
 
-// DeleteIntegrationGroup deletes an Integration Group
-func (db Database) DeleteIntegrationGroup(name string) error {
+// CreateIntegrationGroup creates an Integration Group
+func (db Database) CreateIntegrationGroup(group *models.IntegrationGroup) error {
 	tx := db.Orm.
-		Where("name = ?", name).
-		Unscoped().
-		Delete(&models.IntegrationGroup{})
+		Model(&models.IntegrationGroup{}).
+		Create(group)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// UpdateIntegrationGroup updates the selector of an Integration Group
+func (db Database) UpdateIntegrationGroup(group *models.IntegrationGroup) error {
+	tx := db.Orm.
+		Model(&models.IntegrationGroup{}).
+		Where("name = ?", group.Name).
+		Update("selector", group.Selector)
 	if tx.Error != nil {
 		return tx.Error
 	}
@@ -17,6 +33,146 @@ func (db Database) DeleteIntegrationGroup(name string) error {
 	return nil
 }
 
+// DeleteIntegrationGroup deletes an Integration Group, its members leave the group
+func (db Database) DeleteIntegrationGroup(name string) error {
+	return db.Orm.Transaction(func(tx *gorm.DB) error {
+		var members []models.IntegrationGroupMember
+		if err := tx.Where("group_name = ?", name).Find(&members).Error; err != nil {
+			return err
+		}
+		if err := recordMembershipEvents(tx, name, members, models.IntegrationGroupMembershipLeft); err != nil {
+			return err
+		}
+		if err := tx.Where("group_name = ?", name).Delete(&models.IntegrationGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ?", name).
+			Unscoped().
+			Delete(&models.IntegrationGroup{}).Error
+	})
+}
+
+// ListIntegrationGroupMemberIDs lists the integration ids of the members of a selector group
+func (db Database) ListIntegrationGroupMemberIDs(name string) ([]string, error) {
+	var ids []string
+	tx := db.Orm.
+		Model(&models.IntegrationGroupMember{}).
+		Where("group_name = ?", name).
+		Order("integration_id").
+		Pluck("integration_id", &ids)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return ids, nil
+}
+
+// SetIntegrationGroupMembers replaces the members of a group, recording the integrations joining and leaving it
+func (db Database) SetIntegrationGroupMembers(name string, integrationIDs []uuid.UUID) (joined, left int, err error) {
+	err = db.Orm.Transaction(func(tx *gorm.DB) error {
+		var current []models.IntegrationGroupMember
+		if err := tx.Where("group_name = ?", name).Find(&current).Error; err != nil {
+			return err
+		}
+		wanted := make(map[uuid.UUID]bool, len(integrationIDs))
+		for _, id := range integrationIDs {
+			wanted[id] = true
+		}
+
+		var leaving []models.IntegrationGroupMember
+		for _, m := range current {
+			if wanted[m.IntegrationID] {
+				delete(wanted, m.IntegrationID)
+				continue
+			}
+			leaving = append(leaving, m)
+		}
+		now := time.Now()
+		var joining []models.IntegrationGroupMember
+		for _, id := range integrationIDs {
+			if wanted[id] {
+				joining = append(joining, models.IntegrationGroupMember{GroupName: name, IntegrationID: id, JoinedAt: now})
+			}
+		}
+
+		for _, m := range leaving {
+			if err := tx.Where("group_name = ? AND integration_id = ?", name, m.IntegrationID).
+				Delete(&models.IntegrationGroupMember{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(joining) > 0 {
+			if err := tx.Create(&joining).Error; err != nil {
+				return err
+			}
+		}
+		if err := recordMembershipEvents(tx, name, leaving, models.IntegrationGroupMembershipLeft); err != nil {
+			return err
+		}
+		if err := recordMembershipEvents(tx, name, joining, models.IntegrationGroupMembershipJoined); err != nil {
+			return err
+		}
+		joined, left = len(joining), len(leaving)
+		return nil
+	})
+	return joined, left, err
+}
+
+func recordMembershipEvents(tx *gorm.DB, name string, members []models.IntegrationGroupMember, action models.IntegrationGroupMembershipAction) error {
+	if len(members) == 0 {
+		return nil
+	}
+	events := make([]models.IntegrationGroupMembershipEvent, 0, len(members))
+	for _, m := range members {
+		events = append(events, models.IntegrationGroupMembershipEvent{
+			GroupName:     name,
+			IntegrationID: m.IntegrationID,
+			Action:        action,
+		})
+	}
+	return tx.Create(&events).Error
+}
+
+// ListIntegrationGroupMembershipEvents lists the membership changes of a group, latest first
+func (db Database) ListIntegrationGroupMembershipEvents(name string, since *time.Time, limit, offset int) ([]models.IntegrationGroupMembershipEvent, int64, error) {
+	tx := db.Orm.
+		Model(&models.IntegrationGroupMembershipEvent{}).
+		Where("group_name = ?", name)
+	if since != nil {
+		tx = tx.Where("created_at >= ?", *since)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.IntegrationGroupMembershipEvent
+	tx = tx.Order("created_at DESC, id DESC").Offset(offset)
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+	if err := tx.Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// ListSelectorIntegrationGroups list the Integration Groups defined by a selector
+func (db Database) ListSelectorIntegrationGroups() ([]models.IntegrationGroup, error) {
+	var integrationGroups []models.IntegrationGroup
+	tx := db.Orm.
+		Model(&models.IntegrationGroup{}).
+		Where("selector IS NOT NULL").
+		Find(&integrationGroups)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return integrationGroups, nil
+}
+
 // ListIntegrationGroups list Integration Groups
 func (db Database) ListIntegrationGroups() ([]models.IntegrationGroup, error) {
 	var integrationGroups []models.IntegrationGroup
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opengovern/opensecurity/services/integration/models"
+	"gorm.io/gorm"
+)
+
+// CreateIntegrationHealthCheck records the result of a healthcheck
+func (db Database) CreateIntegrationHealthCheck(check *models.IntegrationHealthCheck) error {
+	tx := db.Orm.
+		Model(&models.IntegrationHealthCheck{}).
+		Create(check)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// ListIntegrationHealthChecks lists the healthchecks of an integration since the given time, latest first
+func (db Database) ListIntegrationHealthChecks(integrationID uuid.UUID, since time.Time, limit, offset int) ([]models.IntegrationHealthCheck, int64, error) {
+	var checks []models.IntegrationHealthCheck
+	var total int64
+	tx := db.Orm.
+		Model(&models.IntegrationHealthCheck{}).
+		Where("integration_id = ? AND checked_at >= ?", integrationID, since)
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Order("checked_at desc").Limit(limit).Offset(offset).Find(&checks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return checks, total, nil
+}
+
+// ListIntegrationHealthStatuses lists the healthchecks of an integration since the given time, oldest first,
+// starting with the last healthcheck before it which tells the status at the start of the window
+func (db Database) ListIntegrationHealthStatuses(integrationID uuid.UUID, since time.Time) ([]models.IntegrationHealthCheck, error) {
+	var checks []models.IntegrationHealthCheck
+
+	var previous models.IntegrationHealthCheck
+	tx := db.Orm.
+		Model(&models.IntegrationHealthCheck{}).
+		Where("integration_id = ? AND checked_at < ?", integrationID, since).
+		Order("checked_at desc").
+		First(&previous)
+	if tx.Error == nil {
+		checks = append(checks, previous)
+	} else if !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return nil, tx.Error
+	}
+
+	var inWindow []models.IntegrationHealthCheck
+	tx = db.Orm.
+		Model(&models.IntegrationHealthCheck{}).
+		Select("id, integration_id, checked_at, status").
+		Where("integration_id = ? AND checked_at >= ?", integrationID, since).
+		Order("checked_at").
+		Find(&inWindow)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return append(checks, inWindow...), nil
+}
+
+// GetLastHealthyIntegrationHealthCheck gets the latest healthcheck of an integration that passed
+func (db Database) GetLastHealthyIntegrationHealthCheck(integrationID uuid.UUID) (*models.IntegrationHealthCheck, error) {
+	var check models.IntegrationHealthCheck
+	tx := db.Orm.
+		Model(&models.IntegrationHealthCheck{}).
+		Where("integration_id = ? AND status <> ?", integrationID, models.IntegrationHealthStatusUnhealthy).
+		Order("checked_at desc").
+		First(&check)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+
+	return &check, nil
+}
+
+// DeleteIntegrationHealthChecks deletes the healthcheck history of an integration
+func (db Database) DeleteIntegrationHealthChecks(integrationID uuid.UUID) error {
+	tx := db.Orm.
+		Where("integration_id = ?", integrationID).
+		Delete(&models.IntegrationHealthCheck{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
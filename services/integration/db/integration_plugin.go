@@ -6,6 +6,7 @@ import (
 	"github.com/opengovern/opensecurity/services/integration/models"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"time"
 )
 
 func (db Database) ListPlugins() ([]models.IntegrationPlugin, error) {
@@ -127,3 +128,97 @@ func (db Database) UpdatePlugin(plugin *models.IntegrationPlugin) error {
 	}
 	return nil
 }
+
+// UpdatePluginSignature stores the signature verification result of the plugin, clearing the previous one
+func (db Database) UpdatePluginSignature(plugin *models.IntegrationPlugin) error {
+	err := db.IntegrationTypeOrm.Model(models.IntegrationPlugin{}).Where("plugin_id = ?", plugin.PluginID).
+		Select("SignatureStatus", "SignedBy", "IntegrationPluginSHA256", "CloudQLPluginSHA256", "VerifiedAt").
+		Updates(plugin).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdatePluginPinnedVersion pins the plugin to the version, an empty version unpins it
+func (db Database) UpdatePluginPinnedVersion(pluginID, version string) error {
+	err := db.IntegrationTypeOrm.Model(models.IntegrationPlugin{}).Where("plugin_id = ?", pluginID).
+		Update("pinned_version", version).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpsertPluginVersion stores the bundle of a plugin version, replacing the bundle of the same version
+func (db Database) UpsertPluginVersion(version *models.IntegrationPluginVersion) error {
+	err := db.IntegrationTypeOrm.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "plugin_id"}, {Name: "version"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "describer_url", "describer_tag", "supported_platform_version",
+			"signature_status", "signed_by", "activated_at", "integration_plugin", "cloud_ql_plugin"}),
+	}).Create(version).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListPluginVersions lists the retained versions of the plugin without their binaries, latest activated first
+func (db Database) ListPluginVersions(pluginID string) ([]models.IntegrationPluginVersion, error) {
+	var versions []models.IntegrationPluginVersion
+	err := db.IntegrationTypeOrm.Model(models.IntegrationPluginVersion{}).
+		Omit("integration_plugin", "cloud_ql_plugin").
+		Where("plugin_id = ?", pluginID).
+		Order("activated_at DESC").
+		Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (db Database) GetPluginVersion(pluginID, version string) (*models.IntegrationPluginVersion, error) {
+	var pluginVersion models.IntegrationPluginVersion
+	err := db.IntegrationTypeOrm.Model(models.IntegrationPluginVersion{}).
+		Where("plugin_id = ? AND version = ?", pluginID, version).
+		First(&pluginVersion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pluginVersion, nil
+}
+
+func (db Database) UpdatePluginVersionActivatedAt(pluginID, version string, t time.Time) error {
+	err := db.IntegrationTypeOrm.Model(models.IntegrationPluginVersion{}).
+		Where("plugin_id = ? AND version = ?", pluginID, version).
+		Update("activated_at", t).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PrunePluginVersions keeps the latest activated versions of the plugin and the given ones, deleting the rest
+func (db Database) PrunePluginVersions(pluginID string, keepLatest int, keep ...string) error {
+	var latest []string
+	err := db.IntegrationTypeOrm.Model(models.IntegrationPluginVersion{}).
+		Where("plugin_id = ?", pluginID).
+		Order("activated_at DESC").
+		Limit(keepLatest).
+		Pluck("version", &latest).Error
+	if err != nil {
+		return err
+	}
+	keep = append(keep, latest...)
+
+	err = db.IntegrationTypeOrm.
+		Where("plugin_id = ? AND version NOT IN ?", pluginID, keep).
+		Delete(&models.IntegrationPluginVersion{}).Error
+	if err != nil {
+		return err
+	}
+	return nil
+}
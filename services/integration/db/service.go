@@ -21,8 +21,13 @@ func (db Database) Initialize() error {
 	err := db.Orm.AutoMigrate(
 		&models.Integration{},
 		&models.Credential{},
+		&models.CredentialOnboardingPolicy{},
+		&models.PendingIntegration{},
 		&models.IntegrationGroup{},
+		&models.IntegrationGroupMember{},
+		&models.IntegrationGroupMembershipEvent{},
 		&models.IntegrationResourcetypes{},
+		&models.IntegrationHealthCheck{},
 	)
 	if err != nil {
 		return err
@@ -31,6 +36,7 @@ func (db Database) Initialize() error {
 	err = db.IntegrationTypeOrm.AutoMigrate(
 		&models.IntegrationPlugin{},
 		&models.IntegrationPluginBinary{},
+		&models.IntegrationPluginVersion{},
 	)
 	if err != nil {
 		return err
@@ -1,18 +1,33 @@
 package entities
 
 import (
+	"encoding/json"
+
+	"github.com/jackc/pgtype"
 	"github.com/opengovern/og-util/pkg/steampipe"
 	api "github.com/opengovern/opensecurity/services/integration/api/models"
 	"github.com/opengovern/opensecurity/services/integration/models"
 	"golang.org/x/net/context"
 )
 
-func NewIntegrationGroup(ctx context.Context, steampipe *steampipe.Database, cg models.IntegrationGroup) (*api.IntegrationGroup, error) {
+// NewIntegrationGroup converts the group to the API model, the integrations of a query group are the result of its query
+// and the integrations of a selector group are its materialized members
+func NewIntegrationGroup(ctx context.Context, steampipe *steampipe.Database, cg models.IntegrationGroup, memberIDs []string) (*api.IntegrationGroup, error) {
 	apiCg := api.IntegrationGroup{
 		Name:  cg.Name,
 		Query: cg.Query,
 	}
 
+	if cg.Selector.Status == pgtype.Present {
+		var selector api.IntegrationGroupSelector
+		if err := json.Unmarshal(cg.Selector.Bytes, &selector); err != nil {
+			return nil, err
+		}
+		apiCg.Selector = &selector
+		apiCg.IntegrationIds = memberIDs
+		return &apiCg, nil
+	}
+
 	if steampipe == nil || cg.Query == "" {
 		return &apiCg, nil
 	}
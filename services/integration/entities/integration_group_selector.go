@@ -0,0 +1,117 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	api "github.com/opengovern/opensecurity/services/integration/api/models"
+)
+
+// ValidateSelector checks that each node of the selector sets exactly one field and its requirements are well formed
+func ValidateSelector(s api.IntegrationGroupSelector) error {
+	set := 0
+	if len(s.All) > 0 {
+		set++
+	}
+	if len(s.Any) > 0 {
+		set++
+	}
+	if s.Not != nil {
+		set++
+	}
+	if s.Label != nil {
+		set++
+	}
+	if s.Annotation != nil {
+		set++
+	}
+	if set != 1 {
+		return errors.New("selector should set exactly one of all, any, not, label or annotation")
+	}
+
+	for _, child := range append(slices.Clone(s.All), s.Any...) {
+		if err := ValidateSelector(child); err != nil {
+			return err
+		}
+	}
+	if s.Not != nil {
+		return ValidateSelector(*s.Not)
+	}
+	if s.Label != nil {
+		return validateRequirement(*s.Label)
+	}
+	if s.Annotation != nil {
+		return validateRequirement(*s.Annotation)
+	}
+	return nil
+}
+
+func validateRequirement(r api.SelectorRequirement) error {
+	if r.Key == "" {
+		return errors.New("selector requirement key is empty")
+	}
+	switch r.Operator {
+	case api.SelectorOperatorEquals, api.SelectorOperatorNotEquals:
+		if len(r.Values) != 1 {
+			return fmt.Errorf("operator %s of key %s takes a single value", r.Operator, r.Key)
+		}
+	case api.SelectorOperatorIn, api.SelectorOperatorNotIn:
+		if len(r.Values) == 0 {
+			return fmt.Errorf("operator %s of key %s takes at least one value", r.Operator, r.Key)
+		}
+	case api.SelectorOperatorExists, api.SelectorOperatorNotExists:
+		if len(r.Values) > 0 {
+			return fmt.Errorf("operator %s of key %s takes no values", r.Operator, r.Key)
+		}
+	default:
+		return fmt.Errorf("invalid selector operator %q", r.Operator)
+	}
+	return nil
+}
+
+// MatchSelector evaluates a validated selector against the labels and annotations of an integration
+func MatchSelector(s api.IntegrationGroupSelector, labels, annotations map[string]string) bool {
+	switch {
+	case len(s.All) > 0:
+		for _, child := range s.All {
+			if !MatchSelector(child, labels, annotations) {
+				return false
+			}
+		}
+		return true
+	case len(s.Any) > 0:
+		for _, child := range s.Any {
+			if MatchSelector(child, labels, annotations) {
+				return true
+			}
+		}
+		return false
+	case s.Not != nil:
+		return !MatchSelector(*s.Not, labels, annotations)
+	case s.Label != nil:
+		return matchRequirement(*s.Label, labels)
+	case s.Annotation != nil:
+		return matchRequirement(*s.Annotation, annotations)
+	}
+	return false
+}
+
+func matchRequirement(r api.SelectorRequirement, values map[string]string) bool {
+	value, ok := values[r.Key]
+	switch r.Operator {
+	case api.SelectorOperatorEquals:
+		return ok && len(r.Values) > 0 && value == r.Values[0]
+	case api.SelectorOperatorNotEquals:
+		return !ok || len(r.Values) == 0 || value != r.Values[0]
+	case api.SelectorOperatorIn:
+		return ok && slices.Contains(r.Values, value)
+	case api.SelectorOperatorNotIn:
+		return !ok || !slices.Contains(r.Values, value)
+	case api.SelectorOperatorExists:
+		return ok
+	case api.SelectorOperatorNotExists:
+		return !ok
+	}
+	return false
+}
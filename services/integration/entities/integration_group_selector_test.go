@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"testing"
+
+	api "github.com/opengovern/opensecurity/services/integration/api/models"
+)
+
+func label(key string, op api.SelectorOperator, values ...string) api.IntegrationGroupSelector {
+	return api.IntegrationGroupSelector{Label: &api.SelectorRequirement{Key: key, Operator: op, Values: values}}
+}
+
+func annotation(key string, op api.SelectorOperator, values ...string) api.IntegrationGroupSelector {
+	return api.IntegrationGroupSelector{Annotation: &api.SelectorRequirement{Key: key, Operator: op, Values: values}}
+}
+
+func TestValidateSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector api.IntegrationGroupSelector
+		wantErr  bool
+	}{
+		{name: "label", selector: label("env", api.SelectorOperatorEquals, "prod")},
+		{name: "nested", selector: api.IntegrationGroupSelector{All: []api.IntegrationGroupSelector{
+			label("env", api.SelectorOperatorIn, "prod", "staging"),
+			{Not: &api.IntegrationGroupSelector{Any: []api.IntegrationGroupSelector{annotation("archived", api.SelectorOperatorExists)}}},
+		}}},
+		{name: "empty", selector: api.IntegrationGroupSelector{}, wantErr: true},
+		{name: "two fields", selector: api.IntegrationGroupSelector{
+			Label:      &api.SelectorRequirement{Key: "env", Operator: api.SelectorOperatorExists},
+			Annotation: &api.SelectorRequirement{Key: "env", Operator: api.SelectorOperatorExists},
+		}, wantErr: true},
+		{name: "empty key", selector: label("", api.SelectorOperatorExists), wantErr: true},
+		{name: "equals with two values", selector: label("env", api.SelectorOperatorEquals, "a", "b"), wantErr: true},
+		{name: "in without values", selector: label("env", api.SelectorOperatorIn), wantErr: true},
+		{name: "exists with a value", selector: label("env", api.SelectorOperatorExists, "prod"), wantErr: true},
+		{name: "unknown operator", selector: label("env", "matches", "prod"), wantErr: true},
+		{name: "invalid nested selector", selector: api.IntegrationGroupSelector{Any: []api.IntegrationGroupSelector{
+			label("env", api.SelectorOperatorExists), {},
+		}}, wantErr: true},
+		{name: "invalid negated selector", selector: api.IntegrationGroupSelector{Not: &api.IntegrationGroupSelector{}}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSelector(tc.selector)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMatchSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "payments"}
+	annotations := map[string]string{"owner": "alice"}
+
+	tests := []struct {
+		name     string
+		selector api.IntegrationGroupSelector
+		want     bool
+	}{
+		{name: "equals", selector: label("env", api.SelectorOperatorEquals, "prod"), want: true},
+		{name: "equals other value", selector: label("env", api.SelectorOperatorEquals, "dev")},
+		{name: "not equals missing key", selector: label("region", api.SelectorOperatorNotEquals, "eu"), want: true},
+		{name: "in", selector: label("team", api.SelectorOperatorIn, "billing", "payments"), want: true},
+		{name: "not in", selector: label("team", api.SelectorOperatorNotIn, "billing", "payments")},
+		{name: "exists", selector: label("team", api.SelectorOperatorExists), want: true},
+		{name: "not exists", selector: label("team", api.SelectorOperatorNotExists)},
+		{name: "annotation is not a label", selector: label("owner", api.SelectorOperatorExists)},
+		{name: "annotation", selector: annotation("owner", api.SelectorOperatorEquals, "alice"), want: true},
+		{name: "all", selector: api.IntegrationGroupSelector{All: []api.IntegrationGroupSelector{
+			label("env", api.SelectorOperatorEquals, "prod"), annotation("owner", api.SelectorOperatorExists),
+		}}, want: true},
+		{name: "all with a mismatch", selector: api.IntegrationGroupSelector{All: []api.IntegrationGroupSelector{
+			label("env", api.SelectorOperatorEquals, "prod"), annotation("owner", api.SelectorOperatorNotExists),
+		}}},
+		{name: "any", selector: api.IntegrationGroupSelector{Any: []api.IntegrationGroupSelector{
+			label("env", api.SelectorOperatorEquals, "dev"), label("team", api.SelectorOperatorExists),
+		}}, want: true},
+		{name: "not", selector: api.IntegrationGroupSelector{Not: &api.IntegrationGroupSelector{
+			Label: &api.SelectorRequirement{Key: "env", Operator: api.SelectorOperatorEquals, Values: []string{"dev"}},
+		}}, want: true},
+		{name: "empty selector", selector: api.IntegrationGroupSelector{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MatchSelector(tc.selector, labels, annotations); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
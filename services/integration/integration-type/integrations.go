@@ -117,6 +117,10 @@ func NewIntegrationTypeManager(logger *zap.Logger, database db.Database, integra
 			}
 			continue
 		}
+		if err := t.CheckBinary(pluginBinary); err != nil {
+			logger.Error("refusing to load plugin", zap.String("plugin_id", t.PluginID), zap.Error(err))
+			continue
+		}
 		// write the plugin to the file system
 		pluginPath := filepath.Join(baseDir, t.IntegrationType.String()+".so")
 		err := os.WriteFile(pluginPath, pluginBinary.IntegrationPlugin, 0755)
@@ -249,6 +253,10 @@ func NewIntegrationTypeManager(logger *zap.Logger, database db.Database, integra
 	return &manager
 }
 
+func (m *IntegrationTypeManager) PluginsConfig() config.IntegrationPluginsConfig {
+	return m.cnf
+}
+
 func (m *IntegrationTypeManager) GetIntegrationTypes() []integration.Type {
 	types := make([]integration.Type, 0, len(m.IntegrationTypes))
 	for t := range m.IntegrationTypes {
@@ -855,6 +863,20 @@ func (a *IntegrationTypeManager) EnableIntegrationTypeHelper(ctx context.Context
 	return nil
 }
 
+// PlatformVersion returns the version of the platform from the platform-version config map
+func (a *IntegrationTypeManager) PlatformVersion(ctx context.Context) (string, error) {
+	currentNamespace, ok := os.LookupEnv("CURRENT_NAMESPACE")
+	if !ok {
+		return "", errors.New("current namespace lookup failed")
+	}
+
+	configMap, err := a.KubeClientset.CoreV1().ConfigMaps(currentNamespace).Get(ctx, "platform-version", metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return configMap.Data["version"], nil
+}
+
 func (a *IntegrationTypeManager) RestartCloudQLEnabledServices(ctx context.Context) error {
 	currentNamespace, ok := os.LookupEnv("CURRENT_NAMESPACE")
 	if !ok {
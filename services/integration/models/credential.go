@@ -21,11 +21,44 @@ type Credential struct {
 	MaskedSecret  pgtype.JSONB 
 	Description     string            
 
+	// IssuedAt and ExpiresAt are derived from the certificates and tokens of the secret when not given explicitly
+	IssuedAt     *time.Time
+	ExpiresAt    *time.Time `gorm:"index"`
+	ExpirySource CredentialExpirySource
+
+	// The previous secret of a rotation is kept until discovery with the new secret is verified
+	RotatedAt            *time.Time
+	RotationState        CredentialRotationState
+	RotationTriggerID    string
+	RotationMessage      string
+	PreviousSecret       string
+	PreviousMaskedSecret pgtype.JSONB `gorm:"default:null"`
+
+	// SecretReference points at the secret in an external backend, Secret is then empty
+	SecretReference pgtype.JSONB `gorm:"default:null"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt sql.NullTime `gorm:"index"`
 }
 
+type CredentialExpirySource string
+
+const (
+	CredentialExpirySourceCertificate CredentialExpirySource = "certificate"
+	CredentialExpirySourceToken       CredentialExpirySource = "token"
+	CredentialExpirySourceManual      CredentialExpirySource = "manual"
+	CredentialExpirySourcePlugin      CredentialExpirySource = "plugin"
+)
+
+type CredentialRotationState string
+
+const (
+	CredentialRotationStateVerifying  CredentialRotationState = "verifying"
+	CredentialRotationStateVerified   CredentialRotationState = "verified"
+	CredentialRotationStateRolledBack CredentialRotationState = "rolled_back"
+)
+
 func (c *Credential) ToApi(returnSecret bool) (*models.Credential, error) {
 	var metadata map[string]string
 	if c.Metadata.Status == pgtype.Present {
@@ -50,10 +83,36 @@ func (c *Credential) ToApi(returnSecret bool) (*models.Credential, error) {
 		Description:     c.Description,
 		CreatedAt:       c.CreatedAt,
 		UpdatedAt:       c.UpdatedAt,
+		IssuedAt:        c.IssuedAt,
+		ExpiresAt:       c.ExpiresAt,
+		ExpirySource:    string(c.ExpirySource),
+		RotatedAt:       c.RotatedAt,
+		RotationState:   string(c.RotationState),
+		RotationMessage: c.RotationMessage,
 	}
 	if returnSecret {
 		credential.Secret = c.Secret
 	}
+	ref, err := c.GetSecretReference()
+	if err != nil {
+		return nil, err
+	}
+	credential.SecretReference = ref
 
 	return credential, nil
 }
+
+// GetSecretReference returns the external secret reference of the credential, nil for credentials storing their secret
+func (c *Credential) GetSecretReference() (*models.CredentialSecretReference, error) {
+	if c.SecretReference.Status != pgtype.Present {
+		return nil, nil
+	}
+	var ref models.CredentialSecretReference
+	if err := json.Unmarshal(c.SecretReference.Bytes, &ref); err != nil {
+		return nil, fmt.Errorf("unmarshal secret reference: %w", err)
+	}
+	if ref.Backend == "" {
+		return nil, nil
+	}
+	return &ref, nil
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+)
+
+type CredentialOnboardingMode string
+
+const (
+	CredentialOnboardingModeAll      CredentialOnboardingMode = "all"
+	CredentialOnboardingModeMatching CredentialOnboardingMode = "matching"
+	CredentialOnboardingModeNotify   CredentialOnboardingMode = "notify"
+)
+
+// CredentialOnboardingPolicy drives the periodic rediscovery of the integrations reachable by a credential
+type CredentialOnboardingPolicy struct {
+	CredentialID    uuid.UUID `gorm:"primaryKey;type:uuid"`
+	Mode            CredentialOnboardingMode
+	NamePattern     string
+	LabelSelector   pgtype.JSONB `gorm:"default:null"`
+	ArchiveMissing  bool
+	IntervalMinutes int
+
+	LastDiscoveryAt    *time.Time
+	LastDiscoveryError string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (p *CredentialOnboardingPolicy) GetLabelSelector() (*models.IntegrationGroupSelector, error) {
+	if p.LabelSelector.Status != pgtype.Present {
+		return nil, nil
+	}
+	var selector models.IntegrationGroupSelector
+	if err := json.Unmarshal(p.LabelSelector.Bytes, &selector); err != nil {
+		return nil, err
+	}
+	return &selector, nil
+}
+
+func (p *CredentialOnboardingPolicy) ToApi() (*models.CredentialOnboardingPolicy, error) {
+	selector, err := p.GetLabelSelector()
+	if err != nil {
+		return nil, err
+	}
+	return &models.CredentialOnboardingPolicy{
+		Mode:               string(p.Mode),
+		NamePattern:        p.NamePattern,
+		LabelSelector:      selector,
+		ArchiveMissing:     p.ArchiveMissing,
+		IntervalMinutes:    p.IntervalMinutes,
+		LastDiscoveryAt:    p.LastDiscoveryAt,
+		LastDiscoveryError: p.LastDiscoveryError,
+	}, nil
+}
+
+// PendingIntegration is an integration discovered by a credential that the onboarding policy did not add
+type PendingIntegration struct {
+	CredentialID uuid.UUID `gorm:"primaryKey;type:uuid"`
+	ProviderID   string    `gorm:"primaryKey"`
+	Name         string
+	FirstSeenAt  time.Time
+	LastSeenAt   time.Time
+}
+
+func (p *PendingIntegration) ToApi() models.PendingIntegration {
+	return models.PendingIntegration{
+		ProviderID:  p.ProviderID,
+		Name:        p.Name,
+		FirstSeenAt: p.FirstSeenAt,
+		LastSeenAt:  p.LastSeenAt,
+	}
+}
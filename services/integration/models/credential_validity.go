@@ -0,0 +1,125 @@
+package models
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/opengovern/og-util/pkg/integration"
+)
+
+// CredentialExpiresAtAnnotation is the annotation plugins set on the integrations they discover to report
+// the expiry of the credential, as an RFC 3339 time
+const CredentialExpiresAtAnnotation = "platform/credential-expires-at"
+
+// ReportedCredentialExpiry returns the earliest credential expiry reported by the plugin on the discovered integrations,
+// nil when none is reported
+func ReportedCredentialExpiry(integrations []integration.Integration) *time.Time {
+	var expiresAt *time.Time
+	for _, i := range integrations {
+		if len(i.Annotations.Bytes) == 0 {
+			continue
+		}
+		var annotations map[string]string
+		if err := json.Unmarshal(i.Annotations.Bytes, &annotations); err != nil {
+			continue
+		}
+		value, ok := annotations[CredentialExpiresAtAnnotation]
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			continue
+		}
+		if expiresAt == nil || t.Before(*expiresAt) {
+			expiresAt = &t
+		}
+	}
+	return expiresAt
+}
+
+// CredentialValidity finds the validity of a secret from the certificates and JWTs in its values, it is the fallback
+// for credentials without an expiry given on create or update or reported by the plugin.
+// The earliest expiry wins, nil is returned when the secret has no certificate or token with an expiry.
+func CredentialValidity(secret map[string]any) (issuedAt, expiresAt *time.Time, source CredentialExpirySource) {
+	consider := func(issued, expires time.Time, s CredentialExpirySource) {
+		if expires.IsZero() || (expiresAt != nil && !expires.Before(*expiresAt)) {
+			return
+		}
+		expiresAt = &expires
+		issuedAt = nil
+		if !issued.IsZero() {
+			issuedAt = &issued
+		}
+		source = s
+	}
+
+	for _, value := range secret {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		str = strings.TrimSpace(str)
+
+		data := []byte(str)
+		if !bytes.Contains(data, []byte("-----BEGIN")) {
+			// certificates are often uploaded base64 encoded
+			if decoded, err := base64.StdEncoding.DecodeString(str); err == nil && bytes.Contains(decoded, []byte("-----BEGIN")) {
+				data = decoded
+			}
+		}
+		for rest := data; ; {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				continue
+			}
+			consider(cert.NotBefore, cert.NotAfter, CredentialExpirySourceCertificate)
+		}
+
+		if issued, expires, ok := jwtValidity(str); ok {
+			consider(issued, expires, CredentialExpirySourceToken)
+		}
+	}
+	return issuedAt, expiresAt, source
+}
+
+func jwtValidity(token string) (issuedAt, expiresAt time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	var claims struct {
+		IssuedAt  *json.Number `json:"iat"`
+		ExpiresAt *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	exp, err := claims.ExpiresAt.Int64()
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	if claims.IssuedAt != nil {
+		if iat, err := claims.IssuedAt.Int64(); err == nil {
+			issuedAt = time.Unix(iat, 0)
+		}
+	}
+	return issuedAt, time.Unix(exp, 0), true
+}
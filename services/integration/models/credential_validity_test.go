@@ -0,0 +1,138 @@
+package models
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/opengovern/og-util/pkg/integration"
+)
+
+func testCertificate(t *testing.T, notBefore, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func testJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func TestCredentialValidity(t *testing.T) {
+	issued := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	certExpiry := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	tokenExpiry := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	cert := testCertificate(t, issued, certExpiry)
+	token := testJWT(fmt.Sprintf(`{"iat":%d,"exp":%d}`, issued.Unix(), tokenExpiry.Unix()))
+
+	tests := []struct {
+		name      string
+		secret    map[string]any
+		expiresAt *time.Time
+		issuedAt  *time.Time
+		source    CredentialExpirySource
+	}{
+		{name: "no certificate or token", secret: map[string]any{"client_secret": "abc", "port": 443}},
+		{name: "pem certificate", secret: map[string]any{"certificate": cert}, expiresAt: &certExpiry, issuedAt: &issued, source: CredentialExpirySourceCertificate},
+		{name: "base64 certificate", secret: map[string]any{"certificate": base64.StdEncoding.EncodeToString([]byte(cert))}, expiresAt: &certExpiry, issuedAt: &issued, source: CredentialExpirySourceCertificate},
+		{name: "token", secret: map[string]any{"token": token}, expiresAt: &tokenExpiry, issuedAt: &issued, source: CredentialExpirySourceToken},
+		{name: "earliest expiry wins", secret: map[string]any{"certificate": cert, "token": token}, expiresAt: &tokenExpiry, issuedAt: &issued, source: CredentialExpirySourceToken},
+		{name: "token without expiry", secret: map[string]any{"token": testJWT(`{"sub":"user"}`)}},
+		{name: "dotted value that is not a token", secret: map[string]any{"host": "a.b.c"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			issuedAt, expiresAt, source := CredentialValidity(tc.secret)
+			if !equalTime(expiresAt, tc.expiresAt) {
+				t.Errorf("expected expiry %v, got %v", tc.expiresAt, expiresAt)
+			}
+			if !equalTime(issuedAt, tc.issuedAt) {
+				t.Errorf("expected issue time %v, got %v", tc.issuedAt, issuedAt)
+			}
+			if source != tc.source {
+				t.Errorf("expected source %q, got %q", tc.source, source)
+			}
+		})
+	}
+}
+
+func TestReportedCredentialExpiry(t *testing.T) {
+	early := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	withAnnotations := func(annotations map[string]string) integration.Integration {
+		var jsonb pgtype.JSONB
+		if annotations != nil {
+			data, err := json.Marshal(annotations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := jsonb.Set(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return integration.Integration{Annotations: jsonb}
+	}
+
+	tests := []struct {
+		name         string
+		integrations []integration.Integration
+		want         *time.Time
+	}{
+		{name: "no integrations"},
+		{name: "no annotations", integrations: []integration.Integration{withAnnotations(nil), withAnnotations(map[string]string{"region": "eu"})}},
+		{
+			name: "earliest reported expiry",
+			integrations: []integration.Integration{
+				withAnnotations(map[string]string{CredentialExpiresAtAnnotation: late.Format(time.RFC3339)}),
+				withAnnotations(map[string]string{CredentialExpiresAtAnnotation: early.Format(time.RFC3339)}),
+			},
+			want: &early,
+		},
+		{
+			name: "invalid time is ignored",
+			integrations: []integration.Integration{
+				withAnnotations(map[string]string{CredentialExpiresAtAnnotation: "next week"}),
+				withAnnotations(map[string]string{CredentialExpiresAtAnnotation: late.Format(time.RFC3339)}),
+			},
+			want: &late,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ReportedCredentialExpiry(tc.integrations); !equalTime(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -1,6 +1,38 @@
 package models
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+)
+
 type IntegrationGroup struct {
 	Name  string `gorm:"primaryKey" json:"name"`
 	Query string `json:"query"`
+	// Selector matches the members by labels and annotations, its members are materialized in IntegrationGroupMember
+	Selector pgtype.JSONB `gorm:"default:null" json:"selector"`
+}
+
+// IntegrationGroupMember is an integration matched by the selector of a group
+type IntegrationGroupMember struct {
+	GroupName     string    `gorm:"primaryKey"`
+	IntegrationID uuid.UUID `gorm:"primaryKey;type:uuid"`
+	JoinedAt      time.Time
+}
+
+type IntegrationGroupMembershipAction string
+
+const (
+	IntegrationGroupMembershipJoined IntegrationGroupMembershipAction = "joined"
+	IntegrationGroupMembershipLeft   IntegrationGroupMembershipAction = "left"
+)
+
+// IntegrationGroupMembershipEvent records an integration joining or leaving a group
+type IntegrationGroupMembershipEvent struct {
+	ID            uint      `gorm:"primaryKey"`
+	GroupName     string    `gorm:"index"`
+	IntegrationID uuid.UUID `gorm:"type:uuid;index"`
+	Action        IntegrationGroupMembershipAction
+	CreatedAt     time.Time `gorm:"index"`
 }
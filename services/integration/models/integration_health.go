@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+)
+
+type IntegrationHealthStatus string
+
+const (
+	IntegrationHealthStatusHealthy   IntegrationHealthStatus = "healthy"
+	IntegrationHealthStatusDegraded  IntegrationHealthStatus = "degraded"
+	IntegrationHealthStatusUnhealthy IntegrationHealthStatus = "unhealthy"
+)
+
+// IntegrationHealthCheck is the result of a single healthcheck of an integration.
+// Degraded integrations pass the healthcheck while their discovery keeps failing on missing permissions.
+type IntegrationHealthCheck struct {
+	ID                 uint      `gorm:"primarykey"`
+	IntegrationID      uuid.UUID `gorm:"type:uuid;index:idx_integration_health_check"`
+	CheckedAt          time.Time `gorm:"index:idx_integration_health_check"`
+	Status             IntegrationHealthStatus
+	Error              string
+	LatencyMs          int64
+	MissingPermissions pgtype.JSONB `gorm:"default:null"`
+}
+
+func (c *IntegrationHealthCheck) ToApi() (models.IntegrationHealthCheck, error) {
+	item := models.IntegrationHealthCheck{
+		CheckedAt: c.CheckedAt,
+		Status:    string(c.Status),
+		Error:     c.Error,
+		LatencyMs: c.LatencyMs,
+	}
+	if c.MissingPermissions.Status == pgtype.Present {
+		if err := json.Unmarshal(c.MissingPermissions.Bytes, &item.MissingPermissions); err != nil {
+			return item, err
+		}
+	}
+	return item, nil
+}
@@ -2,8 +2,10 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/jackc/pgtype"
 	"github.com/opengovern/og-util/pkg/integration"
+	"github.com/opengovern/opensecurity/pkg/pluginsign"
 	"time"
 )
 
@@ -11,10 +13,13 @@ type Manifest struct {
 	IntegrationType          integration.Type `json:"IntegrationType" yaml:"IntegrationType"`
 	DescriberURL             string           `json:"DescriberURL" yaml:"DescriberURL"`
 	DescriberTag             string           `json:"DescriberTag" yaml:"DescriberTag"`
+	Version                  string           `json:"Version" yaml:"Version"`
 	Publisher                string           `json:"Publisher" yaml:"Publisher"`
 	Author                   string           `json:"Author" yaml:"Author"`
 	SupportedPlatformVersion string           `json:"SupportedPlatformVersion" yaml:"SupportedPlatformVersion"`
 	UpdateDate               string           `json:"UpdateDate" yaml:"UpdateDate"`
+	// ResourceTypePermissions lists per resource type the permissions its discovery needs, optional
+	ResourceTypePermissions map[string][]string `json:"ResourceTypePermissions,omitempty" yaml:"ResourceTypePermissions,omitempty"`
 }
 
 type IntegrationPluginInstallState string
@@ -58,6 +63,28 @@ type IntegrationPlugin struct {
 	DescriberTag             string
 	OperationalStatusUpdates pgtype.JSONB `gorm:"default:'[]'"`
 	Tags                     pgtype.JSONB
+
+	// Version is the active version of the plugin, PinnedVersion blocks switching to any other version
+	Version       string
+	PinnedVersion string
+
+	// SignatureStatus is the verification result of the installed bundle, empty for plugins installed before verification
+	SignatureStatus         string
+	SignedBy                string
+	IntegrationPluginSHA256 string
+	CloudQLPluginSHA256     string
+	VerifiedAt              *time.Time
+}
+
+// CheckBinary returns an error when the binary does not match the checksums recorded at install
+func (ip IntegrationPlugin) CheckBinary(binary IntegrationPluginBinary) error {
+	if ip.IntegrationPluginSHA256 != "" && pluginsign.Checksum(binary.IntegrationPlugin) != ip.IntegrationPluginSHA256 {
+		return fmt.Errorf("integration-plugin binary of %s does not match the verified checksum", ip.PluginID)
+	}
+	if ip.CloudQLPluginSHA256 != "" && pluginsign.Checksum(binary.CloudQlPlugin) != ip.CloudQLPluginSHA256 {
+		return fmt.Errorf("cloudql-plugin binary of %s does not match the verified checksum", ip.PluginID)
+	}
+	return nil
 }
 
 func (ip IntegrationPlugin) GetStringOperationalStatusUpdates() ([]string, error) {
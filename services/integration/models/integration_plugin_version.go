@@ -0,0 +1,149 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// ErrUnknownPlatformVersion is returned when the platform version is not a semantic version, e.g. on development builds
+var ErrUnknownPlatformVersion = errors.New("platform version is not a semantic version")
+
+// IntegrationPluginVersion is a retained bundle of a plugin, kept to switch back to it without downloading it again
+type IntegrationPluginVersion struct {
+	ID                       uint   `gorm:"primaryKey"`
+	PluginID                 string `gorm:"uniqueIndex:idx_plugin_version"`
+	Version                  string `gorm:"uniqueIndex:idx_plugin_version"`
+	URL                      string
+	DescriberURL             string
+	DescriberTag             string
+	SupportedPlatformVersion string
+	SignatureStatus          string
+	SignedBy                 string
+	CreatedAt                time.Time
+	// ActivatedAt is the last time the version became the active one, the previous version is the latest activated before the current
+	ActivatedAt time.Time
+
+	IntegrationPlugin []byte `gorm:"type:bytea"`
+	CloudQlPlugin     []byte `gorm:"type:bytea"`
+}
+
+// PluginVersion returns the version of the bundle, the describer tag is used for manifests without a version
+func (m Manifest) PluginVersion() string {
+	if m.Version != "" {
+		return m.Version
+	}
+	return m.DescriberTag
+}
+
+// CheckPlatformCompatibility checks the platform version against the SupportedPlatformVersion constraint of a plugin.
+// The constraint is a list of alternatives separated by ||, each a comma separated list of
+// comparisons (>=, >, <=, <, =) or patterns where X matches any number, e.g. ">= 2.1.0, < 3.0.0 || 4.X.X".
+// An empty constraint accepts every platform version.
+func CheckPlatformCompatibility(constraint, platformVersion string) error {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		return nil
+	}
+	platform := canonicalVersion(platformVersion)
+	if !semver.IsValid(platform) {
+		return fmt.Errorf("%w: %q", ErrUnknownPlatformVersion, platformVersion)
+	}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		ok := true
+		for _, c := range strings.Split(alternative, ",") {
+			match, err := matchVersionConstraint(strings.TrimSpace(c), platform)
+			if err != nil {
+				return err
+			}
+			if !match {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("plugin supports platform versions %q, platform version is %s", constraint, platformVersion)
+}
+
+func matchVersionConstraint(constraint, platform string) (bool, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", "==", ">", "<", "="} {
+		if strings.HasPrefix(constraint, o) {
+			op = o
+			break
+		}
+	}
+	version := strings.TrimSpace(strings.TrimPrefix(constraint, op))
+
+	if op == "" && strings.ContainsAny(version, "Xx*") {
+		return matchVersionPattern(version, platform)
+	}
+	v := canonicalVersion(version)
+	if !semver.IsValid(v) {
+		return false, fmt.Errorf("invalid supported platform version %q", constraint)
+	}
+	cmp := semver.Compare(platform, v)
+	switch op {
+	case ">=":
+		return cmp >= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case "<":
+		return cmp < 0, nil
+	default:
+		return cmp == 0, nil
+	}
+}
+
+// matchVersionPattern matches patterns like 2.X.X or 2.1.X, where X matches any number,
+// against the major or major.minor of the platform version
+func matchVersionPattern(pattern, platform string) (bool, error) {
+	parts := strings.Split(strings.TrimPrefix(pattern, "v"), ".")
+	fixed := 0
+	for fixed < len(parts) && !isVersionWildcard(parts[fixed]) {
+		fixed++
+	}
+	for _, part := range parts[fixed:] {
+		if !isVersionWildcard(part) {
+			return false, fmt.Errorf("invalid supported platform version %q", pattern)
+		}
+	}
+	if fixed == 0 {
+		return true, nil
+	}
+
+	prefix := canonicalVersion(strings.Join(parts[:fixed], "."))
+	if len(parts) > 3 || !semver.IsValid(prefix) {
+		return false, fmt.Errorf("invalid supported platform version %q", pattern)
+	}
+	switch fixed {
+	case len(parts):
+		// no wildcard, e.g. a prerelease like 2.1.0-x
+		return semver.Compare(platform, prefix) == 0, nil
+	case 1:
+		return semver.Major(platform) == semver.Major(prefix), nil
+	default:
+		return semver.MajorMinor(platform) == semver.MajorMinor(prefix), nil
+	}
+}
+
+func isVersionWildcard(part string) bool {
+	return part == "X" || part == "x" || part == "*"
+}
+
+func canonicalVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckPlatformCompatibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint string
+		platform   string
+		compatible bool
+		invalid    bool
+		unknown    bool
+	}{
+		{name: "empty constraint", constraint: "", platform: "dev", compatible: true},
+		{name: "range", constraint: ">= 2.1.0, < 3.0.0", platform: "2.4.1", compatible: true},
+		{name: "below range", constraint: ">= 2.1.0, < 3.0.0", platform: "2.0.9"},
+		{name: "upper bound is exclusive", constraint: ">= 2.1.0, < 3.0.0", platform: "v3.0.0"},
+		{name: "second alternative", constraint: ">= 2.1.0, < 3.0.0 || 4.X.X", platform: "4.2.0", compatible: true},
+		{name: "major pattern", constraint: "2.X.X", platform: "v2.10.3", compatible: true},
+		{name: "major pattern of another major", constraint: "2.X.X", platform: "12.0.0"},
+		{name: "minor pattern", constraint: "2.1.x", platform: "2.1.7", compatible: true},
+		{name: "minor pattern of another minor", constraint: "2.1.*", platform: "2.11.0"},
+		{name: "exact", constraint: "= 2.1.0", platform: "2.1.0", compatible: true},
+		{name: "exact mismatch", constraint: "2.1.0", platform: "2.1.1"},
+		{name: "prerelease is lower", constraint: ">= 2.1.0", platform: "2.1.0-rc.1"},
+		{name: "short versions", constraint: "> 2", platform: "2.0.1", compatible: true},
+		{name: "wildcard in the middle", constraint: "2.X.1", platform: "2.0.1", invalid: true},
+		{name: "invalid version", constraint: ">= two", platform: "2.0.0", invalid: true},
+		{name: "unknown platform version", constraint: ">= 2.0.0", platform: "main-abc123", unknown: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckPlatformCompatibility(tc.constraint, tc.platform)
+			switch {
+			case tc.unknown:
+				if !errors.Is(err, ErrUnknownPlatformVersion) {
+					t.Fatalf("expected ErrUnknownPlatformVersion, got %v", err)
+				}
+			case tc.invalid:
+				if err == nil {
+					t.Fatal("expected an invalid constraint error")
+				}
+			case tc.compatible && err != nil:
+				t.Fatalf("expected compatible, got %v", err)
+			case !tc.compatible && err == nil:
+				t.Fatal("expected incompatible")
+			}
+		})
+	}
+}
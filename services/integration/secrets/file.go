@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/opengovern/opensecurity/services/integration/config"
+)
+
+// fileBackend reads secrets mounted in the base path: a JSON or YAML file of the values,
+// or a directory with a file per value as Kubernetes and the secrets store CSI driver mount them
+type fileBackend struct {
+	basePath string
+}
+
+func newFileBackend(cnf config.FileSecretBackendConfig) *fileBackend {
+	return &fileBackend{basePath: cnf.BasePath}
+}
+
+// resolve returns the path inside the base path, symlinks are followed before checking
+func (b *fileBackend) resolve(path string) (string, error) {
+	base, err := filepath.EvalSymlinks(b.basePath)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(base, path))
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(base, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside of the secrets directory", path)
+	}
+	return resolved, nil
+}
+
+func (b *fileBackend) Read(_ context.Context, path string) (map[string]any, error) {
+	resolved, err := b.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(resolved)
+		if err != nil {
+			return nil, err
+		}
+		data := make(map[string]any)
+		for _, entry := range entries {
+			// mounted secrets keep their data in hidden ..data directories
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			entryPath := filepath.Join(resolved, entry.Name())
+			entryInfo, err := os.Stat(entryPath)
+			if err != nil {
+				return nil, err
+			}
+			if entryInfo.IsDir() {
+				continue
+			}
+			content, err := os.ReadFile(entryPath)
+			if err != nil {
+				return nil, err
+			}
+			data[entry.Name()] = strings.TrimRight(string(content), "\r\n")
+		}
+		return data, nil
+	}
+
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	switch strings.ToLower(filepath.Ext(resolved)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	default:
+		err = json.Unmarshal(content, &data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", filepath.Base(resolved), err)
+	}
+	return data, nil
+}
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/opengovern/opensecurity/services/integration/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// kubernetesBackend reads Kubernetes secrets, every data key of the secret is a value of the credential
+type kubernetesBackend struct {
+	kubeClient client.Client
+	namespaces []string
+}
+
+// newKubernetesBackend requires an explicit list of namespaces, the namespace of the platform is refused
+// since its secrets are the ones of the platform services
+func newKubernetesBackend(kubeClient client.Client, cnf config.KubernetesSecretBackendConfig, platformNamespace string) (*kubernetesBackend, error) {
+	if len(cnf.Namespaces) == 0 {
+		return nil, errors.New("kubernetes secret backend requires the namespaces secrets can be read from")
+	}
+	for _, namespace := range cnf.Namespaces {
+		if namespace == "" {
+			return nil, errors.New("kubernetes secret backend namespace is empty")
+		}
+		if namespace == platformNamespace {
+			return nil, fmt.Errorf("kubernetes secret backend can not read secrets from the platform namespace %q", namespace)
+		}
+	}
+	return &kubernetesBackend{kubeClient: kubeClient, namespaces: cnf.Namespaces}, nil
+}
+
+// Read reads the secret at "namespace/name", a bare name is read from the first allowed namespace
+func (b *kubernetesBackend) Read(ctx context.Context, path string) (map[string]any, error) {
+	namespace, name, ok := strings.Cut(path, "/")
+	if !ok {
+		namespace, name = b.namespaces[0], path
+	}
+	if !slices.Contains(b.namespaces, namespace) {
+		return nil, fmt.Errorf("namespace %q is not allowed", namespace)
+	}
+
+	var secret corev1.Secret
+	if err := b.kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		return nil, err
+	}
+	data := make(map[string]any, len(secret.Data)+len(secret.StringData))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	for k, v := range secret.StringData {
+		data[k] = v
+	}
+	return data, nil
+}
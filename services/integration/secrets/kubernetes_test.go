@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opengovern/opensecurity/services/integration/config"
+)
+
+func TestNewKubernetesBackend(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		wantErr    bool
+	}{
+		{name: "no namespaces", wantErr: true},
+		{name: "empty namespace", namespaces: []string{""}, wantErr: true},
+		{name: "platform namespace", namespaces: []string{"integrations", "opensecurity"}, wantErr: true},
+		{name: "other namespaces", namespaces: []string{"integrations", "team-a"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newKubernetesBackend(nil, config.KubernetesSecretBackendConfig{Enabled: true, Namespaces: tc.namespaces}, "opensecurity")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestKubernetesBackendReadRejectsOtherNamespaces(t *testing.T) {
+	backend, err := newKubernetesBackend(nil, config.KubernetesSecretBackendConfig{Enabled: true, Namespaces: []string{"integrations"}}, "opensecurity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"opensecurity/postgres", "kube-system/token"} {
+		if _, err := backend.Read(context.Background(), path); err == nil {
+			t.Errorf("expected %s to be rejected", path)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package secrets resolves the secrets of integration credentials. A credential either stores its secret
+// encrypted with the platform vault, or references it in an external backend so it is never persisted
+// by the integration service and is read each time it is used.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opengovern/og-util/pkg/vault"
+	"github.com/opengovern/opensecurity/services/integration/api/models"
+	"github.com/opengovern/opensecurity/services/integration/config"
+	models2 "github.com/opengovern/opensecurity/services/integration/models"
+	"go.uber.org/zap"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	BackendVault      = "vault"
+	BackendKubernetes = "kubernetes"
+	BackendFile       = "file"
+)
+
+var ErrBackendNotEnabled = errors.New("secret backend is not enabled")
+
+// Backend reads the secret at path, the values of the secret are the credentials
+type Backend interface {
+	Read(ctx context.Context, path string) (map[string]any, error)
+}
+
+type Store struct {
+	logger   *zap.Logger
+	vault    vault.VaultSourceConfig
+	backends map[string]Backend
+}
+
+func NewStore(logger *zap.Logger, vaultSc vault.VaultSourceConfig, cnf config.SecretBackendsConfig, kubeClient client.Client) *Store {
+	s := &Store{
+		logger:   logger.Named("secrets"),
+		vault:    vaultSc,
+		backends: make(map[string]Backend),
+	}
+	if cnf.Vault.Address != "" {
+		s.backends[BackendVault] = newVaultBackend(cnf.Vault)
+	}
+	if cnf.Kubernetes.Enabled {
+		backend, err := newKubernetesBackend(kubeClient, cnf.Kubernetes, os.Getenv("CURRENT_NAMESPACE"))
+		if err != nil {
+			s.logger.Error("secret backend is not enabled", zap.String("backend", BackendKubernetes), zap.Error(err))
+		} else {
+			s.backends[BackendKubernetes] = backend
+		}
+	}
+	if cnf.File.BasePath != "" {
+		s.backends[BackendFile] = newFileBackend(cnf.File)
+	}
+	for name := range s.backends {
+		s.logger.Info("secret backend enabled", zap.String("backend", name))
+	}
+	return s
+}
+
+// Read reads the secret of a reference, selecting its key when set
+func (s *Store) Read(ctx context.Context, ref models.CredentialSecretReference) (map[string]any, error) {
+	backend, ok := s.backends[ref.Backend]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrBackendNotEnabled, ref.Backend)
+	}
+	if ref.Path == "" {
+		return nil, errors.New("secret reference path is empty")
+	}
+	data, err := backend.Read(ctx, ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s secret %s: %w", ref.Backend, ref.Path, err)
+	}
+	if ref.Key == "" {
+		return data, nil
+	}
+	switch value := data[ref.Key].(type) {
+	case map[string]any:
+		return value, nil
+	case string:
+		var values map[string]any
+		if err := json.Unmarshal([]byte(value), &values); err != nil {
+			return nil, fmt.Errorf("key %s of %s secret %s is not a JSON object", ref.Key, ref.Backend, ref.Path)
+		}
+		return values, nil
+	case nil:
+		return nil, fmt.Errorf("key %s not found in %s secret %s", ref.Key, ref.Backend, ref.Path)
+	default:
+		return nil, fmt.Errorf("key %s of %s secret %s is not an object", ref.Key, ref.Backend, ref.Path)
+	}
+}
+
+// Resolve returns the secret of the credential, decrypting the stored secret or reading the referenced one
+func (s *Store) Resolve(ctx context.Context, credential *models2.Credential) (map[string]any, error) {
+	ref, err := credential.GetSecretReference()
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		return s.vault.Decrypt(ctx, credential.Secret)
+	}
+	return s.Read(ctx, *ref)
+}
+
+// Ciphertext returns the secret of the credential encrypted for the describers.
+// A referenced secret is encrypted on each call and the ciphertext is not stored.
+func (s *Store) Ciphertext(ctx context.Context, credential *models2.Credential) (string, error) {
+	ref, err := credential.GetSecretReference()
+	if err != nil {
+		return "", err
+	}
+	if ref == nil {
+		return credential.Secret, nil
+	}
+	data, err := s.Read(ctx, *ref)
+	if err != nil {
+		return "", err
+	}
+	return s.vault.Encrypt(ctx, data)
+}
@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	vaultApi "github.com/hashicorp/vault/api"
+	kubernetesAuth "github.com/hashicorp/vault/api/auth/kubernetes"
+	"github.com/opengovern/opensecurity/services/integration/config"
+)
+
+// vaultBackend reads KV v2 secrets of a HashiCorp Vault, logging in with the kubernetes service account of the service
+type vaultBackend struct {
+	cnf config.VaultSecretBackendConfig
+
+	mu     sync.Mutex
+	client *vaultApi.Client
+}
+
+func newVaultBackend(cnf config.VaultSecretBackendConfig) *vaultBackend {
+	if cnf.Mount == "" {
+		cnf.Mount = "secret"
+	}
+	if cnf.AuthMount == "" {
+		cnf.AuthMount = "kubernetes"
+	}
+	return &vaultBackend{cnf: cnf}
+}
+
+// login returns the logged in client, logging in again when force is set or there is no client yet
+func (b *vaultBackend) login(ctx context.Context, force bool) (*vaultApi.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.client != nil && !force {
+		return b.client, nil
+	}
+
+	vaultCfg := vaultApi.DefaultConfig()
+	vaultCfg.Address = b.cnf.Address
+	client, err := vaultApi.NewClient(vaultCfg)
+	if err != nil {
+		return nil, err
+	}
+	k8sAuth, err := kubernetesAuth.NewKubernetesAuth(b.cnf.Role, kubernetesAuth.WithMountPath(b.cnf.AuthMount))
+	if err != nil {
+		return nil, err
+	}
+	authInfo, err := client.Auth().Login(ctx, k8sAuth)
+	if err != nil {
+		return nil, err
+	}
+	if authInfo == nil {
+		return nil, errors.New("vault login returned no auth info")
+	}
+	b.client = client
+	return client, nil
+}
+
+func (b *vaultBackend) Read(ctx context.Context, path string) (map[string]any, error) {
+	client, err := b.login(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.KVv2(b.cnf.Mount).Get(ctx, path)
+	var respErr *vaultApi.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden {
+		// the token expired, log in again
+		if client, err = b.login(ctx, true); err != nil {
+			return nil, err
+		}
+		secret, err = client.KVv2(b.cnf.Mount).Get(ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("secret has no data")
+	}
+	return secret.Data, nil
+}
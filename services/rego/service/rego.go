@@ -187,7 +187,17 @@ func (r *RegoEngine) getRegoFunctionForTables(ctx context.Context) ([]func(*rego
 }
 
 func (r *RegoEngine) Evaluate(ctx context.Context, policies []string, query string) (rego.ResultSet, error) {
-	params := append(r.regoFunctions, rego.Query(query))
+	return r.EvaluateWithInput(ctx, policies, query, nil)
+}
+
+// EvaluateWithInput evaluates the query against the given policies, exposing input as the rego `input` document.
+func (r *RegoEngine) EvaluateWithInput(ctx context.Context, policies []string, query string, input any) (rego.ResultSet, error) {
+	params := make([]func(*rego.Rego), 0, len(r.regoFunctions)+len(policies)+2)
+	params = append(params, r.regoFunctions...)
+	params = append(params, rego.Query(query))
+	if input != nil {
+		params = append(params, rego.Input(input))
+	}
 	for i, policy := range policies {
 		params = append(params, rego.Module(fmt.Sprintf("policy_%d.rego", i+1), policy))
 	}
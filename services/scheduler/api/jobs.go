@@ -4,6 +4,7 @@ import (
 	"time"
 
 	queryrunner "github.com/opengovern/opensecurity/jobs/query-runner-job"
+	"github.com/opengovern/opensecurity/pkg/types"
 )
 
 type QuickScanSequenceStatus string
@@ -539,10 +540,42 @@ type QuickScanSequence struct {
 	FailureMessage       string                  `json:"failure_message"`
 	CreatedBy            string                  `json:"created_by"`
 	ComplianceQuickRunID *string                 `json:"compliance_quick_run_id"`
+	Gate                 *QuickScanGate          `json:"gate,omitempty"`
+}
+
+// QuickScanGate is the pass/fail policy of a quick scan used as a CI/CD gate.
+// Without a gate any failing result fails the scan.
+type QuickScanGate struct {
+	// MaxFailures is the number of failing results allowed per severity, severities not listed are unlimited
+	MaxFailures map[types.ComplianceResultSeverity]int `json:"max_failures"`
+	// MandatoryControls fail the gate on any failing result, even if it already fails in the baseline
+	MandatoryControls []string `json:"mandatory_controls"`
+	// BaselineRunID is a finished quick scan sequence, results already failing in it do not count against MaxFailures
+	BaselineRunID *string `json:"baseline_run_id,omitempty"`
 }
 
 type CreateAuditJobRequest struct {
-	FrameworkID    string   `json:"framework_id"`
-	IntegrationIDs []string `json:"integration_ids"`
-	IncludeResults []string `json:"include_results"`
+	FrameworkID    string         `json:"framework_id"`
+	IntegrationIDs []string       `json:"integration_ids"`
+	IncludeResults []string       `json:"include_results"`
+	Gate           *QuickScanGate `json:"gate,omitempty"`
+}
+
+// DiscoveryPermissionErrorCodes are the default describer error codes of resources the credential is not allowed to read,
+// discovery.permission_error_codes of the scheduler config replaces them
+var DiscoveryPermissionErrorCodes = []string{
+	"AuthorizationFailed", "AccessDeniedException", "AccessDenied", "InsufficientPrivilegesException", "403",
+}
+
+// IntegrationDiscoveryPermissionFailure is a resource type whose latest discovery jobs failed with a permission error
+type IntegrationDiscoveryPermissionFailure struct {
+	ResourceType        string    `json:"resource_type"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	ErrorCode           string    `json:"error_code"`
+	FailureMessage      string    `json:"failure_message"`
+	LastFailedAt        time.Time `json:"last_failed_at"`
+}
+
+type GetIntegrationDiscoveryPermissionFailuresResponse struct {
+	Items []IntegrationDiscoveryPermissionFailure `json:"items"`
 }
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"time"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+type NotificationDestinationType string
+
+const (
+	NotificationDestinationTypeWebhook NotificationDestinationType = "webhook"
+	NotificationDestinationTypeSlack   NotificationDestinationType = "slack"
+	NotificationDestinationTypeEmail   NotificationDestinationType = "email"
+)
+
+// NotificationEventType is the kind of event a rule is subscribed to
+type NotificationEventType string
+
+const (
+	// NotificationEventTypeComplianceDrift is sent with the drift events of finished compliance jobs
+	NotificationEventTypeComplianceDrift NotificationEventType = "compliance.drift"
+	// NotificationEventTypePendingIntegrations is sent when a credential discovers integrations left pending by its onboarding policy
+	NotificationEventTypePendingIntegrations NotificationEventType = "integration.pending"
+)
+
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliveryStatusPending   NotificationDeliveryStatus = "PENDING"
+	NotificationDeliveryStatusSucceeded NotificationDeliveryStatus = "SUCCEEDED"
+	NotificationDeliveryStatusFailed    NotificationDeliveryStatus = "FAILED"
+)
+
+type NotificationDestinationConfig struct {
+	// URL is used by webhook and slack destinations, it may carry a token and is never returned by the api
+	URL string `json:"url,omitempty" example:"https://hooks.example.com/opensecurity"`
+	// Secret is used to sign webhook payloads with HMAC-SHA256, it is never returned by the api
+	Secret string `json:"secret,omitempty"`
+	// Headers are sent with webhook payloads, e.g. an authorization header, they are never returned by the api
+	Headers    map[string]string `json:"headers,omitempty"`
+	Recipients []string          `json:"recipients,omitempty" example:"oncall@example.com"`
+}
+
+type NotificationDestination struct {
+	ID     uint                          `json:"id" example:"1"`
+	Name   string                        `json:"name" example:"on-call webhook"`
+	Type   NotificationDestinationType   `json:"type" enums:"webhook,slack,email" example:"webhook"`
+	Config NotificationDestinationConfig `json:"config"`
+	// URLHost is the host of the configured url, the url itself is not returned
+	URLHost string `json:"url_host,omitempty" example:"hooks.example.com"`
+	// HeaderNames are the names of the configured headers, their values are not returned
+	HeaderNames []string  `json:"header_names,omitempty" example:"Authorization"`
+	HasSecret   bool      `json:"has_secret"`
+	Enabled     bool      `json:"enabled" example:"true"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateNotificationDestinationRequest struct {
+	Name    string                        `json:"name" example:"on-call webhook"`
+	Type    NotificationDestinationType   `json:"type" enums:"webhook,slack,email" example:"webhook"`
+	Config  NotificationDestinationConfig `json:"config"`
+	Enabled *bool                         `json:"enabled" example:"true"`
+}
+
+type UpdateNotificationDestinationRequest struct {
+	Name    *string                        `json:"name" example:"on-call webhook"`
+	Config  *NotificationDestinationConfig `json:"config"`
+	Enabled *bool                          `json:"enabled" example:"true"`
+}
+
+type ListNotificationDestinationsResponse struct {
+	Items      []NotificationDestination `json:"items"`
+	TotalCount int                       `json:"total_count"`
+}
+
+type NotificationRule struct {
+	ID                  uint                             `json:"id" example:"1"`
+	Name                string                           `json:"name" example:"critical cis failures"`
+	DestinationID       uint                             `json:"destination_id" example:"1"`
+	EventTypes          []NotificationEventType          `json:"event_types" enums:"compliance.drift,integration.pending"`
+	FrameworkIDs        []string                         `json:"framework_ids" example:"azure_cis_v140"`
+	ControlIDs          []string                         `json:"control_ids" example:"azure_cis_v140_7_5"`
+	Severities          []types.ComplianceResultSeverity `json:"severities" example:"critical"`
+	IntegrationIDs      []string                         `json:"integration_ids"`
+	ComplianceStatuses  []types.ComplianceStatus         `json:"compliance_statuses" example:"alarm"`
+	IncludeInitialState bool                             `json:"include_initial_state" example:"false"`
+	Enabled             bool                             `json:"enabled" example:"true"`
+	CreatedBy           string                           `json:"created_by"`
+	CreatedAt           time.Time                        `json:"created_at"`
+	UpdatedAt           time.Time                        `json:"updated_at"`
+}
+
+type CreateNotificationRuleRequest struct {
+	Name                string                           `json:"name" example:"critical cis failures"`
+	DestinationID       uint                             `json:"destination_id" example:"1"`
+	EventTypes          []NotificationEventType          `json:"event_types" enums:"compliance.drift,integration.pending"`
+	FrameworkIDs        []string                         `json:"framework_ids" example:"azure_cis_v140"`
+	ControlIDs          []string                         `json:"control_ids" example:"azure_cis_v140_7_5"`
+	Severities          []types.ComplianceResultSeverity `json:"severities" example:"critical"`
+	IntegrationIDs      []string                         `json:"integration_ids"`
+	ComplianceStatuses  []types.ComplianceStatus         `json:"compliance_statuses" example:"alarm"`
+	IncludeInitialState bool                             `json:"include_initial_state" example:"false"`
+	Enabled             *bool                            `json:"enabled" example:"true"`
+}
+
+type UpdateNotificationRuleRequest struct {
+	Name                *string                          `json:"name" example:"critical cis failures"`
+	DestinationID       *uint                            `json:"destination_id" example:"1"`
+	EventTypes          []NotificationEventType          `json:"event_types" enums:"compliance.drift,integration.pending"`
+	FrameworkIDs        []string                         `json:"framework_ids" example:"azure_cis_v140"`
+	ControlIDs          []string                         `json:"control_ids" example:"azure_cis_v140_7_5"`
+	Severities          []types.ComplianceResultSeverity `json:"severities" example:"critical"`
+	IntegrationIDs      []string                         `json:"integration_ids"`
+	ComplianceStatuses  []types.ComplianceStatus         `json:"compliance_statuses" example:"alarm"`
+	IncludeInitialState *bool                            `json:"include_initial_state" example:"false"`
+	Enabled             *bool                            `json:"enabled" example:"true"`
+}
+
+type ListNotificationRulesResponse struct {
+	Items      []NotificationRule `json:"items"`
+	TotalCount int                `json:"total_count"`
+}
+
+type NotificationDelivery struct {
+	ID              uint                       `json:"id" example:"1"`
+	RuleID          uint                       `json:"rule_id" example:"1"`
+	DestinationID   uint                       `json:"destination_id" example:"1"`
+	ComplianceJobID uint                       `json:"compliance_job_id" example:"1"`
+	EventCount      int                        `json:"event_count" example:"12"`
+	Status          NotificationDeliveryStatus `json:"status" enums:"PENDING,SUCCEEDED,FAILED" example:"SUCCEEDED"`
+	Attempts        int                        `json:"attempts" example:"1"`
+	LastError       string                     `json:"last_error,omitempty"`
+	NextAttemptAt   *time.Time                 `json:"next_attempt_at,omitempty"`
+	DeliveredAt     *time.Time                 `json:"delivered_at,omitempty"`
+	CreatedAt       time.Time                  `json:"created_at"`
+	UpdatedAt       time.Time                  `json:"updated_at"`
+}
+
+type ListNotificationDeliveriesResponse struct {
+	Items      []NotificationDelivery `json:"items"`
+	TotalCount int64                  `json:"total_count"`
+}
+
+// NotificationPayload is the body posted to webhook destinations, Events is set for compliance drift
+// and PendingIntegrations for pending integrations
+type NotificationPayload struct {
+	DeliveryID          uint                               `json:"delivery_id"`
+	Event               NotificationEventType              `json:"event"`
+	RuleID              uint                               `json:"rule_id"`
+	RuleName            string                             `json:"rule_name"`
+	ComplianceJobID     uint                               `json:"compliance_job_id"`
+	FrameworkIDs        []string                           `json:"framework_ids"`
+	CredentialID        string                             `json:"credential_id,omitempty"`
+	IntegrationType     string                             `json:"integration_type,omitempty"`
+	TotalEvents         int                                `json:"total_events"`
+	Events              []types.ComplianceResultDriftEvent `json:"events"`
+	PendingIntegrations []PendingIntegration               `json:"pending_integrations,omitempty"`
+	CreatedAt           time.Time                          `json:"created_at"`
+}
+
+type PendingIntegration struct {
+	ProviderID string `json:"provider_id"`
+	Name       string `json:"name"`
+}
+
+// NotifyPendingIntegrationsRequest is sent by the integration service when the rediscovery of a credential
+// finds integrations its onboarding policy does not add
+type NotifyPendingIntegrationsRequest struct {
+	CredentialID    string               `json:"credential_id"`
+	IntegrationType string               `json:"integration_type"`
+	Integrations    []PendingIntegration `json:"integrations"`
+}
+
+type NotifyPendingIntegrationsResponse struct {
+	DeliveryCount int `json:"delivery_count"`
+}
@@ -0,0 +1,52 @@
+package api
+
+import "time"
+
+type ScheduleJobType string
+
+const (
+	ScheduleJobTypeDiscovery  ScheduleJobType = "discovery"
+	ScheduleJobTypeCompliance ScheduleJobType = "compliance"
+)
+
+type ScheduleTargetType string
+
+const (
+	ScheduleTargetTypeIntegration      ScheduleTargetType = "integration"
+	ScheduleTargetTypeIntegrationGroup ScheduleTargetType = "integration_group"
+	ScheduleTargetTypeFramework        ScheduleTargetType = "framework"
+)
+
+type Schedule struct {
+	ID             uint               `json:"id" example:"1"`
+	Name           string             `json:"name" example:"production hourly discovery"`
+	JobType        ScheduleJobType    `json:"job_type" enums:"discovery,compliance" example:"discovery"`
+	TargetType     ScheduleTargetType `json:"target_type" enums:"integration,integration_group,framework" example:"integration_group"`
+	TargetID       string             `json:"target_id" example:"production"`
+	CronExpression string             `json:"cron_expression" example:"0 * * * *"`
+	Enabled        bool               `json:"enabled" example:"true"`
+	NextRunAt      *time.Time         `json:"next_run_at,omitempty"`
+	CreatedBy      string             `json:"created_by"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type CreateScheduleRequest struct {
+	Name           string             `json:"name" example:"production hourly discovery"`
+	JobType        ScheduleJobType    `json:"job_type" enums:"discovery,compliance" example:"discovery"`
+	TargetType     ScheduleTargetType `json:"target_type" enums:"integration,integration_group,framework" example:"integration_group"`
+	TargetID       string             `json:"target_id" example:"production"`
+	CronExpression string             `json:"cron_expression" example:"0 * * * *"`
+	Enabled        *bool              `json:"enabled" example:"true"`
+}
+
+type UpdateScheduleRequest struct {
+	Name           *string `json:"name" example:"production hourly discovery"`
+	CronExpression *string `json:"cron_expression" example:"0 * * * *"`
+	Enabled        *bool   `json:"enabled" example:"true"`
+}
+
+type ListSchedulesResponse struct {
+	Items      []Schedule `json:"items"`
+	TotalCount int        `json:"total_count"`
+}
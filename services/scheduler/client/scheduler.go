@@ -24,15 +24,19 @@ type SchedulerServiceClient interface {
 	GetConnectionDescribeStatus(ctx *httpclient.Context, connectionID string) ([]api.IntegrationDescribeStatus, error)
 	ListPendingConnections(ctx *httpclient.Context) ([]string, error)
 	GetLatestComplianceJobForBenchmark(ctx *httpclient.Context, benchmarkID string) (*api.ComplianceJob, error)
+	GetLatestSucceededComplianceJobForBenchmarkBefore(ctx *httpclient.Context, benchmarkID string, before time.Time) (*api.ComplianceJob, error)
 	GetDescribeAllJobsStatus(ctx *httpclient.Context) (*api.DescribeAllJobsStatus, error)
 	CountJobsByDate(ctx *httpclient.Context, includeCost *bool, jobType api.JobType, startDate, endDate time.Time) (int64, error)
 	GetAsyncQueryRunJobStatus(ctx *httpclient.Context, jobID string) (*api.GetAsyncQueryRunJobStatusResponse, error)
 	RunQuery(ctx *httpclient.Context, queryID string) (*model.QueryRunnerJob, error)
 	PurgeSampleData(ctx *httpclient.Context, integrations []string) error
 	RunDiscovery(ctx *httpclient.Context, userId string, request api.RunDiscoveryRequest) (*api.RunDiscoveryResponse, error)
+	GetIntegrationDiscoveryProgress(ctx *httpclient.Context, request api.GetIntegrationDiscoveryProgressRequest) (*api.GetIntegrationDiscoveryProgressResponse, error)
 	ListComplianceJobsHistory(ctx *httpclient.Context, interval, triggerType, createdBy string, cursor, perPage int) (*api.ListComplianceJobsHistoryResponse, error)
 	GetSummaryJobs(ctx *httpclient.Context, jobIDs []string) ([]string, error)
 	GetIntegrationLastDiscoveryJob(ctx *httpclient.Context, request api.GetIntegrationLastDiscoveryJobRequest) (*model.DescribeIntegrationJob, error)
+	GetIntegrationDiscoveryPermissionFailures(ctx *httpclient.Context, integrationID string) (*api.GetIntegrationDiscoveryPermissionFailuresResponse, error)
+	NotifyPendingIntegrations(ctx *httpclient.Context, request api.NotifyPendingIntegrationsRequest) (*api.NotifyPendingIntegrationsResponse, error)
 	GetComplianceQuickSequence(ctx *httpclient.Context, jobID string) (*api.QuickScanSequence, error)
 	GetComplianceJobStatus(ctx *httpclient.Context, jobId string) (*api.GetComplianceJobStatusResponse, error)
 }
@@ -68,6 +72,24 @@ func (s *schedulerClient) RunDiscovery(ctx *httpclient.Context, userId string, r
 	return &response, nil
 }
 
+func (s *schedulerClient) GetIntegrationDiscoveryProgress(ctx *httpclient.Context, request api.GetIntegrationDiscoveryProgressRequest) (*api.GetIntegrationDiscoveryProgressResponse, error) {
+	url := fmt.Sprintf("%s/api/v3/discovery/status", s.baseURL)
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.GetIntegrationDiscoveryProgressResponse
+	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodPost, url, ctx.ToHeaders(), payload, &response); err != nil {
+		if 400 <= statusCode && statusCode < 500 {
+			return nil, echo.NewHTTPError(statusCode, err.Error())
+		}
+		return nil, err
+	}
+	return &response, nil
+}
+
 func (s *schedulerClient) GetSummaryJobs(ctx *httpclient.Context, jobIDs []string) ([]string, error) {
 	url := fmt.Sprintf("%s/api/v3/jobs/compliance/summary/jobs", s.baseURL)
 	firstParamAttached := false
@@ -157,6 +179,19 @@ func (s *schedulerClient) GetLatestComplianceJobForBenchmark(ctx *httpclient.Con
 	return res, nil
 }
 
+func (s *schedulerClient) GetLatestSucceededComplianceJobForBenchmarkBefore(ctx *httpclient.Context, benchmarkId string, before time.Time) (*api.ComplianceJob, error) {
+	url := fmt.Sprintf("%s/api/v1/compliance/status/%s?before=%d", s.baseURL, benchmarkId, before.Unix())
+
+	var res *api.ComplianceJob
+	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodGet, url, ctx.ToHeaders(), nil, &res); err != nil {
+		if 400 <= statusCode && statusCode < 500 {
+			return nil, echo.NewHTTPError(statusCode, err.Error())
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
 func (s *schedulerClient) GetConnectionDescribeStatus(ctx *httpclient.Context, connectionID string) ([]api.IntegrationDescribeStatus, error) {
 	url := fmt.Sprintf("%s/api/v1/describe/connection/status?connection_id=%s", s.baseURL, connectionID)
 
@@ -291,3 +326,33 @@ func (s *schedulerClient) PurgeSampleData(ctx *httpclient.Context, integrations
 	}
 	return nil
 }
+
+func (s *schedulerClient) GetIntegrationDiscoveryPermissionFailures(ctx *httpclient.Context, integrationID string) (*api.GetIntegrationDiscoveryPermissionFailuresResponse, error) {
+	url := fmt.Sprintf("%s/api/v3/integration/%s/discovery/permission-failures", s.baseURL, integrationID)
+	var res api.GetIntegrationDiscoveryPermissionFailuresResponse
+	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodGet, url, ctx.ToHeaders(), nil, &res); err != nil {
+		if 400 <= statusCode && statusCode < 500 {
+			return nil, echo.NewHTTPError(statusCode, err.Error())
+		}
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s *schedulerClient) NotifyPendingIntegrations(ctx *httpclient.Context, request api.NotifyPendingIntegrationsRequest) (*api.NotifyPendingIntegrationsResponse, error) {
+	url := fmt.Sprintf("%s/api/v3/notifications/events/pending-integrations", s.baseURL)
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response api.NotifyPendingIntegrationsResponse
+	if statusCode, err := httpclient.DoRequest(ctx.Ctx, http.MethodPost, url, ctx.ToHeaders(), payload, &response); err != nil {
+		if 400 <= statusCode && statusCode < 500 {
+			return nil, echo.NewHTTPError(statusCode, err.Error())
+		}
+		return nil, err
+	}
+	return &response, nil
+}
@@ -21,6 +21,26 @@ type SchedulerConfig struct {
 	ElasticSearch           config.ElasticSearch
 	Integration             config.OpenGovernanceService
 	NATS                    config.NATS
-	Vault                   vault.Config `yaml:"vault" koanf:"vault"`
-	QueryValidatorEnabled   string       `yaml:"query_validator_enabled" koanf:"query_validator_enabled"`
+	Vault                   vault.Config       `yaml:"vault" koanf:"vault"`
+	QueryValidatorEnabled   string             `yaml:"query_validator_enabled" koanf:"query_validator_enabled"`
+	Notification            NotificationConfig `yaml:"notification" koanf:"notification"`
+	Discovery               DiscoveryConfig    `yaml:"discovery" koanf:"discovery"`
+}
+
+type DiscoveryConfig struct {
+	// PermissionErrorCodes are the describer error codes counted as missing permissions,
+	// they replace api.DiscoveryPermissionErrorCodes when set
+	PermissionErrorCodes []string `yaml:"permission_error_codes" koanf:"permission_error_codes"`
+}
+
+type NotificationConfig struct {
+	SMTP SMTPConfig `yaml:"smtp" koanf:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host" koanf:"host"`
+	Port     int    `yaml:"port" koanf:"port"`
+	Username string `yaml:"username" koanf:"username"`
+	Password string `yaml:"password" koanf:"password"`
+	From     string `yaml:"from" koanf:"from"`
 }
@@ -144,6 +144,41 @@ func (db Database) GetLastComplianceJob(withIncidents bool, frameworkID string)
 	return &job, nil
 }
 
+// GetLastSucceededComplianceJobBefore returns the latest succeeded job of the framework created at or before t
+func (db Database) GetLastSucceededComplianceJobBefore(withIncidents bool, frameworkID string, t time.Time) (*model.ComplianceJob, error) {
+	var job model.ComplianceJob
+	tx := db.ORM.Model(&model.ComplianceJob{}).
+		Where("with_incidents = ?", withIncidents).
+		Where("framework_ids @> ?", pq.StringArray{frameworkID}).
+		Where("status = ?", model.ComplianceJobSucceeded).
+		Where("created_at <= ?", t).
+		Order("created_at DESC").First(&job)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &job, nil
+}
+
+// GetLastComplianceJobForIntegrations returns the latest job of the framework that covered any of the given integrations
+func (db Database) GetLastComplianceJobForIntegrations(withIncidents bool, frameworkID string, integrationIDs []string) (*model.ComplianceJob, error) {
+	var job model.ComplianceJob
+	tx := db.ORM.Model(&model.ComplianceJob{}).
+		Where("with_incidents = ?", withIncidents).
+		Where("framework_ids @> ?", pq.StringArray{frameworkID}).
+		Where("integration_ids && ?", pq.StringArray(integrationIDs)).
+		Order("created_at DESC").First(&job)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+	return &job, nil
+}
+
 func (db Database) ListComplianceJobs(withIncidents bool) ([]model.ComplianceJob, error) {
 	var job []model.ComplianceJob
 	tx := db.ORM.Model(&model.ComplianceJob{}).
@@ -144,6 +144,21 @@ func (db Database) GetDescribeIntegrationJobByIntegrationID(integrationId string
 	return jobs, nil
 }
 
+// ListFinishedDescribeIntegrationJobs lists the finished discovery jobs of an integration, latest first per resource type
+func (db Database) ListFinishedDescribeIntegrationJobs(integrationId string, since time.Time) ([]model.DescribeIntegrationJob, error) {
+	var jobs []model.DescribeIntegrationJob
+	tx := db.ORM.
+		Where("integration_id = ? AND created_at > ?", integrationId, since).
+		Where("status IN ?", []api.DescribeResourceJobStatus{api.DescribeResourceJobSucceeded, api.DescribeResourceJobFailed, api.DescribeResourceJobTimeout}).
+		Order("resource_type, id DESC").
+		Find(&jobs)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return jobs, nil
+}
+
 func (db Database) GetDescribeIntegrationJobByID(id uint) (*model.DescribeIntegrationJob, error) {
 	var job model.DescribeIntegrationJob
 	tx := db.ORM.Preload(clause.Associations).Where("id = ?", id).First(&job)
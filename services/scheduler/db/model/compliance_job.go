@@ -97,8 +97,9 @@ type ComplianceJobWithSummarizerJob struct {
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	BenchmarkID    string
+	FrameworkIds   pq.StringArray `gorm:"type:text[]"`
 	Status         ComplianceJobStatus
-	ConnectionIDs  pq.StringArray `gorm:"type:text[]"`
+	ConnectionIDs  pq.StringArray `gorm:"column:integration_ids;type:text[]"`
 	SummarizerJobs pq.StringArray `gorm:"type:text[]"`
 	TriggerType    ComplianceTriggerType
 	CreatedBy      string
@@ -0,0 +1,200 @@
+package model
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/lib/pq"
+	"github.com/opengovern/opensecurity/pkg/types"
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+)
+
+type NotificationDestination struct {
+	ID        uint `gorm:"primaryKey"`
+	Name      string
+	Type      api.NotificationDestinationType
+	Config    pgtype.JSONB // api.NotificationDestinationConfig
+	Enabled   bool
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (d NotificationDestination) GetConfig() (api.NotificationDestinationConfig, error) {
+	var config api.NotificationDestinationConfig
+	if d.Config.Status != pgtype.Present {
+		return config, nil
+	}
+	err := json.Unmarshal(d.Config.Bytes, &config)
+	return config, err
+}
+
+func (d *NotificationDestination) SetConfig(config api.NotificationDestinationConfig) error {
+	configJson, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	jp := pgtype.JSONB{}
+	if err := jp.Set(configJson); err != nil {
+		return err
+	}
+	d.Config = jp
+	return nil
+}
+
+// ToAPI returns the destination without its credentials, the slack and teams webhook urls are credentials by themselves
+// so only the host of the url, the names of the headers and whether a signing secret is set are returned.
+func (d NotificationDestination) ToAPI() api.NotificationDestination {
+	config, _ := d.GetConfig()
+	destination := api.NotificationDestination{
+		ID:        d.ID,
+		Name:      d.Name,
+		Type:      d.Type,
+		Config:    api.NotificationDestinationConfig{Recipients: config.Recipients},
+		HasSecret: config.Secret != "",
+		Enabled:   d.Enabled,
+		CreatedBy: d.CreatedBy,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+	if u, err := url.Parse(config.URL); err == nil {
+		destination.URLHost = u.Hostname()
+	}
+	for name := range config.Headers {
+		destination.HeaderNames = append(destination.HeaderNames, name)
+	}
+	slices.Sort(destination.HeaderNames)
+	return destination
+}
+
+// MergeConfig returns the config of an update, the url, secret and headers are not returned by the api so the ones
+// left out of the update are kept. An empty headers object removes the headers.
+func (d NotificationDestination) MergeConfig(update api.NotificationDestinationConfig) (api.NotificationDestinationConfig, error) {
+	current, err := d.GetConfig()
+	if err != nil {
+		return update, err
+	}
+	if update.URL == "" {
+		update.URL = current.URL
+	}
+	if update.Secret == "" {
+		update.Secret = current.Secret
+	}
+	if update.Headers == nil {
+		update.Headers = current.Headers
+	}
+	return update, nil
+}
+
+// NotificationRule routes the drift events of a compliance job to a destination, an empty filter matches everything.
+// A rule without event types only handles compliance drift, the filters do not apply to pending integrations.
+// The first evaluations of compliance results only match rules that include the initial state.
+type NotificationRule struct {
+	ID                  uint `gorm:"primaryKey"`
+	Name                string
+	DestinationID       uint           `gorm:"index"`
+	EventTypes          pq.StringArray `gorm:"type:text[]"`
+	FrameworkIDs        pq.StringArray `gorm:"type:text[]"`
+	ControlIDs          pq.StringArray `gorm:"type:text[]"`
+	Severities          pq.StringArray `gorm:"type:text[]"`
+	IntegrationIDs      pq.StringArray `gorm:"type:text[]"`
+	ComplianceStatuses  pq.StringArray `gorm:"type:text[]"`
+	IncludeInitialState bool
+	Enabled             bool
+	CreatedBy           string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+func (r NotificationRule) Matches(event types.ComplianceResultDriftEvent) bool {
+	if event.InitialState && !r.IncludeInitialState {
+		return false
+	}
+	return matchesFilter(r.FrameworkIDs, event.BenchmarkID) &&
+		matchesFilter(r.ControlIDs, event.ControlID) &&
+		matchesFilter(r.Severities, string(event.Severity)) &&
+		matchesFilter(r.IntegrationIDs, event.IntegrationID) &&
+		matchesFilter(r.ComplianceStatuses, string(event.ComplianceStatus))
+}
+
+func (r NotificationRule) HandlesEvent(eventType api.NotificationEventType) bool {
+	if len(r.EventTypes) == 0 {
+		return eventType == api.NotificationEventTypeComplianceDrift
+	}
+	return slices.Contains(r.EventTypes, string(eventType))
+}
+
+func matchesFilter(filter []string, value string) bool {
+	return len(filter) == 0 || slices.Contains(filter, value)
+}
+
+func (r NotificationRule) ToAPI() api.NotificationRule {
+	rule := api.NotificationRule{
+		ID:                  r.ID,
+		Name:                r.Name,
+		DestinationID:       r.DestinationID,
+		FrameworkIDs:        r.FrameworkIDs,
+		ControlIDs:          r.ControlIDs,
+		IntegrationIDs:      r.IntegrationIDs,
+		IncludeInitialState: r.IncludeInitialState,
+		Enabled:             r.Enabled,
+		CreatedBy:           r.CreatedBy,
+		CreatedAt:           r.CreatedAt,
+		UpdatedAt:           r.UpdatedAt,
+	}
+	for _, eventType := range r.EventTypes {
+		rule.EventTypes = append(rule.EventTypes, api.NotificationEventType(eventType))
+	}
+	for _, severity := range r.Severities {
+		rule.Severities = append(rule.Severities, types.ComplianceResultSeverity(severity))
+	}
+	for _, status := range r.ComplianceStatuses {
+		rule.ComplianceStatuses = append(rule.ComplianceStatuses, types.ComplianceStatus(status))
+	}
+	return rule
+}
+
+// NotificationDelivery is a single message of a rule for a compliance job or pending integrations, kept as the delivery log
+type NotificationDelivery struct {
+	ID              uint         `gorm:"primaryKey"`
+	RuleID          uint         `gorm:"index"`
+	DestinationID   uint         `gorm:"index"`
+	ComplianceJobID uint         `gorm:"index"`
+	Payload         pgtype.JSONB // api.NotificationPayload
+	EventCount      int
+	Status          api.NotificationDeliveryStatus `gorm:"index"`
+	Attempts        int
+	LastError       string
+	NextAttemptAt   *time.Time
+	DeliveredAt     *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (d NotificationDelivery) ToAPI() api.NotificationDelivery {
+	return api.NotificationDelivery{
+		ID:              d.ID,
+		RuleID:          d.RuleID,
+		DestinationID:   d.DestinationID,
+		ComplianceJobID: d.ComplianceJobID,
+		EventCount:      d.EventCount,
+		Status:          d.Status,
+		Attempts:        d.Attempts,
+		LastError:       d.LastError,
+		NextAttemptAt:   d.NextAttemptAt,
+		DeliveredAt:     d.DeliveredAt,
+		CreatedAt:       d.CreatedAt,
+		UpdatedAt:       d.UpdatedAt,
+	}
+}
+
+// NotificationDispatch marks a compliance job whose drift events have been routed to the notification rules
+type NotificationDispatch struct {
+	ComplianceJobID uint `gorm:"primaryKey;autoIncrement:false"`
+	EventCount      int
+	DeliveryCount   int
+	CreatedAt       time.Time
+}
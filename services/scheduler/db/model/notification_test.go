@@ -0,0 +1,176 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opengovern/opensecurity/pkg/types"
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+)
+
+func TestNotificationRuleMatches(t *testing.T) {
+	event := types.ComplianceResultDriftEvent{
+		BenchmarkID:      "aws_cis_v300",
+		ControlID:        "aws_cis_v300_1_4",
+		IntegrationID:    "i-1",
+		Severity:         types.ComplianceResultSeverityHigh,
+		ComplianceStatus: types.ComplianceStatusALARM,
+	}
+
+	tests := []struct {
+		name string
+		rule NotificationRule
+		want bool
+	}{
+		{name: "empty rule matches everything", rule: NotificationRule{}, want: true},
+		{name: "matching framework", rule: NotificationRule{FrameworkIDs: []string{"other", "aws_cis_v300"}}, want: true},
+		{name: "other framework", rule: NotificationRule{FrameworkIDs: []string{"other"}}, want: false},
+		{name: "other control", rule: NotificationRule{ControlIDs: []string{"aws_cis_v300_1_5"}}, want: false},
+		{name: "matching severity", rule: NotificationRule{Severities: []string{"critical", "high"}}, want: true},
+		{name: "other severity", rule: NotificationRule{Severities: []string{"low"}}, want: false},
+		{name: "other integration", rule: NotificationRule{IntegrationIDs: []string{"i-2"}}, want: false},
+		{name: "other status", rule: NotificationRule{ComplianceStatuses: []string{"ok"}}, want: false},
+		{
+			name: "every filter must match",
+			rule: NotificationRule{
+				FrameworkIDs:       []string{"aws_cis_v300"},
+				IntegrationIDs:     []string{"i-1"},
+				ComplianceStatuses: []string{"ok"},
+			},
+			want: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.rule.Matches(event); got != tc.want {
+				t.Errorf("Matches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNotificationRuleMatchesInitialState(t *testing.T) {
+	event := types.ComplianceResultDriftEvent{
+		BenchmarkID:      "aws_cis_v300",
+		ComplianceStatus: types.ComplianceStatusALARM,
+		InitialState:     true,
+	}
+
+	if (NotificationRule{}).Matches(event) {
+		t.Errorf("Matches() = true for the initial state of a result, want false by default")
+	}
+	if !(NotificationRule{IncludeInitialState: true}).Matches(event) {
+		t.Errorf("Matches() = false for the initial state of a result with IncludeInitialState, want true")
+	}
+	if (NotificationRule{IncludeInitialState: true, FrameworkIDs: []string{"other"}}).Matches(event) {
+		t.Errorf("Matches() = true for the initial state of a result in another framework, want false")
+	}
+}
+
+func TestNotificationRuleHandlesEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      NotificationRule
+		eventType api.NotificationEventType
+		want      bool
+	}{
+		{name: "no event types handles drift", rule: NotificationRule{}, eventType: api.NotificationEventTypeComplianceDrift, want: true},
+		{name: "no event types skips pending", rule: NotificationRule{}, eventType: api.NotificationEventTypePendingIntegrations, want: false},
+		{
+			name:      "subscribed to pending",
+			rule:      NotificationRule{EventTypes: []string{"integration.pending"}},
+			eventType: api.NotificationEventTypePendingIntegrations,
+			want:      true,
+		},
+		{
+			name:      "pending only skips drift",
+			rule:      NotificationRule{EventTypes: []string{"integration.pending"}},
+			eventType: api.NotificationEventTypeComplianceDrift,
+			want:      false,
+		},
+		{
+			name:      "both",
+			rule:      NotificationRule{EventTypes: []string{"compliance.drift", "integration.pending"}},
+			eventType: api.NotificationEventTypeComplianceDrift,
+			want:      true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.rule.HandlesEvent(tc.eventType); got != tc.want {
+				t.Errorf("HandlesEvent(%s) = %v, want %v", tc.eventType, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNotificationDestinationToAPI(t *testing.T) {
+	var destination NotificationDestination
+	if err := destination.SetConfig(api.NotificationDestinationConfig{
+		URL:     "https://hooks.slack.com/services/T000/B000/XXXX",
+		Secret:  "signing-secret",
+		Headers: map[string]string{"X-Team": "secops", "Authorization": "Bearer token"},
+	}); err != nil {
+		t.Fatalf("SetConfig() error = %v", err)
+	}
+
+	got := destination.ToAPI()
+	if !reflect.DeepEqual(got.Config, api.NotificationDestinationConfig{}) {
+		t.Errorf("ToAPI().Config = %+v, want the url, secret and headers left out", got.Config)
+	}
+	if got.URLHost != "hooks.slack.com" {
+		t.Errorf("ToAPI().URLHost = %q, want hooks.slack.com", got.URLHost)
+	}
+	if want := []string{"Authorization", "X-Team"}; !reflect.DeepEqual(got.HeaderNames, want) {
+		t.Errorf("ToAPI().HeaderNames = %v, want %v", got.HeaderNames, want)
+	}
+	if !got.HasSecret {
+		t.Error("ToAPI().HasSecret = false, want true")
+	}
+}
+
+func TestNotificationDestinationMergeConfig(t *testing.T) {
+	current := api.NotificationDestinationConfig{
+		URL:     "https://hooks.example.com/a",
+		Secret:  "secret",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}
+	var destination NotificationDestination
+	if err := destination.SetConfig(current); err != nil {
+		t.Fatalf("SetConfig() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		update api.NotificationDestinationConfig
+		want   api.NotificationDestinationConfig
+	}{
+		{name: "empty update keeps everything", want: current},
+		{
+			name:   "new url keeps the secret and headers",
+			update: api.NotificationDestinationConfig{URL: "https://hooks.example.com/b"},
+			want:   api.NotificationDestinationConfig{URL: "https://hooks.example.com/b", Secret: "secret", Headers: current.Headers},
+		},
+		{
+			name:   "empty headers remove them",
+			update: api.NotificationDestinationConfig{Headers: map[string]string{}},
+			want:   api.NotificationDestinationConfig{URL: current.URL, Secret: "secret", Headers: map[string]string{}},
+		},
+		{
+			name:   "new headers replace them",
+			update: api.NotificationDestinationConfig{Headers: map[string]string{"X-Team": "secops"}},
+			want:   api.NotificationDestinationConfig{URL: current.URL, Secret: "secret", Headers: map[string]string{"X-Team": "secops"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := destination.MergeConfig(tc.update)
+			if err != nil {
+				t.Fatalf("MergeConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("MergeConfig() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"encoding/json"
 	"strconv"
 
+	"github.com/jackc/pgtype"
 	"github.com/lib/pq"
 	"github.com/opengovern/opensecurity/services/scheduler/api"
 	"gorm.io/gorm"
@@ -27,9 +29,39 @@ type QuickScanSequence struct {
 	Status         QuickScanSequenceStatus
 	FailureMessage string
 	CreatedBy      string
+	Gate           pgtype.JSONB // api.QuickScanGate
+}
+
+func (aj *QuickScanSequence) GetGate() (*api.QuickScanGate, error) {
+	if aj.Gate.Status != pgtype.Present {
+		return nil, nil
+	}
+	var gate api.QuickScanGate
+	if err := json.Unmarshal(aj.Gate.Bytes, &gate); err != nil {
+		return nil, err
+	}
+	return &gate, nil
+}
+
+func (aj *QuickScanSequence) SetGate(gate *api.QuickScanGate) error {
+	if gate == nil {
+		aj.Gate = pgtype.JSONB{Status: pgtype.Null}
+		return nil
+	}
+	gateJson, err := json.Marshal(gate)
+	if err != nil {
+		return err
+	}
+	jp := pgtype.JSONB{}
+	if err := jp.Set(gateJson); err != nil {
+		return err
+	}
+	aj.Gate = jp
+	return nil
 }
 
 func (aj *QuickScanSequence) ToAPI() api.QuickScanSequence {
+	gate, _ := aj.GetGate()
 	return api.QuickScanSequence{
 		ID:             strconv.Itoa(int(aj.ID)),
 		FrameworkID:    aj.FrameworkID,
@@ -37,5 +69,6 @@ func (aj *QuickScanSequence) ToAPI() api.QuickScanSequence {
 		Status:         api.QuickScanSequenceStatus(aj.Status),
 		FailureMessage: aj.FailureMessage,
 		CreatedBy:      aj.CreatedBy,
+		Gate:           gate,
 	}
 }
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"time"
+
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule overrides the global discovery/compliance interval for an integration, an integration group or a framework
+type Schedule struct {
+	ID             uint `gorm:"primaryKey"`
+	Name           string
+	JobType        api.ScheduleJobType    `gorm:"uniqueIndex:idx_schedule_target"`
+	TargetType     api.ScheduleTargetType `gorm:"uniqueIndex:idx_schedule_target"`
+	TargetID       string                 `gorm:"uniqueIndex:idx_schedule_target"`
+	CronExpression string
+	Enabled        bool
+	CreatedBy      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (s Schedule) CronSchedule() (cron.Schedule, error) {
+	return cron.ParseStandard(s.CronExpression)
+}
+
+// IsDue reports whether the schedule has fired at least once since lastRun
+func (s Schedule) IsDue(lastRun time.Time, now time.Time) bool {
+	sched, err := s.CronSchedule()
+	if err != nil {
+		return false
+	}
+	return !sched.Next(lastRun).After(now)
+}
+
+func (s Schedule) ToAPI() api.Schedule {
+	schedule := api.Schedule{
+		ID:             s.ID,
+		Name:           s.Name,
+		JobType:        s.JobType,
+		TargetType:     s.TargetType,
+		TargetID:       s.TargetID,
+		CronExpression: s.CronExpression,
+		Enabled:        s.Enabled,
+		CreatedBy:      s.CreatedBy,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+	if sched, err := s.CronSchedule(); err == nil && s.Enabled {
+		next := sched.Next(time.Now())
+		schedule.NextRunAt = &next
+	}
+	return schedule
+}
+
+// interval returns the time between the next two runs of the schedule after now
+func (s Schedule) interval(now time.Time) time.Duration {
+	sched, err := s.CronSchedule()
+	if err != nil {
+		return 0
+	}
+	next := sched.Next(now)
+	return sched.Next(next).Sub(next)
+}
+
+// ScheduleSet resolves the effective schedule of an integration out of all the schedules of a job type
+type ScheduleSet struct {
+	schedules         []Schedule
+	integrationGroups map[string][]string
+	hasGroupSchedules bool
+}
+
+func NewScheduleSet(schedules []Schedule) *ScheduleSet {
+	set := &ScheduleSet{
+		integrationGroups: make(map[string][]string),
+	}
+	for _, s := range schedules {
+		if !s.Enabled {
+			continue
+		}
+		if _, err := s.CronSchedule(); err != nil {
+			continue
+		}
+		if s.TargetType == api.ScheduleTargetTypeIntegrationGroup {
+			set.hasGroupSchedules = true
+		}
+		set.schedules = append(set.schedules, s)
+	}
+	return set
+}
+
+func (s *ScheduleSet) IsEmpty() bool {
+	return len(s.schedules) == 0
+}
+
+// NeedsIntegrationGroups reports whether group memberships should be loaded with SetIntegrationGroupMembers
+func (s *ScheduleSet) NeedsIntegrationGroups() bool {
+	return s.hasGroupSchedules
+}
+
+func (s *ScheduleSet) SetIntegrationGroupMembers(groupName string, integrationIDs []string) {
+	for _, integrationID := range integrationIDs {
+		s.integrationGroups[integrationID] = append(s.integrationGroups[integrationID], groupName)
+	}
+}
+
+// Resolve returns the most specific schedule for the integration: an integration schedule wins over
+// an integration group schedule, which wins over a framework schedule. When several integration groups
+// of the integration have a schedule the most frequent one is used. Returns nil if no schedule applies.
+func (s *ScheduleSet) Resolve(integrationID string, frameworkID string) *Schedule {
+	var integrationSchedule, groupSchedule, frameworkSchedule *Schedule
+	var groupInterval time.Duration
+	now := time.Now()
+	for i := range s.schedules {
+		schedule := &s.schedules[i]
+		switch schedule.TargetType {
+		case api.ScheduleTargetTypeIntegration:
+			if integrationID != "" && schedule.TargetID == integrationID {
+				integrationSchedule = schedule
+			}
+		case api.ScheduleTargetTypeIntegrationGroup:
+			for _, group := range s.integrationGroups[integrationID] {
+				if schedule.TargetID != group {
+					continue
+				}
+				interval := schedule.interval(now)
+				if groupSchedule == nil || interval < groupInterval {
+					groupSchedule = schedule
+					groupInterval = interval
+				}
+			}
+		case api.ScheduleTargetTypeFramework:
+			if frameworkID != "" && schedule.TargetID == frameworkID {
+				frameworkSchedule = schedule
+			}
+		}
+	}
+
+	switch {
+	case integrationSchedule != nil:
+		return integrationSchedule
+	case groupSchedule != nil:
+		return groupSchedule
+	default:
+		return frameworkSchedule
+	}
+}
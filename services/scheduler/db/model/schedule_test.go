@@ -0,0 +1,82 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+)
+
+func TestScheduleSetResolve(t *testing.T) {
+	integration := Schedule{ID: 1, TargetType: api.ScheduleTargetTypeIntegration, TargetID: "i-1", CronExpression: "0 0 * * *", Enabled: true}
+	hourlyGroup := Schedule{ID: 2, TargetType: api.ScheduleTargetTypeIntegrationGroup, TargetID: "hourly", CronExpression: "0 * * * *", Enabled: true}
+	// the daily schedule runs before the next hourly run from 23:00, it must still lose to the hourly one
+	dailyGroup := Schedule{ID: 3, TargetType: api.ScheduleTargetTypeIntegrationGroup, TargetID: "daily", CronExpression: "59 23 * * *", Enabled: true}
+	weeklyGroup := Schedule{ID: 4, TargetType: api.ScheduleTargetTypeIntegrationGroup, TargetID: "weekly", CronExpression: "* * * * 0", Enabled: false}
+	framework := Schedule{ID: 5, TargetType: api.ScheduleTargetTypeFramework, TargetID: "fw-1", CronExpression: "0 0 * * 0", Enabled: true}
+	invalid := Schedule{ID: 6, TargetType: api.ScheduleTargetTypeFramework, TargetID: "fw-2", CronExpression: "not a cron", Enabled: true}
+
+	tests := []struct {
+		name          string
+		schedules     []Schedule
+		groups        map[string][]string
+		integrationID string
+		frameworkID   string
+		want          uint
+	}{
+		{
+			name:          "integration wins over group and framework",
+			schedules:     []Schedule{integration, hourlyGroup, framework},
+			groups:        map[string][]string{"hourly": {"i-1"}},
+			integrationID: "i-1",
+			frameworkID:   "fw-1",
+			want:          1,
+		},
+		{
+			name:          "group wins over framework",
+			schedules:     []Schedule{hourlyGroup, framework},
+			groups:        map[string][]string{"hourly": {"i-2"}},
+			integrationID: "i-2",
+			frameworkID:   "fw-1",
+			want:          2,
+		},
+		{
+			name:          "most frequent group",
+			schedules:     []Schedule{dailyGroup, hourlyGroup},
+			groups:        map[string][]string{"daily": {"i-2"}, "hourly": {"i-2"}},
+			integrationID: "i-2",
+			want:          2,
+		},
+		{
+			name:          "group of another integration is ignored",
+			schedules:     []Schedule{hourlyGroup, framework},
+			groups:        map[string][]string{"hourly": {"i-3"}},
+			integrationID: "i-2",
+			frameworkID:   "fw-1",
+			want:          5,
+		},
+		{
+			name:          "disabled and invalid schedules are ignored",
+			schedules:     []Schedule{weeklyGroup, invalid},
+			groups:        map[string][]string{"weekly": {"i-2"}},
+			integrationID: "i-2",
+			frameworkID:   "fw-2",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			set := NewScheduleSet(tc.schedules)
+			for group, members := range tc.groups {
+				set.SetIntegrationGroupMembers(group, members)
+			}
+			got := set.Resolve(tc.integrationID, tc.frameworkID)
+			switch {
+			case tc.want == 0 && got != nil:
+				t.Errorf("expected no schedule, got %d", got.ID)
+			case tc.want != 0 && got == nil:
+				t.Errorf("expected schedule %d, got none", tc.want)
+			case tc.want != 0 && got.ID != tc.want:
+				t.Errorf("expected schedule %d, got %d", tc.want, got.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+	"github.com/opengovern/opensecurity/services/scheduler/db/model"
+	"gorm.io/gorm"
+)
+
+func (db Database) CreateNotificationDestination(destination *model.NotificationDestination) error {
+	tx := db.ORM.
+		Model(&model.NotificationDestination{}).
+		Create(destination)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) GetNotificationDestination(id uint) (*model.NotificationDestination, error) {
+	var destination model.NotificationDestination
+	tx := db.ORM.Model(&model.NotificationDestination{}).Where("id = ?", id).First(&destination)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+
+	return &destination, nil
+}
+
+func (db Database) ListNotificationDestinations() ([]model.NotificationDestination, error) {
+	var destinations []model.NotificationDestination
+	tx := db.ORM.Model(&model.NotificationDestination{}).Order("id ASC").Find(&destinations)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return destinations, nil
+}
+
+func (db Database) UpdateNotificationDestination(destination *model.NotificationDestination) error {
+	tx := db.ORM.Model(&model.NotificationDestination{}).
+		Where("id = ?", destination.ID).
+		Select("name", "config", "enabled", "updated_at").
+		Updates(destination)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) DeleteNotificationDestination(id uint) error {
+	tx := db.ORM.Model(&model.NotificationDestination{}).
+		Where("id = ?", id).
+		Delete(&model.NotificationDestination{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) CreateNotificationRule(rule *model.NotificationRule) error {
+	tx := db.ORM.
+		Model(&model.NotificationRule{}).
+		Create(rule)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) GetNotificationRule(id uint) (*model.NotificationRule, error) {
+	var rule model.NotificationRule
+	tx := db.ORM.Model(&model.NotificationRule{}).Where("id = ?", id).First(&rule)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+
+	return &rule, nil
+}
+
+func (db Database) ListNotificationRules(destinationID *uint) ([]model.NotificationRule, error) {
+	var rules []model.NotificationRule
+	tx := db.ORM.Model(&model.NotificationRule{})
+	if destinationID != nil {
+		tx = tx.Where("destination_id = ?", *destinationID)
+	}
+	tx = tx.Order("id ASC").Find(&rules)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return rules, nil
+}
+
+// ListActiveNotificationRules returns the enabled rules pointing to an enabled destination
+func (db Database) ListActiveNotificationRules() ([]model.NotificationRule, error) {
+	var rules []model.NotificationRule
+	tx := db.ORM.Model(&model.NotificationRule{}).
+		Joins("JOIN notification_destinations d ON d.id = notification_rules.destination_id").
+		Where("notification_rules.enabled = ? AND d.enabled = ?", true, true).
+		Order("notification_rules.id ASC").
+		Find(&rules)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return rules, nil
+}
+
+func (db Database) UpdateNotificationRule(rule *model.NotificationRule) error {
+	tx := db.ORM.Model(&model.NotificationRule{}).
+		Where("id = ?", rule.ID).
+		Select("name", "destination_id", "event_types", "framework_ids", "control_ids", "severities", "integration_ids",
+			"compliance_statuses", "enabled", "updated_at").
+		Updates(rule)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) DeleteNotificationRule(id uint) error {
+	tx := db.ORM.Model(&model.NotificationRule{}).
+		Where("id = ?", id).
+		Delete(&model.NotificationRule{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+// ListComplianceJobsPendingNotification returns the succeeded compliance jobs completed after the given time
+// whose drift events have not been dispatched yet
+func (db Database) ListComplianceJobsPendingNotification(completedAfter time.Time) ([]model.ComplianceJob, error) {
+	var jobs []model.ComplianceJob
+	tx := db.ORM.Model(&model.ComplianceJob{}).
+		Where("status = ? AND completed_at >= ?", model.ComplianceJobSucceeded, completedAfter).
+		Where("NOT EXISTS (SELECT 1 FROM notification_dispatches nd WHERE nd.compliance_job_id = compliance_jobs.id)").
+		Order("id ASC").
+		Find(&jobs)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return jobs, nil
+}
+
+// CreateNotificationDispatch stores the deliveries of a compliance job and marks it as dispatched in a single transaction
+func (db Database) CreateNotificationDispatch(dispatch *model.NotificationDispatch, deliveries []model.NotificationDelivery) error {
+	return db.ORM.Transaction(func(tx *gorm.DB) error {
+		if len(deliveries) > 0 {
+			if err := tx.Model(&model.NotificationDelivery{}).Create(&deliveries).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.NotificationDispatch{}).Create(dispatch).Error
+	})
+}
+
+func (db Database) CreateNotificationDeliveries(deliveries []model.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	tx := db.ORM.Model(&model.NotificationDelivery{}).Create(&deliveries)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) ListDueNotificationDeliveries(now time.Time, limit int) ([]model.NotificationDelivery, error) {
+	var deliveries []model.NotificationDelivery
+	tx := db.ORM.Model(&model.NotificationDelivery{}).
+		Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", api.NotificationDeliveryStatusPending, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&deliveries)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return deliveries, nil
+}
+
+func (db Database) UpdateNotificationDeliveryAttempt(delivery *model.NotificationDelivery) error {
+	tx := db.ORM.Model(&model.NotificationDelivery{}).
+		Where("id = ?", delivery.ID).
+		Select("status", "attempts", "last_error", "next_attempt_at", "delivered_at", "updated_at").
+		Updates(delivery)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) ListNotificationDeliveries(ruleID, destinationID, complianceJobID *uint, status *api.NotificationDeliveryStatus,
+	limit, offset int) ([]model.NotificationDelivery, int64, error) {
+	tx := db.ORM.Model(&model.NotificationDelivery{})
+	if ruleID != nil {
+		tx = tx.Where("rule_id = ?", *ruleID)
+	}
+	if destinationID != nil {
+		tx = tx.Where("destination_id = ?", *destinationID)
+	}
+	if complianceJobID != nil {
+		tx = tx.Where("compliance_job_id = ?", *complianceJobID)
+	}
+	if status != nil {
+		tx = tx.Where("status = ?", *status)
+	}
+
+	var count int64
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []model.NotificationDelivery
+	if err := tx.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, count, nil
+}
+
+func (db Database) CleanupNotificationDeliveriesOlderThan(t time.Time) error {
+	tx := db.ORM.Where("created_at < ?", t).Unscoped().Delete(&model.NotificationDelivery{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = db.ORM.Where("created_at < ?", t).Unscoped().Delete(&model.NotificationDispatch{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+	"github.com/opengovern/opensecurity/services/scheduler/db/model"
+	"gorm.io/gorm"
+)
+
+func (db Database) CreateSchedule(schedule *model.Schedule) error {
+	tx := db.ORM.
+		Model(&model.Schedule{}).
+		Create(schedule)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) GetSchedule(id uint) (*model.Schedule, error) {
+	var schedule model.Schedule
+	tx := db.ORM.Model(&model.Schedule{}).Where("id = ?", id).First(&schedule)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, tx.Error
+	}
+
+	return &schedule, nil
+}
+
+func (db Database) ListSchedules(jobType *api.ScheduleJobType, targetType *api.ScheduleTargetType, targetID *string) ([]model.Schedule, error) {
+	var schedules []model.Schedule
+	tx := db.ORM.Model(&model.Schedule{})
+	if jobType != nil {
+		tx = tx.Where("job_type = ?", *jobType)
+	}
+	if targetType != nil {
+		tx = tx.Where("target_type = ?", *targetType)
+	}
+	if targetID != nil {
+		tx = tx.Where("target_id = ?", *targetID)
+	}
+	tx = tx.Order("id ASC").Find(&schedules)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return schedules, nil
+}
+
+func (db Database) UpdateSchedule(schedule *model.Schedule) error {
+	tx := db.ORM.Model(&model.Schedule{}).
+		Where("id = ?", schedule.ID).
+		Select("name", "cron_expression", "enabled", "updated_at").
+		Updates(schedule)
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
+
+func (db Database) DeleteSchedule(id uint) error {
+	tx := db.ORM.Model(&model.Schedule{}).
+		Where("id = ?", id).
+		Delete(&model.Schedule{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	return nil
+}
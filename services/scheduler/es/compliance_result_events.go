@@ -0,0 +1,57 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/opengovern/og-util/pkg/opengovernance-es-sdk"
+	"github.com/opengovern/opensecurity/pkg/types"
+)
+
+const MaxComplianceResultDriftEventsPerJob = 10000
+
+type ComplianceResultDriftEventsResponse struct {
+	Hits struct {
+		Total opengovernance.SearchTotal `json:"total"`
+		Hits  []struct {
+			ID     string                           `json:"_id"`
+			Source types.ComplianceResultDriftEvent `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// GetComplianceResultDriftEventsByComplianceJobID returns the drift events produced by the runners of a compliance job,
+// at most MaxComplianceResultDriftEventsPerJob events are returned alongside the total count
+func GetComplianceResultDriftEventsByComplianceJobID(ctx context.Context, client opengovernance.Client, complianceJobID uint) ([]types.ComplianceResultDriftEvent, int64, error) {
+	root := map[string]any{
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []map[string]any{
+					{"term": map[string]any{"parentComplianceJobID": complianceJobID}},
+				},
+			},
+		},
+		"sort": []map[string]any{
+			{"evaluatedAt": "desc"},
+		},
+		"size":             MaxComplianceResultDriftEventsPerJob,
+		"track_total_hits": true,
+	}
+
+	queryBytes, err := json.Marshal(root)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var response ComplianceResultDriftEventsResponse
+	err = client.Search(ctx, types.ComplianceResultEventsIndex, string(queryBytes), &response)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events := make([]types.ComplianceResultDriftEvent, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		events = append(events, hit.Source)
+	}
+	return events, response.Hits.Total.Value, nil
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/opengovern/opensecurity/services/scheduler/db/model"
 	"github.com/opengovern/opensecurity/services/scheduler/schedulers/compliance"
 	"github.com/opengovern/opensecurity/services/scheduler/schedulers/discovery"
+	"github.com/opengovern/opensecurity/services/scheduler/schedulers/notification"
 
 	coreClient "github.com/opengovern/opensecurity/services/core/client"
 	"github.com/opengovern/opensecurity/services/core/db/models"
@@ -128,6 +129,7 @@ type Scheduler struct {
 	discoveryScheduler      *discovery.Scheduler
 	queryRunnerScheduler    *queryrunnerscheduler.JobScheduler
 	queryValidatorScheduler *queryrvalidatorscheduler.JobScheduler
+	notificationScheduler   *notification.Scheduler
 	conf                    config.SchedulerConfig
 
 	complianceEnabled bool
@@ -573,6 +575,15 @@ func (s *Scheduler) Run(ctx context.Context) error {
 		})
 	}
 
+	// notifications also carry the pending integrations reported by the integration service
+	s.notificationScheduler = notification.New(
+		s.conf,
+		s.logger,
+		s.db,
+		s.es,
+	)
+	s.notificationScheduler.Run(ctx)
+
 	utils.EnsureRunGoroutine(func() {
 		s.RunCheckupJobScheduler(ctx)
 	})
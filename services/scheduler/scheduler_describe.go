@@ -205,6 +205,13 @@ func (s *Scheduler) scheduleDescribeJob(ctx context.Context) {
 		return
 	}
 
+	discoverySchedules, err := s.loadDiscoveryScheduleSet(ctx)
+	if err != nil {
+		s.logger.Error("failed to load discovery schedules", zap.String("spot", "loadDiscoveryScheduleSet"), zap.Error(err))
+		DescribeJobsCount.WithLabelValues("failure").Inc()
+		return
+	}
+
 	for _, integration := range integrations.Integrations {
 		if integration.State == models.IntegrationStateSample || integration.State == models.IntegrationStateInactive {
 			continue
@@ -248,7 +255,7 @@ func (s *Scheduler) scheduleDescribeJob(ctx context.Context) {
 					zap.String("resource_type", resourceType.Name))
 				continue
 			}
-			_, err = s.describe(integration, resourceType.Name, true, false, false, nil, "scheduler", parametersMap)
+			_, err = s.describe(integration, resourceType.Name, true, discoverySchedules, false, false, nil, "scheduler", parametersMap)
 			if err != nil {
 				s.logger.Error("failed to describe connection", zap.String("integration_id", integration.IntegrationID), zap.String("resource_type", resourceType.Name), zap.Error(err))
 			}
@@ -267,7 +274,7 @@ func (s *Scheduler) scheduleDescribeJob(ctx context.Context) {
 			} else {
 				continue
 			}
-			_, err = s.describe(integration, resourceType.ResourceType, true, false, false, nil, "scheduler", parameters)
+			_, err = s.describe(integration, resourceType.ResourceType, true, discoverySchedules, false, false, nil, "scheduler", parameters)
 			if err != nil {
 				s.logger.Error("failed to describe connection", zap.String("integration_id", integration.IntegrationID),
 					zap.String("resource_type", resourceType.ResourceType), zap.Any("parameters", resourceType.Parameters), zap.Error(err))
@@ -283,6 +290,35 @@ func (s *Scheduler) scheduleDescribeJob(ctx context.Context) {
 
 	DescribeJobsCount.WithLabelValues("successful").Inc()
 }
+func (s *Scheduler) loadDiscoveryScheduleSet(ctx context.Context) (*model.ScheduleSet, error) {
+	jobType := api.ScheduleJobTypeDiscovery
+	schedules, err := s.db.ListSchedules(&jobType, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	set := model.NewScheduleSet(schedules)
+	if set.NeedsIntegrationGroups() {
+		groups, err := s.integrationClient.ListIntegrationGroups(&httpclient.Context{UserRole: apiAuth.AdminRole, Ctx: ctx})
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			set.SetIntegrationGroupMembers(group.Name, group.IntegrationIds)
+		}
+	}
+	return set, nil
+}
+
+// isScheduledDiscoveryDue checks the integration's discovery schedule if it has one, otherwise the global discovery interval
+func (s *Scheduler) isScheduledDiscoveryDue(discoverySchedules *model.ScheduleSet, integrationID string, lastRun time.Time) bool {
+	if discoverySchedules != nil {
+		if schedule := discoverySchedules.Resolve(integrationID, ""); schedule != nil {
+			return schedule.IsDue(lastRun, time.Now())
+		}
+	}
+	return !lastRun.After(time.Now().Add(-s.discoveryIntervalHours))
+}
+
 func (s *Scheduler) retryFailedJobs(ctx context.Context) error {
 
 	ctx, span := otel.Tracer(opengovernanceTrace.JaegerTracerName).Start(ctx, "GetFailedJobs")
@@ -310,8 +346,10 @@ func (s *Scheduler) retryFailedJobs(ctx context.Context) error {
 	return nil
 }
 
-func (s *Scheduler) describe(integration integrationapi.Integration, resourceType string, scheduled bool, costFullDiscovery bool,
-	removeResources bool, parentId *uint, createdBy string, parameters map[string]string) (*model.DescribeIntegrationJob, error) {
+// describe creates a discovery job for the resource type, scheduled runs are skipped until discoverySchedules or the
+// global discovery interval make them due
+func (s *Scheduler) describe(integration integrationapi.Integration, resourceType string, scheduled bool, discoverySchedules *model.ScheduleSet,
+	costFullDiscovery bool, removeResources bool, parentId *uint, createdBy string, parameters map[string]string) (*model.DescribeIntegrationJob, error) {
 
 	httpCtx := &httpclient.Context{
 		UserRole: apiAuth.AdminRole,
@@ -349,12 +387,8 @@ func (s *Scheduler) describe(integration integrationapi.Integration, resourceTyp
 	}
 
 	if job != nil {
-		if scheduled {
-			interval := s.discoveryIntervalHours
-
-			if job.UpdatedAt.After(time.Now().Add(-interval)) {
-				return nil, nil
-			}
+		if scheduled && !s.isScheduledDiscoveryDue(discoverySchedules, integration.IntegrationID, job.UpdatedAt) {
+			return nil, nil
 		}
 
 		if job.Status == api.DescribeResourceJobCreated ||
@@ -8,6 +8,7 @@ import (
 	"github.com/opengovern/og-util/pkg/api"
 	"github.com/opengovern/og-util/pkg/httpclient"
 	integrationapi "github.com/opengovern/opensecurity/services/integration/api/models"
+	schedulerApi "github.com/opengovern/opensecurity/services/scheduler/api"
 	"github.com/opengovern/opensecurity/services/scheduler/db/model"
 	"go.uber.org/zap"
 	"time"
@@ -15,11 +16,17 @@ import (
 
 func (s *JobScheduler) runScheduler() error {
 	s.logger.Info("scheduleComplianceJob")
-	if s.complianceIntervalHours <= 0 {
+	clientCtx := &httpclient.Context{UserRole: api.AdminRole}
+
+	schedules, err := s.loadScheduleSet(clientCtx)
+	if err != nil {
+		s.logger.Error("error while loading compliance schedules", zap.Error(err))
+		return fmt.Errorf("error while loading compliance schedules: %v", err)
+	}
+	if s.complianceIntervalHours <= 0 && schedules.IsEmpty() {
 		s.logger.Info("compliance interval is negative or zero, skipping compliance job scheduling")
 		return nil
 	}
-	clientCtx := &httpclient.Context{UserRole: api.AdminRole}
 
 	frameworks, err := s.complianceClient.ListBenchmarks(clientCtx, nil, nil)
 	if err != nil {
@@ -70,19 +77,36 @@ func (s *JobScheduler) runScheduler() error {
 			continue
 		}
 
-		complianceJob, err := s.db.GetLastComplianceJob(true, framework.ID)
-		if err != nil {
-			s.logger.Error("error while getting last compliance job", zap.Error(err))
-			return err
+		// integrations sharing the same effective schedule are evaluated together, the ones without
+		// a schedule fall back to the global compliance interval
+		var defaultIntegrationIDs []string
+		scheduledIntegrationIDs := make(map[uint][]string)
+		scheduleByID := make(map[uint]*model.Schedule)
+		for _, integrationID := range integrationIDs {
+			schedule := schedules.Resolve(integrationID, framework.ID)
+			if schedule == nil {
+				defaultIntegrationIDs = append(defaultIntegrationIDs, integrationID)
+				continue
+			}
+			scheduleByID[schedule.ID] = schedule
+			scheduledIntegrationIDs[schedule.ID] = append(scheduledIntegrationIDs[schedule.ID], integrationID)
 		}
 
-		timeAt := time.Now().Add(-s.complianceIntervalHours)
-		if complianceJob == nil ||
-			complianceJob.CreatedAt.Before(timeAt) {
-
-			_, err := s.CreateComplianceReportJobs(true, framework.ID, complianceJob, integrationIDs, false, "scheduler", nil)
+		if len(defaultIntegrationIDs) > 0 && s.complianceIntervalHours > 0 {
+			timeAt := time.Now().Add(-s.complianceIntervalHours)
+			err = s.triggerIfDue(framework.ID, defaultIntegrationIDs, func(lastRun time.Time) bool {
+				return lastRun.Before(timeAt)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for scheduleID, scheduleIntegrationIDs := range scheduledIntegrationIDs {
+			schedule := scheduleByID[scheduleID]
+			err = s.triggerIfDue(framework.ID, scheduleIntegrationIDs, func(lastRun time.Time) bool {
+				return schedule.IsDue(lastRun, time.Now())
+			})
 			if err != nil {
-				s.logger.Error("error while creating compliance job", zap.Error(err))
 				return err
 			}
 		}
@@ -91,6 +115,44 @@ func (s *JobScheduler) runScheduler() error {
 	return nil
 }
 
+func (s *JobScheduler) triggerIfDue(frameworkID string, integrationIDs []string, isDue func(lastRun time.Time) bool) error {
+	complianceJob, err := s.db.GetLastComplianceJobForIntegrations(true, frameworkID, integrationIDs)
+	if err != nil {
+		s.logger.Error("error while getting last compliance job", zap.Error(err))
+		return err
+	}
+
+	if complianceJob != nil && !isDue(complianceJob.CreatedAt) {
+		return nil
+	}
+
+	_, err = s.CreateComplianceReportJobs(true, frameworkID, complianceJob, integrationIDs, false, "scheduler", nil)
+	if err != nil {
+		s.logger.Error("error while creating compliance job", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *JobScheduler) loadScheduleSet(clientCtx *httpclient.Context) (*model.ScheduleSet, error) {
+	jobType := schedulerApi.ScheduleJobTypeCompliance
+	schedules, err := s.db.ListSchedules(&jobType, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	set := model.NewScheduleSet(schedules)
+	if set.NeedsIntegrationGroups() {
+		groups, err := s.integrationClient.ListIntegrationGroups(clientCtx)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			set.SetIntegrationGroupMembers(group.Name, group.IntegrationIds)
+		}
+	}
+	return set, nil
+}
+
 func (s *JobScheduler) updateRunnersState() error {
 	complianceJobs, err := s.db.ListComplianceJobsByStatus(aws.Bool(true), model.ComplianceJobRunnersInProgress)
 	if err != nil {
@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opengovern/og-util/pkg/ticker"
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+	"github.com/opengovern/opensecurity/services/scheduler/db/model"
+	"go.uber.org/zap"
+)
+
+// RunDeliverer sends the pending deliveries and retries the failed ones with an exponential backoff
+func (s *Scheduler) RunDeliverer(ctx context.Context) {
+	s.logger.Info("Scheduling notification deliverer on a timer")
+
+	t := ticker.NewTicker(DeliveryInterval, time.Second*5)
+	defer t.Stop()
+
+	for ; ; <-t.C {
+		if err := s.deliverPending(ctx); err != nil {
+			s.logger.Error("failed to deliver notifications", zap.Error(err))
+		}
+	}
+}
+
+func (s *Scheduler) deliverPending(ctx context.Context) error {
+	deliveries, err := s.db.ListDueNotificationDeliveries(time.Now(), DeliveryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	destinations := make(map[uint]*model.NotificationDestination)
+	for _, delivery := range deliveries {
+		delivery := delivery
+		destination, ok := destinations[delivery.DestinationID]
+		if !ok {
+			destination, err = s.db.GetNotificationDestination(delivery.DestinationID)
+			if err != nil {
+				return err
+			}
+			destinations[delivery.DestinationID] = destination
+		}
+
+		sendErr := s.deliver(ctx, destination, delivery)
+		s.recordAttempt(&delivery, sendErr)
+		if err := s.db.UpdateNotificationDeliveryAttempt(&delivery); err != nil {
+			s.logger.Error("failed to update notification delivery", zap.Uint("delivery_id", delivery.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) deliver(ctx context.Context, destination *model.NotificationDestination, delivery model.NotificationDelivery) error {
+	if destination == nil {
+		return fmt.Errorf("destination %d not found", delivery.DestinationID)
+	}
+	if !destination.Enabled {
+		return fmt.Errorf("destination %d is disabled", delivery.DestinationID)
+	}
+
+	var payload api.NotificationPayload
+	if err := json.Unmarshal(delivery.Payload.Bytes, &payload); err != nil {
+		return fmt.Errorf("invalid payload: %v", err)
+	}
+	payload.DeliveryID = delivery.ID
+
+	config, err := destination.GetConfig()
+	if err != nil {
+		return fmt.Errorf("invalid destination config: %v", err)
+	}
+
+	switch destination.Type {
+	case api.NotificationDestinationTypeWebhook:
+		return s.sendWebhook(ctx, config, payload)
+	case api.NotificationDestinationTypeSlack:
+		return s.sendSlack(ctx, config, payload)
+	case api.NotificationDestinationTypeEmail:
+		return s.sendEmail(config, payload)
+	default:
+		return fmt.Errorf("unsupported destination type %s", destination.Type)
+	}
+}
+
+func (s *Scheduler) recordAttempt(delivery *model.NotificationDelivery, sendErr error) {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+	if sendErr == nil {
+		delivery.Status = api.NotificationDeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	s.logger.Warn("notification delivery failed", zap.Uint("delivery_id", delivery.ID),
+		zap.Int("attempts", delivery.Attempts), zap.Error(sendErr))
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= MaxDeliveryAttempts {
+		delivery.Status = api.NotificationDeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+	next := now.Add(retryBackoff(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+}
+
+func retryBackoff(attempts int) time.Duration {
+	backoff := BaseRetryBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > MaxRetryBackoff {
+		return MaxRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,184 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/opengovern/og-util/pkg/ticker"
+	"github.com/opengovern/opensecurity/pkg/types"
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+	"github.com/opengovern/opensecurity/services/scheduler/db/model"
+	"github.com/opengovern/opensecurity/services/scheduler/es"
+	"go.uber.org/zap"
+)
+
+// RunDispatcher routes the drift events of every compliance job finished by the summarizer to the matching rules
+func (s *Scheduler) RunDispatcher(ctx context.Context) {
+	s.logger.Info("Scheduling notification dispatcher on a timer")
+
+	t := ticker.NewTicker(DispatchInterval, time.Second*10)
+	defer t.Stop()
+
+	for ; ; <-t.C {
+		if err := s.dispatch(ctx); err != nil {
+			s.logger.Error("failed to dispatch notifications", zap.Error(err))
+		}
+	}
+}
+
+func (s *Scheduler) dispatch(ctx context.Context) error {
+	jobs, err := s.db.ListComplianceJobsPendingNotification(time.Now().Add(-DispatchLookback))
+	if err != nil {
+		s.logger.Error("failed to list compliance jobs pending notification", zap.Error(err))
+		return err
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	rules, err := s.db.ListActiveNotificationRules()
+	if err != nil {
+		s.logger.Error("failed to list notification rules", zap.Error(err))
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := s.dispatchComplianceJob(ctx, job, rules); err != nil {
+			s.logger.Error("failed to dispatch compliance job notifications", zap.Uint("job_id", job.ID), zap.Error(err))
+			continue
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) dispatchComplianceJob(ctx context.Context, job model.ComplianceJob, rules []model.NotificationRule) error {
+	dispatch := model.NotificationDispatch{
+		ComplianceJobID: job.ID,
+	}
+	if len(rules) == 0 {
+		return s.db.CreateNotificationDispatch(&dispatch, nil)
+	}
+
+	events, total, err := es.GetComplianceResultDriftEventsByComplianceJobID(ctx, s.esClient, job.ID)
+	if err != nil {
+		return err
+	}
+	if total > int64(len(events)) {
+		s.logger.Warn("compliance job has more drift events than can be dispatched",
+			zap.Uint("job_id", job.ID), zap.Int64("total", total), zap.Int("dispatched", len(events)))
+	}
+	dispatch.EventCount = len(events)
+
+	var deliveries []model.NotificationDelivery
+	for _, rule := range rules {
+		if !rule.HandlesEvent(api.NotificationEventTypeComplianceDrift) {
+			continue
+		}
+		var matched []types.ComplianceResultDriftEvent
+		for _, event := range events {
+			if rule.Matches(event) {
+				matched = append(matched, event)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		payload := api.NotificationPayload{
+			Event:           api.NotificationEventTypeComplianceDrift,
+			RuleID:          rule.ID,
+			RuleName:        rule.Name,
+			ComplianceJobID: job.ID,
+			FrameworkIDs:    job.FrameworkIds,
+			TotalEvents:     len(matched),
+			Events:          matched[:min(len(matched), MaxEventsPerNotification)],
+			CreatedAt:       time.Now(),
+		}
+		jp, err := payloadJSONB(payload)
+		if err != nil {
+			return err
+		}
+
+		deliveries = append(deliveries, model.NotificationDelivery{
+			RuleID:          rule.ID,
+			DestinationID:   rule.DestinationID,
+			ComplianceJobID: job.ID,
+			Payload:         jp,
+			EventCount:      len(matched),
+			Status:          api.NotificationDeliveryStatusPending,
+		})
+	}
+	dispatch.DeliveryCount = len(deliveries)
+
+	s.logger.Info("dispatching compliance job notifications", zap.Uint("job_id", job.ID),
+		zap.Int("events", len(events)), zap.Int("deliveries", len(deliveries)))
+	return s.db.CreateNotificationDispatch(&dispatch, deliveries)
+}
+
+// DispatchPendingIntegrations creates a delivery for every active rule subscribed to pending integrations
+func (s *Scheduler) DispatchPendingIntegrations(request api.NotifyPendingIntegrationsRequest) (int, error) {
+	if len(request.Integrations) == 0 {
+		return 0, nil
+	}
+	rules, err := s.db.ListActiveNotificationRules()
+	if err != nil {
+		return 0, err
+	}
+
+	var deliveries []model.NotificationDelivery
+	for _, rule := range rules {
+		if !rule.HandlesEvent(api.NotificationEventTypePendingIntegrations) {
+			continue
+		}
+		jp, err := payloadJSONB(api.NotificationPayload{
+			Event:               api.NotificationEventTypePendingIntegrations,
+			RuleID:              rule.ID,
+			RuleName:            rule.Name,
+			CredentialID:        request.CredentialID,
+			IntegrationType:     request.IntegrationType,
+			TotalEvents:         len(request.Integrations),
+			PendingIntegrations: request.Integrations[:min(len(request.Integrations), MaxEventsPerNotification)],
+			CreatedAt:           time.Now(),
+		})
+		if err != nil {
+			return 0, err
+		}
+		deliveries = append(deliveries, model.NotificationDelivery{
+			RuleID:        rule.ID,
+			DestinationID: rule.DestinationID,
+			Payload:       jp,
+			EventCount:    len(request.Integrations),
+			Status:        api.NotificationDeliveryStatusPending,
+		})
+	}
+
+	s.logger.Info("dispatching pending integrations notifications", zap.String("credential_id", request.CredentialID),
+		zap.Int("integrations", len(request.Integrations)), zap.Int("deliveries", len(deliveries)))
+	if err := s.db.CreateNotificationDeliveries(deliveries); err != nil {
+		return 0, err
+	}
+	return len(deliveries), nil
+}
+
+func payloadJSONB(payload api.NotificationPayload) (pgtype.JSONB, error) {
+	jp := pgtype.JSONB{}
+	payloadJson, err := json.Marshal(payload)
+	if err != nil {
+		return jp, err
+	}
+	err = jp.Set(payloadJson)
+	return jp, err
+}
+
+func (s *Scheduler) RunCleanup() {
+	t := ticker.NewTicker(CleanupInterval, time.Second*10)
+	defer t.Stop()
+
+	for ; ; <-t.C {
+		if err := s.db.CleanupNotificationDeliveriesOlderThan(time.Now().Add(-DeliveryRetention)); err != nil {
+			s.logger.Error("failed to cleanup notification deliveries", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+)
+
+const (
+	WebhookEventHeader     = "X-OpenSecurity-Event"
+	WebhookDeliveryHeader  = "X-OpenSecurity-Delivery"
+	WebhookTimestampHeader = "X-OpenSecurity-Timestamp"
+	// WebhookSignatureHeader holds "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the destination secret
+	WebhookSignatureHeader = "X-OpenSecurity-Signature"
+
+	WebhookEventComplianceDrift = string(api.NotificationEventTypeComplianceDrift)
+
+	// maxSummaryEvents is the number of events listed in slack and email messages
+	maxSummaryEvents = 20
+)
+
+func SignWebhookPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Scheduler) sendWebhook(ctx context.Context, config api.NotificationDestinationConfig, payload api.NotificationPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range config.Headers {
+		req.Header.Set(k, v)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, payloadEvent(payload))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(payload.DeliveryID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	if config.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(config.Secret, timestamp, body))
+	}
+
+	return s.doRequest(req)
+}
+
+func (s *Scheduler) sendSlack(ctx context.Context, config api.NotificationDestinationConfig, payload api.NotificationPayload) error {
+	body, err := json.Marshal(map[string]string{
+		"text": "*" + summaryTitle(payload) + "*\n" + summaryBody(payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return s.doRequest(req)
+}
+
+func (s *Scheduler) doRequest(req *http.Request) error {
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("destination responded with status %d: %s", res.StatusCode, string(resBody))
+	}
+	return nil
+}
+
+func (s *Scheduler) sendEmail(config api.NotificationDestinationConfig, payload api.NotificationPayload) error {
+	smtpConf := s.conf.Notification.SMTP
+	if smtpConf.Host == "" {
+		return fmt.Errorf("smtp is not configured")
+	}
+	if len(config.Recipients) == 0 {
+		return fmt.Errorf("no recipients configured")
+	}
+	port := smtpConf.Port
+	if port == 0 {
+		port = 587
+	}
+
+	var auth smtp.Auth
+	if smtpConf.Username != "" {
+		auth = smtp.PlainAuth("", smtpConf.Username, smtpConf.Password, smtpConf.Host)
+	}
+
+	msg := strings.Builder{}
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", smtpConf.From))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", emailHeaderValue(strings.Join(config.Recipients, ", "))))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", emailHeaderValue(summaryTitle(payload))))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(summaryBody(payload), "\n", "\r\n"))
+
+	return smtp.SendMail(fmt.Sprintf("%s:%d", smtpConf.Host, port), auth, smtpConf.From, config.Recipients, []byte(msg.String()))
+}
+
+// emailHeaderValue drops the line breaks that would let a rule name inject headers into the message
+func emailHeaderValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(value)
+}
+
+// payloadEvent returns the event of the payload, deliveries stored before event types were added are compliance drift
+func payloadEvent(payload api.NotificationPayload) string {
+	if payload.Event == "" {
+		return WebhookEventComplianceDrift
+	}
+	return string(payload.Event)
+}
+
+func summaryTitle(payload api.NotificationPayload) string {
+	if payload.Event == api.NotificationEventTypePendingIntegrations {
+		return fmt.Sprintf("[OpenSecurity] %d new %s integrations pending onboarding matched rule %s",
+			payload.TotalEvents, payload.IntegrationType, payload.RuleName)
+	}
+	return fmt.Sprintf("[OpenSecurity] %d compliance changes matched rule %s (job %d)",
+		payload.TotalEvents, payload.RuleName, payload.ComplianceJobID)
+}
+
+func summaryBody(payload api.NotificationPayload) string {
+	builder := strings.Builder{}
+	if payload.Event == api.NotificationEventTypePendingIntegrations {
+		builder.WriteString(fmt.Sprintf("Credential %s discovered integrations its onboarding policy did not add:\n", payload.CredentialID))
+		for i, pending := range payload.PendingIntegrations {
+			if i >= maxSummaryEvents {
+				break
+			}
+			builder.WriteString(fmt.Sprintf("- %s (%s)\n", pending.Name, pending.ProviderID))
+		}
+		if payload.TotalEvents > maxSummaryEvents {
+			builder.WriteString(fmt.Sprintf("... and %d more\n", payload.TotalEvents-maxSummaryEvents))
+		}
+		return builder.String()
+	}
+	for i, event := range payload.Events {
+		if i >= maxSummaryEvents {
+			break
+		}
+		previous := string(event.PreviousComplianceStatus)
+		if previous == "" {
+			previous = "new"
+		}
+		state := ""
+		if !event.StateActive {
+			state = " (inactive)"
+		}
+		builder.WriteString(fmt.Sprintf("- [%s] %s / %s: %s -> %s%s on %s\n",
+			event.Severity, event.BenchmarkID, event.ControlID, previous, event.ComplianceStatus, state, event.PlatformResourceID))
+	}
+	if payload.TotalEvents > maxSummaryEvents {
+		builder.WriteString(fmt.Sprintf("... and %d more\n", payload.TotalEvents-maxSummaryEvents))
+	}
+	return builder.String()
+}
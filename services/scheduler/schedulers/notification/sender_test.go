@@ -0,0 +1,35 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/opengovern/opensecurity/services/scheduler/api"
+)
+
+func TestEmailHeaderValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plain", value: "drift on prod", want: "drift on prod"},
+		{name: "crlf", value: "rule\r\nBcc: attacker@example.com", want: "rule Bcc: attacker@example.com"},
+		{name: "lf", value: "rule\nBcc: attacker@example.com", want: "rule Bcc: attacker@example.com"},
+		{name: "cr", value: "rule\rBcc: attacker@example.com", want: "ruleBcc: attacker@example.com"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := emailHeaderValue(tc.value); got != tc.want {
+				t.Errorf("emailHeaderValue(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSummaryTitleHasNoLineBreak(t *testing.T) {
+	title := emailHeaderValue(summaryTitle(api.NotificationPayload{RuleName: "a\r\nSubject: b", TotalEvents: 2, ComplianceJobID: 3}))
+	want := "[OpenSecurity] 2 compliance changes matched rule a Subject: b (job 3)"
+	if title != want {
+		t.Errorf("got %q, want %q", title, want)
+	}
+}
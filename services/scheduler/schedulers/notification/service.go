@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/opengovern/og-util/pkg/opengovernance-es-sdk"
+	"github.com/opengovern/opensecurity/pkg/utils"
+	"github.com/opengovern/opensecurity/services/scheduler/config"
+	"github.com/opengovern/opensecurity/services/scheduler/db"
+	"go.uber.org/zap"
+)
+
+const (
+	DispatchInterval = 1 * time.Minute
+	DeliveryInterval = 30 * time.Second
+	CleanupInterval  = 1 * time.Hour
+
+	// DispatchLookback is how far back finished compliance jobs are picked up for dispatching
+	DispatchLookback = 24 * time.Hour
+	// DeliveryRetention is how long the delivery log is kept
+	DeliveryRetention = 30 * 24 * time.Hour
+
+	MaxEventsPerNotification = 100
+	MaxDeliveryAttempts      = 5
+	DeliveryBatchSize        = 50
+	BaseRetryBackoff         = 1 * time.Minute
+	MaxRetryBackoff          = 1 * time.Hour
+)
+
+type Scheduler struct {
+	conf       config.SchedulerConfig
+	logger     *zap.Logger
+	db         db.Database
+	esClient   opengovernance.Client
+	httpClient *http.Client
+}
+
+func New(conf config.SchedulerConfig, logger *zap.Logger, db db.Database, esClient opengovernance.Client) *Scheduler {
+	return &Scheduler{
+		conf:     conf,
+		logger:   logger.Named("notification"),
+		db:       db,
+		esClient: esClient,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	utils.EnsureRunGoroutine(func() {
+		s.RunDispatcher(ctx)
+	})
+	utils.EnsureRunGoroutine(func() {
+		s.RunDeliverer(ctx)
+	})
+	utils.EnsureRunGoroutine(func() {
+		s.RunCleanup()
+	})
+}
@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/opengovern/opensecurity/pkg/audit"
+	"github.com/opengovern/opensecurity/pkg/rbac"
 	es2 "github.com/opengovern/opensecurity/services/compliance/es"
 	"net/http"
+	"net/mail"
+	"net/url"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,6 +24,7 @@ import (
 
 	"github.com/jackc/pgtype"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 	apiAuth "github.com/opengovern/og-util/pkg/api"
 	"github.com/opengovern/og-util/pkg/describe/enums"
 	"github.com/opengovern/og-util/pkg/httpclient"
@@ -60,6 +66,19 @@ func NewHTTPServer(
 }
 
 func (h HttpServer) Register(e *echo.Echo) {
+	e.Use(audit.Middleware("scheduler", audit.NewSinkFromEnv(h.Scheduler.logger),
+		"/api/v1/jobs",
+		"/api/v3/jobs",
+		"/api/v3/jobs/discovery",
+		"/api/v3/jobs/compliance",
+		"/api/v3/jobs/discovery/connections",
+		"/api/v3/jobs/compliance/connections",
+		"/api/v3/jobs/discovery/connections/:connection_id",
+		"/api/v3/jobs/compliance/connections/:connection_id",
+		"/api/v3/discovery/status",
+		"/api/v3/benchmark/:benchmark_id/run-history",
+	))
+
 	v1 := e.Group("/api/v1")
 
 	v1.PUT("/describe/trigger/:connection_id", httpserver.AuthorizeHandler(h.TriggerPerConnectionDescribeJob, apiAuth.AdminRole))
@@ -109,9 +128,29 @@ func (h HttpServer) Register(e *echo.Echo) {
 	v3.PUT("/sample/purge", httpserver.AuthorizeHandler(h.PurgeSampleData, apiAuth.AdminRole))
 
 	v3.GET("/integration/discovery/last-job", httpserver.AuthorizeHandler(h.GetIntegrationLastDiscoveryJob, apiAuth.ViewerRole))
+	v3.GET("/integration/:integration_id/discovery/permission-failures", httpserver.AuthorizeHandler(h.GetIntegrationDiscoveryPermissionFailures, apiAuth.ViewerRole))
 
 	v3.POST("/compliance/quick/sequence", httpserver.AuthorizeHandler(h.CreateComplianceQuickSequence, apiAuth.EditorRole))
 	v3.GET("/compliance/quick/sequence/:run_id", httpserver.AuthorizeHandler(h.GetComplianceQuickSequence, apiAuth.ViewerRole))
+
+	v3.GET("/schedules", httpserver.AuthorizeHandler(h.ListSchedules, apiAuth.ViewerRole))
+	v3.POST("/schedules", httpserver.AuthorizeHandler(h.CreateSchedule, apiAuth.AdminRole))
+	v3.GET("/schedules/:schedule_id", httpserver.AuthorizeHandler(h.GetSchedule, apiAuth.ViewerRole))
+	v3.PUT("/schedules/:schedule_id", httpserver.AuthorizeHandler(h.UpdateSchedule, apiAuth.AdminRole))
+	v3.DELETE("/schedules/:schedule_id", httpserver.AuthorizeHandler(h.DeleteSchedule, apiAuth.AdminRole))
+
+	v3.GET("/notifications/destinations", httpserver.AuthorizeHandler(h.ListNotificationDestinations, apiAuth.ViewerRole))
+	v3.POST("/notifications/destinations", httpserver.AuthorizeHandler(h.CreateNotificationDestination, apiAuth.AdminRole))
+	v3.GET("/notifications/destinations/:destination_id", httpserver.AuthorizeHandler(h.GetNotificationDestination, apiAuth.ViewerRole))
+	v3.PUT("/notifications/destinations/:destination_id", httpserver.AuthorizeHandler(h.UpdateNotificationDestination, apiAuth.AdminRole))
+	v3.DELETE("/notifications/destinations/:destination_id", httpserver.AuthorizeHandler(h.DeleteNotificationDestination, apiAuth.AdminRole))
+	v3.GET("/notifications/rules", httpserver.AuthorizeHandler(h.ListNotificationRules, apiAuth.ViewerRole))
+	v3.POST("/notifications/rules", httpserver.AuthorizeHandler(h.CreateNotificationRule, apiAuth.AdminRole))
+	v3.GET("/notifications/rules/:rule_id", httpserver.AuthorizeHandler(h.GetNotificationRule, apiAuth.ViewerRole))
+	v3.PUT("/notifications/rules/:rule_id", httpserver.AuthorizeHandler(h.UpdateNotificationRule, apiAuth.AdminRole))
+	v3.DELETE("/notifications/rules/:rule_id", httpserver.AuthorizeHandler(h.DeleteNotificationRule, apiAuth.AdminRole))
+	v3.GET("/notifications/deliveries", httpserver.AuthorizeHandler(h.ListNotificationDeliveries, apiAuth.ViewerRole))
+	v3.POST("/notifications/events/pending-integrations", httpserver.AuthorizeHandler(h.NotifyPendingIntegrations, apiAuth.AdminRole))
 }
 
 // ListJobs godoc
@@ -175,6 +214,13 @@ func (h HttpServer) ListJobs(ctx echo.Context) error {
 		return err
 	}
 	for _, job := range describeJobs {
+		if job.JobType == "compliance" {
+			if !rbac.HasFrameworkAccess(ctx, job.Title) {
+				continue
+			}
+		} else if !rbac.HasIntegrationAccess(ctx, job.ConnectionID) {
+			continue
+		}
 		var jobSRC integrationapi.Integration
 		for _, src := range srcs.Integrations {
 			if src.IntegrationID == job.ConnectionID {
@@ -419,7 +465,7 @@ func (h HttpServer) TriggerPerConnectionDescribeJob(ctx echo.Context) error {
 		}
 
 		for _, resourceType := range resourceTypes {
-			daj, err := h.Scheduler.describe(src, resourceType, false, costFullDiscovery, false, nil, userID, nil)
+			daj, err := h.Scheduler.describe(src, resourceType, false, nil, costFullDiscovery, false, nil, userID, nil)
 			if err != nil && errors.Is(err, ErrJobInProgress) {
 				return echo.NewHTTPError(http.StatusConflict, err.Error())
 			}
@@ -489,7 +535,7 @@ func (h HttpServer) TriggerDescribeJob(ctx echo.Context) error {
 		}
 
 		for _, resourceType := range rtToDescribe {
-			_, err = h.Scheduler.describe(integration, resourceType, false, false, false, nil, userID, nil)
+			_, err = h.Scheduler.describe(integration, resourceType, false, nil, false, false, nil, userID, nil)
 			if err != nil {
 				h.Scheduler.logger.Error("failed to describe connection", zap.String("integration_id", integration.IntegrationID), zap.Error(err))
 			}
@@ -756,7 +802,7 @@ func (h HttpServer) ReEvaluateComplianceJob(ctx echo.Context) error {
 
 	var dependencyIDs []int64
 	for _, describeJob := range describeJobs {
-		daj, err := h.Scheduler.describe(describeJob.Integration, describeJob.ResourceType, false, false, false, nil, userID, nil)
+		daj, err := h.Scheduler.describe(describeJob.Integration, describeJob.ResourceType, false, nil, false, false, nil, userID, nil)
 		if err != nil {
 			h.Scheduler.logger.Error("failed to describe connection", zap.String("integration_id", describeJob.Integration.IntegrationID), zap.Error(err))
 			continue
@@ -897,12 +943,25 @@ func (h HttpServer) GetComplianceBenchmarkStatus(ctx echo.Context) error {
 	}
 
 	benchmarkId := ctx.Param("benchmark_id")
-	lastComplianceJob, err := h.Scheduler.db.GetLastComplianceJob(true, benchmarkId)
+	if err := rbac.CheckFrameworkAccess(ctx, benchmarkId); err != nil {
+		return err
+	}
+	var lastComplianceJob *model2.ComplianceJob
+	var err error
+	if beforeStr := ctx.QueryParam("before"); beforeStr != "" {
+		before, parseErr := strconv.ParseInt(beforeStr, 10, 64)
+		if parseErr != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid before")
+		}
+		lastComplianceJob, err = h.Scheduler.db.GetLastSucceededComplianceJobBefore(true, benchmarkId, time.Unix(before, 0))
+	} else {
+		lastComplianceJob, err = h.Scheduler.db.GetLastComplianceJob(true, benchmarkId)
+	}
 	if err != nil {
 		h.Scheduler.logger.Error("failed to get compliance job", zap.String("benchmark_id", benchmarkId), zap.Error(err))
 		return err
 	}
-	if lastComplianceJob == nil {
+	if lastComplianceJob == nil || !hasComplianceJobAccess(ctx, *lastComplianceJob) {
 		return ctx.JSON(http.StatusOK, nil)
 	}
 	return ctx.JSON(http.StatusOK, lastComplianceJob.ToApi())
@@ -1072,6 +1131,9 @@ func removeDuplicates(s []string) []string {
 //	@Router		/schedule/api/v3/jobs/discovery/connections/{connection_id} [post]
 func (h HttpServer) GetDescribeJobsHistory(ctx echo.Context) error {
 	connectionId := ctx.Param("connection_id")
+	if err := rbac.CheckIntegrationAccess(ctx, connectionId); err != nil {
+		return err
+	}
 
 	var request api.GetDescribeJobsHistoryRequest
 	if err := ctx.Bind(&request); err != nil {
@@ -1162,6 +1224,9 @@ func (h HttpServer) GetComplianceJobsHistory(ctx echo.Context) error {
 	}
 
 	connectionId := ctx.Param("connection_id")
+	if err := rbac.CheckIntegrationAccess(ctx, connectionId); err != nil {
+		return err
+	}
 
 	jobs, err := h.DB.ListComplianceJobsByFilters(nil, []string{connectionId}, request.BenchmarkId, request.JobStatus, &request.StartTime, request.EndTime)
 	if err != nil {
@@ -1170,6 +1235,9 @@ func (h HttpServer) GetComplianceJobsHistory(ctx echo.Context) error {
 
 	var jobsResults []api.GetComplianceJobsHistoryResponse
 	for _, j := range jobs {
+		if !hasComplianceJobAccess(ctx, j) {
+			continue
+		}
 		jobsResults = append(jobsResults, api.GetComplianceJobsHistoryResponse{
 			JobId:         j.ID,
 			WithIncidents: j.WithIncidents,
@@ -1619,7 +1687,7 @@ func (h HttpServer) RunDiscovery(ctx echo.Context) error {
 				continue
 			}
 			var status, failureReason string
-			job, err := h.Scheduler.describe(integration, resourceType.ResourceType, false, false, false, &integrationDiscovery.ID, userID, resourceType.Parameters)
+			job, err := h.Scheduler.describe(integration, resourceType.ResourceType, false, nil, false, false, &integrationDiscovery.ID, userID, resourceType.Parameters)
 			if err != nil {
 				if err.Error() == "job already in progress" {
 					h.Scheduler.logger.Error("failed to describe connection", zap.String("integration_id", integration.IntegrationID), zap.Error(err))
@@ -1988,6 +2056,10 @@ func (h HttpServer) ListDescribeJobs(ctx echo.Context) error {
 		}
 		connectionIDs = append(connectionIDs, c.IntegrationID)
 	}
+	connectionIDs, err := rbac.ResolveIntegrationIDs(ctx, connectionIDs)
+	if err != nil {
+		return err
+	}
 
 	var jobsResults []api.GetDescribeJobsHistoryResponse
 	var startTime, endTime *time.Time
@@ -2147,6 +2219,9 @@ func (h HttpServer) ListComplianceJobs(ctx echo.Context) error {
 
 	var jobsResults []api.ListComplianceJobsItem
 	for _, j := range jobs {
+		if !hasComplianceJobAccess(ctx, j) {
+			continue
+		}
 		var frameworks []api.ComplianceJobFrameworkInfo
 		for _, benchmark := range benchmarks {
 			if fmt.Sprintf("%v", benchmark.ID) == j.FrameworkIds[0] {
@@ -2256,6 +2331,9 @@ func (h HttpServer) BenchmarkAuditHistory(ctx echo.Context) error {
 	clientCtx := &httpclient.Context{UserRole: apiAuth.AdminRole}
 
 	benchmarkID := ctx.Param("benchmark_id")
+	if err := rbac.CheckFrameworkAccess(ctx, benchmarkID); err != nil {
+		return err
+	}
 
 	var request api.BenchmarkAuditHistoryRequest
 	if err := ctx.Bind(&request); err != nil {
@@ -2327,6 +2405,9 @@ func (h HttpServer) BenchmarkAuditHistory(ctx echo.Context) error {
 	}
 
 	for _, j := range jobs {
+		if !hasComplianceJobAccess(ctx, j) {
+			continue
+		}
 		item := api.BenchmarkAuditHistoryItem{
 			JobId:         j.ID,
 			WithIncidents: j.WithIncidents,
@@ -2438,6 +2519,72 @@ func (h HttpServer) BenchmarkAuditHistoryIntegrations(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, integrations)
 }
 
+// GetIntegrationDiscoveryPermissionFailures godoc
+//
+//	@Summary		Get discovery permission failures of an integration
+//	@Description	List the resource types whose latest discovery jobs of the integration failed because of missing permissions
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Param			integration_id	path	string	true	"Integration ID"
+//	@Param			since			query	string	false	"RFC3339 time of the oldest job looked at, defaults to a week ago"
+//	@Produce		json
+//	@Success		200	{object}	api.GetIntegrationDiscoveryPermissionFailuresResponse
+//	@Router			/schedule/api/v3/integration/{integration_id}/discovery/permission-failures [get]
+func (h HttpServer) GetIntegrationDiscoveryPermissionFailures(ctx echo.Context) error {
+	integrationID := ctx.Param("integration_id")
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	if v := ctx.QueryParam("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid since")
+		}
+		since = t
+	}
+
+	jobs, err := h.DB.ListFinishedDescribeIntegrationJobs(integrationID, since)
+	if err != nil {
+		h.Scheduler.logger.Error("failed to list describe jobs", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list describe jobs")
+	}
+
+	permissionErrorCodes := api.DiscoveryPermissionErrorCodes
+	if codes := h.Scheduler.conf.Discovery.PermissionErrorCodes; len(codes) > 0 {
+		permissionErrorCodes = codes
+	}
+
+	// jobs are grouped by resource type, latest first, only failures at the head of each group count
+	items := make([]api.IntegrationDiscoveryPermissionFailure, 0)
+	var current *api.IntegrationDiscoveryPermissionFailure
+	broken := false
+	for _, job := range jobs {
+		if current == nil || current.ResourceType != job.ResourceType {
+			if current != nil && current.ConsecutiveFailures > 0 {
+				items = append(items, *current)
+			}
+			current = &api.IntegrationDiscoveryPermissionFailure{ResourceType: job.ResourceType}
+			broken = false
+		}
+		if broken {
+			continue
+		}
+		if job.Status == api.DescribeResourceJobSucceeded || !slices.Contains(permissionErrorCodes, job.ErrorCode) {
+			broken = true
+			continue
+		}
+		if current.ConsecutiveFailures == 0 {
+			current.ErrorCode = job.ErrorCode
+			current.FailureMessage = job.FailureMessage
+			current.LastFailedAt = job.UpdatedAt
+		}
+		current.ConsecutiveFailures++
+	}
+	if current != nil && current.ConsecutiveFailures > 0 {
+		items = append(items, *current)
+	}
+
+	return ctx.JSON(http.StatusOK, api.GetIntegrationDiscoveryPermissionFailuresResponse{Items: items})
+}
+
 // GetIntegrationLastDiscoveryJob godoc
 //
 //	@Summary	Get Last dicovery job for integration
@@ -2550,6 +2697,9 @@ func (h HttpServer) GetDescribeJobsHistoryByIntegration(ctx echo.Context) error
 	var jobsResults []api.GetDescribeJobsHistoryResponse
 
 	for _, c := range connectionInfo {
+		if !rbac.HasIntegrationAccess(ctx, c.IntegrationID) {
+			continue
+		}
 		jobs, err := h.DB.ListDescribeJobsByFilters(nil, []string{c.IntegrationID}, request.ResourceType,
 			request.DiscoveryType, request.JobStatus, &request.StartTime, request.EndTime)
 		if err != nil {
@@ -2669,12 +2819,18 @@ func (h HttpServer) GetComplianceJobsHistoryByIntegration(ctx echo.Context) erro
 
 	var jobsResults []api.GetComplianceJobsHistoryResponse
 	for _, c := range connectionInfo {
+		if err := rbac.CheckIntegrationAccess(ctx, c.IntegrationID); err != nil {
+			return err
+		}
 		jobs, err := h.DB.ListComplianceJobsByFilters(request.WithIncidents, []string{c.IntegrationID}, request.BenchmarkId, request.JobStatus, &request.StartTime, request.EndTime)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 
 		for _, j := range jobs {
+			if !hasComplianceJobAccess(ctx, j) {
+				continue
+			}
 			jobsResults = append(jobsResults, api.GetComplianceJobsHistoryResponse{
 				JobId:          j.ID,
 				WithIncidents:  j.WithIncidents,
@@ -2993,6 +3149,12 @@ func (h HttpServer) CancelJob(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid selector. valid values: job_id, integration, status")
 	}
 
+	canceledStatus := map[string]string{
+		"compliance": string(model2.ComplianceJobCanceled),
+		"discovery":  string(api.DescribeResourceJobCanceled),
+		"query":      string(queryrunner.QueryRunnerCanceled),
+	}[strings.ToLower(request.JobType)]
+	before, after := make(map[string]string), make(map[string]string)
 	for _, jobIdStr := range jobIDs {
 		var failureReason string
 		var canceled bool
@@ -3015,6 +3177,7 @@ func (h HttpServer) CancelJob(ctx echo.Context) error {
 				failureReason = "job not found"
 				break
 			}
+			before[jobIdStr] = string(complianceJob.Status)
 			if complianceJob.Status == model2.ComplianceJobCreated {
 				err = h.DB.UpdateComplianceJob(uint(jobId), model2.ComplianceJobCanceled, "", nil)
 				if err != nil {
@@ -3080,6 +3243,7 @@ func (h HttpServer) CancelJob(ctx echo.Context) error {
 				failureReason = "job not found"
 				break
 			}
+			before[jobIdStr] = string(job.Status)
 			if job.Status == api.DescribeResourceJobCreated {
 				err = h.DB.UpdateDescribeIntegrationJobStatus(job.ID, api.DescribeResourceJobCanceled, "", "", 0, 0)
 				if err != nil {
@@ -3126,6 +3290,7 @@ func (h HttpServer) CancelJob(ctx echo.Context) error {
 				failureReason = "job not found"
 				break
 			}
+			before[jobIdStr] = string(job.Status)
 			if job.Status == queryrunner.QueryRunnerCreated {
 				err = h.DB.UpdateQueryRunnerJobStatus(job.ID, queryrunner.QueryRunnerCanceled, "")
 				if err != nil {
@@ -3154,6 +3319,11 @@ func (h HttpServer) CancelJob(ctx echo.Context) error {
 			failureReason = failureReason + ", " + "invalid job type"
 			break
 		}
+		if canceled {
+			after[jobIdStr] = canceledStatus
+		} else if status, ok := before[jobIdStr]; ok {
+			after[jobIdStr] = status
+		}
 		results = append(results, api.CancelJobResponse{
 			JobId:    jobIdStr,
 			JobType:  strings.ToLower(request.JobType),
@@ -3161,6 +3331,8 @@ func (h HttpServer) CancelJob(ctx echo.Context) error {
 			Reason:   failureReason,
 		})
 	}
+	audit.SetBefore(ctx, before)
+	audit.SetAfter(ctx, after)
 
 	return ctx.JSON(http.StatusOK, results)
 }
@@ -3219,6 +3391,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 			}
 
 			for _, j := range jobs {
+				if rbac.IsScoped(ctx) {
+					continue
+				}
 				items = append(items, api.ListJobsByTypeItem{
 					JobId:     strconv.Itoa(int(j.ID)),
 					JobType:   strings.ToLower(request.JobType),
@@ -3236,6 +3411,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 			}
 			for _, j := range jobs {
+				if !hasComplianceJobAccess(ctx, j) {
+					continue
+				}
 				items = append(items, api.ListJobsByTypeItem{
 					JobId:     strconv.Itoa(int(j.ID)),
 					JobType:   strings.ToLower(request.JobType),
@@ -3250,6 +3428,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 			}
 			for _, j := range jobs {
+				if !rbac.HasIntegrationAccess(ctx, j.IntegrationID) {
+					continue
+				}
 				items = append(items, api.ListJobsByTypeItem{
 					JobId:     strconv.Itoa(int(j.ID)),
 					JobType:   strings.ToLower(request.JobType),
@@ -3305,6 +3486,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 			}
 			for _, j := range jobs {
+				if !hasComplianceJobAccess(ctx, j) {
+					continue
+				}
 				items = append(items, api.ListJobsByTypeItem{
 					JobId:     strconv.Itoa(int(j.ID)),
 					JobType:   strings.ToLower(request.JobType),
@@ -3319,6 +3503,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 			}
 			for _, j := range jobs {
+				if !rbac.HasIntegrationAccess(ctx, j.IntegrationID) {
+					continue
+				}
 				items = append(items, api.ListJobsByTypeItem{
 					JobId:     strconv.Itoa(int(j.ID)),
 					JobType:   strings.ToLower(request.JobType),
@@ -3341,6 +3528,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 					return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 				}
 				for _, j := range jobs {
+					if !hasComplianceJobAccess(ctx, j) {
+						continue
+					}
 					items = append(items, api.ListJobsByTypeItem{
 						JobId:     strconv.Itoa(int(j.ID)),
 						JobType:   strings.ToLower(request.JobType),
@@ -3355,6 +3545,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 					return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 				}
 				for _, j := range jobs {
+					if !rbac.HasIntegrationAccess(ctx, j.IntegrationID) {
+						continue
+					}
 					items = append(items, api.ListJobsByTypeItem{
 						JobId:     strconv.Itoa(int(j.ID)),
 						JobType:   strings.ToLower(request.JobType),
@@ -3375,6 +3568,9 @@ func (h HttpServer) ListJobsByType(ctx echo.Context) error {
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 		for _, j := range jobs {
+			if !hasComplianceJobAccess(ctx, j) {
+				continue
+			}
 			items = append(items, api.ListJobsByTypeItem{
 				JobId:     strconv.Itoa(int(j.ID)),
 				JobType:   strings.ToLower(request.JobType),
@@ -3838,10 +4034,12 @@ func (h HttpServer) ListComplianceJobsHistory(ctx echo.Context) error {
 	interval := ctx.QueryParam("interval")
 	triggerType := ctx.QueryParam("trigger_type")
 	createdBy := ctx.QueryParam("created_by")
-	benchmarkIDs := httpserver.QueryArrayParam(ctx, "benchmark_ids")
 
 	var cursor, perPage int64
-	var err error
+	benchmarkIDs, err := rbac.ResolveFrameworkIDs(ctx, httpserver.QueryArrayParam(ctx, "benchmark_ids"))
+	if err != nil {
+		return err
+	}
 	cursorStr := ctx.QueryParam("cursor")
 	if cursorStr != "" {
 		cursor, err = strconv.ParseInt(cursorStr, 10, 64)
@@ -3871,6 +4069,9 @@ func (h HttpServer) ListComplianceJobsHistory(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	for _, j := range jobs {
+		if !hasComplianceJobAccess(ctx, model2.ComplianceJob{FrameworkIds: j.FrameworkIds, IntegrationIDs: j.ConnectionIDs}) {
+			continue
+		}
 		var integrations []api.IntegrationInfo
 		for _, c := range j.ConnectionIDs {
 			connectionIdsMap[c] = true
@@ -3989,11 +4190,12 @@ func parseTimeInterval(intervalStr string) (*time.Time, *time.Time, error) {
 // CreateComplianceQuickSequence godoc
 //
 //	@Summary		Create Compliance Quick Sequence
-//	@Description	Create Compliance Quick Sequence
+//	@Description	Create Compliance Quick Sequence, an optional gate defines the pass/fail verdict of the scan for CI/CD pipelines
 //	@Security		BearerToken
 //	@Tags			workspace
 //	@Accept			json
 //	@Produce		json
+//	@Param			request	body	api.CreateAuditJobRequest	true	"Quick sequence request"
 //	@Success		200
 //	@Router			/schedule/api/v3/compliance/quick/sequence [post]
 func (h HttpServer) CreateComplianceQuickSequence(c echo.Context) error {
@@ -4006,15 +4208,47 @@ func (h HttpServer) CreateComplianceQuickSequence(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
 	}
 
+	if request.Gate != nil {
+		for severity, maxFailures := range request.Gate.MaxFailures {
+			if severity.Level() == 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid severity %s in gate", severity))
+			}
+			if maxFailures < 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, "gate max failures should not be negative")
+			}
+		}
+		if request.Gate.BaselineRunID != nil {
+			baselineID, err := strconv.ParseUint(*request.Gate.BaselineRunID, 10, 64)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid gate baseline run id")
+			}
+			baseline, err := h.DB.GetQuickScanSequenceByID(uint(baselineID))
+			if err != nil {
+				h.Scheduler.logger.Error("failed to get baseline quick scan sequence", zap.Error(err))
+				return echo.NewHTTPError(http.StatusInternalServerError, "failed to get baseline quick scan sequence")
+			}
+			if baseline == nil || baseline.ID == 0 {
+				return echo.NewHTTPError(http.StatusNotFound, "gate baseline run not found")
+			}
+			if baseline.FrameworkID != request.FrameworkID {
+				return echo.NewHTTPError(http.StatusBadRequest, "gate baseline run is for another framework")
+			}
+		}
+	}
+
 	userID := httpserver.GetUserID(c)
 
-	jobId, err := h.DB.CreateQuickScanSequence(&model2.QuickScanSequence{
+	sequence := model2.QuickScanSequence{
 		FrameworkID:    request.FrameworkID,
 		IntegrationIDs: request.IntegrationIDs,
 		IncludeResults: request.IncludeResults,
 		Status:         model2.QuickScanSequenceCreated,
 		CreatedBy:      userID,
-	})
+	}
+	if err := sequence.SetGate(request.Gate); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid gate")
+	}
+	jobId, err := h.DB.CreateQuickScanSequence(&sequence)
 	if err != nil {
 		h.Scheduler.logger.Error("failed to create quick scan sequence", zap.Error(err))
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create quick scan sequence")
@@ -4139,3 +4373,849 @@ func (h HttpServer) PurgeSampleData(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+// ListSchedules godoc
+//
+//	@Summary		List discovery and compliance schedules
+//	@Description	List the cron schedules overriding the global discovery and compliance intervals
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Produce		json
+//	@Param			job_type	query		string	false	"Job type"	Enums(discovery, compliance)
+//	@Param			target_type	query		string	false	"Target type"	Enums(integration, integration_group, framework)
+//	@Param			target_id	query		string	false	"Target ID"
+//	@Success		200			{object}	api.ListSchedulesResponse
+//	@Router			/schedule/api/v3/schedules [get]
+func (h HttpServer) ListSchedules(ctx echo.Context) error {
+	var jobType *api.ScheduleJobType
+	var targetType *api.ScheduleTargetType
+	var targetID *string
+	if v := ctx.QueryParam("job_type"); v != "" {
+		jobType = utils.GetPointer(api.ScheduleJobType(v))
+	}
+	if v := ctx.QueryParam("target_type"); v != "" {
+		targetType = utils.GetPointer(api.ScheduleTargetType(v))
+	}
+	if v := ctx.QueryParam("target_id"); v != "" {
+		targetID = &v
+	}
+
+	schedules, err := h.DB.ListSchedules(jobType, targetType, targetID)
+	if err != nil {
+		h.Scheduler.logger.Error("failed to list schedules", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list schedules")
+	}
+
+	items := make([]api.Schedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		items = append(items, schedule.ToAPI())
+	}
+
+	return ctx.JSON(http.StatusOK, api.ListSchedulesResponse{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
+
+// GetSchedule godoc
+//
+//	@Summary		Get a discovery or compliance schedule
+//	@Description	Get a discovery or compliance schedule
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Produce		json
+//	@Param			schedule_id	path		string	true	"Schedule ID"
+//	@Success		200			{object}	api.Schedule
+//	@Router			/schedule/api/v3/schedules/{schedule_id} [get]
+func (h HttpServer) GetSchedule(ctx echo.Context) error {
+	schedule, err := h.getScheduleFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, schedule.ToAPI())
+}
+
+// CreateSchedule godoc
+//
+//	@Summary		Create a discovery or compliance schedule
+//	@Description	Create a cron schedule for an integration, an integration group or a framework.
+//	@Description	The most specific schedule wins: integration, then integration group, then framework.
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateScheduleRequest	true	"Create schedule request"
+//	@Success		201		{object}	api.Schedule
+//	@Router			/schedule/api/v3/schedules [post]
+func (h HttpServer) CreateSchedule(ctx echo.Context) error {
+	var request api.CreateScheduleRequest
+	if err := ctx.Bind(&request); err != nil {
+		ctx.Logger().Errorf("bind the request: %v", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	switch request.JobType {
+	case api.ScheduleJobTypeDiscovery:
+		if request.TargetType == api.ScheduleTargetTypeFramework {
+			return echo.NewHTTPError(http.StatusBadRequest, "discovery schedules can only target integrations or integration groups")
+		}
+	case api.ScheduleJobTypeCompliance:
+		if !h.Scheduler.complianceEnabled {
+			return echo.NewHTTPError(http.StatusBadRequest, "compliance service is not enabled")
+		}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid job type")
+	}
+	if request.TargetID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "target id is required")
+	}
+	if err := h.validateScheduleTarget(ctx, request.TargetType, request.TargetID); err != nil {
+		return err
+	}
+
+	schedule := model2.Schedule{
+		Name:           request.Name,
+		JobType:        request.JobType,
+		TargetType:     request.TargetType,
+		TargetID:       request.TargetID,
+		CronExpression: request.CronExpression,
+		Enabled:        true,
+		CreatedBy:      httpserver.GetUserID(ctx),
+	}
+	if request.Enabled != nil {
+		schedule.Enabled = *request.Enabled
+	}
+	if _, err := schedule.CronSchedule(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid cron expression: %v", err))
+	}
+
+	existing, err := h.DB.ListSchedules(&schedule.JobType, &schedule.TargetType, &schedule.TargetID)
+	if err != nil {
+		h.Scheduler.logger.Error("failed to list schedules", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list schedules")
+	}
+	if len(existing) > 0 {
+		return echo.NewHTTPError(http.StatusConflict, "a schedule already exists for this target")
+	}
+
+	if err := h.DB.CreateSchedule(&schedule); err != nil {
+		h.Scheduler.logger.Error("failed to create schedule", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create schedule")
+	}
+
+	return ctx.JSON(http.StatusCreated, schedule.ToAPI())
+}
+
+// UpdateSchedule godoc
+//
+//	@Summary		Update a discovery or compliance schedule
+//	@Description	Update the name, cron expression or enabled state of a schedule
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Accept			json
+//	@Produce		json
+//	@Param			schedule_id	path		string						true	"Schedule ID"
+//	@Param			request		body		api.UpdateScheduleRequest	true	"Update schedule request"
+//	@Success		200			{object}	api.Schedule
+//	@Router			/schedule/api/v3/schedules/{schedule_id} [put]
+func (h HttpServer) UpdateSchedule(ctx echo.Context) error {
+	var request api.UpdateScheduleRequest
+	if err := ctx.Bind(&request); err != nil {
+		ctx.Logger().Errorf("bind the request: %v", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	schedule, err := h.getScheduleFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	if request.Name != nil {
+		schedule.Name = *request.Name
+	}
+	if request.CronExpression != nil {
+		schedule.CronExpression = *request.CronExpression
+		if _, err := schedule.CronSchedule(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid cron expression: %v", err))
+		}
+	}
+	if request.Enabled != nil {
+		schedule.Enabled = *request.Enabled
+	}
+	schedule.UpdatedAt = time.Now()
+
+	if err := h.DB.UpdateSchedule(schedule); err != nil {
+		h.Scheduler.logger.Error("failed to update schedule", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update schedule")
+	}
+
+	return ctx.JSON(http.StatusOK, schedule.ToAPI())
+}
+
+// DeleteSchedule godoc
+//
+//	@Summary		Delete a discovery or compliance schedule
+//	@Description	Delete a schedule, its target falls back to the global interval
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Param			schedule_id	path	string	true	"Schedule ID"
+//	@Success		200
+//	@Router			/schedule/api/v3/schedules/{schedule_id} [delete]
+func (h HttpServer) DeleteSchedule(ctx echo.Context) error {
+	schedule, err := h.getScheduleFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.DB.DeleteSchedule(schedule.ID); err != nil {
+		h.Scheduler.logger.Error("failed to delete schedule", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete schedule")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h HttpServer) getScheduleFromParam(ctx echo.Context) (*model2.Schedule, error) {
+	scheduleID, err := strconv.ParseUint(ctx.Param("schedule_id"), 10, 64)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid schedule id")
+	}
+
+	schedule, err := h.DB.GetSchedule(uint(scheduleID))
+	if err != nil {
+		h.Scheduler.logger.Error("failed to get schedule", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get schedule")
+	}
+	if schedule == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "schedule not found")
+	}
+	return schedule, nil
+}
+
+func (h HttpServer) validateScheduleTarget(ctx echo.Context, targetType api.ScheduleTargetType, targetID string) error {
+	clientCtx := &httpclient.Context{UserRole: apiAuth.AdminRole, Ctx: ctx.Request().Context()}
+	switch targetType {
+	case api.ScheduleTargetTypeIntegration:
+		integration, err := h.Scheduler.integrationClient.GetIntegration(clientCtx, targetID)
+		if err != nil || integration == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "integration not found")
+		}
+	case api.ScheduleTargetTypeIntegrationGroup:
+		group, err := h.Scheduler.integrationClient.GetIntegrationGroup(clientCtx, targetID)
+		if err != nil || group == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "integration group not found")
+		}
+	case api.ScheduleTargetTypeFramework:
+		framework, err := h.Scheduler.complianceClient.GetBenchmark(clientCtx, targetID)
+		if err != nil || framework == nil || framework.ID == "" {
+			return echo.NewHTTPError(http.StatusNotFound, "framework not found")
+		}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid target type")
+	}
+	return nil
+}
+
+// ListNotificationDestinations godoc
+//
+//	@Summary		List notification destinations
+//	@Description	List the webhook, slack and email destinations compliance drift notifications are sent to
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Produce		json
+//	@Success		200	{object}	api.ListNotificationDestinationsResponse
+//	@Router			/schedule/api/v3/notifications/destinations [get]
+func (h HttpServer) ListNotificationDestinations(ctx echo.Context) error {
+	destinations, err := h.DB.ListNotificationDestinations()
+	if err != nil {
+		h.Scheduler.logger.Error("failed to list notification destinations", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list notification destinations")
+	}
+
+	items := make([]api.NotificationDestination, 0, len(destinations))
+	for _, destination := range destinations {
+		items = append(items, destination.ToAPI())
+	}
+
+	return ctx.JSON(http.StatusOK, api.ListNotificationDestinationsResponse{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
+
+// GetNotificationDestination godoc
+//
+//	@Summary		Get a notification destination
+//	@Description	Get a notification destination, its url, header values and signing secret are never returned
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Produce		json
+//	@Param			destination_id	path		string	true	"Destination ID"
+//	@Success		200				{object}	api.NotificationDestination
+//	@Router			/schedule/api/v3/notifications/destinations/{destination_id} [get]
+func (h HttpServer) GetNotificationDestination(ctx echo.Context) error {
+	destination, err := h.getNotificationDestinationFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, destination.ToAPI())
+}
+
+// CreateNotificationDestination godoc
+//
+//	@Summary		Create a notification destination
+//	@Description	Create a generic webhook (signed with HMAC-SHA256 when a secret is set), a slack compatible webhook or an email destination
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateNotificationDestinationRequest	true	"Create notification destination request"
+//	@Success		201		{object}	api.NotificationDestination
+//	@Router			/schedule/api/v3/notifications/destinations [post]
+func (h HttpServer) CreateNotificationDestination(ctx echo.Context) error {
+	var request api.CreateNotificationDestinationRequest
+	if err := ctx.Bind(&request); err != nil {
+		ctx.Logger().Errorf("bind the request: %v", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	if request.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	}
+	if err := validateNotificationDestinationConfig(request.Type, request.Config); err != nil {
+		return err
+	}
+
+	destination := model2.NotificationDestination{
+		Name:      request.Name,
+		Type:      request.Type,
+		Enabled:   true,
+		CreatedBy: httpserver.GetUserID(ctx),
+	}
+	if request.Enabled != nil {
+		destination.Enabled = *request.Enabled
+	}
+	if err := destination.SetConfig(request.Config); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid config")
+	}
+
+	if err := h.DB.CreateNotificationDestination(&destination); err != nil {
+		h.Scheduler.logger.Error("failed to create notification destination", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create notification destination")
+	}
+
+	return ctx.JSON(http.StatusCreated, destination.ToAPI())
+}
+
+// UpdateNotificationDestination godoc
+//
+//	@Summary		Update a notification destination
+//	@Description	Update the name, config or enabled state of a destination. An empty url or secret and missing headers keep the current ones.
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Accept			json
+//	@Produce		json
+//	@Param			destination_id	path		string										true	"Destination ID"
+//	@Param			request			body		api.UpdateNotificationDestinationRequest	true	"Update notification destination request"
+//	@Success		200				{object}	api.NotificationDestination
+//	@Router			/schedule/api/v3/notifications/destinations/{destination_id} [put]
+func (h HttpServer) UpdateNotificationDestination(ctx echo.Context) error {
+	var request api.UpdateNotificationDestinationRequest
+	if err := ctx.Bind(&request); err != nil {
+		ctx.Logger().Errorf("bind the request: %v", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	destination, err := h.getNotificationDestinationFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	if request.Name != nil {
+		destination.Name = *request.Name
+	}
+	if request.Config != nil {
+		config, err := destination.MergeConfig(*request.Config)
+		if err != nil {
+			h.Scheduler.logger.Error("failed to read notification destination config", zap.Error(err))
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to read notification destination config")
+		}
+		if err := validateNotificationDestinationConfig(destination.Type, config); err != nil {
+			return err
+		}
+		if err := destination.SetConfig(config); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid config")
+		}
+	}
+	if request.Enabled != nil {
+		destination.Enabled = *request.Enabled
+	}
+	destination.UpdatedAt = time.Now()
+
+	if err := h.DB.UpdateNotificationDestination(destination); err != nil {
+		h.Scheduler.logger.Error("failed to update notification destination", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update notification destination")
+	}
+
+	return ctx.JSON(http.StatusOK, destination.ToAPI())
+}
+
+// DeleteNotificationDestination godoc
+//
+//	@Summary		Delete a notification destination
+//	@Description	Delete a notification destination, fails while rules still use it
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Param			destination_id	path	string	true	"Destination ID"
+//	@Success		200
+//	@Router			/schedule/api/v3/notifications/destinations/{destination_id} [delete]
+func (h HttpServer) DeleteNotificationDestination(ctx echo.Context) error {
+	destination, err := h.getNotificationDestinationFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules, err := h.DB.ListNotificationRules(&destination.ID)
+	if err != nil {
+		h.Scheduler.logger.Error("failed to list notification rules", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list notification rules")
+	}
+	if len(rules) > 0 {
+		return echo.NewHTTPError(http.StatusConflict, "destination is used by notification rules")
+	}
+
+	if err := h.DB.DeleteNotificationDestination(destination.ID); err != nil {
+		h.Scheduler.logger.Error("failed to delete notification destination", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete notification destination")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// ListNotificationRules godoc
+//
+//	@Summary		List notification rules
+//	@Description	List the rules routing compliance drift events to notification destinations
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Produce		json
+//	@Param			destination_id	query		string	false	"Destination ID"
+//	@Success		200				{object}	api.ListNotificationRulesResponse
+//	@Router			/schedule/api/v3/notifications/rules [get]
+func (h HttpServer) ListNotificationRules(ctx echo.Context) error {
+	var destinationID *uint
+	if v := ctx.QueryParam("destination_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid destination id")
+		}
+		destinationID = utils.GetPointer(uint(id))
+	}
+
+	rules, err := h.DB.ListNotificationRules(destinationID)
+	if err != nil {
+		h.Scheduler.logger.Error("failed to list notification rules", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list notification rules")
+	}
+
+	items := make([]api.NotificationRule, 0, len(rules))
+	for _, rule := range rules {
+		items = append(items, rule.ToAPI())
+	}
+
+	return ctx.JSON(http.StatusOK, api.ListNotificationRulesResponse{
+		Items:      items,
+		TotalCount: len(items),
+	})
+}
+
+// GetNotificationRule godoc
+//
+//	@Summary		Get a notification rule
+//	@Description	Get a notification rule
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Produce		json
+//	@Param			rule_id	path		string	true	"Rule ID"
+//	@Success		200		{object}	api.NotificationRule
+//	@Router			/schedule/api/v3/notifications/rules/{rule_id} [get]
+func (h HttpServer) GetNotificationRule(ctx echo.Context) error {
+	rule, err := h.getNotificationRuleFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, rule.ToAPI())
+}
+
+// CreateNotificationRule godoc
+//
+//	@Summary		Create a notification rule
+//	@Description	Create a rule sending the drift events of finished compliance jobs to a destination.
+//	@Description	Events are filtered by framework, control, severity, integration and compliance status, an empty filter matches everything.
+//	@Description	The first evaluation of a compliance result has no previous state and is only sent when include_initial_state is set.
+//	@Description	Rules subscribed to integration.pending also receive the integrations left pending by credential onboarding policies,
+//	@Description	a rule without event types only receives compliance drift.
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.CreateNotificationRuleRequest	true	"Create notification rule request"
+//	@Success		201		{object}	api.NotificationRule
+//	@Router			/schedule/api/v3/notifications/rules [post]
+func (h HttpServer) CreateNotificationRule(ctx echo.Context) error {
+	var request api.CreateNotificationRuleRequest
+	if err := ctx.Bind(&request); err != nil {
+		ctx.Logger().Errorf("bind the request: %v", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	if request.Name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	}
+	if err := h.validateNotificationDestinationID(request.DestinationID); err != nil {
+		return err
+	}
+
+	var err error
+	rule := model2.NotificationRule{
+		Name:           request.Name,
+		DestinationID:  request.DestinationID,
+		FrameworkIDs:   request.FrameworkIDs,
+		ControlIDs:     request.ControlIDs,
+		IntegrationIDs: request.IntegrationIDs,
+		Enabled:        true,
+		CreatedBy:      httpserver.GetUserID(ctx),
+	}
+	if rule.EventTypes, err = parseNotificationRuleEventTypes(request.EventTypes); err != nil {
+		return err
+	}
+	if rule.Severities, err = parseNotificationRuleSeverities(request.Severities); err != nil {
+		return err
+	}
+	for _, status := range request.ComplianceStatuses {
+		rule.ComplianceStatuses = append(rule.ComplianceStatuses, string(status))
+	}
+	rule.IncludeInitialState = request.IncludeInitialState
+	if request.Enabled != nil {
+		rule.Enabled = *request.Enabled
+	}
+
+	if err := h.DB.CreateNotificationRule(&rule); err != nil {
+		h.Scheduler.logger.Error("failed to create notification rule", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create notification rule")
+	}
+
+	return ctx.JSON(http.StatusCreated, rule.ToAPI())
+}
+
+// UpdateNotificationRule godoc
+//
+//	@Summary		Update a notification rule
+//	@Description	Update a notification rule, filters that are not set are left unchanged
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Accept			json
+//	@Produce		json
+//	@Param			rule_id	path		string								true	"Rule ID"
+//	@Param			request	body		api.UpdateNotificationRuleRequest	true	"Update notification rule request"
+//	@Success		200		{object}	api.NotificationRule
+//	@Router			/schedule/api/v3/notifications/rules/{rule_id} [put]
+func (h HttpServer) UpdateNotificationRule(ctx echo.Context) error {
+	var request api.UpdateNotificationRuleRequest
+	if err := ctx.Bind(&request); err != nil {
+		ctx.Logger().Errorf("bind the request: %v", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+
+	rule, err := h.getNotificationRuleFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	if request.Name != nil {
+		rule.Name = *request.Name
+	}
+	if request.DestinationID != nil {
+		if err := h.validateNotificationDestinationID(*request.DestinationID); err != nil {
+			return err
+		}
+		rule.DestinationID = *request.DestinationID
+	}
+	if request.EventTypes != nil {
+		if rule.EventTypes, err = parseNotificationRuleEventTypes(request.EventTypes); err != nil {
+			return err
+		}
+	}
+	if request.FrameworkIDs != nil {
+		rule.FrameworkIDs = request.FrameworkIDs
+	}
+	if request.ControlIDs != nil {
+		rule.ControlIDs = request.ControlIDs
+	}
+	if request.IntegrationIDs != nil {
+		rule.IntegrationIDs = request.IntegrationIDs
+	}
+	if request.Severities != nil {
+		if rule.Severities, err = parseNotificationRuleSeverities(request.Severities); err != nil {
+			return err
+		}
+	}
+	if request.ComplianceStatuses != nil {
+		rule.ComplianceStatuses = pq.StringArray{}
+		for _, status := range request.ComplianceStatuses {
+			rule.ComplianceStatuses = append(rule.ComplianceStatuses, string(status))
+		}
+	}
+	if request.IncludeInitialState != nil {
+		rule.IncludeInitialState = *request.IncludeInitialState
+	}
+	if request.Enabled != nil {
+		rule.Enabled = *request.Enabled
+	}
+	rule.UpdatedAt = time.Now()
+
+	if err := h.DB.UpdateNotificationRule(rule); err != nil {
+		h.Scheduler.logger.Error("failed to update notification rule", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update notification rule")
+	}
+
+	return ctx.JSON(http.StatusOK, rule.ToAPI())
+}
+
+// DeleteNotificationRule godoc
+//
+//	@Summary		Delete a notification rule
+//	@Description	Delete a notification rule, its pending deliveries are still sent
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Param			rule_id	path	string	true	"Rule ID"
+//	@Success		200
+//	@Router			/schedule/api/v3/notifications/rules/{rule_id} [delete]
+func (h HttpServer) DeleteNotificationRule(ctx echo.Context) error {
+	rule, err := h.getNotificationRuleFromParam(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.DB.DeleteNotificationRule(rule.ID); err != nil {
+		h.Scheduler.logger.Error("failed to delete notification rule", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to delete notification rule")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// ListNotificationDeliveries godoc
+//
+//	@Summary		List notification deliveries
+//	@Description	List the delivery log of compliance drift notifications, newest first
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Produce		json
+//	@Param			rule_id				query		string	false	"Rule ID"
+//	@Param			destination_id		query		string	false	"Destination ID"
+//	@Param			compliance_job_id	query		string	false	"Compliance job ID"
+//	@Param			status				query		string	false	"Delivery status"	Enums(PENDING,SUCCEEDED,FAILED)
+//	@Param			cursor				query		int		false	"Cursor"
+//	@Param			per_page			query		int		false	"Per page"
+//	@Success		200					{object}	api.ListNotificationDeliveriesResponse
+//	@Router			/schedule/api/v3/notifications/deliveries [get]
+func (h HttpServer) ListNotificationDeliveries(ctx echo.Context) error {
+	var ruleID, destinationID, complianceJobID *uint
+	for param, target := range map[string]**uint{
+		"rule_id":           &ruleID,
+		"destination_id":    &destinationID,
+		"compliance_job_id": &complianceJobID,
+	} {
+		v := ctx.QueryParam(param)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s", param))
+		}
+		*target = utils.GetPointer(uint(id))
+	}
+	var status *api.NotificationDeliveryStatus
+	if v := ctx.QueryParam("status"); v != "" {
+		status = utils.GetPointer(api.NotificationDeliveryStatus(strings.ToUpper(v)))
+	}
+
+	var cursor, perPage int64 = 1, 20
+	var err error
+	if cursorStr := ctx.QueryParam("cursor"); cursorStr != "" {
+		cursor, err = strconv.ParseInt(cursorStr, 10, 64)
+		if err != nil || cursor < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
+	}
+	if perPageStr := ctx.QueryParam("per_page"); perPageStr != "" {
+		perPage, err = strconv.ParseInt(perPageStr, 10, 64)
+		if err != nil || perPage < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid per_page")
+		}
+	}
+
+	deliveries, count, err := h.DB.ListNotificationDeliveries(ruleID, destinationID, complianceJobID, status,
+		int(perPage), int((cursor-1)*perPage))
+	if err != nil {
+		h.Scheduler.logger.Error("failed to list notification deliveries", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to list notification deliveries")
+	}
+
+	items := make([]api.NotificationDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, delivery.ToAPI())
+	}
+
+	return ctx.JSON(http.StatusOK, api.ListNotificationDeliveriesResponse{
+		Items:      items,
+		TotalCount: count,
+	})
+}
+
+// NotifyPendingIntegrations godoc
+//
+//	@Summary		Notify pending integrations
+//	@Description	Send the integrations a credential discovered but its onboarding policy did not add to the rules subscribed to integration.pending,
+//	@Description	called by the integration service
+//	@Security		BearerToken
+//	@Tags			scheduler
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		api.NotifyPendingIntegrationsRequest	true	"Pending integrations"
+//	@Success		200		{object}	api.NotifyPendingIntegrationsResponse
+//	@Router			/schedule/api/v3/notifications/events/pending-integrations [post]
+func (h HttpServer) NotifyPendingIntegrations(ctx echo.Context) error {
+	var request api.NotifyPendingIntegrationsRequest
+	if err := ctx.Bind(&request); err != nil {
+		ctx.Logger().Errorf("bind the request: %v", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+	}
+	if request.CredentialID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "credential id is required")
+	}
+
+	if h.Scheduler.notificationScheduler == nil {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "notifications are not running")
+	}
+	count, err := h.Scheduler.notificationScheduler.DispatchPendingIntegrations(request)
+	if err != nil {
+		h.Scheduler.logger.Error("failed to dispatch pending integrations notifications", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to dispatch notifications")
+	}
+
+	return ctx.JSON(http.StatusOK, api.NotifyPendingIntegrationsResponse{DeliveryCount: count})
+}
+
+func (h HttpServer) getNotificationDestinationFromParam(ctx echo.Context) (*model2.NotificationDestination, error) {
+	destinationID, err := strconv.ParseUint(ctx.Param("destination_id"), 10, 64)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid destination id")
+	}
+
+	destination, err := h.DB.GetNotificationDestination(uint(destinationID))
+	if err != nil {
+		h.Scheduler.logger.Error("failed to get notification destination", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get notification destination")
+	}
+	if destination == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "notification destination not found")
+	}
+	return destination, nil
+}
+
+func (h HttpServer) getNotificationRuleFromParam(ctx echo.Context) (*model2.NotificationRule, error) {
+	ruleID, err := strconv.ParseUint(ctx.Param("rule_id"), 10, 64)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid rule id")
+	}
+
+	rule, err := h.DB.GetNotificationRule(uint(ruleID))
+	if err != nil {
+		h.Scheduler.logger.Error("failed to get notification rule", zap.Error(err))
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "failed to get notification rule")
+	}
+	if rule == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "notification rule not found")
+	}
+	return rule, nil
+}
+
+func (h HttpServer) validateNotificationDestinationID(destinationID uint) error {
+	destination, err := h.DB.GetNotificationDestination(destinationID)
+	if err != nil {
+		h.Scheduler.logger.Error("failed to get notification destination", zap.Error(err))
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get notification destination")
+	}
+	if destination == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "notification destination not found")
+	}
+	return nil
+}
+
+func validateNotificationDestinationConfig(destinationType api.NotificationDestinationType, config api.NotificationDestinationConfig) error {
+	switch destinationType {
+	case api.NotificationDestinationTypeWebhook, api.NotificationDestinationTypeSlack:
+		u, err := url.Parse(config.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "a valid http(s) url is required")
+		}
+	case api.NotificationDestinationTypeEmail:
+		if len(config.Recipients) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "at least one recipient is required")
+		}
+		for _, recipient := range config.Recipients {
+			if _, err := mail.ParseAddress(recipient); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid recipient %s", recipient))
+			}
+		}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid destination type")
+	}
+	return nil
+}
+
+func parseNotificationRuleEventTypes(eventTypes []api.NotificationEventType) (pq.StringArray, error) {
+	result := pq.StringArray{}
+	for _, eventType := range eventTypes {
+		switch eventType {
+		case api.NotificationEventTypeComplianceDrift, api.NotificationEventTypePendingIntegrations:
+		default:
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid event type %s", eventType))
+		}
+		result = append(result, string(eventType))
+	}
+	return result, nil
+}
+
+func parseNotificationRuleSeverities(severities []types.ComplianceResultSeverity) (pq.StringArray, error) {
+	result := pq.StringArray{}
+	for _, severity := range severities {
+		parsed := types.ParseComplianceResultSeverity(string(severity))
+		if parsed == "" {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid severity %s", severity))
+		}
+		result = append(result, string(parsed))
+	}
+	return result, nil
+}
+
+// hasComplianceJobAccess reports whether the job frameworks and integrations are all within the caller role bindings
+func hasComplianceJobAccess(ctx echo.Context, job model2.ComplianceJob) bool {
+	for _, frameworkID := range job.FrameworkIds {
+		if !rbac.HasFrameworkAccess(ctx, frameworkID) {
+			return false
+		}
+	}
+	for _, integrationID := range job.IntegrationIDs {
+		if !rbac.HasIntegrationAccess(ctx, integrationID) {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	api2 "github.com/opengovern/og-util/pkg/api"
 	"github.com/opengovern/og-util/pkg/httpserver"
+	"github.com/opengovern/opensecurity/pkg/audit"
 	"github.com/opengovern/opensecurity/pkg/utils"
 	"github.com/opengovern/opensecurity/services/tasks/api"
 	"github.com/opengovern/opensecurity/services/tasks/db"
@@ -24,6 +25,8 @@ type httpRoutes struct {
 }
 
 func (r *httpRoutes) Register(e *echo.Echo) {
+	e.Use(audit.Middleware("tasks", audit.NewSinkFromEnv(r.logger)))
+
 	v1 := e.Group("/api/v1")
 	// List all tasks
 	v1.GET("/tasks", httpserver.AuthorizeHandler(r.ListTasks, api2.ViewerRole))